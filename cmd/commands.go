@@ -0,0 +1,272 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/backup"
+	"github.com/rhinosc/code-review-1/internal/loader"
+	"github.com/rhinosc/code-review-1/internal/repository"
+	"github.com/rhinosc/code-review-1/internal/service"
+)
+
+// defaultFilePath is the data file used by the commands when none is given
+const defaultFilePath = "docs/db/vehicles_100.json"
+
+const (
+	// envKeyFile is the environment variable with the path to the keyring of encrypted data files
+	envKeyFile = "VEHICLES_KEYFILE"
+	// envKeys is the environment variable with the keyring of encrypted data files, used when there is no keyfile
+	envKeys = "VEHICLES_KEYS"
+	// envNotifyWebhook is the environment variable with the URL compliance reminders are posted to
+	envNotifyWebhook = "VEHICLES_NOTIFY_WEBHOOK"
+	// envSMTPAddr is the environment variable with the host:port of the mail server compliance reminders are mailed through
+	envSMTPAddr = "VEHICLES_SMTP_ADDR"
+	// envSMTPFrom is the environment variable with the address compliance reminders are mailed from
+	envSMTPFrom = "VEHICLES_SMTP_FROM"
+	// envSMTPTo is the environment variable with the comma separated addresses compliance reminders are mailed to
+	envSMTPTo = "VEHICLES_SMTP_TO"
+	// envSMTPUsername is the environment variable with the username of the mail server
+	envSMTPUsername = "VEHICLES_SMTP_USERNAME"
+	// envSMTPPassword is the environment variable with the password of the mail server
+	envSMTPPassword = "VEHICLES_SMTP_PASSWORD"
+	// envOutboxFile is the environment variable with the path to the file events are appended to as JSON lines
+	envOutboxFile = "VEHICLES_OUTBOX_FILE"
+	// envOutboxStdout is the environment variable that writes events to the standard output when true
+	envOutboxStdout = "VEHICLES_OUTBOX_STDOUT"
+	// envOutboxWebhook is the environment variable with the URL every event is posted to
+	envOutboxWebhook = "VEHICLES_OUTBOX_WEBHOOK"
+	// envAdminToken is the environment variable with the bearer token of the admin and webhook routes, they are disabled without it
+	envAdminToken = "VEHICLES_ADMIN_TOKEN"
+	// envWebhookAllowedHosts is the environment variable with the comma separated hosts webhooks may target whatever their address
+	envWebhookAllowedHosts = "VEHICLES_WEBHOOK_ALLOWED_HOSTS"
+	// envReloadInterval is the environment variable with the time between checks of the vehicles file for changes, as 30s, unset to not watch it
+	envReloadInterval = "VEHICLES_RELOAD_INTERVAL"
+	// envLoaderOnInvalid is the environment variable with the behaviour when the vehicles file contains invalid vehicles: fail, skip or quarantine
+	envLoaderOnInvalid = "VEHICLES_ON_INVALID"
+	// envIDGenerator is the environment variable with the generator of new vehicles: sequential, uuidv7 or ulid
+	envIDGenerator = "VEHICLES_ID_GENERATOR"
+)
+
+// envKeyring is a function that returns the keyring given in the environment, nil when there is none
+func envKeyring() (kr *loader.Keyring, err error) {
+	kr, err = loader.LoadKeyring(os.Getenv(envKeyFile), os.Getenv(envKeys))
+	return
+}
+
+// commands is the set of commands that can be run instead of the server
+var commands = map[string]func(args []string) error{
+	"normalize": normalize,
+	"migrate":   migrate,
+	"keygen":    keygen,
+	"rekey":     rekey,
+	"backup":    backups,
+}
+
+// runCommand is a function that runs the command with the given name
+func runCommand(name string, args []string) (err error) {
+	cmd, ok := commands[name]
+	if !ok {
+		err = fmt.Errorf("unknown command %q", name)
+		return
+	}
+	err = cmd(args)
+	return
+}
+
+// migrate is a command that rewrites a data file in the current schema version
+func migrate(args []string) (err error) {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	in := fs.String("in", defaultFilePath, "path to the vehicles file to migrate")
+	out := fs.String("out", "", "path to write the migrated file, the input file by default")
+	list := fs.Bool("list", false, "list the registered migrations and exit")
+	if err = fs.Parse(args); err != nil {
+		return
+	}
+
+	if *list {
+		for _, m := range loader.Migrations() {
+			fmt.Printf("%d -> %d: %s\n", m.From, m.From+1, m.Description)
+		}
+		return
+	}
+
+	if *out == "" {
+		*out = *in
+	}
+	kr, err := envKeyring()
+	if err != nil {
+		return
+	}
+	from, err := loader.MigrateFile(*in, *out, kr)
+	if err != nil {
+		return
+	}
+	fmt.Printf("migrated %s from schema version %d to %d\n", *out, from, loader.CurrentSchemaVersion)
+	return
+}
+
+// normalize is a command that canonicalises the attributes of every vehicle in a data file
+func normalize(args []string) (err error) {
+	fs := flag.NewFlagSet("normalize", flag.ContinueOnError)
+	path := fs.String("file", defaultFilePath, "path to the vehicles file")
+	dryRun := fs.Bool("dry-run", false, "report the changes without saving them")
+	if err = fs.Parse(args); err != nil {
+		return
+	}
+
+	kr, err := envKeyring()
+	if err != nil {
+		return
+	}
+	ld := loader.NewVehicleJSONFileWithConfig(&loader.ConfigVehicleJSONFile{Path: *path, Keyring: kr})
+	db, err := ld.Load()
+	if err != nil {
+		return
+	}
+
+	nz := service.NewVehicleNormalizer(nil)
+	var changed int
+	for id, vh := range db {
+		if nz.Vehicle(&vh) {
+			db[id] = vh
+			changed++
+		}
+	}
+	fmt.Printf("%d of %d vehicles normalized\n", changed, len(db))
+
+	if *dryRun || changed == 0 {
+		return
+	}
+	err = ld.Save(db)
+	return
+}
+
+// keygen is a command that prints a new keyring entry, to be added first to the keyring to rotate keys
+func keygen(args []string) (err error) {
+	fs := flag.NewFlagSet("keygen", flag.ContinueOnError)
+	id := fs.String("id", "key-"+time.Now().UTC().Format("20060102"), "id of the key")
+	if err = fs.Parse(args); err != nil {
+		return
+	}
+
+	key, err := loader.GenerateKey()
+	if err != nil {
+		return
+	}
+	fmt.Printf("%s:%s\n", *id, key)
+	return
+}
+
+// rekey is a command that saves a data file encrypted with the current key of the keyring, or decrypted.
+// A plaintext file is only encrypted with -from-plaintext, as loading with a keyring refuses plaintext files
+func rekey(args []string) (err error) {
+	fs := flag.NewFlagSet("rekey", flag.ContinueOnError)
+	path := fs.String("file", defaultFilePath, "path to the vehicles file")
+	decrypt := fs.Bool("decrypt", false, "save the file in plaintext")
+	fromPlaintext := fs.Bool("from-plaintext", false, "the file is in plaintext, encrypt it for the first time")
+	if err = fs.Parse(args); err != nil {
+		return
+	}
+
+	kr, err := envKeyring()
+	if err != nil {
+		return
+	}
+	if kr == nil {
+		err = errors.New("no keyring, set " + envKeyFile + " or " + envKeys)
+		return
+	}
+	src := &loader.ConfigVehicleJSONFile{Path: *path, Keyring: kr}
+	if *fromPlaintext {
+		src.Keyring = nil
+	}
+	db, err := loader.NewVehicleJSONFileWithConfig(src).Load()
+	if err != nil {
+		return
+	}
+
+	cfg := &loader.ConfigVehicleJSONFile{Path: *path, Keyring: kr}
+	if *decrypt {
+		cfg.Keyring = nil
+	}
+	if err = loader.NewVehicleJSONFileWithConfig(cfg).Save(db); err != nil {
+		return
+	}
+	if *decrypt {
+		fmt.Printf("%d vehicles saved in plaintext to %s\n", len(db), *path)
+		return
+	}
+	fmt.Printf("%d vehicles saved to %s encrypted with key %q\n", len(db), *path, kr.Current())
+	return
+}
+
+// backups is a command that creates, lists or restores the backups of a data file: backup create|list|restore
+func backups(args []string) (err error) {
+	if len(args) == 0 {
+		err = errors.New("usage: backup create|list|restore [flags]")
+		return
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("backup "+action, flag.ContinueOnError)
+	path := fs.String("file", defaultFilePath, "path to the vehicles file")
+	dir := fs.String("dir", "", "directory of the backups, the backups directory next to the vehicles file by default")
+	id := fs.String("id", "", "id of the backup to restore")
+	if err = fs.Parse(args[1:]); err != nil {
+		return
+	}
+	if *dir == "" {
+		*dir = filepath.Join(filepath.Dir(*path), "backups")
+	}
+
+	kr, err := envKeyring()
+	if err != nil {
+		return
+	}
+	newLoader := func(path string) internal.VehicleLoader {
+		return loader.NewVehicleJSONFileWithConfig(&loader.ConfigVehicleJSONFile{Path: path, Keyring: kr})
+	}
+	ld := newLoader(*path)
+
+	switch action {
+	case "create":
+		var db map[int]internal.Vehicle
+		if db, err = ld.Load(); err != nil {
+			return
+		}
+		bk := backup.NewManager(*dir, repository.NewVehicleMap(ld, db, repository.NewVehicleIDSequential()), newLoader)
+		var b backup.Backup
+		if b, err = bk.Create(); err != nil {
+			return
+		}
+		fmt.Printf("backup %s created with %d vehicles (sha256 %s)\n", b.ID, b.Vehicles, b.Checksum)
+	case "list":
+		var b []backup.Backup
+		if b, err = backup.NewManager(*dir, nil, newLoader).List(); err != nil {
+			return
+		}
+		for _, value := range b {
+			fmt.Printf("%s\t%s\t%d vehicles\t%d bytes\t%s\n", value.ID, value.CreatedAt.Format(time.RFC3339), value.Vehicles, value.Size, value.Checksum)
+		}
+	case "restore":
+		if *id == "" {
+			err = errors.New("the id of the backup is required")
+			return
+		}
+		// - the current vehicles are replaced, the file does not need to be readable
+		bk := backup.NewManager(*dir, repository.NewVehicleMap(ld, nil, repository.NewVehicleIDSequential()), newLoader)
+		var b backup.Backup
+		if b, err = bk.Restore(*id); err != nil {
+			return
+		}
+		fmt.Printf("backup %s restored to %s with %d vehicles\n", b.ID, *path, b.Vehicles)
+	default:
+		err = fmt.Errorf("unknown backup action %q, expected create, list or restore", action)
+	}
+	return
+}
@@ -2,19 +2,70 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/rhinosc/code-review-1/internal/application"
 )
 
 func main() {
+	// commands
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// env
-	// ...
+	keyFile, keys := os.Getenv(envKeyFile), os.Getenv(envKeys)
+	var smtpTo []string
+	for _, to := range strings.Split(os.Getenv(envSMTPTo), ",") {
+		if to = strings.TrimSpace(to); to != "" {
+			smtpTo = append(smtpTo, to)
+		}
+	}
+	outboxStdout, _ := strconv.ParseBool(os.Getenv(envOutboxStdout))
+	var webhookHosts []string
+	for _, host := range strings.Split(os.Getenv(envWebhookAllowedHosts), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			webhookHosts = append(webhookHosts, host)
+		}
+	}
+
+	var reloadInterval time.Duration
+	if v := os.Getenv(envReloadInterval); v != "" {
+		var err error
+		if reloadInterval, err = time.ParseDuration(v); err != nil {
+			fmt.Printf("invalid %s: %v\n", envReloadInterval, err)
+			os.Exit(1)
+		}
+	}
 
 	// app
 	// - config
 	cfg := &application.ConfigServerChi{
-		ServerAddress:  ":8080",
-		LoaderFilePath: "docs/db/vehicles_100.json",
+		ServerAddress:       ":8080",
+		LoaderFilePath:      defaultFilePath,
+		EncryptionKeyFile:   keyFile,
+		EncryptionKeys:      keys,
+		NotifyWebhookURL:    os.Getenv(envNotifyWebhook),
+		SMTPAddr:            os.Getenv(envSMTPAddr),
+		SMTPFrom:            os.Getenv(envSMTPFrom),
+		SMTPTo:              smtpTo,
+		SMTPUsername:        os.Getenv(envSMTPUsername),
+		SMTPPassword:        os.Getenv(envSMTPPassword),
+		OutboxFilePath:      os.Getenv(envOutboxFile),
+		OutboxStdout:        outboxStdout,
+		OutboxWebhookURL:    os.Getenv(envOutboxWebhook),
+		AdminToken:          os.Getenv(envAdminToken),
+		WebhookAllowedHosts: webhookHosts,
+		LoaderOnInvalid:     os.Getenv(envLoaderOnInvalid),
+		IDGenerator:         os.Getenv(envIDGenerator),
+		ReloadInterval:      reloadInterval,
 	}
 	app := application.NewServerChi(cfg)
 	// - run
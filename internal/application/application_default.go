@@ -1,14 +1,30 @@
 package application
 
 import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"log"
 	"net/http"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/backup"
+	"github.com/rhinosc/code-review-1/internal/blob"
+	"github.com/rhinosc/code-review-1/internal/graphql"
 	"github.com/rhinosc/code-review-1/internal/handler"
 	"github.com/rhinosc/code-review-1/internal/loader"
+	"github.com/rhinosc/code-review-1/internal/notify"
+	"github.com/rhinosc/code-review-1/internal/outbox"
+	"github.com/rhinosc/code-review-1/internal/reload"
 	"github.com/rhinosc/code-review-1/internal/repository"
+	"github.com/rhinosc/code-review-1/internal/scheduler"
 	"github.com/rhinosc/code-review-1/internal/service"
+	"github.com/rhinosc/code-review-1/internal/webhook"
 )
 
 // ConfigServerChi is a struct that represents the configuration for ServerChi
@@ -17,13 +33,109 @@ type ConfigServerChi struct {
 	ServerAddress string
 	// LoaderFilePath is the path to the file that contains the vehicles
 	LoaderFilePath string
+	// LoaderOnInvalid is the behaviour when the file contains invalid vehicles: fail, skip or quarantine
+	LoaderOnInvalid string
+	// LoaderRejectsPath is the path to the file where quarantined vehicles are written
+	LoaderRejectsPath string
+	// IdempotencyTTL is the time a response is kept for replays of the same Idempotency-Key
+	IdempotencyTTL time.Duration
+	// IDGenerator is the generator of new vehicles: sequential ids only, or sequential ids and uuidv7 or ulid unique identifiers
+	IDGenerator string
+	// ReloadInterval is the time between checks of the vehicles file for changes, zero disables watching
+	ReloadInterval time.Duration
+	// EncryptionKeyFile is the path to the keyring of the vehicles file, the file is encrypted with its first key when given
+	EncryptionKeyFile string
+	// EncryptionKeys is the keyring of the vehicles file as id:key entries, used when there is no EncryptionKeyFile
+	EncryptionKeys string
+	// MaintenanceFilePath is the path to the file of the maintenance records and rules, maintenance.json next to the vehicles file by default
+	MaintenanceFilePath string
+	// ReservationFilePath is the path to the file of the reservations, reservations.json next to the vehicles file by default
+	ReservationFilePath string
+	// DriverFilePath is the path to the file of the drivers and their assignments, drivers.json next to the vehicles file by default
+	DriverFilePath string
+	// FuelLogFilePath is the path to the file of the fuel logs, fuel.json next to the vehicles file by default
+	FuelLogFilePath string
+	// AttachmentFilePath is the path to the file of the attachments, attachments.json next to the vehicles file by default
+	AttachmentFilePath string
+	// AttachmentDir is the directory of the contents of the attachments, the attachments directory next to the vehicles file by default
+	AttachmentDir string
+	// AttachmentMaxSize is the greatest size in bytes of an attached file, 10 MiB by default
+	AttachmentMaxSize int64
+	// AttachmentTypes is the list of the media types of the files allowed as attachments, documents and photos by default
+	AttachmentTypes []string
+	// ComplianceFilePath is the path to the file of the compliance items, compliance.json next to the vehicles file by default
+	ComplianceFilePath string
+	// ReminderWindows is the list of the windows, in days before an expiry, compliance reminders are sent; 30, 7 and 1 by default
+	ReminderWindows []int
+	// ReminderAt is the local time of the day compliance reminders are sent, as the time since midnight; 08:00 by default
+	ReminderAt time.Duration
+	// ReminderDisabled disables the daily compliance reminders
+	ReminderDisabled bool
+	// NotifyWebhookURL is the URL compliance reminders are posted to, empty to not post them
+	NotifyWebhookURL string
+	// SMTPAddr is the host:port of the mail server compliance reminders are mailed through, empty to not mail them
+	SMTPAddr string
+	// SMTPFrom is the address compliance reminders are mailed from
+	SMTPFrom string
+	// SMTPTo is the list of the addresses compliance reminders are mailed to
+	SMTPTo []string
+	// SMTPUsername is the username of the mail server, empty to mail without authenticating
+	SMTPUsername string
+	// SMTPPassword is the password of the mail server
+	SMTPPassword string
+	// WebhookFilePath is the path to the file of the webhooks, webhooks.json next to the vehicles file by default
+	WebhookFilePath string
+	// WebhookMaxAttempts is the number of attempts to deliver an event to a webhook before it is dead-lettered, 6 by default
+	WebhookMaxAttempts int
+	// WebhookBackoff is the wait before the second attempt to deliver an event, doubled before each next one; 1 second by default
+	WebhookBackoff time.Duration
+	// WebhookAllowedHosts is the list of the hosts webhooks may target whatever their address; other hosts must resolve to
+	// public addresses
+	WebhookAllowedHosts []string
+	// EventReplaySize is the number of the latest events kept for streams resuming from a previous one, 1024 by default
+	EventReplaySize int
+	// EventHeartbeat is the time between keep-alive comments of an idle event stream, 15 seconds by default
+	EventHeartbeat time.Duration
+	// OutboxInterval is the time between checks of the outbox for events left to relay, 1 second by default
+	OutboxInterval time.Duration
+	// OutboxFilePath is the path to a file the events are appended to as JSON lines, empty to not write them
+	OutboxFilePath string
+	// OutboxStdout writes the events to the standard output as JSON lines
+	OutboxStdout bool
+	// OutboxWebhookURL is the URL every event is posted to, empty to not post them
+	OutboxWebhookURL string
+	// GraphQLMaxDepth is the deepest nesting of fields of a GraphQL operation, 10 by default
+	GraphQLMaxDepth int
+	// GraphQLMaxComplexity is the highest complexity of a GraphQL operation, 1000 by default
+	GraphQLMaxComplexity int
+	// BackupDir is the directory of the backups, the backups directory next to the vehicles file by default
+	BackupDir string
+	// AdminToken is the bearer token of the /admin and /webhooks routes, empty to disable them
+	AdminToken string
+	// CompressionDisabled disables the compression of responses
+	CompressionDisabled bool
+	// CompressionMinSize is the size in bytes from which responses are compressed
+	CompressionMinSize int
+	// CompressionLevel is the gzip compression level of responses, from 1 (fastest) to 9 (smallest)
+	CompressionLevel int
 }
 
 // NewServerChi is a function that returns a new instance of ServerChi
 func NewServerChi(cfg *ConfigServerChi) *ServerChi {
 	// default values
 	defaultConfig := &ConfigServerChi{
-		ServerAddress: ":8080",
+		ServerAddress:        ":8080",
+		LoaderOnInvalid:      string(loader.InvalidFail),
+		IdempotencyTTL:       24 * time.Hour,
+		IDGenerator:          IDGeneratorSequential,
+		CompressionMinSize:   1024,
+		CompressionLevel:     gzip.DefaultCompression,
+		AttachmentMaxSize:    10 << 20,
+		AttachmentTypes:      service.DefaultAttachmentTypes,
+		ReminderWindows:      service.DefaultReminderWindows,
+		ReminderAt:           8 * time.Hour,
+		GraphQLMaxDepth:      10,
+		GraphQLMaxComplexity: 1000,
 	}
 	if cfg != nil {
 		if cfg.ServerAddress != "" {
@@ -32,11 +144,147 @@ func NewServerChi(cfg *ConfigServerChi) *ServerChi {
 		if cfg.LoaderFilePath != "" {
 			defaultConfig.LoaderFilePath = cfg.LoaderFilePath
 		}
+		if cfg.LoaderOnInvalid != "" {
+			defaultConfig.LoaderOnInvalid = cfg.LoaderOnInvalid
+		}
+		if cfg.LoaderRejectsPath != "" {
+			defaultConfig.LoaderRejectsPath = cfg.LoaderRejectsPath
+		}
+		if cfg.IdempotencyTTL > 0 {
+			defaultConfig.IdempotencyTTL = cfg.IdempotencyTTL
+		}
+		if cfg.IDGenerator != "" {
+			defaultConfig.IDGenerator = cfg.IDGenerator
+		}
+		if cfg.ReloadInterval > 0 {
+			defaultConfig.ReloadInterval = cfg.ReloadInterval
+		}
+		defaultConfig.EncryptionKeyFile = cfg.EncryptionKeyFile
+		defaultConfig.EncryptionKeys = cfg.EncryptionKeys
+		defaultConfig.MaintenanceFilePath = cfg.MaintenanceFilePath
+		defaultConfig.ReservationFilePath = cfg.ReservationFilePath
+		defaultConfig.DriverFilePath = cfg.DriverFilePath
+		defaultConfig.FuelLogFilePath = cfg.FuelLogFilePath
+		defaultConfig.AttachmentFilePath = cfg.AttachmentFilePath
+		defaultConfig.AttachmentDir = cfg.AttachmentDir
+		if cfg.AttachmentMaxSize > 0 {
+			defaultConfig.AttachmentMaxSize = cfg.AttachmentMaxSize
+		}
+		if len(cfg.AttachmentTypes) > 0 {
+			defaultConfig.AttachmentTypes = cfg.AttachmentTypes
+		}
+		defaultConfig.ComplianceFilePath = cfg.ComplianceFilePath
+		if len(cfg.ReminderWindows) > 0 {
+			defaultConfig.ReminderWindows = cfg.ReminderWindows
+		}
+		if cfg.ReminderAt > 0 {
+			defaultConfig.ReminderAt = cfg.ReminderAt
+		}
+		defaultConfig.ReminderDisabled = cfg.ReminderDisabled
+		defaultConfig.NotifyWebhookURL = cfg.NotifyWebhookURL
+		defaultConfig.SMTPAddr = cfg.SMTPAddr
+		defaultConfig.SMTPFrom = cfg.SMTPFrom
+		defaultConfig.SMTPTo = cfg.SMTPTo
+		defaultConfig.SMTPUsername = cfg.SMTPUsername
+		defaultConfig.SMTPPassword = cfg.SMTPPassword
+		defaultConfig.WebhookFilePath = cfg.WebhookFilePath
+		defaultConfig.WebhookMaxAttempts = cfg.WebhookMaxAttempts
+		defaultConfig.WebhookBackoff = cfg.WebhookBackoff
+		defaultConfig.WebhookAllowedHosts = cfg.WebhookAllowedHosts
+		defaultConfig.EventReplaySize = cfg.EventReplaySize
+		defaultConfig.EventHeartbeat = cfg.EventHeartbeat
+		defaultConfig.OutboxInterval = cfg.OutboxInterval
+		defaultConfig.OutboxFilePath = cfg.OutboxFilePath
+		defaultConfig.OutboxStdout = cfg.OutboxStdout
+		defaultConfig.OutboxWebhookURL = cfg.OutboxWebhookURL
+		if cfg.GraphQLMaxDepth > 0 {
+			defaultConfig.GraphQLMaxDepth = cfg.GraphQLMaxDepth
+		}
+		if cfg.GraphQLMaxComplexity > 0 {
+			defaultConfig.GraphQLMaxComplexity = cfg.GraphQLMaxComplexity
+		}
+		defaultConfig.BackupDir = cfg.BackupDir
+		defaultConfig.AdminToken = cfg.AdminToken
+		defaultConfig.CompressionDisabled = cfg.CompressionDisabled
+		if cfg.CompressionMinSize > 0 {
+			defaultConfig.CompressionMinSize = cfg.CompressionMinSize
+		}
+		if cfg.CompressionLevel != 0 {
+			defaultConfig.CompressionLevel = cfg.CompressionLevel
+		}
+	}
+	if defaultConfig.MaintenanceFilePath == "" {
+		defaultConfig.MaintenanceFilePath = filepath.Join(filepath.Dir(defaultConfig.LoaderFilePath), "maintenance.json")
+	}
+	if defaultConfig.ReservationFilePath == "" {
+		defaultConfig.ReservationFilePath = filepath.Join(filepath.Dir(defaultConfig.LoaderFilePath), "reservations.json")
+	}
+	if defaultConfig.DriverFilePath == "" {
+		defaultConfig.DriverFilePath = filepath.Join(filepath.Dir(defaultConfig.LoaderFilePath), "drivers.json")
+	}
+	if defaultConfig.FuelLogFilePath == "" {
+		defaultConfig.FuelLogFilePath = filepath.Join(filepath.Dir(defaultConfig.LoaderFilePath), "fuel.json")
+	}
+	if defaultConfig.AttachmentFilePath == "" {
+		defaultConfig.AttachmentFilePath = filepath.Join(filepath.Dir(defaultConfig.LoaderFilePath), "attachments.json")
+	}
+	if defaultConfig.AttachmentDir == "" {
+		defaultConfig.AttachmentDir = filepath.Join(filepath.Dir(defaultConfig.LoaderFilePath), "attachments")
+	}
+	if defaultConfig.ComplianceFilePath == "" {
+		defaultConfig.ComplianceFilePath = filepath.Join(filepath.Dir(defaultConfig.LoaderFilePath), "compliance.json")
+	}
+	if defaultConfig.WebhookFilePath == "" {
+		defaultConfig.WebhookFilePath = filepath.Join(filepath.Dir(defaultConfig.LoaderFilePath), "webhooks.json")
+	}
+	if defaultConfig.BackupDir == "" {
+		defaultConfig.BackupDir = filepath.Join(filepath.Dir(defaultConfig.LoaderFilePath), "backups")
 	}
 
 	return &ServerChi{
-		serverAddress:  defaultConfig.ServerAddress,
-		loaderFilePath: defaultConfig.LoaderFilePath,
+		serverAddress:       defaultConfig.ServerAddress,
+		loaderFilePath:      defaultConfig.LoaderFilePath,
+		loaderOnInvalid:     defaultConfig.LoaderOnInvalid,
+		loaderRejectsPath:   defaultConfig.LoaderRejectsPath,
+		idempotencyTTL:      defaultConfig.IdempotencyTTL,
+		idGenerator:         defaultConfig.IDGenerator,
+		reloadInterval:      defaultConfig.ReloadInterval,
+		encryptionKeyFile:   defaultConfig.EncryptionKeyFile,
+		encryptionKeys:      defaultConfig.EncryptionKeys,
+		maintenanceFilePath: defaultConfig.MaintenanceFilePath,
+		reservationFilePath: defaultConfig.ReservationFilePath,
+		driverFilePath:      defaultConfig.DriverFilePath,
+		fuelLogFilePath:     defaultConfig.FuelLogFilePath,
+		attachmentFilePath:  defaultConfig.AttachmentFilePath,
+		attachmentDir:       defaultConfig.AttachmentDir,
+		attachmentMaxSize:   defaultConfig.AttachmentMaxSize,
+		attachmentTypes:     defaultConfig.AttachmentTypes,
+		complianceFilePath:  defaultConfig.ComplianceFilePath,
+		reminderWindows:     defaultConfig.ReminderWindows,
+		reminderAt:          defaultConfig.ReminderAt,
+		reminderDisabled:    defaultConfig.ReminderDisabled,
+		notifyWebhookURL:    defaultConfig.NotifyWebhookURL,
+		smtpAddr:            defaultConfig.SMTPAddr,
+		smtpFrom:            defaultConfig.SMTPFrom,
+		smtpTo:              defaultConfig.SMTPTo,
+		smtpUsername:        defaultConfig.SMTPUsername,
+		smtpPassword:        defaultConfig.SMTPPassword,
+		webhookFilePath:     defaultConfig.WebhookFilePath,
+		webhookMaxAttempts:  defaultConfig.WebhookMaxAttempts,
+		webhookBackoff:      defaultConfig.WebhookBackoff,
+		webhookAllowedHosts: defaultConfig.WebhookAllowedHosts,
+		eventReplaySize:     defaultConfig.EventReplaySize,
+		eventHeartbeat:      defaultConfig.EventHeartbeat,
+		outboxInterval:      defaultConfig.OutboxInterval,
+		outboxFilePath:      defaultConfig.OutboxFilePath,
+		outboxStdout:        defaultConfig.OutboxStdout,
+		outboxWebhookURL:    defaultConfig.OutboxWebhookURL,
+		graphQLLimits:       graphql.Limits{MaxDepth: defaultConfig.GraphQLMaxDepth, MaxComplexity: defaultConfig.GraphQLMaxComplexity},
+		backupDir:           defaultConfig.BackupDir,
+		adminToken:          defaultConfig.AdminToken,
+		compressionDisabled: defaultConfig.CompressionDisabled,
+		compressionMinSize:  defaultConfig.CompressionMinSize,
+		compressionLevel:    defaultConfig.CompressionLevel,
 	}
 }
 
@@ -46,47 +294,625 @@ type ServerChi struct {
 	serverAddress string
 	// loaderFilePath is the path to the file that contains the vehicles
 	loaderFilePath string
+	// loaderOnInvalid is the behaviour when the file contains invalid vehicles
+	loaderOnInvalid string
+	// loaderRejectsPath is the path to the file where quarantined vehicles are written
+	loaderRejectsPath string
+	// idempotencyTTL is the time a response is kept for replays of the same Idempotency-Key
+	idempotencyTTL time.Duration
+	// idGenerator is the generator of the ids of new vehicles
+	idGenerator string
+	// reloadInterval is the time between checks of the vehicles file for changes, zero disables watching
+	reloadInterval time.Duration
+	// encryptionKeyFile is the path to the keyring of the vehicles file
+	encryptionKeyFile string
+	// encryptionKeys is the keyring of the vehicles file, used when there is no keyfile
+	encryptionKeys string
+	// maintenanceFilePath is the path to the file of the maintenance records and rules
+	maintenanceFilePath string
+	// reservationFilePath is the path to the file of the reservations
+	reservationFilePath string
+	// driverFilePath is the path to the file of the drivers and their assignments
+	driverFilePath string
+	// fuelLogFilePath is the path to the file of the fuel logs
+	fuelLogFilePath string
+	// attachmentFilePath is the path to the file of the attachments
+	attachmentFilePath string
+	// attachmentDir is the directory of the contents of the attachments
+	attachmentDir string
+	// attachmentMaxSize is the greatest size in bytes of an attached file
+	attachmentMaxSize int64
+	// attachmentTypes is the list of the media types of the files allowed as attachments
+	attachmentTypes []string
+	// complianceFilePath is the path to the file of the compliance items
+	complianceFilePath string
+	// reminderWindows is the list of the windows, in days before an expiry, compliance reminders are sent
+	reminderWindows []int
+	// reminderAt is the local time of the day compliance reminders are sent
+	reminderAt time.Duration
+	// reminderDisabled disables the daily compliance reminders
+	reminderDisabled bool
+	// notifyWebhookURL is the URL compliance reminders are posted to
+	notifyWebhookURL string
+	// smtpAddr is the host:port of the mail server compliance reminders are mailed through
+	smtpAddr string
+	// smtpFrom is the address compliance reminders are mailed from
+	smtpFrom string
+	// smtpTo is the list of the addresses compliance reminders are mailed to
+	smtpTo []string
+	// smtpUsername is the username of the mail server
+	smtpUsername string
+	// smtpPassword is the password of the mail server
+	smtpPassword string
+	// webhookFilePath is the path to the file of the webhooks
+	webhookFilePath string
+	// webhookMaxAttempts is the number of attempts to deliver an event to a webhook, the dispatcher's default when zero
+	webhookMaxAttempts int
+	// webhookBackoff is the wait before the second attempt to deliver an event, the dispatcher's default when zero
+	webhookBackoff time.Duration
+	// webhookAllowedHosts is the list of the hosts webhooks may target whatever their address
+	webhookAllowedHosts []string
+	// eventReplaySize is the number of the latest events kept for replay, the bus's default when zero
+	eventReplaySize int
+	// eventHeartbeat is the time between keep-alive comments of an idle event stream, the handler's default when zero
+	eventHeartbeat time.Duration
+	// outboxInterval is the time between checks of the outbox, the relay's default when zero
+	outboxInterval time.Duration
+	// outboxFilePath is the path to the file the events are appended to, empty to not write them
+	outboxFilePath string
+	// outboxStdout writes the events to the standard output
+	outboxStdout bool
+	// outboxWebhookURL is the URL every event is posted to, empty to not post them
+	outboxWebhookURL string
+	// graphQLLimits is the limits of the GraphQL operations
+	graphQLLimits graphql.Limits
+	// backupDir is the directory of the backups
+	backupDir string
+	// adminToken is the bearer token of the /admin and /webhooks routes, empty when they are disabled
+	adminToken string
+	// compressionDisabled disables the compression of responses
+	compressionDisabled bool
+	// compressionMinSize is the size in bytes from which responses are compressed
+	compressionMinSize int
+	// compressionLevel is the gzip compression level of responses
+	compressionLevel int
 }
 
 // Run is a method that runs the application
 func (a *ServerChi) Run() (err error) {
 	// dependencies
 	// - loader
-	ld := loader.NewVehicleJSONFile(a.loaderFilePath)
+	kr, err := loader.LoadKeyring(a.encryptionKeyFile, a.encryptionKeys)
+	if err != nil {
+		return
+	}
+	switch loader.InvalidPolicy(a.loaderOnInvalid) {
+	case loader.InvalidFail, loader.InvalidSkip, loader.InvalidQuarantine:
+	default:
+		err = fmt.Errorf("unknown invalid vehicles policy %q", a.loaderOnInvalid)
+		return
+	}
+	ld := loader.NewVehicleJSONFileWithConfig(&loader.ConfigVehicleJSONFile{
+		Path:        a.loaderFilePath,
+		OnInvalid:   loader.InvalidPolicy(a.loaderOnInvalid),
+		RejectsPath: a.loaderRejectsPath,
+		Keyring:     kr,
+		Progress: func(p loader.LoadProgress) {
+			log.Printf("loading vehicles: %d records read (%d/%d bytes), %d accepted, %d rejected", p.Records, p.BytesRead, p.BytesTotal, p.Accepted, p.Rejected)
+		},
+	})
 	db, err := ld.Load()
 	if err != nil {
 		return
 	}
+	logLoadReport(ld.Report())
+	if kr != nil && ld.Report().KeyID != kr.Current() {
+		log.Printf("vehicles file is not encrypted with the current key %q, it is re-encrypted on the next save", kr.Current())
+	}
 	// - repository
-	rp := repository.NewVehicleMap(ld, db, len(db))
+	gen, uid, err := newVehicleIDGenerator(a.idGenerator)
+	if err != nil {
+		return
+	}
+	wt := reload.NewWatcher(ld, a.loaderFilePath, a.reloadInterval)
+	if err = wt.Init(); err != nil {
+		return
+	}
+	rp := repository.NewVehicleMap(wt.Loader(), db, gen)
+	if uid != nil {
+		rp.SetUIDGenerator(uid)
+	}
+	wt.SetRepository(rp)
+	if a.reloadInterval > 0 {
+		go wt.Watch(context.Background())
+	}
+	// - maintenance
+	mld := loader.NewMaintenanceJSONFile(&loader.ConfigMaintenanceJSONFile{Path: a.maintenanceFilePath, Keyring: kr})
+	md, err := mld.Load()
+	if err != nil {
+		return
+	}
+	mrp := repository.NewMaintenanceMap(mld, md)
+	// - reservations
+	rld := loader.NewReservationJSONFile(&loader.ConfigReservationJSONFile{Path: a.reservationFilePath, Keyring: kr})
+	rd, err := rld.Load()
+	if err != nil {
+		return
+	}
+	rrp := repository.NewReservationMap(rld, rd)
+	// - drivers
+	dld := loader.NewDriverJSONFile(&loader.ConfigDriverJSONFile{Path: a.driverFilePath, Keyring: kr})
+	dd, err := dld.Load()
+	if err != nil {
+		return
+	}
+	drp := repository.NewDriverMap(dld, dd)
+	// - fuel logs
+	fld := loader.NewFuelLogJSONFile(&loader.ConfigFuelLogJSONFile{Path: a.fuelLogFilePath, Keyring: kr})
+	fd, err := fld.Load()
+	if err != nil {
+		return
+	}
+	frp := repository.NewFuelLogMap(fld, fd)
+	// - attachments
+	st, err := blob.NewStore(a.attachmentDir)
+	if err != nil {
+		return
+	}
+	ald := loader.NewAttachmentJSONFile(&loader.ConfigAttachmentJSONFile{Path: a.attachmentFilePath, Keyring: kr})
+	atd, err := ald.Load()
+	if err != nil {
+		return
+	}
+	arp := repository.NewAttachmentMap(ald, atd)
+	// - compliance
+	cld := loader.NewComplianceJSONFile(&loader.ConfigComplianceJSONFile{Path: a.complianceFilePath, Keyring: kr})
+	cd, err := cld.Load()
+	if err != nil {
+		return
+	}
+	crp := repository.NewComplianceMap(cld, cd)
+	// - webhooks
+	wld := loader.NewWebhookJSONFile(&loader.ConfigWebhookJSONFile{Path: a.webhookFilePath, Keyring: kr})
+	wd, err := wld.Load()
+	if err != nil {
+		return
+	}
+	wrp := repository.NewWebhookMap(wld, wd)
+	dp := webhook.NewDispatcher(wrp, &webhook.Config{MaxAttempts: a.webhookMaxAttempts, Backoff: a.webhookBackoff, AllowedHosts: a.webhookAllowedHosts})
+	dp.Start(context.Background())
+	// - events are saved to the outbox with the changes, then relayed to the bus, which streams them to subscribers
+	// and forwards them to the webhooks, and to the other sinks
+	bus := service.NewEventBus(&service.ConfigEventBus{ReplaySize: a.eventReplaySize})
+	bus.Forward(dp)
+	sinks, err := a.outboxSinks(bus)
+	if err != nil {
+		return
+	}
+	rl := outbox.NewRelay(rp, sinks, &outbox.Config{Interval: a.outboxInterval})
+	rp.OnOutbox(rl.Wake)
+	go rl.Run(context.Background())
+	// - notifiers
+	nt, err := a.notifier()
+	if err != nil {
+		return
+	}
+	// - backups share the format and the encryption of the vehicles file
+	bk := backup.NewManager(a.backupDir, rp, func(path string) internal.VehicleLoader {
+		return loader.NewVehicleJSONFileWithConfig(&loader.ConfigVehicleJSONFile{Path: path, Keyring: kr})
+	})
 	// - service
-	sv := service.NewVehicleDefault(rp)
+	sv := service.NewVehicleDefault(rp, service.NewVehicleNormalizer(nil))
+	msv := service.NewMaintenanceDefault(mrp, rp, frp)
+	rsv := service.NewReservationDefault(rrp, rp, mrp)
+	dsv := service.NewDriverDefault(drp, rp)
+	fsv := service.NewFuelLogDefault(frp, rp)
+	asv := service.NewAttachmentDefault(arp, rp, st, &service.ConfigAttachmentDefault{MaxSize: a.attachmentMaxSize, Types: a.attachmentTypes})
+	csv := service.NewComplianceDefault(crp, rp, nt, a.reminderWindows)
+	wsv := service.NewWebhookDefault(wrp, dp)
+	// - the attachments of a deleted vehicle are deleted with it, and those left by a previous run on startup
+	sv.OnDelete(func(v internal.Vehicle) {
+		if err := asv.DeleteByVehicle(v.Id); err != nil {
+			log.Printf("deleting attachments of vehicle %d: %v", v.Id, err)
+		}
+		if err := csv.DeleteByVehicle(v.Id); err != nil {
+			log.Printf("deleting compliance items of vehicle %d: %v", v.Id, err)
+		}
+	})
+	if n, m, err := asv.Cleanup(); err != nil {
+		log.Printf("cleaning up attachments: %v", err)
+	} else if n > 0 || m > 0 {
+		log.Printf("cleaned up %d orphaned attachments and %d unreferenced files", n, m)
+	}
 	// - handler
 	hd := handler.NewVehicleDefault(sv)
+	ad := handler.NewAdminDefault(wt, bk)
+	aa := handler.NewAdminAuth(a.adminToken)
+	if a.adminToken == "" {
+		log.Printf("no admin token is configured, the /admin and /webhooks routes are disabled")
+	}
+	mhd := handler.NewMaintenanceDefault(msv)
+	rhd := handler.NewReservationDefault(rsv)
+	dhd := handler.NewDriverDefault(dsv)
+	fhd := handler.NewFuelLogDefault(fsv)
+	athd := handler.NewAttachmentDefault(asv, a.attachmentMaxSize)
+	chd := handler.NewComplianceDefault(csv)
+	whd := handler.NewWebhookDefault(wsv)
+	ehd := handler.NewVehicleEventsDefault(bus, a.eventHeartbeat)
+	gs, err := graphql.NewVehicleSchema(sv)
+	if err != nil {
+		return
+	}
+	ghd := handler.NewGraphQLDefault(gs, a.graphQLLimits)
+	spec := handler.OpenAPI()
+	ohd := handler.NewOpenAPIDefault(spec)
+	// - idempotency
+	idm := handler.NewIdempotency(repository.NewIdempotencyMap(), a.idempotencyTTL)
+	// - compression
+	cp, err := handler.NewCompress(a.compressionMinSize, a.compressionLevel)
+	if err != nil {
+		return
+	}
 	// router
 	rt := chi.NewRouter()
 	// - middlewares
 	rt.Use(middleware.Logger)
 	rt.Use(middleware.Recoverer)
+	if !a.compressionDisabled {
+		rt.Use(cp.Handler)
+	}
+	rt.Use(handler.NewRequestValidator(spec, rt).Handler)
 	// - endpoints
+	routes(rt, handlers{
+		vehicle:     hd,
+		events:      ehd,
+		maintenance: mhd,
+		reservation: rhd,
+		driver:      dhd,
+		fuelLog:     fhd,
+		attachment:  athd,
+		compliance:  chd,
+		webhook:     whd,
+		graphQL:     ghd,
+		admin:       ad,
+		adminAuth:   aa,
+		idempotency: idm,
+		openAPI:     ohd,
+	})
+
+	// - every route must be described by the specification
+	if err = handler.CheckOpenAPI(spec, rt); err != nil {
+		return
+	}
+
+	// scheduler
+	if !a.reminderDisabled {
+		go scheduler.NewDaily("compliance reminders", a.reminderAt, nil, func(ctx context.Context, now time.Time) (err error) {
+			r, err := csv.Remind(ctx, now)
+			if len(r) > 0 {
+				log.Printf("sent %d compliance reminders", len(r))
+			}
+			return
+		}).Run(context.Background())
+	}
+
+	// run server
+	err = http.ListenAndServe(a.serverAddress, rt)
+	return
+}
+
+// handlers is a struct that represents the handlers and the middlewares the routes are served by
+type handlers struct {
+	// vehicle is the handler of the vehicles
+	vehicle *handler.VehicleDefault
+	// events is the handler of the stream of the events of the vehicles
+	events *handler.VehicleEventsDefault
+	// maintenance is the handler of the maintenance records and rules
+	maintenance *handler.MaintenanceDefault
+	// reservation is the handler of the reservations
+	reservation *handler.ReservationDefault
+	// driver is the handler of the drivers and their assignments
+	driver *handler.DriverDefault
+	// fuelLog is the handler of the fuel logs
+	fuelLog *handler.FuelLogDefault
+	// attachment is the handler of the attachments
+	attachment *handler.AttachmentDefault
+	// compliance is the handler of the compliance items
+	compliance *handler.ComplianceDefault
+	// webhook is the handler of the webhooks
+	webhook *handler.WebhookDefault
+	// graphQL is the handler of the GraphQL operations
+	graphQL *handler.GraphQLDefault
+	// admin is the handler of the administration tasks
+	admin *handler.AdminDefault
+	// adminAuth is the middleware authenticating the administration routes
+	adminAuth *handler.AdminAuth
+	// idempotency is the middleware replaying the responses of repeated requests
+	idempotency *handler.Idempotency
+	// openAPI is the handler of the specification
+	openAPI *handler.OpenAPIDefault
+}
+
+// routes is a function that registers the routes of the server, each must be described by handler.OpenAPI
+func routes(rt chi.Router, h handlers) {
 	rt.Route("/vehicles", func(rt chi.Router) {
-		// - GET /vehicles
-		rt.Get("/", hd.GetAll())
+		// - GET /vehicles?status={status}
+		rt.Get("/", h.vehicle.GetAll())
+
+		// - GET /vehicles/color/{color}/year/{year}?status={status}
+		rt.Get("/color/{color}/year/{year}", h.vehicle.GetByColorAndYear())
+
+		// - GET /vehicles/dimensions?length={min}..{max}&width={min}..{max}&height={min}..{max}&status={status}
+		rt.Get("/dimensions", h.vehicle.GetByDimensions())
+
+		// - GET /vehicles/export?status={status}
+		rt.Get("/export", h.vehicle.Export())
+
+		// - GET /vehicles/events?brand={brand}&status={status}
+		rt.Get("/events", h.events.Stream())
+
+		// - GET /vehicles/search?q={query}&limit={limit}
+		rt.Get("/search", h.vehicle.Search())
+
+		// - GET /vehicles/suggest?field={field}&prefix={prefix}&limit={limit}
+		rt.Get("/suggest", h.vehicle.Suggest())
+
+		// - GET /vehicles/maintenance/due?before={date}
+		rt.Get("/maintenance/due", h.maintenance.GetDue())
+
+		// - GET /vehicles/maintenance/rules
+		rt.Get("/maintenance/rules", h.maintenance.GetRules())
+
+		// - POST /vehicles/maintenance/rules
+		rt.Post("/maintenance/rules", h.maintenance.CreateRule())
 
-		// - GET /vehicles/color/{color}/year/{year}
-		rt.Get("/color/{color}/year/{year}", hd.GetByColorAndYear())
+		// - GET /vehicles/available?from={date}&to={date}&min_passengers={n}
+		rt.Get("/available", h.reservation.GetAvailable())
 
-		// - GET /vehicles/dimensions?length={min_length}-{max_length}&width={min_width}-{max_width}
-		rt.Get("/dimensions", hd.GetByDimensions())
+		// - GET /vehicles/compliance/expiring?within={days}
+		rt.Get("/compliance/expiring", h.compliance.GetExpiring())
+
+		// - GET /vehicles/fuel/stats?group_by={brand|model|fuel_type}
+		rt.Get("/fuel/stats", h.fuelLog.GetGroupStats())
 
 		// - GET /vehicles/average_speed/brand/{brand}
-		rt.Get("/average_speed/brand/{brand}", hd.GetAverageSpeedByBrand())
+		rt.Get("/average_speed/brand/{brand}", h.vehicle.GetAverageSpeedByBrand())
 
 		// - POST /vehicles
-		rt.Post("/", hd.Create())
+		rt.With(h.idempotency.Handler).Post("/", h.vehicle.Create())
+
+		// - GET /vehicles/{id}
+		rt.Get("/{id}", h.vehicle.GetByID())
+
+		// - PUT /vehicles/{id}
+		rt.Put("/{id}", h.vehicle.Update())
+
+		// - DELETE /vehicles/{id}
+		rt.Delete("/{id}", h.vehicle.Delete())
+
+		// - GET /vehicles/{id}/transitions
+		rt.Get("/{id}/transitions", h.vehicle.GetTransitions())
+
+		// - POST /vehicles/{id}/transitions
+		rt.With(h.idempotency.Handler).Post("/{id}/transitions", h.vehicle.Transition())
+
+		// - GET /vehicles/{id}/maintenance
+		rt.Get("/{id}/maintenance", h.maintenance.GetByVehicle())
+
+		// - POST /vehicles/{id}/maintenance
+		rt.With(h.idempotency.Handler).Post("/{id}/maintenance", h.maintenance.Create())
+
+		// - GET /vehicles/{id}/reservations
+		rt.Get("/{id}/reservations", h.reservation.GetByVehicle())
+
+		// - POST /vehicles/{id}/reservations
+		rt.With(h.idempotency.Handler).Post("/{id}/reservations", h.reservation.Create())
+
+		// - DELETE /vehicles/{id}/reservations/{reservationID}
+		rt.Delete("/{id}/reservations/{reservationID}", h.reservation.Cancel())
+
+		// - GET /vehicles/{id}/assignments
+		rt.Get("/{id}/assignments", h.driver.GetAssignmentsByVehicle())
+
+		// - PUT /vehicles/{id}/driver
+		rt.Put("/{id}/driver", h.driver.Assign())
+
+		// - DELETE /vehicles/{id}/driver
+		rt.Delete("/{id}/driver", h.driver.Unassign())
+
+		// - GET /vehicles/{id}/fuel
+		rt.Get("/{id}/fuel", h.fuelLog.GetByVehicle())
+
+		// - POST /vehicles/{id}/fuel
+		rt.With(h.idempotency.Handler).Post("/{id}/fuel", h.fuelLog.Create())
+
+		// - GET /vehicles/{id}/fuel/stats
+		rt.Get("/{id}/fuel/stats", h.fuelLog.GetStats())
+
+		// - GET /vehicles/{id}/attachments
+		rt.Get("/{id}/attachments", h.attachment.GetByVehicle())
+
+		// - POST /vehicles/{id}/attachments
+		rt.Post("/{id}/attachments", h.attachment.Upload())
+
+		// - GET /vehicles/{id}/attachments/{attachmentID}
+		rt.Get("/{id}/attachments/{attachmentID}", h.attachment.Download())
+
+		// - DELETE /vehicles/{id}/attachments/{attachmentID}
+		rt.Delete("/{id}/attachments/{attachmentID}", h.attachment.Delete())
+
+		// - GET /vehicles/{id}/compliance
+		rt.Get("/{id}/compliance", h.compliance.GetByVehicle())
+
+		// - POST /vehicles/{id}/compliance
+		rt.With(h.idempotency.Handler).Post("/{id}/compliance", h.compliance.Create())
+
+		// - PUT /vehicles/{id}/compliance/{itemID}
+		rt.Put("/{id}/compliance/{itemID}", h.compliance.Update())
+
+		// - DELETE /vehicles/{id}/compliance/{itemID}
+		rt.Delete("/{id}/compliance/{itemID}", h.compliance.Delete())
 	})
 
-	// run server
-	err = http.ListenAndServe(a.serverAddress, rt)
+	rt.Route("/drivers", func(rt chi.Router) {
+		// - GET /drivers
+		rt.Get("/", h.driver.GetAll())
+
+		// - POST /drivers
+		rt.With(h.idempotency.Handler).Post("/", h.driver.Create())
+
+		// - GET /drivers/{id}
+		rt.Get("/{id}", h.driver.GetByID())
+
+		// - PUT /drivers/{id}
+		rt.Put("/{id}", h.driver.Update())
+
+		// - DELETE /drivers/{id}
+		rt.Delete("/{id}", h.driver.Delete())
+
+		// - GET /drivers/{id}/assignments
+		rt.Get("/{id}/assignments", h.driver.GetAssignmentsByDriver())
+	})
+
+	rt.Route("/webhooks", func(rt chi.Router) {
+		// - webhooks receive every event, so registering one is an admin task
+		rt.Use(h.adminAuth.Handler)
+
+		// - GET /webhooks
+		rt.Get("/", h.webhook.GetAll())
+
+		// - POST /webhooks
+		rt.With(h.idempotency.Handler).Post("/", h.webhook.Create())
+
+		// - GET /webhooks/{id}
+		rt.Get("/{id}", h.webhook.GetByID())
+
+		// - DELETE /webhooks/{id}
+		rt.Delete("/{id}", h.webhook.Delete())
+
+		// - GET /webhooks/{id}/deliveries
+		rt.Get("/{id}/deliveries", h.webhook.GetDeliveries())
+
+		// - GET /webhooks/{id}/dead-letters
+		rt.Get("/{id}/dead-letters", h.webhook.GetDeadLetters())
+
+		// - POST /webhooks/{id}/dead-letters/{deadLetterID}/redeliver
+		rt.Post("/{id}/dead-letters/{deadLetterID}/redeliver", h.webhook.Redeliver())
+	})
+
+	rt.Route("/graphql", func(rt chi.Router) {
+		// - GET /graphql?query={query}&operationName={operationName}&variables={variables}
+		rt.Get("/", h.graphQL.Query())
+
+		// - POST /graphql
+		rt.Post("/", h.graphQL.Query())
+
+		// - GET /graphql/schema
+		rt.Get("/schema", h.graphQL.Schema())
+	})
+
+	rt.Route("/admin", func(rt chi.Router) {
+		rt.Use(h.adminAuth.Handler)
+
+		// - POST /admin/reload
+		rt.Post("/reload", h.admin.Reload())
+
+		// - GET /admin/reload
+		rt.Get("/reload", h.admin.ReloadStatus())
+
+		// - POST /admin/backups
+		rt.Post("/backups", h.admin.CreateBackup())
+
+		// - GET /admin/backups
+		rt.Get("/backups", h.admin.GetBackups())
+
+		// - POST /admin/backups/{id}/restore
+		rt.Post("/backups/{id}/restore", h.admin.RestoreBackup())
+
+		// - POST /admin/compliance/remind
+		rt.Post("/compliance/remind", h.compliance.Remind())
+	})
+
+	// - GET /openapi.json
+	rt.Get("/openapi.json", h.openAPI.Get())
+}
+
+const (
+	// IDGeneratorSequential generates consecutive ids following the greatest id loaded
+	IDGeneratorSequential = "sequential"
+	// IDGeneratorUUIDv7 generates consecutive ids and a UUIDv7 as the unique identifier of every vehicle
+	IDGeneratorUUIDv7 = "uuidv7"
+	// IDGeneratorULID generates consecutive ids and a ULID as the unique identifier of every vehicle
+	IDGeneratorULID = "ulid"
+)
+
+// newVehicleIDGenerator is a function that returns the generator of ids with the given name, and the generator of
+// unique identifiers, nil when the vehicles are not given one
+func newVehicleIDGenerator(name string) (gen internal.VehicleIDGenerator, uid internal.VehicleUIDGenerator, err error) {
+	gen = repository.NewVehicleIDSequential()
+	switch name {
+	case IDGeneratorSequential:
+	case IDGeneratorUUIDv7:
+		uid = repository.NewVehicleUIDv7()
+	case IDGeneratorULID:
+		uid = repository.NewVehicleULID()
+	default:
+		err = fmt.Errorf("unknown id generator %q", name)
+	}
+	return
+}
+
+// notifier is a method that returns the notifier of the reminders: the log, and the webhook and the mail server when configured
+func (a *ServerChi) notifier() (nt internal.Notifier, err error) {
+	m := notify.Multi{notify.NewLog(nil)}
+	if a.notifyWebhookURL != "" {
+		m = append(m, notify.NewWebhook(&notify.ConfigWebhook{URL: a.notifyWebhookURL}))
+	}
+	if a.smtpAddr != "" {
+		var sm *notify.SMTP
+		sm, err = notify.NewSMTP(&notify.ConfigSMTP{
+			Addr:     a.smtpAddr,
+			From:     a.smtpFrom,
+			To:       a.smtpTo,
+			Username: a.smtpUsername,
+			Password: a.smtpPassword,
+		})
+		if err != nil {
+			return
+		}
+		m = append(m, sm)
+	}
+	nt = m
 	return
 }
+
+// outboxSinks is a method that returns the sinks the outbox is relayed to: the event bus, and the file, the standard output
+// and the webhook when configured
+func (a *ServerChi) outboxSinks(bus internal.EventPublisher) (sinks []internal.OutboxSink, err error) {
+	sinks = append(sinks, outbox.NewPublisher("bus", bus))
+	if a.outboxFilePath != "" {
+		var f *outbox.Writer
+		if f, err = outbox.NewFile("file", a.outboxFilePath); err != nil {
+			return
+		}
+		sinks = append(sinks, f)
+	}
+	if a.outboxStdout {
+		sinks = append(sinks, outbox.NewWriter("stdout", os.Stdout))
+	}
+	if a.outboxWebhookURL != "" {
+		sinks = append(sinks, outbox.NewWebhook(&outbox.ConfigWebhook{URL: a.outboxWebhookURL}))
+	}
+	return
+}
+
+// logLoadReport is a function that logs the outcome of a load
+func logLoadReport(r internal.LoadReport) {
+	log.Printf("loaded %d of %d vehicles, %d rejected, max id %d", r.Accepted, r.Records, r.Rejected, r.MaxID)
+	if len(r.Duplicates) > 0 {
+		log.Printf("duplicated ids: %v", r.Duplicates)
+	}
+	if len(r.Gaps) > 0 {
+		log.Printf("missing ids: %v", r.Gaps)
+	}
+}
@@ -0,0 +1,121 @@
+package application
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhinosc/code-review-1/internal/graphql"
+	"github.com/rhinosc/code-review-1/internal/handler"
+	"github.com/rhinosc/code-review-1/internal/repository"
+)
+
+// newTestRouter is a function that returns a router with the routes of the server, served by handlers without
+// dependencies but the GraphQL schema; they are only walked or guarded by the admin token, never reached
+func newTestRouter(t *testing.T) *chi.Mux {
+	t.Helper()
+	gs, err := graphql.NewVehicleSchema(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt := chi.NewRouter()
+	routes(rt, handlers{
+		vehicle:     &handler.VehicleDefault{},
+		events:      &handler.VehicleEventsDefault{},
+		maintenance: &handler.MaintenanceDefault{},
+		reservation: &handler.ReservationDefault{},
+		driver:      &handler.DriverDefault{},
+		fuelLog:     &handler.FuelLogDefault{},
+		attachment:  &handler.AttachmentDefault{},
+		compliance:  &handler.ComplianceDefault{},
+		webhook:     &handler.WebhookDefault{},
+		graphQL:     handler.NewGraphQLDefault(gs, graphql.Limits{}),
+		admin:       &handler.AdminDefault{},
+		adminAuth:   handler.NewAdminAuth("s3cret-token"),
+		idempotency: &handler.Idempotency{},
+		openAPI:     handler.NewOpenAPIDefault(handler.OpenAPI()),
+	})
+	return rt
+}
+
+func TestRoutes_CheckOpenAPI(t *testing.T) {
+	if err := handler.CheckOpenAPI(handler.OpenAPI(), newTestRouter(t)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRoutes_CheckOpenAPI_Mismatch(t *testing.T) {
+	t.Run("route not described", func(t *testing.T) {
+		rt := newTestRouter(t)
+		rt.Get("/undocumented", func(w http.ResponseWriter, r *http.Request) {})
+		err := handler.CheckOpenAPI(handler.OpenAPI(), rt)
+		if err == nil || !strings.Contains(err.Error(), "GET /undocumented") {
+			t.Fatalf("got error %v, want the route not described", err)
+		}
+	})
+
+	t.Run("operation without a route", func(t *testing.T) {
+		spec := handler.OpenAPI()
+		spec.Add(http.MethodGet, "/stale", "getStale", "")
+		err := handler.CheckOpenAPI(spec, newTestRouter(t))
+		if err == nil || !strings.Contains(err.Error(), "GET /stale") {
+			t.Fatalf("got error %v, want the operation without a route", err)
+		}
+	})
+}
+
+func TestRoutes_AdminAuth(t *testing.T) {
+	rt := newTestRouter(t)
+	var guarded int
+	err := chi.Walk(rt, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if !strings.HasPrefix(route, "/admin/") && !strings.HasPrefix(route, "/webhooks/") {
+			return nil
+		}
+		guarded++
+		path := strings.NewReplacer("{id}", "1", "{deadLetterID}", "1").Replace(route)
+		rec := httptest.NewRecorder()
+		rt.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s without a token: got status %d, want %d", method, route, rec.Code, http.StatusUnauthorized)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if guarded == 0 {
+		t.Fatal("got no admin or webhook route")
+	}
+}
+
+func TestNewVehicleIDGenerator(t *testing.T) {
+	tests := []struct {
+		name string
+		uid  any
+	}{
+		{name: IDGeneratorSequential},
+		{name: IDGeneratorUUIDv7, uid: &repository.VehicleUIDv7{}},
+		{name: IDGeneratorULID, uid: &repository.VehicleULID{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, uid, err := newVehicleIDGenerator(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := gen.(*repository.VehicleIDSequential); !ok {
+				t.Errorf("got id generator %T, want sequential ids", gen)
+			}
+			if got, want := fmt.Sprintf("%T", uid), fmt.Sprintf("%T", tt.uid); got != want {
+				t.Errorf("got uid generator %s, want %s", got, want)
+			}
+		})
+	}
+
+	if _, _, err := newVehicleIDGenerator("time"); err == nil {
+		t.Error("got no error for an unknown generator")
+	}
+}
@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrAttachmentNotFound is an error that represents an attachment not found
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	// ErrAttachmentInvalid is an error that represents an upload without a file or with an empty one
+	ErrAttachmentInvalid = errors.New("attachment invalid")
+	// ErrAttachmentTooLarge is an error that represents a file larger than the greatest size allowed
+	ErrAttachmentTooLarge = errors.New("attachment too large")
+	// ErrAttachmentType is an error that represents a file of a type that is not allowed
+	ErrAttachmentType = errors.New("attachment type not allowed")
+)
+
+// Attachment is a struct that represents a file attached to a vehicle, e.g. the registration papers or a photo of a damage
+type Attachment struct {
+	// ID is the unique identifier of the attachment
+	ID int
+	// VehicleID is the id of the vehicle
+	VehicleID int
+	// Name is the name of the file
+	Name string
+	// ContentType is the media type detected from the content
+	ContentType string
+	// Size is the size of the file in bytes
+	Size int64
+	// SHA256 is the SHA-256 in hex of the content, the key of the content in the blob store
+	SHA256 string
+	// CreatedAt is the time the file was uploaded
+	CreatedAt time.Time
+}
@@ -0,0 +1,10 @@
+package internal
+
+// AttachmentLoader is an interface that represents the loader for attachments
+type AttachmentLoader interface {
+	// Load is a method that loads the attachments
+	Load() (a map[int]Attachment, err error)
+
+	// Save is a method that saves the attachments
+	Save(a map[int]Attachment) (err error)
+}
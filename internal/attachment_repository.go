@@ -0,0 +1,19 @@
+package internal
+
+// AttachmentRepository is an interface that represents a repository of attachments
+type AttachmentRepository interface {
+	// FindAll is a method that returns every attachment, ordered by id
+	FindAll() (a []Attachment, err error)
+
+	// FindByVehicle is a method that returns the attachments of a vehicle, ordered by id
+	FindByVehicle(vehicleID int) (a []Attachment, err error)
+
+	// FindByID is a method that returns an attachment by id
+	FindByID(id int) (a Attachment, err error)
+
+	// Create is a method that creates an attachment
+	Create(a *Attachment) (err error)
+
+	// Delete is a method that deletes the attachments matching a condition and returns them
+	Delete(match func(a Attachment) bool) (a []Attachment, err error)
+}
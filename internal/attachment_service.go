@@ -0,0 +1,24 @@
+package internal
+
+import "io"
+
+// AttachmentService is an interface that represents a service of attachments
+type AttachmentService interface {
+	// FindByVehicle is a method that returns the attachments of a vehicle, ordered by id
+	FindByVehicle(vehicleID int) (a []Attachment, err error)
+
+	// Upload is a method that attaches a file to a vehicle, it fails with ErrAttachmentTooLarge or ErrAttachmentType when the file is not allowed
+	Upload(vehicleID int, name string, r io.Reader) (a Attachment, err error)
+
+	// Open is a method that opens the content of an attachment of a vehicle
+	Open(vehicleID, id int) (a Attachment, f io.ReadSeekCloser, err error)
+
+	// Delete is a method that deletes an attachment of a vehicle, and its content when no other attachment shares it
+	Delete(vehicleID, id int) (err error)
+
+	// DeleteByVehicle is a method that deletes the attachments of a vehicle, and the contents no other attachment shares
+	DeleteByVehicle(vehicleID int) (err error)
+
+	// Cleanup is a method that deletes the attachments of vehicles that no longer exist and the contents no attachment refers to
+	Cleanup() (attachments, blobs int, err error)
+}
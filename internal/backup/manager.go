@@ -0,0 +1,257 @@
+package backup
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+var (
+	// ErrBackupNotFound is an error that represents a backup that does not exist
+	ErrBackupNotFound = errors.New("backup not found")
+	// ErrBackupCorrupt is an error that represents a backup whose snapshot does not match its checksum
+	ErrBackupCorrupt = errors.New("backup corrupt")
+	// ErrBackupEmpty is an error that represents a backup without vehicles
+	ErrBackupEmpty = errors.New("backup has no vehicles")
+)
+
+const (
+	// snapshotExt is the extension of the snapshots, they are gzip-compressed
+	snapshotExt = ".json.gz"
+	// manifestExt is the extension of the manifests
+	manifestExt = ".manifest.json"
+)
+
+// idPattern is the pattern of backup ids, made of the creation time and a random suffix
+var idPattern = regexp.MustCompile(`^[0-9]{8}T[0-9]{6}Z-[0-9a-f]{8}$`)
+
+// Backup is a struct that represents a snapshot of the vehicles
+type Backup struct {
+	// ID is the id of the backup
+	ID string `json:"id"`
+	// CreatedAt is the time the snapshot was taken
+	CreatedAt time.Time `json:"created_at"`
+	// Vehicles is the number of vehicles in the snapshot
+	Vehicles int `json:"vehicles"`
+	// Size is the size in bytes of the snapshot file
+	Size int64 `json:"size"`
+	// Checksum is the SHA-256 of the snapshot file
+	Checksum string `json:"checksum"`
+}
+
+// NewManager is a function that returns a new instance of Manager.
+// newLoader returns the loader of a snapshot file, so snapshots share the format and the encryption of the vehicles file
+func NewManager(dir string, rp internal.VehicleRepository, newLoader func(path string) internal.VehicleLoader) *Manager {
+	return &Manager{dir: dir, rp: rp, newLoader: newLoader}
+}
+
+// Manager is a struct that takes and restores checksummed snapshots of a repository in a directory.
+// Each backup is a snapshot file and a manifest written after it, so a backup is only listed once its snapshot is complete
+type Manager struct {
+	// dir is the directory of the backups
+	dir string
+	// rp is the repository
+	rp internal.VehicleRepository
+	// newLoader returns the loader of a snapshot file
+	newLoader func(path string) internal.VehicleLoader
+}
+
+// Create is a method that takes a snapshot of the repository
+func (m *Manager) Create() (b Backup, err error) {
+	// - the repository returns a copy taken at once
+	v, err := m.rp.FindAll()
+	if err != nil {
+		return
+	}
+	if err = os.MkdirAll(m.dir, 0700); err != nil {
+		return
+	}
+
+	// snapshot
+	b.CreatedAt = time.Now().UTC()
+	if b.ID, err = newID(b.CreatedAt); err != nil {
+		return
+	}
+	b.Vehicles = len(v)
+	path := m.snapshotPath(b.ID)
+	if err = m.newLoader(path).Save(v); err != nil {
+		err = fmt.Errorf("error writing snapshot: %w", err)
+		return
+	}
+	if b.Checksum, b.Size, err = checksum(path); err != nil {
+		os.Remove(path)
+		return
+	}
+
+	// manifest
+	if err = writeManifest(m.manifestPath(b.ID), b); err != nil {
+		os.Remove(path)
+	}
+	return
+}
+
+// List is a method that returns the backups, newest first. Backups with an unreadable manifest are left out
+func (m *Manager) List() (b []Backup, err error) {
+	paths, err := filepath.Glob(filepath.Join(m.dir, "*"+manifestExt))
+	if err != nil {
+		return
+	}
+	b = make([]Backup, 0, len(paths))
+	for _, path := range paths {
+		id := strings.TrimSuffix(filepath.Base(path), manifestExt)
+		if !idPattern.MatchString(id) {
+			continue
+		}
+		var bk Backup
+		if bk, err = m.Get(id); err != nil {
+			if errors.Is(err, ErrBackupCorrupt) || errors.Is(err, ErrBackupNotFound) {
+				err = nil
+				continue
+			}
+			return
+		}
+		b = append(b, bk)
+	}
+	sort.Slice(b, func(i, j int) bool {
+		if !b[i].CreatedAt.Equal(b[j].CreatedAt) {
+			return b[i].CreatedAt.After(b[j].CreatedAt)
+		}
+		return b[i].ID > b[j].ID
+	})
+	return
+}
+
+// Get is a method that returns a backup by id
+func (m *Manager) Get(id string) (b Backup, err error) {
+	if !idPattern.MatchString(id) {
+		err = fmt.Errorf("%w: %s", ErrBackupNotFound, id)
+		return
+	}
+	bytes, err := os.ReadFile(m.manifestPath(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = fmt.Errorf("%w: %s", ErrBackupNotFound, id)
+		}
+		return
+	}
+	if err = json.Unmarshal(bytes, &b); err != nil {
+		err = fmt.Errorf("%w: manifest of %s: %v", ErrBackupCorrupt, id, err)
+		return
+	}
+	if b.ID != id {
+		err = fmt.Errorf("%w: manifest of %s holds id %s", ErrBackupCorrupt, id, b.ID)
+	}
+	return
+}
+
+// Restore is a method that replaces the vehicles of the repository with the ones of a backup,
+// once its snapshot matches the checksum of the manifest and every vehicle is valid
+func (m *Manager) Restore(id string) (b Backup, err error) {
+	if b, err = m.Get(id); err != nil {
+		return
+	}
+
+	// verify
+	path := m.snapshotPath(id)
+	sum, _, err := checksum(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = fmt.Errorf("%w: snapshot of %s is missing", ErrBackupCorrupt, id)
+		}
+		return
+	}
+	if sum != b.Checksum {
+		err = fmt.Errorf("%w: snapshot of %s has checksum %s, expected %s", ErrBackupCorrupt, id, sum, b.Checksum)
+		return
+	}
+
+	// load
+	v, err := m.newLoader(path).Load()
+	if err != nil {
+		err = fmt.Errorf("%w: %v", ErrBackupCorrupt, err)
+		return
+	}
+	for _, value := range v {
+		if err = value.Validate(); err != nil {
+			err = fmt.Errorf("%w: vehicle %d: %v", ErrBackupCorrupt, value.Id, err)
+			return
+		}
+	}
+	if len(v) == 0 {
+		err = ErrBackupEmpty
+		return
+	}
+
+	// restore
+	err = m.rp.Restore(v)
+	return
+}
+
+// snapshotPath is a method that returns the path of the snapshot of a backup
+func (m *Manager) snapshotPath(id string) string {
+	return filepath.Join(m.dir, id+snapshotExt)
+}
+
+// manifestPath is a method that returns the path of the manifest of a backup
+func (m *Manager) manifestPath(id string) string {
+	return filepath.Join(m.dir, id+manifestExt)
+}
+
+// newID is a function that returns the id of a backup created at the given time
+func newID(t time.Time) (id string, err error) {
+	suffix := make([]byte, 4)
+	if _, err = rand.Read(suffix); err != nil {
+		return
+	}
+	id = t.Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+	return
+}
+
+// checksum is a function that returns the SHA-256 and the size of a file
+func checksum(path string) (sum string, size int64, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	h := sha256.New()
+	if size, err = io.Copy(h, f); err != nil {
+		return
+	}
+	sum = hex.EncodeToString(h.Sum(nil))
+	return
+}
+
+// writeManifest is a function that writes a manifest through a temporary file, so it is never read partially written
+func writeManifest(path string, b Backup) (err error) {
+	bytes, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".manifest-*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if _, err = f.Write(append(bytes, '\n')); err != nil {
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+	err = os.Rename(f.Name(), path)
+	return
+}
@@ -0,0 +1,188 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/loader"
+	"github.com/rhinosc/code-review-1/internal/repository"
+)
+
+// newTestManager is a function that returns a manager of backups in a temporary directory, of a repository of the given vehicles
+func newTestManager(t *testing.T, brands ...string) (m *Manager, rp *repository.VehicleMap) {
+	t.Helper()
+	dir := t.TempDir()
+	db := make(map[int]internal.Vehicle)
+	for i, brand := range brands {
+		db[i+1] = internal.Vehicle{
+			Id:                i + 1,
+			VehicleAttributes: internal.VehicleAttributes{Brand: brand, Model: "T", FabricationYear: 2020, Capacity: 2},
+			Status:            internal.VehicleStatusInService,
+		}
+	}
+	rp = repository.NewVehicleMap(loader.NewVehicleJSONFile(filepath.Join(dir, "vehicles.json")), db, repository.NewVehicleIDSequential())
+	m = NewManager(filepath.Join(dir, "backups"), rp, func(path string) internal.VehicleLoader {
+		return loader.NewVehicleJSONFile(path)
+	})
+	return
+}
+
+func TestManager_Create(t *testing.T) {
+	m, _ := newTestManager(t, "Ford", "Fiat")
+
+	b, err := m.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !idPattern.MatchString(b.ID) || b.Vehicles != 2 || b.CreatedAt.IsZero() {
+		t.Errorf("got %+v, want a backup of 2 vehicles", b)
+	}
+
+	// - the manifest holds the checksum and the size of the snapshot
+	bytes, err := os.ReadFile(m.snapshotPath(b.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(bytes)
+	if b.Checksum != hex.EncodeToString(sum[:]) || b.Size != int64(len(bytes)) {
+		t.Errorf("got checksum %s of %d bytes, want the one of the snapshot", b.Checksum, b.Size)
+	}
+	got, err := m.Get(b.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.CreatedAt.Equal(b.CreatedAt) || got.Checksum != b.Checksum || got.Vehicles != b.Vehicles {
+		t.Errorf("got manifest %+v, want %+v", got, b)
+	}
+}
+
+func TestManager_List(t *testing.T) {
+	m, _ := newTestManager(t, "Ford")
+	for i := 0; i < 3; i++ {
+		if _, err := m.Create(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// - a corrupt manifest and files of other names are left out
+	if err := os.WriteFile(m.manifestPath("20260101T000000Z-00000000"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(m.dir, "notes"+manifestExt), []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := m.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != 3 {
+		t.Fatalf("got %d backups, want 3", len(b))
+	}
+	for i := 1; i < len(b); i++ {
+		if b[i].CreatedAt.After(b[i-1].CreatedAt) {
+			t.Errorf("got %s after %s, want the newest first", b[i].ID, b[i-1].ID)
+		}
+	}
+
+	// - an empty directory has no backups
+	empty := NewManager(filepath.Join(t.TempDir(), "missing"), nil, nil)
+	if b, err := empty.List(); err != nil || len(b) != 0 {
+		t.Errorf("got %v, %v, want no backups", b, err)
+	}
+}
+
+func TestManager_Restore(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      func(m *Manager, id string) string
+		damage  func(t *testing.T, m *Manager, id string)
+		wantErr error
+	}{
+		{name: "restored"},
+		{name: "unknown id", id: func(m *Manager, id string) string { return "20260101T000000Z-00000000" }, wantErr: ErrBackupNotFound},
+		{name: "id out of the directory", id: func(m *Manager, id string) string { return "../" + id }, wantErr: ErrBackupNotFound},
+		{name: "snapshot changed", damage: func(t *testing.T, m *Manager, id string) {
+			f, err := os.OpenFile(m.snapshotPath(id), os.O_APPEND|os.O_WRONLY, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			if _, err := f.Write([]byte{0}); err != nil {
+				t.Fatal(err)
+			}
+		}, wantErr: ErrBackupCorrupt},
+		{name: "snapshot missing", damage: func(t *testing.T, m *Manager, id string) {
+			if err := os.Remove(m.snapshotPath(id)); err != nil {
+				t.Fatal(err)
+			}
+		}, wantErr: ErrBackupCorrupt},
+		{name: "manifest of another backup", damage: func(t *testing.T, m *Manager, id string) {
+			b, err := m.Get(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b.ID = "20260101T000000Z-00000000"
+			if err := writeManifest(m.manifestPath(id), b); err != nil {
+				t.Fatal(err)
+			}
+		}, wantErr: ErrBackupCorrupt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, rp := newTestManager(t, "Ford", "Fiat")
+			b, err := m.Create()
+			if err != nil {
+				t.Fatal(err)
+			}
+			// - the fleet changes after the backup
+			if _, err := rp.Delete(1, nil); err != nil {
+				t.Fatal(err)
+			}
+			if tt.damage != nil {
+				tt.damage(t, m, b.ID)
+			}
+			id := b.ID
+			if tt.id != nil {
+				id = tt.id(m, b.ID)
+			}
+
+			_, err = m.Restore(id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			// - a backup that fails leaves the fleet as it was
+			want := 2
+			if err != nil {
+				want = 1
+			}
+			if v, _ := rp.FindAll(); len(v) != want {
+				t.Errorf("got %d vehicles, want %d", len(v), want)
+			}
+		})
+	}
+}
+
+func TestManager_Restore_Empty(t *testing.T) {
+	m, rp := newTestManager(t)
+	b, err := m.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := internal.Vehicle{VehicleAttributes: internal.VehicleAttributes{Brand: "Ford", Model: "T", FabricationYear: 2020, Capacity: 2}, Status: internal.VehicleStatusInService}
+	if err := rp.Create(&v, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// - a backup without vehicles would wipe the fleet, so it is not restored
+	if _, err := m.Restore(b.ID); !errors.Is(err, ErrBackupEmpty) {
+		t.Fatalf("got %v, want %v", err, ErrBackupEmpty)
+	}
+	if all, _ := rp.FindAll(); len(all) != 1 {
+		t.Errorf("got %d vehicles, want the fleet kept", len(all))
+	}
+}
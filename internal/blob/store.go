@@ -0,0 +1,132 @@
+package blob
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// sumPattern is the pattern of the SHA-256 in hex that names the contents
+var sumPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// tmpDir is the directory of the contents being written, under the directory of the store
+const tmpDir = "tmp"
+
+// NewStore is a function that returns a new instance of Store, the contents left half-written by a previous process are removed
+func NewStore(dir string) (s *Store, err error) {
+	if err = os.RemoveAll(filepath.Join(dir, tmpDir)); err != nil {
+		return
+	}
+	if err = os.MkdirAll(filepath.Join(dir, tmpDir), 0700); err != nil {
+		return
+	}
+	s = &Store{dir: dir}
+	return
+}
+
+// Store is a struct that implements the BlobStore interface on a local directory.
+// Each content is a file named by its SHA-256 in a subdirectory named by the first two characters, e.g. ab/abcd...;
+// it is written to a temporary file and renamed once complete, so a stored content is never partial
+type Store struct {
+	// dir is the directory of the store
+	dir string
+}
+
+// path is a method that returns the path of a content
+func (s *Store) path(sum string) string {
+	return filepath.Join(s.dir, sum[:2], sum)
+}
+
+// Put is a method that stores a content and returns its SHA-256 in hex and its size, a content already stored is kept once.
+// Nothing is stored when reading r fails
+func (s *Store) Put(r io.Reader) (sum string, size int64, err error) {
+	f, err := os.CreateTemp(filepath.Join(s.dir, tmpDir), "blob-*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	h := sha256.New()
+	if size, err = io.Copy(io.MultiWriter(f, h), r); err != nil {
+		return
+	}
+	if err = f.Sync(); err != nil {
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+	sum = hex.EncodeToString(h.Sum(nil))
+
+	// - deduplicate
+	path := s.path(sum)
+	if _, err = os.Stat(path); err == nil {
+		return
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	err = os.Rename(f.Name(), path)
+	return
+}
+
+// Open is a method that opens a content
+func (s *Store) Open(sum string) (f io.ReadSeekCloser, err error) {
+	if !sumPattern.MatchString(sum) {
+		err = fmt.Errorf("%w: %s", internal.ErrBlobNotFound, sum)
+		return
+	}
+	file, err := os.Open(s.path(sum))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = fmt.Errorf("%w: %s", internal.ErrBlobNotFound, sum)
+		}
+		return
+	}
+	f = file
+	return
+}
+
+// Delete is a method that deletes a content, deleting a missing content is not an error
+func (s *Store) Delete(sum string) (err error) {
+	if !sumPattern.MatchString(sum) {
+		return
+	}
+	if err = os.Remove(s.path(sum)); errors.Is(err, fs.ErrNotExist) {
+		err = nil
+	}
+	return
+}
+
+// List is a method that returns the SHA-256 of every content
+func (s *Store) List() (sums []string, err error) {
+	sums = make([]string, 0)
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	for _, dir := range entries {
+		if !dir.IsDir() || dir.Name() == tmpDir {
+			continue
+		}
+		var files []os.DirEntry
+		if files, err = os.ReadDir(filepath.Join(s.dir, dir.Name())); err != nil {
+			return
+		}
+		for _, file := range files {
+			if sumPattern.MatchString(file.Name()) && file.Name()[:2] == dir.Name() {
+				sums = append(sums, file.Name())
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,178 @@
+package blob
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// newTestStore is a function that returns a store in a temporary directory
+func newTestStore(t *testing.T) (s *Store, dir string) {
+	t.Helper()
+	dir = t.TempDir()
+	s, err := NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+// sha256Hex is a function that returns the SHA-256 in hex of a content
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// read is a function that returns the content stored with a SHA-256
+func read(t *testing.T, s *Store, sum string) string {
+	t.Helper()
+	f, err := s.Open(sum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	b, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestStore_Put(t *testing.T) {
+	s, dir := newTestStore(t)
+
+	// - the content is named by its SHA-256, under a directory named by its first two characters
+	sum, size, err := s.Put(strings.NewReader("registration papers"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := sha256Hex("registration papers"); sum != want || size != 19 {
+		t.Fatalf("got %s of %d bytes, want %s of 19", sum, size, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, sum[:2], sum)); err != nil {
+		t.Errorf("got %v, want the content at %s/%s", err, sum[:2], sum)
+	}
+	if got := read(t, s, sum); got != "registration papers" {
+		t.Errorf("got content %q, want the one stored", got)
+	}
+
+	// - the same content is stored once
+	again, _, err := s.Put(strings.NewReader("registration papers"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := s.Put(strings.NewReader("damage photo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sums, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != sum || len(sums) != 2 {
+		t.Errorf("got %s and %d contents, want %s and 2", again, len(sums), sum)
+	}
+
+	// - no temporary file is left behind
+	if tmp, _ := os.ReadDir(filepath.Join(dir, tmpDir)); len(tmp) != 0 {
+		t.Errorf("got %d temporary files, want none", len(tmp))
+	}
+	if got := read(t, s, other); got != "damage photo" {
+		t.Errorf("got content %q, want the one stored", got)
+	}
+}
+
+func TestStore_Put_ReadFails(t *testing.T) {
+	s, dir := newTestStore(t)
+
+	_, _, err := s.Put(io.MultiReader(strings.NewReader("half a photo"), iotest.ErrReader(errors.New("connection reset"))))
+	if err == nil {
+		t.Fatal("got no error, want the read error")
+	}
+	if sums, _ := s.List(); len(sums) != 0 {
+		t.Errorf("got contents %v, want none", sums)
+	}
+	if tmp, _ := os.ReadDir(filepath.Join(dir, tmpDir)); len(tmp) != 0 {
+		t.Errorf("got %d temporary files, want none", len(tmp))
+	}
+}
+
+func TestStore_Open_NotFound(t *testing.T) {
+	s, _ := newTestStore(t)
+
+	for _, sum := range []string{sha256Hex("missing"), "../../etc/passwd", strings.ToUpper(sha256Hex("missing")), ""} {
+		if _, err := s.Open(sum); !errors.Is(err, internal.ErrBlobNotFound) {
+			t.Errorf("open %q: got %v, want %v", sum, err, internal.ErrBlobNotFound)
+		}
+	}
+}
+
+func TestStore_Delete(t *testing.T) {
+	s, _ := newTestStore(t)
+	sum, _, err := s.Put(strings.NewReader("damage photo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Delete(sum); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Open(sum); !errors.Is(err, internal.ErrBlobNotFound) {
+		t.Errorf("got %v, want the content deleted", err)
+	}
+	// - deleting a missing or invalid content is not an error
+	for _, value := range []string{sum, "not-a-sum"} {
+		if err := s.Delete(value); err != nil {
+			t.Errorf("delete %q: got %v, want no error", value, err)
+		}
+	}
+}
+
+func TestStore_List(t *testing.T) {
+	s, dir := newTestStore(t)
+	sum, _, err := s.Put(strings.NewReader("damage photo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// - files that are not contents are ignored, as a content under the directory of another prefix
+	misplaced := sha256Hex("misplaced")
+	for _, path := range []string{filepath.Join(dir, "README"), filepath.Join(dir, sum[:2], "notes.txt"), filepath.Join(dir, sum[:2], misplaced)} {
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sums, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{sum}; !reflect.DeepEqual(sums, want) {
+		t.Errorf("got %v, want %v", sums, want)
+	}
+}
+
+func TestNewStore_RemovesHalfWritten(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, tmpDir), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, tmpDir, "blob-1"), []byte("half"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewStore(dir); err != nil {
+		t.Fatal(err)
+	}
+	if tmp, err := os.ReadDir(filepath.Join(dir, tmpDir)); err != nil || len(tmp) != 0 {
+		t.Errorf("got %d temporary files and error %v, want none", len(tmp), err)
+	}
+}
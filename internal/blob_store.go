@@ -0,0 +1,26 @@
+package internal
+
+import (
+	"errors"
+	"io"
+)
+
+var (
+	// ErrBlobNotFound is an error that represents a content that is not stored
+	ErrBlobNotFound = errors.New("blob not found")
+)
+
+// BlobStore is an interface that represents a store of contents addressed by their SHA-256
+type BlobStore interface {
+	// Put is a method that stores a content and returns its SHA-256 in hex and its size, a content already stored is kept once
+	Put(r io.Reader) (sum string, size int64, err error)
+
+	// Open is a method that opens a content
+	Open(sum string) (f io.ReadSeekCloser, err error)
+
+	// Delete is a method that deletes a content, deleting a missing content is not an error
+	Delete(sum string) (err error)
+
+	// List is a method that returns the SHA-256 of every content
+	List() (sums []string, err error)
+}
@@ -0,0 +1,131 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrComplianceInvalid is an error that represents a compliance item with invalid attributes
+	ErrComplianceInvalid = errors.New("compliance item invalid")
+	// ErrComplianceNotFound is an error that represents a compliance item not found
+	ErrComplianceNotFound = errors.New("compliance item not found")
+)
+
+// ComplianceKind is the kind of document a vehicle must keep valid to be driven
+type ComplianceKind string
+
+const (
+	// ComplianceKindRegistration is the renewal of the registration
+	ComplianceKindRegistration ComplianceKind = "registration"
+	// ComplianceKindInsurance is an insurance policy
+	ComplianceKindInsurance ComplianceKind = "insurance"
+	// ComplianceKindInspection is a technical inspection
+	ComplianceKindInspection ComplianceKind = "inspection"
+)
+
+// Valid is a method that reports whether the kind is known
+func (k ComplianceKind) Valid() bool {
+	switch k {
+	case ComplianceKindRegistration, ComplianceKindInsurance, ComplianceKindInspection:
+		return true
+	}
+	return false
+}
+
+// ComplianceItem is a struct that represents a dated document of a vehicle, e.g. an insurance policy
+type ComplianceItem struct {
+	// ID is the unique identifier of the item
+	ID int
+	// VehicleID is the id of the vehicle
+	VehicleID int
+	// Kind is the kind of document
+	Kind ComplianceKind
+	// Reference is the number of the document, e.g. the policy number
+	Reference string
+	// Provider is who issued the document, e.g. the insurer
+	Provider string
+	// Expires is the last day the document is valid
+	Expires time.Time
+	// Notes is a free text description of the item
+	Notes string
+}
+
+// Validate is a method that checks the attributes of an item are consistent
+func (i ComplianceItem) Validate() (err error) {
+	switch {
+	case i.VehicleID <= 0:
+		err = fmt.Errorf("%w: vehicle id must be positive", ErrComplianceInvalid)
+	case !i.Kind.Valid():
+		err = fmt.Errorf("%w: kind %q is unknown", ErrComplianceInvalid, i.Kind)
+	case i.Expires.IsZero():
+		err = fmt.Errorf("%w: expires is required", ErrComplianceInvalid)
+	case len(i.Reference) > 100 || len(i.Provider) > 100:
+		err = fmt.Errorf("%w: reference and provider must be at most 100 characters", ErrComplianceInvalid)
+	case strings.ContainsAny(i.Reference+i.Provider, "\r\n"):
+		err = fmt.Errorf("%w: reference and provider must be a single line", ErrComplianceInvalid)
+	}
+	return
+}
+
+// DaysLeft is a method that returns the number of days from the day of at to the last valid day, negative once expired
+func (i ComplianceItem) DaysLeft(at time.Time) int {
+	at = at.UTC()
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+	expires := time.Date(i.Expires.Year(), i.Expires.Month(), i.Expires.Day(), 0, 0, 0, 0, time.UTC)
+	return int(expires.Sub(day).Round(24*time.Hour) / (24 * time.Hour))
+}
+
+// ComplianceExpiry is a struct that represents an item expiring or expired at a given day
+type ComplianceExpiry struct {
+	// Item is the item
+	Item ComplianceItem
+	// Registration is the registration of the vehicle of the item
+	Registration string
+	// DaysLeft is the number of days to the last valid day, negative once expired
+	DaysLeft int
+}
+
+// ComplianceReminder is a struct that represents a reminder of an expiry entering a window
+type ComplianceReminder struct {
+	// Expiry is the expiry of the item
+	Expiry ComplianceExpiry
+	// Window is the number of days of the window the expiry entered, zero once the item expired
+	Window int
+}
+
+// ReminderWindow is a function that returns the window an item with the given days left is in: the narrowest of the
+// windows, in days, it is within, or zero once it expired. ok is false when the item is beyond every window
+func ReminderWindow(windows []int, daysLeft int) (window int, ok bool) {
+	if daysLeft < 0 {
+		return 0, true
+	}
+	for _, w := range windows {
+		if w > 0 && daysLeft <= w && (!ok || w < window) {
+			window, ok = w, true
+		}
+	}
+	return
+}
+
+// ComplianceSent is a struct that represents a reminder sent, so an item is reminded once per window of each expiry
+type ComplianceSent struct {
+	// ItemID is the id of the item
+	ItemID int
+	// Expires is the expiry reminded, a renewed item is reminded again
+	Expires time.Time
+	// Window is the window reminded
+	Window int
+	// SentAt is when the reminder was sent
+	SentAt time.Time
+}
+
+// ComplianceData is a struct that represents the compliance items and the reminders sent persisted together
+type ComplianceData struct {
+	// Items is the set of items by id
+	Items map[int]ComplianceItem
+	// Sent is the list of reminders sent, oldest first
+	Sent []ComplianceSent
+}
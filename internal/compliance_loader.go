@@ -0,0 +1,10 @@
+package internal
+
+// ComplianceLoader is an interface that represents the loader for compliance items and reminders sent
+type ComplianceLoader interface {
+	// Load is a method that loads the items and reminders sent
+	Load() (d ComplianceData, err error)
+
+	// Save is a method that saves the items and reminders sent
+	Save(d ComplianceData) (err error)
+}
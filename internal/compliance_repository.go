@@ -0,0 +1,28 @@
+package internal
+
+// ComplianceRepository is an interface that represents a repository of compliance items and reminders sent
+type ComplianceRepository interface {
+	// FindAll is a method that returns every item, ordered by id
+	FindAll() (i []ComplianceItem, err error)
+
+	// FindByVehicle is a method that returns the items of a vehicle, soonest expiry first
+	FindByVehicle(vehicleID int) (i []ComplianceItem, err error)
+
+	// FindByID is a method that returns an item by id
+	FindByID(id int) (i ComplianceItem, err error)
+
+	// Create is a method that creates an item
+	Create(i *ComplianceItem) (err error)
+
+	// Update is a method that applies fn to an item and saves the result, nothing is saved when fn fails
+	Update(id int, fn func(i *ComplianceItem) error) (i ComplianceItem, err error)
+
+	// Delete is a method that deletes the items matching a condition and returns them
+	Delete(match func(i ComplianceItem) bool) (i []ComplianceItem, err error)
+
+	// FindSent is a method that returns the reminders sent, oldest first
+	FindSent() (s []ComplianceSent, err error)
+
+	// AddSent is a method that records reminders sent, the records of deleted or renewed items are dropped
+	AddSent(s []ComplianceSent) (err error)
+}
@@ -0,0 +1,30 @@
+package internal
+
+import (
+	"context"
+	"time"
+)
+
+// ComplianceService is an interface that represents a service of compliance items and their reminders
+type ComplianceService interface {
+	// FindByVehicle is a method that returns the items of a vehicle, soonest expiry first
+	FindByVehicle(vehicleID int) (i []ComplianceItem, err error)
+
+	// Create is a method that creates an item of an existing vehicle
+	Create(i *ComplianceItem) (err error)
+
+	// Update is a method that replaces the attributes of an item of a vehicle, e.g. when it is renewed
+	Update(vehicleID, id int, i ComplianceItem) (updated ComplianceItem, err error)
+
+	// Delete is a method that deletes an item of a vehicle
+	Delete(vehicleID, id int) (err error)
+
+	// DeleteByVehicle is a method that deletes the items of a vehicle
+	DeleteByVehicle(vehicleID int) (err error)
+
+	// Expiring is a method that returns the items expired at the day of at or expiring within the given days, soonest first
+	Expiring(at time.Time, within int) (e []ComplianceExpiry, err error)
+
+	// Remind is a method that notifies the expiries that entered a reminder window since they were last reminded and returns them
+	Remind(ctx context.Context, at time.Time) (r []ComplianceReminder, err error)
+}
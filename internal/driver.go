@@ -0,0 +1,199 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrDriverInvalid is an error that represents a driver with invalid attributes
+	ErrDriverInvalid = errors.New("driver invalid")
+	// ErrDriverNotFound is an error that represents a driver not found
+	ErrDriverNotFound = errors.New("driver not found")
+	// ErrDriverDuplicate is an error that represents a driver with the licence number of another one
+	ErrDriverDuplicate = errors.New("driver duplicate")
+	// ErrDriverAssigned is an error that represents a driver that is driving a vehicle
+	ErrDriverAssigned = errors.New("driver assigned")
+	// ErrLicenceNotPermitted is an error that represents a driver whose licence does not permit a vehicle
+	ErrLicenceNotPermitted = errors.New("licence does not permit the vehicle")
+	// ErrAssignmentNotFound is an error that represents a vehicle without a driver
+	ErrAssignmentNotFound = errors.New("assignment not found")
+	// ErrAssignmentInvalid is an error that represents an assignment to a vehicle that can not be driven
+	ErrAssignmentInvalid = errors.New("assignment invalid")
+)
+
+// LicenceCategory is a category of driving licence, as in the European licence
+type LicenceCategory string
+
+const (
+	// LicenceCategoryB permits vehicles up to 3500 kg with up to 8 passengers besides the driver
+	LicenceCategoryB LicenceCategory = "B"
+	// LicenceCategoryC1 permits vehicles from 3500 kg up to 7500 kg with up to 8 passengers besides the driver
+	LicenceCategoryC1 LicenceCategory = "C1"
+	// LicenceCategoryC permits vehicles over 3500 kg with up to 8 passengers besides the driver
+	LicenceCategoryC LicenceCategory = "C"
+	// LicenceCategoryD1 permits vehicles with up to 16 passengers besides the driver
+	LicenceCategoryD1 LicenceCategory = "D1"
+	// LicenceCategoryD permits vehicles with any number of passengers
+	LicenceCategoryD LicenceCategory = "D"
+)
+
+// LicenceCategories is the list of the known licence categories
+var LicenceCategories = []LicenceCategory{LicenceCategoryB, LicenceCategoryC1, LicenceCategoryC, LicenceCategoryD1, LicenceCategoryD}
+
+const (
+	// maxWeightB is the greatest weight in kg of the vehicles of category B
+	maxWeightB = 3500
+	// maxWeightC1 is the greatest weight in kg of the vehicles of category C1
+	maxWeightC1 = 7500
+	// maxCapacityB is the greatest capacity of people, driver included, of the vehicles of categories B and C
+	maxCapacityB = 9
+	// maxCapacityD1 is the greatest capacity of people, driver included, of the vehicles of category D1
+	maxCapacityD1 = 17
+)
+
+// Valid is a method that reports whether the category is known
+func (c LicenceCategory) Valid() bool {
+	for _, value := range LicenceCategories {
+		if c == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers is a method that reports whether the category permits the vehicles of another one, C covers C1 and D covers D1
+func (c LicenceCategory) Covers(required LicenceCategory) bool {
+	switch {
+	case c == required:
+		return true
+	case c == LicenceCategoryC:
+		return required == LicenceCategoryC1
+	case c == LicenceCategoryD:
+		return required == LicenceCategoryD1
+	}
+	return false
+}
+
+// RequiredLicence is a function that returns the licence category a vehicle requires by its capacity, then by its weight in kg
+func RequiredLicence(a VehicleAttributes) LicenceCategory {
+	switch {
+	case a.Capacity > maxCapacityD1:
+		return LicenceCategoryD
+	case a.Capacity > maxCapacityB:
+		return LicenceCategoryD1
+	case a.Weight > maxWeightC1:
+		return LicenceCategoryC
+	case a.Weight > maxWeightB:
+		return LicenceCategoryC1
+	}
+	return LicenceCategoryB
+}
+
+// Licence is a struct that represents a category held by a driver
+type Licence struct {
+	// Category is the category of the licence
+	Category LicenceCategory
+	// Expires is the last day the category is valid
+	Expires time.Time
+}
+
+// ValidAt is a method that reports whether the category is valid at a time
+func (l Licence) ValidAt(t time.Time) bool {
+	return t.Before(l.Expires.AddDate(0, 0, 1))
+}
+
+// Driver is a struct that represents a driver of the fleet
+type Driver struct {
+	// ID is the unique identifier of the driver
+	ID int
+	// Name is the full name of the driver
+	Name string
+	// LicenceNumber is the number of the driving licence, unique among the drivers
+	LicenceNumber string
+	// Licences is the categories held by the driver
+	Licences []Licence
+}
+
+// Validate is a method that checks the attributes of a driver are consistent
+func (d Driver) Validate() (err error) {
+	switch {
+	case strings.TrimSpace(d.Name) == "":
+		err = fmt.Errorf("%w: name is required", ErrDriverInvalid)
+	case strings.TrimSpace(d.LicenceNumber) == "":
+		err = fmt.Errorf("%w: licence number is required", ErrDriverInvalid)
+	case len(d.Licences) == 0:
+		err = fmt.Errorf("%w: at least one licence category is required", ErrDriverInvalid)
+	}
+	if err != nil {
+		return
+	}
+
+	seen := make(map[LicenceCategory]bool)
+	for _, l := range d.Licences {
+		switch {
+		case !l.Category.Valid():
+			err = fmt.Errorf("%w: licence category %q is unknown", ErrDriverInvalid, l.Category)
+		case seen[l.Category]:
+			err = fmt.Errorf("%w: licence category %s is repeated", ErrDriverInvalid, l.Category)
+		case l.Expires.IsZero():
+			err = fmt.Errorf("%w: licence category %s requires an expiry date", ErrDriverInvalid, l.Category)
+		}
+		if err != nil {
+			return
+		}
+		seen[l.Category] = true
+	}
+	return
+}
+
+// Permits is a method that checks the driver holds a category valid at a time that permits a vehicle,
+// it fails with ErrLicenceNotPermitted otherwise
+func (d Driver) Permits(a VehicleAttributes, at time.Time) (err error) {
+	required := RequiredLicence(a)
+	expired := false
+	for _, l := range d.Licences {
+		if !l.Category.Covers(required) {
+			continue
+		}
+		if l.ValidAt(at) {
+			return
+		}
+		expired = true
+	}
+	if expired {
+		err = fmt.Errorf("%w: category %s of driver %d has expired", ErrLicenceNotPermitted, required, d.ID)
+		return
+	}
+	err = fmt.Errorf("%w: the vehicle requires category %s, which driver %d does not hold", ErrLicenceNotPermitted, required, d.ID)
+	return
+}
+
+// Assignment is a struct that represents a driver assigned to a vehicle over [From, To)
+type Assignment struct {
+	// ID is the unique identifier of the assignment
+	ID int
+	// DriverID is the id of the driver
+	DriverID int
+	// VehicleID is the id of the vehicle
+	VehicleID int
+	// From is the time the driver was assigned
+	From time.Time
+	// To is the time the assignment ended, zero while it is current
+	To time.Time
+}
+
+// Current is a method that reports whether the assignment has not ended
+func (a Assignment) Current() bool {
+	return a.To.IsZero()
+}
+
+// DriverData is a struct that represents the drivers and their assignments persisted together
+type DriverData struct {
+	// Drivers is the set of drivers by id
+	Drivers map[int]Driver
+	// Assignments is the set of assignments by id
+	Assignments map[int]Assignment
+}
@@ -0,0 +1,10 @@
+package internal
+
+// DriverLoader is an interface that represents the loader for drivers and their assignments
+type DriverLoader interface {
+	// Load is a method that loads the drivers and assignments
+	Load() (d DriverData, err error)
+
+	// Save is a method that saves the drivers and assignments
+	Save(d DriverData) (err error)
+}
@@ -0,0 +1,31 @@
+package internal
+
+import "time"
+
+// DriverRepository is an interface that represents a repository of drivers and their assignments
+type DriverRepository interface {
+	// FindAll is a method that returns every driver, ordered by id
+	FindAll() (d []Driver, err error)
+
+	// FindByID is a method that returns a driver by id
+	FindByID(id int) (d Driver, err error)
+
+	// Create is a method that creates a driver, it fails with ErrDriverDuplicate when the licence number is taken
+	Create(d *Driver) (err error)
+
+	// Update is a method that changes a driver with update and saves it, nothing changes when update fails
+	Update(id int, update func(d *Driver) (err error)) (d Driver, err error)
+
+	// Delete is a method that deletes a driver, it fails with ErrDriverAssigned when the driver has a current assignment
+	Delete(id int) (err error)
+
+	// FindAssignments is a method that returns the assignments of a vehicle and of a driver, zero ids match any, oldest first
+	FindAssignments(vehicleID, driverID int) (a []Assignment, err error)
+
+	// Assign is a method that assigns a driver to a vehicle at a time once check accepts the driver, ending the current assignment
+	// of the vehicle. It fails with ErrDriverAssigned when the driver has a current assignment to another vehicle
+	Assign(a *Assignment, check func(d Driver) (err error)) (err error)
+
+	// Unassign is a method that ends the current assignment of a vehicle at a time, it fails with ErrAssignmentNotFound when there is none
+	Unassign(vehicleID int, at time.Time) (a Assignment, err error)
+}
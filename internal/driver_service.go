@@ -0,0 +1,31 @@
+package internal
+
+// DriverService is an interface that represents a service of drivers and their assignments
+type DriverService interface {
+	// FindAll is a method that returns every driver, ordered by id
+	FindAll() (d []Driver, err error)
+
+	// FindByID is a method that returns a driver by id
+	FindByID(id int) (d Driver, err error)
+
+	// Create is a method that creates a driver
+	Create(d *Driver) (err error)
+
+	// Update is a method that replaces the attributes of a driver
+	Update(id int, d Driver) (u Driver, err error)
+
+	// Delete is a method that deletes a driver without a current assignment
+	Delete(id int) (err error)
+
+	// Assign is a method that assigns a driver to a vehicle, it fails with ErrLicenceNotPermitted when the licence does not permit the vehicle
+	Assign(vehicleID, driverID int) (a Assignment, err error)
+
+	// Unassign is a method that ends the current assignment of a vehicle
+	Unassign(vehicleID int) (a Assignment, err error)
+
+	// FindAssignmentsByVehicle is a method that returns the assignments of a vehicle, oldest first
+	FindAssignmentsByVehicle(vehicleID int) (a []Assignment, err error)
+
+	// FindAssignmentsByDriver is a method that returns the assignments of a driver, oldest first
+	FindAssignmentsByDriver(driverID int) (a []Assignment, err error)
+}
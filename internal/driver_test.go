@@ -0,0 +1,128 @@
+package internal
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequiredLicence(t *testing.T) {
+	tests := []struct {
+		name  string
+		attrs VehicleAttributes
+		want  LicenceCategory
+	}{
+		{name: "car", attrs: VehicleAttributes{Weight: 1500, Capacity: 5}, want: LicenceCategoryB},
+		{name: "greatest of B", attrs: VehicleAttributes{Weight: 3500, Capacity: 9}, want: LicenceCategoryB},
+		{name: "light truck", attrs: VehicleAttributes{Weight: 3501, Capacity: 3}, want: LicenceCategoryC1},
+		{name: "greatest of C1", attrs: VehicleAttributes{Weight: 7500, Capacity: 3}, want: LicenceCategoryC1},
+		{name: "truck", attrs: VehicleAttributes{Weight: 18000, Capacity: 2}, want: LicenceCategoryC},
+		{name: "minibus", attrs: VehicleAttributes{Weight: 3000, Capacity: 10}, want: LicenceCategoryD1},
+		{name: "heavy minibus", attrs: VehicleAttributes{Weight: 9000, Capacity: 17}, want: LicenceCategoryD1},
+		{name: "bus", attrs: VehicleAttributes{Weight: 12000, Capacity: 50}, want: LicenceCategoryD},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RequiredLicence(tt.attrs); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLicenceCategory_Covers(t *testing.T) {
+	tests := []struct {
+		held, required LicenceCategory
+		want           bool
+	}{
+		{held: LicenceCategoryB, required: LicenceCategoryB, want: true},
+		{held: LicenceCategoryC, required: LicenceCategoryC1, want: true},
+		{held: LicenceCategoryD, required: LicenceCategoryD1, want: true},
+		{held: LicenceCategoryC1, required: LicenceCategoryC},
+		{held: LicenceCategoryD1, required: LicenceCategoryD},
+		{held: LicenceCategoryC, required: LicenceCategoryB},
+		{held: LicenceCategoryD, required: LicenceCategoryC1},
+	}
+	for _, tt := range tests {
+		if got := tt.held.Covers(tt.required); got != tt.want {
+			t.Errorf("%s covering %s: got %t, want %t", tt.held, tt.required, got, tt.want)
+		}
+	}
+}
+
+func TestLicence_ValidAt(t *testing.T) {
+	l := Licence{Category: LicenceCategoryB, Expires: time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)}
+
+	// - the licence is valid the whole of its last day
+	tests := []struct {
+		at   time.Time
+		want bool
+	}{
+		{at: time.Date(2026, 3, 30, 12, 0, 0, 0, time.UTC), want: true},
+		{at: time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC), want: true},
+		{at: time.Date(2026, 3, 31, 23, 59, 59, 0, time.UTC), want: true},
+		{at: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := l.ValidAt(tt.at); got != tt.want {
+			t.Errorf("at %s: got %t, want %t", tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestDriver_Validate(t *testing.T) {
+	expires := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		driver  Driver
+		wantErr error
+	}{
+		{name: "valid", driver: Driver{Name: "Ana", LicenceNumber: "X1", Licences: []Licence{{Category: LicenceCategoryB, Expires: expires}, {Category: LicenceCategoryC, Expires: expires}}}},
+		{name: "missing name", driver: Driver{Name: " ", LicenceNumber: "X1", Licences: []Licence{{Category: LicenceCategoryB, Expires: expires}}}, wantErr: ErrDriverInvalid},
+		{name: "missing licence number", driver: Driver{Name: "Ana", Licences: []Licence{{Category: LicenceCategoryB, Expires: expires}}}, wantErr: ErrDriverInvalid},
+		{name: "no category", driver: Driver{Name: "Ana", LicenceNumber: "X1"}, wantErr: ErrDriverInvalid},
+		{name: "unknown category", driver: Driver{Name: "Ana", LicenceNumber: "X1", Licences: []Licence{{Category: "Z", Expires: expires}}}, wantErr: ErrDriverInvalid},
+		{name: "repeated category", driver: Driver{Name: "Ana", LicenceNumber: "X1", Licences: []Licence{{Category: LicenceCategoryB, Expires: expires}, {Category: LicenceCategoryB, Expires: expires}}}, wantErr: ErrDriverInvalid},
+		{name: "missing expiry", driver: Driver{Name: "Ana", LicenceNumber: "X1", Licences: []Licence{{Category: LicenceCategoryB}}}, wantErr: ErrDriverInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.driver.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDriver_Permits(t *testing.T) {
+	at := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	d := Driver{ID: 1, Licences: []Licence{
+		{Category: LicenceCategoryB, Expires: at.AddDate(1, 0, 0)},
+		{Category: LicenceCategoryC, Expires: at.AddDate(0, 0, -1)},
+		{Category: LicenceCategoryD1, Expires: at},
+	}}
+
+	tests := []struct {
+		name    string
+		attrs   VehicleAttributes
+		wantErr error
+		wantMsg string
+	}{
+		{name: "category held", attrs: VehicleAttributes{Weight: 1500, Capacity: 5}},
+		{name: "category held expiring that day", attrs: VehicleAttributes{Weight: 3000, Capacity: 12}},
+		{name: "category covering an expired one", attrs: VehicleAttributes{Weight: 5000, Capacity: 3}, wantErr: ErrLicenceNotPermitted, wantMsg: "expired"},
+		{name: "category not held", attrs: VehicleAttributes{Weight: 12000, Capacity: 50}, wantErr: ErrLicenceNotPermitted, wantMsg: "does not hold"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := d.Permits(tt.attrs, at)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("got %q, want it to tell %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package internal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"time"
+)
+
+// EventType is the type of a domain event
+type EventType string
+
+const (
+	// EventVehicleCreated is the type of the event of a vehicle created
+	EventVehicleCreated EventType = "vehicle.created"
+	// EventVehicleUpdated is the type of the event of the attributes of a vehicle changed
+	EventVehicleUpdated EventType = "vehicle.updated"
+	// EventVehicleDeleted is the type of the event of a vehicle deleted
+	EventVehicleDeleted EventType = "vehicle.deleted"
+	// EventVehicleStatusChanged is the type of the event of the status of a vehicle changed
+	EventVehicleStatusChanged EventType = "vehicle.status_changed"
+	// EventVehiclesReset is the type of the event of every vehicle swapped at once, by a restore or a reload
+	EventVehiclesReset EventType = "vehicles.reset"
+)
+
+// EventTypes is the list of every event type
+var EventTypes = []EventType{EventVehicleCreated, EventVehicleUpdated, EventVehicleDeleted, EventVehicleStatusChanged, EventVehiclesReset}
+
+// Valid is a method that reports whether the type is known
+func (t EventType) Valid() bool {
+	for _, value := range EventTypes {
+		if t == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is a struct that represents a change of a vehicle, published once the change is saved
+type Event struct {
+	// ID is the unique identifier of the event
+	ID string
+	// Type is the type of the event
+	Type EventType
+	// OccurredAt is when the change was saved
+	OccurredAt time.Time
+	// Vehicle is the vehicle after the change, or as it was when it was deleted; empty for resets
+	Vehicle Vehicle
+	// Previous is the vehicle before the change, set for updates
+	Previous *Vehicle
+	// Transition is the change of status, set for status changes
+	Transition *StatusTransition
+	// Reset is the vehicles changed by the swap, set for resets
+	Reset *VehiclesReset
+}
+
+// ResetReason is the cause of the swap of every vehicle
+type ResetReason string
+
+const (
+	// ResetRestore is the reason of the vehicles swapped for the ones of a backup
+	ResetRestore ResetReason = "restore"
+	// ResetReload is the reason of the vehicles swapped for the ones of the reloaded file
+	ResetReload ResetReason = "reload"
+)
+
+// VehiclesReset is a struct that represents the vehicles changed when every vehicle is swapped at once.
+// Consumers tracking the vehicles resynchronise the ones listed, or the whole fleet
+type VehiclesReset struct {
+	// Reason is the cause of the swap
+	Reason ResetReason
+	// Vehicles is the number of vehicles after the swap
+	Vehicles int
+	// Created is the sorted list of the ids of the vehicles that did not exist before
+	Created []int
+	// Updated is the sorted list of the ids of the vehicles whose attributes or status changed
+	Updated []int
+	// Deleted is the sorted list of the ids of the vehicles that no longer exist
+	Deleted []int
+}
+
+// Empty is a method that reports whether no vehicle changed
+func (r VehiclesReset) Empty() bool {
+	return len(r.Created) == 0 && len(r.Updated) == 0 && len(r.Deleted) == 0
+}
+
+// NewResetEvent is a function that returns the reset event of a swap of the vehicles before for the vehicles after.
+// Vehicles are compared by their attributes and status, the history of their statuses is not compared
+func NewResetEvent(reason ResetReason, before, after map[int]Vehicle) (e Event) {
+	rs := &VehiclesReset{Reason: reason, Vehicles: len(after)}
+	for id, value := range after {
+		previous, ok := before[id]
+		switch {
+		case !ok:
+			rs.Created = append(rs.Created, id)
+		case previous.VehicleAttributes != value.VehicleAttributes || previous.Status != value.Status:
+			rs.Updated = append(rs.Updated, id)
+		}
+	}
+	for id := range before {
+		if _, ok := after[id]; !ok {
+			rs.Deleted = append(rs.Deleted, id)
+		}
+	}
+	sort.Ints(rs.Created)
+	sort.Ints(rs.Updated)
+	sort.Ints(rs.Deleted)
+
+	e = NewEvent(EventVehiclesReset, Vehicle{})
+	e.Reset = rs
+	return
+}
+
+// NewEvent is a function that returns a new event of a vehicle with a random id, occurring now
+func NewEvent(t EventType, v Vehicle) Event {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return Event{ID: hex.EncodeToString(b[:]), Type: t, OccurredAt: time.Now().UTC(), Vehicle: v}
+}
+
+// EventPublisher is an interface that represents a destination of domain events
+type EventPublisher interface {
+	// Publish is a method that publishes an event, it must not block the change that raised it
+	Publish(e Event)
+}
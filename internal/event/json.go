@@ -0,0 +1,130 @@
+package event
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// EventJSON is a struct that represents an event in JSON format, as delivered to consumers
+type EventJSON struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       DataJSON  `json:"data"`
+}
+
+// DataJSON is a struct that represents the data of an event in JSON format, resets carry no vehicle
+type DataJSON struct {
+	Vehicle    *VehicleJSON    `json:"vehicle,omitempty"`
+	Previous   *VehicleJSON    `json:"previous,omitempty"`
+	Transition *TransitionJSON `json:"transition,omitempty"`
+	Reset      *ResetJSON      `json:"reset,omitempty"`
+}
+
+// VehicleJSON is a struct that represents a vehicle of an event in JSON format
+type VehicleJSON struct {
+	ID              int     `json:"id"`
+	UID             string  `json:"uid,omitempty"`
+	Brand           string  `json:"brand"`
+	Model           string  `json:"model"`
+	Registration    string  `json:"registration"`
+	Color           string  `json:"color"`
+	FabricationYear int     `json:"year"`
+	Capacity        int     `json:"passengers"`
+	MaxSpeed        float64 `json:"max_speed"`
+	FuelType        string  `json:"fuel_type"`
+	Transmission    string  `json:"transmission"`
+	Weight          float64 `json:"weight"`
+	Height          float64 `json:"height"`
+	Length          float64 `json:"length"`
+	Width           float64 `json:"width"`
+	Status          string  `json:"status"`
+}
+
+// TransitionJSON is a struct that represents a change of status of an event in JSON format
+type TransitionJSON struct {
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	At     time.Time `json:"at"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// ResetJSON is a struct that represents the vehicles changed by a reset in JSON format
+type ResetJSON struct {
+	Reason   string `json:"reason"`
+	Vehicles int    `json:"vehicles"`
+	Created  []int  `json:"created"`
+	Updated  []int  `json:"updated"`
+	Deleted  []int  `json:"deleted"`
+}
+
+// NewEventJSON is a function that serializes an event to JSON
+func NewEventJSON(e internal.Event) EventJSON {
+	data := EventJSON{
+		ID:         e.ID,
+		Type:       string(e.Type),
+		OccurredAt: e.OccurredAt,
+	}
+	if e.Reset != nil {
+		data.Data.Reset = &ResetJSON{
+			Reason:   string(e.Reset.Reason),
+			Vehicles: e.Reset.Vehicles,
+			Created:  ids(e.Reset.Created),
+			Updated:  ids(e.Reset.Updated),
+			Deleted:  ids(e.Reset.Deleted),
+		}
+		return data
+	}
+	vehicle := newVehicleJSON(e.Vehicle)
+	data.Data.Vehicle = &vehicle
+	if e.Previous != nil {
+		previous := newVehicleJSON(*e.Previous)
+		data.Data.Previous = &previous
+	}
+	if e.Transition != nil {
+		data.Data.Transition = &TransitionJSON{
+			From:   string(e.Transition.From),
+			To:     string(e.Transition.To),
+			At:     e.Transition.At,
+			Reason: e.Transition.Reason,
+		}
+	}
+	return data
+}
+
+// Marshal is a function that encodes an event in JSON format
+func Marshal(e internal.Event) ([]byte, error) {
+	return json.Marshal(NewEventJSON(e))
+}
+
+// ids is a function that returns a list of ids, empty rather than nil so it is encoded as an array
+func ids(v []int) []int {
+	if v == nil {
+		return []int{}
+	}
+	return v
+}
+
+// newVehicleJSON is a function that serializes a vehicle to JSON
+func newVehicleJSON(v internal.Vehicle) VehicleJSON {
+	return VehicleJSON{
+		ID:              v.Id,
+		UID:             v.UID,
+		Brand:           v.Brand,
+		Model:           v.Model,
+		Registration:    v.Registration,
+		Color:           v.Color,
+		FabricationYear: v.FabricationYear,
+		Capacity:        v.Capacity,
+		MaxSpeed:        v.MaxSpeed,
+		FuelType:        v.FuelType,
+		Transmission:    v.Transmission,
+		Weight:          v.Weight,
+		Height:          v.Height,
+		Length:          v.Length,
+		Width:           v.Width,
+		Status:          string(v.Status),
+	}
+}
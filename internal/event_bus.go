@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrEventReplayUnavailable is the error returned when the events after a given one are no longer kept for replay
+	ErrEventReplayUnavailable = errors.New("event replay unavailable")
+)
+
+// EventFilter is a struct that represents the events a subscriber receives, an empty field matches every event
+type EventFilter struct {
+	// Brands is the list of the brands of the vehicles, matched ignoring case
+	Brands []string
+	// Statuses is the list of the statuses of the vehicles after the change
+	Statuses StatusFilter
+}
+
+// Matches is a method that reports whether an event passes the filter, resets pass every filter as they may change any vehicle
+func (f EventFilter) Matches(e Event) bool {
+	if e.Reset != nil {
+		return true
+	}
+	if !f.Statuses.Contains(e.Vehicle.Status) {
+		return false
+	}
+	if len(f.Brands) == 0 {
+		return true
+	}
+	for _, value := range f.Brands {
+		if strings.EqualFold(value, e.Vehicle.Brand) {
+			return true
+		}
+	}
+	return false
+}
+
+// EventSubscription is an interface that represents the events received by a subscriber of an EventBus
+type EventSubscription interface {
+	// Events is a method that returns the events published since the subscription, the channel is closed when the
+	// subscription is closed or the subscriber falls too far behind
+	Events() <-chan Event
+	// Close is a method that ends the subscription
+	Close()
+}
+
+// EventBus is an interface that represents a publish/subscribe bus of the events of the changes
+type EventBus interface {
+	EventPublisher
+	// Subscribe is a method that subscribes to the events passing the filter. When lastEventID is given, the kept events
+	// published after it are returned to be replayed first; ErrEventReplayUnavailable is returned with the subscription
+	// when it is no longer kept, so the subscriber knows it missed events
+	Subscribe(f EventFilter, lastEventID string) (replay []Event, s EventSubscription, err error)
+}
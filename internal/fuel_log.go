@@ -0,0 +1,254 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrFuelLogInvalid is an error that represents a fuel log entry with invalid attributes
+	ErrFuelLogInvalid = errors.New("fuel log entry invalid")
+	// ErrOdometerNotMonotonic is an error that represents an odometer reading lower than an earlier one or greater than a later one
+	ErrOdometerNotMonotonic = errors.New("odometer reading not monotonic")
+)
+
+// FuelUnit is the unit of the energy put in a vehicle
+type FuelUnit string
+
+const (
+	// FuelUnitLitre is the unit of liquid and gas fuels
+	FuelUnitLitre FuelUnit = "l"
+	// FuelUnitKWh is the unit of electric charges
+	FuelUnitKWh FuelUnit = "kWh"
+)
+
+// ParseFuelUnit is a function that parses a unit ignoring case, an empty unit is the unit of the fuel type of a vehicle
+func ParseFuelUnit(s, fuelType string) (u FuelUnit, err error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "":
+		u = DefaultFuelUnit(fuelType)
+	case "l", "litre", "litres", "liter", "liters":
+		u = FuelUnitLitre
+	case "kwh":
+		u = FuelUnitKWh
+	default:
+		err = fmt.Errorf("%w: unit %q is unknown, expected l or kWh", ErrFuelLogInvalid, s)
+	}
+	return
+}
+
+// DefaultFuelUnit is a function that returns the unit of a fuel type, kWh for electric vehicles and litres otherwise
+func DefaultFuelUnit(fuelType string) FuelUnit {
+	if strings.EqualFold(fuelType, "electric") {
+		return FuelUnitKWh
+	}
+	return FuelUnitLitre
+}
+
+// FuelLogEntry is a struct that represents an odometer reading of a vehicle, with the fill made at that reading if any
+type FuelLogEntry struct {
+	// ID is the unique identifier of the entry
+	ID int
+	// VehicleID is the id of the vehicle
+	VehicleID int
+	// Date is the time of the reading
+	Date time.Time
+	// Odometer is the reading of the odometer in km
+	Odometer int
+	// Quantity is the energy put in the vehicle, zero for a reading without a fill
+	Quantity float64
+	// Unit is the unit of the quantity
+	Unit FuelUnit
+	// Cost is the cost of the fill
+	Cost float64
+	// Station is the name of the station of the fill
+	Station string
+}
+
+// Fill is a method that reports whether the entry is a fill rather than a plain reading
+func (e FuelLogEntry) Fill() bool {
+	return e.Quantity > 0
+}
+
+// Validate is a method that checks the attributes of an entry are consistent
+func (e FuelLogEntry) Validate() (err error) {
+	switch {
+	case e.VehicleID <= 0:
+		err = fmt.Errorf("%w: vehicle id must be positive", ErrFuelLogInvalid)
+	case e.Date.IsZero():
+		err = fmt.Errorf("%w: date is required", ErrFuelLogInvalid)
+	case e.Odometer < 0:
+		err = fmt.Errorf("%w: odometer must not be negative", ErrFuelLogInvalid)
+	case e.Quantity < 0 || math.IsNaN(e.Quantity) || math.IsInf(e.Quantity, 0):
+		err = fmt.Errorf("%w: quantity must be a non-negative number", ErrFuelLogInvalid)
+	case e.Unit != FuelUnitLitre && e.Unit != FuelUnitKWh:
+		err = fmt.Errorf("%w: unit %q is unknown", ErrFuelLogInvalid, e.Unit)
+	case e.Cost < 0 || math.IsNaN(e.Cost) || math.IsInf(e.Cost, 0):
+		err = fmt.Errorf("%w: cost must be a non-negative number", ErrFuelLogInvalid)
+	case !e.Fill() && e.Cost > 0:
+		err = fmt.Errorf("%w: a reading without quantity can not have a cost", ErrFuelLogInvalid)
+	}
+	return
+}
+
+// CheckFuelLogEntry is a function that checks an entry fits the log of its vehicle: the odometer must not go back
+// from the earlier entries nor pass the later ones, and every fill of a vehicle is in the same unit
+func CheckFuelLogEntry(log []FuelLogEntry, e FuelLogEntry) (err error) {
+	for _, value := range log {
+		switch {
+		case e.Fill() && value.Fill() && value.Unit != e.Unit:
+			err = fmt.Errorf("%w: the vehicle is filled in %s", ErrFuelLogInvalid, value.Unit)
+		case !value.Date.After(e.Date) && value.Odometer > e.Odometer:
+			err = fmt.Errorf("%w: %d km on %s is lower than %d km of entry %d on %s", ErrOdometerNotMonotonic,
+				e.Odometer, e.Date.Format(time.RFC3339), value.Odometer, value.ID, value.Date.Format(time.RFC3339))
+		case value.Date.After(e.Date) && value.Odometer < e.Odometer:
+			err = fmt.Errorf("%w: %d km on %s is greater than %d km of entry %d on %s", ErrOdometerNotMonotonic,
+				e.Odometer, e.Date.Format(time.RFC3339), value.Odometer, value.ID, value.Date.Format(time.RFC3339))
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+const (
+	// FuelAnomalyFactor is how many times the median consumption of a vehicle a segment must exceed to be a spike
+	FuelAnomalyFactor = 1.5
+	// fuelAnomalyMinSegments is the least number of segments of a vehicle to look for spikes
+	fuelAnomalyMinSegments = 3
+)
+
+// FuelSegment is a struct that represents the distance driven between two fills and the energy the second one replaced
+type FuelSegment struct {
+	// FromID is the id of the first fill
+	FromID int
+	// ToID is the id of the second fill
+	ToID int
+	// Distance is the distance in km
+	Distance int
+	// Quantity is the energy of the second fill
+	Quantity float64
+	// Cost is the cost of the second fill
+	Cost float64
+	// Consumption is the energy per 100 km
+	Consumption float64
+	// Anomaly reports whether the consumption is a spike over the usual consumption of the vehicle
+	Anomaly bool
+}
+
+// FuelTotals is a struct that represents the distance, energy and cost of a set of segments
+type FuelTotals struct {
+	// Unit is the unit of the energy
+	Unit FuelUnit
+	// Distance is the distance in km
+	Distance int
+	// Quantity is the energy
+	Quantity float64
+	// Cost is the cost
+	Cost float64
+	// Anomalies is the number of segments that are spikes
+	Anomalies int
+}
+
+// Add is a method that adds a segment to the totals
+func (t *FuelTotals) Add(s FuelSegment) {
+	t.Distance += s.Distance
+	t.Quantity += s.Quantity
+	t.Cost += s.Cost
+	if s.Anomaly {
+		t.Anomalies++
+	}
+}
+
+// Consumption is a method that returns the energy per 100 km, zero without distance
+func (t FuelTotals) Consumption() float64 {
+	if t.Distance == 0 {
+		return 0
+	}
+	return t.Quantity * 100 / float64(t.Distance)
+}
+
+// CostPerKm is a method that returns the cost per km, zero without distance
+func (t FuelTotals) CostPerKm() float64 {
+	if t.Distance == 0 {
+		return 0
+	}
+	return t.Cost / float64(t.Distance)
+}
+
+// FuelStats is a struct that represents the consumption of a vehicle
+type FuelStats struct {
+	FuelTotals
+	// VehicleID is the id of the vehicle
+	VehicleID int
+	// Odometer is the last reading of the vehicle, zero without entries
+	Odometer int
+	// Segments is the segments between consecutive fills, oldest first
+	Segments []FuelSegment
+}
+
+// NewFuelStats is a function that computes the consumption of a vehicle from its log ordered by date. Each fill is taken to
+// replace the energy used since the previous one, so the first fill opens the log and only the later ones count.
+// A segment is a spike when it exceeds FuelAnomalyFactor times the median of the vehicle, given enough segments
+func NewFuelStats(vehicleID int, log []FuelLogEntry) (s FuelStats) {
+	s = FuelStats{VehicleID: vehicleID, Segments: make([]FuelSegment, 0)}
+	var prev *FuelLogEntry
+	for i, e := range log {
+		s.Odometer = max(s.Odometer, e.Odometer)
+		if !e.Fill() {
+			continue
+		}
+		s.Unit = e.Unit
+		if prev != nil && e.Odometer > prev.Odometer {
+			seg := FuelSegment{FromID: prev.ID, ToID: e.ID, Distance: e.Odometer - prev.Odometer, Quantity: e.Quantity, Cost: e.Cost}
+			seg.Consumption = seg.Quantity * 100 / float64(seg.Distance)
+			s.Segments = append(s.Segments, seg)
+		} else if prev != nil {
+			// - a fill at the same reading tops up the previous one
+			if n := len(s.Segments); n > 0 {
+				s.Segments[n-1].Quantity += e.Quantity
+				s.Segments[n-1].Cost += e.Cost
+				s.Segments[n-1].Consumption = s.Segments[n-1].Quantity * 100 / float64(s.Segments[n-1].Distance)
+			}
+		}
+		prev = &log[i]
+	}
+
+	if len(s.Segments) >= fuelAnomalyMinSegments {
+		median := medianConsumption(s.Segments)
+		for i := range s.Segments {
+			s.Segments[i].Anomaly = s.Segments[i].Consumption > median*FuelAnomalyFactor
+		}
+	}
+	for _, seg := range s.Segments {
+		s.Add(seg)
+	}
+	return
+}
+
+// medianConsumption is a function that returns the median consumption of segments
+func medianConsumption(segments []FuelSegment) float64 {
+	c := make([]float64, 0, len(segments))
+	for _, s := range segments {
+		c = append(c, s.Consumption)
+	}
+	sort.Float64s(c)
+	if n := len(c); n%2 == 0 {
+		return (c[n/2-1] + c[n/2]) / 2
+	}
+	return c[len(c)/2]
+}
+
+// FuelGroupStats is a struct that represents the consumption of the vehicles sharing an attribute
+type FuelGroupStats struct {
+	FuelTotals
+	// Value is the value of the attribute
+	Value string
+	// Vehicles is the number of vehicles with segments
+	Vehicles int
+}
@@ -0,0 +1,10 @@
+package internal
+
+// FuelLogLoader is an interface that represents the loader for fuel log entries
+type FuelLogLoader interface {
+	// Load is a method that loads the entries
+	Load() (e map[int]FuelLogEntry, err error)
+
+	// Save is a method that saves the entries
+	Save(e map[int]FuelLogEntry) (err error)
+}
@@ -0,0 +1,13 @@
+package internal
+
+// FuelLogRepository is an interface that represents a repository of fuel log entries
+type FuelLogRepository interface {
+	// FindAll is a method that returns every entry, ordered by date
+	FindAll() (e []FuelLogEntry, err error)
+
+	// FindByVehicle is a method that returns the entries of a vehicle, ordered by date
+	FindByVehicle(vehicleID int) (e []FuelLogEntry, err error)
+
+	// Create is a method that creates an entry once check accepts it against the entries of its vehicle
+	Create(e *FuelLogEntry, check func(log []FuelLogEntry, e FuelLogEntry) (err error)) (err error)
+}
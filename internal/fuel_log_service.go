@@ -0,0 +1,23 @@
+package internal
+
+import "errors"
+
+var (
+	// ErrFuelGroupInvalid is an error that represents an attribute the consumption can not be grouped by
+	ErrFuelGroupInvalid = errors.New("fuel group invalid")
+)
+
+// FuelLogService is an interface that represents a service of fuel logs and consumption
+type FuelLogService interface {
+	// FindByVehicle is a method that returns the entries of a vehicle, ordered by date
+	FindByVehicle(vehicleID int) (e []FuelLogEntry, err error)
+
+	// Create is a method that creates an entry, it fails with ErrOdometerNotMonotonic when the reading goes back
+	Create(e *FuelLogEntry) (err error)
+
+	// Stats is a method that returns the consumption of a vehicle
+	Stats(vehicleID int) (s FuelStats, err error)
+
+	// GroupStats is a method that returns the consumption of the vehicles grouped by brand, model or fuel type, and by unit
+	GroupStats(field string) (s []FuelGroupStats, err error)
+}
@@ -0,0 +1,173 @@
+package internal
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// near is a function that reports whether two amounts are equal but for rounding
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestCheckFuelLogEntry(t *testing.T) {
+	// fills in litres on the 1st and the 10th, a reading on the 20th
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 9, 0, 0, 0, time.UTC) }
+	log := []FuelLogEntry{
+		{ID: 1, VehicleID: 1, Date: day(1), Odometer: 1000, Quantity: 40, Unit: FuelUnitLitre},
+		{ID: 2, VehicleID: 1, Date: day(10), Odometer: 2000, Quantity: 35, Unit: FuelUnitLitre},
+		{ID: 3, VehicleID: 1, Date: day(20), Odometer: 3000, Unit: FuelUnitLitre},
+	}
+
+	tests := []struct {
+		name     string
+		date     time.Time
+		odometer int
+		quantity float64
+		unit     FuelUnit
+		wantErr  error
+	}{
+		{name: "after the last", date: day(25), odometer: 3500},
+		{name: "after the last at the same reading", date: day(25), odometer: 3000},
+		{name: "after the last, lower", date: day(25), odometer: 2999, wantErr: ErrOdometerNotMonotonic},
+		{name: "back-dated between two readings", date: day(5), odometer: 1500},
+		{name: "back-dated at the earlier reading", date: day(5), odometer: 1000},
+		{name: "back-dated at the later reading", date: day(5), odometer: 2000},
+		{name: "back-dated, lower than the earlier reading", date: day(5), odometer: 999, wantErr: ErrOdometerNotMonotonic},
+		{name: "back-dated, greater than the later reading", date: day(5), odometer: 2001, wantErr: ErrOdometerNotMonotonic},
+		{name: "at the time of a reading, lower", date: day(10), odometer: 1999, wantErr: ErrOdometerNotMonotonic},
+		{name: "before the first, greater", date: day(1).Add(-time.Hour), odometer: 1001, wantErr: ErrOdometerNotMonotonic},
+		{name: "fill in the same unit", date: day(25), odometer: 3500, quantity: 30},
+		{name: "fill in another unit", date: day(25), odometer: 3500, quantity: 30, unit: FuelUnitKWh, wantErr: ErrFuelLogInvalid},
+		{name: "reading in another unit", date: day(25), odometer: 3500, unit: FuelUnitKWh},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit := tt.unit
+			if unit == "" {
+				unit = FuelUnitLitre
+			}
+			e := FuelLogEntry{VehicleID: 1, Date: tt.date, Odometer: tt.odometer, Quantity: tt.quantity, Unit: unit}
+			if err := CheckFuelLogEntry(log, e); !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewFuelStats(t *testing.T) {
+	// entry is a function that returns an entry of vehicle 1 in litres at 1.5 per litre, a reading when quantity is zero
+	entry := func(id, odometer int, quantity float64) FuelLogEntry {
+		return FuelLogEntry{ID: id, VehicleID: 1, Odometer: odometer, Quantity: quantity, Unit: FuelUnitLitre, Cost: quantity * 1.5}
+	}
+
+	tests := []struct {
+		name            string
+		log             []FuelLogEntry
+		wantSegments    []FuelSegment
+		wantTotals      FuelTotals
+		wantOdometer    int
+		wantConsumption float64
+		wantCostPerKm   float64
+	}{
+		{
+			name: "segments between fills, a spike over the median",
+			// the first fill opens the log, a reading is skipped, a fill at the same reading tops up the previous one
+			log: []FuelLogEntry{entry(1, 1000, 40), entry(2, 1500, 35), entry(3, 1700, 0), entry(4, 2000, 30), entry(5, 2000, 5), entry(6, 2500, 60)},
+			wantSegments: []FuelSegment{
+				{FromID: 1, ToID: 2, Distance: 500, Quantity: 35, Cost: 52.5, Consumption: 7},
+				{FromID: 2, ToID: 4, Distance: 500, Quantity: 35, Cost: 52.5, Consumption: 7},
+				{FromID: 5, ToID: 6, Distance: 500, Quantity: 60, Cost: 90, Consumption: 12, Anomaly: true},
+			},
+			wantTotals:      FuelTotals{Unit: FuelUnitLitre, Distance: 1500, Quantity: 130, Cost: 195, Anomalies: 1},
+			wantOdometer:    2500,
+			wantConsumption: 130 * 100 / 1500.0,
+			wantCostPerKm:   0.13,
+		},
+		{
+			name: "at the spike factor",
+			log:  []FuelLogEntry{entry(1, 0, 10), entry(2, 100, 6), entry(3, 200, 6), entry(4, 300, 9)},
+			wantSegments: []FuelSegment{
+				{FromID: 1, ToID: 2, Distance: 100, Quantity: 6, Cost: 9, Consumption: 6},
+				{FromID: 2, ToID: 3, Distance: 100, Quantity: 6, Cost: 9, Consumption: 6},
+				{FromID: 3, ToID: 4, Distance: 100, Quantity: 9, Cost: 13.5, Consumption: 9},
+			},
+			wantTotals:      FuelTotals{Unit: FuelUnitLitre, Distance: 300, Quantity: 21, Cost: 31.5},
+			wantOdometer:    300,
+			wantConsumption: 7,
+			wantCostPerKm:   0.105,
+		},
+		{
+			name: "too few segments for spikes",
+			log:  []FuelLogEntry{entry(1, 0, 10), entry(2, 100, 5), entry(3, 200, 50)},
+			wantSegments: []FuelSegment{
+				{FromID: 1, ToID: 2, Distance: 100, Quantity: 5, Cost: 7.5, Consumption: 5},
+				{FromID: 2, ToID: 3, Distance: 100, Quantity: 50, Cost: 75, Consumption: 50},
+			},
+			wantTotals:      FuelTotals{Unit: FuelUnitLitre, Distance: 200, Quantity: 55, Cost: 82.5},
+			wantOdometer:    200,
+			wantConsumption: 27.5,
+			wantCostPerKm:   0.4125,
+		},
+		{
+			name:         "readings only",
+			log:          []FuelLogEntry{entry(1, 100, 0), entry(2, 300, 0)},
+			wantSegments: []FuelSegment{},
+			wantOdometer: 300,
+		},
+		{
+			name:         "no entries",
+			wantSegments: []FuelSegment{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewFuelStats(1, tt.log)
+
+			if len(s.Segments) != len(tt.wantSegments) {
+				t.Fatalf("got segments %+v, want %+v", s.Segments, tt.wantSegments)
+			}
+			for i, want := range tt.wantSegments {
+				got := s.Segments[i]
+				if got.FromID != want.FromID || got.ToID != want.ToID || got.Distance != want.Distance || !near(got.Quantity, want.Quantity) ||
+					!near(got.Cost, want.Cost) || !near(got.Consumption, want.Consumption) || got.Anomaly != want.Anomaly {
+					t.Errorf("got segment %+v, want %+v", got, want)
+				}
+			}
+			got := s.FuelTotals
+			if got.Unit != tt.wantTotals.Unit || got.Distance != tt.wantTotals.Distance || !near(got.Quantity, tt.wantTotals.Quantity) ||
+				!near(got.Cost, tt.wantTotals.Cost) || got.Anomalies != tt.wantTotals.Anomalies {
+				t.Errorf("got totals %+v, want %+v", got, tt.wantTotals)
+			}
+			if s.VehicleID != 1 || s.Odometer != tt.wantOdometer {
+				t.Errorf("got vehicle %d at %d km, want 1 at %d km", s.VehicleID, s.Odometer, tt.wantOdometer)
+			}
+			if !near(s.Consumption(), tt.wantConsumption) || !near(s.CostPerKm(), tt.wantCostPerKm) {
+				t.Errorf("got %v per 100 km and %v per km, want %v and %v", s.Consumption(), s.CostPerKm(), tt.wantConsumption, tt.wantCostPerKm)
+			}
+		})
+	}
+}
+
+func TestParseFuelUnit(t *testing.T) {
+	tests := []struct {
+		value    string
+		fuelType string
+		want     FuelUnit
+		wantErr  error
+	}{
+		{value: "", fuelType: "diesel", want: FuelUnitLitre},
+		{value: "", fuelType: "Electric", want: FuelUnitKWh},
+		{value: " Litres ", fuelType: "electric", want: FuelUnitLitre},
+		{value: "KWH", fuelType: "gasoline", want: FuelUnitKWh},
+		{value: "gallons", fuelType: "gasoline", wantErr: ErrFuelLogInvalid},
+	}
+	for _, tt := range tests {
+		got, err := ParseFuelUnit(tt.value, tt.fuelType)
+		if !errors.Is(err, tt.wantErr) || got != tt.want {
+			t.Errorf("%q of %s: got %q, %v, want %q, %v", tt.value, tt.fuelType, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
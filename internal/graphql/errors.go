@@ -0,0 +1,24 @@
+package graphql
+
+// Location is a struct that represents a position in a document
+type Location struct {
+	// Line is the line, from 1
+	Line int `json:"line"`
+	// Column is the column in characters, from 1
+	Column int `json:"column"`
+}
+
+// Error is a struct that represents an error of a request, as written in the errors of a response
+type Error struct {
+	// Message is the description of the error
+	Message string `json:"message"`
+	// Locations is the list of the positions in the document the error refers to
+	Locations []Location `json:"locations,omitempty"`
+	// Path is the path to the field of the response the error refers to, keys and list indexes
+	Path []any `json:"path,omitempty"`
+}
+
+// Error is a method that returns the message of the error
+func (e *Error) Error() string {
+	return e.Message
+}
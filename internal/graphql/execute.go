@@ -0,0 +1,267 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+)
+
+// Result is a struct that represents the response of an executed operation
+type Result struct {
+	// Errors is the list of the errors raised by fields, their values are null
+	Errors []*Error `json:"errors,omitempty"`
+	// Data is the value of the operation, null when a non-null root field failed
+	Data any `json:"data"`
+}
+
+// Execute is a method that executes the operation, resolving its fields in the order of the document
+func (p *Prepared) Execute(ctx context.Context) (r *Result) {
+	e := &executor{ctx: ctx, doc: p.doc, vars: p.vars}
+	root := p.schema.Query
+	if p.op.Type == "mutation" {
+		root = p.schema.Mutation
+	}
+	r = &Result{}
+	if m, ok := e.selectionSet(root, nil, p.op.Selections, nil); ok {
+		r.Data = m
+	}
+	r.Errors = e.errs
+	return
+}
+
+// fieldGroup is a struct that represents the fields of a selection set sharing a response key, resolved once
+type fieldGroup struct {
+	// key is the response key
+	key string
+	// fields is the list of the fields, their selections are merged
+	fields []*Field
+}
+
+// executor is a struct that resolves the selections of an operation
+type executor struct {
+	// ctx is the context of the request
+	ctx context.Context
+	// doc is the document
+	doc *Document
+	// vars is the set of the variables, coerced to their types
+	vars map[string]any
+	// errs is the list of the errors raised by fields
+	errs []*Error
+}
+
+// fail is a method that records an error of a field
+func (e *executor) fail(fields []*Field, path []any, err error) {
+	gErr := &Error{Message: err.Error(), Locations: []Location{fields[0].Loc}}
+	gErr.Path = append(gErr.Path, path...)
+	e.errs = append(e.errs, gErr)
+}
+
+// collect is a method that groups the fields of a selection set of an object by response key, in the order of the document
+func (e *executor) collect(t *Object, sels []Selection, groups []*fieldGroup, seen map[string]bool) []*fieldGroup {
+	for _, sel := range sels {
+		switch s := sel.(type) {
+		case *Field:
+			if ok, _ := included(s.Directives, e.vars); !ok {
+				continue
+			}
+			key := s.ResponseKey()
+			i := 0
+			for i < len(groups) && groups[i].key != key {
+				i++
+			}
+			if i == len(groups) {
+				groups = append(groups, &fieldGroup{key: key})
+			}
+			groups[i].fields = append(groups[i].fields, s)
+		case *FragmentSpread:
+			if ok, _ := included(s.Directives, e.vars); !ok || seen[s.Name] {
+				continue
+			}
+			f := e.doc.Fragments[s.Name]
+			if f == nil || f.TypeCondition != t.Name {
+				continue
+			}
+			seen[s.Name] = true
+			groups = e.collect(t, f.Selections, groups, seen)
+		case *InlineFragment:
+			if ok, _ := included(s.Directives, e.vars); !ok {
+				continue
+			}
+			if s.TypeCondition != "" && s.TypeCondition != t.Name {
+				continue
+			}
+			groups = e.collect(t, s.Selections, groups, seen)
+		}
+	}
+	return groups
+}
+
+// selectionSet is a method that resolves a selection set of an object, ok is false when a non-null field failed
+func (e *executor) selectionSet(t *Object, src any, sels []Selection, path []any) (m *orderedMap, ok bool) {
+	groups := e.collect(t, sels, nil, make(map[string]bool))
+	m = &orderedMap{keys: make([]string, 0, len(groups)), values: make([]any, 0, len(groups))}
+	for _, g := range groups {
+		fp := append(path[:len(path):len(path)], g.key)
+		v, fOk := e.field(t, src, g, fp)
+		if !fOk {
+			if fd := t.Field(g.fields[0].Name); fd != nil {
+				if _, nonNull := fd.Type.(*NonNull); nonNull {
+					return nil, false
+				}
+			}
+		}
+		m.keys, m.values = append(m.keys, g.key), append(m.values, v)
+	}
+	ok = true
+	return
+}
+
+// field is a method that resolves a group of fields, ok is false when it failed and its value is null
+func (e *executor) field(t *Object, src any, g *fieldGroup, path []any) (v any, ok bool) {
+	f := g.fields[0]
+	if f.Name == "__typename" {
+		return t.Name, true
+	}
+	fd := t.Field(f.Name)
+	if fd == nil {
+		e.fail(g.fields, path, fmt.Errorf("cannot query field %q on type %q", f.Name, t.Name))
+		return
+	}
+	args, err := coerceArguments(fd.Args, f.Arguments, e.vars)
+	if err != nil {
+		e.fail(g.fields, path, err)
+		return
+	}
+	if v, err = e.resolve(fd, ResolveParams{Context: e.ctx, Source: src, Args: args}); err != nil {
+		e.fail(g.fields, path, err)
+		return nil, false
+	}
+	return e.complete(fd.Type, g.fields, v, path)
+}
+
+// resolve is a method that calls the resolver of a field, a panic is an internal error
+func (e *executor) resolve(fd *FieldDefinition, p ResolveParams) (v any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("graphql: panic resolving field %s: %v", fd.Name, r)
+			v, err = nil, errors.New("internal error")
+		}
+	}()
+	if err = p.Context.Err(); err != nil {
+		return
+	}
+	if fd.Resolve == nil {
+		if m, ok := p.Source.(map[string]any); ok {
+			v = m[fd.Name]
+		}
+		return
+	}
+	v, err = fd.Resolve(p)
+	return
+}
+
+// complete is a method that converts a resolved value to its type, ok is false when it failed and the value is null
+func (e *executor) complete(t Type, fields []*Field, v any, path []any) (r any, ok bool) {
+	if nn, nonNull := t.(*NonNull); nonNull {
+		if r, ok = e.complete(nn.Of, fields, v, path); ok && r == nil {
+			e.fail(fields, path, errors.New("cannot return null for a non-null field"))
+			ok = false
+		}
+		return
+	}
+	if isNil(v) {
+		return nil, true
+	}
+
+	var err error
+	switch tt := t.(type) {
+	case *Scalar:
+		if r, err = tt.Serialize(v); err != nil {
+			e.fail(fields, path, err)
+			return nil, false
+		}
+	case *Enum:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.String || !tt.has(rv.String()) {
+			e.fail(fields, path, fmt.Errorf("enum %s cannot represent %v", tt.Name, v))
+			return nil, false
+		}
+		r = rv.String()
+	case *Object:
+		var sels []Selection
+		for _, value := range fields {
+			sels = append(sels, value.Selections...)
+		}
+		var m *orderedMap
+		if m, ok = e.selectionSet(tt, v, sels, path); !ok {
+			return nil, false
+		}
+		r = m
+	case *List:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			e.fail(fields, path, fmt.Errorf("expected a list, found %T", v))
+			return nil, false
+		}
+		_, nonNull := tt.Of.(*NonNull)
+		items := make([]any, rv.Len())
+		for i := range items {
+			var iOk bool
+			items[i], iOk = e.complete(tt.Of, fields, rv.Index(i).Interface(), append(path[:len(path):len(path)], i))
+			if !iOk && nonNull {
+				return nil, false
+			}
+		}
+		r = items
+	}
+	ok = true
+	return
+}
+
+// isNil is a function that reports whether a resolved value is null, including nil pointers, maps and slices
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// orderedMap is a struct that represents an object of a response, its keys in the order of the selections
+type orderedMap struct {
+	// keys is the list of the keys
+	keys []string
+	// values is the list of the values, by index of key
+	values []any
+}
+
+// MarshalJSON is a method that encodes the object with its keys in order
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(m.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,103 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// newTestPets is a function that returns the test schema of pets resolving the pets from maps
+func newTestPets(t *testing.T) *Schema {
+	t.Helper()
+	s := newTestSchema(t)
+	pets := []any{
+		map[string]any{"id": "1", "name": "Rex", "kind": "DOG", "owner": map[string]any{"name": "Ann"}},
+		map[string]any{"id": "2", "name": "Tom", "kind": "CAT"},
+	}
+	s.Query.Field("pets").Resolve = func(p ResolveParams) (any, error) { return pets, nil }
+	s.Query.Field("pet").Resolve = func(p ResolveParams) (any, error) {
+		// - a pet without a kind, which is non-null
+		return map[string]any{"id": p.Args["id"], "name": "Ghost"}, nil
+	}
+	return s
+}
+
+func TestPrepared_Execute(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		vars  map[string]any
+		want  string
+	}{
+		{
+			name:  "fragments and aliases",
+			query: `{ all: pets { ...P ... on Pet { name } } names: pets { name name } } fragment P on Pet { id kind }`,
+			want:  `{"data":{"all":[{"id":"1","kind":"DOG","name":"Rex"},{"id":"2","kind":"CAT","name":"Tom"}],"names":[{"name":"Rex"},{"name":"Tom"}]}}`,
+		},
+		{
+			name:  "directives",
+			query: `query($owner: Boolean!) { pets { name owner @include(if: $owner) { name } kind @skip(if: true) } }`,
+			vars:  map[string]any{"owner": true},
+			want:  `{"data":{"pets":[{"name":"Rex","owner":{"name":"Ann"}},{"name":"Tom","owner":null}]}}`,
+		},
+		{
+			name:  "null in a non-null field",
+			query: `{ pet(id: "3") { name kind } }`,
+			want:  `{"errors":[{"message":"cannot return null for a non-null field","locations":[{"line":1,"column":23}],"path":["pet","kind"]}],"data":{"pet":null}}`,
+		},
+	}
+	s := newTestPets(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, errs := s.Prepare(Request{Query: tt.query, Variables: tt.vars}, Limits{})
+			if len(errs) > 0 {
+				t.Fatalf("got errors %v", messages(errs))
+			}
+			got, err := json.Marshal(p.Execute(context.Background()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// stubVehicleService is a struct that finds the vehicles of a map, the other methods are not used
+type stubVehicleService struct {
+	internal.VehicleService
+	// db is the vehicles found
+	db map[int]internal.Vehicle
+}
+
+func (s stubVehicleService) FindByID(id int) (v internal.Vehicle, err error) {
+	v, ok := s.db[id]
+	if !ok {
+		err = internal.ErrVehicleNotFound
+	}
+	return
+}
+
+func TestVehicleSchema_UID(t *testing.T) {
+	s, err := NewVehicleSchema(stubVehicleService{db: map[int]internal.Vehicle{
+		1: {Id: 1, UID: "01ARZ3NDEKTSV4RRFFQ69G5FAV"},
+		2: {Id: 2},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, errs := s.Prepare(Request{Query: `{ a: vehicle(id: "1") { id uid } b: vehicle(id: "2") { id uid } }`}, Limits{})
+	if len(errs) > 0 {
+		t.Fatalf("got errors %v", messages(errs))
+	}
+	got, err := json.Marshal(p.Execute(context.Background()).Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"a":{"id":"1","uid":"01ARZ3NDEKTSV4RRFFQ69G5FAV"},"b":{"id":"2","uid":null}}`; string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
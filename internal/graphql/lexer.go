@@ -0,0 +1,310 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tokenKind is the kind of a lexical token of a document
+type tokenKind int
+
+const (
+	// tokenEOF is the end of the document
+	tokenEOF tokenKind = iota
+	// tokenPunct is a punctuator, such as { or ...
+	tokenPunct
+	// tokenName is a name, such as a field or a keyword
+	tokenName
+	// tokenInt is an integer literal
+	tokenInt
+	// tokenFloat is a float literal
+	tokenFloat
+	// tokenString is a string literal, its value unescaped
+	tokenString
+)
+
+// token is a struct that represents a lexical token of a document
+type token struct {
+	// kind is the kind of the token
+	kind tokenKind
+	// value is the text of the token, unescaped for strings
+	value string
+	// loc is where the token starts
+	loc Location
+}
+
+// String is a method that returns the token as shown in syntax errors
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "the end of the document"
+	case tokenString:
+		return strconv.Quote(t.value)
+	}
+	return "\"" + t.value + "\""
+}
+
+// lexer is a struct that splits a document into tokens
+type lexer struct {
+	// src is the document
+	src string
+	// pos is the offset of the next byte to read
+	pos int
+	// line is the line of pos, from 1
+	line int
+	// lineStart is the offset of the first byte of the line
+	lineStart int
+}
+
+// newLexer is a function that returns a lexer of a document
+func newLexer(src string) *lexer {
+	return &lexer{src: strings.TrimPrefix(src, "\uFEFF"), line: 1}
+}
+
+// location is a method that returns the location of an offset of the current line
+func (l *lexer) location(pos int) Location {
+	return Location{Line: l.line, Column: utf8.RuneCountInString(l.src[l.lineStart:pos]) + 1}
+}
+
+// errorf is a method that returns a syntax error at an offset of the current line
+func (l *lexer) errorf(pos int, format string, args ...any) *Error {
+	return &Error{Message: "syntax error: " + fmt.Sprintf(format, args...), Locations: []Location{l.location(pos)}}
+}
+
+// next is a method that reads the next token, ignoring whitespace, commas and comments
+func (l *lexer) next() (t token, err error) {
+	l.skip()
+	start := l.pos
+	t.loc = l.location(start)
+	if l.pos >= len(l.src) {
+		t.kind = tokenEOF
+		return
+	}
+
+	c := l.src[l.pos]
+	switch {
+	case strings.IndexByte("!$&()*:=@[]{}|", c) >= 0:
+		l.pos++
+		t.kind, t.value = tokenPunct, string(c)
+	case c == '.':
+		if !strings.HasPrefix(l.src[l.pos:], "...") {
+			err = l.errorf(start, "unexpected %q, did you mean \"...\"?", c)
+			return
+		}
+		l.pos += 3
+		t.kind, t.value = tokenPunct, "..."
+	case c == '_' || isLetter(c):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		t.kind, t.value = tokenName, l.src[start:l.pos]
+	case c == '-' || isDigit(c):
+		t, err = l.number(start)
+		t.loc = l.location(start)
+	case c == '"':
+		if strings.HasPrefix(l.src[l.pos:], `"""`) {
+			t.value, err = l.blockString(start)
+		} else {
+			t.value, err = l.string(start)
+		}
+		t.kind = tokenString
+	default:
+		r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+		err = l.errorf(start, "unexpected character %q", r)
+	}
+	return
+}
+
+// skip is a method that skips whitespace, commas and comments, which are insignificant
+func (l *lexer) skip() {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; c {
+		case ' ', '\t', ',':
+			l.pos++
+		case '\n', '\r':
+			l.pos++
+			if c == '\r' && l.pos < len(l.src) && l.src[l.pos] == '\n' {
+				l.pos++
+			}
+			l.line, l.lineStart = l.line+1, l.pos
+		case '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' && l.src[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// number is a method that reads an integer or a float literal
+func (l *lexer) number(start int) (t token, err error) {
+	t.kind = tokenInt
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+	digits := func() (n int) {
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+			n++
+		}
+		return
+	}
+	intStart := l.pos
+	if digits() == 0 {
+		err = l.errorf(start, "invalid number, expected a digit")
+		return
+	}
+	if l.src[intStart] == '0' && l.pos-intStart > 1 {
+		err = l.errorf(start, "invalid number, unexpected digit after 0")
+		return
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		l.pos++
+		t.kind = tokenFloat
+		if digits() == 0 {
+			err = l.errorf(start, "invalid number, expected a digit after the decimal point")
+			return
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		l.pos++
+		t.kind = tokenFloat
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		if digits() == 0 {
+			err = l.errorf(start, "invalid number, expected a digit in the exponent")
+			return
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == '_' || l.src[l.pos] == '.' || isLetter(l.src[l.pos])) {
+		err = l.errorf(start, "invalid number, unexpected %q", l.src[l.pos])
+		return
+	}
+	t.value = l.src[start:l.pos]
+	return
+}
+
+// string is a method that reads a string literal and unescapes it
+func (l *lexer) string(start int) (s string, err error) {
+	l.pos++
+	var sb strings.Builder
+	for {
+		if l.pos >= len(l.src) || l.src[l.pos] == '\n' || l.src[l.pos] == '\r' {
+			err = l.errorf(start, "unterminated string")
+			return
+		}
+		c := l.src[l.pos]
+		switch c {
+		case '"':
+			l.pos++
+			s = sb.String()
+			return
+		case '\\':
+			if l.pos+1 >= len(l.src) {
+				err = l.errorf(start, "unterminated string")
+				return
+			}
+			esc := l.src[l.pos+1]
+			l.pos += 2
+			switch esc {
+			case '"', '\\', '/':
+				sb.WriteByte(esc)
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case 'u':
+				if l.pos+4 > len(l.src) {
+					err = l.errorf(start, "invalid unicode escape")
+					return
+				}
+				n, pErr := strconv.ParseUint(l.src[l.pos:l.pos+4], 16, 32)
+				if pErr != nil {
+					err = l.errorf(start, "invalid unicode escape")
+					return
+				}
+				l.pos += 4
+				sb.WriteRune(rune(n))
+			default:
+				err = l.errorf(l.pos-2, "invalid escape \\%c", esc)
+				return
+			}
+		default:
+			sb.WriteByte(c)
+			l.pos++
+		}
+	}
+}
+
+// blockString is a method that reads a block string literal, removing its common indentation and blank first and last lines
+func (l *lexer) blockString(start int) (s string, err error) {
+	l.pos += 3
+	var sb strings.Builder
+	for {
+		if l.pos >= len(l.src) {
+			err = l.errorf(start, "unterminated block string")
+			return
+		}
+		switch {
+		case strings.HasPrefix(l.src[l.pos:], `"""`):
+			l.pos += 3
+			s = dedentBlock(sb.String())
+			return
+		case strings.HasPrefix(l.src[l.pos:], `\"""`):
+			sb.WriteString(`"""`)
+			l.pos += 4
+		default:
+			c := l.src[l.pos]
+			sb.WriteByte(c)
+			l.pos++
+			if c == '\n' {
+				l.line, l.lineStart = l.line+1, l.pos
+			}
+		}
+	}
+}
+
+// dedentBlock is a function that removes the common indentation of the lines of a block string, and its blank first and last lines
+func dedentBlock(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	common := -1
+	for i, line := range lines {
+		if i == 0 {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < len(line) && (common < 0 || indent < common) {
+			common = indent
+		}
+	}
+	for i := 1; i < len(lines) && common > 0; i++ {
+		lines[i] = lines[i][min(common, len(lines[i])):]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isLetter is a function that reports whether a byte is an ASCII letter
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// isDigit is a function that reports whether a byte is an ASCII digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
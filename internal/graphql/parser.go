@@ -0,0 +1,626 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Document is a struct that represents a parsed executable document
+type Document struct {
+	// Operations is the list of the operations, in the order of the document
+	Operations []*Operation
+	// Fragments is the set of the fragments by name
+	Fragments map[string]*Fragment
+}
+
+// Operation is a struct that represents a query or a mutation of a document
+type Operation struct {
+	// Type is the type of the operation: query, mutation or subscription
+	Type string
+	// Name is the name of the operation, empty when anonymous
+	Name string
+	// Variables is the list of the variables of the operation
+	Variables []*VariableDefinition
+	// Selections is the selection set of the operation
+	Selections []Selection
+	// Loc is where the operation starts
+	Loc Location
+}
+
+// VariableDefinition is a struct that represents a variable of an operation
+type VariableDefinition struct {
+	// Name is the name of the variable, without $
+	Name string
+	// Type is the type of the variable
+	Type TypeRef
+	// Default is the default value of the variable, nil when there is none
+	Default *Value
+	// Loc is where the definition starts
+	Loc Location
+}
+
+// TypeRef is a struct that represents a reference to a type in a variable definition, such as [Int!]!
+type TypeRef struct {
+	// Name is the name of the named type, empty for lists
+	Name string
+	// Elem is the type of the elements of a list, nil for named types
+	Elem *TypeRef
+	// NonNull reports whether the type is non-null
+	NonNull bool
+}
+
+// String is a method that returns the type reference as written in a document
+func (t TypeRef) String() (s string) {
+	s = t.Name
+	if t.Elem != nil {
+		s = "[" + t.Elem.String() + "]"
+	}
+	if t.NonNull {
+		s += "!"
+	}
+	return
+}
+
+// Selection is an interface that represents an element of a selection set: a field, a fragment spread or an inline fragment
+type Selection interface {
+	// location is a method that returns where the selection starts
+	location() Location
+}
+
+// Field is a struct that represents a selected field
+type Field struct {
+	// Alias is the key of the field in the response, empty when it is the name
+	Alias string
+	// Name is the name of the field
+	Name string
+	// Arguments is the list of the arguments of the field
+	Arguments []*Argument
+	// Directives is the list of the directives of the field
+	Directives []*Directive
+	// Selections is the selection set of the field, empty for leaf fields
+	Selections []Selection
+	// Loc is where the field starts
+	Loc Location
+}
+
+// ResponseKey is a method that returns the key of the field in the response
+func (f *Field) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+func (f *Field) location() Location { return f.Loc }
+
+// FragmentSpread is a struct that represents a spread of a named fragment
+type FragmentSpread struct {
+	// Name is the name of the fragment
+	Name string
+	// Directives is the list of the directives of the spread
+	Directives []*Directive
+	// Loc is where the spread starts
+	Loc Location
+}
+
+func (f *FragmentSpread) location() Location { return f.Loc }
+
+// InlineFragment is a struct that represents an inline fragment
+type InlineFragment struct {
+	// TypeCondition is the name of the type the fragment applies to, empty when it applies to any
+	TypeCondition string
+	// Directives is the list of the directives of the fragment
+	Directives []*Directive
+	// Selections is the selection set of the fragment
+	Selections []Selection
+	// Loc is where the fragment starts
+	Loc Location
+}
+
+func (f *InlineFragment) location() Location { return f.Loc }
+
+// Fragment is a struct that represents a named fragment definition
+type Fragment struct {
+	// Name is the name of the fragment
+	Name string
+	// TypeCondition is the name of the type the fragment applies to
+	TypeCondition string
+	// Selections is the selection set of the fragment
+	Selections []Selection
+	// Loc is where the definition starts
+	Loc Location
+}
+
+// Argument is a struct that represents an argument of a field or a directive
+type Argument struct {
+	// Name is the name of the argument
+	Name string
+	// Value is the value of the argument
+	Value *Value
+	// Loc is where the argument starts
+	Loc Location
+}
+
+// Directive is a struct that represents a directive, such as @include(if: $flag)
+type Directive struct {
+	// Name is the name of the directive, without @
+	Name string
+	// Arguments is the list of the arguments of the directive
+	Arguments []*Argument
+	// Loc is where the directive starts
+	Loc Location
+}
+
+// ValueKind is the kind of a literal value of a document
+type ValueKind int
+
+const (
+	// ValueVariable is a reference to a variable
+	ValueVariable ValueKind = iota
+	// ValueInt is an integer
+	ValueInt
+	// ValueFloat is a float
+	ValueFloat
+	// ValueString is a string
+	ValueString
+	// ValueBoolean is true or false
+	ValueBoolean
+	// ValueNull is null
+	ValueNull
+	// ValueEnum is an enum value
+	ValueEnum
+	// ValueList is a list
+	ValueList
+	// ValueObject is an input object
+	ValueObject
+)
+
+// Value is a struct that represents a literal value of a document
+type Value struct {
+	// Kind is the kind of the value
+	Kind ValueKind
+	// Raw is the text of scalars and enums, unescaped for strings, and the name of variables
+	Raw string
+	// List is the elements of a list
+	List []*Value
+	// Fields is the fields of an input object, in the order of the document
+	Fields []*Argument
+	// Loc is where the value starts
+	Loc Location
+}
+
+// Parse is a function that parses an executable document, it fails with an *Error on syntax errors
+func Parse(src string) (d *Document, err error) {
+	p := &parser{lx: newLexer(src)}
+	if err = p.advance(); err != nil {
+		return
+	}
+	d = &Document{Fragments: make(map[string]*Fragment)}
+	if p.tk.kind == tokenEOF {
+		err = p.errorf("expected an operation or a fragment, found %s", p.tk)
+		return
+	}
+	for p.tk.kind != tokenEOF {
+		switch {
+		case p.peek(tokenPunct, "{"), p.peek(tokenName, "query"), p.peek(tokenName, "mutation"), p.peek(tokenName, "subscription"):
+			var op *Operation
+			if op, err = p.operation(); err != nil {
+				return
+			}
+			d.Operations = append(d.Operations, op)
+		case p.peek(tokenName, "fragment"):
+			var f *Fragment
+			if f, err = p.fragment(); err != nil {
+				return
+			}
+			if _, ok := d.Fragments[f.Name]; ok {
+				err = &Error{Message: "there can be only one fragment named \"" + f.Name + "\"", Locations: []Location{f.Loc}}
+				return
+			}
+			d.Fragments[f.Name] = f
+		default:
+			err = p.errorf("expected an operation or a fragment, found %s", p.tk)
+			return
+		}
+	}
+	return
+}
+
+// parser is a struct that builds a document from its tokens, by recursive descent
+type parser struct {
+	// lx is the lexer of the document
+	lx *lexer
+	// tk is the current token
+	tk token
+}
+
+// advance is a method that reads the next token
+func (p *parser) advance() (err error) {
+	p.tk, err = p.lx.next()
+	return
+}
+
+// peek is a method that reports whether the current token is of a kind and value
+func (p *parser) peek(kind tokenKind, value string) bool {
+	return p.tk.kind == kind && p.tk.value == value
+}
+
+// errorf is a method that returns a syntax error at the current token
+func (p *parser) errorf(format string, args ...any) *Error {
+	return &Error{Message: "syntax error: " + fmt.Sprintf(format, args...), Locations: []Location{p.tk.loc}}
+}
+
+// expect is a method that consumes a punctuator, failing when the current token is another one
+func (p *parser) expect(value string) (err error) {
+	if !p.peek(tokenPunct, value) {
+		err = p.errorf("expected \"%s\", found %s", value, p.tk)
+		return
+	}
+	err = p.advance()
+	return
+}
+
+// skipIf is a method that consumes a punctuator when it is the current token and reports whether it did
+func (p *parser) skipIf(value string) (ok bool, err error) {
+	if ok = p.peek(tokenPunct, value); ok {
+		err = p.advance()
+	}
+	return
+}
+
+// name is a method that consumes a name
+func (p *parser) name() (n string, err error) {
+	if p.tk.kind != tokenName {
+		err = p.errorf("expected a name, found %s", p.tk)
+		return
+	}
+	n = p.tk.value
+	err = p.advance()
+	return
+}
+
+// operation is a method that parses an operation, either the shorthand selection set or a typed one
+func (p *parser) operation() (op *Operation, err error) {
+	op = &Operation{Type: "query", Loc: p.tk.loc}
+	if p.tk.kind == tokenName {
+		op.Type = p.tk.value
+		if err = p.advance(); err != nil {
+			return
+		}
+		if p.tk.kind == tokenName {
+			if op.Name, err = p.name(); err != nil {
+				return
+			}
+		}
+		if p.peek(tokenPunct, "(") {
+			if op.Variables, err = p.variableDefinitions(); err != nil {
+				return
+			}
+		}
+		if _, err = p.directives(false); err != nil {
+			return
+		}
+	}
+	op.Selections, err = p.selectionSet()
+	return
+}
+
+// variableDefinitions is a method that parses the variables of an operation
+func (p *parser) variableDefinitions() (vars []*VariableDefinition, err error) {
+	if err = p.expect("("); err != nil {
+		return
+	}
+	for !p.peek(tokenPunct, ")") {
+		v := &VariableDefinition{Loc: p.tk.loc}
+		if err = p.expect("$"); err != nil {
+			return
+		}
+		if v.Name, err = p.name(); err != nil {
+			return
+		}
+		if err = p.expect(":"); err != nil {
+			return
+		}
+		if v.Type, err = p.typeRef(); err != nil {
+			return
+		}
+		var ok bool
+		if ok, err = p.skipIf("="); err != nil {
+			return
+		}
+		if ok {
+			if v.Default, err = p.value(true); err != nil {
+				return
+			}
+		}
+		if _, err = p.directives(true); err != nil {
+			return
+		}
+		vars = append(vars, v)
+	}
+	err = p.advance()
+	return
+}
+
+// typeRef is a method that parses a type reference
+func (p *parser) typeRef() (t TypeRef, err error) {
+	var ok bool
+	if ok, err = p.skipIf("["); err != nil {
+		return
+	}
+	if ok {
+		var elem TypeRef
+		if elem, err = p.typeRef(); err != nil {
+			return
+		}
+		t.Elem = &elem
+		if err = p.expect("]"); err != nil {
+			return
+		}
+	} else if t.Name, err = p.name(); err != nil {
+		return
+	}
+	t.NonNull, err = p.skipIf("!")
+	return
+}
+
+// fragment is a method that parses a fragment definition
+func (p *parser) fragment() (f *Fragment, err error) {
+	f = &Fragment{Loc: p.tk.loc}
+	if err = p.advance(); err != nil {
+		return
+	}
+	if f.Name, err = p.name(); err != nil {
+		return
+	}
+	if f.Name == "on" {
+		err = &Error{Message: "syntax error: a fragment can not be named \"on\"", Locations: []Location{f.Loc}}
+		return
+	}
+	if !p.peek(tokenName, "on") {
+		err = p.errorf("expected \"on\", found %s", p.tk)
+		return
+	}
+	if err = p.advance(); err != nil {
+		return
+	}
+	if f.TypeCondition, err = p.name(); err != nil {
+		return
+	}
+	if _, err = p.directives(false); err != nil {
+		return
+	}
+	f.Selections, err = p.selectionSet()
+	return
+}
+
+// selectionSet is a method that parses a selection set, which must not be empty
+func (p *parser) selectionSet() (s []Selection, err error) {
+	if err = p.expect("{"); err != nil {
+		return
+	}
+	for !p.peek(tokenPunct, "}") {
+		var sel Selection
+		if sel, err = p.selection(); err != nil {
+			return
+		}
+		s = append(s, sel)
+	}
+	if len(s) == 0 {
+		err = p.errorf("expected a selection, found %s", p.tk)
+		return
+	}
+	err = p.advance()
+	return
+}
+
+// selection is a method that parses a field, a fragment spread or an inline fragment
+func (p *parser) selection() (s Selection, err error) {
+	loc := p.tk.loc
+	var ok bool
+	if ok, err = p.skipIf("..."); err != nil || !ok {
+		if err == nil {
+			s, err = p.field()
+		}
+		return
+	}
+
+	// fragments
+	if p.tk.kind == tokenName && p.tk.value != "on" {
+		fs := &FragmentSpread{Loc: loc}
+		if fs.Name, err = p.name(); err != nil {
+			return
+		}
+		fs.Directives, err = p.directives(false)
+		s = fs
+		return
+	}
+	inf := &InlineFragment{Loc: loc}
+	if p.peek(tokenName, "on") {
+		if err = p.advance(); err != nil {
+			return
+		}
+		if inf.TypeCondition, err = p.name(); err != nil {
+			return
+		}
+	}
+	if inf.Directives, err = p.directives(false); err != nil {
+		return
+	}
+	inf.Selections, err = p.selectionSet()
+	s = inf
+	return
+}
+
+// field is a method that parses a field with its alias, arguments, directives and selection set
+func (p *parser) field() (f *Field, err error) {
+	f = &Field{Loc: p.tk.loc}
+	if f.Name, err = p.name(); err != nil {
+		return
+	}
+	var ok bool
+	if ok, err = p.skipIf(":"); err != nil {
+		return
+	}
+	if ok {
+		f.Alias = f.Name
+		if f.Name, err = p.name(); err != nil {
+			return
+		}
+	}
+	if f.Arguments, err = p.arguments(false); err != nil {
+		return
+	}
+	if f.Directives, err = p.directives(false); err != nil {
+		return
+	}
+	if p.peek(tokenPunct, "{") {
+		f.Selections, err = p.selectionSet()
+	}
+	return
+}
+
+// arguments is a method that parses the arguments in parentheses, none when there are no parentheses
+func (p *parser) arguments(constant bool) (args []*Argument, err error) {
+	var ok bool
+	if ok, err = p.skipIf("("); err != nil || !ok {
+		return
+	}
+	for !p.peek(tokenPunct, ")") {
+		a := &Argument{Loc: p.tk.loc}
+		if a.Name, err = p.name(); err != nil {
+			return
+		}
+		if err = p.expect(":"); err != nil {
+			return
+		}
+		if a.Value, err = p.value(constant); err != nil {
+			return
+		}
+		args = append(args, a)
+	}
+	if len(args) == 0 {
+		err = p.errorf("expected an argument, found %s", p.tk)
+		return
+	}
+	err = p.advance()
+	return
+}
+
+// directives is a method that parses the directives, none when there are no directives
+func (p *parser) directives(constant bool) (d []*Directive, err error) {
+	for p.peek(tokenPunct, "@") {
+		dr := &Directive{Loc: p.tk.loc}
+		if err = p.advance(); err != nil {
+			return
+		}
+		if dr.Name, err = p.name(); err != nil {
+			return
+		}
+		if dr.Arguments, err = p.arguments(constant); err != nil {
+			return
+		}
+		d = append(d, dr)
+	}
+	return
+}
+
+// value is a method that parses a value, variables are not allowed in constant values such as defaults
+func (p *parser) value(constant bool) (v *Value, err error) {
+	v = &Value{Loc: p.tk.loc, Raw: p.tk.value}
+	switch p.tk.kind {
+	case tokenInt:
+		v.Kind = ValueInt
+	case tokenFloat:
+		v.Kind = ValueFloat
+	case tokenString:
+		v.Kind = ValueString
+	case tokenName:
+		switch p.tk.value {
+		case "true", "false":
+			v.Kind = ValueBoolean
+		case "null":
+			v.Kind = ValueNull
+		default:
+			v.Kind = ValueEnum
+		}
+	case tokenPunct:
+		switch p.tk.value {
+		case "$":
+			if constant {
+				err = p.errorf("unexpected variable in a constant value")
+				return
+			}
+			if err = p.advance(); err != nil {
+				return
+			}
+			v.Kind = ValueVariable
+			v.Raw, err = p.name()
+			return
+		case "[":
+			v.Kind, v.Raw = ValueList, ""
+			if err = p.advance(); err != nil {
+				return
+			}
+			for !p.peek(tokenPunct, "]") {
+				var elem *Value
+				if elem, err = p.value(constant); err != nil {
+					return
+				}
+				v.List = append(v.List, elem)
+			}
+			err = p.advance()
+			return
+		case "{":
+			v.Kind, v.Raw = ValueObject, ""
+			if err = p.advance(); err != nil {
+				return
+			}
+			for !p.peek(tokenPunct, "}") {
+				f := &Argument{Loc: p.tk.loc}
+				if f.Name, err = p.name(); err != nil {
+					return
+				}
+				if err = p.expect(":"); err != nil {
+					return
+				}
+				if f.Value, err = p.value(constant); err != nil {
+					return
+				}
+				v.Fields = append(v.Fields, f)
+			}
+			err = p.advance()
+			return
+		}
+		err = p.errorf("expected a value, found %s", p.tk)
+		return
+	default:
+		err = p.errorf("expected a value, found %s", p.tk)
+		return
+	}
+	err = p.advance()
+	return
+}
+
+// String is a method that returns the value as written in a document, as shown in errors
+func (v *Value) String() string {
+	switch v.Kind {
+	case ValueVariable:
+		return "$" + v.Raw
+	case ValueString:
+		return "\"" + strings.ReplaceAll(v.Raw, "\"", "\\\"") + "\""
+	case ValueList:
+		parts := make([]string, 0, len(v.List))
+		for _, value := range v.List {
+			parts = append(parts, value.String())
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case ValueObject:
+		parts := make([]string, 0, len(v.Fields))
+		for _, value := range v.Fields {
+			parts = append(parts, value.Name+": "+value.Value.String())
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	}
+	return v.Raw
+}
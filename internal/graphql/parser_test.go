@@ -0,0 +1,157 @@
+package graphql
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	src := `# the pets of a kind
+query Pets($kind: Kind = DOG, $first: Int!, $ids: [ID!]) @live {
+  all: pets(first: $first, kind: $kind) {
+    ...petFields @include(if: true)
+    ... on Pet { name }
+    ... @skip(if: false) { id }
+  }
+  hello(name: """
+      multi
+        line
+  """, greeting: "café \"quoted\"\n")
+}
+
+fragment petFields on Pet { id owner { name } }
+
+{ hello }
+`
+	d, err := Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Operations) != 2 || len(d.Fragments) != 1 {
+		t.Fatalf("got %d operations and %d fragments, want 2 and 1", len(d.Operations), len(d.Fragments))
+	}
+
+	// - the operation and its variables
+	op := d.Operations[0]
+	if op.Type != "query" || op.Name != "Pets" || op.Loc != (Location{Line: 2, Column: 1}) {
+		t.Errorf("got operation %s %q at %+v, want query \"Pets\" at 2:1", op.Type, op.Name, op.Loc)
+	}
+	var types []string
+	for _, value := range op.Variables {
+		types = append(types, "$"+value.Name+": "+value.Type.String())
+	}
+	if want := []string{"$kind: Kind", "$first: Int!", "$ids: [ID!]"}; !reflect.DeepEqual(types, want) {
+		t.Errorf("got variables %v, want %v", types, want)
+	}
+	if def := op.Variables[0].Default; def == nil || def.Kind != ValueEnum || def.Raw != "DOG" {
+		t.Errorf("got default %+v, want the enum DOG", def)
+	}
+
+	// - the fields, their aliases, arguments and selections
+	all, ok := op.Selections[0].(*Field)
+	if !ok || all.Alias != "all" || all.Name != "pets" || all.ResponseKey() != "all" {
+		t.Fatalf("got first selection %+v, want the field pets aliased all", op.Selections[0])
+	}
+	if len(all.Arguments) != 2 || all.Arguments[0].Value.Kind != ValueVariable || all.Arguments[0].Value.Raw != "first" {
+		t.Errorf("got arguments %+v, want first given the variable $first", all.Arguments)
+	}
+	if len(all.Selections) != 3 {
+		t.Fatalf("got %d selections of pets, want 3", len(all.Selections))
+	}
+	if fs, ok := all.Selections[0].(*FragmentSpread); !ok || fs.Name != "petFields" || len(fs.Directives) != 1 || fs.Directives[0].Name != "include" {
+		t.Errorf("got %+v, want the spread of petFields with @include", all.Selections[0])
+	}
+	if inf, ok := all.Selections[1].(*InlineFragment); !ok || inf.TypeCondition != "Pet" {
+		t.Errorf("got %+v, want an inline fragment on Pet", all.Selections[1])
+	}
+	if inf, ok := all.Selections[2].(*InlineFragment); !ok || inf.TypeCondition != "" || inf.Directives[0].Name != "skip" {
+		t.Errorf("got %+v, want an inline fragment without a type condition, with @skip", all.Selections[2])
+	}
+
+	// - the strings, block strings dedented
+	hello := op.Selections[1].(*Field)
+	if got := hello.Arguments[0].Value.Raw; got != "multi\n  line" {
+		t.Errorf("got block string %q, want it dedented", got)
+	}
+	if got := hello.Arguments[1].Value.Raw; got != "café \"quoted\"\n" {
+		t.Errorf("got string %q, want it unescaped", got)
+	}
+
+	// - the fragment and the shorthand query
+	if f := d.Fragments["petFields"]; f == nil || f.TypeCondition != "Pet" || len(f.Selections) != 2 {
+		t.Errorf("got fragment %+v, want petFields on Pet with 2 selections", f)
+	}
+	if sh := d.Operations[1]; sh.Type != "query" || sh.Name != "" || len(sh.Selections) != 1 {
+		t.Errorf("got operation %+v, want an anonymous query", sh)
+	}
+}
+
+func TestParse_Values(t *testing.T) {
+	d, err := Parse(`{ f(a: -12, b: 1.5e3, c: true, d: null, e: [1, [2]], g: {x: "y", z: $v}) }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := d.Operations[0].Selections[0].(*Field).Arguments
+	want := []struct {
+		kind ValueKind
+		str  string
+	}{
+		{kind: ValueInt, str: "-12"},
+		{kind: ValueFloat, str: "1.5e3"},
+		{kind: ValueBoolean, str: "true"},
+		{kind: ValueNull, str: "null"},
+		{kind: ValueList, str: "[1, [2]]"},
+		{kind: ValueObject, str: `{x: "y", z: $v}`},
+	}
+	for i, value := range want {
+		if args[i].Value.Kind != value.kind || args[i].Value.String() != value.str {
+			t.Errorf("argument %s: got %v %s, want %v %s", args[i].Name, args[i].Value.Kind, args[i].Value, value.kind, value.str)
+		}
+	}
+}
+
+func TestParse_SyntaxErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		message string
+		loc     Location
+	}{
+		{name: "empty document", src: "  # nothing\n", message: "expected an operation or a fragment, found the end of the document", loc: Location{Line: 2, Column: 1}},
+		{name: "empty selection set", src: "{ }", message: `expected a selection, found "}"`, loc: Location{Line: 1, Column: 3}},
+		{name: "unclosed selection set", src: "{ pets {\n name }", message: "expected a name, found the end of the document", loc: Location{Line: 2, Column: 8}},
+		{name: "empty arguments", src: "{ pets() { name } }", message: `expected an argument, found ")"`, loc: Location{Line: 1, Column: 8}},
+		{name: "argument without a value", src: "{ pets(first:) { name } }", message: `expected a value, found ")"`, loc: Location{Line: 1, Column: 14}},
+		{name: "variable in a default", src: "query($a: Int = $b) { hello }", message: "unexpected variable in a constant value", loc: Location{Line: 1, Column: 17}},
+		{name: "fragment named on", src: "fragment on on Pet { name }", message: `a fragment can not be named "on"`, loc: Location{Line: 1, Column: 1}},
+		{name: "fragment without a type condition", src: "fragment F Pet { name }", message: `expected "on", found "Pet"`, loc: Location{Line: 1, Column: 12}},
+		{name: "two fragments of a name", src: "{ hello } fragment F on Pet { id } fragment F on Pet { name }", message: `there can be only one fragment named "F"`, loc: Location{Line: 1, Column: 36}},
+		{name: "single dot", src: "{ pets { .name } }", message: `unexpected '.', did you mean "..."?`, loc: Location{Line: 1, Column: 10}},
+		{name: "unterminated string", src: `{ hello(name: "abc) }`, message: "unterminated string", loc: Location{Line: 1, Column: 15}},
+		{name: "invalid escape", src: `{ hello(name: "a\qb") }`, message: `invalid escape \q`, loc: Location{Line: 1, Column: 17}},
+		{name: "invalid number", src: "{ pets(first: 01) { id } }", message: "invalid number, unexpected digit after 0", loc: Location{Line: 1, Column: 15}},
+		{name: "unexpected character", src: "{ hello ? }", message: `unexpected character '?'`, loc: Location{Line: 1, Column: 9}},
+		{name: "unknown definition", src: "type Pet { name: String }", message: `expected an operation or a fragment, found "type"`, loc: Location{Line: 1, Column: 1}},
+		{name: "location in characters", src: "{ hello(name: \"é\") ! }", message: `expected a name, found "!"`, loc: Location{Line: 1, Column: 20}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.src)
+			var gErr *Error
+			if !errors.As(err, &gErr) {
+				t.Fatalf("got error %v, want an *Error", err)
+			}
+			if !strings.HasPrefix(gErr.Message, "syntax error: ") && !strings.HasPrefix(gErr.Message, "there can be only one") {
+				t.Errorf("got message %q, want a syntax error", gErr.Message)
+			}
+			if !strings.Contains(gErr.Message, tt.message) {
+				t.Errorf("got message %q, want it to contain %q", gErr.Message, tt.message)
+			}
+			if len(gErr.Locations) != 1 || gErr.Locations[0] != tt.loc {
+				t.Errorf("got locations %+v, want %+v", gErr.Locations, tt.loc)
+			}
+		})
+	}
+}
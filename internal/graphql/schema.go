@@ -0,0 +1,543 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Type is an interface that represents a type of a schema: a scalar, an enum, an object, an input object, a list or a non-null type
+type Type interface {
+	// String is a method that returns the type as written in a schema, such as [Vehicle!]!
+	String() string
+}
+
+// Scalar is a struct that represents a leaf type serialized by functions
+type Scalar struct {
+	// Name is the name of the scalar
+	Name string
+	// Description is the description of the scalar
+	Description string
+	// Serialize is the function that converts a resolved value to its JSON representation
+	Serialize func(v any) (r any, err error)
+	// ParseValue is the function that converts a value of a variable, decoded from JSON, to the value given to resolvers
+	ParseValue func(v any) (r any, err error)
+	// ParseLiteral is the function that converts a literal of a document to the value given to resolvers
+	ParseLiteral func(v *Value) (r any, err error)
+}
+
+// String is a method that returns the name of the scalar
+func (s *Scalar) String() string { return s.Name }
+
+// Enum is a struct that represents a leaf type with a fixed set of values, resolved and given to resolvers as strings
+type Enum struct {
+	// Name is the name of the enum
+	Name string
+	// Description is the description of the enum
+	Description string
+	// Values is the list of the values of the enum
+	Values []string
+}
+
+// String is a method that returns the name of the enum
+func (e *Enum) String() string { return e.Name }
+
+// has is a method that reports whether a value belongs to the enum
+func (e *Enum) has(v string) bool {
+	for _, value := range e.Values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Object is a struct that represents an output type with fields
+type Object struct {
+	// Name is the name of the object
+	Name string
+	// Description is the description of the object
+	Description string
+	// Fields is the list of the fields of the object
+	Fields []*FieldDefinition
+}
+
+// String is a method that returns the name of the object
+func (o *Object) String() string { return o.Name }
+
+// Field is a method that returns a field of the object by name, nil when there is none
+func (o *Object) Field(name string) *FieldDefinition {
+	for _, value := range o.Fields {
+		if value.Name == name {
+			return value
+		}
+	}
+	return nil
+}
+
+// InputObject is a struct that represents an input type with fields, given to resolvers as a map[string]any
+type InputObject struct {
+	// Name is the name of the input object
+	Name string
+	// Description is the description of the input object
+	Description string
+	// Fields is the list of the fields of the input object
+	Fields []*ArgumentDefinition
+}
+
+// String is a method that returns the name of the input object
+func (o *InputObject) String() string { return o.Name }
+
+// List is a struct that represents a list of a type, resolved from any slice and given to resolvers as a []any
+type List struct {
+	// Of is the type of the elements
+	Of Type
+}
+
+// String is a method that returns the list as written in a schema
+func (l *List) String() string { return "[" + l.Of.String() + "]" }
+
+// NonNull is a struct that represents a type whose values are never null
+type NonNull struct {
+	// Of is the type of the values
+	Of Type
+}
+
+// String is a method that returns the non-null type as written in a schema
+func (n *NonNull) String() string { return n.Of.String() + "!" }
+
+// ResolveParams is a struct that represents the input of a resolver
+type ResolveParams struct {
+	// Context is the context of the request
+	Context context.Context
+	// Source is the value the parent field resolved to, nil for the fields of the operation types
+	Source any
+	// Args is the set of the arguments of the field, coerced to their types with their defaults applied
+	Args map[string]any
+}
+
+// ResolveFunc is a function that resolves the value of a field
+type ResolveFunc func(p ResolveParams) (v any, err error)
+
+// FieldDefinition is a struct that represents a field of an object
+type FieldDefinition struct {
+	// Name is the name of the field
+	Name string
+	// Description is the description of the field
+	Description string
+	// Args is the list of the arguments of the field
+	Args []*ArgumentDefinition
+	// Type is the type of the field
+	Type Type
+	// Resolve is the resolver of the field, by default the value of the key of the name in a map[string]any source
+	Resolve ResolveFunc
+	// Cost is the function that returns the number of times the selection of the field is resolved, for the complexity of a query;
+	// such as the number of elements a list field returns at most. 1 by default
+	Cost func(args map[string]any) int
+}
+
+// ArgumentDefinition is a struct that represents an argument of a field or a field of an input object
+type ArgumentDefinition struct {
+	// Name is the name of the argument
+	Name string
+	// Description is the description of the argument
+	Description string
+	// Type is the type of the argument
+	Type Type
+	// Default is the value of the argument when it is not given, nil when it has none
+	Default any
+}
+
+// Schema is a struct that represents the types queries are validated and executed against
+type Schema struct {
+	// Query is the type of the query operations
+	Query *Object
+	// Mutation is the type of the mutation operations, nil when mutations are not supported
+	Mutation *Object
+	// types is the set of the named types by name
+	types map[string]Type
+}
+
+// NewSchema is a function that returns a new instance of Schema, it fails when two types share a name
+func NewSchema(query, mutation *Object) (s *Schema, err error) {
+	s = &Schema{Query: query, Mutation: mutation, types: make(map[string]Type)}
+	for _, value := range []Type{Int, Float, String, Boolean, ID} {
+		s.types[value.String()] = value
+	}
+	if err = s.collect(query); err != nil {
+		return
+	}
+	if mutation != nil {
+		err = s.collect(mutation)
+	}
+	return
+}
+
+// collect is a method that adds a type and the types it refers to to the set of named types
+func (s *Schema) collect(t Type) (err error) {
+	switch tt := t.(type) {
+	case *List:
+		return s.collect(tt.Of)
+	case *NonNull:
+		return s.collect(tt.Of)
+	}
+	if prev, ok := s.types[t.String()]; ok {
+		if prev != t {
+			err = fmt.Errorf("graphql: two types named %s", t)
+		}
+		return
+	}
+	s.types[t.String()] = t
+	switch tt := t.(type) {
+	case *Object:
+		for _, f := range tt.Fields {
+			if err = s.collect(f.Type); err != nil {
+				return
+			}
+			for _, a := range f.Args {
+				if err = s.collect(a.Type); err != nil {
+					return
+				}
+			}
+		}
+	case *InputObject:
+		for _, f := range tt.Fields {
+			if err = s.collect(f.Type); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// Type is a method that returns a named type by name, nil when there is none
+func (s *Schema) Type(name string) Type {
+	return s.types[name]
+}
+
+// typeOf is a method that resolves a type reference of a variable definition, it fails when the named type is unknown
+func (s *Schema) typeOf(ref TypeRef) (t Type, err error) {
+	if ref.Elem != nil {
+		var elem Type
+		if elem, err = s.typeOf(*ref.Elem); err != nil {
+			return
+		}
+		t = &List{Of: elem}
+	} else if t = s.types[ref.Name]; t == nil {
+		err = fmt.Errorf("unknown type %s", ref.Name)
+		return
+	}
+	if ref.NonNull {
+		t = &NonNull{Of: t}
+	}
+	return
+}
+
+// SDL is a method that returns the schema in the schema definition language, types ordered by name
+func (s *Schema) SDL() string {
+	var sb strings.Builder
+	names := make([]string, 0, len(s.types))
+	for key := range s.types {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+	sb.WriteString("schema {\n  query: " + s.Query.Name + "\n")
+	if s.Mutation != nil {
+		sb.WriteString("  mutation: " + s.Mutation.Name + "\n")
+	}
+	sb.WriteString("}\n")
+	for _, name := range names {
+		switch t := s.types[name].(type) {
+		case *Scalar:
+			if t == Int || t == Float || t == String || t == Boolean || t == ID {
+				continue
+			}
+			sb.WriteString("\n")
+			writeDescription(&sb, "", t.Description)
+			sb.WriteString("scalar " + t.Name + "\n")
+		case *Enum:
+			sb.WriteString("\n")
+			writeDescription(&sb, "", t.Description)
+			sb.WriteString("enum " + t.Name + " {\n")
+			for _, value := range t.Values {
+				sb.WriteString("  " + value + "\n")
+			}
+			sb.WriteString("}\n")
+		case *Object:
+			sb.WriteString("\n")
+			writeDescription(&sb, "", t.Description)
+			sb.WriteString("type " + t.Name + " {\n")
+			for _, f := range t.Fields {
+				writeDescription(&sb, "  ", f.Description)
+				sb.WriteString("  " + f.Name)
+				if len(f.Args) > 0 {
+					args := make([]string, 0, len(f.Args))
+					for _, a := range f.Args {
+						args = append(args, argumentSDL(a))
+					}
+					sb.WriteString("(" + strings.Join(args, ", ") + ")")
+				}
+				sb.WriteString(": " + f.Type.String() + "\n")
+			}
+			sb.WriteString("}\n")
+		case *InputObject:
+			sb.WriteString("\n")
+			writeDescription(&sb, "", t.Description)
+			sb.WriteString("input " + t.Name + " {\n")
+			for _, f := range t.Fields {
+				writeDescription(&sb, "  ", f.Description)
+				sb.WriteString("  " + argumentSDL(f) + "\n")
+			}
+			sb.WriteString("}\n")
+		}
+	}
+	return sb.String()
+}
+
+// writeDescription is a function that writes a description as a block string, nothing when it is empty
+func writeDescription(sb *strings.Builder, indent, description string) {
+	if description == "" {
+		return
+	}
+	sb.WriteString(indent + "\"\"\"" + strings.ReplaceAll(description, "\"\"\"", "\\\"\"\"") + "\"\"\"\n")
+}
+
+// argumentSDL is a function that returns an argument as written in a schema, with its default
+func argumentSDL(a *ArgumentDefinition) (s string) {
+	s = a.Name + ": " + a.Type.String()
+	if a.Default != nil {
+		s += " = " + literal(a.Default)
+	}
+	return
+}
+
+// literal is a function that returns a value given to resolvers as a literal of a document
+func literal(v any) string {
+	switch vv := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", vv)
+	case []any:
+		parts := make([]string, 0, len(vv))
+		for _, value := range vv {
+			parts = append(parts, literal(value))
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	return fmt.Sprint(v)
+}
+
+// named is a function that returns the named type of a type, without lists and non-null wrappers
+func named(t Type) Type {
+	for {
+		switch tt := t.(type) {
+		case *List:
+			t = tt.Of
+		case *NonNull:
+			t = tt.Of
+		default:
+			return t
+		}
+	}
+}
+
+// isInputType is a function that reports whether a type may be the type of a variable or an argument
+func isInputType(t Type) bool {
+	switch named(t).(type) {
+	case *Scalar, *Enum, *InputObject:
+		return true
+	}
+	return false
+}
+
+var (
+	// Int is the built-in scalar of signed 32-bit integers
+	Int = &Scalar{
+		Name: "Int",
+		Serialize: func(v any) (r any, err error) {
+			var n float64
+			switch vv := v.(type) {
+			case int:
+				n = float64(vv)
+			case int32:
+				n = float64(vv)
+			case int64:
+				n = float64(vv)
+			case float64:
+				n = vv
+			default:
+				err = fmt.Errorf("Int cannot represent %v", v)
+				return
+			}
+			if n != math.Trunc(n) || n < math.MinInt32 || n > math.MaxInt32 {
+				err = fmt.Errorf("Int cannot represent %v", v)
+				return
+			}
+			r = int(n)
+			return
+		},
+		ParseValue: func(v any) (r any, err error) {
+			n, ok := v.(float64)
+			if !ok || n != math.Trunc(n) || n < math.MinInt32 || n > math.MaxInt32 {
+				err = fmt.Errorf("Int cannot represent %v", v)
+				return
+			}
+			r = int(n)
+			return
+		},
+		ParseLiteral: func(v *Value) (r any, err error) {
+			var n int64
+			if v.Kind == ValueInt {
+				_, err = fmt.Sscan(v.Raw, &n)
+			}
+			if v.Kind != ValueInt || err != nil || n < math.MinInt32 || n > math.MaxInt32 {
+				err = fmt.Errorf("Int cannot represent %s", v)
+				return
+			}
+			r = int(n)
+			return
+		},
+	}
+	// Float is the built-in scalar of double-precision numbers
+	Float = &Scalar{
+		Name: "Float",
+		Serialize: func(v any) (r any, err error) {
+			switch vv := v.(type) {
+			case float64:
+				r = vv
+			case float32:
+				r = float64(vv)
+			case int:
+				r = float64(vv)
+			default:
+				err = fmt.Errorf("Float cannot represent %v", v)
+			}
+			if f, ok := r.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+				r, err = nil, fmt.Errorf("Float cannot represent %v", v)
+			}
+			return
+		},
+		ParseValue: func(v any) (r any, err error) {
+			n, ok := v.(float64)
+			if !ok {
+				err = fmt.Errorf("Float cannot represent %v", v)
+				return
+			}
+			r = n
+			return
+		},
+		ParseLiteral: func(v *Value) (r any, err error) {
+			var n float64
+			if v.Kind == ValueInt || v.Kind == ValueFloat {
+				_, err = fmt.Sscan(v.Raw, &n)
+			}
+			if v.Kind != ValueInt && v.Kind != ValueFloat || err != nil {
+				err = fmt.Errorf("Float cannot represent %s", v)
+				return
+			}
+			r = n
+			return
+		},
+	}
+	// String is the built-in scalar of UTF-8 strings
+	String = &Scalar{
+		Name: "String",
+		Serialize: func(v any) (r any, err error) {
+			switch vv := v.(type) {
+			case string:
+				r = vv
+			case fmt.Stringer:
+				r = vv.String()
+			default:
+				err = fmt.Errorf("String cannot represent %v", v)
+			}
+			return
+		},
+		ParseValue: func(v any) (r any, err error) {
+			s, ok := v.(string)
+			if !ok {
+				err = fmt.Errorf("String cannot represent %v", v)
+				return
+			}
+			r = s
+			return
+		},
+		ParseLiteral: func(v *Value) (r any, err error) {
+			if v.Kind != ValueString {
+				err = fmt.Errorf("String cannot represent %s", v)
+				return
+			}
+			r = v.Raw
+			return
+		},
+	}
+	// Boolean is the built-in scalar of true and false
+	Boolean = &Scalar{
+		Name: "Boolean",
+		Serialize: func(v any) (r any, err error) {
+			b, ok := v.(bool)
+			if !ok {
+				err = fmt.Errorf("Boolean cannot represent %v", v)
+				return
+			}
+			r = b
+			return
+		},
+		ParseValue: func(v any) (r any, err error) {
+			b, ok := v.(bool)
+			if !ok {
+				err = fmt.Errorf("Boolean cannot represent %v", v)
+				return
+			}
+			r = b
+			return
+		},
+		ParseLiteral: func(v *Value) (r any, err error) {
+			if v.Kind != ValueBoolean {
+				err = fmt.Errorf("Boolean cannot represent %s", v)
+				return
+			}
+			r = v.Raw == "true"
+			return
+		},
+	}
+	// ID is the built-in scalar of unique identifiers, serialized as strings and accepted as strings or integers
+	ID = &Scalar{
+		Name: "ID",
+		Serialize: func(v any) (r any, err error) {
+			switch vv := v.(type) {
+			case string:
+				r = vv
+			case int:
+				r = fmt.Sprint(vv)
+			default:
+				err = fmt.Errorf("ID cannot represent %v", v)
+			}
+			return
+		},
+		ParseValue: func(v any) (r any, err error) {
+			switch vv := v.(type) {
+			case string:
+				r = vv
+			case float64:
+				if vv != math.Trunc(vv) {
+					err = fmt.Errorf("ID cannot represent %v", v)
+					return
+				}
+				r = fmt.Sprint(int64(vv))
+			default:
+				err = fmt.Errorf("ID cannot represent %v", v)
+			}
+			return
+		},
+		ParseLiteral: func(v *Value) (r any, err error) {
+			if v.Kind != ValueString && v.Kind != ValueInt {
+				err = fmt.Errorf("ID cannot represent %s", v)
+				return
+			}
+			r = v.Raw
+			return
+		},
+	}
+)
@@ -0,0 +1,413 @@
+package graphql
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// complexityCap is the complexity no computation goes past, so that costs never overflow
+	complexityCap = 1 << 30
+	// fieldBudget is the number of fields a validation visits at most, fragments spread many times count every time
+	fieldBudget = 100000
+)
+
+// Request is a struct that represents a request to execute an operation of a document
+type Request struct {
+	// Query is the document
+	Query string `json:"query"`
+	// OperationName is the name of the operation to execute, required when the document has several
+	OperationName string `json:"operationName"`
+	// Variables is the set of the values of the variables, decoded from JSON
+	Variables map[string]any `json:"variables"`
+}
+
+// Limits is a struct that represents the limits of the operations a schema executes, zero values are no limit
+type Limits struct {
+	// MaxDepth is the deepest nesting of fields, root fields are at depth 1
+	MaxDepth int
+	// MaxComplexity is the highest complexity: every field costs 1 plus the complexity of its selection multiplied by the cost of the field
+	MaxComplexity int
+}
+
+// Prepared is a struct that represents an operation validated against a schema, ready to be executed
+type Prepared struct {
+	// Depth is the nesting of fields of the operation
+	Depth int
+	// Complexity is the complexity of the operation
+	Complexity int
+	// schema is the schema the operation is executed against
+	schema *Schema
+	// doc is the document of the operation
+	doc *Document
+	// op is the operation
+	op *Operation
+	// vars is the set of the variables, coerced to their types
+	vars map[string]any
+}
+
+// Type is a method that returns the type of the operation: query or mutation
+func (p *Prepared) Type() string {
+	return p.op.Type
+}
+
+// Prepare is a method that parses a request, selects its operation, coerces its variables and validates the operation,
+// including its limits. It fails with the list of the errors found
+func (s *Schema) Prepare(r Request, l Limits) (p *Prepared, errs []*Error) {
+	doc, err := Parse(r.Query)
+	if err != nil {
+		var gErr *Error
+		if !errors.As(err, &gErr) {
+			gErr = &Error{Message: err.Error()}
+		}
+		errs = []*Error{gErr}
+		return
+	}
+
+	// operation
+	var op *Operation
+	for _, value := range doc.Operations {
+		if r.OperationName == "" || value.Name == r.OperationName {
+			if op != nil {
+				errs = []*Error{{Message: "operationName is required when the document has several operations"}}
+				return
+			}
+			op = value
+		}
+	}
+	if op == nil {
+		errs = []*Error{{Message: fmt.Sprintf("unknown operation %q", r.OperationName)}}
+		return
+	}
+	var root *Object
+	switch op.Type {
+	case "query":
+		root = s.Query
+	case "mutation":
+		root = s.Mutation
+	}
+	if root == nil {
+		errs = []*Error{{Message: op.Type + " operations are not supported", Locations: []Location{op.Loc}}}
+		return
+	}
+
+	v := &validator{
+		s:       s,
+		doc:     doc,
+		types:   make(map[string]Type, len(op.Variables)),
+		vars:    make(map[string]any, len(op.Variables)),
+		used:    make(map[string]bool, len(op.Variables)),
+		defined: make(map[string]*VariableDefinition, len(op.Variables)),
+		spread:  make(map[string]bool),
+		budget:  fieldBudget,
+	}
+
+	// variables
+	for _, def := range op.Variables {
+		if _, ok := v.defined[def.Name]; ok {
+			v.errorf(def.Loc, "there can be only one variable named $%s", def.Name)
+			continue
+		}
+		v.defined[def.Name] = def
+		t, tErr := s.typeOf(def.Type)
+		if tErr == nil && !isInputType(t) {
+			tErr = fmt.Errorf("%s is not an input type", def.Type)
+		}
+		if tErr != nil {
+			v.errorf(def.Loc, "variable $%s: %s", def.Name, tErr)
+			continue
+		}
+		v.types[def.Name] = t
+		value, ok := r.Variables[def.Name]
+		if !ok {
+			_, nonNull := t.(*NonNull)
+			switch {
+			case def.Default != nil:
+				value, err = coerceLiteral(def.Default, t, nil)
+			case nonNull:
+				err = fmt.Errorf("a value of type %s is required", t)
+			default:
+				continue
+			}
+		} else {
+			value, err = coerceVariable(value, t)
+		}
+		if err != nil {
+			v.errorf(def.Loc, "variable $%s: %s", def.Name, err)
+			continue
+		}
+		v.vars[def.Name] = value
+	}
+	if len(v.errs) > 0 {
+		errs = v.errs
+		return
+	}
+
+	// selections
+	complexity, depth := v.selections(root, op.Selections)
+	for _, def := range op.Variables {
+		if !v.used[def.Name] {
+			v.errorf(def.Loc, "variable $%s is never used", def.Name)
+		}
+	}
+	if len(v.errs) > 0 {
+		errs = v.errs
+		return
+	}
+
+	// limits
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		v.errorf(op.Loc, "query depth %d exceeds the limit of %d", depth, l.MaxDepth)
+	}
+	if l.MaxComplexity > 0 && complexity > l.MaxComplexity {
+		v.errorf(op.Loc, "query complexity %d exceeds the limit of %d", complexity, l.MaxComplexity)
+	}
+	if len(v.errs) > 0 {
+		errs = v.errs
+		return
+	}
+
+	p = &Prepared{Depth: depth, Complexity: complexity, schema: s, doc: doc, op: op, vars: v.vars}
+	return
+}
+
+// validator is a struct that validates the selections of an operation and computes its depth and complexity
+type validator struct {
+	// s is the schema
+	s *Schema
+	// doc is the document
+	doc *Document
+	// types is the set of the types of the variables by name
+	types map[string]Type
+	// vars is the set of the values of the variables by name
+	vars map[string]any
+	// used is the set of the variables referred to
+	used map[string]bool
+	// defined is the set of the definitions of the variables by name
+	defined map[string]*VariableDefinition
+	// spread is the set of the fragments being visited, to find cycles
+	spread map[string]bool
+	// budget is the number of fields left to visit
+	budget int
+	// errs is the list of the errors found
+	errs []*Error
+}
+
+// errorf is a method that records an error at a location
+func (v *validator) errorf(loc Location, format string, args ...any) {
+	v.errs = append(v.errs, &Error{Message: fmt.Sprintf(format, args...), Locations: []Location{loc}})
+}
+
+// selections is a method that validates a selection set of an object, returning its complexity and depth
+func (v *validator) selections(t *Object, sels []Selection) (complexity, depth int) {
+	for _, sel := range sels {
+		var c, d int
+		var dirs []*Directive
+		switch s := sel.(type) {
+		case *Field:
+			dirs = s.Directives
+			c, d = v.field(t, s)
+		case *FragmentSpread:
+			dirs = s.Directives
+			f, ok := v.doc.Fragments[s.Name]
+			if !ok {
+				v.errorf(s.Loc, "unknown fragment %q", s.Name)
+				continue
+			}
+			if v.spread[s.Name] {
+				v.errorf(s.Loc, "fragment %q spreads itself", s.Name)
+				continue
+			}
+			if !v.condition(t, f.TypeCondition, s.Loc) {
+				continue
+			}
+			v.spread[s.Name] = true
+			c, d = v.selections(t, f.Selections)
+			delete(v.spread, s.Name)
+		case *InlineFragment:
+			dirs = s.Directives
+			if !v.condition(t, s.TypeCondition, s.Loc) {
+				continue
+			}
+			c, d = v.selections(t, s.Selections)
+		}
+		if v.directives(dirs) {
+			complexity, depth = min(complexity+c, complexityCap), max(depth, d)
+		}
+	}
+	return
+}
+
+// condition is a method that reports whether a fragment of a type condition may be spread on an object
+func (v *validator) condition(t *Object, name string, loc Location) bool {
+	if name == "" || name == t.Name {
+		return true
+	}
+	if v.s.Type(name) == nil {
+		v.errorf(loc, "unknown type %q", name)
+	} else {
+		v.errorf(loc, "fragment on %q cannot be spread on %q", name, t.Name)
+	}
+	return false
+}
+
+// field is a method that validates a field of an object, returning its complexity and depth
+func (v *validator) field(t *Object, f *Field) (complexity, depth int) {
+	if v.budget--; v.budget == 0 {
+		v.errorf(f.Loc, "the query selects more than %d fields", fieldBudget)
+	}
+	if v.budget <= 0 {
+		return
+	}
+
+	switch f.Name {
+	case "__typename":
+		if len(f.Arguments) > 0 || len(f.Selections) > 0 {
+			v.errorf(f.Loc, "field \"__typename\" takes no arguments nor selections")
+		}
+		depth = 1
+		return
+	case "__schema", "__type":
+		v.errorf(f.Loc, "introspection is not supported, the schema is available in the schema definition language")
+		return
+	}
+	fd := t.Field(f.Name)
+	if fd == nil {
+		v.errorf(f.Loc, "cannot query field %q on type %q", f.Name, t.Name)
+		return
+	}
+
+	// arguments
+	for _, a := range f.Arguments {
+		if def := findArgument(fd.Args, a.Name); def != nil {
+			v.usage(a.Value, def.Type)
+		}
+	}
+	args, err := coerceArguments(fd.Args, f.Arguments, v.vars)
+	if err != nil {
+		v.errorf(f.Loc, "field %q: %s", f.Name, err)
+		return
+	}
+
+	// selections
+	nt := named(fd.Type)
+	obj, ok := nt.(*Object)
+	switch {
+	case ok && len(f.Selections) == 0:
+		v.errorf(f.Loc, "field %q of type %q must have a selection of subfields", f.Name, fd.Type)
+		return
+	case !ok && len(f.Selections) > 0:
+		v.errorf(f.Loc, "field %q of type %q must not have a selection of subfields", f.Name, fd.Type)
+		return
+	case !ok:
+		complexity, depth = 1, 1
+		return
+	}
+	complexity, depth = v.selections(obj, f.Selections)
+	cost := 1
+	if fd.Cost != nil {
+		cost = min(max(fd.Cost(args), 0), complexityCap)
+	}
+	complexity, depth = min(1+cost*complexity, complexityCap), depth+1
+	return
+}
+
+// directives is a method that validates the directives of a selection, reporting whether it is included
+func (v *validator) directives(dirs []*Directive) bool {
+	for _, d := range dirs {
+		for _, a := range d.Arguments {
+			if a.Name == "if" {
+				v.usage(a.Value, ifArgument[0].Type)
+			}
+		}
+	}
+	ok, err := included(dirs, v.vars)
+	if err != nil {
+		var gErr *Error
+		errors.As(err, &gErr)
+		v.errs = append(v.errs, gErr)
+		return false
+	}
+	return ok
+}
+
+// usage is a method that checks the variables of a value are defined and of a type allowed in the position of the value
+func (v *validator) usage(val *Value, t Type) {
+	switch val.Kind {
+	case ValueVariable:
+		v.used[val.Raw] = true
+		def, ok := v.defined[val.Raw]
+		if !ok {
+			v.errorf(val.Loc, "variable $%s is not defined", val.Raw)
+			return
+		}
+		vt, ok := v.types[val.Raw]
+		if ok && !allowed(vt, t, def.Default != nil) {
+			v.errorf(val.Loc, "variable $%s of type %s cannot be used where %s is expected", val.Raw, vt, t)
+		}
+	case ValueList:
+		elem := t
+		if nn, ok := elem.(*NonNull); ok {
+			elem = nn.Of
+		}
+		if l, ok := elem.(*List); ok {
+			elem = l.Of
+		}
+		for _, value := range val.List {
+			v.usage(value, elem)
+		}
+	case ValueObject:
+		obj, ok := named(t).(*InputObject)
+		for _, a := range val.Fields {
+			if !ok {
+				continue
+			}
+			if def := findArgument(obj.Fields, a.Name); def != nil {
+				v.usage(a.Value, def.Type)
+			}
+		}
+	}
+}
+
+// allowed is a function that reports whether a variable of a type may be used where a type is expected;
+// a nullable variable with a default may be used where a non-null type is expected
+func allowed(vt, t Type, hasDefault bool) bool {
+	if nn, ok := t.(*NonNull); ok {
+		vn, ok := vt.(*NonNull)
+		if !ok {
+			return hasDefault && allowed(vt, nn.Of, false)
+		}
+		return allowed(vn.Of, nn.Of, false)
+	}
+	if vn, ok := vt.(*NonNull); ok {
+		return allowed(vn.Of, t, false)
+	}
+	if l, ok := t.(*List); ok {
+		vl, ok := vt.(*List)
+		return ok && allowed(vl.Of, l.Of, false)
+	}
+	return vt == t
+}
+
+// ifArgument is the list of the arguments of the @skip and @include directives
+var ifArgument = []*ArgumentDefinition{{Name: "if", Type: &NonNull{Of: Boolean}}}
+
+// included is a function that reports whether a selection is included according to its @skip and @include directives
+func included(dirs []*Directive, vars map[string]any) (ok bool, err error) {
+	ok = true
+	for _, d := range dirs {
+		if d.Name != "skip" && d.Name != "include" {
+			err = &Error{Message: fmt.Sprintf("unknown directive @%s", d.Name), Locations: []Location{d.Loc}}
+			return
+		}
+		args, aErr := coerceArguments(ifArgument, d.Arguments, vars)
+		if aErr != nil {
+			err = &Error{Message: fmt.Sprintf("directive @%s: %s", d.Name, aErr), Locations: []Location{d.Loc}}
+			return
+		}
+		if args["if"] == (d.Name == "skip") {
+			ok = false
+		}
+	}
+	return
+}
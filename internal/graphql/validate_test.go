@@ -0,0 +1,277 @@
+package graphql
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newTestSchema is a function that returns a schema of pets and their owners, resolved from maps:
+// the pets and the pets of an owner cost their first argument, 10 by default
+func newTestSchema(t *testing.T) *Schema {
+	t.Helper()
+	first := func(args map[string]any) int {
+		n, _ := args["first"].(int)
+		return n
+	}
+	kind := &Enum{Name: "Kind", Values: []string{"DOG", "CAT"}}
+	pet := &Object{Name: "Pet"}
+	owner := &Object{Name: "Owner", Fields: []*FieldDefinition{
+		{Name: "name", Type: &NonNull{Of: String}},
+		{Name: "pets", Args: []*ArgumentDefinition{{Name: "first", Type: Int, Default: 10}}, Type: &NonNull{Of: &List{Of: &NonNull{Of: pet}}}, Cost: first},
+	}}
+	pet.Fields = []*FieldDefinition{
+		{Name: "id", Type: &NonNull{Of: ID}},
+		{Name: "name", Type: &NonNull{Of: String}},
+		{Name: "kind", Type: &NonNull{Of: kind}},
+		{Name: "owner", Type: owner},
+	}
+	query := &Object{Name: "Query", Fields: []*FieldDefinition{
+		{Name: "pet", Args: []*ArgumentDefinition{{Name: "id", Type: &NonNull{Of: ID}}}, Type: pet},
+		{Name: "pets", Args: []*ArgumentDefinition{{Name: "first", Type: Int, Default: 10}, {Name: "kind", Type: kind}}, Type: &NonNull{Of: &List{Of: &NonNull{Of: pet}}}, Cost: first},
+		{Name: "hello", Args: []*ArgumentDefinition{{Name: "name", Type: String, Default: "world"}, {Name: "greeting", Type: String}}, Type: &NonNull{Of: String}},
+	}}
+	input := &InputObject{Name: "PetInput", Fields: []*ArgumentDefinition{
+		{Name: "name", Type: &NonNull{Of: String}},
+		{Name: "kind", Type: &NonNull{Of: kind}},
+	}}
+	mutation := &Object{Name: "Mutation", Fields: []*FieldDefinition{
+		{Name: "addPet", Args: []*ArgumentDefinition{{Name: "input", Type: &NonNull{Of: input}}}, Type: &NonNull{Of: pet}},
+	}}
+	s, err := NewSchema(query, mutation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// messages is a function that returns the messages of a list of errors
+func messages(errs []*Error) (m []string) {
+	for _, value := range errs {
+		m = append(m, value.Message)
+	}
+	return
+}
+
+func TestSchema_Prepare(t *testing.T) {
+	s := newTestSchema(t)
+	r := Request{
+		Query: `query Other { hello }
+			query Pets($kind: Kind = CAT, $first: Int!) { pets(first: $first, kind: $kind) { name } dogs: pets(kind: DOG) { id } }
+			mutation Add($in: PetInput!) { addPet(input: $in) { id } }`,
+		OperationName: "Pets",
+		Variables:     map[string]any{"first": float64(3)},
+	}
+	p, errs := s.Prepare(r, Limits{})
+	if len(errs) > 0 {
+		t.Fatalf("got errors %v", messages(errs))
+	}
+	if p.Type() != "query" || p.op.Name != "Pets" {
+		t.Errorf("got %s %q, want the query Pets", p.Type(), p.op.Name)
+	}
+	if want := map[string]any{"kind": "CAT", "first": 3}; !reflect.DeepEqual(p.vars, want) {
+		t.Errorf("got variables %v, want %v with the default applied", p.vars, want)
+	}
+
+	r.OperationName, r.Variables = "Add", map[string]any{"in": map[string]any{"name": "Rex", "kind": "DOG"}}
+	if p, errs = s.Prepare(r, Limits{}); len(errs) > 0 {
+		t.Fatalf("got errors %v preparing the mutation", messages(errs))
+	}
+	if p.Type() != "mutation" {
+		t.Errorf("got %s, want the mutation Add", p.Type())
+	}
+	if want := map[string]any{"name": "Rex", "kind": "DOG"}; !reflect.DeepEqual(p.vars["in"], want) {
+		t.Errorf("got input %v, want %v", p.vars["in"], want)
+	}
+}
+
+func TestSchema_Prepare_Valid(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		vars  map[string]any
+	}{
+		{name: "fragment spread twice", query: `{ pets { ...A ...B } } fragment A on Pet { ...C } fragment B on Pet { ...C owner { pets { ...C } } } fragment C on Pet { name }`},
+		{name: "nested fragments", query: `{ pets { ... on Pet { ... { id } } ...F } } fragment F on Pet { owner { ...O } } fragment O on Owner { name }`},
+		{name: "nullable variable with a default in a non-null position", query: `query($id: ID = "1") { pet(id: $id) { name } }`},
+		{name: "non-null variable in a nullable position", query: `query($n: Int!) { pets(first: $n) { name } }`, vars: map[string]any{"n": float64(2)}},
+		{name: "variable in a list and an object", query: `mutation($k: Kind!) { addPet(input: {name: "Rex", kind: $k}) { id } }`, vars: map[string]any{"k": "CAT"}},
+		{name: "variable of a directive", query: `query($b: Boolean!) { hello @include(if: $b) }`, vars: map[string]any{"b": false}},
+		{name: "typename", query: `{ __typename pets { __typename } }`},
+	}
+	s := newTestSchema(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, errs := s.Prepare(Request{Query: tt.query, Variables: tt.vars}, Limits{}); len(errs) > 0 {
+				t.Fatalf("got errors %v, want none", messages(errs))
+			}
+		})
+	}
+}
+
+func TestSchema_Prepare_Invalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		operation string
+		vars      map[string]any
+		message   string
+	}{
+		// - operations
+		{name: "several operations without a name", query: `query A { hello } query B { hello }`, message: "operationName is required when the document has several operations"},
+		{name: "unknown operation", query: `query A { hello }`, operation: "B", message: `unknown operation "B"`},
+		{name: "subscription", query: `subscription { hello }`, message: "subscription operations are not supported"},
+		{name: "syntax error", query: `{ hello `, message: "syntax error: "},
+
+		// - fields and arguments
+		{name: "unknown field", query: `{ pets { color } }`, message: `cannot query field "color" on type "Pet"`},
+		{name: "unknown argument", query: `{ pets(size: 1) { name } }`, message: `field "pets": unknown argument "size"`},
+		{name: "argument given twice", query: `{ pets(first: 1, first: 2) { name } }`, message: `there can be only one argument named "first"`},
+		{name: "argument required", query: `{ pet { name } }`, message: `argument "id" of type ID! is required`},
+		{name: "argument of another type", query: `{ pets(kind: BIRD) { name } }`, message: "expected a value of enum Kind, found BIRD"},
+		{name: "field of an input object missing", query: `mutation { addPet(input: {name: "Rex"}) { id } }`, message: `PetInput: argument "kind" of type Kind! is required`},
+		{name: "selection of a leaf", query: `{ hello { name } }`, message: `field "hello" of type "String!" must not have a selection of subfields`},
+		{name: "object without a selection", query: `{ pets }`, message: `field "pets" of type "[Pet!]!" must have a selection of subfields`},
+		{name: "typename with arguments", query: `{ __typename(x: 1) }`, message: `field "__typename" takes no arguments nor selections`},
+		{name: "introspection", query: `{ __schema { types { name } } }`, message: "introspection is not supported"},
+
+		// - variables
+		{name: "variable not defined", query: `{ pets(first: $n) { name } }`, message: "variable $n is not defined"},
+		{name: "variable never used", query: `query($n: Int) { hello }`, message: "variable $n is never used"},
+		{name: "variable defined twice", query: `query($n: Int, $n: Int) { pets(first: $n) { name } }`, message: "there can be only one variable named $n"},
+		{name: "variable of an unknown type", query: `query($n: Number) { hello }`, message: "variable $n: unknown type Number"},
+		{name: "variable of an output type", query: `query($p: Pet) { hello }`, message: "variable $p: Pet is not an input type"},
+		{name: "variable of another type", query: `query($id: Int!) { pet(id: $id) { name } }`, vars: map[string]any{"id": float64(1)}, message: "variable $id of type Int! cannot be used where ID! is expected"},
+		{name: "nullable variable in a non-null position", query: `query($id: ID) { pet(id: $id) { name } }`, message: "variable $id of type ID cannot be used where ID! is expected"},
+		{name: "variable in an input object", query: `mutation($n: String) { addPet(input: {name: $n, kind: DOG}) { id } }`, message: "variable $n of type String cannot be used where String! is expected"},
+		{name: "variable required", query: `query($id: ID!) { pet(id: $id) { name } }`, message: "variable $id: a value of type ID! is required"},
+		{name: "variable of an invalid value", query: `query($n: Int) { pets(first: $n) { name } }`, vars: map[string]any{"n": "three"}, message: "variable $n: Int cannot represent three"},
+
+		// - fragments
+		{name: "unknown fragment", query: `{ pets { ...F } }`, message: `unknown fragment "F"`},
+		{name: "fragment spreading itself", query: `{ pets { ...F } } fragment F on Pet { name ...F }`, message: `fragment "F" spreads itself`},
+		{name: "fragments spreading each other", query: `{ pets { ...A } } fragment A on Pet { ...B } fragment B on Pet { name ...A }`, message: `fragment "A" spreads itself`},
+		{name: "fragment spreading itself through a field", query: `{ pets { ...F } } fragment F on Pet { owner { pets { ...F } } }`, message: `fragment "F" spreads itself`},
+		{name: "fragment spreading itself in an inline fragment", query: `{ pets { ...F } } fragment F on Pet { ... on Pet { ...F } }`, message: `fragment "F" spreads itself`},
+		{name: "fragment spreading itself under a directive", query: `{ pets { ...F } } fragment F on Pet { name ...F @skip(if: true) }`, message: `fragment "F" spreads itself`},
+		{name: "fragment on another type", query: `{ pets { ...O } } fragment O on Owner { name }`, message: `fragment on "Owner" cannot be spread on "Pet"`},
+		{name: "inline fragment on an unknown type", query: `{ pets { ... on Bird { name } } }`, message: `unknown type "Bird"`},
+
+		// - directives
+		{name: "unknown directive", query: `{ hello @deprecated }`, message: "unknown directive @deprecated"},
+		{name: "directive without its argument", query: `{ hello @skip }`, message: `directive @skip: argument "if" of type Boolean! is required`},
+		{name: "directive of a nullable variable", query: `query($b: Boolean) { hello @include(if: $b) }`, message: "variable $b of type Boolean cannot be used where Boolean! is expected"},
+	}
+	s := newTestSchema(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, errs := s.Prepare(Request{Query: tt.query, OperationName: tt.operation, Variables: tt.vars}, Limits{})
+			if p != nil {
+				t.Fatal("got the operation prepared, want errors")
+			}
+			m := messages(errs)
+			for _, value := range m {
+				if strings.Contains(value, tt.message) {
+					return
+				}
+			}
+			t.Errorf("got errors %q, want one containing %q", m, tt.message)
+		})
+	}
+}
+
+func TestSchema_Prepare_Errors(t *testing.T) {
+	s := newTestSchema(t)
+	_, errs := s.Prepare(Request{Query: "{\n  pets { color }\n  owner\n}"}, Limits{})
+	want := []*Error{
+		{Message: `cannot query field "color" on type "Pet"`, Locations: []Location{{Line: 2, Column: 10}}},
+		{Message: `cannot query field "owner" on type "Query"`, Locations: []Location{{Line: 3, Column: 3}}},
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("got errors %+v, want every error with its location", errs)
+	}
+}
+
+func TestSchema_Prepare_Limits(t *testing.T) {
+	const nested = `{ pets(first: 5) { owner { pets(first: 2) { name } } } }`
+	tests := []struct {
+		name       string
+		query      string
+		vars       map[string]any
+		limits     Limits
+		depth      int
+		complexity int
+		messages   []string
+	}{
+		// - name costs 1, the inner pets 1 + 2*1, owner 1 + 1*3 and the outer pets 1 + 5*4
+		{name: "no limits", query: nested, depth: 4, complexity: 21},
+		{name: "at the limits", query: nested, limits: Limits{MaxDepth: 4, MaxComplexity: 21}, depth: 4, complexity: 21},
+		{name: "too deep", query: nested, limits: Limits{MaxDepth: 3}, messages: []string{"query depth 4 exceeds the limit of 3"}},
+		{name: "too complex", query: nested, limits: Limits{MaxComplexity: 20}, messages: []string{"query complexity 21 exceeds the limit of 20"}},
+		{name: "too deep and complex", query: nested, limits: Limits{MaxDepth: 1, MaxComplexity: 1}, messages: []string{"query depth 4 exceeds the limit of 1", "query complexity 21 exceeds the limit of 1"}},
+		{name: "fragments", query: `{ pets(first: 5) { ...P } } fragment P on Pet { owner { ... on Owner { pets(first: 2) { name } } } }`, depth: 4, complexity: 21},
+		{name: "fragment spread twice", query: `{ pets(first: 5) { ...P ...P } } fragment P on Pet { name }`, depth: 2, complexity: 11},
+		{name: "aliases", query: `{ a: pets(first: 3) { name } b: pets(first: 3) { name } }`, depth: 2, complexity: 8},
+		{name: "default cost", query: `{ pets { name id } }`, depth: 2, complexity: 21},
+		{name: "cost of a variable", query: `query($n: Int) { pets(first: $n) { name } }`, vars: map[string]any{"n": float64(3)}, depth: 2, complexity: 4},
+		{name: "skipped", query: `query($deep: Boolean = false) { pets(first: 5) { name owner @include(if: $deep) { pets(first: 2) { name } } } }`, depth: 2, complexity: 6},
+		{name: "included", query: `query($deep: Boolean = false) { pets(first: 5) { name owner @include(if: $deep) { pets(first: 2) { name } } } }`, vars: map[string]any{"deep": true}, depth: 4, complexity: 26},
+		{name: "negative cost", query: `{ pets(first: -5) { name } }`, depth: 2, complexity: 1},
+		{name: "capped complexity", query: `{ pets(first: 2000000000) { owner { pets(first: 2000000000) { owner { pets(first: 2000000000) { name } } } } } }`, limits: Limits{MaxComplexity: 1000}, messages: []string{fmt.Sprintf("query complexity %d exceeds the limit of 1000", complexityCap)}},
+	}
+	s := newTestSchema(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, errs := s.Prepare(Request{Query: tt.query, Variables: tt.vars}, tt.limits)
+			if m := messages(errs); !reflect.DeepEqual(m, tt.messages) {
+				t.Fatalf("got errors %q, want %q", m, tt.messages)
+			}
+			if p != nil && (p.Depth != tt.depth || p.Complexity != tt.complexity) {
+				t.Errorf("got depth %d and complexity %d, want %d and %d", p.Depth, p.Complexity, tt.depth, tt.complexity)
+			}
+		})
+	}
+}
+
+func TestSchema_Prepare_FieldBudget(t *testing.T) {
+	// - every fragment spreads the previous one twice, so the query selects 2^20 fields in a few hundred bytes
+	var b strings.Builder
+	b.WriteString("{ pets { ...F20 } }\nfragment F0 on Pet { name }\n")
+	for i := 1; i <= 20; i++ {
+		fmt.Fprintf(&b, "fragment F%d on Pet { ...F%d ...F%d }\n", i, i-1, i-1)
+	}
+	_, errs := newTestSchema(t).Prepare(Request{Query: b.String()}, Limits{})
+	if want := []string{fmt.Sprintf("the query selects more than %d fields", fieldBudget)}; !reflect.DeepEqual(messages(errs), want) {
+		t.Errorf("got errors %q, want %q", messages(errs), want)
+	}
+}
+
+func TestVehicleSchema_Limits(t *testing.T) {
+	s, err := NewVehicleSchema(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		query      string
+		depth      int
+		complexity int
+	}{
+		// - nodes cost 1 + 1*(1 + 2) and the vehicles 1 + first*4
+		{name: "default page", query: `{ vehicles { nodes { id transitions { at } } } }`, depth: 4, complexity: 1 + defaultPageSize*4},
+		{name: "page over the maximum", query: `{ vehicles(first: 5000) { nodes { id transitions { at } } } }`, depth: 4, complexity: 1 + maxPageSize*4},
+		{name: "single vehicle", query: `{ vehicle(id: "1") { dimensions { height } } }`, depth: 3, complexity: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, errs := s.Prepare(Request{Query: tt.query}, Limits{})
+			if len(errs) > 0 {
+				t.Fatalf("got errors %v", messages(errs))
+			}
+			if p.Depth != tt.depth || p.Complexity != tt.complexity {
+				t.Errorf("got depth %d and complexity %d, want %d and %d", p.Depth, p.Complexity, tt.depth, tt.complexity)
+			}
+		})
+	}
+}
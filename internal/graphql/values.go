@@ -0,0 +1,206 @@
+package graphql
+
+import (
+	"fmt"
+)
+
+// coerceArguments is a function that coerces the arguments of a field or a directive to their definitions, applying defaults.
+// Arguments given a variable that was not provided are left out, as if they were not given
+func coerceArguments(defs []*ArgumentDefinition, args []*Argument, vars map[string]any) (r map[string]any, err error) {
+	r = make(map[string]any, len(defs))
+	given := make(map[string]*Argument, len(args))
+	for _, a := range args {
+		if _, ok := given[a.Name]; ok {
+			err = fmt.Errorf("there can be only one argument named %q", a.Name)
+			return
+		}
+		given[a.Name] = a
+	}
+	for _, a := range args {
+		if findArgument(defs, a.Name) == nil {
+			err = fmt.Errorf("unknown argument %q", a.Name)
+			return
+		}
+	}
+	for _, d := range defs {
+		a, ok := given[d.Name]
+		if ok && a.Value.Kind == ValueVariable {
+			_, ok = vars[a.Value.Raw]
+		}
+		if !ok {
+			if d.Default != nil {
+				r[d.Name] = d.Default
+			} else if _, nonNull := d.Type.(*NonNull); nonNull {
+				err = fmt.Errorf("argument %q of type %s is required", d.Name, d.Type)
+				return
+			}
+			continue
+		}
+		var v any
+		if v, err = coerceLiteral(a.Value, d.Type, vars); err != nil {
+			err = fmt.Errorf("argument %q: %w", d.Name, err)
+			return
+		}
+		r[d.Name] = v
+	}
+	return
+}
+
+// findArgument is a function that returns an argument definition by name, nil when there is none
+func findArgument(defs []*ArgumentDefinition, name string) *ArgumentDefinition {
+	for _, value := range defs {
+		if value.Name == name {
+			return value
+		}
+	}
+	return nil
+}
+
+// coerceLiteral is a function that coerces a literal of a document to a type, variables are taken from vars already coerced
+func coerceLiteral(v *Value, t Type, vars map[string]any) (r any, err error) {
+	if v.Kind == ValueVariable {
+		var ok bool
+		if r, ok = vars[v.Raw]; !ok {
+			r = nil
+		}
+		if _, nonNull := t.(*NonNull); nonNull && r == nil {
+			err = fmt.Errorf("variable $%s of a non-null position is null", v.Raw)
+		}
+		return
+	}
+	if nn, ok := t.(*NonNull); ok {
+		if v.Kind == ValueNull {
+			err = fmt.Errorf("expected a value of type %s, found null", t)
+			return
+		}
+		r, err = coerceLiteral(v, nn.Of, vars)
+		return
+	}
+	if v.Kind == ValueNull {
+		return
+	}
+
+	switch tt := t.(type) {
+	case *List:
+		if v.Kind != ValueList {
+			// - a single value is a list of one
+			var elem any
+			if elem, err = coerceLiteral(v, tt.Of, vars); err != nil {
+				return
+			}
+			r = []any{elem}
+			return
+		}
+		list := make([]any, 0, len(v.List))
+		for i, value := range v.List {
+			var elem any
+			if elem, err = coerceLiteral(value, tt.Of, vars); err != nil {
+				err = fmt.Errorf("at index %d: %w", i, err)
+				return
+			}
+			list = append(list, elem)
+		}
+		r = list
+	case *Scalar:
+		r, err = tt.ParseLiteral(v)
+	case *Enum:
+		if v.Kind != ValueEnum || !tt.has(v.Raw) {
+			err = fmt.Errorf("expected a value of enum %s, found %s", tt.Name, v)
+			return
+		}
+		r = v.Raw
+	case *InputObject:
+		if v.Kind != ValueObject {
+			err = fmt.Errorf("expected an object of type %s, found %s", tt.Name, v)
+			return
+		}
+		r, err = coerceArguments(tt.Fields, v.Fields, vars)
+		if err != nil {
+			err = fmt.Errorf("%s: %w", tt.Name, err)
+		}
+	default:
+		err = fmt.Errorf("%s is not an input type", t)
+	}
+	return
+}
+
+// coerceVariable is a function that coerces the value of a variable, decoded from JSON, to a type
+func coerceVariable(v any, t Type) (r any, err error) {
+	if nn, ok := t.(*NonNull); ok {
+		if v == nil {
+			err = fmt.Errorf("expected a value of type %s, found null", t)
+			return
+		}
+		r, err = coerceVariable(v, nn.Of)
+		return
+	}
+	if v == nil {
+		return
+	}
+
+	switch tt := t.(type) {
+	case *List:
+		list, ok := v.([]any)
+		if !ok {
+			// - a single value is a list of one
+			var elem any
+			if elem, err = coerceVariable(v, tt.Of); err != nil {
+				return
+			}
+			r = []any{elem}
+			return
+		}
+		out := make([]any, 0, len(list))
+		for i, value := range list {
+			var elem any
+			if elem, err = coerceVariable(value, tt.Of); err != nil {
+				err = fmt.Errorf("at index %d: %w", i, err)
+				return
+			}
+			out = append(out, elem)
+		}
+		r = out
+	case *Scalar:
+		r, err = tt.ParseValue(v)
+	case *Enum:
+		s, ok := v.(string)
+		if !ok || !tt.has(s) {
+			err = fmt.Errorf("expected a value of enum %s, found %v", tt.Name, v)
+			return
+		}
+		r = s
+	case *InputObject:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			err = fmt.Errorf("expected an object of type %s, found %v", tt.Name, v)
+			return
+		}
+		for key := range obj {
+			if findArgument(tt.Fields, key) == nil {
+				err = fmt.Errorf("%s: unknown field %q", tt.Name, key)
+				return
+			}
+		}
+		out := make(map[string]any, len(tt.Fields))
+		for _, f := range tt.Fields {
+			value, ok := obj[f.Name]
+			if !ok {
+				if f.Default != nil {
+					out[f.Name] = f.Default
+				} else if _, nonNull := f.Type.(*NonNull); nonNull {
+					err = fmt.Errorf("%s: field %q of type %s is required", tt.Name, f.Name, f.Type)
+					return
+				}
+				continue
+			}
+			if out[f.Name], err = coerceVariable(value, f.Type); err != nil {
+				err = fmt.Errorf("%s: field %q: %w", tt.Name, f.Name, err)
+				return
+			}
+		}
+		r = out
+	default:
+		err = fmt.Errorf("%s is not an input type", t)
+	}
+	return
+}
@@ -0,0 +1,539 @@
+package graphql
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+const (
+	// defaultPageSize is the number of vehicles of a page when first is not given
+	defaultPageSize = 20
+	// maxPageSize is the largest number of vehicles of a page
+	maxPageSize = 100
+	// cursorPrefix is the prefix of the id of the last vehicle of a page in a cursor, before it is encoded
+	cursorPrefix = "vehicle:"
+)
+
+var (
+	// errInternal is the error of a field that failed for a reason not shown to clients
+	errInternal = errors.New("internal error")
+)
+
+// NewVehicleSchema is a function that returns the schema of the vehicles, resolved against a vehicle service:
+// queries of a vehicle, pages of vehicles matching a filter and their stats, and mutations creating and updating vehicles
+func NewVehicleSchema(sv internal.VehicleService) (s *Schema, err error) {
+	vs := &vehicleSchema{sv: sv}
+
+	// types
+	status := &Enum{
+		Name:        "VehicleStatus",
+		Description: "Lifecycle status of a vehicle",
+		Values:      make([]string, 0, len(internal.VehicleStatuses)),
+	}
+	for _, value := range internal.VehicleStatuses {
+		status.Values = append(status.Values, strings.ToUpper(string(value)))
+	}
+	dimensions := &Object{
+		Name:        "Dimensions",
+		Description: "Dimensions of a vehicle",
+		Fields: []*FieldDefinition{
+			dimensionsField("height", func(d internal.Dimensions) float64 { return d.Height }),
+			dimensionsField("length", func(d internal.Dimensions) float64 { return d.Length }),
+			dimensionsField("width", func(d internal.Dimensions) float64 { return d.Width }),
+		},
+	}
+	transition := &Object{
+		Name:        "Transition",
+		Description: "Change of status of a vehicle",
+		Fields: []*FieldDefinition{
+			transitionField("from", &NonNull{Of: status}, func(t internal.StatusTransition) any { return statusName(t.From) }),
+			transitionField("to", &NonNull{Of: status}, func(t internal.StatusTransition) any { return statusName(t.To) }),
+			transitionField("at", &NonNull{Of: String}, func(t internal.StatusTransition) any { return t.At.Format(time.RFC3339) }),
+			transitionField("reason", &NonNull{Of: String}, func(t internal.StatusTransition) any { return t.Reason }),
+		},
+	}
+	vehicle := &Object{
+		Name:        "Vehicle",
+		Description: "Vehicle of the fleet",
+		Fields: []*FieldDefinition{
+			vehicleField("id", &NonNull{Of: ID}, func(v internal.Vehicle) any { return v.Id }),
+			vehicleField("uid", String, func(v internal.Vehicle) any {
+				if v.UID == "" {
+					return nil
+				}
+				return v.UID
+			}),
+			vehicleField("brand", &NonNull{Of: String}, func(v internal.Vehicle) any { return v.Brand }),
+			vehicleField("model", &NonNull{Of: String}, func(v internal.Vehicle) any { return v.Model }),
+			vehicleField("registration", &NonNull{Of: String}, func(v internal.Vehicle) any { return v.Registration }),
+			vehicleField("color", &NonNull{Of: String}, func(v internal.Vehicle) any { return v.Color }),
+			vehicleField("year", &NonNull{Of: Int}, func(v internal.Vehicle) any { return v.FabricationYear }),
+			vehicleField("passengers", &NonNull{Of: Int}, func(v internal.Vehicle) any { return v.Capacity }),
+			vehicleField("maxSpeed", &NonNull{Of: Float}, func(v internal.Vehicle) any { return v.MaxSpeed }),
+			vehicleField("fuelType", &NonNull{Of: String}, func(v internal.Vehicle) any { return v.FuelType }),
+			vehicleField("transmission", &NonNull{Of: String}, func(v internal.Vehicle) any { return v.Transmission }),
+			vehicleField("weight", &NonNull{Of: Float}, func(v internal.Vehicle) any { return v.Weight }),
+			vehicleField("status", &NonNull{Of: status}, func(v internal.Vehicle) any { return statusName(v.Status) }),
+			vehicleField("dimensions", &NonNull{Of: dimensions}, func(v internal.Vehicle) any { return v.Dimensions }),
+			vehicleField("transitions", &NonNull{Of: &List{Of: &NonNull{Of: transition}}}, func(v internal.Vehicle) any {
+				if v.Transitions == nil {
+					return []internal.StatusTransition{}
+				}
+				return v.Transitions
+			}),
+		},
+	}
+	pageInfo := &Object{
+		Name:        "PageInfo",
+		Description: "Position of a page in a list",
+		Fields: []*FieldDefinition{
+			{Name: "endCursor", Description: "Cursor of the last element of the page, to pass as after for the next page", Type: String},
+			{Name: "hasNextPage", Description: "Whether there are elements after the page", Type: &NonNull{Of: Boolean}},
+		},
+	}
+	connection := &Object{
+		Name:        "VehicleConnection",
+		Description: "Page of vehicles, ordered by id",
+		Fields: []*FieldDefinition{
+			{Name: "nodes", Type: &NonNull{Of: &List{Of: &NonNull{Of: vehicle}}}},
+			{Name: "totalCount", Description: "Number of vehicles matching the filter, in every page", Type: &NonNull{Of: Int}},
+			{Name: "pageInfo", Type: &NonNull{Of: pageInfo}},
+		},
+	}
+	count := &Object{
+		Name:        "Count",
+		Description: "Number of vehicles sharing a value",
+		Fields: []*FieldDefinition{
+			{Name: "key", Type: &NonNull{Of: String}},
+			{Name: "count", Type: &NonNull{Of: Int}},
+		},
+	}
+	stats := &Object{
+		Name:        "VehicleStats",
+		Description: "Aggregates of the vehicles matching a filter",
+		Fields: []*FieldDefinition{
+			{Name: "count", Type: &NonNull{Of: Int}},
+			{Name: "averageMaxSpeed", Description: "Average maximum speed, null when no vehicle matches", Type: Float},
+			{Name: "byStatus", Description: "Number of vehicles by status, most frequent first", Type: &NonNull{Of: &List{Of: &NonNull{Of: count}}}},
+			{Name: "byBrand", Description: "Number of vehicles by brand, most frequent first", Type: &NonNull{Of: &List{Of: &NonNull{Of: count}}}},
+		},
+	}
+	filter := &InputObject{
+		Name:        "VehicleFilter",
+		Description: "Conditions vehicles must all meet, text is matched case-insensitively",
+		Fields: []*ArgumentDefinition{
+			{Name: "brand", Type: String},
+			{Name: "model", Type: String},
+			{Name: "color", Type: String},
+			{Name: "fuelType", Type: String},
+			{Name: "status", Description: "Statuses a vehicle must be in any of", Type: &List{Of: &NonNull{Of: status}}},
+			{Name: "yearMin", Type: Int},
+			{Name: "yearMax", Type: Int},
+			{Name: "minPassengers", Type: Int},
+		},
+	}
+	dimensionsInput := &InputObject{
+		Name: "DimensionsInput",
+		Fields: []*ArgumentDefinition{
+			{Name: "height", Type: &NonNull{Of: Float}},
+			{Name: "length", Type: &NonNull{Of: Float}},
+			{Name: "width", Type: &NonNull{Of: Float}},
+		},
+	}
+	input := &InputObject{
+		Name:        "VehicleInput",
+		Description: "Attributes of a new vehicle",
+		Fields: []*ArgumentDefinition{
+			{Name: "brand", Type: &NonNull{Of: String}},
+			{Name: "model", Type: &NonNull{Of: String}},
+			{Name: "registration", Type: String},
+			{Name: "color", Type: String},
+			{Name: "year", Type: &NonNull{Of: Int}},
+			{Name: "passengers", Type: &NonNull{Of: Int}},
+			{Name: "maxSpeed", Type: Float},
+			{Name: "fuelType", Type: String},
+			{Name: "transmission", Type: String},
+			{Name: "weight", Type: Float},
+			{Name: "dimensions", Type: dimensionsInput},
+			{Name: "status", Description: "Status of the vehicle, IN_SERVICE by default", Type: status},
+		},
+	}
+	update := &InputObject{
+		Name:        "VehicleUpdateInput",
+		Description: "Attributes of a vehicle to change, the ones not given are kept",
+		Fields: []*ArgumentDefinition{
+			{Name: "brand", Type: String},
+			{Name: "model", Type: String},
+			{Name: "registration", Type: String},
+			{Name: "color", Type: String},
+			{Name: "year", Type: Int},
+			{Name: "passengers", Type: Int},
+			{Name: "maxSpeed", Type: Float},
+			{Name: "fuelType", Type: String},
+			{Name: "transmission", Type: String},
+			{Name: "weight", Type: Float},
+			{Name: "dimensions", Type: dimensionsInput},
+		},
+	}
+
+	// operations
+	query := &Object{
+		Name: "Query",
+		Fields: []*FieldDefinition{
+			{
+				Name:        "vehicle",
+				Description: "Vehicle by id, null when there is none",
+				Args:        []*ArgumentDefinition{{Name: "id", Type: &NonNull{Of: ID}}},
+				Type:        vehicle,
+				Resolve:     vs.vehicle,
+			},
+			{
+				Name:        "vehicles",
+				Description: "Page of the vehicles matching a filter, ordered by id",
+				Args: []*ArgumentDefinition{
+					{Name: "filter", Type: filter},
+					{Name: "first", Description: fmt.Sprintf("Number of vehicles of the page, at most %d", maxPageSize), Type: Int, Default: defaultPageSize},
+					{Name: "after", Description: "Cursor of the vehicle the page starts after", Type: String},
+				},
+				Type:    &NonNull{Of: connection},
+				Resolve: vs.vehicles,
+				Cost: func(args map[string]any) int {
+					n, _ := args["first"].(int)
+					return min(max(n, 1), maxPageSize)
+				},
+			},
+			{
+				Name:        "stats",
+				Description: "Aggregates of the vehicles matching a filter",
+				Args:        []*ArgumentDefinition{{Name: "filter", Type: filter}},
+				Type:        &NonNull{Of: stats},
+				Resolve:     vs.stats,
+			},
+		},
+	}
+	mutation := &Object{
+		Name: "Mutation",
+		Fields: []*FieldDefinition{
+			{
+				Name:        "createVehicle",
+				Description: "Creates a vehicle",
+				Args:        []*ArgumentDefinition{{Name: "input", Type: &NonNull{Of: input}}},
+				Type:        &NonNull{Of: vehicle},
+				Resolve:     vs.createVehicle,
+			},
+			{
+				Name:        "updateVehicle",
+				Description: "Changes the attributes of a vehicle, its status and history are kept",
+				Args: []*ArgumentDefinition{
+					{Name: "id", Type: &NonNull{Of: ID}},
+					{Name: "input", Type: &NonNull{Of: update}},
+				},
+				Type:    &NonNull{Of: vehicle},
+				Resolve: vs.updateVehicle,
+			},
+		},
+	}
+
+	s, err = NewSchema(query, mutation)
+	return
+}
+
+// vehicleField is a function that returns a field of the Vehicle type resolved from an internal.Vehicle
+func vehicleField(name string, t Type, fn func(v internal.Vehicle) any) *FieldDefinition {
+	return &FieldDefinition{Name: name, Type: t, Resolve: func(p ResolveParams) (v any, err error) {
+		v = fn(p.Source.(internal.Vehicle))
+		return
+	}}
+}
+
+// dimensionsField is a function that returns a field of the Dimensions type resolved from an internal.Dimensions
+func dimensionsField(name string, fn func(d internal.Dimensions) float64) *FieldDefinition {
+	return &FieldDefinition{Name: name, Type: &NonNull{Of: Float}, Resolve: func(p ResolveParams) (v any, err error) {
+		v = fn(p.Source.(internal.Dimensions))
+		return
+	}}
+}
+
+// transitionField is a function that returns a field of the Transition type resolved from an internal.StatusTransition
+func transitionField(name string, t Type, fn func(t internal.StatusTransition) any) *FieldDefinition {
+	return &FieldDefinition{Name: name, Type: t, Resolve: func(p ResolveParams) (v any, err error) {
+		v = fn(p.Source.(internal.StatusTransition))
+		return
+	}}
+}
+
+// statusName is a function that returns the value of the VehicleStatus enum of a status
+func statusName(s internal.VehicleStatus) string {
+	return strings.ToUpper(string(s))
+}
+
+// vehicleSchema is a struct with methods that resolve the operations of the vehicle schema
+type vehicleSchema struct {
+	// sv is the service of the vehicles
+	sv internal.VehicleService
+}
+
+// vehicle is a method that resolves the field Query.vehicle
+func (s *vehicleSchema) vehicle(p ResolveParams) (r any, err error) {
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return
+	}
+	v, err := s.sv.FindByID(id)
+	if err != nil {
+		if errors.Is(err, internal.ErrVehicleNotFound) {
+			err = nil
+			return
+		}
+		err = internalError(err)
+		return
+	}
+	r = v
+	return
+}
+
+// vehicles is a method that resolves the field Query.vehicles
+func (s *vehicleSchema) vehicles(p ResolveParams) (r any, err error) {
+	first, _ := p.Args["first"].(int)
+	if first < 0 || first > maxPageSize {
+		err = fmt.Errorf("first must be between 0 and %d", maxPageSize)
+		return
+	}
+	afterID, hasAfter := 0, false
+	if after, ok := p.Args["after"].(string); ok {
+		if afterID, err = parseCursor(after); err != nil {
+			return
+		}
+		hasAfter = true
+	}
+	v, err := s.filter(p.Args["filter"])
+	if err != nil {
+		return
+	}
+
+	// - the page starts after the cursor, even when its vehicle was deleted
+	start := 0
+	if hasAfter {
+		start = sort.Search(len(v), func(i int) bool { return v[i].Id > afterID })
+	}
+	end := min(start+first, len(v))
+	var endCursor any
+	if end > start {
+		endCursor = newCursor(v[end-1].Id)
+	}
+	r = map[string]any{
+		"nodes":      v[start:end],
+		"totalCount": len(v),
+		"pageInfo": map[string]any{
+			"endCursor":   endCursor,
+			"hasNextPage": end < len(v),
+		},
+	}
+	return
+}
+
+// stats is a method that resolves the field Query.stats
+func (s *vehicleSchema) stats(p ResolveParams) (r any, err error) {
+	v, err := s.filter(p.Args["filter"])
+	if err != nil {
+		return
+	}
+	var averageMaxSpeed any
+	byStatus, byBrand := make(map[string]int), make(map[string]int)
+	var total float64
+	for _, value := range v {
+		total += value.MaxSpeed
+		byStatus[statusName(value.Status)]++
+		byBrand[value.Brand]++
+	}
+	if len(v) > 0 {
+		averageMaxSpeed = total / float64(len(v))
+	}
+	r = map[string]any{
+		"count":           len(v),
+		"averageMaxSpeed": averageMaxSpeed,
+		"byStatus":        counts(byStatus),
+		"byBrand":         counts(byBrand),
+	}
+	return
+}
+
+// createVehicle is a method that resolves the field Mutation.createVehicle
+func (s *vehicleSchema) createVehicle(p ResolveParams) (r any, err error) {
+	in := p.Args["input"].(map[string]any)
+	v := internal.Vehicle{}
+	mergeAttributes(&v.VehicleAttributes, in)
+	if st, ok := in["status"].(string); ok {
+		v.Status = internal.VehicleStatus(strings.ToLower(st))
+	}
+	if err = s.sv.Create(&v); err != nil {
+		err = mutationError(err)
+		return
+	}
+	r = v
+	return
+}
+
+// updateVehicle is a method that resolves the field Mutation.updateVehicle
+func (s *vehicleSchema) updateVehicle(p ResolveParams) (r any, err error) {
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return
+	}
+	v, err := s.sv.FindByID(id)
+	if err != nil {
+		err = mutationError(err)
+		return
+	}
+	attrs := v.VehicleAttributes
+	mergeAttributes(&attrs, p.Args["input"].(map[string]any))
+	if v, err = s.sv.Update(id, attrs); err != nil {
+		err = mutationError(err)
+		return
+	}
+	r = v
+	return
+}
+
+// filter is a method that returns the vehicles matching a VehicleFilter, ordered by id
+func (s *vehicleSchema) filter(arg any) (v []internal.Vehicle, err error) {
+	f, _ := arg.(map[string]any)
+	all, err := s.sv.FindAll()
+	if err != nil {
+		err = internalError(err)
+		return
+	}
+	var statuses internal.StatusFilter
+	if list, ok := f["status"].([]any); ok {
+		for _, value := range list {
+			statuses = append(statuses, internal.VehicleStatus(strings.ToLower(value.(string))))
+		}
+	}
+	text := func(key, value string) bool {
+		want, ok := f[key].(string)
+		return !ok || strings.EqualFold(strings.TrimSpace(want), value)
+	}
+	atLeast := func(key string, value int) bool {
+		n, ok := f[key].(int)
+		return !ok || value >= n
+	}
+	atMost := func(key string, value int) bool {
+		n, ok := f[key].(int)
+		return !ok || value <= n
+	}
+
+	v = make([]internal.Vehicle, 0, len(all))
+	for _, value := range all {
+		if text("brand", value.Brand) && text("model", value.Model) && text("color", value.Color) && text("fuelType", value.FuelType) &&
+			statuses.Contains(value.Status) && atLeast("yearMin", value.FabricationYear) && atMost("yearMax", value.FabricationYear) &&
+			atLeast("minPassengers", value.Capacity) {
+			v = append(v, value)
+		}
+	}
+	sort.Slice(v, func(i, j int) bool { return v[i].Id < v[j].Id })
+	return
+}
+
+// mergeAttributes is a function that sets the attributes given in a VehicleInput or a VehicleUpdateInput
+func mergeAttributes(a *internal.VehicleAttributes, in map[string]any) {
+	for key, value := range in {
+		if value == nil {
+			continue
+		}
+		switch key {
+		case "brand":
+			a.Brand = value.(string)
+		case "model":
+			a.Model = value.(string)
+		case "registration":
+			a.Registration = value.(string)
+		case "color":
+			a.Color = value.(string)
+		case "year":
+			a.FabricationYear = value.(int)
+		case "passengers":
+			a.Capacity = value.(int)
+		case "maxSpeed":
+			a.MaxSpeed = value.(float64)
+		case "fuelType":
+			a.FuelType = value.(string)
+		case "transmission":
+			a.Transmission = value.(string)
+		case "weight":
+			a.Weight = value.(float64)
+		case "dimensions":
+			d := value.(map[string]any)
+			a.Dimensions = internal.Dimensions{Height: d["height"].(float64), Length: d["length"].(float64), Width: d["width"].(float64)}
+		}
+	}
+}
+
+// counts is a function that returns the counts of a set of values as Count objects, most frequent first then by key
+func counts(m map[string]int) (c []map[string]any) {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if m[keys[i]] != m[keys[j]] {
+			return m[keys[i]] > m[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	c = make([]map[string]any, 0, len(keys))
+	for _, key := range keys {
+		c = append(c, map[string]any{"key": key, "count": m[key]})
+	}
+	return
+}
+
+// parseID is a function that returns the id of a vehicle from an ID argument
+func parseID(arg any) (id int, err error) {
+	s, _ := arg.(string)
+	if id, err = strconv.Atoi(s); err != nil || id <= 0 {
+		err = fmt.Errorf("invalid id %q", s)
+	}
+	return
+}
+
+// newCursor is a function that returns the opaque cursor of a vehicle id
+func newCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(id)))
+}
+
+// parseCursor is a function that returns the vehicle id of a cursor
+func parseCursor(c string) (id int, err error) {
+	b, err := base64.RawURLEncoding.DecodeString(c)
+	if err == nil && strings.HasPrefix(string(b), cursorPrefix) {
+		id, err = strconv.Atoi(strings.TrimPrefix(string(b), cursorPrefix))
+	} else if err == nil {
+		err = errors.New("no prefix")
+	}
+	if err != nil {
+		err = fmt.Errorf("invalid cursor %q", c)
+	}
+	return
+}
+
+// mutationError is a function that returns the error of a mutation shown to clients
+func mutationError(err error) error {
+	switch {
+	case errors.Is(err, internal.ErrVehicleNotFound), errors.Is(err, internal.ErrVehicleInvalid):
+		return err
+	}
+	return internalError(err)
+}
+
+// internalError is a function that logs an error and returns the error shown to clients instead
+func internalError(err error) error {
+	log.Printf("graphql: %v", err)
+	return errInternal
+}
@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bootcamp-go/web/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/rhinosc/code-review-1/internal/backup"
+	"github.com/rhinosc/code-review-1/internal/reload"
+)
+
+// ReloadJSON is a struct that represents the outcome of a reload in JSON format
+type ReloadJSON struct {
+	Reloaded   bool   `json:"reloaded"`
+	Vehicles   int    `json:"vehicles"`
+	Checksum   string `json:"checksum"`
+	Rejected   int    `json:"rejected"`
+	Duplicates []int  `json:"duplicates"`
+}
+
+// ReloadStatusJSON is a struct that represents the state of the reloads in JSON format
+type ReloadStatusJSON struct {
+	LastReloadAt *time.Time `json:"last_reload_at"`
+	Checksum     string     `json:"checksum"`
+	Failures     int        `json:"failures"`
+	LastError    string     `json:"last_error,omitempty"`
+	LastErrorAt  *time.Time `json:"last_error_at,omitempty"`
+	Retrying     bool       `json:"retrying"`
+}
+
+// newReloadStatusJSON is a function that serializes the state of the reloads to JSON
+func newReloadStatusJSON(s reload.Status) (data ReloadStatusJSON) {
+	data = ReloadStatusJSON{
+		Checksum:  s.Checksum,
+		Failures:  s.Failures,
+		LastError: s.LastError,
+		Retrying:  s.Retrying,
+	}
+	if !s.LastReloadAt.IsZero() {
+		data.LastReloadAt = &s.LastReloadAt
+	}
+	if !s.LastErrorAt.IsZero() {
+		data.LastErrorAt = &s.LastErrorAt
+	}
+	return
+}
+
+// BackupJSON is a struct that represents a backup in JSON format
+type BackupJSON struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Vehicles  int       `json:"vehicles"`
+	Size      int64     `json:"size"`
+	Checksum  string    `json:"checksum"`
+}
+
+// newBackupJSON is a function that serializes a backup to JSON
+func newBackupJSON(b backup.Backup) BackupJSON {
+	return BackupJSON{
+		ID:        b.ID,
+		CreatedAt: b.CreatedAt,
+		Vehicles:  b.Vehicles,
+		Size:      b.Size,
+		Checksum:  b.Checksum,
+	}
+}
+
+// NewAdminDefault is a function that returns a new instance of AdminDefault
+func NewAdminDefault(wt *reload.Watcher, bk *backup.Manager) *AdminDefault {
+	return &AdminDefault{wt: wt, bk: bk}
+}
+
+// AdminDefault is a struct with methods that represent handlers for administration tasks
+type AdminDefault struct {
+	// wt is the watcher that reloads the vehicles file
+	wt *reload.Watcher
+	// bk is the manager of the backups
+	bk *backup.Manager
+}
+
+// Reload is a method that returns a handler for the route POST /admin/reload
+func (h *AdminDefault) Reload() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// ...
+
+		// process
+		// - reload the vehicles file
+		rl, err := h.wt.Reload()
+		if err != nil {
+			switch {
+			case errors.Is(err, reload.ErrReloadEmpty):
+				response.JSON(w, http.StatusUnprocessableEntity, "the file has no vehicles, the reload was discarded")
+			case errors.Is(err, reload.ErrReloadInvalid):
+				response.JSON(w, http.StatusUnprocessableEntity, "the file could not be reloaded: "+err.Error())
+			case errors.Is(err, reload.ErrReloadConflict):
+				response.JSON(w, http.StatusConflict, "the file was saved by the server during the reload, try again")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		data := ReloadJSON{
+			Reloaded: rl.Reloaded,
+			Vehicles: rl.Vehicles,
+			Checksum: rl.Checksum,
+		}
+		if rl.Report != nil {
+			data.Rejected = rl.Report.Rejected
+			data.Duplicates = rl.Report.Duplicates
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
+
+// ReloadStatus is a method that returns a handler for the route GET /admin/reload, the state of the reloads,
+// manual or by the watcher, so that a file that keeps failing to reload is noticed
+func (h *AdminDefault) ReloadStatus() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// ...
+
+		// process
+		st := h.wt.Status()
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    newReloadStatusJSON(st),
+		})
+	}
+}
+
+// CreateBackup is a method that returns a handler for the route POST /admin/backups
+func (h *AdminDefault) CreateBackup() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// ...
+
+		// process
+		// - take a snapshot of the vehicles
+		b, err := h.bk.Create()
+		if err != nil {
+			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusCreated, map[string]any{
+			"message": "success",
+			"data":    newBackupJSON(b),
+		})
+	}
+}
+
+// GetBackups is a method that returns a handler for the route GET /admin/backups
+func (h *AdminDefault) GetBackups() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// ...
+
+		// process
+		b, err := h.bk.List()
+		if err != nil {
+			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		// response
+		data := make([]BackupJSON, 0, len(b))
+		for _, value := range b {
+			data = append(data, newBackupJSON(value))
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
+
+// RestoreBackup is a method that returns a handler for the route POST /admin/backups/{id}/restore
+func (h *AdminDefault) RestoreBackup() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id := chi.URLParam(r, "id")
+
+		// process
+		// - replace the vehicles with the ones of the backup
+		b, err := h.bk.Restore(id)
+		if err != nil {
+			switch {
+			case errors.Is(err, backup.ErrBackupNotFound):
+				response.JSON(w, http.StatusNotFound, "backup not found")
+			case errors.Is(err, backup.ErrBackupCorrupt):
+				response.JSON(w, http.StatusUnprocessableEntity, "the backup could not be restored: "+err.Error())
+			case errors.Is(err, backup.ErrBackupEmpty):
+				response.JSON(w, http.StatusUnprocessableEntity, "the backup has no vehicles, the restore was discarded")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    newBackupJSON(b),
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/bootcamp-go/web/response"
+)
+
+// AdminSecurityScheme is the name of the security scheme of the routes behind AdminAuth in the OpenAPI specification
+const AdminSecurityScheme = "adminToken"
+
+// NewAdminAuth is a function that returns a new instance of AdminAuth, an empty token refuses every request
+func NewAdminAuth(token string) *AdminAuth {
+	a := &AdminAuth{enabled: token != ""}
+	a.hash = sha256.Sum256([]byte(token))
+	return a
+}
+
+// AdminAuth is a struct with a middleware that lets through only the requests bearing the admin token,
+// as in Authorization: Bearer <token>. The routes it guards are disabled when no token is configured
+type AdminAuth struct {
+	// enabled reports whether a token is configured
+	enabled bool
+	// hash is the SHA-256 of the token, hashes of equal length are compared so the length of the token is not leaked
+	hash [sha256.Size]byte
+}
+
+// Handler is a method that returns a middleware authenticating the requests of the next handler
+func (a *AdminAuth) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.enabled {
+			response.JSON(w, http.StatusForbidden, "the admin routes are disabled, no admin token is configured")
+			return
+		}
+		scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		hash := sha256.Sum256([]byte(strings.TrimSpace(token)))
+		if !strings.EqualFold(scheme, "Bearer") || subtle.ConstantTimeCompare(hash[:], a.hash[:]) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			response.JSON(w, http.StatusUnauthorized, "a valid admin token is required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminAuth_Handler(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		want          int
+	}{
+		{name: "valid token", token: "s3cret-token", authorization: "Bearer s3cret-token", want: http.StatusNoContent},
+		{name: "scheme in lower case", token: "s3cret-token", authorization: "bearer s3cret-token", want: http.StatusNoContent},
+		{name: "no header", token: "s3cret-token", want: http.StatusUnauthorized},
+		{name: "wrong token", token: "s3cret-token", authorization: "Bearer s3cret-tokeN", want: http.StatusUnauthorized},
+		{name: "prefix of the token", token: "s3cret-token", authorization: "Bearer s3cret", want: http.StatusUnauthorized},
+		{name: "other scheme", token: "s3cret-token", authorization: "Basic s3cret-token", want: http.StatusUnauthorized},
+		{name: "no token configured", authorization: "Bearer ", want: http.StatusForbidden},
+		{name: "no token configured nor given", want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/admin/reload", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			NewAdminAuth(tt.token).Handler(next).ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("got status %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("got no WWW-Authenticate header on a 401")
+			}
+		})
+	}
+}
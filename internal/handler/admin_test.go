@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/backup"
+	"github.com/rhinosc/code-review-1/internal/loader"
+	"github.com/rhinosc/code-review-1/internal/reload"
+	"github.com/rhinosc/code-review-1/internal/repository"
+)
+
+// racingLoader is a struct that loads the vehicles file and saves it back through the watcher on every load,
+// as a server saving on every reload attempt
+type racingLoader struct {
+	*loader.VehicleJSONFile
+	// w is the watcher saves go through
+	w *reload.Watcher
+}
+
+func (l *racingLoader) Load() (v map[int]internal.Vehicle, err error) {
+	if v, err = l.VehicleJSONFile.Load(); err != nil {
+		return
+	}
+	err = l.w.Loader().Save(v)
+	return
+}
+
+func TestAdminDefault_Reload(t *testing.T) {
+	valid := `[{"id":1,"brand":"Ford","model":"T","registration":"1234-ABC","color":"Black","year":1920,"passengers":2,"max_speed":70,"fuel_type":"gasoline","transmission":"manual","weight":540,"height":2,"length":3.4,"width":1.7}]`
+
+	tests := []struct {
+		name     string
+		contents string
+		remove   bool
+		race     bool
+		want     int
+	}{
+		{name: "reloaded", contents: valid, want: http.StatusOK},
+		{name: "no vehicles", contents: "[]", want: http.StatusUnprocessableEntity},
+		{name: "contents not decoded", contents: `[{"id":1,`, want: http.StatusUnprocessableEntity},
+		{name: "invalid vehicle", contents: `[{"id":1,"brand":"Ford"}]`, want: http.StatusUnprocessableEntity},
+		{name: "file removed", remove: true, want: http.StatusInternalServerError},
+		{name: "saved during every attempt", contents: valid, race: true, want: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "vehicles.json")
+			if err := os.WriteFile(path, []byte(valid), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			ld := &racingLoader{VehicleJSONFile: loader.NewVehicleJSONFile(path)}
+			var vl internal.VehicleLoader = ld.VehicleJSONFile
+			if tt.race {
+				vl = ld
+			}
+			wt := reload.NewWatcher(vl, path, 0)
+			ld.w = wt
+			if err := wt.Init(); err != nil {
+				t.Fatal(err)
+			}
+			wt.SetRepository(repository.NewVehicleMap(wt.Loader(), nil, repository.NewVehicleIDSequential()))
+
+			// - the file is changed by hand
+			if tt.remove {
+				if err := os.Remove(path); err != nil {
+					t.Fatal(err)
+				}
+			} else if err := os.WriteFile(path, []byte(tt.contents), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/reload", nil)
+			rec := httptest.NewRecorder()
+			NewAdminDefault(wt, nil).Reload()(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestAdminDefault_Backups(t *testing.T) {
+	dir := t.TempDir()
+	db := map[int]internal.Vehicle{1: {
+		Id:                1,
+		VehicleAttributes: internal.VehicleAttributes{Brand: "Ford", Model: "T", FabricationYear: 1920, Capacity: 2},
+		Status:            internal.VehicleStatusInService,
+	}}
+	rp := repository.NewVehicleMap(loader.NewVehicleJSONFile(filepath.Join(dir, "vehicles.json")), db, repository.NewVehicleIDSequential())
+	bk := backup.NewManager(filepath.Join(dir, "backups"), rp, func(path string) internal.VehicleLoader {
+		return loader.NewVehicleJSONFile(path)
+	})
+	hd := NewAdminDefault(nil, bk)
+	rt := chi.NewRouter()
+	rt.Post("/admin/backups", hd.CreateBackup())
+	rt.Get("/admin/backups", hd.GetBackups())
+	rt.Post("/admin/backups/{id}/restore", hd.RestoreBackup())
+
+	// serve is a function that serves a request and decodes the data of the response
+	serve := func(method, url string, want int, data any) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		rt.ServeHTTP(rec, httptest.NewRequest(method, url, nil))
+		if rec.Code != want {
+			t.Fatalf("%s %s: got status %d, want %d: %s", method, url, rec.Code, want, rec.Body)
+		}
+		if data == nil {
+			return
+		}
+		body := struct {
+			Data any `json:"data"`
+		}{Data: data}
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// - a backup is taken and listed
+	var created BackupJSON
+	serve(http.MethodPost, "/admin/backups", http.StatusCreated, &created)
+	if created.ID == "" || created.Vehicles != 1 || len(created.Checksum) != 64 || created.Size == 0 {
+		t.Fatalf("got %+v, want a backup of 1 vehicle", created)
+	}
+	var list []BackupJSON
+	serve(http.MethodGet, "/admin/backups", http.StatusOK, &list)
+	if len(list) != 1 || list[0] != created {
+		t.Errorf("got %+v, want %+v", list, created)
+	}
+
+	// - the fleet is restored from it after a change
+	if _, err := rp.Delete(1, nil); err != nil {
+		t.Fatal(err)
+	}
+	var restored BackupJSON
+	serve(http.MethodPost, "/admin/backups/"+created.ID+"/restore", http.StatusOK, &restored)
+	if v, err := rp.FindByID(1); err != nil || v.Brand != "Ford" || restored.ID != created.ID {
+		t.Errorf("got %+v, %v, want vehicle 1 restored", v, err)
+	}
+
+	// - unknown and corrupt backups are not restored
+	serve(http.MethodPost, "/admin/backups/20260101T000000Z-00000000/restore", http.StatusNotFound, nil)
+	if err := os.WriteFile(filepath.Join(dir, "backups", created.ID+".json.gz"), []byte("corrupt"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	serve(http.MethodPost, "/admin/backups/"+created.ID+"/restore", http.StatusUnprocessableEntity, nil)
+}
@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bootcamp-go/web/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// multipartOverhead is the room given to the headers and boundaries of an upload over the greatest size of the file
+const multipartOverhead = 1 << 20
+
+// AttachmentJSON is a struct that represents an attachment in JSON format
+type AttachmentJSON struct {
+	ID          int       `json:"id"`
+	VehicleID   int       `json:"vehicle_id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// newAttachmentJSON is a function that serializes an attachment to JSON
+func newAttachmentJSON(a internal.Attachment) AttachmentJSON {
+	return AttachmentJSON{
+		ID:          a.ID,
+		VehicleID:   a.VehicleID,
+		Name:        a.Name,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		SHA256:      a.SHA256,
+		CreatedAt:   a.CreatedAt,
+	}
+}
+
+// parseAttachmentIDParam is a function that parses the id of the attachment in the URL
+func parseAttachmentIDParam(r *http.Request) (id int, ok bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "attachmentID"))
+	ok = err == nil && id > 0
+	return
+}
+
+// NewAttachmentDefault is a function that returns a new instance of AttachmentDefault, maxSize is the greatest size of a file in bytes
+func NewAttachmentDefault(sv internal.AttachmentService, maxSize int64) *AttachmentDefault {
+	return &AttachmentDefault{sv: sv, maxSize: maxSize}
+}
+
+// AttachmentDefault is a struct with methods that represent handlers for attachments
+type AttachmentDefault struct {
+	// sv is the service that will be used by the handler
+	sv internal.AttachmentService
+	// maxSize is the greatest size of a file in bytes, the body of an upload is cut past it
+	maxSize int64
+}
+
+// GetByVehicle is a method that returns a handler for the route GET /vehicles/{id}/attachments
+func (h *AttachmentDefault) GetByVehicle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		a, err := h.sv.FindByVehicle(id)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrVehicleNotFound):
+				response.JSON(w, http.StatusNotFound, "vehicle not found")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		data := make([]AttachmentJSON, 0, len(a))
+		for _, value := range a {
+			data = append(data, newAttachmentJSON(value))
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
+
+// Upload is a method that returns a handler for the route POST /vehicles/{id}/attachments.
+// The body is a multipart/form-data form whose file field is streamed to the blob store, the other fields are ignored
+func (h *AttachmentDefault) Upload() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, h.maxSize+multipartOverhead)
+		mr, err := r.MultipartReader()
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, "expected a multipart/form-data body")
+			return
+		}
+
+		// process
+		// - the first file field is uploaded
+		var a internal.Attachment
+		found := false
+		for !found {
+			part, pErr := mr.NextPart()
+			if errors.Is(pErr, io.EOF) {
+				break
+			}
+			if pErr != nil {
+				err = pErr
+				break
+			}
+			if part.FormName() == "file" {
+				found = true
+				a, err = h.sv.Upload(id, part.FileName(), part)
+			}
+			part.Close()
+		}
+		if err == nil && !found {
+			response.JSON(w, http.StatusBadRequest, "missing file field")
+			return
+		}
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			switch {
+			case errors.Is(err, internal.ErrVehicleNotFound):
+				response.JSON(w, http.StatusNotFound, "vehicle not found")
+			case errors.Is(err, internal.ErrAttachmentTooLarge), errors.As(err, &maxBytesErr):
+				response.JSON(w, http.StatusRequestEntityTooLarge, "file too large, the greatest size is "+strconv.FormatInt(h.maxSize, 10)+" bytes")
+			case errors.Is(err, internal.ErrAttachmentType):
+				response.JSON(w, http.StatusUnsupportedMediaType, err.Error())
+			case errors.Is(err, internal.ErrAttachmentInvalid):
+				response.JSON(w, http.StatusUnprocessableEntity, err.Error())
+			case !found:
+				response.JSON(w, http.StatusBadRequest, "invalid multipart body")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusCreated, map[string]any{
+			"message": "success",
+			"data":    newAttachmentJSON(a),
+		})
+	}
+}
+
+// Download is a method that returns a handler for the route GET /vehicles/{id}/attachments/{attachmentID}.
+// It serves range and conditional requests, the SHA-256 of the content being its entity tag
+func (h *AttachmentDefault) Download() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		attachmentID, ok := parseAttachmentIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid attachment id")
+			return
+		}
+
+		// process
+		a, f, err := h.sv.Open(id, attachmentID)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrAttachmentNotFound):
+				response.JSON(w, http.StatusNotFound, "attachment not found")
+			default:
+				log.Printf("attachment %d: %v", attachmentID, err)
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+		defer f.Close()
+
+		// response
+		w.Header().Set("Content-Type", a.ContentType)
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Name}))
+		w.Header().Set("ETag", `"`+a.SHA256+`"`)
+		w.Header().Set("Cache-Control", "private")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		http.ServeContent(w, r, "", a.CreatedAt, f)
+	}
+}
+
+// Delete is a method that returns a handler for the route DELETE /vehicles/{id}/attachments/{attachmentID}
+func (h *AttachmentDefault) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		attachmentID, ok := parseAttachmentIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid attachment id")
+			return
+		}
+
+		// process
+		if err := h.sv.Delete(id, attachmentID); err != nil {
+			switch {
+			case errors.Is(err, internal.ErrAttachmentNotFound):
+				response.JSON(w, http.StatusNotFound, "attachment not found")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
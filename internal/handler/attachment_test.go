@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/blob"
+	"github.com/rhinosc/code-review-1/internal/loader"
+	"github.com/rhinosc/code-review-1/internal/repository"
+	"github.com/rhinosc/code-review-1/internal/service"
+)
+
+// testAttachmentMaxSize is the greatest size of a file in the attachment tests
+const testAttachmentMaxSize = 64
+
+// newAttachmentRouter is a function that returns the attachment routes of vehicle 1, saved to a temporary directory
+func newAttachmentRouter(t *testing.T) *chi.Mux {
+	t.Helper()
+	dir := t.TempDir()
+	db := map[int]internal.Vehicle{1: {Id: 1, VehicleAttributes: internal.VehicleAttributes{Brand: "Ford", Model: "Transit", FabricationYear: 2020, Capacity: 3}}}
+	vr := repository.NewVehicleMap(loader.NewVehicleJSONFile(filepath.Join(dir, "vehicles.json")), db, repository.NewVehicleIDSequential())
+	st, err := blob.NewStore(filepath.Join(dir, "blobs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	arp := repository.NewAttachmentMap(loader.NewAttachmentJSONFile(&loader.ConfigAttachmentJSONFile{Path: filepath.Join(dir, "attachments.json")}), nil)
+	hd := NewAttachmentDefault(service.NewAttachmentDefault(arp, vr, st, &service.ConfigAttachmentDefault{MaxSize: testAttachmentMaxSize}), testAttachmentMaxSize)
+
+	rt := chi.NewRouter()
+	rt.Post("/vehicles/{id}/attachments", hd.Upload())
+	rt.Get("/vehicles/{id}/attachments/{attachmentID}", hd.Download())
+	return rt
+}
+
+// uploadRequest is a function that returns a request uploading a file in the given form field
+func uploadRequest(t *testing.T, vehicleID, field, name, content string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if err := mw.WriteField("note", "ignored"); err != nil {
+		t.Fatal(err)
+	}
+	fw, err := mw.CreateFormFile(field, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fw.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/vehicles/"+vehicleID+"/attachments", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestAttachmentDefault_Upload(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		field   string
+		content string
+		want    int
+	}{
+		{name: "uploaded", id: "1", field: "file", content: "%PDF-1.7\n", want: http.StatusCreated},
+		{name: "too large", id: "1", field: "file", content: "%PDF-1.7\n" + strings.Repeat("x", testAttachmentMaxSize), want: http.StatusRequestEntityTooLarge},
+		{name: "type not allowed", id: "1", field: "file", content: "just some notes", want: http.StatusUnsupportedMediaType},
+		{name: "empty", id: "1", field: "file", want: http.StatusUnprocessableEntity},
+		{name: "missing file field", id: "1", field: "photo", content: "%PDF-1.7\n", want: http.StatusBadRequest},
+		{name: "vehicle not found", id: "9", field: "file", content: "%PDF-1.7\n", want: http.StatusNotFound},
+		{name: "invalid id", id: "x", field: "file", content: "%PDF-1.7\n", want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			newAttachmentRouter(t).ServeHTTP(rec, uploadRequest(t, tt.id, tt.field, "papers.pdf", tt.content))
+			if rec.Code != tt.want {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+
+	t.Run("body not multipart", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/vehicles/1/attachments", strings.NewReader("%PDF-1.7\n"))
+		req.Header.Set("Content-Type", "application/pdf")
+		rec := httptest.NewRecorder()
+		newAttachmentRouter(t).ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("got status %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body)
+		}
+	})
+}
+
+func TestAttachmentDefault_Download(t *testing.T) {
+	rt := newAttachmentRouter(t)
+	content := "%PDF-1.7\nregistration papers"
+	rec := httptest.NewRecorder()
+	rt.ServeHTTP(rec, uploadRequest(t, "1", "file", "papers.pdf", content))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("got status %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+	var body struct {
+		Data AttachmentJSON `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	a := body.Data
+	url := "/vehicles/1/attachments/1"
+	etag := `"` + a.SHA256 + `"`
+
+	tests := []struct {
+		name         string
+		url          string
+		header       map[string]string
+		want         int
+		wantBody     string
+		wantRange    string
+		wantHeaderOK bool
+	}{
+		{name: "whole file", url: url, want: http.StatusOK, wantBody: content, wantHeaderOK: true},
+		{name: "range", url: url, header: map[string]string{"Range": "bytes=0-7"}, want: http.StatusPartialContent, wantBody: "%PDF-1.7", wantRange: "bytes 0-7/28"},
+		{name: "suffix range", url: url, header: map[string]string{"Range": "bytes=-6"}, want: http.StatusPartialContent, wantBody: "papers", wantRange: "bytes 22-27/28"},
+		{name: "range past the end", url: url, header: map[string]string{"Range": "bytes=100-"}, want: http.StatusRequestedRangeNotSatisfiable, wantRange: "bytes */28"},
+		{name: "range of an older version", url: url, header: map[string]string{"Range": "bytes=0-7", "If-Range": `"older"`}, want: http.StatusOK, wantBody: content},
+		{name: "not modified", url: url, header: map[string]string{"If-None-Match": etag}, want: http.StatusNotModified},
+		{name: "of another vehicle", url: "/vehicles/2/attachments/1", want: http.StatusNotFound},
+		{name: "not found", url: "/vehicles/1/attachments/9", want: http.StatusNotFound},
+		{name: "invalid attachment id", url: "/vehicles/1/attachments/x", want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			for key, value := range tt.header {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+			rt.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("got body %q, want %q", rec.Body, tt.wantBody)
+			}
+			if got := rec.Header().Get("Content-Range"); got != tt.wantRange {
+				t.Errorf("got Content-Range %q, want %q", got, tt.wantRange)
+			}
+			if !tt.wantHeaderOK {
+				return
+			}
+			h := rec.Header()
+			if h.Get("ETag") != etag || h.Get("Content-Type") != "application/pdf" || h.Get("Accept-Ranges") != "bytes" ||
+				h.Get("Content-Disposition") != `attachment; filename=papers.pdf` || h.Get("X-Content-Type-Options") != "nosniff" {
+				t.Errorf("got headers %v, want the tag, type and name of the file", h)
+			}
+		})
+	}
+}
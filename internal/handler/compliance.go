@@ -0,0 +1,310 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bootcamp-go/web/request"
+	"github.com/bootcamp-go/web/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// defaultExpiringWithin is how many days ahead expiring items are looked for when no window is given
+const defaultExpiringWithin = 30
+
+// ComplianceItemJSON is a struct that represents a compliance item in JSON format
+type ComplianceItemJSON struct {
+	ID        int    `json:"id"`
+	VehicleID int    `json:"vehicle_id"`
+	Kind      string `json:"kind"`
+	Reference string `json:"reference,omitempty"`
+	Provider  string `json:"provider,omitempty"`
+	Expires   string `json:"expires"`
+	Notes     string `json:"notes,omitempty"`
+}
+
+// newComplianceItemJSON is a function that serializes a compliance item to JSON
+func newComplianceItemJSON(i internal.ComplianceItem) ComplianceItemJSON {
+	return ComplianceItemJSON{
+		ID:        i.ID,
+		VehicleID: i.VehicleID,
+		Kind:      string(i.Kind),
+		Reference: i.Reference,
+		Provider:  i.Provider,
+		Expires:   formatDate(i.Expires),
+		Notes:     i.Notes,
+	}
+}
+
+// BodyComplianceItemJSON is a struct that represents the request of a compliance item in JSON format
+type BodyComplianceItemJSON struct {
+	Kind      string `json:"kind"`
+	Reference string `json:"reference"`
+	Provider  string `json:"provider"`
+	Expires   string `json:"expires"`
+	Notes     string `json:"notes"`
+}
+
+// Item is a method that deserializes a compliance item of a vehicle from JSON
+func (b BodyComplianceItemJSON) Item(vehicleID int) (i internal.ComplianceItem, err error) {
+	i = internal.ComplianceItem{
+		VehicleID: vehicleID,
+		Kind:      internal.ComplianceKind(b.Kind),
+		Reference: b.Reference,
+		Provider:  b.Provider,
+		Notes:     b.Notes,
+	}
+	i.Expires, err = time.Parse(dateLayout, b.Expires)
+	return
+}
+
+// ComplianceExpiryJSON is a struct that represents an expiring compliance item in JSON format
+type ComplianceExpiryJSON struct {
+	Item         ComplianceItemJSON `json:"item"`
+	Registration string             `json:"registration"`
+	DaysLeft     int                `json:"days_left"`
+	Expired      bool               `json:"expired"`
+}
+
+// newComplianceExpiryJSON is a function that serializes an expiry to JSON
+func newComplianceExpiryJSON(e internal.ComplianceExpiry) ComplianceExpiryJSON {
+	return ComplianceExpiryJSON{
+		Item:         newComplianceItemJSON(e.Item),
+		Registration: e.Registration,
+		DaysLeft:     e.DaysLeft,
+		Expired:      e.DaysLeft < 0,
+	}
+}
+
+// ComplianceReminderJSON is a struct that represents a reminder sent in JSON format
+type ComplianceReminderJSON struct {
+	ComplianceExpiryJSON
+	Window int `json:"window"`
+}
+
+// parseComplianceItemIDParam is a function that parses the id of the compliance item in the URL
+func parseComplianceItemIDParam(r *http.Request) (id int, ok bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "itemID"))
+	ok = err == nil && id > 0
+	return
+}
+
+// writeComplianceError is a function that writes the response of an error of the compliance service
+func writeComplianceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, internal.ErrVehicleNotFound):
+		response.JSON(w, http.StatusNotFound, "vehicle not found")
+	case errors.Is(err, internal.ErrComplianceNotFound):
+		response.JSON(w, http.StatusNotFound, "compliance item not found")
+	case errors.Is(err, internal.ErrComplianceInvalid):
+		response.JSON(w, http.StatusUnprocessableEntity, err.Error())
+	default:
+		response.JSON(w, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+// NewComplianceDefault is a function that returns a new instance of ComplianceDefault
+func NewComplianceDefault(sv internal.ComplianceService) *ComplianceDefault {
+	return &ComplianceDefault{sv: sv}
+}
+
+// ComplianceDefault is a struct with methods that represent handlers for compliance items
+type ComplianceDefault struct {
+	// sv is the service that will be used by the handler
+	sv internal.ComplianceService
+}
+
+// GetByVehicle is a method that returns a handler for the route GET /vehicles/{id}/compliance
+func (h *ComplianceDefault) GetByVehicle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		items, err := h.sv.FindByVehicle(id)
+		if err != nil {
+			writeComplianceError(w, err)
+			return
+		}
+
+		// response
+		data := make([]ComplianceItemJSON, 0, len(items))
+		for _, value := range items {
+			data = append(data, newComplianceItemJSON(value))
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
+
+// Create is a method that returns a handler for the route POST /vehicles/{id}/compliance
+func (h *ComplianceDefault) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		var body BodyComplianceItemJSON
+		if err := request.JSON(r, &body); err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+		item, err := body.Item(id)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid expires, expected YYYY-MM-DD")
+			return
+		}
+
+		// process
+		if err = h.sv.Create(&item); err != nil {
+			writeComplianceError(w, err)
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusCreated, map[string]any{
+			"message": "success",
+			"data":    newComplianceItemJSON(item),
+		})
+	}
+}
+
+// Update is a method that returns a handler for the route PUT /vehicles/{id}/compliance/{itemID}
+func (h *ComplianceDefault) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		itemID, ok := parseComplianceItemIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid item id")
+			return
+		}
+		var body BodyComplianceItemJSON
+		if err := request.JSON(r, &body); err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+		item, err := body.Item(id)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid expires, expected YYYY-MM-DD")
+			return
+		}
+
+		// process
+		if item, err = h.sv.Update(id, itemID, item); err != nil {
+			writeComplianceError(w, err)
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    newComplianceItemJSON(item),
+		})
+	}
+}
+
+// Delete is a method that returns a handler for the route DELETE /vehicles/{id}/compliance/{itemID}
+func (h *ComplianceDefault) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		itemID, ok := parseComplianceItemIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid item id")
+			return
+		}
+
+		// process
+		if err := h.sv.Delete(id, itemID); err != nil {
+			writeComplianceError(w, err)
+			return
+		}
+
+		// response
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetExpiring is a method that returns a handler for the route GET /vehicles/compliance/expiring?within={days}.
+// It lists the expired items and the ones expiring within the days, 30 by default
+func (h *ComplianceDefault) GetExpiring() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		q := r.URL.Query()
+		var errs ParamErrors
+		within := defaultExpiringWithin
+		if q.Has("within") {
+			var err error
+			if within, err = strconv.Atoi(q.Get("within")); err != nil || within < 0 {
+				errs = append(errs, ParamError{Parameter: "within", Value: q.Get("within"), Message: "must be a non-negative integer"})
+			}
+		}
+		if len(errs) > 0 {
+			writeParamErrors(w, errs)
+			return
+		}
+
+		// process
+		e, err := h.sv.Expiring(time.Now(), within)
+		if err != nil {
+			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		// response
+		data := make([]ComplianceExpiryJSON, 0, len(e))
+		for _, value := range e {
+			data = append(data, newComplianceExpiryJSON(value))
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
+
+// Remind is a method that returns a handler for the route POST /admin/compliance/remind.
+// It runs the daily reminders now and returns the reminders sent, none when every expiry was already reminded
+func (h *ComplianceDefault) Remind() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// ...
+
+		// process
+		rem, err := h.sv.Remind(r.Context(), time.Now())
+		if err != nil {
+			response.JSON(w, http.StatusBadGateway, "error delivering reminders: "+err.Error())
+			return
+		}
+
+		// response
+		data := make([]ComplianceReminderJSON, 0, len(rem))
+		for _, value := range rem {
+			data = append(data, ComplianceReminderJSON{ComplianceExpiryJSON: newComplianceExpiryJSON(value.Expiry), Window: value.Window})
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// NewCompress is a function that returns a new instance of Compress.
+// Responses smaller than minSize are sent uncompressed, level is a gzip compression level
+func NewCompress(minSize, level int) (c *Compress, err error) {
+	if _, err = gzip.NewWriterLevel(io.Discard, level); err != nil {
+		err = fmt.Errorf("invalid compression level %d: %w", level, err)
+		return
+	}
+	c = &Compress{minSize: minSize}
+	c.pool.New = func() any {
+		gw, _ := gzip.NewWriterLevel(io.Discard, level)
+		return gw
+	}
+	return
+}
+
+// Compress is a struct that compresses responses with gzip when the client accepts it.
+// The response is buffered until it reaches the minimum size, so small responses are not worth the overhead.
+// zstd is not offered: the standard library has no encoder for it, and clients accepting zstd accept gzip too
+type Compress struct {
+	// minSize is the size in bytes from which responses are compressed
+	minSize int
+	// pool is the pool of gzip writers
+	pool sync.Pool
+}
+
+// Handler is a method that returns a middleware compressing the responses of the next handler
+func (c *Compress) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, c: c}
+		next.ServeHTTP(cw, r)
+		cw.Close()
+	})
+}
+
+// acceptsGzip is a function that reports whether an Accept-Encoding header accepts gzip, explicitly or through *
+func acceptsGzip(header string) bool {
+	gzipQ, anyQ := -1.0, -1.0
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(coding)) {
+		case "gzip", "x-gzip":
+			gzipQ = q
+		case "*":
+			anyQ = q
+		}
+	}
+	if gzipQ >= 0 {
+		return gzipQ > 0
+	}
+	return anyQ > 0
+}
+
+// compressWriter is a struct that buffers a response until it can decide whether to compress it
+type compressWriter struct {
+	http.ResponseWriter
+	// c is the middleware
+	c *Compress
+	// code is the status code of the response
+	code int
+	// buf is the body written before the decision
+	buf []byte
+	// decided reports whether the status code and the buffered body were sent
+	decided bool
+	// gw is the gzip writer of the body, nil when the response is not compressed
+	gw *gzip.Writer
+}
+
+// WriteHeader is a method that records the status code, it is sent once the response is known to be compressed or not
+func (w *compressWriter) WriteHeader(code int) {
+	if w.code != 0 || w.decided {
+		return
+	}
+	w.code = code
+	// - responses without a body or with a partial one are sent as they are
+	if code == http.StatusNoContent || code == http.StatusNotModified || code == http.StatusPartialContent {
+		w.decide(false)
+	}
+}
+
+// Write is a method that writes the body, compressing it once the minimum size is reached
+func (w *compressWriter) Write(p []byte) (n int, err error) {
+	if w.code == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.decided {
+		w.buf = append(w.buf, p...)
+		if len(w.buf) < w.c.minSize {
+			return len(p), nil
+		}
+		if err = w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if w.gw != nil {
+		return w.gw.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush is a method that sends what was written so far, a flushed response is streamed so it is compressed whatever its size
+func (w *compressWriter) Flush() {
+	if !w.decided && w.code != 0 {
+		if err := w.decide(true); err != nil {
+			return
+		}
+	}
+	if w.gw != nil {
+		if err := w.gw.Flush(); err != nil {
+			return
+		}
+	}
+	if fl, ok := w.ResponseWriter.(http.Flusher); ok {
+		fl.Flush()
+	}
+}
+
+// decide is a method that sends the status code and the buffered body, compressed when requested and the content allows it
+func (w *compressWriter) decide(compress bool) (err error) {
+	w.decided = true
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	hd := w.Header()
+	// - the server would sniff the compressed bytes otherwise
+	if hd.Get("Content-Type") == "" && len(w.buf) > 0 {
+		hd.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	if compress && hd.Get("Content-Encoding") == "" && hd.Get("Content-Range") == "" && compressible(hd.Get("Content-Type")) {
+		hd.Set("Content-Encoding", "gzip")
+		hd.Del("Content-Length")
+		w.gw = w.c.pool.Get().(*gzip.Writer)
+		w.gw.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.code)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return
+	}
+	if w.gw != nil {
+		_, err = w.gw.Write(buf)
+		return
+	}
+	_, err = w.ResponseWriter.Write(buf)
+	return
+}
+
+// Close is a method that sends a response smaller than the minimum size uncompressed, or ends the compressed stream
+func (w *compressWriter) Close() {
+	if !w.decided {
+		// - nothing written, the server sends its default response
+		if w.code == 0 {
+			return
+		}
+		w.decide(false)
+		return
+	}
+	if w.gw != nil {
+		w.gw.Close()
+		w.gw.Reset(io.Discard)
+		w.c.pool.Put(w.gw)
+		w.gw = nil
+	}
+}
+
+// compressible is a function that reports whether a content type benefits from compression
+func compressible(contentType string) bool {
+	mt, _, _ := strings.Cut(contentType, ";")
+	mt = strings.ToLower(strings.TrimSpace(mt))
+	switch {
+	case strings.HasPrefix(mt, "image/") && mt != "image/svg+xml":
+		return false
+	case strings.HasPrefix(mt, "video/"), strings.HasPrefix(mt, "audio/"):
+		return false
+	case mt == "application/gzip", mt == "application/x-gzip", mt == "application/zip", mt == "application/zstd":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// gunzip is a function that returns the body of a response, decompressed when it is encoded with gzip
+func gunzip(t *testing.T, res *http.Response) string {
+	t.Helper()
+	var r io.Reader = res.Body
+	if res.Header.Get("Content-Encoding") == "gzip" {
+		gr, err := gzip.NewReader(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = gr
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{header: "", want: false},
+		{header: "gzip", want: true},
+		{header: "GZIP", want: true},
+		{header: "x-gzip", want: true},
+		{header: "deflate, br", want: false},
+		{header: "identity", want: false},
+		{header: "gzip;q=0", want: false},
+		{header: "gzip; q=0.5", want: true},
+		{header: "deflate, gzip;q=0.001", want: true},
+		{header: "*", want: true},
+		{header: "*;q=0", want: false},
+		{header: "br, *;q=0.1", want: true},
+		{header: "gzip;q=0, *", want: false},
+		{header: "*;q=0, gzip", want: true},
+		{header: "gzip;q=abc", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := acceptsGzip(tt.header); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompress_Handler(t *testing.T) {
+	large := strings.Repeat("vehicle ", 200)
+	tests := []struct {
+		name           string
+		method         string
+		acceptEncoding string
+		status         int
+		contentType    string
+		encoding       string
+		body           string
+		wantGzip       bool
+	}{
+		{name: "large response", acceptEncoding: "gzip", contentType: "application/json", body: large, wantGzip: true},
+		{name: "at the minimum size", acceptEncoding: "gzip", contentType: "text/plain", body: strings.Repeat("x", 1024), wantGzip: true},
+		{name: "under the minimum size", acceptEncoding: "gzip", contentType: "text/plain", body: strings.Repeat("x", 1023)},
+		{name: "gzip not accepted", acceptEncoding: "br", contentType: "application/json", body: large},
+		{name: "gzip refused", acceptEncoding: "gzip;q=0", contentType: "application/json", body: large},
+		{name: "image", acceptEncoding: "gzip", contentType: "image/png", body: large},
+		{name: "svg image", acceptEncoding: "gzip", contentType: "image/svg+xml", body: large, wantGzip: true},
+		{name: "video", acceptEncoding: "gzip", contentType: "video/mp4", body: large},
+		{name: "zip archive", acceptEncoding: "gzip", contentType: "application/zip", body: large},
+		{name: "gzip archive", acceptEncoding: "gzip", contentType: "application/gzip", body: large},
+		{name: "already encoded", acceptEncoding: "gzip", contentType: "text/plain", encoding: "br", body: large},
+		{name: "content type sniffed", acceptEncoding: "gzip", body: "<html>" + large, wantGzip: true},
+		{name: "partial content", acceptEncoding: "gzip", status: http.StatusPartialContent, contentType: "text/plain", body: large},
+		{name: "no content", acceptEncoding: "gzip", status: http.StatusNoContent},
+		{name: "head", method: http.MethodHead, acceptEncoding: "gzip", contentType: "application/json", body: large},
+	}
+	c, err := NewCompress(1024, gzip.DefaultCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := c.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				if tt.encoding != "" {
+					w.Header().Set("Content-Encoding", tt.encoding)
+				}
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				// - written in pieces, the decision is taken once the buffer reaches the minimum size
+				for body := tt.body; body != ""; {
+					n := min(len(body), 100)
+					w.Write([]byte(body[:n]))
+					body = body[n:]
+				}
+			}))
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/vehicles", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			res := rec.Result()
+
+			if got := res.Header.Get("Content-Encoding") == "gzip"; got != tt.wantGzip {
+				t.Fatalf("got Content-Encoding %q, want gzip %t", res.Header.Get("Content-Encoding"), tt.wantGzip)
+			}
+			if got := res.Header.Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("got Vary %q, want Accept-Encoding", got)
+			}
+			if tt.wantGzip && res.Header.Get("Content-Length") != "" {
+				t.Errorf("got Content-Length %q on a compressed response, want none", res.Header.Get("Content-Length"))
+			}
+			if tt.status != 0 && res.StatusCode != tt.status {
+				t.Errorf("got status %d, want %d", res.StatusCode, tt.status)
+			}
+			if tt.contentType == "" && tt.body != "" && !strings.HasPrefix(res.Header.Get("Content-Type"), "text/html") {
+				t.Errorf("got Content-Type %q, want the type sniffed from the uncompressed body", res.Header.Get("Content-Type"))
+			}
+			if method == http.MethodHead || tt.encoding != "" {
+				return
+			}
+			if got := gunzip(t, res); got != tt.body {
+				t.Errorf("got a body of %d bytes, want the %d bytes written", len(got), len(tt.body))
+			}
+		})
+	}
+}
+
+func TestCompress_Handler_Flush(t *testing.T) {
+	c, err := NewCompress(1024, gzip.DefaultCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resume := make(chan struct{})
+	srv := httptest.NewServer(c.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: first\n\n"))
+		w.(http.Flusher).Flush()
+		<-resume
+		w.Write([]byte("data: second\n\n"))
+	})))
+	t.Cleanup(srv.Close)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept-Encoding", "gzip")
+	// - the transport would decompress the response and hide its encoding otherwise
+	cl := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+	res, err := cl.Do(req)
+	if err != nil {
+		close(resume)
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.Header.Get("Content-Encoding") != "gzip" {
+		close(resume)
+		t.Fatalf("got Content-Encoding %q, want a flushed response compressed whatever its size", res.Header.Get("Content-Encoding"))
+	}
+
+	// - the first event is readable before the handler writes the second one
+	gr, err := gzip.NewReader(res.Body)
+	if err != nil {
+		close(resume)
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(gr)
+	line, err := br.ReadString('\n')
+	if err != nil || line != "data: first\n" {
+		close(resume)
+		t.Fatalf("got %q, %v before the handler resumed, want the first event", line, err)
+	}
+	close(resume)
+	rest, err := io.ReadAll(br)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rest) != "\ndata: second\n\n" {
+		t.Errorf("got %q after the handler resumed, want the second event", rest)
+	}
+}
+
+func TestNewCompress_InvalidLevel(t *testing.T) {
+	if _, err := NewCompress(1024, 42); err == nil {
+		t.Fatal("got no error, want the level refused")
+	}
+}
@@ -0,0 +1,371 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bootcamp-go/web/request"
+	"github.com/bootcamp-go/web/response"
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// DriverJSON is a struct that represents a driver in JSON format
+type DriverJSON struct {
+	ID            int           `json:"id"`
+	Name          string        `json:"name"`
+	LicenceNumber string        `json:"licence_number"`
+	Licences      []LicenceJSON `json:"licences"`
+}
+
+// LicenceJSON is a struct that represents a licence category of a driver in JSON format
+type LicenceJSON struct {
+	Category string `json:"category"`
+	Expires  string `json:"expires"`
+}
+
+// newDriverJSON is a function that serializes a driver to JSON
+func newDriverJSON(d internal.Driver) DriverJSON {
+	data := DriverJSON{
+		ID:            d.ID,
+		Name:          d.Name,
+		LicenceNumber: d.LicenceNumber,
+		Licences:      make([]LicenceJSON, 0, len(d.Licences)),
+	}
+	for _, l := range d.Licences {
+		data.Licences = append(data.Licences, LicenceJSON{Category: string(l.Category), Expires: formatDate(l.Expires)})
+	}
+	return data
+}
+
+// BodyDriverJSON is a struct that represents the request of a driver in JSON format
+type BodyDriverJSON struct {
+	Name          string        `json:"name"`
+	LicenceNumber string        `json:"licence_number"`
+	Licences      []LicenceJSON `json:"licences"`
+}
+
+// Driver is a method that deserializes the driver of the request, it fails when an expiry date is not a date
+func (b BodyDriverJSON) Driver() (d internal.Driver, err error) {
+	d = internal.Driver{
+		Name:          b.Name,
+		LicenceNumber: b.LicenceNumber,
+		Licences:      make([]internal.Licence, 0, len(b.Licences)),
+	}
+	for _, l := range b.Licences {
+		var expires time.Time
+		if expires, err = parseDate(l.Expires); err != nil {
+			return
+		}
+		d.Licences = append(d.Licences, internal.Licence{Category: internal.LicenceCategory(l.Category), Expires: expires})
+	}
+	return
+}
+
+// AssignmentJSON is a struct that represents an assignment in JSON format
+type AssignmentJSON struct {
+	ID        int        `json:"id"`
+	DriverID  int        `json:"driver_id"`
+	VehicleID int        `json:"vehicle_id"`
+	From      time.Time  `json:"from"`
+	To        *time.Time `json:"to"`
+	Current   bool       `json:"current"`
+}
+
+// newAssignmentJSON is a function that serializes an assignment to JSON
+func newAssignmentJSON(a internal.Assignment) AssignmentJSON {
+	data := AssignmentJSON{
+		ID:        a.ID,
+		DriverID:  a.DriverID,
+		VehicleID: a.VehicleID,
+		From:      a.From,
+		Current:   a.Current(),
+	}
+	if !a.Current() {
+		data.To = &a.To
+	}
+	return data
+}
+
+// newAssignmentsJSON is a function that serializes assignments to JSON
+func newAssignmentsJSON(a []internal.Assignment) []AssignmentJSON {
+	data := make([]AssignmentJSON, 0, len(a))
+	for _, value := range a {
+		data = append(data, newAssignmentJSON(value))
+	}
+	return data
+}
+
+// BodyAssignmentJSON is a struct that represents the request of an assignment in JSON format
+type BodyAssignmentJSON struct {
+	DriverID int `json:"driver_id"`
+}
+
+// writeDriverError is a function that writes the response of an error of the driver service
+func writeDriverError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, internal.ErrDriverNotFound):
+		response.JSON(w, http.StatusNotFound, "driver not found")
+	case errors.Is(err, internal.ErrVehicleNotFound):
+		response.JSON(w, http.StatusNotFound, "vehicle not found")
+	case errors.Is(err, internal.ErrAssignmentNotFound):
+		response.JSON(w, http.StatusNotFound, "vehicle has no driver")
+	case errors.Is(err, internal.ErrDriverInvalid), errors.Is(err, internal.ErrLicenceNotPermitted):
+		response.JSON(w, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, internal.ErrDriverDuplicate), errors.Is(err, internal.ErrDriverAssigned), errors.Is(err, internal.ErrAssignmentInvalid):
+		response.JSON(w, http.StatusConflict, err.Error())
+	default:
+		response.JSON(w, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+// NewDriverDefault is a function that returns a new instance of DriverDefault
+func NewDriverDefault(sv internal.DriverService) *DriverDefault {
+	return &DriverDefault{sv: sv}
+}
+
+// DriverDefault is a struct with methods that represent handlers for drivers and their assignments
+type DriverDefault struct {
+	// sv is the service that will be used by the handler
+	sv internal.DriverService
+}
+
+// GetAll is a method that returns a handler for the route GET /drivers
+func (h *DriverDefault) GetAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// ...
+
+		// process
+		d, err := h.sv.FindAll()
+		if err != nil {
+			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		// response
+		data := make([]DriverJSON, 0, len(d))
+		for _, value := range d {
+			data = append(data, newDriverJSON(value))
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
+
+// GetByID is a method that returns a handler for the route GET /drivers/{id}
+func (h *DriverDefault) GetByID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		d, err := h.sv.FindByID(id)
+		if err != nil {
+			writeDriverError(w, err)
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    newDriverJSON(d),
+		})
+	}
+}
+
+// Create is a method that returns a handler for the route POST /drivers
+func (h *DriverDefault) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		var body BodyDriverJSON
+		if err := request.JSON(r, &body); err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+		d, err := body.Driver()
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid expires, expected YYYY-MM-DD")
+			return
+		}
+
+		// process
+		if err = h.sv.Create(&d); err != nil {
+			writeDriverError(w, err)
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusCreated, map[string]any{
+			"message": "success",
+			"data":    newDriverJSON(d),
+		})
+	}
+}
+
+// Update is a method that returns a handler for the route PUT /drivers/{id}
+func (h *DriverDefault) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		var body BodyDriverJSON
+		if err := request.JSON(r, &body); err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+		d, err := body.Driver()
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid expires, expected YYYY-MM-DD")
+			return
+		}
+
+		// process
+		if d, err = h.sv.Update(id, d); err != nil {
+			writeDriverError(w, err)
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    newDriverJSON(d),
+		})
+	}
+}
+
+// Delete is a method that returns a handler for the route DELETE /drivers/{id}
+func (h *DriverDefault) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		if err := h.sv.Delete(id); err != nil {
+			writeDriverError(w, err)
+			return
+		}
+
+		// response
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetAssignmentsByDriver is a method that returns a handler for the route GET /drivers/{id}/assignments
+func (h *DriverDefault) GetAssignmentsByDriver() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		a, err := h.sv.FindAssignmentsByDriver(id)
+		if err != nil {
+			writeDriverError(w, err)
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    newAssignmentsJSON(a),
+		})
+	}
+}
+
+// GetAssignmentsByVehicle is a method that returns a handler for the route GET /vehicles/{id}/assignments
+func (h *DriverDefault) GetAssignmentsByVehicle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		a, err := h.sv.FindAssignmentsByVehicle(id)
+		if err != nil {
+			writeDriverError(w, err)
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    newAssignmentsJSON(a),
+		})
+	}
+}
+
+// Assign is a method that returns a handler for the route PUT /vehicles/{id}/driver.
+// The driver replaces the current driver of the vehicle, whose assignment ends
+func (h *DriverDefault) Assign() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		var body BodyAssignmentJSON
+		if err := request.JSON(r, &body); err != nil || body.DriverID <= 0 {
+			response.JSON(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+
+		// process
+		a, err := h.sv.Assign(id, body.DriverID)
+		if err != nil {
+			writeDriverError(w, err)
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    newAssignmentJSON(a),
+		})
+	}
+}
+
+// Unassign is a method that returns a handler for the route DELETE /vehicles/{id}/driver
+func (h *DriverDefault) Unassign() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		a, err := h.sv.Unassign(id)
+		if err != nil {
+			writeDriverError(w, err)
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    newAssignmentJSON(a),
+		})
+	}
+}
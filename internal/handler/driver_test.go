@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/loader"
+	"github.com/rhinosc/code-review-1/internal/repository"
+	"github.com/rhinosc/code-review-1/internal/service"
+)
+
+// newDriverRouter is a function that returns the driver routes over a fleet saved to a temporary directory:
+// vehicle 1 is a car and 2 a truck, driver 1 holds B and an expired C
+func newDriverRouter(t *testing.T) *chi.Mux {
+	t.Helper()
+	dir := t.TempDir()
+	vehicles := map[int]internal.Vehicle{
+		1: {Id: 1, VehicleAttributes: internal.VehicleAttributes{Brand: "Ford", Model: "Focus", FabricationYear: 2020, Weight: 1400, Capacity: 5}, Status: internal.VehicleStatusInService},
+		2: {Id: 2, VehicleAttributes: internal.VehicleAttributes{Brand: "MAN", Model: "TGX", FabricationYear: 2020, Weight: 18000, Capacity: 2}, Status: internal.VehicleStatusInService},
+	}
+	data := internal.DriverData{Drivers: map[int]internal.Driver{
+		1: {ID: 1, Name: "Ana", LicenceNumber: "A-1", Licences: []internal.Licence{
+			{Category: internal.LicenceCategoryB, Expires: time.Now().UTC().AddDate(1, 0, 0)},
+			{Category: internal.LicenceCategoryC, Expires: time.Now().UTC().AddDate(0, -1, 0)},
+		}},
+	}}
+	vr := repository.NewVehicleMap(loader.NewVehicleJSONFile(filepath.Join(dir, "vehicles.json")), vehicles, repository.NewVehicleIDSequential())
+	rp := repository.NewDriverMap(loader.NewDriverJSONFile(&loader.ConfigDriverJSONFile{Path: filepath.Join(dir, "drivers.json")}), data)
+	hd := NewDriverDefault(service.NewDriverDefault(rp, vr))
+
+	rt := chi.NewRouter()
+	rt.Post("/drivers", hd.Create())
+	rt.Get("/drivers/{id}/assignments", hd.GetAssignmentsByDriver())
+	rt.Get("/vehicles/{id}/assignments", hd.GetAssignmentsByVehicle())
+	rt.Put("/vehicles/{id}/driver", hd.Assign())
+	rt.Delete("/vehicles/{id}/driver", hd.Unassign())
+	return rt
+}
+
+// serveJSON is a function that serves a request with a JSON body
+func serveJSON(rt http.Handler, method, url, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	rt.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestDriverDefault_Create(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{name: "created", body: `{"name":"Luis","licence_number":"L-2","licences":[{"category":"d1","expires":"2030-01-31"}]}`, want: http.StatusCreated},
+		{name: "licence number of another driver", body: `{"name":"Luis","licence_number":"a-1","licences":[{"category":"B","expires":"2030-01-31"}]}`, want: http.StatusConflict},
+		{name: "unknown category", body: `{"name":"Luis","licence_number":"L-2","licences":[{"category":"Z","expires":"2030-01-31"}]}`, want: http.StatusUnprocessableEntity},
+		{name: "no category", body: `{"name":"Luis","licence_number":"L-2","licences":[]}`, want: http.StatusUnprocessableEntity},
+		{name: "invalid expiry", body: `{"name":"Luis","licence_number":"L-2","licences":[{"category":"B","expires":"31/01/2030"}]}`, want: http.StatusBadRequest},
+		{name: "invalid body", body: `{"name":`, want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveJSON(newDriverRouter(t), http.MethodPost, "/drivers", tt.body)
+			if rec.Code != tt.want {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestDriverDefault_Assign(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		body string
+		want int
+	}{
+		{name: "permitted", id: "1", body: `{"driver_id":1}`, want: http.StatusOK},
+		{name: "category expired", id: "2", body: `{"driver_id":1}`, want: http.StatusUnprocessableEntity},
+		{name: "driver not found", id: "1", body: `{"driver_id":9}`, want: http.StatusNotFound},
+		{name: "vehicle not found", id: "9", body: `{"driver_id":1}`, want: http.StatusNotFound},
+		{name: "missing driver", id: "1", body: `{}`, want: http.StatusBadRequest},
+		{name: "invalid id", id: "x", body: `{"driver_id":1}`, want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveJSON(newDriverRouter(t), http.MethodPut, "/vehicles/"+tt.id+"/driver", tt.body)
+			if rec.Code != tt.want {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestDriverDefault_Assignments(t *testing.T) {
+	rt := newDriverRouter(t)
+	if rec := serveJSON(rt, http.MethodPut, "/vehicles/1/driver", `{"driver_id":1}`); rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+
+	// - the current assignment has no end until the driver is unassigned
+	var current []AssignmentJSON
+	getData(t, rt, "/vehicles/1/assignments", http.StatusOK, &current)
+	if len(current) != 1 || current[0].DriverID != 1 || !current[0].Current || current[0].To != nil {
+		t.Fatalf("got %+v, want the current assignment of driver 1", current)
+	}
+	if rec := serveJSON(rt, http.MethodDelete, "/vehicles/1/driver", ""); rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	if rec := serveJSON(rt, http.MethodDelete, "/vehicles/1/driver", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("got status %d, want %d: %s", rec.Code, http.StatusNotFound, rec.Body)
+	}
+
+	var history []AssignmentJSON
+	getData(t, rt, "/drivers/1/assignments", http.StatusOK, &history)
+	if len(history) != 1 || history[0].VehicleID != 1 || history[0].Current || history[0].To == nil || history[0].To.Before(history[0].From) {
+		t.Errorf("got %+v, want the ended assignment to vehicle 1", history)
+	}
+	getData(t, rt, "/drivers/9/assignments", http.StatusNotFound, nil)
+}
@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bootcamp-go/web/response"
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+const (
+	// MediaTypeJSON is the media type of JSON responses
+	MediaTypeJSON = "application/json"
+	// MediaTypeNDJSON is the media type of newline delimited JSON responses
+	MediaTypeNDJSON = "application/x-ndjson"
+	// MediaTypeCSV is the media type of CSV responses
+	MediaTypeCSV = "text/csv"
+	// MediaTypeXML is the media type of XML responses
+	MediaTypeXML = "application/xml"
+)
+
+// vehicleEncoder is a function that writes a list of vehicles in a media type
+type vehicleEncoder func(w http.ResponseWriter, code int, v map[int]internal.Vehicle)
+
+// vehicleEncoders is the list of encoders of vehicle lists, in order of preference
+var vehicleEncoders = []struct {
+	// mediaTypes is the list of media types served by the encoder, the first one is set as Content-Type
+	mediaTypes []string
+	// encode is the encoder
+	encode vehicleEncoder
+}{
+	{mediaTypes: []string{MediaTypeJSON}, encode: encodeVehiclesJSON},
+	{mediaTypes: []string{MediaTypeNDJSON, "application/ndjson", "application/jsonl"}, encode: encodeVehiclesNDJSON},
+	{mediaTypes: []string{MediaTypeCSV}, encode: encodeVehiclesCSV},
+	{mediaTypes: []string{MediaTypeXML, "text/xml"}, encode: encodeVehiclesXML},
+}
+
+// negotiateVehicles is a function that returns the encoder of vehicle lists that best matches the Accept header of a request
+func negotiateVehicles(r *http.Request) (enc vehicleEncoder, ok bool) {
+	i, ok := negotiate(r, vehicleOffers())
+	if !ok {
+		return
+	}
+	enc = vehicleEncoders[i].encode
+	return
+}
+
+// negotiate is a function that returns the index of the offer that best matches the Accept header of a request,
+// each offer being the list of media types it serves. A missing Accept header selects the first offer
+func negotiate(r *http.Request, offers [][]string) (i int, ok bool) {
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return 0, len(offers) > 0
+	}
+
+	// the quality of an offer is the one of the most specific range matching it, ties keep the order of preference
+	rgs := parseAccept(accept)
+	bestQ := 0.0
+	for j, mediaTypes := range offers {
+		for _, rg := range rgs {
+			if !matchMediaRange(rg.mediaRange, mediaTypes) {
+				continue
+			}
+			if rg.q > bestQ {
+				i, ok, bestQ = j, true, rg.q
+			}
+			break
+		}
+	}
+	return
+}
+
+// writeNotAcceptable is a function that writes a 406 response listing the supported media types
+func writeNotAcceptable(w http.ResponseWriter, offers [][]string) {
+	var types []string
+	for _, mediaTypes := range offers {
+		types = append(types, mediaTypes[0])
+	}
+	response.JSON(w, http.StatusNotAcceptable, "not acceptable, supported media types are "+strings.Join(types, ", "))
+}
+
+// vehicleOffers is a function that returns the media types served by the encoders of vehicle lists
+func vehicleOffers() (offers [][]string) {
+	for _, ve := range vehicleEncoders {
+		offers = append(offers, ve.mediaTypes)
+	}
+	return
+}
+
+// acceptRange is a struct that represents a media range of an Accept header
+type acceptRange struct {
+	// mediaRange is the media range, e.g. text/* or application/json
+	mediaRange string
+	// q is the quality of the media range
+	q float64
+}
+
+// parseAccept is a function that parses the media ranges of an Accept header, ignoring malformed ones
+func parseAccept(accept string) (rgs []acceptRange) {
+	for _, part := range strings.Split(accept, ",") {
+		mediaRange, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		rgs = append(rgs, acceptRange{mediaRange: mediaRange, q: q})
+	}
+	// more specific ranges first
+	sort.SliceStable(rgs, func(i, j int) bool {
+		return strings.Count(rgs[i].mediaRange, "*") < strings.Count(rgs[j].mediaRange, "*")
+	})
+	return
+}
+
+// matchMediaRange is a function that reports whether a media range matches any of the media types
+func matchMediaRange(mediaRange string, mediaTypes []string) bool {
+	for _, mt := range mediaTypes {
+		if mediaRange == "*/*" || mediaRange == mt {
+			return true
+		}
+		if typ, sub, _ := strings.Cut(mediaRange, "/"); sub == "*" && strings.HasPrefix(mt, typ+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedVehiclesJSON is a function that serializes vehicles to JSON ordered by id
+func sortedVehiclesJSON(v map[int]internal.Vehicle) (data []VehicleJSON) {
+	data = make([]VehicleJSON, 0, len(v))
+	for _, value := range v {
+		data = append(data, newVehicleJSON(value))
+	}
+	sort.Slice(data, func(i, j int) bool { return data[i].ID < data[j].ID })
+	return
+}
+
+// encodeVehiclesJSON is a function that writes vehicles as a JSON object keyed by id
+func encodeVehiclesJSON(w http.ResponseWriter, code int, v map[int]internal.Vehicle) {
+	data := make(map[int]VehicleJSON)
+	for key, value := range v {
+		data[key] = newVehicleJSON(value)
+	}
+	response.JSON(w, code, map[string]any{
+		"message": "success",
+		"data":    data,
+	})
+}
+
+// encodeVehiclesNDJSON is a function that streams vehicles as newline delimited JSON, one vehicle per line
+func encodeVehiclesNDJSON(w http.ResponseWriter, code int, v map[int]internal.Vehicle) {
+	w.Header().Set("Content-Type", MediaTypeNDJSON)
+	w.WriteHeader(code)
+
+	fl, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	for i, value := range sortedVehiclesJSON(v) {
+		if err := enc.Encode(value); err != nil {
+			return
+		}
+		if fl != nil && (i+1)%100 == 0 {
+			fl.Flush()
+		}
+	}
+}
+
+// csvHeader is the header row of CSV responses, it uses the JSON field names
+var csvHeader = []string{"id", "brand", "model", "registration", "color", "year", "passengers", "max_speed", "fuel_type", "transmission", "weight", "height", "length", "width", "status"}
+
+// encodeVehiclesCSV is a function that writes vehicles as CSV with a header row
+func encodeVehiclesCSV(w http.ResponseWriter, code int, v map[int]internal.Vehicle) {
+	w.Header().Set("Content-Type", MediaTypeCSV+"; charset=utf-8")
+	w.WriteHeader(code)
+
+	cw := csv.NewWriter(w)
+	cw.Write(csvHeader)
+	for _, value := range sortedVehiclesJSON(v) {
+		cw.Write(csvRecord(value))
+	}
+	cw.Flush()
+}
+
+// csvRecord is a function that returns the CSV row of a vehicle in the order of csvHeader
+func csvRecord(v VehicleJSON) []string {
+	float := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+	return []string{
+		strconv.Itoa(v.ID), v.Brand, v.Model, v.Registration, v.Color,
+		strconv.Itoa(v.FabricationYear), strconv.Itoa(v.Capacity), float(v.MaxSpeed),
+		v.FuelType, v.Transmission, float(v.Weight), float(v.Height), float(v.Length), float(v.Width), v.Status,
+	}
+}
+
+// VehiclesXML is a struct that represents a list of vehicles in XML format
+type VehiclesXML struct {
+	XMLName  xml.Name      `xml:"vehicles"`
+	Vehicles []VehicleJSON `xml:"vehicle"`
+}
+
+// encodeVehiclesXML is a function that writes vehicles as an XML document
+func encodeVehiclesXML(w http.ResponseWriter, code int, v map[int]internal.Vehicle) {
+	bytes, err := xml.Marshal(VehiclesXML{Vehicles: sortedVehiclesJSON(v)})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", MediaTypeXML+"; charset=utf-8")
+	w.WriteHeader(code)
+	w.Write([]byte(xml.Header))
+	w.Write(bytes)
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	offers := vehicleOffers()
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+		wantOK bool
+	}{
+		{name: "no header", accept: "", want: MediaTypeJSON, wantOK: true},
+		{name: "exact", accept: "text/csv", want: MediaTypeCSV, wantOK: true},
+		{name: "alias", accept: "text/xml", want: MediaTypeXML, wantOK: true},
+		{name: "alias of ndjson", accept: "application/jsonl", want: MediaTypeNDJSON, wantOK: true},
+		{name: "any", accept: "*/*", want: MediaTypeJSON, wantOK: true},
+		{name: "any of a type", accept: "text/*", want: MediaTypeCSV, wantOK: true},
+		{name: "highest quality", accept: "application/json;q=0.5, application/xml;q=0.9", want: MediaTypeXML, wantOK: true},
+		{name: "specific range over a wildcard", accept: "*/*;q=0.1, text/csv", want: MediaTypeCSV, wantOK: true},
+		{name: "specific range refusing a wildcard", accept: "application/*, application/json;q=0", want: MediaTypeNDJSON, wantOK: true},
+		{name: "ties keep the order of preference", accept: "application/xml, text/csv", want: MediaTypeCSV, wantOK: true},
+		{name: "parameters ignored", accept: "text/csv; charset=utf-8", want: MediaTypeCSV, wantOK: true},
+		{name: "malformed ranges ignored", accept: "text/csv;q=2, ;;, application/xml", want: MediaTypeXML, wantOK: true},
+		{name: "unsupported", accept: "text/html, image/*"},
+		{name: "refused", accept: "*/*;q=0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/vehicles", nil)
+			r.Header.Set("Accept", tt.accept)
+
+			i, ok := negotiate(r, offers)
+			if ok != tt.wantOK {
+				t.Fatalf("got %t, want %t", ok, tt.wantOK)
+			}
+			if ok && offers[i][0] != tt.want {
+				t.Errorf("got %s, want %s", offers[i][0], tt.want)
+			}
+		})
+	}
+}
+
+func TestVehicleDefault_GetAll_Negotiation(t *testing.T) {
+	rt := newStatusRouter(t)
+	// get is a function that returns the response to a list of vehicles in a media type
+	get := func(url, accept string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		rt.ServeHTTP(rec, req)
+		return rec
+	}
+	// - the same representation in every media type, ordered by id but for the JSON object keyed by id
+	wantIDs := []int{1, 2, 3}
+
+	t.Run("json", func(t *testing.T) {
+		rec := get("/vehicles", MediaTypeJSON)
+		var body struct {
+			Data map[int]VehicleJSON `json:"data"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != http.StatusOK || len(body.Data) != 3 || body.Data[2].Status != "reserved" {
+			t.Errorf("got status %d and %+v, want the 3 vehicles", rec.Code, body.Data)
+		}
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		rec := get("/vehicles", MediaTypeNDJSON)
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != MediaTypeNDJSON {
+			t.Fatalf("got status %d of type %s, want %d of type %s", rec.Code, rec.Header().Get("Content-Type"), http.StatusOK, MediaTypeNDJSON)
+		}
+		var ids []int
+		sc := bufio.NewScanner(rec.Body)
+		for sc.Scan() {
+			var v VehicleJSON
+			if err := json.Unmarshal(sc.Bytes(), &v); err != nil {
+				t.Fatalf("line %q: %v", sc.Text(), err)
+			}
+			ids = append(ids, v.ID)
+		}
+		if !reflect.DeepEqual(ids, wantIDs) {
+			t.Errorf("got vehicles %v, want %v", ids, wantIDs)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		rec := get("/vehicles", "text/csv, */*;q=0.1")
+		if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), MediaTypeCSV) {
+			t.Fatalf("got status %d of type %s, want %d of type %s", rec.Code, rec.Header().Get("Content-Type"), http.StatusOK, MediaTypeCSV)
+		}
+		records, err := csv.NewReader(rec.Body).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 4 || !reflect.DeepEqual(records[0], csvHeader) {
+			t.Fatalf("got %v, want the header and 3 rows", records)
+		}
+		want := []string{"2", "Ford", "Transit", "", "White", "2020", "3", "0", "", "", "0", "2.5", "5.9", "2", "reserved"}
+		if !reflect.DeepEqual(records[2], want) {
+			t.Errorf("got row %v, want %v", records[2], want)
+		}
+	})
+
+	t.Run("xml", func(t *testing.T) {
+		rec := get("/vehicles?status=reserved,decommissioned", "text/xml")
+		if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), MediaTypeXML) {
+			t.Fatalf("got status %d of type %s, want %d of type %s", rec.Code, rec.Header().Get("Content-Type"), http.StatusOK, MediaTypeXML)
+		}
+		var body VehiclesXML
+		if err := xml.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if len(body.Vehicles) != 2 || body.Vehicles[0].ID != 2 || body.Vehicles[1].ID != 3 || body.Vehicles[1].Brand != "Ford" {
+			t.Errorf("got %+v, want vehicles 2 and 3", body.Vehicles)
+		}
+	})
+
+	t.Run("query endpoints", func(t *testing.T) {
+		for _, url := range []string{"/vehicles/color/white/year/2020", "/vehicles/dimensions?length=5..6"} {
+			rec := get(url, MediaTypeCSV)
+			if records, err := csv.NewReader(rec.Body).ReadAll(); rec.Code != http.StatusOK || err != nil || len(records) != 4 {
+				t.Errorf("%s: got status %d and %d rows, %v, want %d and 4", url, rec.Code, len(records), err, http.StatusOK)
+			}
+		}
+	})
+
+	t.Run("not acceptable", func(t *testing.T) {
+		for _, url := range []string{"/vehicles", "/vehicles/color/white/year/2020", "/vehicles/dimensions?length=5..6"} {
+			rec := get(url, "text/html")
+			if rec.Code != http.StatusNotAcceptable || !strings.Contains(rec.Body.String(), MediaTypeCSV) {
+				t.Errorf("%s: got status %d: %s, want %d listing the media types", url, rec.Code, rec.Body, http.StatusNotAcceptable)
+			}
+		}
+	})
+}
@@ -0,0 +1,273 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bootcamp-go/web/request"
+	"github.com/bootcamp-go/web/response"
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// FuelLogEntryJSON is a struct that represents a fuel log entry in JSON format
+type FuelLogEntryJSON struct {
+	ID        int       `json:"id"`
+	VehicleID int       `json:"vehicle_id"`
+	Date      time.Time `json:"date"`
+	Odometer  int       `json:"odometer"`
+	Quantity  float64   `json:"quantity"`
+	Unit      string    `json:"unit"`
+	Cost      float64   `json:"cost"`
+	Station   string    `json:"station,omitempty"`
+}
+
+// newFuelLogEntryJSON is a function that serializes a fuel log entry to JSON
+func newFuelLogEntryJSON(e internal.FuelLogEntry) FuelLogEntryJSON {
+	return FuelLogEntryJSON{
+		ID:        e.ID,
+		VehicleID: e.VehicleID,
+		Date:      e.Date,
+		Odometer:  e.Odometer,
+		Quantity:  e.Quantity,
+		Unit:      string(e.Unit),
+		Cost:      e.Cost,
+		Station:   e.Station,
+	}
+}
+
+// BodyFuelLogEntryJSON is a struct that represents the request of a fuel log entry in JSON format
+type BodyFuelLogEntryJSON struct {
+	Date     string  `json:"date"`
+	Odometer int     `json:"odometer"`
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit"`
+	Cost     float64 `json:"cost"`
+	Station  string  `json:"station"`
+}
+
+// FuelTotalsJSON is a struct that represents the distance, energy and cost of a set of segments in JSON format
+type FuelTotalsJSON struct {
+	Unit        string  `json:"unit,omitempty"`
+	Distance    int     `json:"distance"`
+	Quantity    float64 `json:"quantity"`
+	Cost        float64 `json:"cost"`
+	Consumption float64 `json:"consumption_per_100km"`
+	CostPerKm   float64 `json:"cost_per_km"`
+	Anomalies   int     `json:"anomalies"`
+}
+
+// newFuelTotalsJSON is a function that serializes totals to JSON
+func newFuelTotalsJSON(t internal.FuelTotals) FuelTotalsJSON {
+	return FuelTotalsJSON{
+		Unit:        string(t.Unit),
+		Distance:    t.Distance,
+		Quantity:    t.Quantity,
+		Cost:        t.Cost,
+		Consumption: t.Consumption(),
+		CostPerKm:   t.CostPerKm(),
+		Anomalies:   t.Anomalies,
+	}
+}
+
+// FuelSegmentJSON is a struct that represents the segment between two fills in JSON format
+type FuelSegmentJSON struct {
+	FromID      int     `json:"from_entry_id"`
+	ToID        int     `json:"to_entry_id"`
+	Distance    int     `json:"distance"`
+	Quantity    float64 `json:"quantity"`
+	Cost        float64 `json:"cost"`
+	Consumption float64 `json:"consumption_per_100km"`
+	Anomaly     bool    `json:"anomaly"`
+}
+
+// FuelStatsJSON is a struct that represents the consumption of a vehicle in JSON format
+type FuelStatsJSON struct {
+	VehicleID int `json:"vehicle_id"`
+	Odometer  int `json:"odometer"`
+	FuelTotalsJSON
+	Segments []FuelSegmentJSON `json:"segments"`
+}
+
+// FuelGroupStatsJSON is a struct that represents the consumption of a group of vehicles in JSON format
+type FuelGroupStatsJSON struct {
+	Value    string `json:"value"`
+	Vehicles int    `json:"vehicles"`
+	FuelTotalsJSON
+}
+
+// NewFuelLogDefault is a function that returns a new instance of FuelLogDefault
+func NewFuelLogDefault(sv internal.FuelLogService) *FuelLogDefault {
+	return &FuelLogDefault{sv: sv}
+}
+
+// FuelLogDefault is a struct with methods that represent handlers for fuel logs and consumption
+type FuelLogDefault struct {
+	// sv is the service that will be used by the handler
+	sv internal.FuelLogService
+}
+
+// GetByVehicle is a method that returns a handler for the route GET /vehicles/{id}/fuel
+func (h *FuelLogDefault) GetByVehicle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		e, err := h.sv.FindByVehicle(id)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrVehicleNotFound):
+				response.JSON(w, http.StatusNotFound, "vehicle not found")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		data := make([]FuelLogEntryJSON, 0, len(e))
+		for _, value := range e {
+			data = append(data, newFuelLogEntryJSON(value))
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
+
+// Create is a method that returns a handler for the route POST /vehicles/{id}/fuel.
+// An entry without quantity records a reading of the odometer only
+func (h *FuelLogDefault) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		var body BodyFuelLogEntryJSON
+		if err := request.JSON(r, &body); err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+		e := internal.FuelLogEntry{
+			VehicleID: id,
+			Odometer:  body.Odometer,
+			Quantity:  body.Quantity,
+			Unit:      internal.FuelUnit(body.Unit),
+			Cost:      body.Cost,
+			Station:   body.Station,
+		}
+		var err error
+		if e.Date, err = parseDate(body.Date); err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid date, expected YYYY-MM-DD or an RFC 3339 time")
+			return
+		}
+
+		// process
+		if err = h.sv.Create(&e); err != nil {
+			switch {
+			case errors.Is(err, internal.ErrVehicleNotFound):
+				response.JSON(w, http.StatusNotFound, "vehicle not found")
+			case errors.Is(err, internal.ErrFuelLogInvalid), errors.Is(err, internal.ErrOdometerNotMonotonic):
+				response.JSON(w, http.StatusUnprocessableEntity, err.Error())
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusCreated, map[string]any{
+			"message": "success",
+			"data":    newFuelLogEntryJSON(e),
+		})
+	}
+}
+
+// GetStats is a method that returns a handler for the route GET /vehicles/{id}/fuel/stats
+func (h *FuelLogDefault) GetStats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		st, err := h.sv.Stats(id)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrVehicleNotFound):
+				response.JSON(w, http.StatusNotFound, "vehicle not found")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		data := FuelStatsJSON{
+			VehicleID:      st.VehicleID,
+			Odometer:       st.Odometer,
+			FuelTotalsJSON: newFuelTotalsJSON(st.FuelTotals),
+			Segments:       make([]FuelSegmentJSON, 0, len(st.Segments)),
+		}
+		for _, seg := range st.Segments {
+			data.Segments = append(data.Segments, FuelSegmentJSON{
+				FromID:      seg.FromID,
+				ToID:        seg.ToID,
+				Distance:    seg.Distance,
+				Quantity:    seg.Quantity,
+				Cost:        seg.Cost,
+				Consumption: seg.Consumption,
+				Anomaly:     seg.Anomaly,
+			})
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
+
+// GetGroupStats is a method that returns a handler for the route GET /vehicles/fuel/stats?group_by={brand|model|fuel_type}.
+// The vehicles are grouped by fuel type by default
+func (h *FuelLogDefault) GetGroupStats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		field := internal.FieldFuelType
+		if q := r.URL.Query(); q.Has("group_by") {
+			field = q.Get("group_by")
+		}
+
+		// process
+		st, err := h.sv.GroupStats(field)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrFuelGroupInvalid):
+				writeParamErrors(w, ParamErrors{{Parameter: "group_by", Value: field, Message: "must be one of brand, model, fuel_type"}})
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		data := make([]FuelGroupStatsJSON, 0, len(st))
+		for _, value := range st {
+			data = append(data, FuelGroupStatsJSON{Value: value.Value, Vehicles: value.Vehicles, FuelTotalsJSON: newFuelTotalsJSON(value.FuelTotals)})
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
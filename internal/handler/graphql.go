@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+
+	"github.com/bootcamp-go/web/response"
+	"github.com/rhinosc/code-review-1/internal/graphql"
+)
+
+const (
+	// graphQLMaxBody is the largest body of a GraphQL request, 1 MiB
+	graphQLMaxBody = 1 << 20
+)
+
+// NewGraphQLDefault is a function that returns a new instance of GraphQLDefault
+func NewGraphQLDefault(s *graphql.Schema, l graphql.Limits) *GraphQLDefault {
+	return &GraphQLDefault{s: s, l: l}
+}
+
+// GraphQLDefault is a struct with methods that represent handlers for a GraphQL schema
+type GraphQLDefault struct {
+	// s is the schema the operations are executed against
+	s *graphql.Schema
+	// l is the limits of the operations
+	l graphql.Limits
+}
+
+// Query is a method that returns a handler for the routes GET and POST /graphql.
+// POST takes a JSON body with query, operationName and variables; GET takes them as parameters, variables encoded in
+// JSON, and only executes queries. Requests that can not be executed, such as invalid documents or operations over the
+// limits, respond 400 with the errors; executed operations respond 200 with their data and the errors of their fields
+func (h *GraphQLDefault) Query() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		var req graphql.Request
+		switch r.Method {
+		case http.MethodGet:
+			q := r.URL.Query()
+			req.Query, req.OperationName = q.Get("query"), q.Get("operationName")
+			if vars := q.Get("variables"); vars != "" {
+				if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+					writeGraphQLErrors(w, http.StatusBadRequest, "variables must be a JSON object")
+					return
+				}
+			}
+		default:
+			if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "application/json" {
+				writeGraphQLErrors(w, http.StatusUnsupportedMediaType, "the body must be application/json")
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, graphQLMaxBody)
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeGraphQLErrors(w, http.StatusBadRequest, "invalid body")
+				return
+			}
+		}
+		if req.Query == "" {
+			writeGraphQLErrors(w, http.StatusBadRequest, "query is required")
+			return
+		}
+
+		// process
+		p, errs := h.s.Prepare(req, h.l)
+		if len(errs) > 0 {
+			response.JSON(w, http.StatusBadRequest, map[string]any{"errors": errs})
+			return
+		}
+		if r.Method == http.MethodGet && p.Type() != "query" {
+			w.Header().Set("Allow", http.MethodPost)
+			writeGraphQLErrors(w, http.StatusMethodNotAllowed, p.Type()+" operations must be sent with POST")
+			return
+		}
+		res := p.Execute(r.Context())
+
+		// response
+		response.JSON(w, http.StatusOK, res)
+	}
+}
+
+// Schema is a method that returns a handler for the route GET /graphql/schema, the schema in the schema definition language
+func (h *GraphQLDefault) Schema() http.HandlerFunc {
+	sdl := h.s.SDL()
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(sdl))
+	}
+}
+
+// writeGraphQLErrors is a function that writes a response with a single error, as GraphQL clients expect them
+func writeGraphQLErrors(w http.ResponseWriter, code int, message string) {
+	response.JSON(w, code, map[string]any{"errors": []*graphql.Error{{Message: message}}})
+}
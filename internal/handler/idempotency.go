@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bootcamp-go/web/response"
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// IdempotencyKeyHeader is the header used by clients to make a request idempotent
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyReplayedHeader is the header set on responses replayed from the store
+const IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+// NewIdempotency is a function that returns a new instance of Idempotency
+func NewIdempotency(st internal.IdempotencyStore, ttl time.Duration) *Idempotency {
+	return &Idempotency{st: st, ttl: ttl}
+}
+
+// Idempotency is a struct with a middleware that replays stored responses for repeated Idempotency-Key headers
+type Idempotency struct {
+	// st is the store of the responses
+	st internal.IdempotencyStore
+	// ttl is the time a response is kept in the store
+	ttl time.Duration
+}
+
+// Handler is a method that returns the idempotency middleware
+func (m *Idempotency) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - requests without a key are not idempotent
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// - read body to fingerprint the request
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		fingerprint := fingerprintRequest(r, body)
+
+		// process
+		// - the lookup and the reservation are a single step, so that a retry can not reserve a key saved in between
+		rc, err := m.st.Reserve(key)
+		switch {
+		case err == nil:
+		case errors.Is(err, internal.ErrIdempotencyKeyExists):
+			// - replay the stored response
+			if rc.Fingerprint != fingerprint {
+				response.JSON(w, http.StatusUnprocessableEntity, "idempotency key reused with a different request")
+				return
+			}
+			for k, v := range rc.Header {
+				w.Header()[k] = v
+			}
+			w.Header().Set(IdempotencyReplayedHeader, "true")
+			w.WriteHeader(rc.StatusCode)
+			w.Write(rc.Body)
+			return
+		case errors.Is(err, internal.ErrIdempotencyKeyInProgress):
+			response.JSON(w, http.StatusConflict, "a request with the same idempotency key is in progress")
+			return
+		default:
+			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		// - first request for the key
+		rec := &recorder{ResponseWriter: w, statusCode: http.StatusOK}
+		defer func() {
+			// - panics are left to the recoverer without storing a response
+			if p := recover(); p != nil {
+				m.st.Release(key)
+				panic(p)
+			}
+			// - server errors are not stored so the client can retry them
+			if rec.statusCode >= http.StatusInternalServerError {
+				m.st.Release(key)
+				return
+			}
+			m.st.Save(internal.IdempotencyRecord{
+				Key:         key,
+				Fingerprint: fingerprint,
+				StatusCode:  rec.statusCode,
+				Header:      storedHeader(w.Header()),
+				Body:        rec.body.Bytes(),
+				ExpiresAt:   time.Now().Add(m.ttl),
+			})
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// storedHeader is a function that returns a copy of the header of a response to store, without the headers of its encoding:
+// a middleware wrapping this one, such as Compress, may have encoded the body it sent while the body stored is the one
+// written by the handler, and a replay is encoded again for the client replayed to
+func storedHeader(h http.Header) (hd http.Header) {
+	hd = h.Clone()
+	hd.Del("Content-Encoding")
+	hd.Del("Content-Length")
+	hd.Del("Vary")
+	return
+}
+
+// fingerprintRequest is a function that returns the hash of the method, path and body of a request
+func fingerprintRequest(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.Path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recorder is a struct that captures the status code and body written to a response
+type recorder struct {
+	http.ResponseWriter
+	// statusCode is the status code written to the response
+	statusCode int
+	// wroteHeader reports whether the status code was written
+	wroteHeader bool
+	// body is a copy of the body written to the response
+	body bytes.Buffer
+}
+
+// WriteHeader is a method that captures the status code
+func (r *recorder) WriteHeader(code int) {
+	if r.wroteHeader {
+		return
+	}
+	r.statusCode = code
+	r.wroteHeader = true
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Write is a method that captures the body
+func (r *recorder) Write(b []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal/repository"
+)
+
+func TestIdempotency_Handler(t *testing.T) {
+	var calls atomic.Int32
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":1}`))
+	})
+	h := NewIdempotency(repository.NewIdempotencyMap(), time.Hour).Handler(next)
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/vehicles", strings.NewReader(body))
+		req.Header.Set(IdempotencyKeyHeader, "k")
+		res := httptest.NewRecorder()
+		h.ServeHTTP(res, req)
+		return res
+	}
+
+	t.Run("retries reach the handler once", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if res := post(`{}`); res.Code != http.StatusCreated && res.Code != http.StatusConflict {
+					t.Errorf("status: got %d", res.Code)
+				}
+			}()
+		}
+		wg.Wait()
+		if n := calls.Load(); n != 1 {
+			t.Fatalf("calls: got %d, want 1", n)
+		}
+	})
+
+	t.Run("replays the stored response", func(t *testing.T) {
+		res := post(`{}`)
+		if res.Code != http.StatusCreated || res.Body.String() != `{"id":1}` || res.Header().Get(IdempotencyReplayedHeader) != "true" {
+			t.Fatalf("got %d %q %v", res.Code, res.Body.String(), res.Header())
+		}
+		if n := calls.Load(); n != 1 {
+			t.Fatalf("calls: got %d, want 1", n)
+		}
+	})
+
+	t.Run("rejects a different request", func(t *testing.T) {
+		if res := post(`{"brand":"x"}`); res.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("status: got %d, want %d", res.Code, http.StatusUnprocessableEntity)
+		}
+	})
+}
+
+func TestIdempotency_Handler_Compress(t *testing.T) {
+	body := strings.Repeat("x", 4096)
+	var calls atomic.Int32
+	c, err := NewCompress(1024, gzip.DefaultCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := c.Handler(NewIdempotency(repository.NewIdempotencyMap(), time.Hour).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(body))
+	})))
+	post := func(acceptEncoding string) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/vehicles", strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, "k")
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Result()
+	}
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		wantGzip       bool
+		wantReplayed   bool
+	}{
+		{name: "first request compressed", acceptEncoding: "gzip", wantGzip: true},
+		{name: "replay to a client without gzip", acceptEncoding: "", wantReplayed: true},
+		{name: "replay to a client with gzip", acceptEncoding: "gzip", wantGzip: true, wantReplayed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := post(tt.acceptEncoding)
+			if res.StatusCode != http.StatusCreated {
+				t.Fatalf("got status %d, want %d", res.StatusCode, http.StatusCreated)
+			}
+			if got := res.Header.Get("Content-Encoding") == "gzip"; got != tt.wantGzip {
+				t.Fatalf("got Content-Encoding %q, want gzip %t", res.Header.Get("Content-Encoding"), tt.wantGzip)
+			}
+			if got := res.Header.Get(IdempotencyReplayedHeader) == "true"; got != tt.wantReplayed {
+				t.Errorf("got %s %q, want replayed %t", IdempotencyReplayedHeader, res.Header.Get(IdempotencyReplayedHeader), tt.wantReplayed)
+			}
+			if got := res.Header.Values("Vary"); len(got) != 1 || got[0] != "Accept-Encoding" {
+				t.Errorf("got Vary %q, want Accept-Encoding once", got)
+			}
+			if got := gunzip(t, res); got != body {
+				t.Errorf("got a body of %d bytes, want the %d bytes written", len(got), len(body))
+			}
+		})
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("got %d calls of the handler, want 1", n)
+	}
+}
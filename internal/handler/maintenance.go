@@ -0,0 +1,337 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/bootcamp-go/web/request"
+	"github.com/bootcamp-go/web/response"
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// dateLayout is the layout of dates in requests and responses
+const dateLayout = "2006-01-02"
+
+// defaultDueWindow is how far ahead due services are looked for when no date is given
+const defaultDueWindow = 30 * 24 * time.Hour
+
+// parseDate is a function that parses a date, either a day or a time in RFC 3339 format
+func parseDate(s string) (t time.Time, err error) {
+	if t, err = time.Parse(dateLayout, s); err == nil {
+		return
+	}
+	t, err = time.Parse(time.RFC3339, s)
+	return
+}
+
+// parseDateParam is a function that parses a date query parameter, returning def when it is missing
+func parseDateParam(q url.Values, name string, def time.Time, errs *ParamErrors) (t time.Time) {
+	if !q.Has(name) {
+		return def
+	}
+	t, err := parseDate(q.Get(name))
+	if err != nil {
+		*errs = append(*errs, ParamError{Parameter: name, Value: q.Get(name), Message: "must be a date (YYYY-MM-DD) or an RFC 3339 time"})
+	}
+	return
+}
+
+// formatDate is a function that formats a date, zero dates are empty
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(dateLayout)
+}
+
+// MaintenanceRecordJSON is a struct that represents a maintenance record in JSON format
+type MaintenanceRecordJSON struct {
+	ID        int     `json:"id"`
+	VehicleID int     `json:"vehicle_id"`
+	Type      string  `json:"type"`
+	RuleID    int     `json:"rule_id,omitempty"`
+	Date      string  `json:"date"`
+	EndDate   string  `json:"end_date,omitempty"`
+	Odometer  int     `json:"odometer"`
+	Cost      float64 `json:"cost"`
+	Notes     string  `json:"notes,omitempty"`
+}
+
+// newMaintenanceRecordJSON is a function that serializes a maintenance record to JSON
+func newMaintenanceRecordJSON(r internal.MaintenanceRecord) MaintenanceRecordJSON {
+	return MaintenanceRecordJSON{
+		ID:        r.ID,
+		VehicleID: r.VehicleID,
+		Type:      string(r.Type),
+		RuleID:    r.RuleID,
+		Date:      formatDate(r.Date),
+		EndDate:   formatDate(r.EndDate),
+		Odometer:  r.Odometer,
+		Cost:      r.Cost,
+		Notes:     r.Notes,
+	}
+}
+
+// BodyMaintenanceRecordJSON is a struct that represents the request of a maintenance record in JSON format
+type BodyMaintenanceRecordJSON struct {
+	Type     string  `json:"type"`
+	RuleID   int     `json:"rule_id"`
+	Date     string  `json:"date"`
+	EndDate  string  `json:"end_date"`
+	Odometer int     `json:"odometer"`
+	Cost     float64 `json:"cost"`
+	Notes    string  `json:"notes"`
+}
+
+// MaintenanceRuleJSON is a struct that represents a schedule rule in JSON format
+type MaintenanceRuleJSON struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Brand       string `json:"brand,omitempty"`
+	Model       string `json:"model,omitempty"`
+	EveryKm     int    `json:"every_km,omitempty"`
+	EveryMonths int    `json:"every_months,omitempty"`
+}
+
+// newMaintenanceRuleJSON is a function that serializes a schedule rule to JSON
+func newMaintenanceRuleJSON(r internal.MaintenanceRule) MaintenanceRuleJSON {
+	return MaintenanceRuleJSON{
+		ID:          r.ID,
+		Name:        r.Name,
+		Type:        string(r.Type),
+		Brand:       r.Brand,
+		Model:       r.Model,
+		EveryKm:     r.EveryKm,
+		EveryMonths: r.EveryMonths,
+	}
+}
+
+// BodyMaintenanceRuleJSON is a struct that represents the request of a schedule rule in JSON format
+type BodyMaintenanceRuleJSON struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Brand       string `json:"brand"`
+	Model       string `json:"model"`
+	EveryKm     int    `json:"every_km"`
+	EveryMonths int    `json:"every_months"`
+}
+
+// MaintenanceDueJSON is a struct that represents a service that is due in JSON format
+type MaintenanceDueJSON struct {
+	VehicleID   int                    `json:"vehicle_id"`
+	Rule        MaintenanceRuleJSON    `json:"rule"`
+	Last        *MaintenanceRecordJSON `json:"last"`
+	DueDate     string                 `json:"due_date,omitempty"`
+	DueOdometer int                    `json:"due_odometer,omitempty"`
+	Odometer    int                    `json:"odometer"`
+	Overdue     bool                   `json:"overdue"`
+}
+
+// NewMaintenanceDefault is a function that returns a new instance of MaintenanceDefault
+func NewMaintenanceDefault(sv internal.MaintenanceService) *MaintenanceDefault {
+	return &MaintenanceDefault{sv: sv}
+}
+
+// MaintenanceDefault is a struct with methods that represent handlers for maintenance records and schedules
+type MaintenanceDefault struct {
+	// sv is the service that will be used by the handler
+	sv internal.MaintenanceService
+}
+
+// GetByVehicle is a method that returns a handler for the route GET /vehicles/{id}/maintenance
+func (h *MaintenanceDefault) GetByVehicle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		rec, err := h.sv.FindByVehicle(id)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrVehicleNotFound):
+				response.JSON(w, http.StatusNotFound, "vehicle not found")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		data := make([]MaintenanceRecordJSON, 0, len(rec))
+		for _, value := range rec {
+			data = append(data, newMaintenanceRecordJSON(value))
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
+
+// Create is a method that returns a handler for the route POST /vehicles/{id}/maintenance
+func (h *MaintenanceDefault) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		var body BodyMaintenanceRecordJSON
+		if err := request.JSON(r, &body); err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+		rec := internal.MaintenanceRecord{
+			VehicleID: id,
+			Type:      internal.MaintenanceType(body.Type),
+			RuleID:    body.RuleID,
+			Odometer:  body.Odometer,
+			Cost:      body.Cost,
+			Notes:     body.Notes,
+		}
+		var err error
+		if rec.Date, err = parseDate(body.Date); err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid date, expected YYYY-MM-DD")
+			return
+		}
+		if body.EndDate != "" {
+			if rec.EndDate, err = parseDate(body.EndDate); err != nil {
+				response.JSON(w, http.StatusBadRequest, "invalid end_date, expected YYYY-MM-DD")
+				return
+			}
+		}
+
+		// process
+		if err = h.sv.Create(&rec); err != nil {
+			switch {
+			case errors.Is(err, internal.ErrVehicleNotFound):
+				response.JSON(w, http.StatusNotFound, "vehicle not found")
+			case errors.Is(err, internal.ErrMaintenanceInvalid), errors.Is(err, internal.ErrMaintenanceRuleNotFound):
+				response.JSON(w, http.StatusUnprocessableEntity, err.Error())
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusCreated, map[string]any{
+			"message": "success",
+			"data":    newMaintenanceRecordJSON(rec),
+		})
+	}
+}
+
+// GetRules is a method that returns a handler for the route GET /vehicles/maintenance/rules
+func (h *MaintenanceDefault) GetRules() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// ...
+
+		// process
+		rules, err := h.sv.FindRules()
+		if err != nil {
+			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		// response
+		data := make([]MaintenanceRuleJSON, 0, len(rules))
+		for _, value := range rules {
+			data = append(data, newMaintenanceRuleJSON(value))
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
+
+// CreateRule is a method that returns a handler for the route POST /vehicles/maintenance/rules
+func (h *MaintenanceDefault) CreateRule() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		var body BodyMaintenanceRuleJSON
+		if err := request.JSON(r, &body); err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+		rule := internal.MaintenanceRule{
+			Name:        body.Name,
+			Type:        internal.MaintenanceType(body.Type),
+			Brand:       body.Brand,
+			Model:       body.Model,
+			EveryKm:     body.EveryKm,
+			EveryMonths: body.EveryMonths,
+		}
+
+		// process
+		if err := h.sv.CreateRule(&rule); err != nil {
+			switch {
+			case errors.Is(err, internal.ErrMaintenanceInvalid):
+				response.JSON(w, http.StatusUnprocessableEntity, err.Error())
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusCreated, map[string]any{
+			"message": "success",
+			"data":    newMaintenanceRuleJSON(rule),
+		})
+	}
+}
+
+// GetDue is a method that returns a handler for the route GET /vehicles/maintenance/due?before={date}.
+// It lists the overdue services and the ones due before the date, 30 days from now by default
+func (h *MaintenanceDefault) GetDue() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		now := time.Now().UTC()
+		var errs ParamErrors
+		before := parseDateParam(r.URL.Query(), "before", now.Add(defaultDueWindow), &errs)
+		if len(errs) > 0 {
+			writeParamErrors(w, errs)
+			return
+		}
+
+		// process
+		due, err := h.sv.Due(now, before)
+		if err != nil {
+			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		// response
+		data := make([]MaintenanceDueJSON, 0, len(due))
+		for _, value := range due {
+			d := MaintenanceDueJSON{
+				VehicleID:   value.VehicleID,
+				Rule:        newMaintenanceRuleJSON(value.Rule),
+				DueDate:     formatDate(value.DueDate),
+				DueOdometer: value.DueOdometer,
+				Odometer:    value.Odometer,
+				Overdue:     value.Overdue,
+			}
+			if value.Last != nil {
+				last := newMaintenanceRecordJSON(*value.Last)
+				d.Last = &last
+			}
+			data = append(data, d)
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/loader"
+	"github.com/rhinosc/code-review-1/internal/repository"
+	"github.com/rhinosc/code-review-1/internal/service"
+)
+
+func TestMaintenanceDefault_GetDue(t *testing.T) {
+	dir := t.TempDir()
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	// - vehicle 1 was inspected almost a year ago, so it is due in 10 days, and vehicle 2 was never inspected
+	vehicles := make(map[int]internal.Vehicle)
+	for id := 1; id <= 2; id++ {
+		vehicles[id] = internal.Vehicle{
+			Id:                id,
+			VehicleAttributes: internal.VehicleAttributes{Brand: "Ford", Model: "Transit", FabricationYear: 2020, Capacity: 3},
+			Status:            internal.VehicleStatusInService,
+		}
+	}
+	data := internal.MaintenanceData{
+		Records: map[int]internal.MaintenanceRecord{
+			1: {ID: 1, VehicleID: 1, Type: internal.MaintenanceTypeInspection, Date: today.AddDate(-1, 0, 10), Odometer: 1000},
+		},
+		Rules: map[int]internal.MaintenanceRule{
+			1: {ID: 1, Name: "Inspection", Type: internal.MaintenanceTypeInspection, EveryMonths: 12},
+		},
+	}
+	vr := repository.NewVehicleMap(loader.NewVehicleJSONFile(filepath.Join(dir, "vehicles.json")), vehicles, repository.NewVehicleIDSequential())
+	rp := repository.NewMaintenanceMap(loader.NewMaintenanceJSONFile(&loader.ConfigMaintenanceJSONFile{Path: filepath.Join(dir, "maintenance.json")}), data)
+	fr := repository.NewFuelLogMap(loader.NewFuelLogJSONFile(&loader.ConfigFuelLogJSONFile{Path: filepath.Join(dir, "fuel_log.json")}), nil)
+	hd := NewMaintenanceDefault(service.NewMaintenanceDefault(rp, vr, fr))
+	rt := chi.NewRouter()
+	rt.Get("/vehicles/maintenance/due", hd.GetDue())
+
+	dueDate := formatDate(data.Records[1].Date.AddDate(0, 12, 0))
+	tests := []struct {
+		name string
+		url  string
+		want []MaintenanceDueJSON
+	}{
+		{name: "within 30 days by default", url: "/vehicles/maintenance/due", want: []MaintenanceDueJSON{
+			{VehicleID: 2, Overdue: true},
+			{VehicleID: 1, DueDate: dueDate, Odometer: 1000},
+		}},
+		{name: "before a day", url: "/vehicles/maintenance/due?before=" + formatDate(today.AddDate(0, 0, 5)), want: []MaintenanceDueJSON{
+			{VehicleID: 2, Overdue: true},
+		}},
+		{name: "before a time", url: "/vehicles/maintenance/due?before=" + data.Records[1].Date.AddDate(0, 12, 0).Format(time.RFC3339), want: []MaintenanceDueJSON{
+			{VehicleID: 2, Overdue: true},
+			{VehicleID: 1, DueDate: dueDate, Odometer: 1000},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []MaintenanceDueJSON
+			getData(t, rt, tt.url, http.StatusOK, &got)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			for i, value := range got {
+				want := tt.want[i]
+				if value.VehicleID != want.VehicleID || value.DueDate != want.DueDate || value.Odometer != want.Odometer || value.Overdue != want.Overdue || value.Rule.ID != 1 {
+					t.Errorf("got %+v, want %+v", value, want)
+				}
+				// - the last record is given for the services done before
+				if (value.Last != nil) != (want.VehicleID == 1) {
+					t.Errorf("got last record %+v of vehicle %d", value.Last, value.VehicleID)
+				}
+			}
+		})
+	}
+
+	getData(t, rt, "/vehicles/maintenance/due?before=tomorrow", http.StatusBadRequest, nil)
+}
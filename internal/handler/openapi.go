@@ -0,0 +1,437 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bootcamp-go/web/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/graphql"
+	"github.com/rhinosc/code-review-1/internal/openapi"
+)
+
+// OpenAPI is a function that returns the OpenAPI specification of the routes of the server, its schemas generated from
+// the JSON types of the handlers. Every route must be described, CheckOpenAPI fails otherwise
+func OpenAPI() (d *openapi.Document) {
+	d = openapi.New("Vehicles API", "1.0.0", "Fleet of vehicles with their maintenance, reservations, drivers, fuel, attachments and compliance.\n\n"+
+		"Successful responses wrap their data as {\"message\": \"success\", \"data\": ...}; errors are a JSON string with the reason, "+
+		"or for invalid parameters and bodies the list of the invalid values.")
+
+	// components
+	errorJSON := d.Define("Error", openapi.String("Reason of the error"))
+	paramErrors := d.Define("ParamErrors", openapi.Object(map[string]*openapi.Schema{
+		"message": openapi.String(""),
+		"errors":  openapi.Array(d.Schema(ParamError{})),
+	}, "message", "errors"))
+	badRequest := d.Define("BadRequest", &openapi.Schema{OneOf: []*openapi.Schema{errorJSON, paramErrors}})
+	success := func(data *openapi.Schema) *openapi.Schema {
+		return openapi.Object(map[string]*openapi.Schema{"message": openapi.String(""), "data": data}, "message", "data")
+	}
+	message := openapi.Object(map[string]*openapi.Schema{"message": openapi.String("")}, "message")
+	vehicle := d.Schema(VehicleJSON{})
+	vehicleBody := d.Body(BodyVehicleJSON{}, "brand", "model", "year", "passengers")
+	statuses := make([]string, 0, len(internal.VehicleStatuses))
+	for _, value := range internal.VehicleStatuses {
+		statuses = append(statuses, string(value))
+	}
+	d.Resolve(vehicle).Properties["status"] = openapi.Enum("", statuses...)
+	positive := func(description string) *openapi.Schema {
+		return openapi.Integer(description).Between(openapi.Bound(1), nil)
+	}
+	date := openapi.String("Day as YYYY-MM-DD, or a time in RFC 3339 format")
+	status := openapi.String("Statuses the vehicles must be in any of, comma separated or repeated: " + strings.Join(statuses, ", "))
+	rangeOf := func(name string) string {
+		return "Range of the " + name + " as min..max, either bound may be omitted, wrapped in [ ] or ( ) for inclusive or exclusive bounds"
+	}
+	limit := openapi.Integer(fmt.Sprintf("Number of results, %d by default", defaultLimit)).Between(openapi.Bound(1), openapi.Bound(maxLimit))
+	idempotent := func(o *openapi.Operation) *openapi.Operation {
+		return o.Param("header", IdempotencyKeyHeader, "Key of the request, a repeated key replays the stored response", false, openapi.String(""))
+	}
+	d.Bearer(AdminSecurityScheme, "Admin token of the server, the routes requiring it are disabled when none is configured")
+	admin := func(o *openapi.Operation) *openapi.Operation {
+		return o.Secure(AdminSecurityScheme).
+			Respond(http.StatusUnauthorized, "Missing or invalid admin token", errorJSON).
+			Respond(http.StatusForbidden, "No admin token is configured", errorJSON)
+	}
+
+	// routes of a vehicle
+	onVehicle := func(method, path, id, summary, tag string) *openapi.Operation {
+		return d.Add(method, path, id, summary, tag).
+			Param("path", "id", "Id of the vehicle", true, positive("")).
+			Respond(http.StatusBadRequest, "Invalid parameters", badRequest).
+			Respond(http.StatusNotFound, "Vehicle not found", errorJSON)
+	}
+	vehicles := func(o *openapi.Operation) *openapi.Operation {
+		for _, value := range vehicleEncoders {
+			switch value.mediaTypes[0] {
+			case MediaTypeJSON:
+				o.RespondWith(http.StatusOK, "Vehicles keyed by id", MediaTypeJSON, success(&openapi.Schema{Type: openapi.TypeObject, AdditionalProperties: vehicle}))
+			default:
+				o.RespondWith(http.StatusOK, "", value.mediaTypes[0], openapi.String(""))
+			}
+		}
+		return o.Param("query", "status", "", false, status).
+			Param("header", "Accept", "Media type of the response, JSON by default", false, openapi.String("")).
+			Respond(http.StatusBadRequest, "Invalid parameters", badRequest).
+			Respond(http.StatusNotAcceptable, "No supported media type is acceptable", errorJSON)
+	}
+
+	// vehicles
+	vehicles(d.Add(http.MethodGet, "/vehicles", "getVehicles", "Vehicles, optionally of some statuses", "vehicles"))
+	vehicles(d.Add(http.MethodGet, "/vehicles/color/{color}/year/{year}", "getVehiclesByColorAndYear", "Vehicles of a color and a fabrication year", "vehicles")).
+		Param("path", "year", "Fabrication year", true, openapi.Integer("")).
+		Respond(http.StatusNotFound, "No vehicle matches", errorJSON)
+	dimensions := vehicles(d.Add(http.MethodGet, "/vehicles/dimensions", "getVehiclesByDimensions", "Vehicles whose dimensions fall in ranges", "vehicles")).
+		Respond(http.StatusNotFound, "No vehicle matches", errorJSON)
+	for _, name := range []string{"length", "width", "height"} {
+		dimensions.Param("query", name, rangeOf(name), false, openapi.String("")).
+			Param("query", name+"_min", "Inclusive lowest "+name, false, openapi.Number("")).
+			Param("query", name+"_max", "Inclusive highest "+name, false, openapi.Number(""))
+	}
+	export := d.Add(http.MethodGet, "/vehicles/export", "exportVehicles", "Every vehicle ordered by id, streamed", "vehicles").
+		Param("query", "status", "", false, status).
+		Param("header", "Accept", "Media type of the response, JSON by default", false, openapi.String("")).
+		Respond(http.StatusBadRequest, "Invalid parameters", badRequest).
+		Respond(http.StatusNotAcceptable, "No supported media type is acceptable", errorJSON)
+	for _, value := range exportFormats {
+		s := openapi.String("")
+		if value.mediaTypes[0] == MediaTypeJSON {
+			s = openapi.Array(vehicle)
+		}
+		export.RespondWith(http.StatusOK, "Vehicles", value.mediaTypes[0], s)
+	}
+	d.Add(http.MethodGet, "/vehicles/events", "streamVehicleEvents", "Events of the vehicles as Server-Sent Events", "vehicles").
+		Param("query", "brand", "Brands of the vehicles, comma separated or repeated", false, openapi.String("")).
+		Param("query", "status", "", false, status).
+		Param("query", "last_event_id", "Id of the last event received, when the Last-Event-ID header can not be set", false, openapi.String("")).
+		Param("header", "Last-Event-ID", "Id of the last event received, to resume the stream", false, openapi.String("")).
+		RespondWith(http.StatusOK, "Stream of the events, a reset event when it can not be resumed", MediaTypeEventStream, openapi.String("")).
+		Respond(http.StatusBadRequest, "Invalid parameters", badRequest)
+	d.Add(http.MethodGet, "/vehicles/search", "searchVehicles", "Vehicles matching a typo-tolerant query, most relevant first", "vehicles").
+		Param("query", "q", "Query", true, openapi.String("")).
+		Param("query", "limit", "", false, limit).
+		Respond(http.StatusOK, "Matches", success(openapi.Array(d.Schema(VehicleMatchJSON{})))).
+		Respond(http.StatusBadRequest, "Invalid parameters", badRequest)
+	d.Add(http.MethodGet, "/vehicles/suggest", "suggestVehicleValues", "Values of a field starting with a prefix, most frequent first", "vehicles").
+		Param("query", "field", "", true, openapi.Enum("", internal.SearchableFields...)).
+		Param("query", "prefix", "", false, openapi.String("")).
+		Param("query", "limit", "", false, limit).
+		Respond(http.StatusOK, "Suggestions", success(openapi.Array(d.Schema(SuggestionJSON{})))).
+		Respond(http.StatusBadRequest, "Invalid parameters", badRequest)
+	d.Add(http.MethodGet, "/vehicles/average_speed/brand/{brand}", "getAverageSpeedByBrand", "Average maximum speed of the vehicles of a brand", "vehicles").
+		Respond(http.StatusOK, "Average speed", success(openapi.Number(""))).
+		Respond(http.StatusBadRequest, "Invalid brand", errorJSON).
+		Respond(http.StatusNotFound, "No vehicle of the brand", errorJSON)
+	idempotent(d.Add(http.MethodPost, "/vehicles", "createVehicle", "Creates a vehicle, in service", "vehicles")).
+		Body(MediaTypeJSON, vehicleBody).
+		Respond(http.StatusCreated, "Vehicle created", success(vehicle)).
+		Respond(http.StatusBadRequest, "Invalid body", badRequest).
+		Respond(http.StatusUnprocessableEntity, "Invalid attributes", errorJSON)
+	onVehicle(http.MethodGet, "/vehicles/{id}", "getVehicle", "Vehicle with its history of statuses", "vehicles").
+		Respond(http.StatusOK, "Vehicle", success(d.Schema(VehicleDetailJSON{})))
+	onVehicle(http.MethodPut, "/vehicles/{id}", "updateVehicle", "Replaces the attributes of a vehicle, its status and history are kept", "vehicles").
+		Body(MediaTypeJSON, vehicleBody).
+		Respond(http.StatusOK, "Vehicle updated", success(vehicle)).
+		Respond(http.StatusUnprocessableEntity, "Invalid attributes", errorJSON)
+	onVehicle(http.MethodDelete, "/vehicles/{id}", "deleteVehicle", "Deletes a vehicle with its attachments and compliance items", "vehicles").
+		Respond(http.StatusNoContent, "Vehicle deleted", nil)
+	onVehicle(http.MethodGet, "/vehicles/{id}/transitions", "getVehicleTransitions", "History of the statuses of a vehicle, oldest first", "vehicles").
+		Respond(http.StatusOK, "Transitions", success(openapi.Array(d.Schema(TransitionJSON{}))))
+	transitionBody := d.Body(BodyTransitionJSON{}, "status")
+	d.Resolve(transitionBody).Properties["status"] = openapi.Enum("", statuses...)
+	idempotent(onVehicle(http.MethodPost, "/vehicles/{id}/transitions", "transitionVehicle", "Changes the status of a vehicle", "vehicles")).
+		Body(MediaTypeJSON, transitionBody).
+		Respond(http.StatusCreated, "Status changed", success(openapi.Object(map[string]*openapi.Schema{
+			"transition": d.Schema(TransitionJSON{}),
+			"vehicle":    d.Schema(VehicleDetailJSON{}),
+		}, "transition", "vehicle"))).
+		Respond(http.StatusUnprocessableEntity, "Unknown status or missing reason", errorJSON).
+		Respond(http.StatusConflict, "Transition not allowed", errorJSON)
+
+	// maintenance
+	d.Add(http.MethodGet, "/vehicles/maintenance/due", "getMaintenanceDue", "Maintenance due before a date", "maintenance").
+		Param("query", "before", "Date the maintenance is due before, 30 days from now by default", false, date).
+		Respond(http.StatusOK, "Maintenance due", success(openapi.Array(d.Schema(MaintenanceDueJSON{})))).
+		Respond(http.StatusBadRequest, "Invalid parameters", badRequest)
+	d.Add(http.MethodGet, "/vehicles/maintenance/rules", "getMaintenanceRules", "Rules of the maintenance schedule", "maintenance").
+		Respond(http.StatusOK, "Rules", success(openapi.Array(d.Schema(MaintenanceRuleJSON{}))))
+	d.Add(http.MethodPost, "/vehicles/maintenance/rules", "createMaintenanceRule", "Creates a rule of the maintenance schedule", "maintenance").
+		Body(MediaTypeJSON, d.Body(BodyMaintenanceRuleJSON{}, "name", "type")).
+		Respond(http.StatusCreated, "Rule created", success(d.Schema(MaintenanceRuleJSON{}))).
+		Respond(http.StatusBadRequest, "Invalid body", badRequest).
+		Respond(http.StatusUnprocessableEntity, "Invalid rule", errorJSON)
+	onVehicle(http.MethodGet, "/vehicles/{id}/maintenance", "getVehicleMaintenance", "Maintenance records of a vehicle", "maintenance").
+		Respond(http.StatusOK, "Records", success(openapi.Array(d.Schema(MaintenanceRecordJSON{}))))
+	idempotent(onVehicle(http.MethodPost, "/vehicles/{id}/maintenance", "createMaintenanceRecord", "Records a maintenance of a vehicle", "maintenance")).
+		Body(MediaTypeJSON, d.Body(BodyMaintenanceRecordJSON{}, "type", "date")).
+		Respond(http.StatusCreated, "Record created", success(d.Schema(MaintenanceRecordJSON{}))).
+		Respond(http.StatusUnprocessableEntity, "Invalid record", errorJSON)
+
+	// reservations
+	d.Add(http.MethodGet, "/vehicles/available", "getAvailableVehicles", "Vehicles free of reservations and maintenance in a period", "reservations").
+		Param("query", "from", "Start of the period", true, date).
+		Param("query", "to", "End of the period, after from", true, date).
+		Param("query", "min_passengers", "Lowest number of passengers", false, positive("")).
+		Respond(http.StatusOK, "Vehicles", success(openapi.Array(vehicle))).
+		Respond(http.StatusBadRequest, "Invalid parameters", badRequest)
+	onVehicle(http.MethodGet, "/vehicles/{id}/reservations", "getVehicleReservations", "Reservations of a vehicle", "reservations").
+		Respond(http.StatusOK, "Reservations", success(openapi.Array(d.Schema(ReservationJSON{}))))
+	idempotent(onVehicle(http.MethodPost, "/vehicles/{id}/reservations", "createReservation", "Reserves a vehicle for a period", "reservations")).
+		Body(MediaTypeJSON, d.Body(BodyReservationJSON{}, "from", "to")).
+		Respond(http.StatusCreated, "Reservation created", success(d.Schema(ReservationJSON{}))).
+		Respond(http.StatusUnprocessableEntity, "Invalid reservation", errorJSON).
+		Respond(http.StatusConflict, "The vehicle is not available", errorJSON)
+	onVehicle(http.MethodDelete, "/vehicles/{id}/reservations/{reservationID}", "cancelReservation", "Cancels a reservation", "reservations").
+		Param("path", "reservationID", "Id of the reservation", true, positive("")).
+		Respond(http.StatusOK, "Reservation cancelled", success(d.Schema(ReservationJSON{}))).
+		Respond(http.StatusNotFound, "Reservation not found", errorJSON).
+		Respond(http.StatusConflict, "Reservation already cancelled", errorJSON)
+
+	// drivers
+	driver := d.Schema(DriverJSON{})
+	driverBody := d.Body(BodyDriverJSON{}, "name", "licence_number")
+	onDriver := func(method, path, id, summary string) *openapi.Operation {
+		return d.Add(method, path, id, summary, "drivers").
+			Param("path", "id", "Id of the driver", true, positive("")).
+			Respond(http.StatusBadRequest, "Invalid parameters", badRequest).
+			Respond(http.StatusNotFound, "Driver not found", errorJSON)
+	}
+	onVehicle(http.MethodGet, "/vehicles/{id}/assignments", "getVehicleAssignments", "Drivers assigned to a vehicle, latest first", "drivers").
+		Respond(http.StatusOK, "Assignments", success(openapi.Array(d.Schema(AssignmentJSON{}))))
+	onVehicle(http.MethodPut, "/vehicles/{id}/driver", "assignDriver", "Assigns a driver to a vehicle", "drivers").
+		Body(MediaTypeJSON, d.Body(BodyAssignmentJSON{}, "driver_id")).
+		Respond(http.StatusOK, "Driver assigned", success(d.Schema(AssignmentJSON{}))).
+		Respond(http.StatusUnprocessableEntity, "The driver may not drive the vehicle", errorJSON).
+		Respond(http.StatusConflict, "The driver is assigned to another vehicle", errorJSON)
+	d.Resolve(d.Schema(BodyAssignmentJSON{})).Properties["driver_id"] = positive("")
+	onVehicle(http.MethodDelete, "/vehicles/{id}/driver", "unassignDriver", "Ends the assignment of the driver of a vehicle", "drivers").
+		Respond(http.StatusOK, "Driver unassigned", success(d.Schema(AssignmentJSON{})))
+	d.Add(http.MethodGet, "/drivers", "getDrivers", "Drivers", "drivers").
+		Respond(http.StatusOK, "Drivers", success(openapi.Array(driver)))
+	idempotent(d.Add(http.MethodPost, "/drivers", "createDriver", "Creates a driver", "drivers")).
+		Body(MediaTypeJSON, driverBody).
+		Respond(http.StatusCreated, "Driver created", success(driver)).
+		Respond(http.StatusBadRequest, "Invalid body", badRequest).
+		Respond(http.StatusUnprocessableEntity, "Invalid driver", errorJSON).
+		Respond(http.StatusConflict, "Licence number already in use", errorJSON)
+	onDriver(http.MethodGet, "/drivers/{id}", "getDriver", "Driver").
+		Respond(http.StatusOK, "Driver", success(driver))
+	onDriver(http.MethodPut, "/drivers/{id}", "updateDriver", "Replaces a driver").
+		Body(MediaTypeJSON, driverBody).
+		Respond(http.StatusOK, "Driver updated", success(driver)).
+		Respond(http.StatusUnprocessableEntity, "Invalid driver", errorJSON).
+		Respond(http.StatusConflict, "Licence number already in use", errorJSON)
+	onDriver(http.MethodDelete, "/drivers/{id}", "deleteDriver", "Deletes a driver with no current assignment").
+		Respond(http.StatusNoContent, "Driver deleted", nil).
+		Respond(http.StatusConflict, "The driver is assigned to a vehicle", errorJSON)
+	onDriver(http.MethodGet, "/drivers/{id}/assignments", "getDriverAssignments", "Vehicles assigned to a driver, latest first").
+		Respond(http.StatusOK, "Assignments", success(openapi.Array(d.Schema(AssignmentJSON{}))))
+
+	// fuel
+	d.Add(http.MethodGet, "/vehicles/fuel/stats", "getFuelGroupStats", "Fuel consumption of the vehicles grouped by an attribute", "fuel").
+		Param("query", "group_by", "Attribute the vehicles are grouped by, fuel_type by default", false, openapi.Enum("", internal.FieldBrand, internal.FieldModel, internal.FieldFuelType)).
+		Respond(http.StatusOK, "Stats by group", success(openapi.Array(d.Schema(FuelGroupStatsJSON{})))).
+		Respond(http.StatusBadRequest, "Invalid parameters", badRequest)
+	onVehicle(http.MethodGet, "/vehicles/{id}/fuel", "getVehicleFuel", "Fuel log of a vehicle", "fuel").
+		Respond(http.StatusOK, "Entries", success(openapi.Array(d.Schema(FuelLogEntryJSON{}))))
+	idempotent(onVehicle(http.MethodPost, "/vehicles/{id}/fuel", "createFuelLogEntry", "Records a refuelling of a vehicle", "fuel")).
+		Body(MediaTypeJSON, d.Body(BodyFuelLogEntryJSON{}, "date")).
+		Respond(http.StatusCreated, "Entry created", success(d.Schema(FuelLogEntryJSON{}))).
+		Respond(http.StatusUnprocessableEntity, "Invalid entry", errorJSON)
+	onVehicle(http.MethodGet, "/vehicles/{id}/fuel/stats", "getVehicleFuelStats", "Fuel consumption of a vehicle", "fuel").
+		Respond(http.StatusOK, "Stats", success(d.Schema(FuelStatsJSON{})))
+
+	// attachments
+	attachment := d.Schema(AttachmentJSON{})
+	onVehicle(http.MethodGet, "/vehicles/{id}/attachments", "getVehicleAttachments", "Attachments of a vehicle", "attachments").
+		Respond(http.StatusOK, "Attachments", success(openapi.Array(attachment)))
+	onVehicle(http.MethodPost, "/vehicles/{id}/attachments", "uploadAttachment", "Attaches a file to a vehicle", "attachments").
+		Body("multipart/form-data", openapi.Object(map[string]*openapi.Schema{"file": {Type: openapi.TypeString, Format: "binary"}}, "file")).
+		Respond(http.StatusCreated, "Attachment created", success(attachment)).
+		Respond(http.StatusRequestEntityTooLarge, "File too large", errorJSON).
+		Respond(http.StatusUnsupportedMediaType, "Type of file not allowed", errorJSON).
+		Respond(http.StatusUnprocessableEntity, "Invalid file", errorJSON)
+	onVehicle(http.MethodGet, "/vehicles/{id}/attachments/{attachmentID}", "downloadAttachment", "Content of an attachment", "attachments").
+		Param("path", "attachmentID", "Id of the attachment", true, positive("")).
+		RespondWith(http.StatusOK, "Content of the file, in its type", "application/octet-stream", &openapi.Schema{Type: openapi.TypeString, Format: "binary"}).
+		Respond(http.StatusNotFound, "Attachment not found", errorJSON)
+	onVehicle(http.MethodDelete, "/vehicles/{id}/attachments/{attachmentID}", "deleteAttachment", "Deletes an attachment", "attachments").
+		Param("path", "attachmentID", "Id of the attachment", true, positive("")).
+		Respond(http.StatusNoContent, "Attachment deleted", nil).
+		Respond(http.StatusNotFound, "Attachment not found", errorJSON)
+
+	// compliance
+	item := d.Schema(ComplianceItemJSON{})
+	itemBody := d.Body(BodyComplianceItemJSON{}, "kind", "expires")
+	d.Add(http.MethodGet, "/vehicles/compliance/expiring", "getComplianceExpiring", "Compliance items expiring within a number of days", "compliance").
+		Param("query", "within", "Number of days, 30 by default", false, openapi.Integer("").Between(openapi.Bound(0), nil)).
+		Respond(http.StatusOK, "Expiring items", success(openapi.Array(d.Schema(ComplianceExpiryJSON{})))).
+		Respond(http.StatusBadRequest, "Invalid parameters", badRequest)
+	onVehicle(http.MethodGet, "/vehicles/{id}/compliance", "getVehicleCompliance", "Compliance items of a vehicle", "compliance").
+		Respond(http.StatusOK, "Items", success(openapi.Array(item)))
+	idempotent(onVehicle(http.MethodPost, "/vehicles/{id}/compliance", "createComplianceItem", "Adds a compliance item to a vehicle", "compliance")).
+		Body(MediaTypeJSON, itemBody).
+		Respond(http.StatusCreated, "Item created", success(item)).
+		Respond(http.StatusUnprocessableEntity, "Invalid item", errorJSON)
+	onVehicle(http.MethodPut, "/vehicles/{id}/compliance/{itemID}", "updateComplianceItem", "Replaces a compliance item, such as when it is renewed", "compliance").
+		Param("path", "itemID", "Id of the item", true, positive("")).
+		Body(MediaTypeJSON, itemBody).
+		Respond(http.StatusOK, "Item updated", success(item)).
+		Respond(http.StatusNotFound, "Item not found", errorJSON).
+		Respond(http.StatusUnprocessableEntity, "Invalid item", errorJSON)
+	onVehicle(http.MethodDelete, "/vehicles/{id}/compliance/{itemID}", "deleteComplianceItem", "Deletes a compliance item", "compliance").
+		Param("path", "itemID", "Id of the item", true, positive("")).
+		Respond(http.StatusNoContent, "Item deleted", nil).
+		Respond(http.StatusNotFound, "Item not found", errorJSON)
+
+	// webhooks
+	webhook := d.Schema(WebhookJSON{})
+	webhookBody := d.Body(BodyWebhookJSON{}, "url")
+	eventTypes := make([]string, 0, len(internal.EventTypes))
+	for _, value := range internal.EventTypes {
+		eventTypes = append(eventTypes, string(value))
+	}
+	d.Resolve(webhookBody).Properties["events"] = openapi.Array(openapi.Enum("", eventTypes...))
+	onWebhook := func(method, path, id, summary string) *openapi.Operation {
+		return admin(d.Add(method, path, id, summary, "webhooks")).
+			Param("path", "id", "Id of the webhook", true, positive("")).
+			Respond(http.StatusBadRequest, "Invalid parameters", badRequest).
+			Respond(http.StatusNotFound, "Webhook not found", errorJSON)
+	}
+	admin(d.Add(http.MethodGet, "/webhooks", "getWebhooks", "Webhooks", "webhooks")).
+		Respond(http.StatusOK, "Webhooks", success(openapi.Array(webhook)))
+	admin(idempotent(d.Add(http.MethodPost, "/webhooks", "createWebhook", "Subscribes a URL to the events of the vehicles", "webhooks"))).
+		Body(MediaTypeJSON, webhookBody).
+		Respond(http.StatusCreated, "Webhook created, with its secret", success(webhook)).
+		Respond(http.StatusBadRequest, "Invalid body", badRequest).
+		Respond(http.StatusUnprocessableEntity, "Invalid webhook, or a URL of a host webhooks may not reach", errorJSON)
+	onWebhook(http.MethodGet, "/webhooks/{id}", "getWebhook", "Webhook").
+		Respond(http.StatusOK, "Webhook", success(webhook))
+	onWebhook(http.MethodDelete, "/webhooks/{id}", "deleteWebhook", "Deletes a webhook").
+		Respond(http.StatusNoContent, "Webhook deleted", nil)
+	onWebhook(http.MethodGet, "/webhooks/{id}/deliveries", "getWebhookDeliveries", "Latest attempts to deliver events to a webhook").
+		Respond(http.StatusOK, "Deliveries", success(openapi.Array(d.Schema(WebhookDeliveryJSON{}))))
+	onWebhook(http.MethodGet, "/webhooks/{id}/dead-letters", "getWebhookDeadLetters", "Events a webhook failed to receive").
+		Respond(http.StatusOK, "Dead letters", success(openapi.Array(d.Schema(WebhookDeadLetterJSON{}))))
+	onWebhook(http.MethodPost, "/webhooks/{id}/dead-letters/{deadLetterID}/redeliver", "redeliverDeadLetter", "Delivers a dead letter again").
+		Param("path", "deadLetterID", "Id of the dead letter", true, positive("")).
+		Respond(http.StatusAccepted, "Redelivery scheduled", message)
+
+	// graphql
+	graphQLResponse := openapi.Object(map[string]*openapi.Schema{
+		"data":   {Type: openapi.TypeObject, Nullable: true},
+		"errors": openapi.Array(d.Named("GraphQLError", graphql.Error{})),
+	})
+	d.Add(http.MethodGet, "/graphql", "queryGraphQL", "Executes a GraphQL query", "graphql").
+		Param("query", "query", "Document", false, openapi.String("")).
+		Param("query", "operationName", "Operation to execute, required when the document has several", false, openapi.String("")).
+		Param("query", "variables", "Variables encoded in JSON", false, openapi.String("")).
+		Respond(http.StatusOK, "Result", graphQLResponse).
+		Respond(http.StatusBadRequest, "Invalid request", graphQLResponse).
+		Respond(http.StatusMethodNotAllowed, "Mutations must be sent with POST", graphQLResponse)
+	d.Add(http.MethodPost, "/graphql", "executeGraphQL", "Executes a GraphQL operation", "graphql").
+		Body(MediaTypeJSON, d.Named("GraphQLRequest", graphql.Request{})).
+		Respond(http.StatusOK, "Result", graphQLResponse).
+		Respond(http.StatusBadRequest, "Invalid request", graphQLResponse)
+	d.Resolve(d.Named("GraphQLRequest", graphql.Request{})).Required = []string{"query"}
+	d.Resolve(d.Named("GraphQLRequest", graphql.Request{})).Properties["variables"] = &openapi.Schema{Type: openapi.TypeObject, Nullable: true}
+	d.Add(http.MethodGet, "/graphql/schema", "getGraphQLSchema", "GraphQL schema in the schema definition language", "graphql").
+		RespondWith(http.StatusOK, "Schema", "text/plain", openapi.String(""))
+
+	// admin
+	backup := d.Schema(BackupJSON{})
+	admin(d.Add(http.MethodPost, "/admin/reload", "reload", "Reloads the vehicles file", "admin")).
+		Respond(http.StatusOK, "Outcome of the reload", success(d.Schema(ReloadJSON{}))).
+		Respond(http.StatusUnprocessableEntity, "The file could not be reloaded", errorJSON)
+	admin(d.Add(http.MethodGet, "/admin/reload", "getReloadStatus", "State of the reloads, manual or on change of the file", "admin")).
+		Respond(http.StatusOK, "State of the reloads", success(d.Schema(ReloadStatusJSON{})))
+	admin(d.Add(http.MethodPost, "/admin/backups", "createBackup", "Backs up the vehicles", "admin")).
+		Respond(http.StatusCreated, "Backup created", success(backup))
+	admin(d.Add(http.MethodGet, "/admin/backups", "getBackups", "Backups, latest first", "admin")).
+		Respond(http.StatusOK, "Backups", success(openapi.Array(backup)))
+	admin(d.Add(http.MethodPost, "/admin/backups/{id}/restore", "restoreBackup", "Replaces the vehicles with the ones of a backup", "admin")).
+		Param("path", "id", "Id of the backup", true, openapi.String("")).
+		Respond(http.StatusOK, "Backup restored", success(backup)).
+		Respond(http.StatusNotFound, "Backup not found", errorJSON).
+		Respond(http.StatusUnprocessableEntity, "The backup could not be restored", errorJSON)
+	admin(d.Add(http.MethodPost, "/admin/compliance/remind", "remindCompliance", "Sends the reminders of the compliance items due now", "admin")).
+		Respond(http.StatusOK, "Reminders sent", success(openapi.Array(d.Schema(ComplianceReminderJSON{})))).
+		Respond(http.StatusBadGateway, "Reminders could not be delivered", errorJSON)
+
+	// specification
+	d.Add(http.MethodGet, "/openapi.json", "getOpenAPI", "This specification", "openapi").
+		Respond(http.StatusOK, "OpenAPI document", &openapi.Schema{Type: openapi.TypeObject})
+
+	// - any operation may fail, the ones with a body in a media type they do not take
+	for _, item := range d.Paths {
+		for _, op := range item {
+			if op.RequestBody != nil {
+				op.Respond(http.StatusUnsupportedMediaType, "Unsupported media type", errorJSON)
+			}
+			op.Respond(http.StatusInternalServerError, "Internal server error", errorJSON)
+		}
+	}
+	return
+}
+
+// CheckOpenAPI is a function that checks every route of a router is described in a specification and the other way around
+func CheckOpenAPI(d *openapi.Document, rt chi.Routes) (err error) {
+	described := make(map[string]bool)
+	for _, value := range d.Routes() {
+		described[value] = false
+	}
+	var missing []string
+	err = chi.Walk(rt, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		key := method + " " + openapi.Path(route)
+		if _, ok := described[key]; !ok {
+			missing = append(missing, key)
+			return nil
+		}
+		described[key] = true
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	var stale []string
+	for key, value := range described {
+		if !value {
+			stale = append(stale, key)
+		}
+	}
+	switch {
+	case len(missing) > 0:
+		err = fmt.Errorf("routes not described in the OpenAPI specification: %s", strings.Join(missing, ", "))
+	case len(stale) > 0:
+		err = fmt.Errorf("OpenAPI operations with no route: %s", strings.Join(stale, ", "))
+	}
+	return
+}
+
+// NewOpenAPIDefault is a function that returns a new instance of OpenAPIDefault
+func NewOpenAPIDefault(d *openapi.Document) *OpenAPIDefault {
+	return &OpenAPIDefault{d: d}
+}
+
+// OpenAPIDefault is a struct with methods that represent handlers for the OpenAPI specification
+type OpenAPIDefault struct {
+	// d is the specification
+	d *openapi.Document
+}
+
+// Get is a method that returns a handler for the route GET /openapi.json
+func (h *OpenAPIDefault) Get() http.HandlerFunc {
+	b, err := json.MarshalIndent(h.d, "", "  ")
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		w.Header().Set("Content-Type", MediaTypeJSON)
+		w.WriteHeader(http.StatusOK)
+		w.Write(b)
+	}
+}
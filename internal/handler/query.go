@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bootcamp-go/web/response"
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+var (
+	// ErrRangeEmpty is an error that represents a range with no values in it
+	ErrRangeEmpty = errors.New("range is empty")
+	// ErrRangeSyntax is an error that represents a range that can not be parsed
+	ErrRangeSyntax = errors.New("invalid range syntax, expected min..max")
+	// ErrRangeBound is an error that represents a bound that is not a finite number
+	ErrRangeBound = errors.New("bound is not a finite number")
+)
+
+// legacyRange matches the former {min}-{max} syntax, which only supports non-negative bounds
+var legacyRange = regexp.MustCompile(`^\d+(\.\d+)?-\d+(\.\d+)?$`)
+
+// ParseRange is a function that parses a range of values.
+// The accepted syntax is min..max where either bound may be omitted to leave that end open,
+// optionally wrapped in [ ] for inclusive or ( ) for exclusive bounds, e.g. (0..10] or 5.. .
+// A single number matches that exact value and the legacy min-max syntax is still accepted.
+func ParseRange(s string) (rg internal.Range, err error) {
+	rg = internal.NewRangeAll()
+	s = strings.TrimSpace(s)
+
+	// legacy syntax
+	if legacyRange.MatchString(s) {
+		bounds := strings.SplitN(s, "-", 2)
+		if rg.Min, err = parseBound(bounds[0]); err != nil {
+			return
+		}
+		if rg.Max, err = parseBound(bounds[1]); err != nil {
+			return
+		}
+		if rg.Empty() {
+			err = ErrRangeEmpty
+		}
+		return
+	}
+
+	// brackets
+	if strings.HasPrefix(s, "[") || strings.HasPrefix(s, "(") {
+		rg.MinExclusive = s[0] == '('
+		s = s[1:]
+		if !strings.HasSuffix(s, "]") && !strings.HasSuffix(s, ")") {
+			err = ErrRangeSyntax
+			return
+		}
+		rg.MaxExclusive = s[len(s)-1] == ')'
+		s = s[:len(s)-1]
+		if !strings.Contains(s, "..") {
+			err = ErrRangeSyntax
+			return
+		}
+	}
+
+	// single value
+	lower, upper, found := strings.Cut(s, "..")
+	if !found {
+		if rg.Min, err = parseBound(s); err != nil {
+			return
+		}
+		rg.Max = rg.Min
+		return
+	}
+
+	// bounds
+	if strings.Contains(upper, "..") {
+		err = ErrRangeSyntax
+		return
+	}
+	if lower != "" {
+		if rg.Min, err = parseBound(lower); err != nil {
+			return
+		}
+	}
+	if upper != "" {
+		if rg.Max, err = parseBound(upper); err != nil {
+			return
+		}
+	}
+	if rg.Empty() {
+		err = ErrRangeEmpty
+	}
+	return
+}
+
+// parseBound is a function that parses a finite bound of a range
+func parseBound(s string) (b float64, err error) {
+	b, err = strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || math.IsInf(b, 0) || math.IsNaN(b) {
+		err = ErrRangeBound
+		return
+	}
+	return
+}
+
+// ParamError is a struct that represents an invalid query parameter
+type ParamError struct {
+	// Parameter is the name of the parameter
+	Parameter string `json:"parameter"`
+	// Value is the value received for the parameter
+	Value string `json:"value"`
+	// Message is the reason the value is invalid
+	Message string `json:"message"`
+}
+
+// ParamErrors is a slice of invalid query parameters
+type ParamErrors []ParamError
+
+// Error is a method that returns the parameter errors as a string
+func (e ParamErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, pe := range e {
+		msgs = append(msgs, pe.Parameter+": "+pe.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// writeParamErrors is a function that writes the parameter errors as a structured 400 response
+func writeParamErrors(w http.ResponseWriter, errs ParamErrors) {
+	response.JSON(w, http.StatusBadRequest, map[string]any{
+		"message": "invalid parameters",
+		"errors":  errs,
+	})
+}
+
+// parseRangeParam is a function that reads the range of a parameter from either {name}={range}
+// or the inclusive {name}_min={min} and {name}_max={max} pair, leaving the range open when none is present
+func parseRangeParam(q url.Values, name string, errs *ParamErrors) (rg internal.Range) {
+	rg = internal.NewRangeAll()
+
+	nameMin, nameMax := name+"_min", name+"_max"
+	if q.Has(name) {
+		if q.Has(nameMin) || q.Has(nameMax) {
+			*errs = append(*errs, ParamError{Parameter: name, Value: q.Get(name), Message: "can not be combined with " + nameMin + " or " + nameMax})
+			return
+		}
+		var err error
+		rg, err = ParseRange(q.Get(name))
+		if err != nil {
+			*errs = append(*errs, ParamError{Parameter: name, Value: q.Get(name), Message: err.Error()})
+		}
+		return
+	}
+
+	var err error
+	if q.Has(nameMin) {
+		if rg.Min, err = parseBound(q.Get(nameMin)); err != nil {
+			*errs = append(*errs, ParamError{Parameter: nameMin, Value: q.Get(nameMin), Message: err.Error()})
+			return
+		}
+	}
+	if q.Has(nameMax) {
+		if rg.Max, err = parseBound(q.Get(nameMax)); err != nil {
+			*errs = append(*errs, ParamError{Parameter: nameMax, Value: q.Get(nameMax), Message: err.Error()})
+			return
+		}
+	}
+	if rg.Empty() {
+		*errs = append(*errs, ParamError{Parameter: nameMin, Value: q.Get(nameMin), Message: "must not be greater than " + nameMax})
+	}
+	return
+}
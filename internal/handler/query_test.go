@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+func TestParseRange(t *testing.T) {
+	inf := math.Inf(1)
+	tests := []struct {
+		name string
+		in   string
+		want internal.Range
+		err  error
+	}{
+		{name: "bounds", in: "1..2", want: internal.Range{Min: 1, Max: 2}},
+		{name: "inclusive brackets", in: "[1..2]", want: internal.Range{Min: 1, Max: 2}},
+		{name: "exclusive brackets", in: "(1..2)", want: internal.Range{Min: 1, Max: 2, MinExclusive: true, MaxExclusive: true}},
+		{name: "mixed brackets", in: "(0..10]", want: internal.Range{Min: 0, Max: 10, MinExclusive: true}},
+		{name: "open upper end", in: "5..", want: internal.Range{Min: 5, Max: inf}},
+		{name: "open lower end", in: "..5", want: internal.Range{Min: -inf, Max: 5}},
+		{name: "open ends", in: "..", want: internal.NewRangeAll()},
+		{name: "open end in brackets", in: "[5..)", want: internal.Range{Min: 5, Max: inf, MaxExclusive: true}},
+		{name: "negative and decimal bounds", in: "-1.5..2.25", want: internal.Range{Min: -1.5, Max: 2.25}},
+		{name: "spaces", in: " [ 1 .. 2 ] ", want: internal.Range{Min: 1, Max: 2}},
+		{name: "single value", in: "3", want: internal.Range{Min: 3, Max: 3}},
+		{name: "legacy", in: "1-2", want: internal.Range{Min: 1, Max: 2}},
+		{name: "legacy decimals", in: "1.5-2.5", want: internal.Range{Min: 1.5, Max: 2.5}},
+		{name: "legacy empty", in: "2-1", err: ErrRangeEmpty},
+		{name: "empty", in: "2..1", err: ErrRangeEmpty},
+		{name: "empty exclusive", in: "(1..1]", err: ErrRangeEmpty},
+		{name: "unclosed bracket", in: "[1..2", err: ErrRangeSyntax},
+		{name: "brackets without separator", in: "[1]", err: ErrRangeSyntax},
+		{name: "several separators", in: "1..2..3", err: ErrRangeSyntax},
+		{name: "not a number", in: "a..b", err: ErrRangeBound},
+		{name: "infinite bound", in: "1..Inf", err: ErrRangeBound},
+		{name: "nan bound", in: "NaN", err: ErrRangeBound},
+		{name: "blank", in: "", err: ErrRangeBound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRange(tt.in)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseRange(%q) error: got %v, want %v", tt.in, err, tt.err)
+			}
+			if tt.err == nil && got != tt.want {
+				t.Fatalf("ParseRange(%q): got %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRangeParam(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  internal.Range
+		errs  []string
+	}{
+		{name: "absent", query: "", want: internal.NewRangeAll()},
+		{name: "range", query: "length=1..2", want: internal.Range{Min: 1, Max: 2}},
+		{name: "min and max", query: "length_min=1&length_max=2", want: internal.Range{Min: 1, Max: 2}},
+		{name: "min only", query: "length_min=1", want: internal.Range{Min: 1, Max: math.Inf(1)}},
+		{name: "max only", query: "length_max=2", want: internal.Range{Min: math.Inf(-1), Max: 2}},
+		{name: "range and min", query: "length=1..2&length_min=1", errs: []string{"length"}},
+		{name: "invalid range", query: "length=x", errs: []string{"length"}},
+		{name: "invalid min", query: "length_min=x", errs: []string{"length_min"}},
+		{name: "invalid max", query: "length_max=x", errs: []string{"length_max"}},
+		{name: "min over max", query: "length_min=3&length_max=2", errs: []string{"length_min"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var errs ParamErrors
+			got := parseRangeParam(q, "length", &errs)
+			if len(errs) != len(tt.errs) {
+				t.Fatalf("errors: got %v, want parameters %v", errs, tt.errs)
+			}
+			for i, value := range errs {
+				if value.Parameter != tt.errs[i] {
+					t.Fatalf("errors: got %v, want parameters %v", errs, tt.errs)
+				}
+			}
+			if len(tt.errs) == 0 && got != tt.want {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func FuzzParseRange(f *testing.F) {
+	for _, value := range []string{"1..2", "[1..2]", "(0..10]", "5..", "..5", "..", "3", "1-2", "1.5-2.5", "2..1", "[1..2", "1..2..3", "a..b", "(..)", ""} {
+		f.Add(value)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		rg, err := ParseRange(s)
+		if err != nil {
+			return
+		}
+		if math.IsNaN(rg.Min) || math.IsNaN(rg.Max) || math.IsInf(rg.Min, 1) || math.IsInf(rg.Max, -1) {
+			t.Fatalf("ParseRange(%q): invalid bounds %+v", s, rg)
+		}
+		if rg.Empty() {
+			t.Fatalf("ParseRange(%q): empty range %+v accepted", s, rg)
+		}
+
+		// the range written back in the canonical syntax parses to itself
+		canonical := "[" + formatBound(rg.Min) + ".." + formatBound(rg.Max) + "]"
+		if rg.MinExclusive {
+			canonical = "(" + canonical[1:]
+		}
+		if rg.MaxExclusive {
+			canonical = canonical[:len(canonical)-1] + ")"
+		}
+		again, err := ParseRange(canonical)
+		if err != nil || again != rg {
+			t.Fatalf("ParseRange(%q) = %+v, but its canonical form %q parses to %+v, %v", s, rg, canonical, again, err)
+		}
+	})
+}
+
+// formatBound is a function that writes a bound of a range, empty for an open end
+func formatBound(b float64) string {
+	if math.IsInf(b, 0) {
+		return ""
+	}
+	return strconv.FormatFloat(b, 'g', -1, 64)
+}
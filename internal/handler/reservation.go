@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/bootcamp-go/web/request"
+	"github.com/bootcamp-go/web/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// ReservationJSON is a struct that represents a reservation in JSON format
+type ReservationJSON struct {
+	ID          int        `json:"id"`
+	VehicleID   int        `json:"vehicle_id"`
+	Driver      string     `json:"driver"`
+	Purpose     string     `json:"purpose,omitempty"`
+	From        time.Time  `json:"from"`
+	To          time.Time  `json:"to"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+}
+
+// newReservationJSON is a function that serializes a reservation to JSON
+func newReservationJSON(r internal.Reservation) ReservationJSON {
+	data := ReservationJSON{
+		ID:        r.ID,
+		VehicleID: r.VehicleID,
+		Driver:    r.Driver,
+		Purpose:   r.Purpose,
+		From:      r.From,
+		To:        r.To,
+		Status:    string(r.Status),
+		CreatedAt: r.CreatedAt,
+	}
+	if !r.CancelledAt.IsZero() {
+		data.CancelledAt = &r.CancelledAt
+	}
+	return data
+}
+
+// BodyReservationJSON is a struct that represents the request of a reservation in JSON format
+type BodyReservationJSON struct {
+	Driver  string `json:"driver"`
+	Purpose string `json:"purpose"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+}
+
+// parseRequiredDateParam is a function that parses a date query parameter that must be present
+func parseRequiredDateParam(q url.Values, name string, errs *ParamErrors) (t time.Time) {
+	if !q.Has(name) {
+		*errs = append(*errs, ParamError{Parameter: name, Message: "is required"})
+		return
+	}
+	return parseDateParam(q, name, time.Time{}, errs)
+}
+
+// NewReservationDefault is a function that returns a new instance of ReservationDefault
+func NewReservationDefault(sv internal.ReservationService) *ReservationDefault {
+	return &ReservationDefault{sv: sv}
+}
+
+// ReservationDefault is a struct with methods that represent handlers for reservations
+type ReservationDefault struct {
+	// sv is the service that will be used by the handler
+	sv internal.ReservationService
+}
+
+// GetByVehicle is a method that returns a handler for the route GET /vehicles/{id}/reservations
+func (h *ReservationDefault) GetByVehicle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		res, err := h.sv.FindByVehicle(id)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrVehicleNotFound):
+				response.JSON(w, http.StatusNotFound, "vehicle not found")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		data := make([]ReservationJSON, 0, len(res))
+		for _, value := range res {
+			data = append(data, newReservationJSON(value))
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
+
+// Create is a method that returns a handler for the route POST /vehicles/{id}/reservations
+func (h *ReservationDefault) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		var body BodyReservationJSON
+		if err := request.JSON(r, &body); err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+		res := internal.Reservation{
+			VehicleID: id,
+			Driver:    body.Driver,
+			Purpose:   body.Purpose,
+		}
+		var err error
+		if res.From, err = parseDate(body.From); err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid from, expected YYYY-MM-DD or an RFC 3339 time")
+			return
+		}
+		if res.To, err = parseDate(body.To); err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid to, expected YYYY-MM-DD or an RFC 3339 time")
+			return
+		}
+
+		// process
+		if err = h.sv.Create(&res); err != nil {
+			switch {
+			case errors.Is(err, internal.ErrVehicleNotFound):
+				response.JSON(w, http.StatusNotFound, "vehicle not found")
+			case errors.Is(err, internal.ErrReservationInvalid):
+				response.JSON(w, http.StatusUnprocessableEntity, err.Error())
+			case errors.Is(err, internal.ErrReservationConflict):
+				response.JSON(w, http.StatusConflict, err.Error())
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusCreated, map[string]any{
+			"message": "success",
+			"data":    newReservationJSON(res),
+		})
+	}
+}
+
+// Cancel is a method that returns a handler for the route DELETE /vehicles/{id}/reservations/{reservationID}.
+// The reservation is kept as cancelled so the history of the vehicle is not lost
+func (h *ReservationDefault) Cancel() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		reservationID, err := strconv.Atoi(chi.URLParam(r, "reservationID"))
+		if err != nil || reservationID <= 0 {
+			response.JSON(w, http.StatusBadRequest, "invalid reservation id")
+			return
+		}
+
+		// process
+		res, err := h.sv.Cancel(id, reservationID)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrReservationNotFound):
+				response.JSON(w, http.StatusNotFound, "reservation not found")
+			case errors.Is(err, internal.ErrReservationCancelled):
+				response.JSON(w, http.StatusConflict, "reservation already cancelled")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    newReservationJSON(res),
+		})
+	}
+}
+
+// GetAvailable is a method that returns a handler for the route GET /vehicles/available?from={date}&to={date}&min_passengers={n}.
+// It lists the vehicles that can be booked over the whole interval, min_passengers is 1 by default
+func (h *ReservationDefault) GetAvailable() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		q := r.URL.Query()
+		var errs ParamErrors
+		from := parseRequiredDateParam(q, "from", &errs)
+		to := parseRequiredDateParam(q, "to", &errs)
+		minPassengers := 1
+		if q.Has("min_passengers") {
+			var err error
+			if minPassengers, err = strconv.Atoi(q.Get("min_passengers")); err != nil || minPassengers < 1 {
+				errs = append(errs, ParamError{Parameter: "min_passengers", Value: q.Get("min_passengers"), Message: "must be a positive integer"})
+			}
+		}
+		if len(errs) == 0 && !from.Before(to) {
+			errs = append(errs, ParamError{Parameter: "to", Value: q.Get("to"), Message: "must be after from"})
+		}
+		if len(errs) > 0 {
+			writeParamErrors(w, errs)
+			return
+		}
+
+		// process
+		v, err := h.sv.Available(from, to, minPassengers)
+		if err != nil {
+			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		// response
+		data := make([]VehicleJSON, 0, len(v))
+		for _, value := range v {
+			data = append(data, newVehicleJSON(value))
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/loader"
+	"github.com/rhinosc/code-review-1/internal/repository"
+	"github.com/rhinosc/code-review-1/internal/service"
+)
+
+func TestReservationDefault_GetAvailable(t *testing.T) {
+	// vehicles 1, 2 and 3 seat 2, 5 and 9, vehicle 3 is booked the whole day
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 1, 0)
+	dir := t.TempDir()
+	vehicles := make(map[int]internal.Vehicle)
+	for id, capacity := range map[int]int{1: 2, 2: 5, 3: 9} {
+		vehicles[id] = internal.Vehicle{
+			Id:                id,
+			VehicleAttributes: internal.VehicleAttributes{Brand: "Ford", Model: "Transit", FabricationYear: 2020, Capacity: capacity},
+			Status:            internal.VehicleStatusInService,
+		}
+	}
+	reservations := map[int]internal.Reservation{
+		1: {ID: 1, VehicleID: 3, Driver: "Ana", From: day, To: day.AddDate(0, 0, 1), Status: internal.ReservationStatusActive},
+	}
+	vr := repository.NewVehicleMap(loader.NewVehicleJSONFile(filepath.Join(dir, "vehicles.json")), vehicles, repository.NewVehicleIDSequential())
+	rp := repository.NewReservationMap(loader.NewReservationJSONFile(&loader.ConfigReservationJSONFile{Path: filepath.Join(dir, "reservations.json")}), reservations)
+	mr := repository.NewMaintenanceMap(loader.NewMaintenanceJSONFile(&loader.ConfigMaintenanceJSONFile{Path: filepath.Join(dir, "maintenance.json")}), internal.MaintenanceData{})
+	hd := NewReservationDefault(service.NewReservationDefault(rp, vr, mr))
+
+	date := day.Format(dateLayout)
+	next := day.AddDate(0, 0, 1).Format(dateLayout)
+	tests := []struct {
+		name  string
+		query string
+		want  int
+		ids   []int
+	}{
+		{name: "any capacity", query: "from=" + date + "&to=" + next, want: http.StatusOK, ids: []int{1, 2}},
+		{name: "capacity", query: "from=" + date + "&to=" + next + "&min_passengers=3", want: http.StatusOK, ids: []int{2}},
+		{name: "capacity of a booked vehicle", query: "from=" + date + "&to=" + next + "&min_passengers=6", want: http.StatusOK, ids: []int{}},
+		{name: "after the booking", query: "from=" + next + "&to=" + day.AddDate(0, 0, 2).Format(dateLayout) + "&min_passengers=6", want: http.StatusOK, ids: []int{3}},
+		{name: "invalid capacity", query: "from=" + date + "&to=" + next + "&min_passengers=0", want: http.StatusBadRequest},
+		{name: "to before from", query: "from=" + next + "&to=" + date, want: http.StatusBadRequest},
+		{name: "missing to", query: "from=" + date, want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			hd.GetAvailable()(rec, httptest.NewRequest(http.MethodGet, "/vehicles/available?"+tt.query, nil))
+			if rec.Code != tt.want {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.ids == nil {
+				return
+			}
+
+			var body struct {
+				Data []VehicleJSON `json:"data"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			got := make([]int, 0, len(body.Data))
+			for _, value := range body.Data {
+				got = append(got, value.ID)
+			}
+			if !reflect.DeepEqual(got, tt.ids) {
+				t.Errorf("got vehicles %v, want %v", got, tt.ids)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/bootcamp-go/web/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/rhinosc/code-review-1/internal/openapi"
+)
+
+const (
+	// validatorMaxBody is the largest JSON body the validator reads, 1 MiB
+	validatorMaxBody = 1 << 20
+)
+
+// NewRequestValidator is a function that returns a new instance of RequestValidator, matching the requests against the
+// routes of rt to find their operation in d
+func NewRequestValidator(d *openapi.Document, rt chi.Routes) *RequestValidator {
+	return &RequestValidator{d: d, rt: rt}
+}
+
+// RequestValidator is a struct that checks the requests against the operation of their route in an OpenAPI specification
+// before they reach the handlers: the path and query parameters, the media type of the body and, for JSON, the body itself.
+// Invalid requests respond 400 with every invalid value, as the handlers do for their parameters
+type RequestValidator struct {
+	// d is the specification
+	d *openapi.Document
+	// rt is the router the requests are matched against
+	rt chi.Routes
+}
+
+// Handler is a method that returns a middleware validating the requests of the next handler.
+// Requests matching no operation, such as the ones of unknown routes, are passed through
+func (v *RequestValidator) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rctx := chi.NewRouteContext()
+		if !v.rt.Match(rctx, r.Method, r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		op := v.d.Operation(r.Method, openapi.Path(rctx.RoutePattern()))
+		if op == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// parameters
+		var vs []openapi.Violation
+		q := r.URL.Query()
+		for _, p := range op.Parameters {
+			switch p.In {
+			case "path":
+				vs = append(vs, v.d.ValidateParameter(p, rctx.URLParam(p.Name))...)
+			case "query":
+				if !q.Has(p.Name) {
+					if p.Required {
+						vs = append(vs, openapi.Violation{Path: p.Name, Message: "is required"})
+					}
+					continue
+				}
+				for _, value := range q[p.Name] {
+					vs = append(vs, v.d.ValidateParameter(p, value)...)
+				}
+			}
+		}
+
+		// body
+		if op.RequestBody != nil {
+			bvs, code, message := v.body(w, r, op.RequestBody)
+			if code != 0 {
+				response.JSON(w, code, message)
+				return
+			}
+			vs = append(vs, bvs...)
+		}
+
+		if len(vs) > 0 {
+			errs := make(ParamErrors, 0, len(vs))
+			for _, value := range vs {
+				errs = append(errs, ParamError{Parameter: value.Path, Value: value.Value, Message: value.Message})
+			}
+			writeParamErrors(w, errs)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// body is a method that checks the body of a request, restoring it for the handler once read.
+// The request is rejected with code and message when they are set, the violations of the body are returned otherwise
+func (v *RequestValidator) body(w http.ResponseWriter, r *http.Request, rb *openapi.RequestBody) (vs []openapi.Violation, code int, message string) {
+	header := r.Header.Get("Content-Type")
+	if header == "" && r.ContentLength <= 0 && len(r.TransferEncoding) == 0 {
+		if rb.Required {
+			vs = append(vs, openapi.Violation{Path: "body", Message: "is required"})
+		}
+		return
+	}
+	mt, _, err := mime.ParseMediaType(header)
+	content, ok := rb.Content[mt]
+	if err != nil || !ok {
+		types := make([]string, 0, len(rb.Content))
+		for key := range rb.Content {
+			types = append(types, key)
+		}
+		sort.Strings(types)
+		code, message = http.StatusUnsupportedMediaType, "the body must be "+strings.Join(types, " or ")
+		return
+	}
+	// - only JSON bodies are validated, others such as files are left to the handlers
+	if mt != MediaTypeJSON {
+		return
+	}
+
+	b, err := io.ReadAll(http.MaxBytesReader(w, r.Body, validatorMaxBody))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			code, message = http.StatusRequestEntityTooLarge, "the body is too large"
+			return
+		}
+		code, message = http.StatusBadRequest, "invalid body"
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(b))
+	if len(bytes.TrimSpace(b)) == 0 {
+		if rb.Required {
+			vs = append(vs, openapi.Violation{Path: "body", Message: "is required"})
+		}
+		return
+	}
+	var body any
+	if err = json.Unmarshal(b, &body); err != nil {
+		vs = append(vs, openapi.Violation{Path: "body", Message: "must be valid JSON"})
+		return
+	}
+	vs = v.d.Validate(content.Schema, body, "body")
+	return
+}
@@ -4,7 +4,6 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
-	"strings"
 
 	"github.com/bootcamp-go/web/request"
 	"github.com/bootcamp-go/web/response"
@@ -14,20 +13,22 @@ import (
 
 // VehicleJSON is a struct that represents a vehicle in JSON format
 type VehicleJSON struct {
-	ID              int     `json:"id"`
-	Brand           string  `json:"brand"`
-	Model           string  `json:"model"`
-	Registration    string  `json:"registration"`
-	Color           string  `json:"color"`
-	FabricationYear int     `json:"year"`
-	Capacity        int     `json:"passengers"`
-	MaxSpeed        float64 `json:"max_speed"`
-	FuelType        string  `json:"fuel_type"`
-	Transmission    string  `json:"transmission"`
-	Weight          float64 `json:"weight"`
-	Height          float64 `json:"height"`
-	Length          float64 `json:"length"`
-	Width           float64 `json:"width"`
+	ID              int     `json:"id" xml:"id"`
+	UID             string  `json:"uid,omitempty" xml:"uid,omitempty"`
+	Brand           string  `json:"brand" xml:"brand"`
+	Model           string  `json:"model" xml:"model"`
+	Registration    string  `json:"registration" xml:"registration"`
+	Color           string  `json:"color" xml:"color"`
+	FabricationYear int     `json:"year" xml:"year"`
+	Capacity        int     `json:"passengers" xml:"passengers"`
+	MaxSpeed        float64 `json:"max_speed" xml:"max_speed"`
+	FuelType        string  `json:"fuel_type" xml:"fuel_type"`
+	Transmission    string  `json:"transmission" xml:"transmission"`
+	Weight          float64 `json:"weight" xml:"weight"`
+	Height          float64 `json:"height" xml:"height"`
+	Length          float64 `json:"length" xml:"length"`
+	Width           float64 `json:"width" xml:"width"`
+	Status          string  `json:"status" xml:"status"`
 }
 
 type BodyVehicleJSON struct {
@@ -46,6 +47,49 @@ type BodyVehicleJSON struct {
 	Width           float64 `json:"width"`
 }
 
+// Attributes is a method that deserializes the attributes of a vehicle from JSON
+func (b BodyVehicleJSON) Attributes() internal.VehicleAttributes {
+	return internal.VehicleAttributes{
+		Brand:           b.Brand,
+		Model:           b.Model,
+		Registration:    b.Registration,
+		Color:           b.Color,
+		FabricationYear: b.FabricationYear,
+		Capacity:        b.Capacity,
+		MaxSpeed:        b.MaxSpeed,
+		FuelType:        b.FuelType,
+		Transmission:    b.Transmission,
+		Weight:          b.Weight,
+		Dimensions: internal.Dimensions{
+			Height: b.Height,
+			Length: b.Length,
+			Width:  b.Width,
+		},
+	}
+}
+
+// newVehicleJSON is a function that serializes a vehicle to JSON
+func newVehicleJSON(v internal.Vehicle) VehicleJSON {
+	return VehicleJSON{
+		ID:              v.Id,
+		UID:             v.UID,
+		Brand:           v.Brand,
+		Model:           v.Model,
+		Registration:    v.Registration,
+		Color:           v.Color,
+		FabricationYear: v.FabricationYear,
+		Capacity:        v.Capacity,
+		MaxSpeed:        v.MaxSpeed,
+		FuelType:        v.FuelType,
+		Transmission:    v.Transmission,
+		Weight:          v.Weight,
+		Height:          v.Height,
+		Length:          v.Length,
+		Width:           v.Width,
+		Status:          string(v.Status),
+	}
+}
+
 // NewVehicleDefault is a function that returns a new instance of VehicleDefault
 func NewVehicleDefault(sv internal.VehicleService) *VehicleDefault {
 	return &VehicleDefault{sv: sv}
@@ -61,40 +105,30 @@ type VehicleDefault struct {
 func (h *VehicleDefault) GetAll() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
-		// ...
+		// - negotiate the response format
+		enc, ok := negotiateVehicles(r)
+		if !ok {
+			writeNotAcceptable(w, vehicleOffers())
+			return
+		}
+		// - parse the status filter
+		var errs ParamErrors
+		status := parseStatusParam(r.URL.Query(), &errs)
+		if len(errs) > 0 {
+			writeParamErrors(w, errs)
+			return
+		}
 
 		// process
-		// - get all vehicles
-		v, err := h.sv.FindAll()
+		// - get all vehicles in the statuses of the filter
+		v, err := h.sv.FindByStatus(status)
 		if err != nil {
 			response.JSON(w, http.StatusInternalServerError, nil)
 			return
 		}
 
 		// response
-		data := make(map[int]VehicleJSON)
-		for key, value := range v {
-			data[key] = VehicleJSON{
-				ID:              value.Id,
-				Brand:           value.Brand,
-				Model:           value.Model,
-				Registration:    value.Registration,
-				Color:           value.Color,
-				FabricationYear: value.FabricationYear,
-				Capacity:        value.Capacity,
-				MaxSpeed:        value.MaxSpeed,
-				FuelType:        value.FuelType,
-				Transmission:    value.Transmission,
-				Weight:          value.Weight,
-				Height:          value.Height,
-				Length:          value.Length,
-				Width:           value.Width,
-			}
-		}
-		response.JSON(w, http.StatusOK, map[string]any{
-			"message": "success",
-			"data":    data,
-		})
+		enc(w, http.StatusOK, v)
 	}
 }
 
@@ -112,51 +146,25 @@ func (h *VehicleDefault) Create() http.HandlerFunc {
 		//process
 
 		// create vehicle
-		vehicleAttributes := internal.VehicleAttributes{
-			Brand:           body.Brand,
-			Model:           body.Model,
-			Registration:    body.Registration,
-			Color:           body.Color,
-			FabricationYear: body.FabricationYear,
-			Capacity:        body.Capacity,
-			MaxSpeed:        body.MaxSpeed,
-			FuelType:        body.FuelType,
-			Transmission:    body.Transmission,
-			Weight:          body.Weight,
-			Dimensions: internal.Dimensions{
-				Height: body.Height,
-				Length: body.Length,
-				Width:  body.Width,
-			},
-		}
 		vehicle := internal.Vehicle{
-			VehicleAttributes: vehicleAttributes,
+			VehicleAttributes: body.Attributes(),
 		}
 
 		err = h.sv.Create(&vehicle)
 		if err != nil {
-			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			switch {
+			case errors.Is(err, internal.ErrVehicleInvalid):
+				response.JSON(w, http.StatusUnprocessableEntity, err.Error())
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
 		}
 
 		//response
 
 		// serialize vehicle to JSON
-		data := VehicleJSON{
-			ID:              vehicle.Id,
-			Brand:           vehicle.Brand,
-			Model:           vehicle.Model,
-			Registration:    vehicle.Registration,
-			Color:           vehicle.Color,
-			FabricationYear: vehicle.FabricationYear,
-			Capacity:        vehicle.Capacity,
-			MaxSpeed:        vehicle.MaxSpeed,
-			FuelType:        vehicle.FuelType,
-			Transmission:    vehicle.Transmission,
-			Weight:          vehicle.Weight,
-			Height:          vehicle.Height,
-			Length:          vehicle.Length,
-			Width:           vehicle.Width,
-		}
+		data := newVehicleJSON(vehicle)
 
 		response.JSON(w, http.StatusCreated, map[string]any{
 			"message": "success",
@@ -165,117 +173,150 @@ func (h *VehicleDefault) Create() http.HandlerFunc {
 	}
 }
 
-// GetByColorAndYear is a method that returns a handler for the route GET /vehicles?color={color}&year={year}
-func (h *VehicleDefault) GetByColorAndYear() http.HandlerFunc {
+// Update is a method that returns a handler for the route PUT /vehicles/{id}
+func (h *VehicleDefault) Update() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
-		// ...
-		color := chi.URLParam(r, "color")
-		year, err := strconv.Atoi(chi.URLParam(r, "year"))
-		if err != nil {
-			response.JSON(w, http.StatusBadRequest, "invalid year")
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		var body BodyVehicleJSON
+		if err := request.JSON(r, &body); err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid body")
 			return
 		}
 
 		// process
-		// - get vehicles by color and year
-		v, err := h.sv.GetByColorAndYear(color, year)
+		v, err := h.sv.Update(id, body.Attributes())
 		if err != nil {
-			response.JSON(w, http.StatusNotFound, "vehicles not found")
+			switch {
+			case errors.Is(err, internal.ErrVehicleNotFound):
+				response.JSON(w, http.StatusNotFound, "vehicle not found")
+			case errors.Is(err, internal.ErrVehicleInvalid):
+				response.JSON(w, http.StatusUnprocessableEntity, err.Error())
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
 			return
 		}
 
 		// response
-		data := make(map[int]VehicleJSON)
-		for key, value := range v {
-			data[key] = VehicleJSON{
-				ID:              value.Id,
-				Brand:           value.Brand,
-				Model:           value.Model,
-				Registration:    value.Registration,
-				Color:           value.Color,
-				FabricationYear: value.FabricationYear,
-				Capacity:        value.Capacity,
-				MaxSpeed:        value.MaxSpeed,
-				FuelType:        value.FuelType,
-				Transmission:    value.Transmission,
-				Weight:          value.Weight,
-				Height:          value.Height,
-				Length:          value.Length,
-				Width:           value.Width,
-			}
-		}
 		response.JSON(w, http.StatusOK, map[string]any{
 			"message": "success",
-			"data":    data,
+			"data":    newVehicleJSON(v),
 		})
 	}
 }
 
-// GetByDimensions is a method that returns a handler for the route GET /vehicles/dimensions?length={min_length}-{max_length}&width={min_width}-{max_width}
-func (h *VehicleDefault) GetByDimensions() http.HandlerFunc {
+// Delete is a method that returns a handler for the route DELETE /vehicles/{id}
+func (h *VehicleDefault) Delete() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
-		// ...
-		length := r.URL.Query().Get("length")
-		width := r.URL.Query().Get("width")
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
 
-		lengthValues := strings.Split(length, "-")
-		widthValues := strings.Split(width, "-")
+		// process
+		if err := h.sv.Delete(id); err != nil {
+			switch {
+			case errors.Is(err, internal.ErrVehicleNotFound):
+				response.JSON(w, http.StatusNotFound, "vehicle not found")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
 
-		// fmt.Println(lengthValues, widthValues)
-		minLength, err := strconv.ParseFloat(lengthValues[0], 64)
-		if err != nil {
-			response.JSON(w, http.StatusBadRequest, "invalid min length")
+		// response
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetByColorAndYear is a method that returns a handler for the route GET /vehicles?color={color}&year={year}
+func (h *VehicleDefault) GetByColorAndYear() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - negotiate the response format
+		enc, ok := negotiateVehicles(r)
+		if !ok {
+			writeNotAcceptable(w, vehicleOffers())
 			return
 		}
-		maxLength, err := strconv.ParseFloat(lengthValues[1], 64)
+		color := chi.URLParam(r, "color")
+		year, err := strconv.Atoi(chi.URLParam(r, "year"))
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, "invalid max length")
+			response.JSON(w, http.StatusBadRequest, "invalid year")
 			return
 		}
-		minWidth, err := strconv.ParseFloat(widthValues[0], 64)
-		if err != nil {
-			response.JSON(w, http.StatusBadRequest, "invalid min width")
+		var errs ParamErrors
+		status := parseStatusParam(r.URL.Query(), &errs)
+		if len(errs) > 0 {
+			writeParamErrors(w, errs)
 			return
 		}
-		maxWidth, err := strconv.ParseFloat(widthValues[1], 64)
+
+		// process
+		// - get vehicles by color and year
+		v, err := h.sv.GetByColorAndYear(color, year, status)
 		if err != nil {
-			response.JSON(w, http.StatusBadRequest, "invalid max width")
+			switch {
+			case errors.Is(err, internal.ErrVehicleNotFound):
+				response.JSON(w, http.StatusNotFound, "vehicles not found")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		enc(w, http.StatusOK, v)
+	}
+}
+
+// GetByDimensions is a method that returns a handler for the route GET /vehicles/dimensions?length={range}&width={range}&height={range}
+// where each range is min..max (see ParseRange) or given as {dimension}_min and {dimension}_max
+func (h *VehicleDefault) GetByDimensions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - negotiate the response format
+		enc, ok := negotiateVehicles(r)
+		if !ok {
+			writeNotAcceptable(w, vehicleOffers())
+			return
+		}
+		// - parse the range of each dimension
+		q := r.URL.Query()
+		var errs ParamErrors
+		f := internal.DimensionsFilter{
+			Length: parseRangeParam(q, "length", &errs),
+			Width:  parseRangeParam(q, "width", &errs),
+			Height: parseRangeParam(q, "height", &errs),
+		}
+		status := parseStatusParam(q, &errs)
+		if len(errs) > 0 {
+			writeParamErrors(w, errs)
 			return
 		}
+
 		// process
 		// - get vehicles by dimensions
-		v, err := h.sv.GetByDimensions(minLength, maxLength, minWidth, maxWidth)
+		v, err := h.sv.GetByDimensions(f, status)
 		if err != nil {
-			response.JSON(w, http.StatusNotFound, "vehicles not found")
+			switch {
+			case errors.Is(err, internal.ErrVehicleNotFound):
+				response.JSON(w, http.StatusNotFound, "vehicles not found")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
 			return
 		}
 
 		// response
-		data := make(map[int]VehicleJSON)
-		for key, value := range v {
-			data[key] = VehicleJSON{
-				ID:              value.Id,
-				Brand:           value.Brand,
-				Model:           value.Model,
-				Registration:    value.Registration,
-				Color:           value.Color,
-				FabricationYear: value.FabricationYear,
-				Capacity:        value.Capacity,
-				MaxSpeed:        value.MaxSpeed,
-				FuelType:        value.FuelType,
-				Transmission:    value.Transmission,
-				Weight:          value.Weight,
-				Height:          value.Height,
-				Length:          value.Length,
-				Width:           value.Width,
-			}
-		}
-		response.JSON(w, http.StatusOK, map[string]any{
-			"message": "success",
-			"data":    data,
-		})
+		enc(w, http.StatusOK, v)
 	}
 }
 
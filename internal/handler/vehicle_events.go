@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bootcamp-go/web/response"
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/event"
+)
+
+const (
+	// MediaTypeEventStream is the media type of a Server-Sent Events stream
+	MediaTypeEventStream = "text/event-stream"
+	// eventStreamRetry is the wait in milliseconds clients are told to reconnect after
+	eventStreamRetry = 3000
+	// eventReset is the type of the event sent when the stream can not be resumed, clients reload the fleet on it
+	eventReset = "reset"
+)
+
+// NewVehicleEventsDefault is a function that returns a new instance of VehicleEventsDefault, a heartbeat of zero defaults to 15 seconds
+func NewVehicleEventsDefault(bus internal.EventBus, heartbeat time.Duration) *VehicleEventsDefault {
+	if heartbeat <= 0 {
+		heartbeat = 15 * time.Second
+	}
+	return &VehicleEventsDefault{bus: bus, heartbeat: heartbeat}
+}
+
+// VehicleEventsDefault is a struct with methods that represent handlers for the stream of the events of the vehicles
+type VehicleEventsDefault struct {
+	// bus is the bus the events are subscribed from
+	bus internal.EventBus
+	// heartbeat is the time between keep-alive comments of an idle stream
+	heartbeat time.Duration
+}
+
+// parseEventFilter is a function that parses the filter of the events of the query, brand and status may be repeated or comma separated
+func parseEventFilter(r *http.Request, errs *ParamErrors) (f internal.EventFilter) {
+	q := r.URL.Query()
+	for _, value := range q["brand"] {
+		for _, brand := range strings.Split(value, ",") {
+			if brand = strings.TrimSpace(brand); brand != "" {
+				f.Brands = append(f.Brands, brand)
+			}
+		}
+	}
+	f.Statuses = parseStatusParam(q, errs)
+	return
+}
+
+// Stream is a method that returns a handler for the route GET /vehicles/events?brand={brand}&status={status}.
+// It streams the events of the vehicles as Server-Sent Events, the id of each being the id of the event. A client
+// resumes with the Last-Event-ID header, or the last_event_id parameter, and receives the events it missed while they
+// are kept; otherwise it receives a reset event and reloads the fleet. Idle streams receive a comment as heartbeat
+func (h *VehicleEventsDefault) Stream() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		var errs ParamErrors
+		f := parseEventFilter(r, &errs)
+		if len(errs) > 0 {
+			writeParamErrors(w, errs)
+			return
+		}
+		lastEventID := r.Header.Get("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = r.URL.Query().Get("last_event_id")
+		}
+		fl, ok := w.(http.Flusher)
+		if !ok {
+			response.JSON(w, http.StatusInternalServerError, "streaming unsupported")
+			return
+		}
+
+		// process
+		replay, sub, err := h.bus.Subscribe(f, lastEventID)
+		reset := errors.Is(err, internal.ErrEventReplayUnavailable)
+		if err != nil && !reset {
+			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		defer sub.Close()
+
+		// response
+		w.Header().Set("Content-Type", MediaTypeEventStream)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		// - proxies must not buffer the stream
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		if _, err = fmt.Fprintf(w, "retry: %d\n\n", eventStreamRetry); err != nil {
+			return
+		}
+		if reset {
+			if err = writeSSE(w, "", eventReset, map[string]string{"reason": "events after " + lastEventID + " are no longer available"}); err != nil {
+				return
+			}
+		}
+		for _, e := range replay {
+			if err = writeSSE(w, e.ID, string(e.Type), event.NewEventJSON(e)); err != nil {
+				return
+			}
+		}
+		fl.Flush()
+
+		tk := time.NewTicker(h.heartbeat)
+		defer tk.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case e, ok := <-sub.Events():
+				if !ok {
+					// - the subscriber fell behind, the client reconnects and resumes from its last event
+					return
+				}
+				err = writeSSE(w, e.ID, string(e.Type), event.NewEventJSON(e))
+			case <-tk.C:
+				_, err = io.WriteString(w, ": heartbeat\n\n")
+			}
+			if err != nil {
+				return
+			}
+			fl.Flush()
+			tk.Reset(h.heartbeat)
+		}
+	}
+}
+
+// writeSSE is a function that writes an event of a Server-Sent Events stream, the data encoded as JSON in a single line
+func writeSSE(w io.Writer, id, typ string, data any) (err error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("error encoding event %s: %v", id, err)
+		return
+	}
+	var sb strings.Builder
+	if id != "" {
+		sb.WriteString("id: " + id + "\n")
+	}
+	sb.WriteString("event: " + typ + "\n")
+	sb.WriteString("data: ")
+	sb.Write(b)
+	sb.WriteString("\n\n")
+	_, err = io.WriteString(w, sb.String())
+	return
+}
@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/service"
+)
+
+// sseTimeout is the time a test waits for a message of a stream
+const sseTimeout = 5 * time.Second
+
+// sseMessage is a struct that represents a message of a Server-Sent Events stream, an event or a comment
+type sseMessage struct {
+	// id is the id of the event
+	id string
+	// event is the type of the event
+	event string
+	// data is the data of the event
+	data string
+	// comment is the comment, empty for events
+	comment string
+}
+
+// sseStream is a struct that reads the messages of a stream served by a test server
+type sseStream struct {
+	// res is the response of the stream
+	res *http.Response
+	// messages is the channel of the messages read, closed when the stream ends
+	messages chan sseMessage
+}
+
+// openSSE is a function that opens a stream at url with the given Last-Event-ID, and waits for its first message,
+// the retry sent once the subscription is taken, so events published afterwards are received
+func openSSE(t *testing.T, url, lastEventID string) *sseStream {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { res.Body.Close() })
+	if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != MediaTypeEventStream {
+		t.Fatalf("got status %d of type %s, want %d of type %s", res.StatusCode, res.Header.Get("Content-Type"), http.StatusOK, MediaTypeEventStream)
+	}
+
+	s := &sseStream{res: res, messages: make(chan sseMessage, 16)}
+	sc := bufio.NewScanner(res.Body)
+	if !sc.Scan() || sc.Text() != "retry: "+strconv.Itoa(eventStreamRetry) {
+		t.Fatalf("got first line %q, want the retry", sc.Text())
+	}
+	go func() {
+		defer close(s.messages)
+		var m sseMessage
+		for sc.Scan() {
+			line := sc.Text()
+			switch {
+			case line == "":
+				if m != (sseMessage{}) {
+					s.messages <- m
+				}
+				m = sseMessage{}
+			case strings.HasPrefix(line, ":"):
+				m.comment = strings.TrimSpace(line[1:])
+			case strings.HasPrefix(line, "id: "):
+				m.id = line[len("id: "):]
+			case strings.HasPrefix(line, "event: "):
+				m.event = line[len("event: "):]
+			case strings.HasPrefix(line, "data: "):
+				m.data = line[len("data: "):]
+			}
+		}
+	}()
+	return s
+}
+
+// next is a method that returns the next message of the stream
+func (s *sseStream) next(t *testing.T) (m sseMessage) {
+	t.Helper()
+	select {
+	case m, ok := <-s.messages:
+		if !ok {
+			t.Fatal("got the stream ended, want a message")
+		}
+		return m
+	case <-time.After(sseTimeout):
+		t.Fatal("got no message, want one")
+	}
+	return
+}
+
+// testEvent is a function that returns the event of the update of a vehicle of a brand and status, with a known id
+func testEvent(id, brand string, status internal.VehicleStatus) internal.Event {
+	e := internal.NewEvent(internal.EventVehicleUpdated, internal.Vehicle{
+		Id:                1,
+		VehicleAttributes: internal.VehicleAttributes{Brand: brand, Model: "T", FabricationYear: 2020, Capacity: 2},
+		Status:            status,
+	})
+	e.ID = id
+	return e
+}
+
+// newEventsServer is a function that returns a test server of the stream of the events of a bus
+func newEventsServer(t *testing.T, bus *service.EventBus, heartbeat time.Duration) *httptest.Server {
+	t.Helper()
+	sv := httptest.NewServer(NewVehicleEventsDefault(bus, heartbeat).Stream())
+	t.Cleanup(sv.Close)
+	return sv
+}
+
+func TestVehicleEventsDefault_Stream_Filter(t *testing.T) {
+	bus := service.NewEventBus(nil)
+	sv := newEventsServer(t, bus, time.Hour)
+
+	s := openSSE(t, sv.URL+"?brand=seat,FORD&status=reserved", "")
+	bus.Publish(testEvent("1", "Fiat", internal.VehicleStatusReserved))
+	bus.Publish(testEvent("2", "Ford", internal.VehicleStatusInService))
+	bus.Publish(testEvent("3", "Ford", internal.VehicleStatusReserved))
+	bus.Publish(testEvent("4", "Seat", internal.VehicleStatusInMaintenance))
+	bus.Publish(testEvent("5", "Seat", internal.VehicleStatusReserved))
+
+	// - only the events of the brands and statuses are received, in order
+	for _, want := range []string{"3", "5"} {
+		m := s.next(t)
+		if m.id != want || m.event != string(internal.EventVehicleUpdated) || !strings.Contains(m.data, `"id":"`+want+`"`) {
+			t.Errorf("got %+v, want event %s", m, want)
+		}
+	}
+}
+
+func TestVehicleEventsDefault_Stream_InvalidFilter(t *testing.T) {
+	sv := newEventsServer(t, service.NewEventBus(nil), time.Hour)
+
+	res, err := http.Get(sv.URL + "?status=parked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("got status %d, want %d", res.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestVehicleEventsDefault_Stream_Replay(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		lastEventID string
+		want        []sseMessage
+	}{
+		{name: "from a kept event", lastEventID: "3", want: []sseMessage{{id: "4"}, {id: "5"}, {id: "6"}}},
+		{name: "from the parameter", query: "?last_event_id=4", want: []sseMessage{{id: "5"}, {id: "6"}}},
+		{name: "from the latest event", lastEventID: "5", want: []sseMessage{{id: "6"}}},
+		{name: "filtered", query: "?brand=fiat", lastEventID: "3", want: []sseMessage{{id: "4"}, {id: "6"}}},
+		{name: "from an event no longer kept", lastEventID: "1", want: []sseMessage{{event: eventReset}, {id: "6"}}},
+		{name: "from an unknown event", lastEventID: "unknown", want: []sseMessage{{event: eventReset}, {id: "6"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// - the buffer keeps the latest 3 of 5 events, 3 to 5
+			bus := service.NewEventBus(&service.ConfigEventBus{ReplaySize: 3})
+			for i, brand := range []string{"Fiat", "Ford", "Ford", "Fiat", "Ford"} {
+				bus.Publish(testEvent(strconv.Itoa(i+1), brand, internal.VehicleStatusInService))
+			}
+			sv := newEventsServer(t, bus, time.Hour)
+
+			s := openSSE(t, sv.URL+tt.query, tt.lastEventID)
+			// - the live events follow the replayed ones
+			bus.Publish(testEvent("6", "Fiat", internal.VehicleStatusInService))
+			for _, want := range tt.want {
+				m := s.next(t)
+				if want.event == eventReset {
+					if m.event != eventReset || m.id != "" || !strings.Contains(m.data, tt.lastEventID) {
+						t.Errorf("got %+v, want a reset after %s", m, tt.lastEventID)
+					}
+					continue
+				}
+				if m.id != want.id {
+					t.Errorf("got event %q, want %q", m.id, want.id)
+				}
+			}
+		})
+	}
+}
+
+func TestVehicleEventsDefault_Stream_Heartbeat(t *testing.T) {
+	bus := service.NewEventBus(nil)
+	sv := newEventsServer(t, bus, 20*time.Millisecond)
+
+	s := openSSE(t, sv.URL, "")
+	// - an idle stream receives heartbeats, and keeps receiving events between them
+	if m := s.next(t); m.comment != "heartbeat" {
+		t.Fatalf("got %+v, want a heartbeat", m)
+	}
+	bus.Publish(testEvent("1", "Ford", internal.VehicleStatusInService))
+	for {
+		m := s.next(t)
+		if m.comment == "heartbeat" {
+			continue
+		}
+		if m.id != "1" {
+			t.Fatalf("got %+v, want event 1", m)
+		}
+		break
+	}
+	if m := s.next(t); m.comment != "heartbeat" {
+		t.Errorf("got %+v, want a heartbeat after the event", m)
+	}
+}
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log"
+	"math"
+	"net/http"
+
+	"github.com/bootcamp-go/web/response"
+)
+
+// exportBatchSize is the number of vehicles read from the service and flushed to the client at a time
+const exportBatchSize = 500
+
+// vehicleStream is an interface that represents an encoder writing vehicles one at a time
+type vehicleStream interface {
+	// Begin is a method that writes what precedes the first vehicle
+	Begin() (err error)
+	// Vehicle is a method that writes a vehicle
+	Vehicle(v VehicleJSON) (err error)
+	// End is a method that writes what follows the last vehicle
+	End() (err error)
+}
+
+// exportFormats is the list of formats of the export, in order of preference
+var exportFormats = []struct {
+	// mediaTypes is the list of media types served by the format, the first one is set as Content-Type
+	mediaTypes []string
+	// stream is the constructor of the encoder
+	stream func(w io.Writer) vehicleStream
+}{
+	{mediaTypes: []string{MediaTypeJSON}, stream: func(w io.Writer) vehicleStream { return &jsonArrayStream{w: w} }},
+	{mediaTypes: []string{MediaTypeNDJSON, "application/ndjson", "application/jsonl"}, stream: func(w io.Writer) vehicleStream { return &ndjsonStream{enc: json.NewEncoder(w)} }},
+	{mediaTypes: []string{MediaTypeCSV}, stream: func(w io.Writer) vehicleStream { return &csvStream{cw: csv.NewWriter(w)} }},
+}
+
+// Export is a method that returns a handler for the route GET /vehicles/export.
+// The vehicles are streamed in batches ordered by id, so memory does not grow with the size of the fleet
+func (h *VehicleDefault) Export() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - negotiate the response format
+		offers := make([][]string, 0, len(exportFormats))
+		for _, ef := range exportFormats {
+			offers = append(offers, ef.mediaTypes)
+		}
+		i, ok := negotiate(r, offers)
+		if !ok {
+			writeNotAcceptable(w, offers)
+			return
+		}
+		// - parse the status filter
+		var errs ParamErrors
+		status := parseStatusParam(r.URL.Query(), &errs)
+		if len(errs) > 0 {
+			writeParamErrors(w, errs)
+			return
+		}
+
+		// process
+		// - read the first batch before committing to a status code
+		batch, err := h.sv.FindAfter(math.MinInt, exportBatchSize)
+		if err != nil {
+			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		// response
+		w.Header().Set("Content-Type", exportFormats[i].mediaTypes[0])
+		w.WriteHeader(http.StatusOK)
+		fl, _ := w.(http.Flusher)
+		st := exportFormats[i].stream(w)
+		if err = st.Begin(); err != nil {
+			return
+		}
+		for len(batch) > 0 {
+			for _, value := range batch {
+				if !status.Contains(value.Status) {
+					continue
+				}
+				if err = st.Vehicle(newVehicleJSON(value)); err != nil {
+					return
+				}
+			}
+			if fl != nil {
+				fl.Flush()
+			}
+			if len(batch) < exportBatchSize {
+				break
+			}
+			// - the status is already sent, a failure can only cut the stream short
+			batch, err = h.sv.FindAfter(batch[len(batch)-1].Id, exportBatchSize)
+			if err != nil {
+				log.Println("error exporting vehicles:", err)
+				return
+			}
+		}
+		st.End()
+	}
+}
+
+// jsonArrayStream is a struct that writes vehicles as a JSON array
+type jsonArrayStream struct {
+	// w is the writer of the array
+	w io.Writer
+	// n is the number of vehicles written
+	n int
+}
+
+// Begin is a method that opens the array
+func (s *jsonArrayStream) Begin() (err error) {
+	_, err = io.WriteString(s.w, "[")
+	return
+}
+
+// Vehicle is a method that writes an element of the array
+func (s *jsonArrayStream) Vehicle(v VehicleJSON) (err error) {
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	if s.n > 0 {
+		if _, err = io.WriteString(s.w, ","); err != nil {
+			return
+		}
+	}
+	s.n++
+	_, err = s.w.Write(bytes)
+	return
+}
+
+// End is a method that closes the array
+func (s *jsonArrayStream) End() (err error) {
+	_, err = io.WriteString(s.w, "]\n")
+	return
+}
+
+// ndjsonStream is a struct that writes vehicles as newline delimited JSON
+type ndjsonStream struct {
+	// enc is the encoder of the lines
+	enc *json.Encoder
+}
+
+// Begin is a method that writes nothing
+func (s *ndjsonStream) Begin() (err error) {
+	return
+}
+
+// Vehicle is a method that writes a vehicle in its own line
+func (s *ndjsonStream) Vehicle(v VehicleJSON) (err error) {
+	err = s.enc.Encode(v)
+	return
+}
+
+// End is a method that writes nothing
+func (s *ndjsonStream) End() (err error) {
+	return
+}
+
+// csvStream is a struct that writes vehicles as CSV
+type csvStream struct {
+	// cw is the writer of the records
+	cw *csv.Writer
+}
+
+// Begin is a method that writes the header row
+func (s *csvStream) Begin() (err error) {
+	err = s.cw.Write(csvHeader)
+	return
+}
+
+// Vehicle is a method that writes the row of a vehicle, rows are buffered up to the size of the writer buffer
+func (s *csvStream) Vehicle(v VehicleJSON) (err error) {
+	err = s.cw.Write(csvRecord(v))
+	return
+}
+
+// End is a method that flushes the pending rows
+func (s *csvStream) End() (err error) {
+	s.cw.Flush()
+	err = s.cw.Error()
+	return
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/repository"
+	"github.com/rhinosc/code-review-1/internal/service"
+)
+
+// discardWriter is a struct that represents a response writer dropping the body, so that the benchmark does not hold it
+type discardWriter struct {
+	// header is the header of the response
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return io.Discard.Write(b) }
+func (w *discardWriter) WriteHeader(code int)        {}
+func (w *discardWriter) Flush()                      {}
+
+// heapWriter is a struct that represents a response writer dropping the body and recording the peak of the live heap
+// every heapSampleBytes written, measured after a collection
+type heapWriter struct {
+	discardWriter
+	// base is the live heap before the response
+	base uint64
+	// written is the number of bytes written since the last sample
+	written int
+	// peak is the greatest growth of the live heap over base
+	peak uint64
+}
+
+// heapSampleBytes is the number of bytes written between samples of the live heap
+const heapSampleBytes = 64 << 10
+
+// liveHeap is a function that returns the live heap, after a collection
+func liveHeap() uint64 {
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms.HeapAlloc
+}
+
+func (w *heapWriter) Write(b []byte) (int, error) {
+	if w.written += len(b); w.written >= heapSampleBytes {
+		w.written = 0
+		if h := liveHeap(); h > w.base {
+			w.peak = max(w.peak, h-w.base)
+		}
+	}
+	return len(b), nil
+}
+
+// newExportFleet is a function that returns a vehicle handler over a fleet of n vehicles
+func newExportFleet(n int) *VehicleDefault {
+	db := make(map[int]internal.Vehicle, n)
+	for i := 1; i <= n; i++ {
+		db[i] = internal.Vehicle{
+			Id: i,
+			VehicleAttributes: internal.VehicleAttributes{
+				Brand: "Ford", Model: "Transit", Registration: fmt.Sprintf("AB-%06d", i), Color: "White",
+				FabricationYear: 2020, Capacity: 3, MaxSpeed: 150, FuelType: "diesel", Transmission: "manual",
+				Weight: 2000, Dimensions: internal.Dimensions{Height: 2.5, Length: 5.9, Width: 2},
+			},
+			Status: internal.VehicleStatusInService,
+		}
+	}
+	rp := repository.NewVehicleMap(nil, db, repository.NewVehicleIDSequential())
+	return NewVehicleDefault(service.NewVehicleDefault(rp, nil))
+}
+
+// BenchmarkExport measures the export of fleets of growing size. The allocations per vehicle stay the same whatever
+// the size, as the vehicles are read and written in batches rather than all at once. The fleet of a million vehicles is
+// skipped in short mode
+func BenchmarkExport(b *testing.B) {
+	for _, n := range []int{1_000, 10_000, 100_000, 1_000_000} {
+		if n > 100_000 && testing.Short() {
+			b.Logf("skipping the fleet of %d vehicles in short mode", n)
+			continue
+		}
+		hd := newExportFleet(n)
+		for _, mediaType := range []string{MediaTypeJSON, MediaTypeNDJSON, MediaTypeCSV} {
+			b.Run(fmt.Sprintf("%s/%d", mediaType, n), func(b *testing.B) {
+				h := hd.Export()
+				req := httptest.NewRequest(http.MethodGet, "/vehicles/export", nil)
+				req.Header.Set("Accept", mediaType)
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					h(&discardWriter{header: make(http.Header)}, req)
+				}
+				b.StopTimer()
+				ms := testing.AllocsPerRun(1, func() { h(&discardWriter{header: make(http.Header)}, req) })
+				b.ReportMetric(ms/float64(n), "allocs/vehicle")
+			})
+		}
+	}
+}
+
+func TestVehicleDefault_Export_Memory(t *testing.T) {
+	// usage is a function that returns the allocations per vehicle and the peak growth of the live heap of an export
+	usage := func(hd *VehicleDefault, n int, mediaType string) (allocs float64, peak uint64) {
+		h := hd.Export()
+		req := httptest.NewRequest(http.MethodGet, "/vehicles/export", nil)
+		req.Header.Set("Accept", mediaType)
+		allocs = testing.AllocsPerRun(1, func() { h(&discardWriter{header: make(http.Header)}, req) }) / float64(n)
+		w := &heapWriter{discardWriter: discardWriter{header: make(http.Header)}, base: liveHeap()}
+		h(w, req)
+		peak = w.peak
+		return
+	}
+
+	small, large := 1_000, 20_000
+	hs, hl := newExportFleet(small), newExportFleet(large)
+	for _, mediaType := range []string{MediaTypeJSON, MediaTypeNDJSON, MediaTypeCSV} {
+		t.Run(mediaType, func(t *testing.T) {
+			as, ps := usage(hs, small, mediaType)
+			al, pl := usage(hl, large, mediaType)
+			t.Logf("%d vehicles: %.2f allocs/vehicle, peak %d bytes; %d vehicles: %.2f allocs/vehicle, peak %d bytes", small, as, ps, large, al, pl)
+
+			// - the allocations per vehicle do not grow with the fleet
+			if al > as*1.1 {
+				t.Errorf("got %.2f allocs/vehicle exporting %d vehicles, want at most those of %d: %.2f", al, large, small, as)
+			}
+			// - nor does the live heap: 20 times the vehicles may not take twice the memory, plus the noise of a MiB
+			if pl > 2*ps+1<<20 {
+				t.Errorf("got a peak of %d bytes exporting %d vehicles, want it bounded by that of %d: %d", pl, large, small, ps)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bootcamp-go/web/response"
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+const (
+	// defaultLimit is the number of results returned when no limit is given
+	defaultLimit = 10
+	// maxLimit is the maximum number of results that can be requested
+	maxLimit = 100
+)
+
+// VehicleMatchJSON is a struct that represents a vehicle matching a search in JSON format
+type VehicleMatchJSON struct {
+	VehicleJSON
+	Score  float64  `json:"score"`
+	Fields []string `json:"matched_fields"`
+}
+
+// SuggestionJSON is a struct that represents a suggested value in JSON format
+type SuggestionJSON struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Search is a method that returns a handler for the route GET /vehicles/search?q={query}&limit={limit}
+func (h *VehicleDefault) Search() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		q := r.URL.Query()
+		var errs ParamErrors
+		query := strings.TrimSpace(q.Get("q"))
+		if query == "" {
+			errs = append(errs, ParamError{Parameter: "q", Value: q.Get("q"), Message: "is required"})
+		}
+		limit := parseLimitParam(q, &errs)
+		if len(errs) > 0 {
+			writeParamErrors(w, errs)
+			return
+		}
+
+		// process
+		// - search vehicles
+		m, err := h.sv.Search(query, limit)
+		if err != nil {
+			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		// response
+		data := make([]VehicleMatchJSON, 0, len(m))
+		for _, value := range m {
+			data = append(data, VehicleMatchJSON{
+				VehicleJSON: newVehicleJSON(value.Vehicle),
+				Score:       value.Score,
+				Fields:      value.Fields,
+			})
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
+
+// Suggest is a method that returns a handler for the route GET /vehicles/suggest?field={field}&prefix={prefix}&limit={limit}
+func (h *VehicleDefault) Suggest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		q := r.URL.Query()
+		var errs ParamErrors
+		field := q.Get("field")
+		if field == "" {
+			errs = append(errs, ParamError{Parameter: "field", Message: "is required"})
+		}
+		limit := parseLimitParam(q, &errs)
+		if len(errs) > 0 {
+			writeParamErrors(w, errs)
+			return
+		}
+
+		// process
+		// - suggest values
+		sg, err := h.sv.Suggest(field, q.Get("prefix"), limit)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrFieldNotSearchable):
+				writeParamErrors(w, ParamErrors{{Parameter: "field", Value: field, Message: "must be one of " + strings.Join(internal.SearchableFields, ", ")}})
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		data := make([]SuggestionJSON, 0, len(sg))
+		for _, value := range sg {
+			data = append(data, SuggestionJSON{Value: value.Value, Count: value.Count})
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
+
+// parseLimitParam is a function that reads the limit parameter, defaulting to defaultLimit
+func parseLimitParam(q url.Values, errs *ParamErrors) (limit int) {
+	limit = defaultLimit
+	if !q.Has("limit") {
+		return
+	}
+	limit, err := strconv.Atoi(q.Get("limit"))
+	if err != nil || limit < 1 || limit > maxLimit {
+		*errs = append(*errs, ParamError{Parameter: "limit", Value: q.Get("limit"), Message: "must be an integer between 1 and " + strconv.Itoa(maxLimit)})
+	}
+	return
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/loader"
+	"github.com/rhinosc/code-review-1/internal/repository"
+	"github.com/rhinosc/code-review-1/internal/service"
+)
+
+// newSearchRouter is a function that returns the search routes over a fleet saved to a temporary file, and its service:
+// 1 is a red Chevrolet Spark, 2 a yellow Chevrolet Camaro, 3 a red Ford Focus and 4 a white Toyota Corolla
+func newSearchRouter(t *testing.T) (rt *chi.Mux, sv *service.VehicleDefault) {
+	t.Helper()
+	db := make(map[int]internal.Vehicle)
+	for id, value := range map[int][3]string{1: {"Chevrolet", "Spark", "Red"}, 2: {"Chevrolet", "Camaro", "Yellow"}, 3: {"Ford", "Focus", "Red"}, 4: {"Toyota", "Corolla", "White"}} {
+		db[id] = internal.Vehicle{
+			Id:                id,
+			VehicleAttributes: internal.VehicleAttributes{Brand: value[0], Model: value[1], Color: value[2], FabricationYear: 2020, Capacity: 4},
+			Status:            internal.VehicleStatusInService,
+		}
+	}
+	ld := loader.NewVehicleJSONFile(filepath.Join(t.TempDir(), "vehicles.json"))
+	sv = service.NewVehicleDefault(repository.NewVehicleMap(ld, db, repository.NewVehicleIDSequential()), nil)
+	hd := NewVehicleDefault(sv)
+
+	rt = chi.NewRouter()
+	rt.Get("/vehicles/search", hd.Search())
+	rt.Get("/vehicles/suggest", hd.Suggest())
+	return
+}
+
+// getData is a function that serves a GET request and decodes the data of the response
+func getData(t *testing.T, rt http.Handler, url string, want int, data any) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	rt.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+	if rec.Code != want {
+		t.Fatalf("got status %d, want %d: %s", rec.Code, want, rec.Body)
+	}
+	if data == nil {
+		return
+	}
+	body := struct {
+		Data any `json:"data"`
+	}{Data: data}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVehicleDefault_Search(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		want       int
+		ids        []int
+		wantFields []string
+	}{
+		{name: "misspelt brand", url: "/vehicles/search?q=chevrolt", want: http.StatusOK, ids: []int{1, 2}, wantFields: []string{internal.FieldBrand}},
+		{name: "alias", url: "/vehicles/search?q=CHEVY", want: http.StatusOK, ids: []int{1, 2}, wantFields: []string{internal.FieldBrand}},
+		{name: "every word matches", url: "/vehicles/search?q=chevrolt%20red", want: http.StatusOK, ids: []int{1}, wantFields: []string{internal.FieldBrand, internal.FieldColor}},
+		{name: "exact before typo", url: "/vehicles/search?q=corolla", want: http.StatusOK, ids: []int{4}, wantFields: []string{internal.FieldModel}},
+		{name: "prefix", url: "/vehicles/search?q=foc", want: http.StatusOK, ids: []int{3}, wantFields: []string{internal.FieldModel}},
+		{name: "limit", url: "/vehicles/search?q=red&limit=1", want: http.StatusOK, ids: []int{1}, wantFields: []string{internal.FieldColor}},
+		{name: "no match", url: "/vehicles/search?q=lamborghini", want: http.StatusOK, ids: []int{}},
+		{name: "missing query", url: "/vehicles/search?q=%20", want: http.StatusBadRequest},
+		{name: "invalid limit", url: "/vehicles/search?q=ford&limit=0", want: http.StatusBadRequest},
+	}
+	rt, _ := newSearchRouter(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data []VehicleMatchJSON
+			if tt.ids == nil {
+				getData(t, rt, tt.url, tt.want, nil)
+				return
+			}
+			getData(t, rt, tt.url, tt.want, &data)
+
+			ids := make([]int, 0, len(data))
+			for _, value := range data {
+				ids = append(ids, value.ID)
+				if !reflect.DeepEqual(value.Fields, tt.wantFields) || value.Score <= 0 || value.Score > 1 {
+					t.Errorf("got vehicle %d matched in %v scoring %v, want matched in %v", value.ID, value.Fields, value.Score, tt.wantFields)
+				}
+			}
+			if !reflect.DeepEqual(ids, tt.ids) {
+				t.Errorf("got vehicles %v, want %v", ids, tt.ids)
+			}
+		})
+	}
+
+	// - the closest match ranks first
+	rt, sv := newSearchRouter(t)
+	v := internal.Vehicle{VehicleAttributes: internal.VehicleAttributes{Brand: "Chevrolet", Model: "Sparks", Color: "Red", FabricationYear: 2021, Capacity: 4}}
+	if err := sv.Create(&v); err != nil {
+		t.Fatal(err)
+	}
+	var data []VehicleMatchJSON
+	getData(t, rt, "/vehicles/search?q=sparks", http.StatusOK, &data)
+	if len(data) != 2 || data[0].ID != v.Id || data[0].Score <= data[1].Score {
+		t.Errorf("got %+v, want vehicle %d first", data, v.Id)
+	}
+}
+
+func TestVehicleDefault_Suggest(t *testing.T) {
+	rt, sv := newSearchRouter(t)
+	// suggest is a function that returns the suggestions of a URL
+	suggest := func(url string) (s []SuggestionJSON) {
+		t.Helper()
+		getData(t, rt, url, http.StatusOK, &s)
+		return
+	}
+
+	if got, want := suggest("/vehicles/suggest?field=brand&prefix=c"), []SuggestionJSON{{"Chevrolet", 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, want := suggest("/vehicles/suggest?field=color&prefix=&limit=2"), []SuggestionJSON{{"Red", 2}, {"White", 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// - the index follows creates, updates and deletes
+	v := internal.Vehicle{VehicleAttributes: internal.VehicleAttributes{Brand: "chevy", Model: "Bolt", FabricationYear: 2021, Capacity: 4}}
+	if err := sv.Create(&v); err != nil {
+		t.Fatal(err)
+	}
+	attrs := internal.VehicleAttributes{Brand: "Cadillac", Model: "Escalade", Color: "Black", FabricationYear: 2020, Capacity: 7}
+	if _, err := sv.Update(2, attrs); err != nil {
+		t.Fatal(err)
+	}
+	if err := sv.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := suggest("/vehicles/suggest?field=brand&prefix=C"), []SuggestionJSON{{"Cadillac", 1}, {"Chevrolet", 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := suggest("/vehicles/suggest?field=model&prefix=spa"); len(got) != 0 {
+		t.Errorf("got %v, want the model of the deleted vehicle gone", got)
+	}
+
+	for _, url := range []string{"/vehicles/suggest?prefix=c", "/vehicles/suggest?field=year&prefix=2", "/vehicles/suggest?field=brand&limit=x"} {
+		getData(t, rt, url, http.StatusBadRequest, nil)
+	}
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bootcamp-go/web/request"
+	"github.com/bootcamp-go/web/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// TransitionJSON is a struct that represents a change of status in JSON format
+type TransitionJSON struct {
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	At     time.Time `json:"at"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// newTransitionJSON is a function that serializes a change of status to JSON
+func newTransitionJSON(t internal.StatusTransition) TransitionJSON {
+	return TransitionJSON{From: string(t.From), To: string(t.To), At: t.At, Reason: t.Reason}
+}
+
+// VehicleDetailJSON is a struct that represents a vehicle with its history in JSON format
+type VehicleDetailJSON struct {
+	VehicleJSON
+	Transitions     []TransitionJSON `json:"transitions"`
+	NextStatus      []string         `json:"next_status"`
+	LicenceCategory string           `json:"licence_category"`
+}
+
+// newVehicleDetailJSON is a function that serializes a vehicle with its history to JSON
+func newVehicleDetailJSON(v internal.Vehicle) VehicleDetailJSON {
+	data := VehicleDetailJSON{
+		VehicleJSON:     newVehicleJSON(v),
+		Transitions:     make([]TransitionJSON, 0, len(v.Transitions)),
+		NextStatus:      make([]string, 0),
+		LicenceCategory: string(internal.RequiredLicence(v.VehicleAttributes)),
+	}
+	for _, t := range v.Transitions {
+		data.Transitions = append(data.Transitions, newTransitionJSON(t))
+	}
+	for _, st := range v.Status.Next() {
+		data.NextStatus = append(data.NextStatus, string(st))
+	}
+	return data
+}
+
+// BodyTransitionJSON is a struct that represents the request of a change of status in JSON format
+type BodyTransitionJSON struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// parseStatusParam is a function that parses the status filter of a list, given as a comma separated list or repeated
+func parseStatusParam(q url.Values, errs *ParamErrors) (f internal.StatusFilter) {
+	for _, value := range q["status"] {
+		for _, name := range strings.Split(value, ",") {
+			if strings.TrimSpace(name) == "" {
+				continue
+			}
+			st, err := internal.ParseVehicleStatus(name)
+			if err != nil {
+				*errs = append(*errs, ParamError{Parameter: "status", Value: name, Message: err.Error()})
+				continue
+			}
+			f = append(f, st)
+		}
+	}
+	return
+}
+
+// parseIDParam is a function that parses the id of the vehicle in the URL
+func parseIDParam(r *http.Request) (id int, ok bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	ok = err == nil && id > 0
+	return
+}
+
+// GetByID is a method that returns a handler for the route GET /vehicles/{id}
+func (h *VehicleDefault) GetByID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		v, err := h.sv.FindByID(id)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrVehicleNotFound):
+				response.JSON(w, http.StatusNotFound, "vehicle not found")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    newVehicleDetailJSON(v),
+		})
+	}
+}
+
+// GetTransitions is a method that returns a handler for the route GET /vehicles/{id}/transitions
+func (h *VehicleDefault) GetTransitions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		v, err := h.sv.FindByID(id)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrVehicleNotFound):
+				response.JSON(w, http.StatusNotFound, "vehicle not found")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    newVehicleDetailJSON(v).Transitions,
+		})
+	}
+}
+
+// Transition is a method that returns a handler for the route POST /vehicles/{id}/transitions
+func (h *VehicleDefault) Transition() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		var body BodyTransitionJSON
+		if err := request.JSON(r, &body); err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+		to, err := internal.ParseVehicleStatus(body.Status)
+		if err != nil {
+			response.JSON(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+
+		// process
+		v, t, err := h.sv.Transition(id, to, body.Reason)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrVehicleNotFound):
+				response.JSON(w, http.StatusNotFound, "vehicle not found")
+			case errors.Is(err, internal.ErrVehicleTransitionReason):
+				response.JSON(w, http.StatusUnprocessableEntity, err.Error())
+			case errors.Is(err, internal.ErrVehicleTransitionInvalid):
+				response.JSON(w, http.StatusConflict, err.Error())
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusCreated, map[string]any{
+			"message": "success",
+			"data": map[string]any{
+				"transition": newTransitionJSON(t),
+				"vehicle":    newVehicleDetailJSON(v),
+			},
+		})
+	}
+}
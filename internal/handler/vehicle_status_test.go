@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/loader"
+	"github.com/rhinosc/code-review-1/internal/repository"
+	"github.com/rhinosc/code-review-1/internal/service"
+)
+
+// newStatusRouter is a function that returns the vehicle routes over a fleet saved to a temporary file: vehicle 1 is in
+// service, 2 reserved and 3 decommissioned, all of them white from 2020 and of the same dimensions
+func newStatusRouter(t *testing.T) *chi.Mux {
+	t.Helper()
+	db := make(map[int]internal.Vehicle)
+	for id, st := range map[int]internal.VehicleStatus{1: internal.VehicleStatusInService, 2: internal.VehicleStatusReserved, 3: internal.VehicleStatusDecommissioned} {
+		db[id] = internal.Vehicle{
+			Id: id,
+			VehicleAttributes: internal.VehicleAttributes{
+				Brand: "Ford", Model: "Transit", Color: "White", FabricationYear: 2020, Capacity: 3,
+				Dimensions: internal.Dimensions{Height: 2.5, Length: 5.9, Width: 2},
+			},
+			Status: st,
+		}
+	}
+	ld := loader.NewVehicleJSONFile(filepath.Join(t.TempDir(), "vehicles.json"))
+	hd := NewVehicleDefault(service.NewVehicleDefault(repository.NewVehicleMap(ld, db, repository.NewVehicleIDSequential()), nil))
+
+	rt := chi.NewRouter()
+	rt.Get("/vehicles", hd.GetAll())
+	rt.Get("/vehicles/color/{color}/year/{year}", hd.GetByColorAndYear())
+	rt.Get("/vehicles/dimensions", hd.GetByDimensions())
+	rt.Post("/vehicles/{id}/transitions", hd.Transition())
+	return rt
+}
+
+func TestVehicleDefault_Transition(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		body string
+		want int
+	}{
+		{name: "allowed", id: "1", body: `{"status":"in_maintenance","reason":"brakes"}`, want: http.StatusCreated},
+		{name: "status in other case", id: "2", body: `{"status":" IN_SERVICE "}`, want: http.StatusCreated},
+		{name: "not allowed", id: "2", body: `{"status":"in_maintenance"}`, want: http.StatusConflict},
+		{name: "from a final status", id: "3", body: `{"status":"in_service"}`, want: http.StatusConflict},
+		{name: "to the same status", id: "1", body: `{"status":"in_service"}`, want: http.StatusConflict},
+		{name: "decommissioned without a reason", id: "1", body: `{"status":"decommissioned"}`, want: http.StatusUnprocessableEntity},
+		{name: "unknown status", id: "1", body: `{"status":"parked"}`, want: http.StatusUnprocessableEntity},
+		{name: "invalid body", id: "1", body: `{"status":`, want: http.StatusBadRequest},
+		{name: "invalid id", id: "x", body: `{"status":"reserved"}`, want: http.StatusBadRequest},
+		{name: "vehicle not found", id: "9", body: `{"status":"reserved"}`, want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := newStatusRouter(t)
+			req := httptest.NewRequest(http.MethodPost, "/vehicles/"+tt.id+"/transitions", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			rt.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestVehicleDefault_Transition_History(t *testing.T) {
+	rt := newStatusRouter(t)
+	req := httptest.NewRequest(http.MethodPost, "/vehicles/1/transitions", strings.NewReader(`{"status":"decommissioned","reason":" sold "}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	rt.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("got status %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+
+	var body struct {
+		Data struct {
+			Transition TransitionJSON    `json:"transition"`
+			Vehicle    VehicleDetailJSON `json:"vehicle"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	tr, v := body.Data.Transition, body.Data.Vehicle
+	if tr.From != "in_service" || tr.To != "decommissioned" || tr.Reason != "sold" || tr.At.IsZero() {
+		t.Errorf("got transition %+v, want from in_service to decommissioned because sold", tr)
+	}
+	if v.Status != "decommissioned" || len(v.Transitions) != 1 || v.Transitions[0] != tr || len(v.NextStatus) != 0 {
+		t.Errorf("got vehicle %+v, want it decommissioned with the transition as its history and no next status", v)
+	}
+}
+
+func TestVehicleDefault_StatusFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want int
+		ids  []int
+	}{
+		{name: "all in a status", url: "/vehicles?status=reserved", want: http.StatusOK, ids: []int{2}},
+		{name: "all in none of the statuses", url: "/vehicles?status=in_maintenance", want: http.StatusOK, ids: []int{}},
+		{name: "color and year in statuses", url: "/vehicles/color/white/year/2020?status=in_service,decommissioned", want: http.StatusOK, ids: []int{1, 3}},
+		{name: "color and year in none of the statuses", url: "/vehicles/color/white/year/2020?status=in_maintenance", want: http.StatusNotFound},
+		{name: "color and year not found", url: "/vehicles/color/red/year/2020", want: http.StatusNotFound},
+		{name: "dimensions in a status", url: "/vehicles/dimensions?length=5..6&status=reserved&status=in_service", want: http.StatusOK, ids: []int{1, 2}},
+		{name: "dimensions in none of the statuses", url: "/vehicles/dimensions?length=5..6&status=in_maintenance", want: http.StatusNotFound},
+		{name: "unknown status", url: "/vehicles/dimensions?status=parked", want: http.StatusBadRequest},
+	}
+	rt := newStatusRouter(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			rt.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			if rec.Code != tt.want {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.ids == nil {
+				return
+			}
+
+			var body struct {
+				Data map[int]VehicleJSON `json:"data"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if len(body.Data) != len(tt.ids) {
+				t.Fatalf("got vehicles %v, want %v", body.Data, tt.ids)
+			}
+			for _, id := range tt.ids {
+				if _, ok := body.Data[id]; !ok {
+					t.Errorf("got vehicles %v, want %v", body.Data, tt.ids)
+				}
+			}
+		})
+	}
+}
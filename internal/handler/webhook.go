@@ -0,0 +1,302 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bootcamp-go/web/request"
+	"github.com/bootcamp-go/web/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// WebhookJSON is a struct that represents a webhook in JSON format, the secret is only shown once it is registered
+type WebhookJSON struct {
+	ID        int       `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// newWebhookJSON is a function that serializes a webhook to JSON without its secret
+func newWebhookJSON(w internal.Webhook) WebhookJSON {
+	data := WebhookJSON{ID: w.ID, URL: w.URL, Events: make([]string, 0, len(w.Events)), CreatedAt: w.CreatedAt}
+	for _, t := range w.Events {
+		data.Events = append(data.Events, string(t))
+	}
+	return data
+}
+
+// BodyWebhookJSON is a struct that represents the request of a webhook in JSON format
+type BodyWebhookJSON struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
+
+// WebhookDeliveryJSON is a struct that represents an attempt to deliver an event in JSON format
+type WebhookDeliveryJSON struct {
+	ID         int       `json:"id"`
+	EventID    string    `json:"event_id"`
+	EventType  string    `json:"event_type"`
+	Attempt    int       `json:"attempt"`
+	Succeeded  bool      `json:"succeeded"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	At         time.Time `json:"at"`
+}
+
+// WebhookDeadLetterJSON is a struct that represents a dead letter in JSON format
+type WebhookDeadLetterJSON struct {
+	ID        int             `json:"id"`
+	EventID   string          `json:"event_id"`
+	EventType string          `json:"event_type"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"last_error"`
+	FailedAt  time.Time       `json:"failed_at"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// parseDeadLetterIDParam is a function that parses the id of the dead letter in the URL
+func parseDeadLetterIDParam(r *http.Request) (id int, ok bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "deadLetterID"))
+	ok = err == nil && id > 0
+	return
+}
+
+// writeWebhookError is a function that writes the response of an error of the webhook service
+func writeWebhookError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, internal.ErrWebhookNotFound):
+		response.JSON(w, http.StatusNotFound, "webhook not found")
+	case errors.Is(err, internal.ErrDeadLetterNotFound):
+		response.JSON(w, http.StatusNotFound, "dead letter not found")
+	case errors.Is(err, internal.ErrWebhookInvalid):
+		response.JSON(w, http.StatusUnprocessableEntity, err.Error())
+	default:
+		response.JSON(w, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+// NewWebhookDefault is a function that returns a new instance of WebhookDefault
+func NewWebhookDefault(sv internal.WebhookService) *WebhookDefault {
+	return &WebhookDefault{sv: sv}
+}
+
+// WebhookDefault is a struct with methods that represent handlers for webhooks
+type WebhookDefault struct {
+	// sv is the service that will be used by the handler
+	sv internal.WebhookService
+}
+
+// GetAll is a method that returns a handler for the route GET /webhooks
+func (h *WebhookDefault) GetAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// ...
+
+		// process
+		hooks, err := h.sv.FindAll()
+		if err != nil {
+			writeWebhookError(w, err)
+			return
+		}
+
+		// response
+		data := make([]WebhookJSON, 0, len(hooks))
+		for _, value := range hooks {
+			data = append(data, newWebhookJSON(value))
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
+
+// GetByID is a method that returns a handler for the route GET /webhooks/{id}
+func (h *WebhookDefault) GetByID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		hook, err := h.sv.FindByID(id)
+		if err != nil {
+			writeWebhookError(w, err)
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    newWebhookJSON(hook),
+		})
+	}
+}
+
+// Create is a method that returns a handler for the route POST /webhooks.
+// The response holds the secret deliveries are signed with, it is not shown again
+func (h *WebhookDefault) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		var body BodyWebhookJSON
+		if err := request.JSON(r, &body); err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+		hook := internal.Webhook{URL: body.URL, Secret: body.Secret}
+		for _, t := range body.Events {
+			hook.Events = append(hook.Events, internal.EventType(t))
+		}
+
+		// process
+		if err := h.sv.Create(&hook); err != nil {
+			writeWebhookError(w, err)
+			return
+		}
+
+		// response
+		data := newWebhookJSON(hook)
+		data.Secret = hook.Secret
+		response.JSON(w, http.StatusCreated, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
+
+// Delete is a method that returns a handler for the route DELETE /webhooks/{id}
+func (h *WebhookDefault) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		if err := h.sv.Delete(id); err != nil {
+			writeWebhookError(w, err)
+			return
+		}
+
+		// response
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetDeliveries is a method that returns a handler for the route GET /webhooks/{id}/deliveries
+func (h *WebhookDefault) GetDeliveries() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		d, err := h.sv.FindDeliveries(id)
+		if err != nil {
+			writeWebhookError(w, err)
+			return
+		}
+
+		// response
+		data := make([]WebhookDeliveryJSON, 0, len(d))
+		for _, value := range d {
+			data = append(data, WebhookDeliveryJSON{
+				ID:         value.ID,
+				EventID:    value.EventID,
+				EventType:  string(value.EventType),
+				Attempt:    value.Attempt,
+				Succeeded:  value.Succeeded(),
+				StatusCode: value.StatusCode,
+				Error:      value.Error,
+				DurationMs: value.Duration.Milliseconds(),
+				At:         value.At,
+			})
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
+
+// GetDeadLetters is a method that returns a handler for the route GET /webhooks/{id}/dead-letters
+func (h *WebhookDefault) GetDeadLetters() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		dl, err := h.sv.FindDeadLetters(id)
+		if err != nil {
+			writeWebhookError(w, err)
+			return
+		}
+
+		// response
+		data := make([]WebhookDeadLetterJSON, 0, len(dl))
+		for _, value := range dl {
+			data = append(data, WebhookDeadLetterJSON{
+				ID:        value.ID,
+				EventID:   value.EventID,
+				EventType: string(value.EventType),
+				Attempts:  value.Attempts,
+				LastError: value.LastError,
+				FailedAt:  value.FailedAt,
+				Payload:   json.RawMessage(value.Payload),
+			})
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    data,
+		})
+	}
+}
+
+// Redeliver is a method that returns a handler for the route POST /webhooks/{id}/dead-letters/{deadLetterID}/redeliver.
+// The dead letter is removed and its event queued for delivery with every attempt
+func (h *WebhookDefault) Redeliver() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := parseIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		deadLetterID, ok := parseDeadLetterIDParam(r)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid dead letter id")
+			return
+		}
+
+		// process
+		if err := h.sv.Redeliver(id, deadLetterID); err != nil {
+			writeWebhookError(w, err)
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusAccepted, map[string]any{
+			"message": "success",
+		})
+	}
+}
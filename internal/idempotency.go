@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrIdempotencyKeyNotFound is an error that represents an idempotency key not found
+	ErrIdempotencyKeyNotFound = errors.New("idempotency key not found")
+	// ErrIdempotencyKeyInProgress is an error that represents an idempotency key whose first request is still being processed
+	ErrIdempotencyKeyInProgress = errors.New("idempotency key in progress")
+	// ErrIdempotencyKeyExists is an error that represents an idempotency key whose response is already stored
+	ErrIdempotencyKeyExists = errors.New("idempotency key exists")
+)
+
+// IdempotencyRecord is a struct that represents a stored response for an idempotency key
+type IdempotencyRecord struct {
+	// Key is the value of the Idempotency-Key header
+	Key string
+	// Fingerprint is the hash of the request that produced the response
+	Fingerprint string
+	// StatusCode is the status code of the stored response
+	StatusCode int
+	// Header is the header of the stored response
+	Header http.Header
+	// Body is the body of the stored response
+	Body []byte
+	// ExpiresAt is the time after which the record is discarded
+	ExpiresAt time.Time
+}
+
+// IdempotencyStore is an interface that represents a store for idempotency records
+type IdempotencyStore interface {
+	// Reserve is a method that marks a key as in progress until it is saved or released, in a single step with the lookup
+	// of its record: when the key has a record it is returned with ErrIdempotencyKeyExists and the key is not marked
+	Reserve(key string) (r IdempotencyRecord, err error)
+
+	// Release is a method that removes the in progress mark of a key without saving a record
+	Release(key string)
+
+	// Get is a method that returns the record of a key
+	Get(key string) (r IdempotencyRecord, err error)
+
+	// Save is a method that saves the record of a key and removes its in progress mark
+	Save(r IdempotencyRecord) (err error)
+}
@@ -0,0 +1,122 @@
+package loader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// AttachmentSchemaVersion is the version of the attachment files written by Save
+const AttachmentSchemaVersion = 1
+
+// ConfigAttachmentJSONFile is a struct that represents the configuration for AttachmentJSONFile
+type ConfigAttachmentJSONFile struct {
+	// Path is the path to the file that contains the attachments in JSON format
+	Path string
+	// Keyring is the keyring of encrypted files, files are saved encrypted with its current key when set
+	Keyring *Keyring
+}
+
+// NewAttachmentJSONFile is a function that returns a new instance of AttachmentJSONFile
+func NewAttachmentJSONFile(cfg *ConfigAttachmentJSONFile) *AttachmentJSONFile {
+	return &AttachmentJSONFile{path: cfg.Path, keyring: cfg.Keyring}
+}
+
+// AttachmentJSONFile is a struct that implements the AttachmentLoader interface
+type AttachmentJSONFile struct {
+	// path is the path to the file that contains the attachments in JSON format
+	path string
+	// keyring is the keyring of encrypted files, nil saves them in plaintext
+	keyring *Keyring
+}
+
+// AttachmentFileJSON is a struct that represents an attachment file in JSON format
+type AttachmentFileJSON struct {
+	SchemaVersion int              `json:"schema_version"`
+	Attachments   []AttachmentJSON `json:"attachments"`
+}
+
+// AttachmentJSON is a struct that represents an attachment in JSON format
+type AttachmentJSON struct {
+	ID          int       `json:"id"`
+	VehicleID   int       `json:"vehicle_id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Load is a method that loads the attachments, a missing file holds none
+func (l *AttachmentJSONFile) Load() (a map[int]internal.Attachment, err error) {
+	a = make(map[int]internal.Attachment)
+
+	// open file
+	r, _, err := readFile(l.path, l.keyring)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+	defer r.Close()
+
+	// decode file
+	var file AttachmentFileJSON
+	if err = json.NewDecoder(r).Decode(&file); err != nil {
+		err = fmt.Errorf("error decoding attachment file: %w", err)
+		return
+	}
+	if file.SchemaVersion < 1 || file.SchemaVersion > AttachmentSchemaVersion {
+		err = fmt.Errorf("%w: %d, the greatest supported is %d", ErrSchemaVersionUnsupported, file.SchemaVersion, AttachmentSchemaVersion)
+		return
+	}
+
+	// serialize attachments
+	for _, value := range file.Attachments {
+		a[value.ID] = internal.Attachment{
+			ID:          value.ID,
+			VehicleID:   value.VehicleID,
+			Name:        value.Name,
+			ContentType: value.ContentType,
+			Size:        value.Size,
+			SHA256:      value.SHA256,
+			CreatedAt:   value.CreatedAt.UTC(),
+		}
+	}
+	return
+}
+
+// Save is a method that saves the attachments ordered by id, the file is written as described by writeFile
+func (l *AttachmentJSONFile) Save(a map[int]internal.Attachment) (err error) {
+	file := AttachmentFileJSON{
+		SchemaVersion: AttachmentSchemaVersion,
+		Attachments:   make([]AttachmentJSON, 0, len(a)),
+	}
+	for _, value := range a {
+		file.Attachments = append(file.Attachments, AttachmentJSON{
+			ID:          value.ID,
+			VehicleID:   value.VehicleID,
+			Name:        value.Name,
+			ContentType: value.ContentType,
+			Size:        value.Size,
+			SHA256:      value.SHA256,
+			CreatedAt:   value.CreatedAt,
+		})
+	}
+	sort.Slice(file.Attachments, func(i, j int) bool { return file.Attachments[i].ID < file.Attachments[j].ID })
+
+	err = writeFile(l.path, l.keyring, func(w io.Writer) (err error) {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(file)
+		return
+	})
+	return
+}
@@ -0,0 +1,155 @@
+package loader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// ComplianceSchemaVersion is the version of the compliance files written by Save
+const ComplianceSchemaVersion = 1
+
+// ConfigComplianceJSONFile is a struct that represents the configuration for ComplianceJSONFile
+type ConfigComplianceJSONFile struct {
+	// Path is the path to the file that contains the compliance items in JSON format
+	Path string
+	// Keyring is the keyring of encrypted files, files are saved encrypted with its current key when set
+	Keyring *Keyring
+}
+
+// NewComplianceJSONFile is a function that returns a new instance of ComplianceJSONFile
+func NewComplianceJSONFile(cfg *ConfigComplianceJSONFile) *ComplianceJSONFile {
+	return &ComplianceJSONFile{path: cfg.Path, keyring: cfg.Keyring}
+}
+
+// ComplianceJSONFile is a struct that implements the ComplianceLoader interface
+type ComplianceJSONFile struct {
+	// path is the path to the file that contains the compliance items in JSON format
+	path string
+	// keyring is the keyring of encrypted files, nil saves them in plaintext
+	keyring *Keyring
+}
+
+// ComplianceFileJSON is a struct that represents a compliance file in JSON format
+type ComplianceFileJSON struct {
+	SchemaVersion int                  `json:"schema_version"`
+	Items         []ComplianceItemJSON `json:"items"`
+	Sent          []ComplianceSentJSON `json:"sent"`
+}
+
+// ComplianceItemJSON is a struct that represents a compliance item in JSON format
+type ComplianceItemJSON struct {
+	ID        int    `json:"id"`
+	VehicleID int    `json:"vehicle_id"`
+	Kind      string `json:"kind"`
+	Reference string `json:"reference,omitempty"`
+	Provider  string `json:"provider,omitempty"`
+	Expires   string `json:"expires"`
+	Notes     string `json:"notes,omitempty"`
+}
+
+// ComplianceSentJSON is a struct that represents a reminder sent in JSON format
+type ComplianceSentJSON struct {
+	ItemID  int       `json:"item_id"`
+	Expires string    `json:"expires"`
+	Window  int       `json:"window"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// Load is a method that loads the compliance items and reminders sent, a missing file holds none
+func (l *ComplianceJSONFile) Load() (d internal.ComplianceData, err error) {
+	d = internal.ComplianceData{Items: make(map[int]internal.ComplianceItem), Sent: make([]internal.ComplianceSent, 0)}
+
+	// open file
+	r, _, err := readFile(l.path, l.keyring)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+	defer r.Close()
+
+	// decode file
+	var file ComplianceFileJSON
+	if err = json.NewDecoder(r).Decode(&file); err != nil {
+		err = fmt.Errorf("error decoding compliance file: %w", err)
+		return
+	}
+	if file.SchemaVersion < 1 || file.SchemaVersion > ComplianceSchemaVersion {
+		err = fmt.Errorf("%w: %d, the greatest supported is %d", ErrSchemaVersionUnsupported, file.SchemaVersion, ComplianceSchemaVersion)
+		return
+	}
+
+	// serialize items and reminders sent
+	for i, value := range file.Items {
+		item := internal.ComplianceItem{
+			ID:        value.ID,
+			VehicleID: value.VehicleID,
+			Kind:      internal.ComplianceKind(value.Kind),
+			Reference: value.Reference,
+			Provider:  value.Provider,
+			Notes:     value.Notes,
+		}
+		if item.Expires, err = time.Parse(dateLayout, value.Expires); err == nil {
+			err = item.Validate()
+		}
+		if err != nil {
+			err = fmt.Errorf("error loading compliance item %d: %w", i, err)
+			return
+		}
+		d.Items[item.ID] = item
+	}
+	for i, value := range file.Sent {
+		s := internal.ComplianceSent{ItemID: value.ItemID, Window: value.Window, SentAt: value.SentAt.UTC()}
+		if s.Expires, err = time.Parse(dateLayout, value.Expires); err != nil {
+			err = fmt.Errorf("error loading reminder sent %d: %w", i, err)
+			return
+		}
+		d.Sent = append(d.Sent, s)
+	}
+	return
+}
+
+// Save is a method that saves the compliance items ordered by id and the reminders sent, the file is written as described by writeFile
+func (l *ComplianceJSONFile) Save(d internal.ComplianceData) (err error) {
+	file := ComplianceFileJSON{
+		SchemaVersion: ComplianceSchemaVersion,
+		Items:         make([]ComplianceItemJSON, 0, len(d.Items)),
+		Sent:          make([]ComplianceSentJSON, 0, len(d.Sent)),
+	}
+	for _, value := range d.Items {
+		file.Items = append(file.Items, ComplianceItemJSON{
+			ID:        value.ID,
+			VehicleID: value.VehicleID,
+			Kind:      string(value.Kind),
+			Reference: value.Reference,
+			Provider:  value.Provider,
+			Expires:   value.Expires.Format(dateLayout),
+			Notes:     value.Notes,
+		})
+	}
+	sort.Slice(file.Items, func(i, j int) bool { return file.Items[i].ID < file.Items[j].ID })
+	for _, value := range d.Sent {
+		file.Sent = append(file.Sent, ComplianceSentJSON{
+			ItemID:  value.ItemID,
+			Expires: value.Expires.Format(dateLayout),
+			Window:  value.Window,
+			SentAt:  value.SentAt,
+		})
+	}
+
+	err = writeFile(l.path, l.keyring, func(w io.Writer) (err error) {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(file)
+		return
+	})
+	return
+}
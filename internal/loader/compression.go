@@ -0,0 +1,80 @@
+package loader
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrZstdUnsupported is an error that represents a zstd-compressed file. Only gzip is supported: zstd has no implementation
+	// in the standard library and the module takes no dependency for it, so such files must be recompressed with gzip
+	ErrZstdUnsupported = errors.New("zstd-compressed files are not supported, recompress the file with gzip")
+)
+
+var (
+	// gzipMagic is the header every gzip stream starts with
+	gzipMagic = []byte{0x1f, 0x8b}
+	// zstdMagic is the header every zstd frame starts with
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// decompress is a function that returns a reader of the decompressed contents when r is gzip-compressed,
+// detected by its magic bytes whatever the file extension, or of the plain contents otherwise.
+// zstd-compressed contents fail with ErrZstdUnsupported rather than as invalid JSON
+func decompress(r io.Reader) (dr io.ReadCloser, err error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return
+	}
+	err = nil
+	if bytes.HasPrefix(magic, zstdMagic) {
+		err = ErrZstdUnsupported
+		return
+	}
+	if bytes.HasPrefix(magic, gzipMagic) {
+		dr, err = gzip.NewReader(br)
+		return
+	}
+	dr = io.NopCloser(br)
+	return
+}
+
+// compress is a function that returns a writer compressing to w when the path has the .gz extension,
+// closing it flushes the compressed stream but does not close w
+func compress(path string, w io.Writer) io.WriteCloser {
+	if strings.HasSuffix(path, ".gz") {
+		gw, _ := gzip.NewWriterLevel(w, gzip.BestCompression)
+		return gw
+	}
+	return nopWriteCloser{w}
+}
+
+// nopWriteCloser is a struct that adds a Close method doing nothing to a writer
+type nopWriteCloser struct {
+	io.Writer
+}
+
+// Close is a method that does nothing
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// countingReader is a struct that counts the bytes read through it
+type countingReader struct {
+	// r is the reader
+	r io.Reader
+	// n is the number of bytes read
+	n int64
+}
+
+// Read is a method that reads from the reader and counts the bytes
+func (c *countingReader) Read(p []byte) (n int, err error) {
+	n, err = c.r.Read(p)
+	c.n += int64(n)
+	return
+}
@@ -0,0 +1,29 @@
+package loader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"testing"
+)
+
+func TestVehicleJSONFile_Load_Compression(t *testing.T) {
+	data := "[" + testRecord("1") + "]"
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write([]byte(data))
+	gw.Close()
+
+	t.Run("gzip", func(t *testing.T) {
+		v, err := NewVehicleJSONFile(writeTestFile(t, gz.String())).Load()
+		if err != nil || len(v) != 1 {
+			t.Fatalf("load: got %d vehicles, %v", len(v), err)
+		}
+	})
+	t.Run("zstd", func(t *testing.T) {
+		_, err := NewVehicleJSONFile(writeTestFile(t, "\x28\xb5\x2f\xfd"+data)).Load()
+		if !errors.Is(err, ErrZstdUnsupported) {
+			t.Fatalf("load: got %v, want %v", err, ErrZstdUnsupported)
+		}
+	})
+}
@@ -0,0 +1,196 @@
+package loader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// DriverSchemaVersion is the version of the driver files written by Save
+const DriverSchemaVersion = 1
+
+// ConfigDriverJSONFile is a struct that represents the configuration for DriverJSONFile
+type ConfigDriverJSONFile struct {
+	// Path is the path to the file that contains the drivers and assignments in JSON format
+	Path string
+	// Keyring is the keyring of encrypted files, files are saved encrypted with its current key when set
+	Keyring *Keyring
+}
+
+// NewDriverJSONFile is a function that returns a new instance of DriverJSONFile
+func NewDriverJSONFile(cfg *ConfigDriverJSONFile) *DriverJSONFile {
+	return &DriverJSONFile{path: cfg.Path, keyring: cfg.Keyring}
+}
+
+// DriverJSONFile is a struct that implements the DriverLoader interface
+type DriverJSONFile struct {
+	// path is the path to the file that contains the drivers and assignments in JSON format
+	path string
+	// keyring is the keyring of encrypted files, nil saves them in plaintext
+	keyring *Keyring
+}
+
+// DriverFileJSON is a struct that represents a driver file in JSON format
+type DriverFileJSON struct {
+	SchemaVersion int              `json:"schema_version"`
+	Drivers       []DriverJSON     `json:"drivers"`
+	Assignments   []AssignmentJSON `json:"assignments"`
+}
+
+// DriverJSON is a struct that represents a driver in JSON format
+type DriverJSON struct {
+	ID            int           `json:"id"`
+	Name          string        `json:"name"`
+	LicenceNumber string        `json:"licence_number"`
+	Licences      []LicenceJSON `json:"licences"`
+}
+
+// LicenceJSON is a struct that represents a licence category of a driver in JSON format
+type LicenceJSON struct {
+	Category string `json:"category"`
+	Expires  string `json:"expires"`
+}
+
+// AssignmentJSON is a struct that represents an assignment in JSON format
+type AssignmentJSON struct {
+	ID        int        `json:"id"`
+	DriverID  int        `json:"driver_id"`
+	VehicleID int        `json:"vehicle_id"`
+	From      time.Time  `json:"from"`
+	To        *time.Time `json:"to,omitempty"`
+}
+
+// Load is a method that loads the drivers and assignments, a missing file holds none
+func (l *DriverJSONFile) Load() (d internal.DriverData, err error) {
+	d = internal.DriverData{Drivers: make(map[int]internal.Driver), Assignments: make(map[int]internal.Assignment)}
+
+	// open file
+	r, _, err := readFile(l.path, l.keyring)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+	defer r.Close()
+
+	// decode file
+	var file DriverFileJSON
+	if err = json.NewDecoder(r).Decode(&file); err != nil {
+		err = fmt.Errorf("error decoding driver file: %w", err)
+		return
+	}
+	if file.SchemaVersion < 1 || file.SchemaVersion > DriverSchemaVersion {
+		err = fmt.Errorf("%w: %d, the greatest supported is %d", ErrSchemaVersionUnsupported, file.SchemaVersion, DriverSchemaVersion)
+		return
+	}
+
+	// serialize drivers and assignments
+	for i, value := range file.Drivers {
+		var dr internal.Driver
+		if dr, err = value.Driver(); err == nil {
+			err = dr.Validate()
+		}
+		if err != nil {
+			err = fmt.Errorf("error loading driver %d: %w", i, err)
+			return
+		}
+		d.Drivers[dr.ID] = dr
+	}
+	for _, value := range file.Assignments {
+		a := value.Assignment()
+		d.Assignments[a.ID] = a
+	}
+	return
+}
+
+// Save is a method that saves the drivers and assignments ordered by id, the file is written as described by writeFile
+func (l *DriverJSONFile) Save(d internal.DriverData) (err error) {
+	file := DriverFileJSON{
+		SchemaVersion: DriverSchemaVersion,
+		Drivers:       make([]DriverJSON, 0, len(d.Drivers)),
+		Assignments:   make([]AssignmentJSON, 0, len(d.Assignments)),
+	}
+	for _, value := range d.Drivers {
+		file.Drivers = append(file.Drivers, newDriverJSON(value))
+	}
+	sort.Slice(file.Drivers, func(i, j int) bool { return file.Drivers[i].ID < file.Drivers[j].ID })
+	for _, value := range d.Assignments {
+		file.Assignments = append(file.Assignments, newAssignmentJSON(value))
+	}
+	sort.Slice(file.Assignments, func(i, j int) bool { return file.Assignments[i].ID < file.Assignments[j].ID })
+
+	err = writeFile(l.path, l.keyring, func(w io.Writer) (err error) {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(file)
+		return
+	})
+	return
+}
+
+// newDriverJSON is a function that serializes a driver to JSON
+func newDriverJSON(d internal.Driver) DriverJSON {
+	data := DriverJSON{
+		ID:            d.ID,
+		Name:          d.Name,
+		LicenceNumber: d.LicenceNumber,
+		Licences:      make([]LicenceJSON, 0, len(d.Licences)),
+	}
+	for _, l := range d.Licences {
+		data.Licences = append(data.Licences, LicenceJSON{Category: string(l.Category), Expires: l.Expires.Format(dateLayout)})
+	}
+	return data
+}
+
+// Driver is a method that deserializes a driver from JSON
+func (d DriverJSON) Driver() (dr internal.Driver, err error) {
+	dr = internal.Driver{
+		ID:            d.ID,
+		Name:          d.Name,
+		LicenceNumber: d.LicenceNumber,
+		Licences:      make([]internal.Licence, 0, len(d.Licences)),
+	}
+	for _, l := range d.Licences {
+		var expires time.Time
+		if expires, err = time.Parse(dateLayout, l.Expires); err != nil {
+			return
+		}
+		dr.Licences = append(dr.Licences, internal.Licence{Category: internal.LicenceCategory(l.Category), Expires: expires})
+	}
+	return
+}
+
+// newAssignmentJSON is a function that serializes an assignment to JSON
+func newAssignmentJSON(a internal.Assignment) AssignmentJSON {
+	data := AssignmentJSON{
+		ID:        a.ID,
+		DriverID:  a.DriverID,
+		VehicleID: a.VehicleID,
+		From:      a.From,
+	}
+	if !a.Current() {
+		data.To = &a.To
+	}
+	return data
+}
+
+// Assignment is a method that deserializes an assignment from JSON
+func (a AssignmentJSON) Assignment() internal.Assignment {
+	as := internal.Assignment{
+		ID:        a.ID,
+		DriverID:  a.DriverID,
+		VehicleID: a.VehicleID,
+		From:      a.From.UTC(),
+	}
+	if a.To != nil {
+		as.To = a.To.UTC()
+	}
+	return as
+}
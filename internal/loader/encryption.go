@@ -0,0 +1,422 @@
+package loader
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var (
+	// ErrEncryptionIntegrity is an error that represents an encrypted file that was tampered with, truncated or encrypted with another key
+	ErrEncryptionIntegrity = errors.New("encrypted file failed the integrity check")
+	// ErrEncryptionKeyUnknown is an error that represents an encrypted file whose key is not in the keyring
+	ErrEncryptionKeyUnknown = errors.New("encryption key unknown")
+	// ErrEncryptionKeyRequired is an error that represents an encrypted file loaded without a keyring
+	ErrEncryptionKeyRequired = errors.New("file is encrypted and no encryption key was given")
+	// ErrEncryptionRequired is an error that represents a plaintext file loaded with a keyring, which could have been swapped
+	// for the encrypted one to feed forged data
+	ErrEncryptionRequired = errors.New("file is not encrypted and an encryption key was given")
+	// ErrKeyringInvalid is an error that represents a malformed keyring
+	ErrKeyringInvalid = errors.New("keyring invalid")
+)
+
+// encryptionMagic is the header every encrypted file starts with
+var encryptionMagic = []byte("VEHENC1\n")
+
+const (
+	// encryptionChunkSize is the size of the plaintext sealed at a time, so files are decrypted without reading them whole
+	encryptionChunkSize = 64 << 10
+	// dataKeySize is the size of the key that encrypts the contents of a file, AES-256
+	dataKeySize = 32
+	// maxHeaderSize is the greatest size accepted for the header of an encrypted file
+	maxHeaderSize = 4 << 10
+)
+
+// Keyring is a struct that holds the master keys that wrap the data keys of encrypted files, by id.
+// The first key encrypts new files, every key decrypts, so keys are rotated by adding the new one first
+// and removing the old one once every file has been saved again
+type Keyring struct {
+	// ids is the list of key ids, the first one is the current key
+	ids []string
+	// keys is the set of keys by id
+	keys map[string][]byte
+}
+
+// ParseKeyring is a function that parses a keyring made of id:key entries separated by commas or newlines,
+// each key encoded in base64 and 16, 24 or 32 bytes long. Blank lines and lines starting with # are ignored
+func ParseKeyring(s string) (kr *Keyring, err error) {
+	kr = &Keyring{keys: make(map[string][]byte)}
+	entries := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' })
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		id = strings.TrimSpace(id)
+		if !ok || id == "" {
+			err = fmt.Errorf("%w: entry %q is not id:key", ErrKeyringInvalid, entry)
+			return
+		}
+		if _, ok := kr.keys[id]; ok {
+			err = fmt.Errorf("%w: key %q given twice", ErrKeyringInvalid, id)
+			return
+		}
+		key, decErr := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if decErr != nil {
+			err = fmt.Errorf("%w: key %q is not base64: %v", ErrKeyringInvalid, id, decErr)
+			return
+		}
+		if n := len(key); n != 16 && n != 24 && n != 32 {
+			err = fmt.Errorf("%w: key %q is %d bytes long, expected 16, 24 or 32", ErrKeyringInvalid, id, n)
+			return
+		}
+		kr.ids = append(kr.ids, id)
+		kr.keys[id] = key
+	}
+	if len(kr.ids) == 0 {
+		err = fmt.Errorf("%w: no keys", ErrKeyringInvalid)
+	}
+	return
+}
+
+// ReadKeyringFile is a function that reads a keyring from a file, which must not be accessible by other users
+func ReadKeyringFile(path string) (kr *Keyring, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		err = fmt.Errorf("%w: %s is accessible by other users (mode %v), expected 0600", ErrKeyringInvalid, path, info.Mode().Perm())
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	kr, err = ParseKeyring(string(data))
+	return
+}
+
+// LoadKeyring is a function that returns the keyring of a keyfile, or the one given as a value when there is no keyfile.
+// It returns nil when neither is given, which disables encryption
+func LoadKeyring(path, value string) (kr *Keyring, err error) {
+	switch {
+	case path != "":
+		kr, err = ReadKeyringFile(path)
+	case value != "":
+		kr, err = ParseKeyring(value)
+	}
+	return
+}
+
+// GenerateKey is a function that returns a new random AES-256 key encoded in base64
+func GenerateKey() (key string, err error) {
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return
+	}
+	key = base64.StdEncoding.EncodeToString(b)
+	return
+}
+
+// Current is a method that returns the id of the key that encrypts new files
+func (k *Keyring) Current() string {
+	return k.ids[0]
+}
+
+// encryptionHeader is a struct that represents the header of an encrypted file
+type encryptionHeader struct {
+	// KeyID is the id of the master key that wraps the data key
+	KeyID string `json:"kid"`
+	// Algorithm is the cipher of the contents
+	Algorithm string `json:"alg"`
+	// WrappedKey is the data key sealed with the master key
+	WrappedKey []byte `json:"wrapped_key"`
+	// WrapNonce is the nonce of the wrapped key
+	WrapNonce []byte `json:"wrap_nonce"`
+	// NoncePrefix is the prefix of the nonces of the chunks, followed by their index
+	NoncePrefix []byte `json:"nonce_prefix"`
+	// ChunkSize is the size of the plaintext of each chunk
+	ChunkSize int `json:"chunk_size"`
+}
+
+// newGCM is a function that returns an AES-GCM cipher
+func newGCM(key []byte) (aead cipher.AEAD, err error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return
+	}
+	aead, err = cipher.NewGCM(block)
+	return
+}
+
+// encrypt is a function that returns a writer encrypting to w with a new data key wrapped by the current key of the keyring.
+// The contents are sealed in chunks bound to the header, their index and whether they are the last one,
+// so chunks can not be altered, reordered or dropped. Closing it seals the last chunk but does not close w
+func encrypt(w io.Writer, kr *Keyring) (ew io.WriteCloser, err error) {
+	kid := kr.Current()
+	kek, err := newGCM(kr.keys[kid])
+	if err != nil {
+		return
+	}
+
+	// data key
+	dek := make([]byte, dataKeySize)
+	hd := encryptionHeader{KeyID: kid, Algorithm: "AES-256-GCM", WrapNonce: make([]byte, kek.NonceSize()), NoncePrefix: make([]byte, 8), ChunkSize: encryptionChunkSize}
+	for _, b := range [][]byte{dek, hd.WrapNonce, hd.NoncePrefix} {
+		if _, err = rand.Read(b); err != nil {
+			return
+		}
+	}
+	hd.WrappedKey = kek.Seal(nil, hd.WrapNonce, dek, []byte(kid))
+	aead, err := newGCM(dek)
+	if err != nil {
+		return
+	}
+
+	// header
+	raw, err := json.Marshal(hd)
+	if err != nil {
+		return
+	}
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(raw)))
+	for _, b := range [][]byte{encryptionMagic, size[:], raw} {
+		if _, err = w.Write(b); err != nil {
+			return
+		}
+	}
+
+	ew = &encryptWriter{w: w, aead: aead, header: raw, prefix: hd.NoncePrefix}
+	return
+}
+
+// encryptWriter is a struct that seals what is written to it in chunks
+type encryptWriter struct {
+	// w is the writer of the sealed chunks
+	w io.Writer
+	// aead is the cipher of the data key
+	aead cipher.AEAD
+	// header is the header of the file, authenticated with every chunk
+	header []byte
+	// prefix is the prefix of the nonces
+	prefix []byte
+	// index is the index of the next chunk
+	index uint32
+	// buf is the plaintext not sealed yet
+	buf []byte
+}
+
+// Write is a method that seals every full chunk except the last one, which is only known to be the last on Close
+func (e *encryptWriter) Write(p []byte) (n int, err error) {
+	e.buf = append(e.buf, p...)
+	for len(e.buf) > encryptionChunkSize {
+		if err = e.seal(e.buf[:encryptionChunkSize], false); err != nil {
+			return
+		}
+		e.buf = e.buf[encryptionChunkSize:]
+	}
+	n = len(p)
+	return
+}
+
+// Close is a method that seals the last chunk
+func (e *encryptWriter) Close() (err error) {
+	err = e.seal(e.buf, true)
+	e.buf = nil
+	return
+}
+
+// seal is a method that writes a chunk prefixed by its size
+func (e *encryptWriter) seal(plaintext []byte, last bool) (err error) {
+	ct := e.aead.Seal(nil, chunkNonce(e.prefix, e.index), plaintext, chunkAAD(e.header, last))
+	e.index++
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(ct)))
+	if _, err = e.w.Write(size[:]); err != nil {
+		return
+	}
+	_, err = e.w.Write(ct)
+	return
+}
+
+// chunkNonce is a function that returns the nonce of a chunk
+func chunkNonce(prefix []byte, index uint32) []byte {
+	nonce := make([]byte, len(prefix)+4)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[len(prefix):], index)
+	return nonce
+}
+
+// chunkAAD is a function that returns the additional data of a chunk
+func chunkAAD(header []byte, last bool) []byte {
+	aad := append([]byte(nil), header...)
+	if last {
+		return append(aad, 1)
+	}
+	return append(aad, 0)
+}
+
+// decrypt is a function that returns a reader of the decrypted contents when r is encrypted, detected by its magic bytes,
+// and the id of the key it was encrypted with. Plain contents are returned unchanged with an empty key id when kr is nil,
+// and fail with ErrEncryptionRequired otherwise, so that encryption can not be downgraded by replacing the file
+func decrypt(r io.Reader, kr *Keyring) (dr io.Reader, keyID string, err error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(encryptionMagic))
+	if err != nil && err != io.EOF {
+		return
+	}
+	err = nil
+	if !bytes.Equal(magic, encryptionMagic) {
+		if kr != nil {
+			err = ErrEncryptionRequired
+			return
+		}
+		dr = br
+		return
+	}
+	if kr == nil {
+		err = ErrEncryptionKeyRequired
+		return
+	}
+	br.Discard(len(encryptionMagic))
+
+	// header
+	var size [4]byte
+	if _, err = io.ReadFull(br, size[:]); err != nil {
+		err = fmt.Errorf("%w: truncated header", ErrEncryptionIntegrity)
+		return
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxHeaderSize {
+		err = fmt.Errorf("%w: header of %d bytes", ErrEncryptionIntegrity, n)
+		return
+	}
+	raw := make([]byte, n)
+	if _, err = io.ReadFull(br, raw); err != nil {
+		err = fmt.Errorf("%w: truncated header", ErrEncryptionIntegrity)
+		return
+	}
+	var hd encryptionHeader
+	if err = json.Unmarshal(raw, &hd); err != nil {
+		err = fmt.Errorf("%w: malformed header: %v", ErrEncryptionIntegrity, err)
+		return
+	}
+	if hd.ChunkSize <= 0 || hd.ChunkSize > 16*encryptionChunkSize {
+		err = fmt.Errorf("%w: chunk size %d", ErrEncryptionIntegrity, hd.ChunkSize)
+		return
+	}
+
+	// data key
+	key, ok := kr.keys[hd.KeyID]
+	if !ok {
+		err = fmt.Errorf("%w: %q", ErrEncryptionKeyUnknown, hd.KeyID)
+		return
+	}
+	kek, err := newGCM(key)
+	if err != nil {
+		return
+	}
+	if len(hd.WrapNonce) != kek.NonceSize() {
+		err = fmt.Errorf("%w: malformed header", ErrEncryptionIntegrity)
+		return
+	}
+	dek, err := kek.Open(nil, hd.WrapNonce, hd.WrappedKey, []byte(hd.KeyID))
+	if err != nil {
+		err = fmt.Errorf("%w: the data key can not be unwrapped with key %q", ErrEncryptionIntegrity, hd.KeyID)
+		return
+	}
+	aead, err := newGCM(dek)
+	if err != nil {
+		return
+	}
+	if len(hd.NoncePrefix)+4 != aead.NonceSize() {
+		err = fmt.Errorf("%w: malformed header", ErrEncryptionIntegrity)
+		return
+	}
+
+	dr, keyID = &decryptReader{r: br, aead: aead, header: raw, prefix: hd.NoncePrefix, maxChunk: hd.ChunkSize + aead.Overhead()}, hd.KeyID
+	return
+}
+
+// decryptReader is a struct that opens the chunks of an encrypted file as they are read
+type decryptReader struct {
+	// r is the reader of the sealed chunks
+	r io.Reader
+	// aead is the cipher of the data key
+	aead cipher.AEAD
+	// header is the header of the file
+	header []byte
+	// prefix is the prefix of the nonces
+	prefix []byte
+	// maxChunk is the greatest size of a sealed chunk
+	maxChunk int
+	// index is the index of the next chunk
+	index uint32
+	// buf is the plaintext not read yet
+	buf []byte
+	// done reports whether the last chunk was opened
+	done bool
+}
+
+// Read is a method that reads the plaintext, opening the next chunk when the current one is consumed
+func (d *decryptReader) Read(p []byte) (n int, err error) {
+	for len(d.buf) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err = d.open(); err != nil {
+			return
+		}
+	}
+	n = copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return
+}
+
+// open is a method that reads and opens the next chunk, a file must end right after its last chunk
+func (d *decryptReader) open() (err error) {
+	var size [4]byte
+	if _, err = io.ReadFull(d.r, size[:]); err != nil {
+		err = fmt.Errorf("%w: truncated after chunk %d", ErrEncryptionIntegrity, d.index)
+		return
+	}
+	n := int(binary.BigEndian.Uint32(size[:]))
+	if n < d.aead.Overhead() || n > d.maxChunk {
+		err = fmt.Errorf("%w: chunk %d of %d bytes", ErrEncryptionIntegrity, d.index, n)
+		return
+	}
+	ct := make([]byte, n)
+	if _, err = io.ReadFull(d.r, ct); err != nil {
+		err = fmt.Errorf("%w: truncated chunk %d", ErrEncryptionIntegrity, d.index)
+		return
+	}
+
+	// - a chunk only opens as the last one if it was sealed as the last one
+	nonce := chunkNonce(d.prefix, d.index)
+	if d.buf, err = d.aead.Open(nil, nonce, ct, chunkAAD(d.header, false)); err != nil {
+		if d.buf, err = d.aead.Open(nil, nonce, ct, chunkAAD(d.header, true)); err != nil {
+			err = fmt.Errorf("%w: chunk %d", ErrEncryptionIntegrity, d.index)
+			return
+		}
+		d.done = true
+		var extra [1]byte
+		if m, _ := d.r.Read(extra[:]); m > 0 {
+			err = fmt.Errorf("%w: data after the last chunk", ErrEncryptionIntegrity)
+			return
+		}
+	}
+	d.index++
+	return
+}
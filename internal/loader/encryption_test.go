@@ -0,0 +1,90 @@
+package loader
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// testKeyring is a function that returns a keyring with a new key
+func testKeyring(t *testing.T) *Keyring {
+	t.Helper()
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kr, err := ParseKeyring("k1:" + key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kr
+}
+
+func TestVehicleJSONFile_Load_Encryption(t *testing.T) {
+	kr := testKeyring(t)
+
+	t.Run("plaintext refused with a keyring", func(t *testing.T) {
+		path := writeTestFile(t, "["+testRecord("1")+"]")
+		_, err := NewVehicleJSONFileWithConfig(&ConfigVehicleJSONFile{Path: path, Keyring: kr}).Load()
+		if !errors.Is(err, ErrEncryptionRequired) {
+			t.Fatalf("load: got %v, want %v", err, ErrEncryptionRequired)
+		}
+	})
+
+	t.Run("encrypted round trip", func(t *testing.T) {
+		path := writeTestFile(t, "["+testRecord("1")+"]")
+		db, err := NewVehicleJSONFile(path).Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ld := NewVehicleJSONFileWithConfig(&ConfigVehicleJSONFile{Path: path, Keyring: kr})
+		if err = ld.Save(db); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := os.ReadFile(path)
+		if bytes.Contains(b, []byte("Transit")) {
+			t.Fatal("saved file holds plaintext")
+		}
+		if _, err = ld.Load(); err != nil || ld.Report().KeyID != "k1" {
+			t.Fatalf("load: got key %q, %v", ld.Report().KeyID, err)
+		}
+		if _, err = NewVehicleJSONFile(path).Load(); !errors.Is(err, ErrEncryptionKeyRequired) {
+			t.Fatalf("load without keyring: got %v, want %v", err, ErrEncryptionKeyRequired)
+		}
+	})
+
+	t.Run("rejects encrypted", func(t *testing.T) {
+		// - an encrypted file holding an invalid record
+		path := writeTestFile(t, "")
+		err := writeFile(path, kr, func(w io.Writer) (err error) {
+			_, err = io.WriteString(w, "["+testRecord("1")+`,{"id":2,"brand":"Secret","registration":"AB-123"}]`)
+			return
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ld := NewVehicleJSONFileWithConfig(&ConfigVehicleJSONFile{Path: path, Keyring: kr, OnInvalid: InvalidQuarantine})
+		if db, err := ld.Load(); err != nil || len(db) != 1 {
+			t.Fatalf("load: got %d vehicles, %v", len(db), err)
+		}
+		b, err := os.ReadFile(path + ".rejects.ndjson")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(b, []byte("AB-123")) {
+			t.Fatal("rejects file holds plaintext")
+		}
+		r, _, err := readFile(path+".rejects.ndjson", kr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		plain, err := io.ReadAll(r)
+		if err != nil || !strings.Contains(string(plain), "AB-123") {
+			t.Fatalf("decrypted rejects: got %q, %v", plain, err)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package loader
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// writeFile is a function that writes a data file through a temporary file renamed over the previous one,
+// so readers never see a partial file. The contents are gzip-compressed when the path ends in .gz, then encrypted
+// with the current key of the keyring when it is not nil. The file is only readable and writable by its owner
+func writeFile(path string, kr *Keyring, write func(w io.Writer) (err error)) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if err = f.Chmod(0600); err != nil {
+		return
+	}
+
+	bw := bufio.NewWriter(f)
+	var ew io.WriteCloser = nopWriteCloser{bw}
+	if kr != nil {
+		if ew, err = encrypt(bw, kr); err != nil {
+			return
+		}
+	}
+	cw := compress(path, ew)
+	if err = write(cw); err != nil {
+		return
+	}
+	if err = cw.Close(); err != nil {
+		return
+	}
+	if err = ew.Close(); err != nil {
+		return
+	}
+	if err = bw.Flush(); err != nil {
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+	err = os.Rename(f.Name(), path)
+	return
+}
+
+// readFile is a function that opens a data file written by writeFile, decrypting and decompressing it transparently.
+// It also returns the id of the key the file was encrypted with, empty when it was not encrypted
+func readFile(path string, kr *Keyring) (r io.ReadCloser, keyID string, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	pr, keyID, err := decrypt(f, kr)
+	if err != nil {
+		f.Close()
+		return
+	}
+	dr, err := decompress(pr)
+	if err != nil {
+		f.Close()
+		return
+	}
+	r = &fileReader{ReadCloser: dr, f: f}
+	return
+}
+
+// fileReader is a struct that closes the file under a decompressed reader
+type fileReader struct {
+	io.ReadCloser
+	// f is the file
+	f *os.File
+}
+
+// Close is a method that closes the reader and the file
+func (r *fileReader) Close() (err error) {
+	err = r.ReadCloser.Close()
+	if fErr := r.f.Close(); err == nil {
+		err = fErr
+	}
+	return
+}
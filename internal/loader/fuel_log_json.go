@@ -0,0 +1,140 @@
+package loader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// FuelLogSchemaVersion is the version of the fuel log files written by Save
+const FuelLogSchemaVersion = 1
+
+// ConfigFuelLogJSONFile is a struct that represents the configuration for FuelLogJSONFile
+type ConfigFuelLogJSONFile struct {
+	// Path is the path to the file that contains the fuel log entries in JSON format
+	Path string
+	// Keyring is the keyring of encrypted files, files are saved encrypted with its current key when set
+	Keyring *Keyring
+}
+
+// NewFuelLogJSONFile is a function that returns a new instance of FuelLogJSONFile
+func NewFuelLogJSONFile(cfg *ConfigFuelLogJSONFile) *FuelLogJSONFile {
+	return &FuelLogJSONFile{path: cfg.Path, keyring: cfg.Keyring}
+}
+
+// FuelLogJSONFile is a struct that implements the FuelLogLoader interface
+type FuelLogJSONFile struct {
+	// path is the path to the file that contains the fuel log entries in JSON format
+	path string
+	// keyring is the keyring of encrypted files, nil saves them in plaintext
+	keyring *Keyring
+}
+
+// FuelLogFileJSON is a struct that represents a fuel log file in JSON format
+type FuelLogFileJSON struct {
+	SchemaVersion int                `json:"schema_version"`
+	Entries       []FuelLogEntryJSON `json:"entries"`
+}
+
+// FuelLogEntryJSON is a struct that represents a fuel log entry in JSON format
+type FuelLogEntryJSON struct {
+	ID        int       `json:"id"`
+	VehicleID int       `json:"vehicle_id"`
+	Date      time.Time `json:"date"`
+	Odometer  int       `json:"odometer"`
+	Quantity  float64   `json:"quantity,omitempty"`
+	Unit      string    `json:"unit"`
+	Cost      float64   `json:"cost,omitempty"`
+	Station   string    `json:"station,omitempty"`
+}
+
+// Load is a method that loads the fuel log entries, a missing file holds none
+func (l *FuelLogJSONFile) Load() (e map[int]internal.FuelLogEntry, err error) {
+	e = make(map[int]internal.FuelLogEntry)
+
+	// open file
+	r, _, err := readFile(l.path, l.keyring)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+	defer r.Close()
+
+	// decode file
+	var file FuelLogFileJSON
+	if err = json.NewDecoder(r).Decode(&file); err != nil {
+		err = fmt.Errorf("error decoding fuel log file: %w", err)
+		return
+	}
+	if file.SchemaVersion < 1 || file.SchemaVersion > FuelLogSchemaVersion {
+		err = fmt.Errorf("%w: %d, the greatest supported is %d", ErrSchemaVersionUnsupported, file.SchemaVersion, FuelLogSchemaVersion)
+		return
+	}
+
+	// serialize entries
+	for i, value := range file.Entries {
+		entry := value.Entry()
+		if err = entry.Validate(); err != nil {
+			err = fmt.Errorf("error loading entry %d: %w", i, err)
+			return
+		}
+		e[entry.ID] = entry
+	}
+	return
+}
+
+// Save is a method that saves the fuel log entries ordered by id, the file is written as described by writeFile
+func (l *FuelLogJSONFile) Save(e map[int]internal.FuelLogEntry) (err error) {
+	file := FuelLogFileJSON{
+		SchemaVersion: FuelLogSchemaVersion,
+		Entries:       make([]FuelLogEntryJSON, 0, len(e)),
+	}
+	for _, value := range e {
+		file.Entries = append(file.Entries, newFuelLogEntryJSON(value))
+	}
+	sort.Slice(file.Entries, func(i, j int) bool { return file.Entries[i].ID < file.Entries[j].ID })
+
+	err = writeFile(l.path, l.keyring, func(w io.Writer) (err error) {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(file)
+		return
+	})
+	return
+}
+
+// newFuelLogEntryJSON is a function that serializes a fuel log entry to JSON
+func newFuelLogEntryJSON(e internal.FuelLogEntry) FuelLogEntryJSON {
+	return FuelLogEntryJSON{
+		ID:        e.ID,
+		VehicleID: e.VehicleID,
+		Date:      e.Date,
+		Odometer:  e.Odometer,
+		Quantity:  e.Quantity,
+		Unit:      string(e.Unit),
+		Cost:      e.Cost,
+		Station:   e.Station,
+	}
+}
+
+// Entry is a method that deserializes a fuel log entry from JSON
+func (e FuelLogEntryJSON) Entry() internal.FuelLogEntry {
+	return internal.FuelLogEntry{
+		ID:        e.ID,
+		VehicleID: e.VehicleID,
+		Date:      e.Date.UTC(),
+		Odometer:  e.Odometer,
+		Quantity:  e.Quantity,
+		Unit:      internal.FuelUnit(e.Unit),
+		Cost:      e.Cost,
+		Station:   e.Station,
+	}
+}
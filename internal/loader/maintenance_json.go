@@ -0,0 +1,208 @@
+package loader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// MaintenanceSchemaVersion is the version of the maintenance files written by Save
+const MaintenanceSchemaVersion = 1
+
+// dateLayout is the layout of the dates of the files
+const dateLayout = "2006-01-02"
+
+// ConfigMaintenanceJSONFile is a struct that represents the configuration for MaintenanceJSONFile
+type ConfigMaintenanceJSONFile struct {
+	// Path is the path to the file that contains the maintenance records and rules in JSON format
+	Path string
+	// Keyring is the keyring of encrypted files, files are saved encrypted with its current key when set
+	Keyring *Keyring
+}
+
+// NewMaintenanceJSONFile is a function that returns a new instance of MaintenanceJSONFile
+func NewMaintenanceJSONFile(cfg *ConfigMaintenanceJSONFile) *MaintenanceJSONFile {
+	return &MaintenanceJSONFile{path: cfg.Path, keyring: cfg.Keyring}
+}
+
+// MaintenanceJSONFile is a struct that implements the MaintenanceLoader interface
+type MaintenanceJSONFile struct {
+	// path is the path to the file that contains the maintenance records and rules in JSON format
+	path string
+	// keyring is the keyring of encrypted files, nil saves them in plaintext
+	keyring *Keyring
+}
+
+// MaintenanceFileJSON is a struct that represents a maintenance file in JSON format
+type MaintenanceFileJSON struct {
+	SchemaVersion int                     `json:"schema_version"`
+	Records       []MaintenanceRecordJSON `json:"records"`
+	Rules         []MaintenanceRuleJSON   `json:"rules"`
+}
+
+// MaintenanceRecordJSON is a struct that represents a maintenance record in JSON format
+type MaintenanceRecordJSON struct {
+	ID        int     `json:"id"`
+	VehicleID int     `json:"vehicle_id"`
+	Type      string  `json:"type"`
+	RuleID    int     `json:"rule_id,omitempty"`
+	Date      string  `json:"date"`
+	EndDate   string  `json:"end_date,omitempty"`
+	Odometer  int     `json:"odometer"`
+	Cost      float64 `json:"cost"`
+	Notes     string  `json:"notes,omitempty"`
+}
+
+// MaintenanceRuleJSON is a struct that represents a schedule rule in JSON format
+type MaintenanceRuleJSON struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Brand       string `json:"brand,omitempty"`
+	Model       string `json:"model,omitempty"`
+	EveryKm     int    `json:"every_km,omitempty"`
+	EveryMonths int    `json:"every_months,omitempty"`
+}
+
+// Load is a method that loads the maintenance records and rules, a missing file holds none
+func (l *MaintenanceJSONFile) Load() (d internal.MaintenanceData, err error) {
+	d = internal.MaintenanceData{Records: make(map[int]internal.MaintenanceRecord), Rules: make(map[int]internal.MaintenanceRule)}
+
+	// open file
+	r, _, err := readFile(l.path, l.keyring)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+	defer r.Close()
+
+	// decode file
+	var file MaintenanceFileJSON
+	if err = json.NewDecoder(r).Decode(&file); err != nil {
+		err = fmt.Errorf("error decoding maintenance file: %w", err)
+		return
+	}
+	if file.SchemaVersion < 1 || file.SchemaVersion > MaintenanceSchemaVersion {
+		err = fmt.Errorf("%w: %d, the greatest supported is %d", ErrSchemaVersionUnsupported, file.SchemaVersion, MaintenanceSchemaVersion)
+		return
+	}
+
+	// serialize records and rules
+	for i, value := range file.Rules {
+		rule := value.Rule()
+		if err = rule.Validate(); err != nil {
+			err = fmt.Errorf("error loading rule %d: %w", i, err)
+			return
+		}
+		d.Rules[rule.ID] = rule
+	}
+	for i, value := range file.Records {
+		var rec internal.MaintenanceRecord
+		if rec, err = value.Record(); err == nil {
+			err = rec.Validate()
+		}
+		if err != nil {
+			err = fmt.Errorf("error loading record %d: %w", i, err)
+			return
+		}
+		d.Records[rec.ID] = rec
+	}
+	return
+}
+
+// Save is a method that saves the maintenance records and rules ordered by id, the file is written as described by writeFile
+func (l *MaintenanceJSONFile) Save(d internal.MaintenanceData) (err error) {
+	file := MaintenanceFileJSON{
+		SchemaVersion: MaintenanceSchemaVersion,
+		Records:       make([]MaintenanceRecordJSON, 0, len(d.Records)),
+		Rules:         make([]MaintenanceRuleJSON, 0, len(d.Rules)),
+	}
+	for _, value := range d.Records {
+		file.Records = append(file.Records, newMaintenanceRecordJSON(value))
+	}
+	sort.Slice(file.Records, func(i, j int) bool { return file.Records[i].ID < file.Records[j].ID })
+	for _, value := range d.Rules {
+		file.Rules = append(file.Rules, newMaintenanceRuleJSON(value))
+	}
+	sort.Slice(file.Rules, func(i, j int) bool { return file.Rules[i].ID < file.Rules[j].ID })
+
+	err = writeFile(l.path, l.keyring, func(w io.Writer) (err error) {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(file)
+		return
+	})
+	return
+}
+
+// newMaintenanceRecordJSON is a function that serializes a maintenance record to JSON
+func newMaintenanceRecordJSON(r internal.MaintenanceRecord) MaintenanceRecordJSON {
+	data := MaintenanceRecordJSON{
+		ID:        r.ID,
+		VehicleID: r.VehicleID,
+		Type:      string(r.Type),
+		RuleID:    r.RuleID,
+		Date:      r.Date.Format(dateLayout),
+		Odometer:  r.Odometer,
+		Cost:      r.Cost,
+		Notes:     r.Notes,
+	}
+	if !r.EndDate.IsZero() {
+		data.EndDate = r.EndDate.Format(dateLayout)
+	}
+	return data
+}
+
+// Record is a method that deserializes a maintenance record from JSON
+func (r MaintenanceRecordJSON) Record() (rec internal.MaintenanceRecord, err error) {
+	rec = internal.MaintenanceRecord{
+		ID:        r.ID,
+		VehicleID: r.VehicleID,
+		Type:      internal.MaintenanceType(r.Type),
+		RuleID:    r.RuleID,
+		Odometer:  r.Odometer,
+		Cost:      r.Cost,
+		Notes:     r.Notes,
+	}
+	if rec.Date, err = time.Parse(dateLayout, r.Date); err != nil {
+		return
+	}
+	if r.EndDate != "" {
+		rec.EndDate, err = time.Parse(dateLayout, r.EndDate)
+	}
+	return
+}
+
+// newMaintenanceRuleJSON is a function that serializes a schedule rule to JSON
+func newMaintenanceRuleJSON(r internal.MaintenanceRule) MaintenanceRuleJSON {
+	return MaintenanceRuleJSON{
+		ID:          r.ID,
+		Name:        r.Name,
+		Type:        string(r.Type),
+		Brand:       r.Brand,
+		Model:       r.Model,
+		EveryKm:     r.EveryKm,
+		EveryMonths: r.EveryMonths,
+	}
+}
+
+// Rule is a method that deserializes a schedule rule from JSON
+func (r MaintenanceRuleJSON) Rule() internal.MaintenanceRule {
+	return internal.MaintenanceRule{
+		ID:          r.ID,
+		Name:        r.Name,
+		Type:        internal.MaintenanceType(r.Type),
+		Brand:       r.Brand,
+		Model:       r.Model,
+		EveryKm:     r.EveryKm,
+		EveryMonths: r.EveryMonths,
+	}
+}
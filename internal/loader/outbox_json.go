@@ -0,0 +1,121 @@
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// outboxMember is the name of the member of the vehicles file that holds the outbox
+const outboxMember = "outbox"
+
+// OutboxEntryJSON is a struct that represents an outbox entry in JSON format
+type OutboxEntryJSON struct {
+	ID        int             `json:"id"`
+	Event     OutboxEventJSON `json:"event"`
+	Attempts  int             `json:"attempts,omitempty"`
+	LastError string          `json:"last_error,omitempty"`
+	Delivered []string        `json:"delivered,omitempty"`
+}
+
+// OutboxEventJSON is a struct that represents the event of an outbox entry in JSON format.
+// The vehicles are kept without their transitions, which events do not carry; resets carry no vehicle
+type OutboxEventJSON struct {
+	ID         string           `json:"id"`
+	Type       string           `json:"type"`
+	OccurredAt time.Time        `json:"occurred_at"`
+	Vehicle    *VehicleJSON     `json:"vehicle,omitempty"`
+	Previous   *VehicleJSON     `json:"previous,omitempty"`
+	Transition *TransitionJSON  `json:"transition,omitempty"`
+	Reset      *OutboxResetJSON `json:"reset,omitempty"`
+}
+
+// OutboxResetJSON is a struct that represents the vehicles changed by a reset event in JSON format
+type OutboxResetJSON struct {
+	Reason   string `json:"reason"`
+	Vehicles int    `json:"vehicles"`
+	Created  []int  `json:"created,omitempty"`
+	Updated  []int  `json:"updated,omitempty"`
+	Deleted  []int  `json:"deleted,omitempty"`
+}
+
+// newOutboxEntryJSON is a function that serializes an outbox entry to JSON
+func newOutboxEntryJSON(o internal.OutboxEntry) (data OutboxEntryJSON) {
+	e := o.Event
+	data = OutboxEntryJSON{
+		ID: o.ID,
+		Event: OutboxEventJSON{
+			ID:         e.ID,
+			Type:       string(e.Type),
+			OccurredAt: e.OccurredAt,
+		},
+		Attempts:  o.Attempts,
+		LastError: o.LastError,
+		Delivered: o.Delivered,
+	}
+	if rs := e.Reset; rs != nil {
+		data.Event.Reset = &OutboxResetJSON{Reason: string(rs.Reason), Vehicles: rs.Vehicles, Created: rs.Created, Updated: rs.Updated, Deleted: rs.Deleted}
+		return
+	}
+	v := newOutboxVehicleJSON(e.Vehicle)
+	data.Event.Vehicle = &v
+	if e.Previous != nil {
+		p := newOutboxVehicleJSON(*e.Previous)
+		data.Event.Previous = &p
+	}
+	if t := e.Transition; t != nil {
+		data.Event.Transition = &TransitionJSON{From: string(t.From), To: string(t.To), At: t.At, Reason: t.Reason}
+	}
+	return
+}
+
+// newOutboxVehicleJSON is a function that serializes the vehicle of an event to JSON, without its transitions
+func newOutboxVehicleJSON(v internal.Vehicle) (data VehicleJSON) {
+	data = newVehicleJSON(v)
+	data.Transitions = nil
+	return
+}
+
+// Entry is a method that deserializes an outbox entry from JSON
+func (o OutboxEntryJSON) Entry() (e internal.OutboxEntry) {
+	e = internal.OutboxEntry{
+		ID: o.ID,
+		Event: internal.Event{
+			ID:         o.Event.ID,
+			Type:       internal.EventType(o.Event.Type),
+			OccurredAt: o.Event.OccurredAt.UTC(),
+		},
+		Attempts:  o.Attempts,
+		LastError: o.LastError,
+		Delivered: o.Delivered,
+	}
+	if o.Event.Vehicle != nil {
+		e.Event.Vehicle = o.Event.Vehicle.Vehicle()
+	}
+	if rs := o.Event.Reset; rs != nil {
+		e.Event.Reset = &internal.VehiclesReset{Reason: internal.ResetReason(rs.Reason), Vehicles: rs.Vehicles, Created: rs.Created, Updated: rs.Updated, Deleted: rs.Deleted}
+	}
+	if o.Event.Previous != nil {
+		p := o.Event.Previous.Vehicle()
+		e.Event.Previous = &p
+	}
+	if t := o.Event.Transition; t != nil {
+		e.Event.Transition = &internal.StatusTransition{From: internal.VehicleStatus(t.From), To: internal.VehicleStatus(t.To), At: t.At.UTC(), Reason: t.Reason}
+	}
+	return
+}
+
+// decodeOutbox is a function that decodes the outbox member of a vehicles file
+func decodeOutbox(raw json.RawMessage) (o []internal.OutboxEntry, err error) {
+	var entries []OutboxEntryJSON
+	if err = json.Unmarshal(raw, &entries); err != nil {
+		err = fmt.Errorf("error decoding outbox: %w", err)
+		return
+	}
+	for _, value := range entries {
+		o = append(o, value.Entry())
+	}
+	return
+}
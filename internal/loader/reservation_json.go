@@ -0,0 +1,149 @@
+package loader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// ReservationSchemaVersion is the version of the reservation files written by Save
+const ReservationSchemaVersion = 1
+
+// ConfigReservationJSONFile is a struct that represents the configuration for ReservationJSONFile
+type ConfigReservationJSONFile struct {
+	// Path is the path to the file that contains the reservations in JSON format
+	Path string
+	// Keyring is the keyring of encrypted files, files are saved encrypted with its current key when set
+	Keyring *Keyring
+}
+
+// NewReservationJSONFile is a function that returns a new instance of ReservationJSONFile
+func NewReservationJSONFile(cfg *ConfigReservationJSONFile) *ReservationJSONFile {
+	return &ReservationJSONFile{path: cfg.Path, keyring: cfg.Keyring}
+}
+
+// ReservationJSONFile is a struct that implements the ReservationLoader interface
+type ReservationJSONFile struct {
+	// path is the path to the file that contains the reservations in JSON format
+	path string
+	// keyring is the keyring of encrypted files, nil saves them in plaintext
+	keyring *Keyring
+}
+
+// ReservationFileJSON is a struct that represents a reservation file in JSON format
+type ReservationFileJSON struct {
+	SchemaVersion int               `json:"schema_version"`
+	Reservations  []ReservationJSON `json:"reservations"`
+}
+
+// ReservationJSON is a struct that represents a reservation in JSON format
+type ReservationJSON struct {
+	ID          int        `json:"id"`
+	VehicleID   int        `json:"vehicle_id"`
+	Driver      string     `json:"driver"`
+	Purpose     string     `json:"purpose,omitempty"`
+	From        time.Time  `json:"from"`
+	To          time.Time  `json:"to"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+}
+
+// Load is a method that loads the reservations, a missing file holds none
+func (l *ReservationJSONFile) Load() (r map[int]internal.Reservation, err error) {
+	r = make(map[int]internal.Reservation)
+
+	// open file
+	f, _, err := readFile(l.path, l.keyring)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+	defer f.Close()
+
+	// decode file
+	var file ReservationFileJSON
+	if err = json.NewDecoder(f).Decode(&file); err != nil {
+		err = fmt.Errorf("error decoding reservation file: %w", err)
+		return
+	}
+	if file.SchemaVersion < 1 || file.SchemaVersion > ReservationSchemaVersion {
+		err = fmt.Errorf("%w: %d, the greatest supported is %d", ErrSchemaVersionUnsupported, file.SchemaVersion, ReservationSchemaVersion)
+		return
+	}
+
+	// serialize reservations
+	for i, value := range file.Reservations {
+		res := value.Reservation()
+		if err = res.Validate(); err != nil {
+			err = fmt.Errorf("error loading reservation %d: %w", i, err)
+			return
+		}
+		r[res.ID] = res
+	}
+	return
+}
+
+// Save is a method that saves the reservations ordered by id, the file is written as described by writeFile
+func (l *ReservationJSONFile) Save(r map[int]internal.Reservation) (err error) {
+	file := ReservationFileJSON{
+		SchemaVersion: ReservationSchemaVersion,
+		Reservations:  make([]ReservationJSON, 0, len(r)),
+	}
+	for _, value := range r {
+		file.Reservations = append(file.Reservations, newReservationJSON(value))
+	}
+	sort.Slice(file.Reservations, func(i, j int) bool { return file.Reservations[i].ID < file.Reservations[j].ID })
+
+	err = writeFile(l.path, l.keyring, func(w io.Writer) (err error) {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(file)
+		return
+	})
+	return
+}
+
+// newReservationJSON is a function that serializes a reservation to JSON
+func newReservationJSON(r internal.Reservation) ReservationJSON {
+	data := ReservationJSON{
+		ID:        r.ID,
+		VehicleID: r.VehicleID,
+		Driver:    r.Driver,
+		Purpose:   r.Purpose,
+		From:      r.From,
+		To:        r.To,
+		Status:    string(r.Status),
+		CreatedAt: r.CreatedAt,
+	}
+	if !r.CancelledAt.IsZero() {
+		data.CancelledAt = &r.CancelledAt
+	}
+	return data
+}
+
+// Reservation is a method that deserializes a reservation from JSON
+func (r ReservationJSON) Reservation() internal.Reservation {
+	res := internal.Reservation{
+		ID:        r.ID,
+		VehicleID: r.VehicleID,
+		Driver:    r.Driver,
+		Purpose:   r.Purpose,
+		From:      r.From.UTC(),
+		To:        r.To.UTC(),
+		Status:    internal.ReservationStatus(r.Status),
+		CreatedAt: r.CreatedAt.UTC(),
+	}
+	if r.CancelledAt != nil {
+		res.CancelledAt = r.CancelledAt.UTC()
+	}
+	return res
+}
@@ -0,0 +1,329 @@
+package loader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+const (
+	// LegacySchemaVersion is the version of files holding a bare array of vehicles, written before the envelope existed
+	LegacySchemaVersion = 1
+	// CurrentSchemaVersion is the version of the files written by Save
+	CurrentSchemaVersion = 3
+)
+
+var (
+	// ErrSchemaVersionUnsupported is an error that represents a file written by a newer version of the schema
+	ErrSchemaVersionUnsupported = errors.New("schema version unsupported")
+)
+
+// Migration is a struct that represents the upgrade of a record from a schema version to the next one
+type Migration struct {
+	// From is the version the migration upgrades from, it produces version From+1
+	From int
+	// Description is what the migration changes
+	Description string
+	// Apply is the function that upgrades a record in place
+	Apply func(record map[string]any) (err error)
+}
+
+// migrations is the registry of migrations by the version they upgrade from
+var migrations = map[int]Migration{}
+
+// RegisterMigration is a function that adds a migration to the registry, it panics if one from the same version exists
+func RegisterMigration(m Migration) {
+	if _, ok := migrations[m.From]; ok {
+		panic(fmt.Sprintf("loader: migration from schema version %d registered twice", m.From))
+	}
+	migrations[m.From] = m
+}
+
+// Migrations is a function that returns the registered migrations ordered by version
+func Migrations() (m []Migration) {
+	for _, value := range migrations {
+		m = append(m, value)
+	}
+	sort.Slice(m, func(i, j int) bool { return m[i].From < m[j].From })
+	return
+}
+
+func init() {
+	RegisterMigration(Migration{
+		From:        1,
+		Description: "wrap the vehicles in an envelope with the schema version, records are unchanged",
+		Apply:       func(record map[string]any) (err error) { return },
+	})
+	RegisterMigration(Migration{
+		From:        2,
+		Description: "add the lifecycle status, every vehicle starts in service",
+		Apply: func(record map[string]any) (err error) {
+			if _, ok := record["status"]; !ok {
+				record["status"] = string(internal.VehicleStatusInService)
+			}
+			return
+		},
+	})
+}
+
+// migrateRecord is a function that upgrades a record from a schema version to the current one
+func migrateRecord(raw json.RawMessage, version int) (migrated json.RawMessage, err error) {
+	if version == CurrentSchemaVersion {
+		migrated = raw
+		return
+	}
+
+	var record map[string]any
+	if err = json.Unmarshal(raw, &record); err != nil {
+		return
+	}
+	for ; version < CurrentSchemaVersion; version++ {
+		m, ok := migrations[version]
+		if !ok {
+			err = fmt.Errorf("no migration from schema version %d", version)
+			return
+		}
+		if err = m.Apply(record); err != nil {
+			err = fmt.Errorf("error migrating from schema version %d: %w", version, err)
+			return
+		}
+	}
+	migrated, err = json.Marshal(record)
+	return
+}
+
+// recordFunc is a function that receives a record upgraded to the current schema version, or the record as it is in the
+// file with the error that prevented its upgrade
+type recordFunc func(raw json.RawMessage, migrateErr error) (err error)
+
+// decodeDocument is a function that decodes a document in any supported schema version, either a legacy bare array
+// or an envelope, calling onRecord with each record upgraded to the current version, in the order of the file.
+// Other members of the envelope are passed to onMember, or ignored when it is nil
+func decodeDocument(dec *json.Decoder, onRecord recordFunc, onMember func(name string, raw json.RawMessage) (err error)) (version int, err error) {
+	tk, err := dec.Token()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		err = fmt.Errorf("error decoding file: %w", err)
+		return
+	}
+
+	switch tk {
+	// legacy
+	case json.Delim('['):
+		version = LegacySchemaVersion
+		err = decodeRecords(dec, version, onRecord)
+		return
+	// envelope
+	case json.Delim('{'):
+	default:
+		err = fmt.Errorf("error decoding file: expected [ or {, found %v", tk)
+		return
+	}
+
+	// - records found before the version are kept until it is known
+	var pending []json.RawMessage
+	var found bool
+	for dec.More() {
+		tk, err = dec.Token()
+		if err != nil {
+			err = fmt.Errorf("error decoding file: %w", err)
+			return
+		}
+		switch tk {
+		case "schema_version":
+			if err = dec.Decode(&version); err != nil {
+				err = fmt.Errorf("error decoding schema version: %w", err)
+				return
+			}
+			if version < LegacySchemaVersion || version > CurrentSchemaVersion {
+				err = fmt.Errorf("%w: %d, the greatest supported is %d", ErrSchemaVersionUnsupported, version, CurrentSchemaVersion)
+				return
+			}
+		case "vehicles":
+			found = true
+			if version != 0 {
+				if err = expectDelim(dec, '['); err != nil {
+					return
+				}
+				if err = decodeRecords(dec, version, onRecord); err != nil {
+					return
+				}
+				continue
+			}
+			if err = dec.Decode(&pending); err != nil {
+				err = fmt.Errorf("error decoding vehicles: %w", err)
+				return
+			}
+		default:
+			var raw json.RawMessage
+			if err = dec.Decode(&raw); err != nil {
+				err = fmt.Errorf("error decoding file: %w", err)
+				return
+			}
+			name, _ := tk.(string)
+			if onMember != nil {
+				if err = onMember(name, raw); err != nil {
+					return
+				}
+			}
+		}
+	}
+	if err = expectDelim(dec, '}'); err != nil {
+		return
+	}
+	if version == 0 {
+		err = fmt.Errorf("error decoding file: schema_version is required")
+		return
+	}
+	if !found {
+		err = fmt.Errorf("error decoding file: vehicles is required")
+		return
+	}
+	for _, raw := range pending {
+		if err = migrateAndCall(raw, version, onRecord); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// decodeRecords is a function that decodes the records of an array one at a time, its opening bracket already read.
+// Whether the bracket is read depends on the shape of the document rather than its version, as an envelope may hold any version
+func decodeRecords(dec *json.Decoder, version int, onRecord recordFunc) (err error) {
+	for n := 0; dec.More(); n++ {
+		// - a malformed document can not be resynchronized
+		var raw json.RawMessage
+		if err = dec.Decode(&raw); err != nil {
+			err = fmt.Errorf("error decoding record %d: %w", n, err)
+			return
+		}
+		if err = migrateAndCall(raw, version, onRecord); err != nil {
+			return
+		}
+	}
+	err = expectDelim(dec, ']')
+	return
+}
+
+// migrateAndCall is a function that upgrades a record and passes it to onRecord, records that can not be upgraded are passed
+// unchanged with the error, so that onRecord decides whether the record is rejected or the whole document fails
+func migrateAndCall(raw json.RawMessage, version int, onRecord recordFunc) (err error) {
+	migrated, migrateErr := migrateRecord(raw, version)
+	if migrateErr != nil {
+		err = onRecord(raw, migrateErr)
+		return
+	}
+	err = onRecord(migrated, nil)
+	return
+}
+
+// documentWriter is a struct that writes a document in the current schema version one record at a time
+type documentWriter struct {
+	// w is the writer of the document
+	w io.Writer
+	// n is the number of records written
+	n int
+	// members is the list of the members written after the records, in order
+	members []documentMember
+}
+
+// documentMember is a struct that represents a member of the envelope other than the schema version and the records
+type documentMember struct {
+	// name is the name of the member
+	name string
+	// value is the value of the member, encoded as JSON
+	value any
+}
+
+// Begin is a method that writes the envelope up to the first record
+func (d *documentWriter) Begin() (err error) {
+	_, err = fmt.Fprintf(d.w, "{\"schema_version\":%d,\"vehicles\":[", CurrentSchemaVersion)
+	return
+}
+
+// Record is a method that writes a record in its own line
+func (d *documentWriter) Record(record any) (err error) {
+	bytes, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	sep := "\n"
+	if d.n > 0 {
+		sep = ",\n"
+	}
+	d.n++
+	if _, err = io.WriteString(d.w, sep); err != nil {
+		return
+	}
+	_, err = d.w.Write(bytes)
+	return
+}
+
+// Member is a method that adds a member written after the records when the envelope is closed
+func (d *documentWriter) Member(name string, value any) {
+	d.members = append(d.members, documentMember{name: name, value: value})
+}
+
+// End is a method that closes the records and writes the members before closing the envelope
+func (d *documentWriter) End() (err error) {
+	if _, err = io.WriteString(d.w, "\n]"); err != nil {
+		return
+	}
+	for _, m := range d.members {
+		var name, value []byte
+		if name, err = json.Marshal(m.name); err != nil {
+			return
+		}
+		if value, err = json.Marshal(m.value); err != nil {
+			return
+		}
+		if _, err = fmt.Fprintf(d.w, ",\n%s:%s", name, value); err != nil {
+			return
+		}
+	}
+	_, err = io.WriteString(d.w, "}\n")
+	return
+}
+
+// MigrateFile is a function that rewrites a vehicles file of any supported schema version in the current one.
+// Records are upgraded as they are, without validation, and other members are kept, so no data is lost. in and out may be the same file.
+// A gzip-compressed input is read transparently, and the output is compressed when its path ends in .gz.
+// Encrypted inputs are decrypted with the keyring, and the output is encrypted with its current key when it is not nil
+func MigrateFile(in, out string, kr *Keyring) (from int, err error) {
+	src, _, err := readFile(in, kr)
+	if err != nil {
+		return
+	}
+	defer src.Close()
+
+	err = writeFile(out, kr, func(w io.Writer) (err error) {
+		dw := &documentWriter{w: w}
+		if err = dw.Begin(); err != nil {
+			return
+		}
+		from, err = decodeDocument(json.NewDecoder(src), func(raw json.RawMessage, migrateErr error) (err error) {
+			// - a record that can not be upgraded would be written in the wrong version
+			if migrateErr != nil {
+				err = migrateErr
+				return
+			}
+			err = dw.Record(raw)
+			return
+		}, func(name string, raw json.RawMessage) (err error) {
+			dw.Member(name, raw)
+			return
+		})
+		if err != nil {
+			return
+		}
+		err = dw.End()
+		return
+	})
+	return
+}
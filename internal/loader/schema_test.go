@@ -0,0 +1,102 @@
+package loader
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+func TestVehicleJSONFile_Load_SchemaVersions(t *testing.T) {
+	records := testRecord("1") + "," + testRecord("2")
+	tests := []struct {
+		name    string
+		data    string
+		version int
+		err     error
+	}{
+		{name: "legacy array", data: "[" + records + "]", version: 1},
+		{name: "envelope of version 1", data: `{"schema_version":1,"vehicles":[` + records + `]}`, version: 1},
+		{name: "envelope of version 2", data: `{"schema_version":2,"vehicles":[` + records + `]}`, version: 2},
+		{name: "envelope of the current version", data: `{"schema_version":3,"vehicles":[` + strings.ReplaceAll(records, `"year"`, `"status":"in_service","year"`) + `]}`, version: 3},
+		{name: "vehicles before the version", data: `{"vehicles":[` + records + `],"schema_version":1}`, version: 1},
+		{name: "unknown members", data: `{"schema_version":1,"vehicles":[` + records + `],"extra":{"a":[1]}}`, version: 1},
+		{name: "newer version", data: `{"schema_version":4,"vehicles":[]}`, err: ErrSchemaVersionUnsupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ld := NewVehicleJSONFile(writeTestFile(t, tt.data))
+			v, err := ld.Load()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("load: got %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if len(v) != 2 || v[1].Status != internal.VehicleStatusInService {
+				t.Fatalf("loaded %+v, want 2 vehicles in service", v)
+			}
+			if r := ld.Report(); r.SchemaVersion != tt.version {
+				t.Fatalf("version: got %d, want %d", r.SchemaVersion, tt.version)
+			}
+		})
+	}
+
+	t.Run("malformed envelope", func(t *testing.T) {
+		for _, data := range []string{`{"schema_version":1,"vehicles":{}}`, `{"schema_version":1,"vehicles":[` + records + `}`, `{"vehicles":[]}`, `{"schema_version":1}`} {
+			if _, err := NewVehicleJSONFile(writeTestFile(t, data)).Load(); err == nil {
+				t.Fatalf("load %s: got no error", data)
+			}
+		}
+	})
+}
+
+func TestVehicleJSONFile_Load_MigrationErrors(t *testing.T) {
+	// - a record that is not an object can not be upgraded
+	data := `{"schema_version":1,"vehicles":[` + testRecord("1") + `,42]}`
+
+	t.Run("fail", func(t *testing.T) {
+		_, err := NewVehicleJSONFile(writeTestFile(t, data)).Load()
+		if !errors.Is(err, internal.ErrVehicleInvalid) {
+			t.Fatalf("load: got %v, want %v", err, internal.ErrVehicleInvalid)
+		}
+	})
+	t.Run("quarantine", func(t *testing.T) {
+		path := writeTestFile(t, data)
+		ld := NewVehicleJSONFileWithConfig(&ConfigVehicleJSONFile{Path: path, OnInvalid: InvalidQuarantine})
+		v, err := ld.Load()
+		if err != nil {
+			t.Fatalf("load: %v", err)
+		}
+		if len(v) != 1 || ld.Report().Rejected != 1 {
+			t.Fatalf("loaded %d vehicles and rejected %d, want 1 and 1", len(v), ld.Report().Rejected)
+		}
+		b, err := os.ReadFile(path + ".rejects.ndjson")
+		if err != nil || !strings.Contains(string(b), `"data":42`) {
+			t.Fatalf("rejects: got %q, %v", b, err)
+		}
+	})
+	t.Run("migrate file", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "out.json")
+		if _, err := MigrateFile(writeTestFile(t, data), out, nil); err == nil {
+			t.Fatal("migrate: got no error")
+		}
+	})
+}
+
+func TestMigrateFile(t *testing.T) {
+	in := writeTestFile(t, "["+testRecord("2")+","+testRecord("1")+"]")
+	out := filepath.Join(t.TempDir(), "out.json")
+	from, err := MigrateFile(in, out, nil)
+	if err != nil || from != LegacySchemaVersion {
+		t.Fatalf("migrate: got %d, %v", from, err)
+	}
+	ld := NewVehicleJSONFile(out)
+	v, err := ld.Load()
+	if err != nil || len(v) != 2 || ld.Report().SchemaVersion != CurrentSchemaVersion {
+		t.Fatalf("load migrated: got %d vehicles of version %d, %v", len(v), ld.Report().SchemaVersion, err)
+	}
+}
@@ -1,46 +1,231 @@
 package loader
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"time"
 
 	"github.com/rhinosc/code-review-1/internal"
 )
 
+// InvalidPolicy is the behaviour of the loader when it finds an invalid record
+type InvalidPolicy string
+
+const (
+	// InvalidFail fails the whole load on the first invalid record
+	InvalidFail InvalidPolicy = "fail"
+	// InvalidSkip leaves invalid records out of the load
+	InvalidSkip InvalidPolicy = "skip"
+	// InvalidQuarantine leaves invalid records out of the load and writes them to the rejects file
+	InvalidQuarantine InvalidPolicy = "quarantine"
+)
+
+// defaultProgressEvery is the number of records between progress reports when none is given
+const defaultProgressEvery = 10000
+
+// LoadProgress is a struct that represents the progress of a load
+type LoadProgress struct {
+	// Records is the number of records read
+	Records int
+	// Accepted is the number of valid records
+	Accepted int
+	// Rejected is the number of invalid records that were skipped or quarantined
+	Rejected int
+	// BytesRead is the number of bytes of the file read
+	BytesRead int64
+	// BytesTotal is the size of the file
+	BytesTotal int64
+	// Done reports whether the load finished
+	Done bool
+}
+
+// ConfigVehicleJSONFile is a struct that represents the configuration for VehicleJSONFile
+type ConfigVehicleJSONFile struct {
+	// Path is the path to the file that contains the vehicles in JSON format
+	Path string
+	// OnInvalid is the behaviour when an invalid record is found, InvalidFail by default
+	OnInvalid InvalidPolicy
+	// RejectsPath is the path to the file where quarantined records are written, {Path}.rejects.ndjson by default,
+	// encrypted with Keyring when it is set
+	RejectsPath string
+	// Progress is called every ProgressEvery records and when the load finishes
+	Progress func(p LoadProgress)
+	// ProgressEvery is the number of records between progress reports
+	ProgressEvery int
+	// Keyring is the keyring of encrypted files, files are saved encrypted with its current key when set and plaintext
+	// files are refused with ErrEncryptionRequired
+	Keyring *Keyring
+}
+
 // NewVehicleJSONFile is a function that returns a new instance of VehicleJSONFile
 func NewVehicleJSONFile(path string) *VehicleJSONFile {
+	return NewVehicleJSONFileWithConfig(&ConfigVehicleJSONFile{Path: path})
+}
+
+// NewVehicleJSONFileWithConfig is a function that returns a new instance of VehicleJSONFile with a configuration
+func NewVehicleJSONFileWithConfig(cfg *ConfigVehicleJSONFile) *VehicleJSONFile {
+	// default values
+	defaultConfig := &ConfigVehicleJSONFile{
+		OnInvalid:     InvalidFail,
+		ProgressEvery: defaultProgressEvery,
+	}
+	if cfg != nil {
+		defaultConfig.Path = cfg.Path
+		if cfg.OnInvalid != "" {
+			defaultConfig.OnInvalid = cfg.OnInvalid
+		}
+		defaultConfig.RejectsPath = cfg.RejectsPath
+		defaultConfig.Progress = cfg.Progress
+		if cfg.ProgressEvery > 0 {
+			defaultConfig.ProgressEvery = cfg.ProgressEvery
+		}
+		defaultConfig.Keyring = cfg.Keyring
+	}
+	if defaultConfig.RejectsPath == "" {
+		defaultConfig.RejectsPath = defaultConfig.Path + ".rejects.ndjson"
+	}
+
 	return &VehicleJSONFile{
-		path: path,
+		path:          defaultConfig.Path,
+		onInvalid:     defaultConfig.OnInvalid,
+		rejectsPath:   defaultConfig.RejectsPath,
+		progress:      defaultConfig.Progress,
+		progressEvery: defaultConfig.ProgressEvery,
+		keyring:       defaultConfig.Keyring,
 	}
 }
 
-// VehicleJSONFile is a struct that implements the LoaderVehicle interface
+// VehicleJSONFile is a struct that implements the LoaderVehicle and VehicleOutboxLoader interfaces
 type VehicleJSONFile struct {
 	// path is the path to the file that contains the vehicles in JSON format
 	path string
+	// onInvalid is the behaviour when an invalid record is found
+	onInvalid InvalidPolicy
+	// rejectsPath is the path to the file where quarantined records are written
+	rejectsPath string
+	// progress is called every progressEvery records and when the load finishes
+	progress func(p LoadProgress)
+	// progressEvery is the number of records between progress reports
+	progressEvery int
+	// keyring is the keyring of encrypted files, nil saves them in plaintext
+	keyring *Keyring
+	// report is the report of the last load
+	report internal.LoadReport
+	// outbox is the outbox read by the last load
+	outbox []internal.OutboxEntry
 }
 
 // VehicleJSON is a struct that represents a vehicle in JSON format
 type VehicleJSON struct {
-	Id              int     `json:"id"`
-	Brand           string  `json:"brand"`
-	Model           string  `json:"model"`
-	Registration    string  `json:"registration"`
-	Color           string  `json:"color"`
-	FabricationYear int     `json:"year"`
-	Capacity        int     `json:"passengers"`
-	MaxSpeed        float64 `json:"max_speed"`
-	FuelType        string  `json:"fuel_type"`
-	Transmission    string  `json:"transmission"`
-	Weight          float64 `json:"weight"`
-	Height          float64 `json:"height"`
-	Length          float64 `json:"length"`
-	Width           float64 `json:"width"`
-}
-
-// Load is a method that loads the vehicles
+	Id              int              `json:"id"`
+	UID             string           `json:"uid,omitempty"`
+	Brand           string           `json:"brand"`
+	Model           string           `json:"model"`
+	Registration    string           `json:"registration"`
+	Color           string           `json:"color"`
+	FabricationYear int              `json:"year"`
+	Capacity        int              `json:"passengers"`
+	MaxSpeed        float64          `json:"max_speed"`
+	FuelType        string           `json:"fuel_type"`
+	Transmission    string           `json:"transmission"`
+	Weight          float64          `json:"weight"`
+	Height          float64          `json:"height"`
+	Length          float64          `json:"length"`
+	Width           float64          `json:"width"`
+	Status          string           `json:"status"`
+	Transitions     []TransitionJSON `json:"transitions,omitempty"`
+}
+
+// TransitionJSON is a struct that represents a change of status in JSON format
+type TransitionJSON struct {
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	At     time.Time `json:"at"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// newVehicleJSON is a function that serializes a vehicle to JSON
+func newVehicleJSON(vh internal.Vehicle) (data VehicleJSON) {
+	data = VehicleJSON{
+		Id:              vh.Id,
+		UID:             vh.UID,
+		Brand:           vh.Brand,
+		Model:           vh.Model,
+		Registration:    vh.Registration,
+		Color:           vh.Color,
+		FabricationYear: vh.FabricationYear,
+		Capacity:        vh.Capacity,
+		MaxSpeed:        vh.MaxSpeed,
+		FuelType:        vh.FuelType,
+		Transmission:    vh.Transmission,
+		Weight:          vh.Weight,
+		Height:          vh.Height,
+		Length:          vh.Length,
+		Width:           vh.Width,
+		Status:          string(vh.Status),
+	}
+	for _, t := range vh.Transitions {
+		data.Transitions = append(data.Transitions, TransitionJSON{From: string(t.From), To: string(t.To), At: t.At, Reason: t.Reason})
+	}
+	return
+}
+
+// Vehicle is a method that deserializes a vehicle from JSON
+func (vh VehicleJSON) Vehicle() (v internal.Vehicle) {
+	v = internal.Vehicle{
+		Id:  vh.Id,
+		UID: vh.UID,
+		VehicleAttributes: internal.VehicleAttributes{
+			Brand:           vh.Brand,
+			Model:           vh.Model,
+			Registration:    vh.Registration,
+			Color:           vh.Color,
+			FabricationYear: vh.FabricationYear,
+			Capacity:        vh.Capacity,
+			MaxSpeed:        vh.MaxSpeed,
+			FuelType:        vh.FuelType,
+			Transmission:    vh.Transmission,
+			Weight:          vh.Weight,
+			Dimensions: internal.Dimensions{
+				Height: vh.Height,
+				Length: vh.Length,
+				Width:  vh.Width,
+			},
+		},
+		Status: internal.VehicleStatus(vh.Status),
+	}
+	for _, t := range vh.Transitions {
+		v.Transitions = append(v.Transitions, internal.StatusTransition{From: internal.VehicleStatus(t.From), To: internal.VehicleStatus(t.To), At: t.At, Reason: t.Reason})
+	}
+	return
+}
+
+// Report is a method that returns the report of the last load
+func (l *VehicleJSONFile) Report() (r internal.LoadReport) {
+	r = l.report
+	return
+}
+
+// Outbox is a method that returns the outbox read by the last load
+func (l *VehicleJSONFile) Outbox() (o []internal.OutboxEntry) {
+	o = l.outbox
+	return
+}
+
+// Load is a method that loads the vehicles and the outbox.
+// The file is decoded one record at a time and every record is validated, invalid records are handled according to onInvalid.
+// Only the first record of a duplicated id is loaded, the following ones are reported and left out whatever onInvalid is,
+// quarantined when it is InvalidQuarantine, as the file is still usable without them.
+// Encrypted and gzip-compressed files are detected by their magic bytes, an encrypted file that was tampered with fails with ErrEncryptionIntegrity
 func (l *VehicleJSONFile) Load() (v map[int]internal.Vehicle, err error) {
+	l.report = internal.LoadReport{}
+	l.outbox = nil
+
 	// open file
 	file, err := os.Open(l.path)
 	if err != nil {
@@ -48,74 +233,266 @@ func (l *VehicleJSONFile) Load() (v map[int]internal.Vehicle, err error) {
 	}
 	defer file.Close()
 
+	var p LoadProgress
+	if info, err := file.Stat(); err == nil {
+		p.BytesTotal = info.Size()
+	}
+	rj := &rejects{path: l.rejectsPath, keyring: l.keyring}
+	defer func() {
+		// - a rejects file cut short would lose records
+		if cErr := rj.Close(); cErr != nil && err == nil {
+			err = fmt.Errorf("error writing rejects: %w", cErr)
+		}
+	}()
+	// - rejects of a previous load are stale
+	if l.onInvalid == InvalidQuarantine {
+		if err = os.Remove(l.rejectsPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return
+		}
+		err = nil
+	}
+
 	// decode file
-	var vehiclesJSON []VehicleJSON
-	err = json.NewDecoder(file).Decode(&vehiclesJSON)
+	// - progress counts the bytes of the file, compressed or not
+	cr := &countingReader{r: file}
+	pr, keyID, err := decrypt(cr, l.keyring)
 	if err != nil {
+		err = fmt.Errorf("error decrypting file: %w", err)
 		return
 	}
-
-	// serialize vehicles
+	l.report.KeyID = keyID
+	dr, err := decompress(pr)
+	if err != nil {
+		err = fmt.Errorf("error reading file: %w", err)
+		return
+	}
+	defer dr.Close()
+	dec := json.NewDecoder(dr)
 	v = make(map[int]internal.Vehicle)
-	for _, vh := range vehiclesJSON {
-		v[vh.Id] = internal.Vehicle{
-			Id: vh.Id,
-			VehicleAttributes: internal.VehicleAttributes{
-				Brand:           vh.Brand,
-				Model:           vh.Model,
-				Registration:    vh.Registration,
-				Color:           vh.Color,
-				FabricationYear: vh.FabricationYear,
-				Capacity:        vh.Capacity,
-				MaxSpeed:        vh.MaxSpeed,
-				FuelType:        vh.FuelType,
-				Transmission:    vh.Transmission,
-				Weight:          vh.Weight,
-				Dimensions: internal.Dimensions{
-					Height: vh.Height,
-					Length: vh.Length,
-					Width:  vh.Width,
-				},
-			},
+	l.report.SchemaVersion, err = decodeDocument(dec, func(raw json.RawMessage, migrateErr error) (err error) {
+		index := p.Records
+		p.Records++
+		p.BytesRead = cr.n
+
+		// - invalid records
+		// - records that can not be upgraded are invalid too
+		var vh internal.Vehicle
+		recErr := migrateErr
+		if recErr != nil {
+			recErr = fmt.Errorf("%w: %v", internal.ErrVehicleInvalid, recErr)
+		} else {
+			vh, recErr = decodeRecord(raw)
+		}
+		if _, ok := v[vh.Id]; recErr == nil && ok {
+			l.report.Duplicates = appendUnique(l.report.Duplicates, vh.Id)
+			if l.onInvalid == InvalidQuarantine {
+				if err = rj.Write(index, raw, fmt.Errorf("%w: %d", internal.ErrVehicleDuplicateID, vh.Id)); err != nil {
+					return
+				}
+			}
+			p.Rejected++
+			return
+		}
+		if recErr != nil {
+			switch l.onInvalid {
+			case InvalidSkip:
+			case InvalidQuarantine:
+				if err = rj.Write(index, raw, recErr); err != nil {
+					return
+				}
+			default:
+				err = fmt.Errorf("error loading record %d: %w", index, recErr)
+				return
+			}
+			p.Rejected++
+			return
+		}
+
+		// serialize vehicle
+		v[vh.Id] = vh
+		p.Accepted++
+		if l.progress != nil && p.Records%l.progressEvery == 0 {
+			l.progress(p)
+		}
+		return
+	}, func(name string, raw json.RawMessage) (err error) {
+		// - other unknown members are ignored
+		if name == outboxMember {
+			l.outbox, err = decodeOutbox(raw)
 		}
+		return
+	})
+	if err != nil {
+		return
 	}
 
+	p.Done = true
+	if l.progress != nil {
+		l.progress(p)
+	}
+
+	// report
+	l.report.Records = p.Records
+	l.report.Accepted = p.Accepted
+	l.report.Rejected = p.Rejected
+	l.report.MaxID, l.report.Gaps = idGaps(v)
 	return
 }
 
-// Save is a method that saves the vehicles
-func (l *VehicleJSONFile) Save(v map[int]internal.Vehicle) (err error) {
-	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
+// appendUnique is a function that appends an id to a list unless it is already in it
+func appendUnique(ids []int, id int) []int {
+	for _, value := range ids {
+		if value == id {
+			return ids
+		}
+	}
+	return append(ids, id)
+}
+
+// idGaps is a function that returns the greatest id of the vehicles and the ranges of ids missing below it
+func idGaps(v map[int]internal.Vehicle) (maxID int, gaps []internal.IDRange) {
+	ids := make([]int, 0, len(v))
+	for key := range v {
+		ids = append(ids, key)
+	}
+	sort.Ints(ids)
+
+	next := 1
+	for _, id := range ids {
+		if id > next {
+			gaps = append(gaps, internal.IDRange{From: next, To: id - 1})
+		}
+		next = id + 1
+		maxID = id
+	}
+	return
+}
+
+// decodeRecord is a function that decodes and validates a record
+func decodeRecord(raw json.RawMessage) (vh internal.Vehicle, err error) {
+	var vj VehicleJSON
+	if err = json.Unmarshal(raw, &vj); err != nil {
+		err = fmt.Errorf("%w: %v", internal.ErrVehicleInvalid, err)
+		return
+	}
+	vh = vj.Vehicle()
+	err = vh.Validate()
+	return
+}
+
+// expectDelim is a function that reads the next token and checks it is the given delimiter
+func expectDelim(dec *json.Decoder, delim json.Delim) (err error) {
+	tk, err := dec.Token()
 	if err != nil {
-		fmt.Println(err)
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		err = fmt.Errorf("error decoding file: %w", err)
 		return
 	}
-	defer f.Close()
+	if d, ok := tk.(json.Delim); !ok || d != delim {
+		err = fmt.Errorf("error decoding file: expected %s, found %v", delim, tk)
+		return
+	}
+	return
+}
 
-	var vehiclesJSON []VehicleJSON
-	for _, vh := range v {
-		vehiclesJSON = append(vehiclesJSON, VehicleJSON{
-			Id:              vh.Id,
-			Brand:           vh.Brand,
-			Model:           vh.Model,
-			Registration:    vh.Registration,
-			Color:           vh.Color,
-			FabricationYear: vh.FabricationYear,
-			Capacity:        vh.Capacity,
-			MaxSpeed:        vh.MaxSpeed,
-			FuelType:        vh.FuelType,
-			Transmission:    vh.Transmission,
-			Weight:          vh.Weight,
-			Height:          vh.Height,
-			Length:          vh.Length,
-			Width:           vh.Width,
-		})
+// rejects is a struct that writes quarantined records to a newline delimited JSON file, created on the first write.
+// The file is encrypted like the data file when there is a keyring, as the records hold the same personal data
+type rejects struct {
+	// path is the path to the file
+	path string
+	// keyring is the keyring the file is encrypted with, nil for plaintext
+	keyring *Keyring
+	// file is the file, nil until the first write
+	file *os.File
+	// bw is the buffer of the file
+	bw *bufio.Writer
+	// ew is the encrypting writer of the file, or bw when it is not encrypted
+	ew io.WriteCloser
+	// enc is the encoder of the records
+	enc *json.Encoder
+}
+
+// RejectJSON is a struct that represents a quarantined record in JSON format
+type RejectJSON struct {
+	Record int             `json:"record"`
+	Error  string          `json:"error"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Write is a method that writes a quarantined record
+func (r *rejects) Write(index int, raw json.RawMessage, reason error) (err error) {
+	if r.file == nil {
+		r.file, err = os.OpenFile(r.path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return
+		}
+		r.bw = bufio.NewWriter(r.file)
+		r.ew = nopWriteCloser{r.bw}
+		if r.keyring != nil {
+			if r.ew, err = encrypt(r.bw, r.keyring); err != nil {
+				return
+			}
+		}
+		r.enc = json.NewEncoder(r.ew)
 	}
+	err = r.enc.Encode(RejectJSON{Record: index, Error: reason.Error(), Data: raw})
+	return
+}
 
-	err = json.NewEncoder(f).Encode(vehiclesJSON)
-	if err != nil {
-		fmt.Println("error encoding file: ", l.path)
+// Close is a method that seals and closes the file if it was created
+func (r *rejects) Close() (err error) {
+	if r.file == nil {
 		return
 	}
+	if r.ew != nil {
+		err = r.ew.Close()
+	}
+	if r.bw != nil && err == nil {
+		err = r.bw.Flush()
+	}
+	if fErr := r.file.Close(); err == nil {
+		err = fErr
+	}
+	return
+}
+
+// Save is a method that saves the vehicles in the current schema version, ordered by id, without an outbox
+func (l *VehicleJSONFile) Save(v map[int]internal.Vehicle) (err error) {
+	err = l.SaveWithOutbox(v, nil)
+	return
+}
+
+// SaveWithOutbox is a method that saves the vehicles in the current schema version, ordered by id, followed by the outbox.
+// The file is written as described by writeFile, so a file encrypted with a retired key is re-encrypted with the current one
+func (l *VehicleJSONFile) SaveWithOutbox(v map[int]internal.Vehicle, o []internal.OutboxEntry) (err error) {
+	ids := make([]int, 0, len(v))
+	for key := range v {
+		ids = append(ids, key)
+	}
+	sort.Ints(ids)
+
+	err = writeFile(l.path, l.keyring, func(w io.Writer) (err error) {
+		dw := &documentWriter{w: w}
+		if err = dw.Begin(); err != nil {
+			return
+		}
+		for _, id := range ids {
+			if err = dw.Record(newVehicleJSON(v[id])); err != nil {
+				fmt.Println("error encoding file: ", l.path)
+				return
+			}
+		}
+		if len(o) > 0 {
+			entries := make([]OutboxEntryJSON, 0, len(o))
+			for _, value := range o {
+				entries = append(entries, newOutboxEntryJSON(value))
+			}
+			dw.Member(outboxMember, entries)
+		}
+		err = dw.End()
+		return
+	})
 	return
 }
@@ -0,0 +1,45 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// writeTestFile is a function that writes a vehicles file in a temporary directory and returns its path
+func writeTestFile(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vehicles.json")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// testRecord is a function that returns a valid record of a vehicle in JSON format
+func testRecord(id string) string {
+	return `{"id":` + id + `,"brand":"Ford","model":"Transit","year":2020,"passengers":3}`
+}
+
+func TestVehicleJSONFile_Load_Duplicates(t *testing.T) {
+	path := writeTestFile(t, "["+testRecord("1")+","+testRecord("4")+","+testRecord("1")+","+testRecord("4")+","+testRecord("1")+"]")
+	for _, policy := range []InvalidPolicy{InvalidFail, InvalidSkip, InvalidQuarantine} {
+		t.Run(string(policy), func(t *testing.T) {
+			ld := NewVehicleJSONFileWithConfig(&ConfigVehicleJSONFile{Path: path, OnInvalid: policy})
+			v, err := ld.Load()
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			if len(v) != 2 {
+				t.Fatalf("loaded %d vehicles, want 2", len(v))
+			}
+			want := internal.LoadReport{Records: 5, Accepted: 2, Rejected: 3, Duplicates: []int{1, 4}, Gaps: []internal.IDRange{{From: 2, To: 3}}, MaxID: 4, SchemaVersion: 1}
+			if r := ld.Report(); !reflect.DeepEqual(r, want) {
+				t.Fatalf("report: got %+v, want %+v", r, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package loader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// WebhookSchemaVersion is the version of the webhook files written by Save
+const WebhookSchemaVersion = 1
+
+// ConfigWebhookJSONFile is a struct that represents the configuration for WebhookJSONFile
+type ConfigWebhookJSONFile struct {
+	// Path is the path to the file that contains the webhooks in JSON format
+	Path string
+	// Keyring is the keyring of encrypted files, files are saved encrypted with its current key when set
+	Keyring *Keyring
+}
+
+// NewWebhookJSONFile is a function that returns a new instance of WebhookJSONFile
+func NewWebhookJSONFile(cfg *ConfigWebhookJSONFile) *WebhookJSONFile {
+	return &WebhookJSONFile{path: cfg.Path, keyring: cfg.Keyring}
+}
+
+// WebhookJSONFile is a struct that implements the WebhookLoader interface
+type WebhookJSONFile struct {
+	// path is the path to the file that contains the webhooks in JSON format
+	path string
+	// keyring is the keyring of encrypted files, nil saves them in plaintext
+	keyring *Keyring
+}
+
+// WebhookFileJSON is a struct that represents a webhook file in JSON format
+type WebhookFileJSON struct {
+	SchemaVersion int                     `json:"schema_version"`
+	Webhooks      []WebhookJSON           `json:"webhooks"`
+	Deliveries    []WebhookDeliveryJSON   `json:"deliveries"`
+	DeadLetters   []WebhookDeadLetterJSON `json:"dead_letters"`
+}
+
+// WebhookJSON is a struct that represents a webhook in JSON format
+type WebhookJSON struct {
+	ID        int       `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret"`
+	Events    []string  `json:"events,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookDeliveryJSON is a struct that represents an attempt to deliver an event in JSON format
+type WebhookDeliveryJSON struct {
+	ID         int       `json:"id"`
+	WebhookID  int       `json:"webhook_id"`
+	EventID    string    `json:"event_id"`
+	EventType  string    `json:"event_type"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	At         time.Time `json:"at"`
+}
+
+// WebhookDeadLetterJSON is a struct that represents a dead letter in JSON format
+type WebhookDeadLetterJSON struct {
+	ID        int             `json:"id"`
+	WebhookID int             `json:"webhook_id"`
+	EventID   string          `json:"event_id"`
+	EventType string          `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"last_error"`
+	FailedAt  time.Time       `json:"failed_at"`
+}
+
+// Load is a method that loads the webhooks, delivery logs and dead letters, a missing file holds none
+func (l *WebhookJSONFile) Load() (d internal.WebhookData, err error) {
+	d = internal.WebhookData{
+		Webhooks:    make(map[int]internal.Webhook),
+		Deliveries:  make([]internal.WebhookDelivery, 0),
+		DeadLetters: make(map[int]internal.WebhookDeadLetter),
+	}
+
+	// open file
+	r, _, err := readFile(l.path, l.keyring)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+	defer r.Close()
+
+	// decode file
+	var file WebhookFileJSON
+	if err = json.NewDecoder(r).Decode(&file); err != nil {
+		err = fmt.Errorf("error decoding webhook file: %w", err)
+		return
+	}
+	if file.SchemaVersion < 1 || file.SchemaVersion > WebhookSchemaVersion {
+		err = fmt.Errorf("%w: %d, the greatest supported is %d", ErrSchemaVersionUnsupported, file.SchemaVersion, WebhookSchemaVersion)
+		return
+	}
+
+	// serialize webhooks, delivery logs and dead letters
+	for i, value := range file.Webhooks {
+		w := internal.Webhook{ID: value.ID, URL: value.URL, Secret: value.Secret, CreatedAt: value.CreatedAt.UTC()}
+		for _, t := range value.Events {
+			w.Events = append(w.Events, internal.EventType(t))
+		}
+		if err = w.Validate(); err != nil {
+			err = fmt.Errorf("error loading webhook %d: %w", i, err)
+			return
+		}
+		d.Webhooks[w.ID] = w
+	}
+	for _, value := range file.Deliveries {
+		d.Deliveries = append(d.Deliveries, internal.WebhookDelivery{
+			ID:         value.ID,
+			WebhookID:  value.WebhookID,
+			EventID:    value.EventID,
+			EventType:  internal.EventType(value.EventType),
+			Attempt:    value.Attempt,
+			StatusCode: value.StatusCode,
+			Error:      value.Error,
+			Duration:   time.Duration(value.DurationMs) * time.Millisecond,
+			At:         value.At.UTC(),
+		})
+	}
+	sort.SliceStable(d.Deliveries, func(i, j int) bool { return d.Deliveries[i].ID < d.Deliveries[j].ID })
+	for _, value := range file.DeadLetters {
+		d.DeadLetters[value.ID] = internal.WebhookDeadLetter{
+			ID:        value.ID,
+			WebhookID: value.WebhookID,
+			EventID:   value.EventID,
+			EventType: internal.EventType(value.EventType),
+			Payload:   []byte(value.Payload),
+			Attempts:  value.Attempts,
+			LastError: value.LastError,
+			FailedAt:  value.FailedAt.UTC(),
+		}
+	}
+	return
+}
+
+// Save is a method that saves the webhooks, delivery logs and dead letters ordered by id, the file is written as described by writeFile
+func (l *WebhookJSONFile) Save(d internal.WebhookData) (err error) {
+	file := WebhookFileJSON{
+		SchemaVersion: WebhookSchemaVersion,
+		Webhooks:      make([]WebhookJSON, 0, len(d.Webhooks)),
+		Deliveries:    make([]WebhookDeliveryJSON, 0, len(d.Deliveries)),
+		DeadLetters:   make([]WebhookDeadLetterJSON, 0, len(d.DeadLetters)),
+	}
+	for _, value := range d.Webhooks {
+		w := WebhookJSON{ID: value.ID, URL: value.URL, Secret: value.Secret, CreatedAt: value.CreatedAt}
+		for _, t := range value.Events {
+			w.Events = append(w.Events, string(t))
+		}
+		file.Webhooks = append(file.Webhooks, w)
+	}
+	sort.Slice(file.Webhooks, func(i, j int) bool { return file.Webhooks[i].ID < file.Webhooks[j].ID })
+	for _, value := range d.Deliveries {
+		file.Deliveries = append(file.Deliveries, WebhookDeliveryJSON{
+			ID:         value.ID,
+			WebhookID:  value.WebhookID,
+			EventID:    value.EventID,
+			EventType:  string(value.EventType),
+			Attempt:    value.Attempt,
+			StatusCode: value.StatusCode,
+			Error:      value.Error,
+			DurationMs: value.Duration.Milliseconds(),
+			At:         value.At,
+		})
+	}
+	for _, value := range d.DeadLetters {
+		file.DeadLetters = append(file.DeadLetters, WebhookDeadLetterJSON{
+			ID:        value.ID,
+			WebhookID: value.WebhookID,
+			EventID:   value.EventID,
+			EventType: string(value.EventType),
+			Payload:   json.RawMessage(value.Payload),
+			Attempts:  value.Attempts,
+			LastError: value.LastError,
+			FailedAt:  value.FailedAt,
+		})
+	}
+	sort.Slice(file.DeadLetters, func(i, j int) bool { return file.DeadLetters[i].ID < file.DeadLetters[j].ID })
+
+	err = writeFile(l.path, l.keyring, func(w io.Writer) (err error) {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(file)
+		return
+	})
+	return
+}
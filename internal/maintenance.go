@@ -0,0 +1,171 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrMaintenanceInvalid is an error that represents a maintenance record or rule with invalid attributes
+	ErrMaintenanceInvalid = errors.New("maintenance invalid")
+	// ErrMaintenanceRuleNotFound is an error that represents a schedule rule not found
+	ErrMaintenanceRuleNotFound = errors.New("maintenance rule not found")
+)
+
+// MaintenanceType is the kind of work done on a vehicle
+type MaintenanceType string
+
+const (
+	// MaintenanceTypeService is a scheduled service, e.g. an oil change
+	MaintenanceTypeService MaintenanceType = "service"
+	// MaintenanceTypeRepair is the repair of a fault
+	MaintenanceTypeRepair MaintenanceType = "repair"
+	// MaintenanceTypeInspection is an inspection, e.g. a roadworthiness test
+	MaintenanceTypeInspection MaintenanceType = "inspection"
+)
+
+// Valid is a method that reports whether the type is known
+func (t MaintenanceType) Valid() bool {
+	switch t {
+	case MaintenanceTypeService, MaintenanceTypeRepair, MaintenanceTypeInspection:
+		return true
+	}
+	return false
+}
+
+// MaintenanceRecord is a struct that represents work done, or planned, on a vehicle
+type MaintenanceRecord struct {
+	// ID is the unique identifier of the record
+	ID int
+	// VehicleID is the id of the vehicle
+	VehicleID int
+	// Type is the kind of work
+	Type MaintenanceType
+	// RuleID is the id of the schedule rule the work fulfils, zero when it fulfils the rules of its type
+	RuleID int
+	// Date is the day the work started
+	Date time.Time
+	// EndDate is the day the work ended, zero when it took a single day
+	EndDate time.Time
+	// Odometer is the reading of the odometer in km
+	Odometer int
+	// Cost is the cost of the work
+	Cost float64
+	// Notes is a free text description of the work
+	Notes string
+}
+
+// End is a method that returns the last day of the work
+func (r MaintenanceRecord) End() time.Time {
+	if r.EndDate.IsZero() {
+		return r.Date
+	}
+	return r.EndDate
+}
+
+// Validate is a method that checks the attributes of a record are consistent
+func (r MaintenanceRecord) Validate() (err error) {
+	switch {
+	case r.VehicleID <= 0:
+		err = fmt.Errorf("%w: vehicle id must be positive", ErrMaintenanceInvalid)
+	case !r.Type.Valid():
+		err = fmt.Errorf("%w: type %q is unknown", ErrMaintenanceInvalid, r.Type)
+	case r.Date.IsZero():
+		err = fmt.Errorf("%w: date is required", ErrMaintenanceInvalid)
+	case !r.EndDate.IsZero() && r.EndDate.Before(r.Date):
+		err = fmt.Errorf("%w: end date must not be before date", ErrMaintenanceInvalid)
+	case r.Odometer < 0:
+		err = fmt.Errorf("%w: odometer must not be negative", ErrMaintenanceInvalid)
+	case r.Cost < 0 || math.IsNaN(r.Cost) || math.IsInf(r.Cost, 0):
+		err = fmt.Errorf("%w: cost must be a non-negative number", ErrMaintenanceInvalid)
+	}
+	return
+}
+
+// MaintenanceRule is a struct that represents a service due every so many km or months for the vehicles of a brand and model
+type MaintenanceRule struct {
+	// ID is the unique identifier of the rule
+	ID int
+	// Name is the name of the service, a rule overrides the less specific rules with the same name
+	Name string
+	// Type is the kind of work
+	Type MaintenanceType
+	// Brand is the brand of the vehicles, empty for every brand
+	Brand string
+	// Model is the model of the vehicles, empty for every model
+	Model string
+	// EveryKm is the distance between services, zero when it is not due by distance
+	EveryKm int
+	// EveryMonths is the time between services, zero when it is not due by time
+	EveryMonths int
+}
+
+// Validate is a method that checks the attributes of a rule are consistent
+func (r MaintenanceRule) Validate() (err error) {
+	switch {
+	case strings.TrimSpace(r.Name) == "":
+		err = fmt.Errorf("%w: name is required", ErrMaintenanceInvalid)
+	case !r.Type.Valid():
+		err = fmt.Errorf("%w: type %q is unknown", ErrMaintenanceInvalid, r.Type)
+	case r.Brand == "" && r.Model != "":
+		err = fmt.Errorf("%w: a model requires a brand", ErrMaintenanceInvalid)
+	case r.EveryKm < 0 || r.EveryMonths < 0:
+		err = fmt.Errorf("%w: intervals must not be negative", ErrMaintenanceInvalid)
+	case r.EveryKm == 0 && r.EveryMonths == 0:
+		err = fmt.Errorf("%w: every_km or every_months is required", ErrMaintenanceInvalid)
+	}
+	return
+}
+
+// Matches is a method that reports whether the rule applies to a vehicle
+func (r MaintenanceRule) Matches(v Vehicle) bool {
+	return (r.Brand == "" || strings.EqualFold(r.Brand, v.Brand)) && (r.Model == "" || strings.EqualFold(r.Model, v.Model))
+}
+
+// Specificity is a method that returns how specific the rule is, a rule for a model is more specific than one for a brand
+func (r MaintenanceRule) Specificity() int {
+	switch {
+	case r.Model != "":
+		return 2
+	case r.Brand != "":
+		return 1
+	}
+	return 0
+}
+
+// Fulfils is a method that reports whether a record fulfils the rule
+func (r MaintenanceRule) Fulfils(rec MaintenanceRecord) bool {
+	if rec.RuleID != 0 {
+		return rec.RuleID == r.ID
+	}
+	return rec.Type == r.Type
+}
+
+// MaintenanceDue is a struct that represents a service of a vehicle that is due
+type MaintenanceDue struct {
+	// VehicleID is the id of the vehicle
+	VehicleID int
+	// Rule is the rule of the service
+	Rule MaintenanceRule
+	// Last is the last record fulfilling the rule, nil when the service was never done
+	Last *MaintenanceRecord
+	// DueDate is the day the service is due, zero when it is not due by time or was never done
+	DueDate time.Time
+	// DueOdometer is the reading at which the service is due, zero when it is not due by distance or was never done
+	DueOdometer int
+	// Odometer is the last known reading of the vehicle
+	Odometer int
+	// Overdue reports whether the service is past its date or distance, or was never done
+	Overdue bool
+}
+
+// MaintenanceData is a struct that represents the maintenance records and rules persisted together
+type MaintenanceData struct {
+	// Records is the set of records by id
+	Records map[int]MaintenanceRecord
+	// Rules is the set of rules by id
+	Rules map[int]MaintenanceRule
+}
@@ -0,0 +1,10 @@
+package internal
+
+// MaintenanceLoader is an interface that represents the loader for maintenance records and rules
+type MaintenanceLoader interface {
+	// Load is a method that loads the records and rules
+	Load() (d MaintenanceData, err error)
+
+	// Save is a method that saves the records and rules
+	Save(d MaintenanceData) (err error)
+}
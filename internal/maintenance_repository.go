@@ -0,0 +1,22 @@
+package internal
+
+// MaintenanceRepository is an interface that represents a repository of maintenance records and schedule rules
+type MaintenanceRepository interface {
+	// FindAll is a method that returns every record
+	FindAll() (r []MaintenanceRecord, err error)
+
+	// FindByVehicle is a method that returns the records of a vehicle, oldest first
+	FindByVehicle(vehicleID int) (r []MaintenanceRecord, err error)
+
+	// Create is a method that creates a record
+	Create(r *MaintenanceRecord) (err error)
+
+	// FindRules is a method that returns every schedule rule
+	FindRules() (r []MaintenanceRule, err error)
+
+	// FindRule is a method that returns a schedule rule by id
+	FindRule(id int) (r MaintenanceRule, err error)
+
+	// CreateRule is a method that creates a schedule rule
+	CreateRule(r *MaintenanceRule) (err error)
+}
@@ -0,0 +1,21 @@
+package internal
+
+import "time"
+
+// MaintenanceService is an interface that represents a service of maintenance records and schedules
+type MaintenanceService interface {
+	// FindByVehicle is a method that returns the records of a vehicle, oldest first
+	FindByVehicle(vehicleID int) (r []MaintenanceRecord, err error)
+
+	// Create is a method that creates a record of an existing vehicle
+	Create(r *MaintenanceRecord) (err error)
+
+	// FindRules is a method that returns every schedule rule
+	FindRules() (r []MaintenanceRule, err error)
+
+	// CreateRule is a method that creates a schedule rule
+	CreateRule(r *MaintenanceRule) (err error)
+
+	// Due is a method that returns the services overdue at now or due before the given time, soonest first
+	Due(now, before time.Time) (d []MaintenanceDue, err error)
+}
@@ -0,0 +1,19 @@
+package internal
+
+import "context"
+
+// Notification is a struct that represents a message to the people in charge of the fleet
+type Notification struct {
+	// Subject is the subject of the message
+	Subject string
+	// Body is the message in plain text
+	Body string
+	// Reminders is the list of the reminders the message is about
+	Reminders []ComplianceReminder
+}
+
+// Notifier is an interface that represents a channel notifications are delivered through
+type Notifier interface {
+	// Notify is a method that delivers a notification, it fails when the notification may not have been delivered
+	Notify(ctx context.Context, n Notification) (err error)
+}
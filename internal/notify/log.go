@@ -0,0 +1,34 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// NewLog is a function that returns a new instance of Log, the standard logger is used when lg is nil
+func NewLog(lg *log.Logger) *Log {
+	if lg == nil {
+		lg = log.Default()
+	}
+	return &Log{lg: lg}
+}
+
+// Log is a struct that implements the Notifier interface by writing notifications to a logger
+type Log struct {
+	// lg is the logger
+	lg *log.Logger
+}
+
+// Notify is a method that writes a notification to the logger, a line for the subject and one for each line of the body
+func (n *Log) Notify(ctx context.Context, nt internal.Notification) (err error) {
+	n.lg.Printf("notification: %s", nt.Subject)
+	for _, line := range strings.Split(strings.TrimSpace(nt.Body), "\n") {
+		if line != "" {
+			n.lg.Printf("notification: %s", line)
+		}
+	}
+	return
+}
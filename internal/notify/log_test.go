@@ -0,0 +1,24 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"testing"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+func TestLog_Notify(t *testing.T) {
+	var b bytes.Buffer
+	nt := internal.Notification{Subject: "2 documents expire soon", Body: "\n- vehicle 7 insurance\n\n- vehicle 9 inspection\n"}
+	if err := NewLog(log.New(&b, "", 0)).Notify(context.Background(), nt); err != nil {
+		t.Fatal(err)
+	}
+	want := "notification: 2 documents expire soon\n" +
+		"notification: - vehicle 7 insurance\n" +
+		"notification: - vehicle 9 inspection\n"
+	if b.String() != want {
+		t.Errorf("got %q, want %q", b.String(), want)
+	}
+}
@@ -0,0 +1,23 @@
+package notify
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// Multi is a type that implements the Notifier interface by delivering notifications through several notifiers
+type Multi []internal.Notifier
+
+// Notify is a method that delivers a notification through every notifier, even when some of them fail, and returns their errors joined
+func (m Multi) Notify(ctx context.Context, n internal.Notification) (err error) {
+	errs := make([]error, 0)
+	for _, nt := range m {
+		if e := nt.Notify(ctx, n); e != nil {
+			errs = append(errs, e)
+		}
+	}
+	err = errors.Join(errs...)
+	return
+}
@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// notifierFunc is a function that implements the Notifier interface
+type notifierFunc func(ctx context.Context, n internal.Notification) error
+
+func (f notifierFunc) Notify(ctx context.Context, n internal.Notification) error {
+	return f(ctx, n)
+}
+
+func TestMulti_Notify(t *testing.T) {
+	errMail := errors.New("mail server down")
+	errHook := errors.New("webhook down")
+	var delivered []string
+	m := Multi{
+		notifierFunc(func(ctx context.Context, n internal.Notification) error { return errMail }),
+		notifierFunc(func(ctx context.Context, n internal.Notification) error {
+			delivered = append(delivered, n.Subject)
+			return nil
+		}),
+		notifierFunc(func(ctx context.Context, n internal.Notification) error { return errHook }),
+	}
+
+	err := m.Notify(context.Background(), internal.Notification{Subject: "s"})
+	if !errors.Is(err, errMail) || !errors.Is(err, errHook) {
+		t.Errorf("got error %v, want both errors joined", err)
+	}
+	if len(delivered) != 1 {
+		t.Errorf("got %d deliveries after a failure, want 1", len(delivered))
+	}
+	if err := (Multi{}).Notify(context.Background(), internal.Notification{}); err != nil {
+		t.Errorf("got error %v without notifiers, want none", err)
+	}
+}
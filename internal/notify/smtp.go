@@ -0,0 +1,185 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// defaultSMTPTimeout is the time a mail server is given to take a message by default
+const defaultSMTPTimeout = 30 * time.Second
+
+// ConfigSMTP is a struct that represents the configuration for SMTP
+type ConfigSMTP struct {
+	// Addr is the host:port of the mail server
+	Addr string
+	// From is the address messages are sent from
+	From string
+	// To is the list of the addresses messages are sent to
+	To []string
+	// Username is the username of the PLAIN authentication, empty to send without authenticating
+	Username string
+	// Password is the password of the PLAIN authentication
+	Password string
+	// Timeout is the time the server is given to take a message, 30 seconds by default
+	Timeout time.Duration
+}
+
+// NewSMTP is a function that returns a new instance of SMTP, it fails when an address is invalid
+func NewSMTP(cfg *ConfigSMTP) (n *SMTP, err error) {
+	host, _, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		err = fmt.Errorf("invalid smtp address %q: %w", cfg.Addr, err)
+		return
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		err = fmt.Errorf("invalid smtp sender %q: %w", cfg.From, err)
+		return
+	}
+	if len(cfg.To) == 0 {
+		err = errors.New("smtp requires at least one recipient")
+		return
+	}
+	to := make([]*mail.Address, 0, len(cfg.To))
+	for _, value := range cfg.To {
+		var addr *mail.Address
+		if addr, err = mail.ParseAddress(value); err != nil {
+			err = fmt.Errorf("invalid smtp recipient %q: %w", value, err)
+			return
+		}
+		to = append(to, addr)
+	}
+	n = &SMTP{addr: cfg.Addr, host: host, from: from, to: to, username: cfg.Username, password: cfg.Password, timeout: cfg.Timeout}
+	if n.timeout <= 0 {
+		n.timeout = defaultSMTPTimeout
+	}
+	return
+}
+
+// SMTP is a struct that implements the Notifier interface by mailing notifications in plain text.
+// The connection is upgraded with STARTTLS when the server offers it, and credentials are only sent over TLS or to localhost
+type SMTP struct {
+	// addr is the host:port of the mail server
+	addr string
+	// host is the host of the mail server, the name its certificate is verified for
+	host string
+	// from is the address messages are sent from
+	from *mail.Address
+	// to is the list of the addresses messages are sent to
+	to []*mail.Address
+	// username is the username of the PLAIN authentication
+	username string
+	// password is the password of the PLAIN authentication
+	password string
+	// timeout is the time the server is given to take a message
+	timeout time.Duration
+}
+
+// Notify is a method that mails a notification to every recipient
+func (n *SMTP) Notify(ctx context.Context, nt internal.Notification) (err error) {
+	msg, err := n.message(nt)
+	if err != nil {
+		return
+	}
+
+	// connect
+	d := net.Dialer{Timeout: n.timeout}
+	conn, err := d.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		err = fmt.Errorf("error connecting to smtp server: %w", err)
+		return
+	}
+	deadline := time.Now().Add(n.timeout)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	if err = conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return
+	}
+	c, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		conn.Close()
+		err = fmt.Errorf("error connecting to smtp server: %w", err)
+		return
+	}
+	defer c.Close()
+
+	// send
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err = c.StartTLS(&tls.Config{ServerName: n.host}); err != nil {
+			err = fmt.Errorf("error starting tls: %w", err)
+			return
+		}
+	}
+	if n.username != "" {
+		if err = c.Auth(smtp.PlainAuth("", n.username, n.password, n.host)); err != nil {
+			err = fmt.Errorf("error authenticating to smtp server: %w", err)
+			return
+		}
+	}
+	if err = c.Mail(n.from.Address); err != nil {
+		err = fmt.Errorf("error sending mail: %w", err)
+		return
+	}
+	for _, value := range n.to {
+		if err = c.Rcpt(value.Address); err != nil {
+			err = fmt.Errorf("error sending mail to %s: %w", value.Address, err)
+			return
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		err = fmt.Errorf("error sending mail: %w", err)
+		return
+	}
+	if _, err = w.Write(msg); err != nil {
+		err = fmt.Errorf("error sending mail: %w", err)
+		return
+	}
+	if err = w.Close(); err != nil {
+		err = fmt.Errorf("error sending mail: %w", err)
+		return
+	}
+	err = c.Quit()
+	return
+}
+
+// message is a method that returns a notification as a message with CRLF line endings and a quoted-printable body
+func (n *SMTP) message(nt internal.Notification) (msg []byte, err error) {
+	to := make([]string, 0, len(n.to))
+	for _, value := range n.to {
+		to = append(to, value.String())
+	}
+	subject := strings.Join(strings.Fields(nt.Subject), " ")
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", n.from.String())
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	qp := quotedprintable.NewWriter(&b)
+	if _, err = qp.Write([]byte(strings.ReplaceAll(nt.Body, "\n", "\r\n"))); err != nil {
+		return
+	}
+	if err = qp.Close(); err != nil {
+		return
+	}
+	msg = b.Bytes()
+	return
+}
@@ -0,0 +1,270 @@
+package notify
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// fakeMail is a struct that represents a message taken by the fake mail server
+type fakeMail struct {
+	// auth is the identity, username and password of the PLAIN authentication, empty without one
+	auth string
+	// from is the sender of the envelope
+	from string
+	// to is the list of the recipients of the envelope
+	to []string
+	// data is the message
+	data string
+}
+
+// fakeSMTP is a struct that represents a mail server on a local port, speaking just enough SMTP for net/smtp
+type fakeSMTP struct {
+	// addr is the host:port the server listens on
+	addr string
+	// reject is the recipient the server refuses
+	reject string
+	// mu guards mails
+	mu sync.Mutex
+	// mails is the list of the messages taken
+	mails []fakeMail
+}
+
+// newFakeSMTP is a function that starts a fake mail server refusing a recipient, closed as the test ends
+func newFakeSMTP(t *testing.T, reject string) *fakeSMTP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTP{addr: ln.Addr().String(), reject: reject}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.serve(conn)
+			}()
+		}
+	}()
+	t.Cleanup(func() {
+		ln.Close()
+		wg.Wait()
+	})
+	return s
+}
+
+// serve is a method that speaks SMTP on a connection until the client quits or hangs up
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	c := textproto.NewConn(conn)
+	var m fakeMail
+	_ = c.PrintfLine("220 localhost ESMTP fake")
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			_ = c.PrintfLine("250-localhost")
+			_ = c.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			raw, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(arg, "PLAIN "))
+			m.auth = string(raw)
+			_ = c.PrintfLine("235 2.7.0 authenticated")
+		case "MAIL":
+			m.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			_ = c.PrintfLine("250 2.1.0 ok")
+		case "RCPT":
+			to := strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>")
+			if to == s.reject {
+				_ = c.PrintfLine("550 5.1.1 no such user")
+				continue
+			}
+			m.to = append(m.to, to)
+			_ = c.PrintfLine("250 2.1.5 ok")
+		case "DATA":
+			_ = c.PrintfLine("354 end data with <CR><LF>.<CR><LF>")
+			// - the lines are read one by one, as a dot reader would turn the CRLF line endings into LF
+			var data []string
+			for {
+				line, err := c.ReadLine()
+				if err != nil {
+					return
+				}
+				if line == "." {
+					break
+				}
+				data = append(data, strings.TrimPrefix(line, "."))
+			}
+			m.data = strings.Join(data, "\r\n") + "\r\n"
+			s.mu.Lock()
+			s.mails = append(s.mails, m)
+			s.mu.Unlock()
+			m = fakeMail{auth: m.auth}
+			_ = c.PrintfLine("250 2.0.0 queued")
+		case "RSET", "NOOP":
+			_ = c.PrintfLine("250 2.0.0 ok")
+		case "QUIT":
+			_ = c.PrintfLine("221 2.0.0 bye")
+			return
+		default:
+			_ = c.PrintfLine("502 5.5.2 command not recognized")
+		}
+	}
+}
+
+// taken is a method that returns the messages taken by the server
+func (s *fakeSMTP) taken() []fakeMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]fakeMail(nil), s.mails...)
+}
+
+func TestSMTP_Notify(t *testing.T) {
+	srv := newFakeSMTP(t, "")
+	n, err := NewSMTP(&ConfigSMTP{
+		Addr:     srv.addr,
+		From:     "Fleet <fleet@example.com>",
+		To:       []string{"ops@example.com", "José Núñez <jose@example.com>"},
+		Username: "fleet",
+		Password: "s3cret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := "2 documents expire soon:\n" +
+		"- vehicle 7 (1234-ABC) insurance POL-1 expires on 2026-11-01, in 13 days, with a premium of 1.000 € = too much for a single line of text\n" +
+		"- vehicle 9 (5678-DEF) inspection expired on 2026-10-15\n"
+	if err := n.Notify(context.Background(), internal.Notification{Subject: "Compliance:  2 documents\n expiring — soon", Body: body}); err != nil {
+		t.Fatal(err)
+	}
+
+	m := srv.taken()
+	if len(m) != 1 {
+		t.Fatalf("got %d messages taken, want 1", len(m))
+	}
+	// - the envelope
+	if m[0].auth != "\x00fleet\x00s3cret" {
+		t.Errorf("got authentication %q, want the username and password", m[0].auth)
+	}
+	if m[0].from != "fleet@example.com" {
+		t.Errorf("got sender %q, want fleet@example.com", m[0].from)
+	}
+	if want := []string{"ops@example.com", "jose@example.com"}; !reflect.DeepEqual(m[0].to, want) {
+		t.Errorf("got recipients %v, want %v", m[0].to, want)
+	}
+
+	// - the message
+	msg, err := mail.ReadMessage(strings.NewReader(m[0].data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != "Compliance: 2 documents expiring — soon" {
+		t.Errorf("got subject %q, want it on a line with the spaces collapsed", subject)
+	}
+	to, err := msg.Header.AddressList("To")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(to) != 2 || to[1].Name != "José Núñez" || to[1].Address != "jose@example.com" {
+		t.Errorf("got To header %v, want both recipients with their names", to)
+	}
+	if got := msg.Header.Get("Content-Transfer-Encoding"); got != "quoted-printable" {
+		t.Errorf("got Content-Transfer-Encoding %q, want quoted-printable", got)
+	}
+	if _, err := msg.Header.Date(); err != nil {
+		t.Errorf("got an invalid Date header: %v", err)
+	}
+	_, raw, _ := strings.Cut(m[0].data, "\r\n\r\n")
+	for _, line := range strings.Split(raw, "\r\n") {
+		if len(line) > 76 {
+			t.Errorf("got a line of the body of %d characters, want at most 76: %q", len(line), line)
+		}
+	}
+	decoded, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.ReplaceAll(body, "\n", "\r\n"); string(decoded) != want {
+		t.Errorf("got body %q, want %q", decoded, want)
+	}
+}
+
+func TestSMTP_Notify_Errors(t *testing.T) {
+	t.Run("recipient refused", func(t *testing.T) {
+		srv := newFakeSMTP(t, "gone@example.com")
+		n, err := NewSMTP(&ConfigSMTP{Addr: srv.addr, From: "fleet@example.com", To: []string{"ops@example.com", "gone@example.com"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = n.Notify(context.Background(), internal.Notification{Subject: "s", Body: "b"})
+		if err == nil || !strings.Contains(err.Error(), "gone@example.com") {
+			t.Fatalf("got error %v, want the recipient refused", err)
+		}
+		if m := srv.taken(); len(m) != 0 {
+			t.Errorf("got %d messages taken, want none", len(m))
+		}
+	})
+
+	t.Run("server down", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr := ln.Addr().String()
+		ln.Close()
+		n, err := NewSMTP(&ConfigSMTP{Addr: addr, From: "fleet@example.com", To: []string{"ops@example.com"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = n.Notify(context.Background(), internal.Notification{Subject: "s", Body: "b"})
+		if err == nil || !strings.Contains(err.Error(), "connecting") {
+			t.Fatalf("got error %v, want the connection refused", err)
+		}
+	})
+}
+
+func TestNewSMTP_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ConfigSMTP
+	}{
+		{name: "address without port", cfg: ConfigSMTP{Addr: "mail.example.com", From: "fleet@example.com", To: []string{"ops@example.com"}}},
+		{name: "invalid sender", cfg: ConfigSMTP{Addr: "mail.example.com:25", From: "fleet", To: []string{"ops@example.com"}}},
+		{name: "no recipient", cfg: ConfigSMTP{Addr: "mail.example.com:25", From: "fleet@example.com"}},
+		{name: "invalid recipient", cfg: ConfigSMTP{Addr: "mail.example.com:25", From: "fleet@example.com", To: []string{"ops@example.com", "ops"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSMTP(&tt.cfg); err == nil {
+				t.Fatal("got no error, want the configuration refused")
+			}
+		})
+	}
+}
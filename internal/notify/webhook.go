@@ -0,0 +1,101 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// defaultWebhookTimeout is the time a webhook is given to respond by default
+const defaultWebhookTimeout = 10 * time.Second
+
+// WebhookJSON is a struct that represents the body posted to a webhook
+type WebhookJSON struct {
+	Subject   string                `json:"subject"`
+	Body      string                `json:"body"`
+	Reminders []WebhookReminderJSON `json:"reminders"`
+}
+
+// WebhookReminderJSON is a struct that represents a reminder in the body posted to a webhook
+type WebhookReminderJSON struct {
+	ItemID       int    `json:"item_id"`
+	VehicleID    int    `json:"vehicle_id"`
+	Registration string `json:"registration"`
+	Kind         string `json:"kind"`
+	Reference    string `json:"reference,omitempty"`
+	Provider     string `json:"provider,omitempty"`
+	Expires      string `json:"expires"`
+	DaysLeft     int    `json:"days_left"`
+	Window       int    `json:"window"`
+}
+
+// ConfigWebhook is a struct that represents the configuration for Webhook
+type ConfigWebhook struct {
+	// URL is the URL notifications are posted to
+	URL string
+	// Client is the client of the requests, a client with a timeout of 10 seconds by default
+	Client *http.Client
+}
+
+// NewWebhook is a function that returns a new instance of Webhook
+func NewWebhook(cfg *ConfigWebhook) *Webhook {
+	cl := cfg.Client
+	if cl == nil {
+		cl = &http.Client{Timeout: defaultWebhookTimeout}
+	}
+	return &Webhook{url: cfg.URL, cl: cl}
+}
+
+// Webhook is a struct that implements the Notifier interface by posting notifications in JSON format to a URL
+type Webhook struct {
+	// url is the URL notifications are posted to
+	url string
+	// cl is the client of the requests
+	cl *http.Client
+}
+
+// Notify is a method that posts a notification, it fails unless the webhook responds with a 2xx status
+func (n *Webhook) Notify(ctx context.Context, nt internal.Notification) (err error) {
+	body := WebhookJSON{Subject: nt.Subject, Body: nt.Body, Reminders: make([]WebhookReminderJSON, 0, len(nt.Reminders))}
+	for _, value := range nt.Reminders {
+		i := value.Expiry.Item
+		body.Reminders = append(body.Reminders, WebhookReminderJSON{
+			ItemID:       i.ID,
+			VehicleID:    i.VehicleID,
+			Registration: value.Expiry.Registration,
+			Kind:         string(i.Kind),
+			Reference:    i.Reference,
+			Provider:     i.Provider,
+			Expires:      i.Expires.Format("2006-01-02"),
+			DaysLeft:     value.Expiry.DaysLeft,
+			Window:       value.Window,
+		})
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := n.cl.Do(req)
+	if err != nil {
+		err = fmt.Errorf("error posting notification: %w", err)
+		return
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		err = fmt.Errorf("error posting notification: webhook responded %s", res.Status)
+	}
+	return
+}
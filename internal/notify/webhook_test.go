@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+func TestWebhook_Notify(t *testing.T) {
+	var got WebhookJSON
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("got %s with Content-Type %q, want a POST of JSON", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	nt := internal.Notification{
+		Subject: "2 documents expire soon",
+		Body:    "- vehicle 7 insurance\n- vehicle 9 inspection\n",
+		Reminders: []internal.ComplianceReminder{
+			{Expiry: internal.ComplianceExpiry{
+				Item:         internal.ComplianceItem{ID: 3, VehicleID: 7, Kind: internal.ComplianceKindInsurance, Reference: "POL-1", Provider: "Mapfre", Expires: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+				Registration: "1234-ABC",
+				DaysLeft:     13,
+			}, Window: 14},
+			{Expiry: internal.ComplianceExpiry{
+				Item:         internal.ComplianceItem{ID: 4, VehicleID: 9, Kind: internal.ComplianceKindInspection, Expires: time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)},
+				Registration: "5678-DEF",
+				DaysLeft:     -4,
+			}},
+		},
+	}
+	if err := NewWebhook(&ConfigWebhook{URL: srv.URL}).Notify(context.Background(), nt); err != nil {
+		t.Fatal(err)
+	}
+
+	want := WebhookJSON{Subject: nt.Subject, Body: nt.Body, Reminders: []WebhookReminderJSON{
+		{ItemID: 3, VehicleID: 7, Registration: "1234-ABC", Kind: "insurance", Reference: "POL-1", Provider: "Mapfre", Expires: "2026-11-01", DaysLeft: 13, Window: 14},
+		{ItemID: 4, VehicleID: 9, Registration: "5678-DEF", Kind: "inspection", Expires: "2026-10-15", DaysLeft: -4},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got body %+v, want %+v", got, want)
+	}
+}
+
+func TestWebhook_Notify_Errors(t *testing.T) {
+	t.Run("status not 2xx", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}))
+		t.Cleanup(srv.Close)
+		err := NewWebhook(&ConfigWebhook{URL: srv.URL}).Notify(context.Background(), internal.Notification{Subject: "s"})
+		if err == nil || !strings.Contains(err.Error(), "503") {
+			t.Fatalf("got error %v, want the status of the webhook", err)
+		}
+	})
+
+	t.Run("no reminders", func(t *testing.T) {
+		var raw map[string]any
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&raw)
+		}))
+		t.Cleanup(srv.Close)
+		if err := NewWebhook(&ConfigWebhook{URL: srv.URL}).Notify(context.Background(), internal.Notification{Subject: "s"}); err != nil {
+			t.Fatal(err)
+		}
+		if r, ok := raw["reminders"].([]any); !ok || len(r) != 0 {
+			t.Errorf("got reminders %v, want an empty list", raw["reminders"])
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		release := make(chan struct{})
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		t.Cleanup(srv.Close)
+		t.Cleanup(func() { close(release) })
+		cl := &http.Client{Timeout: 50 * time.Millisecond}
+		if err := NewWebhook(&ConfigWebhook{URL: srv.URL, Client: cl}).Notify(context.Background(), internal.Notification{Subject: "s"}); err == nil {
+			t.Fatal("got no error, want the timeout of the client")
+		}
+	})
+}
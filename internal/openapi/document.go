@@ -0,0 +1,232 @@
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is the version of the OpenAPI specification the documents follow
+const Version = "3.0.3"
+
+// Document is a struct that represents an OpenAPI document, the subset of it describing JSON APIs
+type Document struct {
+	// OpenAPI is the version of the specification
+	OpenAPI string `json:"openapi"`
+	// Info is the metadata of the API
+	Info Info `json:"info"`
+	// Paths is the set of the operations by path and method
+	Paths map[string]PathItem `json:"paths"`
+	// Components is the set of the definitions referred to by the operations
+	Components Components `json:"components"`
+}
+
+// Info is a struct that represents the metadata of an API
+type Info struct {
+	// Title is the name of the API
+	Title string `json:"title"`
+	// Description is the description of the API
+	Description string `json:"description,omitempty"`
+	// Version is the version of the API
+	Version string `json:"version"`
+}
+
+// PathItem is a map of the operations of a path by lower case method
+type PathItem map[string]*Operation
+
+// Components is a struct that represents the definitions of a document referred to by name
+type Components struct {
+	// Schemas is the set of the schemas by name
+	Schemas map[string]*Schema `json:"schemas"`
+	// SecuritySchemes is the set of the ways of authenticating requests by name
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme is a struct that represents a way of authenticating requests, the subset of HTTP authentication
+type SecurityScheme struct {
+	// Type is the type of the scheme, http
+	Type string `json:"type"`
+	// Scheme is the HTTP authentication scheme, such as bearer
+	Scheme string `json:"scheme"`
+	// Description is the description of the scheme
+	Description string `json:"description,omitempty"`
+}
+
+// Operation is a struct that represents a method of a path
+type Operation struct {
+	// OperationID is the unique name of the operation
+	OperationID string `json:"operationId"`
+	// Summary is the description of the operation
+	Summary string `json:"summary,omitempty"`
+	// Tags is the list of the groups of the operation
+	Tags []string `json:"tags,omitempty"`
+	// Parameters is the list of the parameters of the operation
+	Parameters []*Parameter `json:"parameters,omitempty"`
+	// RequestBody is the body of the requests, nil when the operation takes none
+	RequestBody *RequestBody `json:"requestBody,omitempty"`
+	// Responses is the set of the responses by status code
+	Responses map[string]*Response `json:"responses"`
+	// Security is the list of the security requirements of the operation, any of which authenticates a request
+	Security []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter is a struct that represents a parameter of an operation
+type Parameter struct {
+	// Name is the name of the parameter
+	Name string `json:"name"`
+	// In is the location of the parameter: path, query or header
+	In string `json:"in"`
+	// Description is the description of the parameter
+	Description string `json:"description,omitempty"`
+	// Required reports whether the parameter must be given, always true for path parameters
+	Required bool `json:"required,omitempty"`
+	// Schema is the schema of the value of the parameter
+	Schema *Schema `json:"schema"`
+}
+
+// RequestBody is a struct that represents the body of the requests of an operation
+type RequestBody struct {
+	// Required reports whether the body must be given
+	Required bool `json:"required,omitempty"`
+	// Content is the set of the schemas of the body by media type
+	Content map[string]*MediaType `json:"content"`
+}
+
+// Response is a struct that represents a response of an operation
+type Response struct {
+	// Description is the description of the response
+	Description string `json:"description"`
+	// Content is the set of the schemas of the body by media type, empty when the response has no body
+	Content map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType is a struct that represents the schema of a body in a media type
+type MediaType struct {
+	// Schema is the schema of the body, nil when it is not described
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// New is a function that returns a new instance of Document, with no operations
+func New(title, version, description string) *Document {
+	return &Document{
+		OpenAPI:    Version,
+		Info:       Info{Title: title, Description: description, Version: version},
+		Paths:      make(map[string]PathItem),
+		Components: Components{Schemas: make(map[string]*Schema)},
+	}
+}
+
+// Path is a function that returns the path of a route pattern, without the trailing slash of the root of a group
+func Path(pattern string) string {
+	if len(pattern) > 1 {
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	return pattern
+}
+
+// Add is a method that adds the operation of a method and a path, with a string parameter for each parameter of the path;
+// Param changes their schema
+func (d *Document) Add(method, path, id, summary string, tags ...string) (op *Operation) {
+	op = &Operation{OperationID: id, Summary: summary, Tags: tags, Responses: make(map[string]*Response)}
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			op.Parameters = append(op.Parameters, &Parameter{Name: segment[1 : len(segment)-1], In: "path", Required: true, Schema: &Schema{Type: TypeString}})
+		}
+	}
+	item, ok := d.Paths[path]
+	if !ok {
+		item = make(PathItem)
+		d.Paths[path] = item
+	}
+	item[strings.ToLower(method)] = op
+	return
+}
+
+// Bearer is a method that adds a security scheme of bearer tokens with a name, operations require it with Secure
+func (d *Document) Bearer(name, description string) {
+	if d.Components.SecuritySchemes == nil {
+		d.Components.SecuritySchemes = make(map[string]*SecurityScheme)
+	}
+	d.Components.SecuritySchemes[name] = &SecurityScheme{Type: "http", Scheme: "bearer", Description: description}
+}
+
+// Operation is a method that returns the operation of a method and a path, nil when there is none
+func (d *Document) Operation(method, path string) *Operation {
+	return d.Paths[path][strings.ToLower(method)]
+}
+
+// Routes is a method that returns the method and path of every operation, ordered by path then method
+func (d *Document) Routes() (r []string) {
+	for path, item := range d.Paths {
+		for method := range item {
+			r = append(r, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Slice(r, func(i, j int) bool {
+		mi, pi, _ := strings.Cut(r[i], " ")
+		mj, pj, _ := strings.Cut(r[j], " ")
+		if pi != pj {
+			return pi < pj
+		}
+		return mi < mj
+	})
+	return
+}
+
+// Param is a method that adds a parameter to the operation, or replaces the one of the same name and location
+func (o *Operation) Param(in, name, description string, required bool, s *Schema) *Operation {
+	p := &Parameter{Name: name, In: in, Description: description, Required: required || in == "path", Schema: s}
+	for i, value := range o.Parameters {
+		if value.Name == name && value.In == in {
+			o.Parameters[i] = p
+			return o
+		}
+	}
+	o.Parameters = append(o.Parameters, p)
+	return o
+}
+
+// Secure is a method that requires the security scheme of a name to authenticate the requests of the operation
+func (o *Operation) Secure(scheme string) *Operation {
+	o.Security = append(o.Security, map[string][]string{scheme: {}})
+	return o
+}
+
+// Body is a method that sets the schema of the required body of the operation in a media type
+func (o *Operation) Body(mediaType string, s *Schema) *Operation {
+	if o.RequestBody == nil {
+		o.RequestBody = &RequestBody{Required: true, Content: make(map[string]*MediaType)}
+	}
+	o.RequestBody.Content[mediaType] = &MediaType{Schema: s}
+	return o
+}
+
+// Respond is a method that adds a response of a status code to the operation, with a body of JSON when s is not nil
+func (o *Operation) Respond(code int, description string, s *Schema) *Operation {
+	if s == nil {
+		return o.RespondWith(code, description, "", nil)
+	}
+	return o.RespondWith(code, description, "application/json", s)
+}
+
+// RespondWith is a method that adds a response of a status code to the operation, with a body of a media type when it is not empty.
+// Responding again with the same code adds the media type to the response
+func (o *Operation) RespondWith(code int, description, mediaType string, s *Schema) *Operation {
+	key := strconv.Itoa(code)
+	rs, ok := o.Responses[key]
+	if !ok {
+		if description == "" {
+			description = http.StatusText(code)
+		}
+		rs = &Response{Description: description}
+		o.Responses[key] = rs
+	}
+	if mediaType != "" {
+		if rs.Content == nil {
+			rs.Content = make(map[string]*MediaType)
+		}
+		rs.Content[mediaType] = &MediaType{Schema: s}
+	}
+	return o
+}
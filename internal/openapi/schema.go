@@ -0,0 +1,216 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	// TypeString is the type of strings
+	TypeString = "string"
+	// TypeInteger is the type of integers
+	TypeInteger = "integer"
+	// TypeNumber is the type of numbers
+	TypeNumber = "number"
+	// TypeBoolean is the type of true and false
+	TypeBoolean = "boolean"
+	// TypeArray is the type of lists
+	TypeArray = "array"
+	// TypeObject is the type of objects
+	TypeObject = "object"
+)
+
+// Schema is a struct that represents the schema of a JSON value, the subset of it the documents use
+type Schema struct {
+	// Ref is the reference to a schema of the components, the other fields are empty when it is set
+	Ref string `json:"$ref,omitempty"`
+	// Type is the type of the values
+	Type string `json:"type,omitempty"`
+	// Format is the format of the values, such as date-time
+	Format string `json:"format,omitempty"`
+	// Description is the description of the values
+	Description string `json:"description,omitempty"`
+	// Nullable reports whether the values may be null
+	Nullable bool `json:"nullable,omitempty"`
+	// Enum is the list of the allowed values, any when it is empty
+	Enum []any `json:"enum,omitempty"`
+	// Minimum is the lowest allowed number, nil when there is none
+	Minimum *float64 `json:"minimum,omitempty"`
+	// Maximum is the highest allowed number, nil when there is none
+	Maximum *float64 `json:"maximum,omitempty"`
+	// Items is the schema of the elements of arrays
+	Items *Schema `json:"items,omitempty"`
+	// Properties is the set of the schemas of the properties of objects by name
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	// Required is the list of the properties objects must have
+	Required []string `json:"required,omitempty"`
+	// AdditionalProperties is the schema of the properties of objects not in Properties, nil when it is not described
+	AdditionalProperties *Schema `json:"additionalProperties,omitempty"`
+	// OneOf is the list of the schemas exactly one of which the values match
+	OneOf []*Schema `json:"oneOf,omitempty"`
+	// AllOf is the list of the schemas the values all match
+	AllOf []*Schema `json:"allOf,omitempty"`
+}
+
+// String is a function that returns the schema of strings
+func String(description string) *Schema {
+	return &Schema{Type: TypeString, Description: description}
+}
+
+// Integer is a function that returns the schema of integers
+func Integer(description string) *Schema {
+	return &Schema{Type: TypeInteger, Description: description}
+}
+
+// Number is a function that returns the schema of numbers
+func Number(description string) *Schema {
+	return &Schema{Type: TypeNumber, Description: description}
+}
+
+// Boolean is a function that returns the schema of true and false
+func Boolean(description string) *Schema {
+	return &Schema{Type: TypeBoolean, Description: description}
+}
+
+// Array is a function that returns the schema of lists of a schema
+func Array(items *Schema) *Schema {
+	return &Schema{Type: TypeArray, Items: items}
+}
+
+// Object is a function that returns the schema of objects with properties
+func Object(properties map[string]*Schema, required ...string) *Schema {
+	return &Schema{Type: TypeObject, Properties: properties, Required: required}
+}
+
+// Enum is a function that returns the schema of strings among a list of values
+func Enum(description string, values ...string) *Schema {
+	s := &Schema{Type: TypeString, Description: description}
+	for _, value := range values {
+		s.Enum = append(s.Enum, value)
+	}
+	return s
+}
+
+// Between is a method that sets the lowest and highest allowed numbers, nil for no limit, and returns the schema
+func (s *Schema) Between(minimum, maximum *float64) *Schema {
+	s.Minimum, s.Maximum = minimum, maximum
+	return s
+}
+
+// Bound is a function that returns a pointer to a limit of Between
+func Bound(n float64) *float64 {
+	return &n
+}
+
+// Define is a method that adds a schema to the components under a name and returns a reference to it
+func (d *Document) Define(name string, s *Schema) *Schema {
+	d.Components.Schemas[name] = s
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// Resolve is a method that returns the schema a reference refers to, the schema itself when it is not a reference
+func (d *Document) Resolve(s *Schema) *Schema {
+	for s != nil && s.Ref != "" {
+		s = d.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	return s
+}
+
+// Schema is a method that returns the schema of the JSON encoding of a value, from the json tags of its structs.
+// Named structs are added to the components under their name and referred to; the properties without omitempty are required
+func (d *Document) Schema(v any) *Schema {
+	return d.schemaOf(reflect.TypeOf(v))
+}
+
+// Named is a method that returns the schema of the JSON encoding of a struct as Schema, added to the components under a
+// name rather than the name of its type, such as for types of different packages sharing a name
+func (d *Document) Named(name string, v any) *Schema {
+	if _, ok := d.Components.Schemas[name]; !ok {
+		d.Components.Schemas[name] = &Schema{}
+		d.Components.Schemas[name] = d.structOf(reflect.TypeOf(v))
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// Body is a method that returns the schema of the JSON decoding of a struct, as Schema, with the given required properties only
+func (d *Document) Body(v any, required ...string) *Schema {
+	ref := d.Schema(v)
+	d.Resolve(ref).Required = required
+	return ref
+}
+
+// timeType is the type of the times, encoded as RFC 3339 strings
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaOf is a method that returns the schema of the JSON encoding of a type
+func (d *Document) schemaOf(t reflect.Type) (s *Schema) {
+	if t == timeType {
+		return &Schema{Type: TypeString, Format: "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		s = d.schemaOf(t.Elem())
+		if s.Ref != "" {
+			return &Schema{AllOf: []*Schema{s}, Nullable: true}
+		}
+		s.Nullable = true
+		return
+	case reflect.String:
+		return &Schema{Type: TypeString}
+	case reflect.Bool:
+		return &Schema{Type: TypeBoolean}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: TypeInteger}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: TypeNumber}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: TypeString, Format: "byte"}
+		}
+		return Array(d.schemaOf(t.Elem()))
+	case reflect.Map:
+		return &Schema{Type: TypeObject, AdditionalProperties: d.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.structOf(t)
+		}
+		if _, ok := d.Components.Schemas[t.Name()]; !ok {
+			// - the name is taken first, so that recursive types refer to themselves
+			d.Components.Schemas[t.Name()] = &Schema{}
+			d.Components.Schemas[t.Name()] = d.structOf(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	}
+	// - interfaces and the like are any value
+	return &Schema{}
+}
+
+// structOf is a method that returns the schema of the JSON encoding of a struct, the fields of embedded structs promoted
+func (d *Document) structOf(t reflect.Type) (s *Schema) {
+	s = &Schema{Type: TypeObject, Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || !f.IsExported() && !f.Anonymous {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			embedded := d.structOf(f.Type)
+			for key, value := range embedded.Properties {
+				s.Properties[key] = value
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = d.schemaOf(f.Type)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return
+}
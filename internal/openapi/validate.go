@@ -0,0 +1,167 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxViolationValue is the length of the values shown in violations, longer ones are cut
+const maxViolationValue = 64
+
+// Violation is a struct that represents a value that does not match its schema
+type Violation struct {
+	// Path is where the value is: the name of a parameter, or body followed by the properties and indexes leading to it
+	Path string
+	// Value is the value, encoded in JSON for bodies
+	Value string
+	// Message is the reason the value does not match
+	Message string
+}
+
+// Validate is a method that checks a value decoded from JSON matches a schema, returning the violations found at a path
+func (d *Document) Validate(s *Schema, v any, path string) (vs []Violation) {
+	s = d.Resolve(s)
+	if s == nil {
+		return
+	}
+	for _, value := range s.AllOf {
+		vs = append(vs, d.Validate(value, v, path)...)
+	}
+	if len(s.OneOf) > 0 {
+		matches := 0
+		for _, value := range s.OneOf {
+			if len(d.Validate(value, v, path)) == 0 {
+				matches++
+			}
+		}
+		if matches != 1 {
+			vs = append(vs, violation(path, v, "must match exactly one of the allowed schemas"))
+		}
+	}
+	if v == nil {
+		if !s.Nullable && s.Type != "" {
+			vs = append(vs, violation(path, v, "must not be null"))
+		}
+		return
+	}
+
+	switch s.Type {
+	case TypeString:
+		str, ok := v.(string)
+		if !ok {
+			vs = append(vs, violation(path, v, "must be a string"))
+			return
+		}
+		if len(s.Enum) > 0 && !contains(s.Enum, str) {
+			vs = append(vs, violation(path, v, "must be one of "+enumString(s.Enum)))
+		}
+	case TypeInteger, TypeNumber:
+		n, ok := v.(float64)
+		if !ok || s.Type == TypeInteger && n != math.Trunc(n) {
+			vs = append(vs, violation(path, v, "must be "+map[string]string{TypeInteger: "an integer", TypeNumber: "a number"}[s.Type]))
+			return
+		}
+		if s.Minimum != nil && n < *s.Minimum {
+			vs = append(vs, violation(path, v, "must be at least "+strconv.FormatFloat(*s.Minimum, 'f', -1, 64)))
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			vs = append(vs, violation(path, v, "must be at most "+strconv.FormatFloat(*s.Maximum, 'f', -1, 64)))
+		}
+	case TypeBoolean:
+		if _, ok := v.(bool); !ok {
+			vs = append(vs, violation(path, v, "must be a boolean"))
+		}
+	case TypeArray:
+		list, ok := v.([]any)
+		if !ok {
+			vs = append(vs, violation(path, v, "must be an array"))
+			return
+		}
+		for i, value := range list {
+			vs = append(vs, d.Validate(s.Items, value, path+"["+strconv.Itoa(i)+"]")...)
+		}
+	case TypeObject:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			vs = append(vs, violation(path, v, "must be an object"))
+			return
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				vs = append(vs, Violation{Path: path + "." + name, Message: "is required"})
+			}
+		}
+		// - properties in order, so that violations are too
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if ps, ok := s.Properties[key]; ok {
+				vs = append(vs, d.Validate(ps, obj[key], path+"."+key)...)
+			} else if s.AdditionalProperties != nil {
+				vs = append(vs, d.Validate(s.AdditionalProperties, obj[key], path+"."+key)...)
+			}
+		}
+	}
+	return
+}
+
+// ValidateParameter is a method that checks the raw value of a parameter matches its schema, once converted to its type
+func (d *Document) ValidateParameter(p *Parameter, raw string) (vs []Violation) {
+	s := d.Resolve(p.Schema)
+	var v any = raw
+	if s != nil {
+		switch s.Type {
+		case TypeInteger, TypeNumber:
+			n, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+			if err != nil || math.IsInf(n, 0) || math.IsNaN(n) {
+				v = raw
+				break
+			}
+			v = n
+		case TypeBoolean:
+			if b, err := strconv.ParseBool(raw); err == nil {
+				v = b
+			}
+		}
+	}
+	vs = d.Validate(s, v, p.Name)
+	for i := range vs {
+		vs[i].Value = raw
+	}
+	return
+}
+
+// violation is a function that returns a violation of a value at a path
+func violation(path string, v any, message string) Violation {
+	value, _ := json.Marshal(v)
+	if len(value) > maxViolationValue {
+		value = append(value[:maxViolationValue], "..."...)
+	}
+	return Violation{Path: path, Value: string(value), Message: message}
+}
+
+// contains is a function that reports whether a list of allowed values has a value
+func contains(enum []any, v any) bool {
+	for _, value := range enum {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// enumString is a function that returns a list of allowed values as shown in violations
+func enumString(enum []any) string {
+	parts := make([]string, 0, len(enum))
+	for _, value := range enum {
+		parts = append(parts, fmt.Sprint(value))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrOutboxEntryNotFound is the error returned when an outbox entry does not exist, it was relayed already
+	ErrOutboxEntryNotFound = errors.New("outbox entry not found")
+)
+
+// EventsFunc is a function that returns the events of a change, given the vehicle once changed or as it was when deleted.
+// Repositories call it while saving the change, so the events are saved to the outbox with it
+type EventsFunc func(v Vehicle) (e []Event)
+
+// OutboxEntry is a struct that represents an event saved with the change that raised it, waiting to be relayed to every sink
+type OutboxEntry struct {
+	// ID is the position of the entry in the outbox
+	ID int
+	// Event is the event, its id is the key consumers dedupe redeliveries with
+	Event Event
+	// Attempts is the number of failed attempts to relay the entry
+	Attempts int
+	// LastError is the error of the last failed attempt
+	LastError string
+	// Delivered is the list of the names of the sinks the entry was relayed to
+	Delivered []string
+}
+
+// DeliveredTo is a method that reports whether the entry was relayed to a sink
+func (o OutboxEntry) DeliveredTo(sink string) bool {
+	for _, value := range o.Delivered {
+		if value == sink {
+			return true
+		}
+	}
+	return false
+}
+
+// OutboxSink is an interface that represents a destination the events of the outbox are relayed to
+type OutboxSink interface {
+	// Name is a method that returns the name of the sink, it is saved with the entries so it must not change between runs
+	Name() string
+	// Send is a method that delivers an event, an event may be sent more than once so consumers dedupe it by id
+	Send(ctx context.Context, e Event) (err error)
+}
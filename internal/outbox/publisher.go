@@ -0,0 +1,32 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// NewPublisher is a function that returns a new instance of Publisher
+func NewPublisher(name string, pb internal.EventPublisher) *Publisher {
+	return &Publisher{name: name, pb: pb}
+}
+
+// Publisher is a struct that implements the OutboxSink interface by handing the events to an in-process publisher,
+// such as the event bus. Publishers never fail, they queue or drop the events themselves
+type Publisher struct {
+	// name is the name of the sink
+	name string
+	// pb is the publisher of the events
+	pb internal.EventPublisher
+}
+
+// Name is a method that returns the name of the sink
+func (s *Publisher) Name() string {
+	return s.name
+}
+
+// Send is a method that publishes an event
+func (s *Publisher) Send(ctx context.Context, e internal.Event) (err error) {
+	s.pb.Publish(e)
+	return
+}
@@ -0,0 +1,164 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// Config is a struct that represents the configuration for Relay
+type Config struct {
+	// Interval is the time between checks of the outbox when the relay is not woken up, 1 second by default
+	Interval time.Duration
+	// BatchSize is the number of entries read from the outbox at a time, 100 by default
+	BatchSize int
+	// MaxBackoff is the longest wait after failed attempts, 1 minute by default
+	MaxBackoff time.Duration
+}
+
+// NewRelay is a function that returns a new instance of Relay, it relays once Run is called
+func NewRelay(rp internal.OutboxRepository, sinks []internal.OutboxSink, cfg *Config) *Relay {
+	// default config
+	defaultCfg := &Config{
+		Interval:   time.Second,
+		BatchSize:  100,
+		MaxBackoff: time.Minute,
+	}
+	if cfg != nil {
+		if cfg.Interval > 0 {
+			defaultCfg.Interval = cfg.Interval
+		}
+		if cfg.BatchSize > 0 {
+			defaultCfg.BatchSize = cfg.BatchSize
+		}
+		if cfg.MaxBackoff > 0 {
+			defaultCfg.MaxBackoff = cfg.MaxBackoff
+		}
+	}
+	return &Relay{
+		rp:         rp,
+		sinks:      sinks,
+		interval:   defaultCfg.Interval,
+		batchSize:  defaultCfg.BatchSize,
+		maxBackoff: defaultCfg.MaxBackoff,
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// Relay is a struct that relays the entries of the outbox to the sinks, at least once.
+// Entries are relayed in order: a failing entry is retried with exponential backoff before any later one, and only the
+// sinks it was not delivered to are retried. An entry is removed once every sink received it; a crash in between sends
+// it again on the next run, so sinks receive the id of each event to dedupe it
+type Relay struct {
+	// rp is the repository of the outbox
+	rp internal.OutboxRepository
+	// sinks is the list of the destinations of the entries
+	sinks []internal.OutboxSink
+	// interval is the time between checks of the outbox
+	interval time.Duration
+	// batchSize is the number of entries read at a time
+	batchSize int
+	// maxBackoff is the longest wait after failed attempts
+	maxBackoff time.Duration
+	// wake is signalled when entries are added
+	wake chan struct{}
+}
+
+// Wake is a method that makes the relay check the outbox now, it never blocks
+func (r *Relay) Wake() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run is a method that relays the outbox until the context is done
+func (r *Relay) Run(ctx context.Context) {
+	failures := 0
+	for {
+		wait := r.interval
+		if err := r.Relay(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			failures++
+			wait = r.backoff(failures)
+			log.Printf("error relaying outbox, retrying in %s: %v", wait, err)
+		} else {
+			failures = 0
+		}
+
+		tm := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			tm.Stop()
+			return
+		case <-r.wake:
+			// - a failing entry still waits out its backoff
+			if failures > 0 {
+				select {
+				case <-ctx.Done():
+					tm.Stop()
+					return
+				case <-tm.C:
+				}
+			}
+		case <-tm.C:
+		}
+		tm.Stop()
+	}
+}
+
+// Relay is a method that relays the entries of the outbox until it is empty or an entry fails
+func (r *Relay) Relay(ctx context.Context) (err error) {
+	for {
+		var o []internal.OutboxEntry
+		if o, err = r.rp.FindPending(r.batchSize); err != nil || len(o) == 0 {
+			return
+		}
+		for _, value := range o {
+			if err = r.relay(ctx, value); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// relay is a method that sends an entry to the sinks it was not delivered to, then removes it or records the attempt
+func (r *Relay) relay(ctx context.Context, o internal.OutboxEntry) (err error) {
+	delivered := append([]string(nil), o.Delivered...)
+	var errs []error
+	for _, sk := range r.sinks {
+		if o.DeliveredTo(sk.Name()) {
+			continue
+		}
+		if sErr := sk.Send(ctx, o.Event); sErr != nil {
+			errs = append(errs, fmt.Errorf("sink %s: %w", sk.Name(), sErr))
+			continue
+		}
+		delivered = append(delivered, sk.Name())
+	}
+	if len(errs) == 0 {
+		err = r.rp.Ack(o.ID)
+		return
+	}
+
+	err = fmt.Errorf("event %s: %w", o.Event.ID, errors.Join(errs...))
+	if fErr := r.rp.Fail(o.ID, delivered, err.Error()); fErr != nil {
+		err = errors.Join(err, fErr)
+	}
+	return
+}
+
+// backoff is a method that returns the wait after consecutive failures: the interval doubled for each previous one, capped
+func (r *Relay) backoff(failures int) time.Duration {
+	w := r.interval
+	for i := 1; i < failures && w < r.maxBackoff; i++ {
+		w *= 2
+	}
+	return min(w, r.maxBackoff)
+}
@@ -0,0 +1,79 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/event"
+	"github.com/rhinosc/code-review-1/internal/webhook"
+)
+
+// ConfigWebhook is a struct that represents the configuration for Webhook
+type ConfigWebhook struct {
+	// Name is the name of the sink, webhook by default
+	Name string
+	// URL is the URL events are posted to
+	URL string
+	// Client is the client of the requests, a client with a timeout of 10 seconds by default
+	Client *http.Client
+}
+
+// NewWebhook is a function that returns a new instance of Webhook
+func NewWebhook(cfg *ConfigWebhook) *Webhook {
+	s := &Webhook{name: cfg.Name, url: cfg.URL, cl: cfg.Client}
+	if s.name == "" {
+		s.name = "webhook"
+	}
+	if s.cl == nil {
+		s.cl = &http.Client{Timeout: 10 * time.Second}
+	}
+	return s
+}
+
+// Webhook is a struct that implements the OutboxSink interface by posting the events in JSON format to a URL.
+// The id of the event is sent as the Idempotency-Key header, so the receiver can dedupe redeliveries
+type Webhook struct {
+	// name is the name of the sink
+	name string
+	// url is the URL events are posted to
+	url string
+	// cl is the client of the requests
+	cl *http.Client
+}
+
+// Name is a method that returns the name of the sink
+func (s *Webhook) Name() string {
+	return s.name
+}
+
+// Send is a method that posts an event, it fails unless the receiver responds with a 2xx status
+func (s *Webhook) Send(ctx context.Context, e internal.Event) (err error) {
+	data, err := event.Marshal(e)
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", e.ID)
+	req.Header.Set(webhook.HeaderEventID, e.ID)
+	req.Header.Set(webhook.HeaderEventType, string(e.Type))
+
+	res, err := s.cl.Do(req)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		err = fmt.Errorf("webhook responded %s", res.Status)
+	}
+	return
+}
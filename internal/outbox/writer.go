@@ -0,0 +1,62 @@
+package outbox
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/event"
+)
+
+// NewWriter is a function that returns a new instance of Writer, such as one writing to the standard output
+func NewWriter(name string, w io.Writer) *Writer {
+	return &Writer{name: name, w: w}
+}
+
+// NewFile is a function that returns a new instance of Writer appending to a file, which is created when missing.
+// Every event is synced to disk before it is reported as sent
+func NewFile(name, path string) (s *Writer, err error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return
+	}
+	s = &Writer{name: name, w: f, sync: f.Sync}
+	return
+}
+
+// Writer is a struct that implements the OutboxSink interface by writing the events as JSON, one per line
+type Writer struct {
+	// name is the name of the sink
+	name string
+	// mu serializes the writes so lines do not interleave
+	mu sync.Mutex
+	// w is the writer of the events
+	w io.Writer
+	// sync flushes what was written to durable storage, nil when there is nothing to flush
+	sync func() error
+}
+
+// Name is a method that returns the name of the sink
+func (s *Writer) Name() string {
+	return s.name
+}
+
+// Send is a method that writes an event in its own line
+func (s *Writer) Send(ctx context.Context, e internal.Event) (err error) {
+	data, err := event.Marshal(e)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err = s.w.Write(append(data, '\n')); err != nil {
+		return
+	}
+	if s.sync != nil {
+		err = s.sync()
+	}
+	return
+}
@@ -0,0 +1,13 @@
+package internal
+
+// VehicleOutboxLoader is an interface that represents a loader for vehicles that keeps the outbox in the same file,
+// so a change and its events are saved at once
+type VehicleOutboxLoader interface {
+	VehicleLoader
+
+	// Outbox is a method that returns the outbox read by the last load
+	Outbox() (o []OutboxEntry)
+
+	// SaveWithOutbox is a method that saves the vehicles with the outbox
+	SaveWithOutbox(v map[int]Vehicle, o []OutboxEntry) (err error)
+}
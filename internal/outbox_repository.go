@@ -0,0 +1,13 @@
+package internal
+
+// OutboxRepository is an interface that represents the outbox of the events saved with the changes of the vehicles
+type OutboxRepository interface {
+	// FindPending is a method that returns up to limit entries waiting to be relayed, oldest first
+	FindPending(limit int) (o []OutboxEntry, err error)
+
+	// Ack is a method that removes an entry relayed to every sink
+	Ack(id int) (err error)
+
+	// Fail is a method that records a failed attempt to relay an entry, with the sinks it was relayed to so far
+	Fail(id int, delivered []string, lastError string) (err error)
+}
@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"math"
+	"strconv"
+)
+
+// NewRangeAll is a function that returns a range that contains every value
+func NewRangeAll() Range {
+	return Range{Min: math.Inf(-1), Max: math.Inf(1)}
+}
+
+// Range is a struct that represents an interval of values, open ends are represented by infinite bounds
+type Range struct {
+	// Min is the lower bound of the range
+	Min float64
+	// Max is the upper bound of the range
+	Max float64
+	// MinExclusive reports whether Min is excluded from the range
+	MinExclusive bool
+	// MaxExclusive reports whether Max is excluded from the range
+	MaxExclusive bool
+}
+
+// Contains is a method that reports whether a value is inside the range
+func (r Range) Contains(x float64) bool {
+	if x < r.Min || (r.MinExclusive && x == r.Min) {
+		return false
+	}
+	if x > r.Max || (r.MaxExclusive && x == r.Max) {
+		return false
+	}
+	return true
+}
+
+// Empty is a method that reports whether no value is inside the range
+func (r Range) Empty() bool {
+	if r.Min > r.Max {
+		return true
+	}
+	return r.Min == r.Max && (r.MinExclusive || r.MaxExclusive)
+}
+
+// String is a method that returns the range in interval notation
+func (r Range) String() string {
+	lo, hi := "[", "]"
+	if r.MinExclusive || math.IsInf(r.Min, -1) {
+		lo = "("
+	}
+	if r.MaxExclusive || math.IsInf(r.Max, 1) {
+		hi = ")"
+	}
+	return lo + strconv.FormatFloat(r.Min, 'f', -1, 64) + ", " + strconv.FormatFloat(r.Max, 'f', -1, 64) + hi
+}
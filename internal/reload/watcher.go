@@ -0,0 +1,421 @@
+package reload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+var (
+	// ErrReloadEmpty is an error that represents a reload that would leave the repository without vehicles
+	ErrReloadEmpty = errors.New("reload would remove every vehicle")
+	// ErrReloadConflict is an error that represents a reload overtaken by saves of the server on every attempt
+	ErrReloadConflict = errors.New("the file was saved by the server during every reload attempt")
+	// ErrReloadInvalid is an error that represents a file that was read but whose contents are not valid vehicles
+	ErrReloadInvalid = errors.New("the file contents are invalid")
+)
+
+// Result is a struct that represents the outcome of a reload
+type Result struct {
+	// Reloaded reports whether the repository contents were replaced
+	Reloaded bool
+	// Vehicles is the number of vehicles loaded
+	Vehicles int
+	// Checksum is the SHA-256 of the file that was loaded
+	Checksum string
+	// Report is the report of the load, when the loader provides one
+	Report *internal.LoadReport
+}
+
+// Status is a struct that represents the state of the reloads, so that a file that can not be reloaded is noticed
+type Status struct {
+	// LastReloadAt is the time of the last successful reload, zero when there was none
+	LastReloadAt time.Time
+	// Checksum is the SHA-256 of the file last loaded or saved
+	Checksum string
+	// Failures is the number of consecutive failed reloads
+	Failures int
+	// LastError is the error of the last failed reload, empty when the last reload succeeded
+	LastError string
+	// LastErrorAt is the time of the last failed reload
+	LastErrorAt time.Time
+	// Retrying reports whether the watcher retries on every poll, as the repository may not match the file
+	Retrying bool
+}
+
+// fingerprint is a struct that identifies a version of the file
+type fingerprint struct {
+	// modTime is the modification time of the file
+	modTime time.Time
+	// size is the size of the file
+	size int64
+	// checksum is the SHA-256 of the file, empty until it is computed
+	checksum string
+}
+
+// NewWatcher is a function that returns a new instance of Watcher, its repository is set with SetRepository
+// once the repository is built with the loader returned by Loader
+func NewWatcher(ld internal.VehicleLoader, path string, interval time.Duration) *Watcher {
+	return &Watcher{ld: ld, path: path, interval: interval}
+}
+
+// SetRepository is a method that sets the repository whose contents are replaced
+func (w *Watcher) SetRepository(rp internal.VehicleRepository) {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	w.rp = rp
+}
+
+// Watcher is a struct that reloads the repository when its data file changes on disk.
+// The file is polled for modification time and size, a change is only loaded once it has been stable for a whole
+// interval and its checksum differs from the last file loaded or saved, so the server's own saves are not reloaded
+type Watcher struct {
+	// reloadMu serializes reloads
+	reloadMu sync.Mutex
+	// mu guards last, saves, status and failed
+	mu sync.Mutex
+	// ld is the loader of the file
+	ld internal.VehicleLoader
+	// rp is the repository whose contents are replaced
+	rp internal.VehicleRepository
+	// path is the path of the file
+	path string
+	// interval is the time between polls
+	interval time.Duration
+	// last is the fingerprint of the last file loaded or saved
+	last fingerprint
+	// saves is the number of saves through Loader, but the watcher's own
+	saves int
+	// swap is the vehicles being swapped into the repository by a reload, nil out of one
+	swap map[int]internal.Vehicle
+	// swapSaves is the number of saves when the vehicles being swapped in were loaded
+	swapSaves int
+	// swapSaved reports whether the repository saved the vehicles being swapped in
+	swapSaved bool
+	// status is the state of the reloads
+	status Status
+	// failed is the checksum of the last file that could not be loaded, retried once it changes
+	failed string
+}
+
+// Loader is a method that returns a loader saving through the watcher, so its saves are recognised as its own
+func (w *Watcher) Loader() internal.VehicleLoader {
+	return &watchedLoader{w: w}
+}
+
+// watchedLoader is a struct that implements the VehicleLoader and VehicleOutboxLoader interfaces recording the fingerprint of each save
+type watchedLoader struct {
+	// w is the watcher
+	w *Watcher
+}
+
+// Load is a method that loads the vehicles
+func (l *watchedLoader) Load() (v map[int]internal.Vehicle, err error) {
+	v, err = l.w.ld.Load()
+	return
+}
+
+// Outbox is a method that returns the outbox read by the last load, none when the loader does not keep one
+func (l *watchedLoader) Outbox() (o []internal.OutboxEntry) {
+	if ol, ok := l.w.ld.(internal.VehicleOutboxLoader); ok {
+		o = ol.Outbox()
+	}
+	return
+}
+
+// Save is a method that saves the vehicles and records the fingerprint of the file.
+// Repositories save while holding their own lock, so it must not wait for a reload, which takes that lock to swap the contents
+func (l *watchedLoader) Save(v map[int]internal.Vehicle) (err error) {
+	err = l.save(v, func() error { return l.w.ld.Save(v) })
+	return
+}
+
+// SaveWithOutbox is a method that saves the vehicles with the outbox and records the fingerprint of the file,
+// the outbox is not saved when the loader does not keep one
+func (l *watchedLoader) SaveWithOutbox(v map[int]internal.Vehicle, o []internal.OutboxEntry) (err error) {
+	ol, ok := l.w.ld.(internal.VehicleOutboxLoader)
+	if !ok {
+		err = l.Save(v)
+		return
+	}
+	err = l.save(v, func() error { return ol.SaveWithOutbox(v, o) })
+	return
+}
+
+// save is a method that saves the vehicles with fn and records the fingerprint of the file.
+// The save of the vehicles being swapped in by a reload, the very map loaded, is the watcher's own: it is not counted as a
+// save of the server, and fails with ErrReloadConflict when the server saved since they were loaded, as they would
+// overwrite its save. Repositories save while holding their own lock, so no save can happen between the check and fn
+func (l *watchedLoader) save(v map[int]internal.Vehicle, fn func() error) (err error) {
+	l.w.mu.Lock()
+	own := l.w.swap != nil && reflect.ValueOf(v).Pointer() == reflect.ValueOf(l.w.swap).Pointer()
+	conflict := own && l.w.saves != l.w.swapSaves
+	l.w.mu.Unlock()
+	if conflict {
+		err = ErrReloadConflict
+		return
+	}
+
+	if err = fn(); err != nil {
+		return
+	}
+	fp, err := l.w.stat(true)
+
+	l.w.mu.Lock()
+	defer l.w.mu.Unlock()
+	if own {
+		l.w.swapSaved = true
+	} else {
+		l.w.saves++
+	}
+	if err != nil {
+		return
+	}
+	l.w.last = fp
+	return
+}
+
+// Init is a method that records the fingerprint of the file already loaded, so it is not reloaded on the first poll
+func (w *Watcher) Init() (err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.last, err = w.stat(true)
+	return
+}
+
+// Reload is a method that loads the file and replaces the repository contents, even when the file did not change
+func (w *Watcher) Reload() (r Result, err error) {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	r, err = w.reload()
+	return
+}
+
+// Status is a method that returns the state of the reloads
+func (w *Watcher) Status() (s Status) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	s = w.status
+	s.Checksum = w.last.checksum
+	return
+}
+
+// maxReloadAttempts is the number of times a reload is retried when the server saves the file while it is being loaded
+const maxReloadAttempts = 3
+
+// reload is a method that loads the file and replaces the repository contents, it must be called with reloadMu held.
+// A save between the load and the swap would be overwritten by older contents, so the reload is retried until none happens,
+// and fails with ErrReloadConflict after maxReloadAttempts; the watcher then retries on every poll until one succeeds.
+// When the repository saves the vehicles swapped in, the conflict is caught by that save; otherwise by any save during the swap
+func (w *Watcher) reload() (r Result, err error) {
+	var fp fingerprint
+	defer func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if err == nil {
+			w.status = Status{LastReloadAt: time.Now()}
+			w.failed = ""
+			return
+		}
+		w.status.Failures++
+		w.status.LastError, w.status.LastErrorAt = err.Error(), time.Now()
+		w.status.Retrying = errors.Is(err, ErrReloadConflict)
+		w.failed = fp.checksum
+	}()
+
+	for attempt := 1; ; attempt++ {
+		w.mu.Lock()
+		saves := w.saves
+		w.mu.Unlock()
+
+		if fp, err = w.stat(true); err != nil {
+			return
+		}
+		r, err = w.replace(fp, saves)
+		if err != nil && !errors.Is(err, ErrReloadConflict) {
+			return
+		}
+
+		w.mu.Lock()
+		// - the fingerprint of the watcher's own save is already recorded, and saves after it do not conflict
+		conflict := err != nil || !w.swapSaved && w.saves != saves
+		if !conflict && !w.swapSaved {
+			w.last = fp
+		}
+		w.swapSaved = false
+		w.mu.Unlock()
+		if !conflict {
+			return
+		}
+		if attempt == maxReloadAttempts {
+			err = fmt.Errorf("error reloading vehicles: %w", ErrReloadConflict)
+			return
+		}
+	}
+}
+
+// replace is a method that loads the file, validates its vehicles and swaps them into the repository, failing with
+// ErrReloadConflict when the repository saves them after the server saved more than saves times
+func (w *Watcher) replace(fp fingerprint, saves int) (r Result, err error) {
+	// load
+	// - a file that can not be read is a failure of the server, one that can not be decoded is invalid
+	v, err := w.ld.Load()
+	if err != nil {
+		var pErr *fs.PathError
+		if errors.As(err, &pErr) {
+			err = fmt.Errorf("error reloading vehicles: %w", err)
+			return
+		}
+		err = fmt.Errorf("error reloading vehicles: %w: %w", ErrReloadInvalid, err)
+		return
+	}
+	if rp, ok := w.ld.(interface{ Report() internal.LoadReport }); ok {
+		report := rp.Report()
+		r.Report = &report
+	}
+
+	// validate
+	for _, value := range v {
+		if err = value.Validate(); err != nil {
+			err = fmt.Errorf("error reloading vehicle %d: %w: %w", value.Id, ErrReloadInvalid, err)
+			return
+		}
+	}
+	if len(v) == 0 {
+		err = ErrReloadEmpty
+		return
+	}
+
+	// swap
+	w.mu.Lock()
+	w.swap, w.swapSaves = v, saves
+	w.mu.Unlock()
+	err = w.rp.Replace(v)
+	w.mu.Lock()
+	w.swap = nil
+	w.mu.Unlock()
+	if err != nil {
+		return
+	}
+	r.Reloaded, r.Vehicles, r.Checksum = true, len(v), fp.checksum
+	return
+}
+
+// Watch is a method that polls the file every interval until the context is done.
+// A reload overtaken by saves is retried on every poll, a file that can not be loaded is retried once it changes
+func (w *Watcher) Watch(ctx context.Context) {
+	tk := time.NewTicker(w.interval)
+	defer tk.Stop()
+
+	// pending is the fingerprint of a change waiting to be stable
+	var pending *fingerprint
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tk.C:
+		}
+
+		w.mu.Lock()
+		retrying := w.status.Retrying
+		w.mu.Unlock()
+		if retrying {
+			pending = nil
+			w.poll()
+			continue
+		}
+
+		fp, err := w.stat(false)
+		if err != nil {
+			log.Println("error watching vehicles file:", err)
+			continue
+		}
+
+		w.mu.Lock()
+		changed := !fp.modTime.Equal(w.last.modTime) || fp.size != w.last.size
+		w.mu.Unlock()
+		if !changed {
+			pending = nil
+			continue
+		}
+		// - wait for writers to finish
+		if pending == nil || !fp.modTime.Equal(pending.modTime) || fp.size != pending.size {
+			pending = &fp
+			continue
+		}
+		pending = nil
+
+		w.poll()
+	}
+}
+
+// poll is a method that reloads the file if its checksum differs from the last file loaded or saved and from the last file
+// that could not be loaded, or whatever its checksum when a reload is being retried
+func (w *Watcher) poll() {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	fp, err := w.stat(true)
+	if err != nil {
+		log.Println("error watching vehicles file:", err)
+		return
+	}
+	w.mu.Lock()
+	same := fp.checksum == w.last.checksum && !w.status.Retrying
+	if same {
+		w.last = fp
+	}
+	// - a file that failed is not loaded again until it changes, its error is already logged
+	failed := fp.checksum == w.failed && !w.status.Retrying
+	w.mu.Unlock()
+	if same || failed {
+		return
+	}
+
+	r, err := w.reload()
+	if err != nil {
+		st := w.Status()
+		log.Printf("%v (%d consecutive failures, retrying: %t)", err, st.Failures, st.Retrying)
+		return
+	}
+	log.Printf("reloaded %d vehicles from %s (sha256 %s)", r.Vehicles, w.path, r.Checksum)
+}
+
+// stat is a method that returns the fingerprint of the file, with its checksum when requested
+func (w *Watcher) stat(checksum bool) (fp fingerprint, err error) {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return
+	}
+	fp.modTime, fp.size = info.ModTime(), info.Size()
+	if !checksum {
+		return
+	}
+
+	f, err := os.Open(w.path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return
+	}
+	fp.checksum = hex.EncodeToString(h.Sum(nil))
+	return
+}
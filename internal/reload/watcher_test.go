@@ -0,0 +1,304 @@
+package reload
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/loader"
+	"github.com/rhinosc/code-review-1/internal/repository"
+)
+
+// stubLoader is a struct that loads a fixed set of vehicles, saving through the watcher during loads to simulate saves
+// of the server racing a reload
+type stubLoader struct {
+	// path is the path of the file saved
+	path string
+	// w is the watcher saves go through
+	w *Watcher
+	// races is the number of loads that are overtaken by a save
+	races int
+	// err is the error of the loads
+	err error
+	// vehicles is the vehicles loaded, a valid one when nil
+	vehicles map[int]internal.Vehicle
+	// loads is the number of loads
+	loads int
+}
+
+func (l *stubLoader) Load() (v map[int]internal.Vehicle, err error) {
+	l.loads++
+	if l.err != nil {
+		err = l.err
+		return
+	}
+	if l.vehicles != nil {
+		v = l.vehicles
+		return
+	}
+	v = map[int]internal.Vehicle{1: {Id: 1, VehicleAttributes: internal.VehicleAttributes{Brand: "Ford", Model: "T", FabricationYear: 1920, Capacity: 2}, Status: internal.VehicleStatusInService}}
+	if l.races > 0 {
+		l.races--
+		err = l.w.Loader().Save(v)
+	}
+	return
+}
+
+func (l *stubLoader) Save(v map[int]internal.Vehicle) (err error) {
+	err = os.WriteFile(l.path, []byte(time.Now().String()), 0o600)
+	return
+}
+
+// stubRepository is a struct that records the vehicles swapped in, the other methods are not used by the watcher
+type stubRepository struct {
+	internal.VehicleRepository
+	// db is the vehicles swapped in
+	db map[int]internal.Vehicle
+}
+
+func (r *stubRepository) Replace(v map[int]internal.Vehicle) (err error) {
+	r.db = v
+	return
+}
+
+// newTestWatcher is a function that returns a watcher over a file in a temporary directory
+func newTestWatcher(t *testing.T) (w *Watcher, ld *stubLoader, rp *stubRepository) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vehicles.json")
+	if err := os.WriteFile(path, []byte("[]"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ld = &stubLoader{path: path}
+	w = NewWatcher(ld, path, time.Millisecond)
+	ld.w = w
+	rp = &stubRepository{}
+	w.SetRepository(rp)
+	if err := w.Init(); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestWatcher_Reload_Conflict(t *testing.T) {
+	w, ld, rp := newTestWatcher(t)
+
+	// every attempt is overtaken by a save
+	ld.races = maxReloadAttempts
+	if _, err := w.Reload(); !errors.Is(err, ErrReloadConflict) {
+		t.Fatalf("reload: got %v, want %v", err, ErrReloadConflict)
+	}
+	if st := w.Status(); !st.Retrying || st.Failures != 1 || st.LastError == "" {
+		t.Fatalf("status: got %+v, want retrying after 1 failure", st)
+	}
+
+	// the next poll retries although the file is the server's own save
+	w.poll()
+	if st := w.Status(); st.Retrying || st.Failures != 0 || st.LastReloadAt.IsZero() {
+		t.Fatalf("status: got %+v, want a successful reload", st)
+	}
+	if len(rp.db) != 1 {
+		t.Fatalf("repository: got %d vehicles, want 1", len(rp.db))
+	}
+}
+
+func TestWatcher_Poll_Failed(t *testing.T) {
+	w, ld, _ := newTestWatcher(t)
+
+	// a file that can not be loaded
+	if err := os.WriteFile(ld.path, []byte("broken"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ld.err = errors.New("broken file")
+	w.poll()
+	if st := w.Status(); st.Failures != 1 || st.Retrying {
+		t.Fatalf("status: got %+v, want 1 failure without retrying", st)
+	}
+
+	// it is not loaded again until it changes
+	w.poll()
+	if ld.loads != 1 {
+		t.Fatalf("loads: got %d, want 1", ld.loads)
+	}
+	if err := os.WriteFile(ld.path, []byte("fixed"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ld.err = nil
+	w.poll()
+	if st := w.Status(); ld.loads != 2 || st.Failures != 0 {
+		t.Fatalf("got %d loads and status %+v, want 2 loads and a successful reload", ld.loads, st)
+	}
+}
+
+func TestWatcher_Reload_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		vehicles map[int]internal.Vehicle
+		want     error
+		invalid  bool
+	}{
+		{name: "file not read", err: &fs.PathError{Op: "open", Path: "vehicles.json", Err: fs.ErrPermission}, want: fs.ErrPermission},
+		{name: "file not decoded", err: errors.New("error decoding file: unexpected EOF"), want: ErrReloadInvalid, invalid: true},
+		{name: "invalid vehicle", vehicles: map[int]internal.Vehicle{1: {Id: 1}}, want: internal.ErrVehicleInvalid, invalid: true},
+		{name: "no vehicles", vehicles: map[int]internal.Vehicle{}, want: ErrReloadEmpty},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, ld, rp := newTestWatcher(t)
+			ld.err, ld.vehicles = tt.err, tt.vehicles
+
+			_, err := w.Reload()
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if got := errors.Is(err, ErrReloadInvalid); got != tt.invalid {
+				t.Errorf("got invalid %t, want %t", got, tt.invalid)
+			}
+			if rp.db != nil {
+				t.Errorf("got %d vehicles swapped in, want none", len(rp.db))
+			}
+		})
+	}
+}
+
+// racingVehicleLoader is a struct that loads a vehicles file, creating a vehicle through the repository during loads to
+// simulate the server saving while the file is reloaded
+type racingVehicleLoader struct {
+	*loader.VehicleJSONFile
+	// rp is the repository vehicles are created through
+	rp *repository.VehicleMap
+	// races is the number of loads that are overtaken by a save
+	races int
+}
+
+func (l *racingVehicleLoader) Load() (v map[int]internal.Vehicle, err error) {
+	if v, err = l.VehicleJSONFile.Load(); err != nil || l.races == 0 {
+		return
+	}
+	l.races--
+	nv := testVehicle(0, "Seat")
+	err = l.rp.Create(&nv, nil)
+	return
+}
+
+// testVehicle is a function that returns a valid vehicle of a brand
+func testVehicle(id int, brand string) internal.Vehicle {
+	return internal.Vehicle{Id: id, VehicleAttributes: internal.VehicleAttributes{Brand: brand, Model: "T", FabricationYear: 1920, Capacity: 2}, Status: internal.VehicleStatusInService}
+}
+
+// newRepositoryWatcher is a function that returns a watcher over a vehicles file in a temporary directory and the
+// repository it reloads, saving through the watcher as the server does
+func newRepositoryWatcher(t *testing.T) (w *Watcher, ld *racingVehicleLoader, rp *repository.VehicleMap) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vehicles.json")
+	ld = &racingVehicleLoader{VehicleJSONFile: loader.NewVehicleJSONFile(path)}
+	if err := ld.Save(map[int]internal.Vehicle{1: testVehicle(1, "Ford"), 2: testVehicle(2, "Fiat")}); err != nil {
+		t.Fatal(err)
+	}
+	db, err := ld.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w = NewWatcher(ld, path, time.Millisecond)
+	if err := w.Init(); err != nil {
+		t.Fatal(err)
+	}
+	rp = repository.NewVehicleMap(w.Loader(), db, repository.NewVehicleIDSequential())
+	ld.rp = rp
+	w.SetRepository(rp)
+	return
+}
+
+// countBrand is a function that returns the number of vehicles of a brand
+func countBrand(v map[int]internal.Vehicle, brand string) (n int) {
+	for _, value := range v {
+		if value.Brand == brand {
+			n++
+		}
+	}
+	return
+}
+
+// editFile is a function that saves vehicles to the file of a watcher as an edit by hand
+func editFile(t *testing.T, w *Watcher, v map[int]internal.Vehicle) {
+	t.Helper()
+	if err := loader.NewVehicleJSONFile(w.path).Save(v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcher_Reload_SavesResetEvent(t *testing.T) {
+	w, _, rp := newRepositoryWatcher(t)
+	editFile(t, w, map[int]internal.Vehicle{1: testVehicle(1, "Ford"), 3: testVehicle(3, "Kia")})
+
+	r, err := w.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Reloaded || r.Vehicles != 2 {
+		t.Fatalf("got %+v, want 2 vehicles reloaded", r)
+	}
+
+	// - the reset event is saved with the vehicles reloaded
+	ld := loader.NewVehicleJSONFile(w.path)
+	v, err := ld.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := ld.Outbox()
+	if _, ok := v[3]; !ok || len(v) != 2 || len(o) != 1 || o[0].Event.Type != internal.EventVehiclesReset {
+		t.Fatalf("got vehicles %v and outbox %+v saved, want vehicles 1 and 3 with the reset event", v, o)
+	}
+	if p, _ := rp.FindPending(10); len(p) != 1 || p[0].ID != o[0].ID {
+		t.Errorf("got outbox %+v in the repository, want the one saved", p)
+	}
+
+	// - the save is the watcher's own, it is not reloaded
+	st := w.Status()
+	w.poll()
+	if got := w.Status(); got.Failures != 0 || !got.LastReloadAt.Equal(st.LastReloadAt) {
+		t.Errorf("got status %+v after a poll, want no reload since %s", got, st.LastReloadAt)
+	}
+}
+
+func TestWatcher_Reload_SaveConflict(t *testing.T) {
+	tests := []struct {
+		name  string
+		races int
+		want  error
+	}{
+		{name: "retried after a save", races: 1},
+		{name: "saved during every attempt", races: maxReloadAttempts, want: ErrReloadConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, ld, rp := newRepositoryWatcher(t)
+			editFile(t, w, map[int]internal.Vehicle{1: testVehicle(1, "Ford"), 3: testVehicle(3, "Kia")})
+			ld.races = tt.races
+
+			if _, err := w.Reload(); !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+
+			// - the vehicles created during the reload are never overwritten by the file loaded before them
+			saved, err := loader.NewVehicleJSONFile(w.path).Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			v, err := rp.FindAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := countBrand(saved, "Seat"); got != tt.races {
+				t.Errorf("got %d vehicles created during the reload in the file, want %d", got, tt.races)
+			}
+			if got := countBrand(v, "Seat"); got != tt.races {
+				t.Errorf("got %d vehicles created during the reload in the repository, want %d", got, tt.races)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// NewAttachmentMap is a function that returns a new instance of AttachmentMap
+func NewAttachmentMap(ld internal.AttachmentLoader, db map[int]internal.Attachment) *AttachmentMap {
+	// default db
+	if db == nil {
+		db = make(map[int]internal.Attachment)
+	}
+	// last id
+	m := &AttachmentMap{ld: ld, db: db}
+	for key := range db {
+		m.lastID = max(m.lastID, key)
+	}
+	return m
+}
+
+// AttachmentMap is a struct that represents a repository of attachments
+type AttachmentMap struct {
+	// mu guards db and lastID
+	mu sync.RWMutex
+	// ld is the loader that saves the attachments
+	ld internal.AttachmentLoader
+	// db is the set of attachments by id
+	db map[int]internal.Attachment
+	// lastID is the greatest id of the attachments
+	lastID int
+}
+
+// FindAll is a method that returns every attachment, ordered by id
+func (m *AttachmentMap) FindAll() (a []internal.Attachment, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	a = m.find(func(internal.Attachment) bool { return true })
+	return
+}
+
+// FindByVehicle is a method that returns the attachments of a vehicle, ordered by id
+func (m *AttachmentMap) FindByVehicle(vehicleID int) (a []internal.Attachment, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	a = m.find(func(a internal.Attachment) bool { return a.VehicleID == vehicleID })
+	return
+}
+
+// FindByID is a method that returns an attachment by id
+func (m *AttachmentMap) FindByID(id int) (a internal.Attachment, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	a, ok := m.db[id]
+	if !ok {
+		err = fmt.Errorf("%w: %d", internal.ErrAttachmentNotFound, id)
+	}
+	return
+}
+
+// Create is a method that creates an attachment
+func (m *AttachmentMap) Create(a *internal.Attachment) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastID++
+	a.ID = m.lastID
+	m.db[a.ID] = *a
+
+	// save db to JSON file
+	if err = m.ld.Save(m.db); err != nil {
+		// - memory is kept as the file
+		delete(m.db, a.ID)
+		m.lastID--
+	}
+	return
+}
+
+// Delete is a method that deletes the attachments matching a condition and returns them
+func (m *AttachmentMap) Delete(match func(a internal.Attachment) bool) (a []internal.Attachment, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a = m.find(match)
+	if len(a) == 0 {
+		return
+	}
+	for _, value := range a {
+		delete(m.db, value.ID)
+	}
+
+	// save db to JSON file
+	if err = m.ld.Save(m.db); err != nil {
+		// - memory is kept as the file
+		for _, value := range a {
+			m.db[value.ID] = value
+		}
+		a = nil
+	}
+	return
+}
+
+// find is a method that returns the attachments matching a condition ordered by id, it must be called with mu held
+func (m *AttachmentMap) find(match func(a internal.Attachment) bool) (a []internal.Attachment) {
+	a = make([]internal.Attachment, 0)
+	for _, value := range m.db {
+		if match(value) {
+			a = append(a, value)
+		}
+	}
+	sort.Slice(a, func(i, j int) bool { return a[i].ID < a[j].ID })
+	return
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// NewComplianceMap is a function that returns a new instance of ComplianceMap
+func NewComplianceMap(ld internal.ComplianceLoader, d internal.ComplianceData) *ComplianceMap {
+	// default data
+	if d.Items == nil {
+		d.Items = make(map[int]internal.ComplianceItem)
+	}
+	// last id
+	m := &ComplianceMap{ld: ld, d: d}
+	for key := range d.Items {
+		m.lastID = max(m.lastID, key)
+	}
+	return m
+}
+
+// ComplianceMap is a struct that represents a repository of compliance items and reminders sent
+type ComplianceMap struct {
+	// mu guards d and lastID
+	mu sync.RWMutex
+	// ld is the loader that saves the items and reminders sent
+	ld internal.ComplianceLoader
+	// d is the set of items and reminders sent
+	d internal.ComplianceData
+	// lastID is the greatest id of the items
+	lastID int
+}
+
+// FindAll is a method that returns every item, ordered by id
+func (m *ComplianceMap) FindAll() (i []internal.ComplianceItem, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i = m.find(func(internal.ComplianceItem) bool { return true })
+	sort.Slice(i, func(a, b int) bool { return i[a].ID < i[b].ID })
+	return
+}
+
+// FindByVehicle is a method that returns the items of a vehicle, soonest expiry first
+func (m *ComplianceMap) FindByVehicle(vehicleID int) (i []internal.ComplianceItem, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i = m.find(func(i internal.ComplianceItem) bool { return i.VehicleID == vehicleID })
+	sort.Slice(i, func(a, b int) bool {
+		if !i[a].Expires.Equal(i[b].Expires) {
+			return i[a].Expires.Before(i[b].Expires)
+		}
+		return i[a].ID < i[b].ID
+	})
+	return
+}
+
+// FindByID is a method that returns an item by id
+func (m *ComplianceMap) FindByID(id int) (i internal.ComplianceItem, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i, ok := m.d.Items[id]
+	if !ok {
+		err = fmt.Errorf("%w: %d", internal.ErrComplianceNotFound, id)
+	}
+	return
+}
+
+// Create is a method that creates an item
+func (m *ComplianceMap) Create(i *internal.ComplianceItem) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastID++
+	i.ID = m.lastID
+	m.d.Items[i.ID] = *i
+
+	// save data to JSON file
+	if err = m.ld.Save(m.d); err != nil {
+		// - memory is kept as the file
+		delete(m.d.Items, i.ID)
+		m.lastID--
+	}
+	return
+}
+
+// Update is a method that applies fn to an item and saves the result, nothing is saved when fn fails
+func (m *ComplianceMap) Update(id int, fn func(i *internal.ComplianceItem) error) (i internal.ComplianceItem, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, ok := m.d.Items[id]
+	if !ok {
+		err = fmt.Errorf("%w: %d", internal.ErrComplianceNotFound, id)
+		return
+	}
+	i = old
+	if err = fn(&i); err != nil {
+		return
+	}
+	i.ID = id
+	m.d.Items[id] = i
+
+	// save data to JSON file
+	if err = m.ld.Save(m.d); err != nil {
+		// - memory is kept as the file
+		m.d.Items[id] = old
+	}
+	return
+}
+
+// Delete is a method that deletes the items matching a condition and returns them
+func (m *ComplianceMap) Delete(match func(i internal.ComplianceItem) bool) (i []internal.ComplianceItem, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i = m.find(match)
+	if len(i) == 0 {
+		return
+	}
+	for _, value := range i {
+		delete(m.d.Items, value.ID)
+	}
+	sent := m.d.Sent
+	m.d.Sent = m.prune(sent)
+
+	// save data to JSON file
+	if err = m.ld.Save(m.d); err != nil {
+		// - memory is kept as the file
+		for _, value := range i {
+			m.d.Items[value.ID] = value
+		}
+		m.d.Sent = sent
+		i = nil
+	}
+	return
+}
+
+// FindSent is a method that returns the reminders sent, oldest first
+func (m *ComplianceMap) FindSent() (s []internal.ComplianceSent, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s = make([]internal.ComplianceSent, len(m.d.Sent))
+	copy(s, m.d.Sent)
+	return
+}
+
+// AddSent is a method that records reminders sent, the records of deleted or renewed items are dropped
+func (m *ComplianceMap) AddSent(s []internal.ComplianceSent) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sent := m.d.Sent
+	m.d.Sent = m.prune(append(append(make([]internal.ComplianceSent, 0, len(sent)+len(s)), sent...), s...))
+
+	// save data to JSON file
+	if err = m.ld.Save(m.d); err != nil {
+		// - memory is kept as the file
+		m.d.Sent = sent
+	}
+	return
+}
+
+// prune is a method that returns the reminders sent of the current expiry of existing items, it must be called with mu held
+func (m *ComplianceMap) prune(s []internal.ComplianceSent) (pruned []internal.ComplianceSent) {
+	pruned = make([]internal.ComplianceSent, 0, len(s))
+	for _, value := range s {
+		if i, ok := m.d.Items[value.ItemID]; ok && i.Expires.Equal(value.Expires) {
+			pruned = append(pruned, value)
+		}
+	}
+	return
+}
+
+// find is a method that returns the items matching a condition, it must be called with mu held
+func (m *ComplianceMap) find(match func(i internal.ComplianceItem) bool) (i []internal.ComplianceItem) {
+	i = make([]internal.ComplianceItem, 0)
+	for _, value := range m.d.Items {
+		if match(value) {
+			i = append(i, value)
+		}
+	}
+	return
+}
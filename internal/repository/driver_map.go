@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// NewDriverMap is a function that returns a new instance of DriverMap
+func NewDriverMap(ld internal.DriverLoader, d internal.DriverData) *DriverMap {
+	// default data
+	if d.Drivers == nil {
+		d.Drivers = make(map[int]internal.Driver)
+	}
+	if d.Assignments == nil {
+		d.Assignments = make(map[int]internal.Assignment)
+	}
+	// last ids
+	m := &DriverMap{ld: ld, d: d}
+	for key := range d.Drivers {
+		m.lastDriverID = max(m.lastDriverID, key)
+	}
+	for key := range d.Assignments {
+		m.lastAssignmentID = max(m.lastAssignmentID, key)
+	}
+	return m
+}
+
+// DriverMap is a struct that represents a repository of drivers and their assignments
+type DriverMap struct {
+	// mu guards d and the last ids
+	mu sync.RWMutex
+	// ld is the loader that saves the drivers and assignments
+	ld internal.DriverLoader
+	// d is the set of drivers and assignments
+	d internal.DriverData
+	// lastDriverID is the greatest id of the drivers
+	lastDriverID int
+	// lastAssignmentID is the greatest id of the assignments
+	lastAssignmentID int
+}
+
+// FindAll is a method that returns every driver, ordered by id
+func (m *DriverMap) FindAll() (d []internal.Driver, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	d = make([]internal.Driver, 0, len(m.d.Drivers))
+	for _, value := range m.d.Drivers {
+		d = append(d, value)
+	}
+	sort.Slice(d, func(i, j int) bool { return d[i].ID < d[j].ID })
+	return
+}
+
+// FindByID is a method that returns a driver by id
+func (m *DriverMap) FindByID(id int) (d internal.Driver, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	d, ok := m.d.Drivers[id]
+	if !ok {
+		err = fmt.Errorf("%w: %d", internal.ErrDriverNotFound, id)
+	}
+	return
+}
+
+// Create is a method that creates a driver, it fails with ErrDriverDuplicate when the licence number is taken
+func (m *DriverMap) Create(d *internal.Driver) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err = m.checkLicenceNumber(0, d.LicenceNumber); err != nil {
+		return
+	}
+	m.lastDriverID++
+	d.ID = m.lastDriverID
+	m.d.Drivers[d.ID] = *d
+
+	// save data to JSON file
+	if err = m.ld.Save(m.d); err != nil {
+		// - memory is kept as the file
+		delete(m.d.Drivers, d.ID)
+		m.lastDriverID--
+	}
+	return
+}
+
+// Update is a method that changes a driver with update and saves it, nothing changes when update fails
+func (m *DriverMap) Update(id int, update func(d *internal.Driver) (err error)) (d internal.Driver, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, ok := m.d.Drivers[id]
+	if !ok {
+		err = fmt.Errorf("%w: %d", internal.ErrDriverNotFound, id)
+		return
+	}
+	d = old
+	if err = update(&d); err != nil {
+		return
+	}
+	if err = m.checkLicenceNumber(id, d.LicenceNumber); err != nil {
+		return
+	}
+	d.ID = id
+	m.d.Drivers[id] = d
+
+	// save data to JSON file
+	if err = m.ld.Save(m.d); err != nil {
+		// - memory is kept as the file
+		m.d.Drivers[id] = old
+	}
+	return
+}
+
+// Delete is a method that deletes a driver, it fails with ErrDriverAssigned when the driver has a current assignment.
+// The past assignments are kept as the history of the vehicles
+func (m *DriverMap) Delete(id int) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, ok := m.d.Drivers[id]
+	if !ok {
+		err = fmt.Errorf("%w: %d", internal.ErrDriverNotFound, id)
+		return
+	}
+	if a, ok := m.currentAssignment(func(a internal.Assignment) bool { return a.DriverID == id }); ok {
+		err = fmt.Errorf("%w: driver %d drives vehicle %d", internal.ErrDriverAssigned, id, a.VehicleID)
+		return
+	}
+	delete(m.d.Drivers, id)
+
+	// save data to JSON file
+	if err = m.ld.Save(m.d); err != nil {
+		// - memory is kept as the file
+		m.d.Drivers[id] = old
+	}
+	return
+}
+
+// FindAssignments is a method that returns the assignments of a vehicle and of a driver, zero ids match any, oldest first
+func (m *DriverMap) FindAssignments(vehicleID, driverID int) (a []internal.Assignment, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	a = make([]internal.Assignment, 0)
+	for _, value := range m.d.Assignments {
+		if (vehicleID == 0 || value.VehicleID == vehicleID) && (driverID == 0 || value.DriverID == driverID) {
+			a = append(a, value)
+		}
+	}
+	sort.Slice(a, func(i, j int) bool {
+		if !a[i].From.Equal(a[j].From) {
+			return a[i].From.Before(a[j].From)
+		}
+		return a[i].ID < a[j].ID
+	})
+	return
+}
+
+// Assign is a method that assigns a driver to a vehicle at a time once check accepts the driver, ending the current assignment
+// of the vehicle. It fails with ErrDriverAssigned when the driver has a current assignment to another vehicle
+func (m *DriverMap) Assign(a *internal.Assignment, check func(d internal.Driver) (err error)) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d, ok := m.d.Drivers[a.DriverID]
+	if !ok {
+		err = fmt.Errorf("%w: %d", internal.ErrDriverNotFound, a.DriverID)
+		return
+	}
+	if err = check(d); err != nil {
+		return
+	}
+	if other, ok := m.currentAssignment(func(c internal.Assignment) bool { return c.DriverID == a.DriverID }); ok {
+		err = fmt.Errorf("%w: driver %d drives vehicle %d", internal.ErrDriverAssigned, a.DriverID, other.VehicleID)
+		return
+	}
+
+	// - the current driver of the vehicle is replaced
+	prev, replaced := m.currentAssignment(func(c internal.Assignment) bool { return c.VehicleID == a.VehicleID })
+	if replaced {
+		ended := prev
+		ended.To = a.From
+		m.d.Assignments[prev.ID] = ended
+	}
+	m.lastAssignmentID++
+	a.ID, a.To = m.lastAssignmentID, time.Time{}
+	m.d.Assignments[a.ID] = *a
+
+	// save data to JSON file
+	if err = m.ld.Save(m.d); err != nil {
+		// - memory is kept as the file
+		delete(m.d.Assignments, a.ID)
+		m.lastAssignmentID--
+		if replaced {
+			m.d.Assignments[prev.ID] = prev
+		}
+	}
+	return
+}
+
+// Unassign is a method that ends the current assignment of a vehicle at a time, it fails with ErrAssignmentNotFound when there is none
+func (m *DriverMap) Unassign(vehicleID int, at time.Time) (a internal.Assignment, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, ok := m.currentAssignment(func(c internal.Assignment) bool { return c.VehicleID == vehicleID })
+	if !ok {
+		err = fmt.Errorf("%w: vehicle %d has no driver", internal.ErrAssignmentNotFound, vehicleID)
+		return
+	}
+	a = old
+	a.To = at
+	m.d.Assignments[a.ID] = a
+
+	// save data to JSON file
+	if err = m.ld.Save(m.d); err != nil {
+		// - memory is kept as the file
+		m.d.Assignments[a.ID] = old
+	}
+	return
+}
+
+// currentAssignment is a method that returns the current assignment matching a condition, there is at most one per vehicle and driver
+func (m *DriverMap) currentAssignment(match func(a internal.Assignment) bool) (a internal.Assignment, ok bool) {
+	for _, value := range m.d.Assignments {
+		if value.Current() && match(value) {
+			return value, true
+		}
+	}
+	return
+}
+
+// checkLicenceNumber is a method that checks no driver but id has the licence number, ignoring case and spaces
+func (m *DriverMap) checkLicenceNumber(id int, number string) (err error) {
+	key := licenceKey(number)
+	for _, value := range m.d.Drivers {
+		if value.ID != id && licenceKey(value.LicenceNumber) == key {
+			err = fmt.Errorf("%w: licence number %s belongs to driver %d", internal.ErrDriverDuplicate, number, value.ID)
+			return
+		}
+	}
+	return
+}
+
+// licenceKey is a function that returns the key of a licence number to compare it
+func licenceKey(number string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(number), ""))
+}
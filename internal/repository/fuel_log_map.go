@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// NewFuelLogMap is a function that returns a new instance of FuelLogMap
+func NewFuelLogMap(ld internal.FuelLogLoader, db map[int]internal.FuelLogEntry) *FuelLogMap {
+	// default db
+	if db == nil {
+		db = make(map[int]internal.FuelLogEntry)
+	}
+	// last id
+	m := &FuelLogMap{ld: ld, db: db}
+	for key := range db {
+		m.lastID = max(m.lastID, key)
+	}
+	return m
+}
+
+// FuelLogMap is a struct that represents a repository of fuel log entries
+type FuelLogMap struct {
+	// mu guards db and lastID, entries are checked and created under the same lock
+	mu sync.RWMutex
+	// ld is the loader that saves the entries
+	ld internal.FuelLogLoader
+	// db is the set of entries by id
+	db map[int]internal.FuelLogEntry
+	// lastID is the greatest id of the entries
+	lastID int
+}
+
+// FindAll is a method that returns every entry, ordered by date
+func (m *FuelLogMap) FindAll() (e []internal.FuelLogEntry, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	e = m.find(0)
+	return
+}
+
+// FindByVehicle is a method that returns the entries of a vehicle, ordered by date
+func (m *FuelLogMap) FindByVehicle(vehicleID int) (e []internal.FuelLogEntry, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	e = m.find(vehicleID)
+	return
+}
+
+// Create is a method that creates an entry once check accepts it against the entries of its vehicle
+func (m *FuelLogMap) Create(e *internal.FuelLogEntry, check func(log []internal.FuelLogEntry, e internal.FuelLogEntry) (err error)) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err = check(m.find(e.VehicleID), *e); err != nil {
+		return
+	}
+	m.lastID++
+	e.ID = m.lastID
+	m.db[e.ID] = *e
+
+	// save db to JSON file
+	if err = m.ld.Save(m.db); err != nil {
+		// - memory is kept as the file
+		delete(m.db, e.ID)
+		m.lastID--
+	}
+	return
+}
+
+// find is a method that returns the entries of a vehicle ordered by date then by id, a zero id matches any
+func (m *FuelLogMap) find(vehicleID int) (e []internal.FuelLogEntry) {
+	e = make([]internal.FuelLogEntry, 0)
+	for _, value := range m.db {
+		if vehicleID == 0 || value.VehicleID == vehicleID {
+			e = append(e, value)
+		}
+	}
+	sort.Slice(e, func(i, j int) bool {
+		if !e[i].Date.Equal(e[j].Date) {
+			return e[i].Date.Before(e[j].Date)
+		}
+		return e[i].ID < e[j].ID
+	})
+	return
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// NewIdempotencyMap is a function that returns a new instance of IdempotencyMap
+func NewIdempotencyMap() *IdempotencyMap {
+	return &IdempotencyMap{
+		db:         make(map[string]internal.IdempotencyRecord),
+		inProgress: make(map[string]struct{}),
+		now:        time.Now,
+	}
+}
+
+// IdempotencyMap is a struct that represents an in-memory idempotency store
+type IdempotencyMap struct {
+	// mu guards db and inProgress
+	mu sync.Mutex
+	// db is a map of idempotency records by key
+	db map[string]internal.IdempotencyRecord
+	// inProgress is the set of keys whose first request is being processed
+	inProgress map[string]struct{}
+	// now returns the current time
+	now func() time.Time
+}
+
+// Reserve is a method that marks a key as in progress until it is saved or released, or returns its record when it has one
+func (r *IdempotencyMap) Reserve(key string) (rc internal.IdempotencyRecord, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.evict()
+
+	// - a retry arriving once the first request is saved replays it
+	if value, ok := r.db[key]; ok {
+		rc = value
+		err = internal.ErrIdempotencyKeyExists
+		return
+	}
+	if _, ok := r.inProgress[key]; ok {
+		err = internal.ErrIdempotencyKeyInProgress
+		return
+	}
+	r.inProgress[key] = struct{}{}
+	return
+}
+
+// Release is a method that removes the in progress mark of a key without saving a record
+func (r *IdempotencyMap) Release(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.inProgress, key)
+}
+
+// Get is a method that returns the record of a key
+func (r *IdempotencyMap) Get(key string) (rc internal.IdempotencyRecord, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.evict()
+
+	rc, ok := r.db[key]
+	if !ok {
+		err = internal.ErrIdempotencyKeyNotFound
+		return
+	}
+	return
+}
+
+// Save is a method that saves the record of a key and removes its in progress mark
+func (r *IdempotencyMap) Save(rc internal.IdempotencyRecord) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.db[rc.Key] = rc
+	delete(r.inProgress, rc.Key)
+	return
+}
+
+// evict is a method that removes the expired records, it must be called with mu held
+func (r *IdempotencyMap) evict() {
+	now := r.now()
+	for key, value := range r.db {
+		if !now.Before(value.ExpiresAt) {
+			delete(r.db, key)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+func TestIdempotencyMap_Reserve(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := NewIdempotencyMap()
+	r.now = func() time.Time { return now }
+
+	// first request reserves the key, a concurrent one is told it is in progress
+	if _, err := r.Reserve("k"); err != nil {
+		t.Fatalf("first reserve: %v", err)
+	}
+	if _, err := r.Reserve("k"); !errors.Is(err, internal.ErrIdempotencyKeyInProgress) {
+		t.Fatalf("reserve in progress: got %v, want %v", err, internal.ErrIdempotencyKeyInProgress)
+	}
+
+	// once saved, a retry gets the record instead of a new reservation
+	want := internal.IdempotencyRecord{Key: "k", Fingerprint: "f", StatusCode: 201, ExpiresAt: now.Add(time.Minute)}
+	if err := r.Save(want); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	rc, err := r.Reserve("k")
+	if !errors.Is(err, internal.ErrIdempotencyKeyExists) {
+		t.Fatalf("reserve saved: got %v, want %v", err, internal.ErrIdempotencyKeyExists)
+	}
+	if rc.Fingerprint != want.Fingerprint || rc.StatusCode != want.StatusCode {
+		t.Fatalf("reserve saved: got %+v, want %+v", rc, want)
+	}
+
+	// expired records are evicted, so the key can be reserved again
+	now = now.Add(time.Minute)
+	if _, err := r.Reserve("k"); err != nil {
+		t.Fatalf("reserve expired: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// NewMaintenanceMap is a function that returns a new instance of MaintenanceMap
+func NewMaintenanceMap(ld internal.MaintenanceLoader, d internal.MaintenanceData) *MaintenanceMap {
+	// default data
+	if d.Records == nil {
+		d.Records = make(map[int]internal.MaintenanceRecord)
+	}
+	if d.Rules == nil {
+		d.Rules = make(map[int]internal.MaintenanceRule)
+	}
+	// last ids
+	m := &MaintenanceMap{ld: ld, d: d}
+	for key := range d.Records {
+		m.lastRecordID = max(m.lastRecordID, key)
+	}
+	for key := range d.Rules {
+		m.lastRuleID = max(m.lastRuleID, key)
+	}
+	return m
+}
+
+// MaintenanceMap is a struct that represents a repository of maintenance records and schedule rules
+type MaintenanceMap struct {
+	// mu guards d and the last ids
+	mu sync.RWMutex
+	// ld is the loader that saves the records and rules
+	ld internal.MaintenanceLoader
+	// d is the set of records and rules
+	d internal.MaintenanceData
+	// lastRecordID is the greatest id of the records
+	lastRecordID int
+	// lastRuleID is the greatest id of the rules
+	lastRuleID int
+}
+
+// FindAll is a method that returns every record
+func (m *MaintenanceMap) FindAll() (r []internal.MaintenanceRecord, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	r = make([]internal.MaintenanceRecord, 0, len(m.d.Records))
+	for _, value := range m.d.Records {
+		r = append(r, value)
+	}
+	sortRecords(r)
+	return
+}
+
+// FindByVehicle is a method that returns the records of a vehicle, oldest first
+func (m *MaintenanceMap) FindByVehicle(vehicleID int) (r []internal.MaintenanceRecord, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	r = make([]internal.MaintenanceRecord, 0)
+	for _, value := range m.d.Records {
+		if value.VehicleID == vehicleID {
+			r = append(r, value)
+		}
+	}
+	sortRecords(r)
+	return
+}
+
+// Create is a method that creates a record
+func (m *MaintenanceMap) Create(r *internal.MaintenanceRecord) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastRecordID++
+	r.ID = m.lastRecordID
+	m.d.Records[r.ID] = *r
+
+	// save data to JSON file
+	err = m.ld.Save(m.d)
+	return
+}
+
+// FindRules is a method that returns every schedule rule, ordered by id
+func (m *MaintenanceMap) FindRules() (r []internal.MaintenanceRule, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	r = make([]internal.MaintenanceRule, 0, len(m.d.Rules))
+	for _, value := range m.d.Rules {
+		r = append(r, value)
+	}
+	sort.Slice(r, func(i, j int) bool { return r[i].ID < r[j].ID })
+	return
+}
+
+// FindRule is a method that returns a schedule rule by id
+func (m *MaintenanceMap) FindRule(id int) (r internal.MaintenanceRule, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	r, ok := m.d.Rules[id]
+	if !ok {
+		err = fmt.Errorf("%w: %d", internal.ErrMaintenanceRuleNotFound, id)
+	}
+	return
+}
+
+// CreateRule is a method that creates a schedule rule
+func (m *MaintenanceMap) CreateRule(r *internal.MaintenanceRule) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastRuleID++
+	r.ID = m.lastRuleID
+	m.d.Rules[r.ID] = *r
+
+	// save data to JSON file
+	err = m.ld.Save(m.d)
+	return
+}
+
+// sortRecords is a function that sorts records by date, then by id
+func sortRecords(r []internal.MaintenanceRecord) {
+	sort.Slice(r, func(i, j int) bool {
+		if !r[i].Date.Equal(r[j].Date) {
+			return r[i].Date.Before(r[j].Date)
+		}
+		return r[i].ID < r[j].ID
+	})
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// NewReservationMap is a function that returns a new instance of ReservationMap
+func NewReservationMap(ld internal.ReservationLoader, db map[int]internal.Reservation) *ReservationMap {
+	// default db
+	if db == nil {
+		db = make(map[int]internal.Reservation)
+	}
+	// last id
+	m := &ReservationMap{ld: ld, db: db}
+	for key := range db {
+		m.lastID = max(m.lastID, key)
+	}
+	return m
+}
+
+// ReservationMap is a struct that represents a repository of reservations
+type ReservationMap struct {
+	// mu guards db and lastID, conflicts are checked and reservations created under the same lock
+	mu sync.RWMutex
+	// ld is the loader that saves the reservations
+	ld internal.ReservationLoader
+	// db is the set of reservations by id
+	db map[int]internal.Reservation
+	// lastID is the greatest id of the reservations
+	lastID int
+}
+
+// FindAll is a method that returns every reservation
+func (m *ReservationMap) FindAll() (r []internal.Reservation, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	r = make([]internal.Reservation, 0, len(m.db))
+	for _, value := range m.db {
+		r = append(r, value)
+	}
+	sortReservations(r)
+	return
+}
+
+// FindByVehicle is a method that returns the reservations of a vehicle, earliest first
+func (m *ReservationMap) FindByVehicle(vehicleID int) (r []internal.Reservation, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	r = make([]internal.Reservation, 0)
+	for _, value := range m.db {
+		if value.VehicleID == vehicleID {
+			r = append(r, value)
+		}
+	}
+	sortReservations(r)
+	return
+}
+
+// FindByID is a method that returns a reservation by id
+func (m *ReservationMap) FindByID(id int) (r internal.Reservation, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	r, ok := m.db[id]
+	if !ok {
+		err = fmt.Errorf("%w: %d", internal.ErrReservationNotFound, id)
+	}
+	return
+}
+
+// Create is a method that creates a reservation, it fails with ErrReservationConflict when an active reservation of the vehicle overlaps it
+func (m *ReservationMap) Create(r *internal.Reservation) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// - the earliest overlapping reservation is reported
+	var conflict *internal.Reservation
+	for _, value := range m.db {
+		if value.VehicleID != r.VehicleID || !value.Overlaps(r.From, r.To) {
+			continue
+		}
+		if conflict == nil || value.From.Before(conflict.From) {
+			c := value
+			conflict = &c
+		}
+	}
+	if conflict != nil {
+		err = fmt.Errorf("%w: vehicle %d is booked by reservation %d from %s to %s", internal.ErrReservationConflict,
+			r.VehicleID, conflict.ID, conflict.From.Format(time.RFC3339), conflict.To.Format(time.RFC3339))
+		return
+	}
+
+	m.lastID++
+	r.ID = m.lastID
+	m.db[r.ID] = *r
+
+	// save db to JSON file
+	if err = m.ld.Save(m.db); err != nil {
+		// - memory is kept as the file
+		delete(m.db, r.ID)
+		m.lastID--
+	}
+	return
+}
+
+// Update is a method that changes a reservation with update and saves it, nothing changes when update fails
+func (m *ReservationMap) Update(id int, update func(r *internal.Reservation) (err error)) (r internal.Reservation, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, ok := m.db[id]
+	if !ok {
+		err = fmt.Errorf("%w: %d", internal.ErrReservationNotFound, id)
+		return
+	}
+	r = old
+	if err = update(&r); err != nil {
+		return
+	}
+	r.ID = id
+	m.db[id] = r
+
+	// save db to JSON file
+	if err = m.ld.Save(m.db); err != nil {
+		// - memory is kept as the file
+		m.db[id] = old
+	}
+	return
+}
+
+// sortReservations is a function that sorts reservations by start, then by id
+func sortReservations(r []internal.Reservation) {
+	sort.Slice(r, func(i, j int) bool {
+		if !r[i].From.Equal(r[j].From) {
+			return r[i].From.Before(r[j].From)
+		}
+		return r[i].ID < r[j].ID
+	})
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/loader"
+)
+
+// failingReservationLoader is a struct that fails every save, the other methods are not used
+type failingReservationLoader struct {
+	internal.ReservationLoader
+}
+
+func (l failingReservationLoader) Save(r map[int]internal.Reservation) (err error) {
+	err = errors.New("disk full")
+	return
+}
+
+func TestReservationMap_Create(t *testing.T) {
+	// vehicle 1 is booked from 10:00 to 12:00, and was booked from 12:00 to 14:00 by a cancelled reservation
+	at := time.Date(2026, 11, 2, 10, 0, 0, 0, time.UTC)
+	db := map[int]internal.Reservation{
+		1: {ID: 1, VehicleID: 1, Driver: "Ana", From: at, To: at.Add(2 * time.Hour), Status: internal.ReservationStatusActive},
+		2: {ID: 2, VehicleID: 1, Driver: "Luis", From: at.Add(2 * time.Hour), To: at.Add(4 * time.Hour), Status: internal.ReservationStatusCancelled},
+	}
+
+	tests := []struct {
+		name      string
+		vehicleID int
+		from      time.Time
+		to        time.Time
+		wantErr   error
+	}{
+		{name: "overlapping the start", vehicleID: 1, from: at.Add(-time.Hour), to: at.Add(time.Hour), wantErr: internal.ErrReservationConflict},
+		{name: "overlapping the end", vehicleID: 1, from: at.Add(time.Hour), to: at.Add(3 * time.Hour), wantErr: internal.ErrReservationConflict},
+		{name: "inside", vehicleID: 1, from: at.Add(30 * time.Minute), to: at.Add(time.Hour), wantErr: internal.ErrReservationConflict},
+		{name: "back to back before", vehicleID: 1, from: at.Add(-time.Hour), to: at},
+		{name: "back to back after, over the cancelled one", vehicleID: 1, from: at.Add(2 * time.Hour), to: at.Add(3 * time.Hour)},
+		{name: "another vehicle", vehicleID: 2, from: at, to: at.Add(2 * time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			copied := make(map[int]internal.Reservation, len(db))
+			for key, value := range db {
+				copied[key] = value
+			}
+			ld := loader.NewReservationJSONFile(&loader.ConfigReservationJSONFile{Path: filepath.Join(t.TempDir(), "reservations.json")})
+			m := NewReservationMap(ld, copied)
+
+			r := internal.Reservation{VehicleID: tt.vehicleID, Driver: "Eva", From: tt.from, To: tt.to, Status: internal.ReservationStatusActive}
+			err := m.Create(&r)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if all, _ := m.FindAll(); r.ID != 0 || len(all) != len(db) {
+					t.Errorf("got id %d and %d reservations, want the reservation not created", r.ID, len(all))
+				}
+				return
+			}
+
+			// - the reservation is saved with the next id
+			saved, err := ld.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, ok := saved[3]; r.ID != 3 || !ok || !got.From.Equal(tt.from) || !got.To.Equal(tt.to) {
+				t.Errorf("got id %d and saved %+v, want reservation 3 saved", r.ID, got)
+			}
+		})
+	}
+}
+
+func TestReservationMap_Create_SaveFails(t *testing.T) {
+	at := time.Date(2026, 11, 2, 10, 0, 0, 0, time.UTC)
+	m := NewReservationMap(failingReservationLoader{}, nil)
+
+	r := internal.Reservation{VehicleID: 1, Driver: "Eva", From: at, To: at.Add(time.Hour), Status: internal.ReservationStatusActive}
+	if err := m.Create(&r); err == nil {
+		t.Fatal("got no error, want the save error")
+	}
+	// - the failed reservation neither holds the vehicle nor uses an id
+	m.ld = loader.NewReservationJSONFile(&loader.ConfigReservationJSONFile{Path: filepath.Join(t.TempDir(), "reservations.json")})
+	r = internal.Reservation{VehicleID: 1, Driver: "Eva", From: at, To: at.Add(time.Hour), Status: internal.ReservationStatusActive}
+	if err := m.Create(&r); err != nil || r.ID != 1 {
+		t.Errorf("got id %d and error %v, want reservation 1 created", r.ID, err)
+	}
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// NewVehicleIDSequential is a function that returns a new instance of VehicleIDSequential
+func NewVehicleIDSequential() *VehicleIDSequential {
+	return &VehicleIDSequential{}
+}
+
+// VehicleIDSequential is a struct that generates consecutive ids following the greatest id observed
+type VehicleIDSequential struct {
+	// mu guards last
+	mu sync.Mutex
+	// last is the greatest id generated or observed
+	last int
+}
+
+// Observe is a method that informs the generator of an id in use
+func (g *VehicleIDSequential) Observe(id int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.last = max(g.last, id)
+}
+
+// Next is a method that returns the id following the greatest id generated or observed
+func (g *VehicleIDSequential) Next() (id int, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.last++
+	id = g.last
+	return
+}
+
+// NewVehicleUIDv7 is a function that returns a new instance of VehicleUIDv7
+func NewVehicleUIDv7() *VehicleUIDv7 {
+	return &VehicleUIDv7{now: time.Now, rand: rand.Reader}
+}
+
+// VehicleUIDv7 is a struct that generates UUIDv7 identifiers (RFC 9562): the Unix time in milliseconds, a 12-bit counter
+// seeded at random every millisecond and 62 random bits. Identifiers generated in the same millisecond, or after the clock
+// goes back, take the next counter value, so they keep sorting in the order they are generated
+type VehicleUIDv7 struct {
+	// mu guards ms and seq
+	mu sync.Mutex
+	// ms is the time in milliseconds of the last identifier
+	ms int64
+	// seq is the counter of the last identifier
+	seq uint16
+	// now returns the current time
+	now func() time.Time
+	// rand is the source of the random bits
+	rand io.Reader
+}
+
+// Next is a method that returns a new UUIDv7 in its canonical form, e.g. 019a2b3c-4d5e-7f01-8a2b-3c4d5e6f7a8b
+func (g *VehicleUIDv7) Next() (uid string, err error) {
+	var b [16]byte
+	if _, err = io.ReadFull(g.rand, b[6:]); err != nil {
+		err = fmt.Errorf("error generating uuid: %w", err)
+		return
+	}
+
+	g.mu.Lock()
+	ms := g.now().UnixMilli()
+	switch {
+	case ms > g.ms:
+		// - the counter starts below half its range, leaving room for the identifiers of the same millisecond
+		g.ms, g.seq = ms, binary.BigEndian.Uint16(b[6:8])&0x7ff
+	case g.seq < 0xfff:
+		g.seq++
+	default:
+		// - the counter overflows into the next millisecond
+		g.ms, g.seq = g.ms+1, binary.BigEndian.Uint16(b[6:8])&0x7ff
+	}
+	ms, seq := g.ms, g.seq
+	g.mu.Unlock()
+
+	// 48 bits of time, the version and 12 bits of counter, the variant and 62 random bits
+	b[0], b[1], b[2], b[3], b[4], b[5] = byte(ms>>40), byte(ms>>32), byte(ms>>24), byte(ms>>16), byte(ms>>8), byte(ms)
+	b[6], b[7] = 0x70|byte(seq>>8), byte(seq)
+	b[8] = 0x80 | b[8]&0x3f
+	h := hex.EncodeToString(b[:])
+	uid = h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+	return
+}
+
+// crockford is the alphabet of the Crockford's base32 encoding of ULIDs, without I, L, O and U
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewVehicleULID is a function that returns a new instance of VehicleULID
+func NewVehicleULID() *VehicleULID {
+	return &VehicleULID{now: time.Now, rand: rand.Reader}
+}
+
+// VehicleULID is a struct that generates ULID identifiers: the Unix time in milliseconds and 80 random bits, in 26
+// characters of Crockford's base32. Identifiers generated in the same millisecond, or after the clock goes back, take
+// the random bits of the last one plus one, so they keep sorting in the order they are generated
+type VehicleULID struct {
+	// mu guards ms, hi and lo
+	mu sync.Mutex
+	// ms is the time in milliseconds of the last identifier
+	ms int64
+	// hi is the 16 upper random bits of the last identifier
+	hi uint16
+	// lo is the 64 lower random bits of the last identifier
+	lo uint64
+	// now returns the current time
+	now func() time.Time
+	// rand is the source of the random bits
+	rand io.Reader
+}
+
+// Next is a method that returns a new ULID, e.g. 01JAR8ZQ7M3X0V5N9K2C4D6F8H
+func (g *VehicleULID) Next() (uid string, err error) {
+	var r [10]byte
+	if _, err = io.ReadFull(g.rand, r[:]); err != nil {
+		err = fmt.Errorf("error generating ulid: %w", err)
+		return
+	}
+
+	g.mu.Lock()
+	ms := g.now().UnixMilli()
+	switch {
+	case ms > g.ms:
+		g.ms, g.hi, g.lo = ms, binary.BigEndian.Uint16(r[:2]), binary.BigEndian.Uint64(r[2:])
+	default:
+		// - the random bits are incremented, overflowing into the next millisecond
+		g.lo++
+		if g.lo == 0 {
+			g.hi++
+			if g.hi == 0 {
+				g.ms++
+			}
+		}
+	}
+	ms, hi, lo := g.ms, g.hi, g.lo
+	g.mu.Unlock()
+
+	// 128 bits in 26 characters of 5 bits, from the last one: the 64 lower bits, then the time and the 16 upper ones
+	var b [26]byte
+	top := uint64(ms)<<16 | uint64(hi)
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i] = crockford[lo&0x1f]
+		lo = lo>>5 | top<<59
+		top >>= 5
+	}
+	uid = string(b[:])
+	return
+}
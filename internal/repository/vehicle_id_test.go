@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// zeroReader is a reader of zero bytes, so the random bits of the identifiers are known
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (n int, err error) {
+	clear(p)
+	n = len(p)
+	return
+}
+
+// fixedClock is a function that returns a clock at the given Unix time in milliseconds, moved by setting ms
+func fixedClock(ms *int64) func() time.Time {
+	return func() time.Time { return time.UnixMilli(*ms) }
+}
+
+// uuidv7Pattern is the canonical form of a UUIDv7: the version 7 and the variant 10
+var uuidv7Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+// ulidPattern is the form of a ULID: 26 characters of Crockford's base32, the first one at most 7
+var ulidPattern = regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+
+func TestVehicleIDSequential_Next(t *testing.T) {
+	g := NewVehicleIDSequential()
+	for _, id := range []int{89, 6, 22} {
+		g.Observe(id)
+	}
+	for _, want := range []int{90, 91} {
+		if got, err := g.Next(); err != nil || got != want {
+			t.Fatalf("got %d, %v, want %d", got, err, want)
+		}
+	}
+}
+
+func TestVehicleUIDv7_Next(t *testing.T) {
+	// the time of the example of RFC 9562, 2022-02-22T19:22:22Z
+	ms := int64(0x017f22e279b0)
+	g := &VehicleUIDv7{now: fixedClock(&ms), rand: zeroReader{}}
+
+	uid, err := g.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "017f22e2-79b0-7000-8000-000000000000"; uid != want {
+		t.Fatalf("got %s, want %s", uid, want)
+	}
+
+	// - the counter goes on in the same millisecond and after the clock goes back, then overflows into the next one
+	ms -= 1000
+	last := uid
+	for i := 1; i <= 0x1000; i++ {
+		uid, err = g.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !uuidv7Pattern.MatchString(uid) {
+			t.Fatalf("got %s, want a UUIDv7", uid)
+		}
+		if uid <= last {
+			t.Fatalf("got %s after %s, want it greater", uid, last)
+		}
+		last = uid
+	}
+	if got, err := strconv.ParseInt(strings.ReplaceAll(uid[:13], "-", ""), 16, 64); err != nil || got != 0x017f22e279b1 {
+		t.Errorf("got the time %x of %s, want it in the next millisecond", got, uid)
+	}
+
+	// - a new millisecond seeds the counter again
+	ms = 0x017f22e279b0 + 5000
+	if uid, _ = g.Next(); !strings.HasPrefix(uid, "017f22e2-8d38-7000-") {
+		t.Errorf("got %s, want the new time and the counter seeded", uid)
+	}
+}
+
+func TestVehicleULID_Next(t *testing.T) {
+	// the time of the example of the ULID specification, 01ARZ3NDEK
+	ms := int64(1469922850259)
+	g := &VehicleULID{now: fixedClock(&ms), rand: zeroReader{}}
+
+	uid, err := g.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "01ARZ3NDEK0000000000000000"; uid != want {
+		t.Fatalf("got %s, want %s", uid, want)
+	}
+
+	// - the random bits are incremented in the same millisecond and after the clock goes back
+	for _, want := range []string{"01ARZ3NDEK0000000000000001", "01ARZ3NDEK0000000000000002"} {
+		ms--
+		if uid, _ = g.Next(); uid != want {
+			t.Fatalf("got %s, want %s", uid, want)
+		}
+	}
+
+	// - and overflow into the next millisecond
+	g.hi, g.lo = 0xffff, 1<<64-1
+	if uid, _ = g.Next(); uid != "01ARZ3NDEM0000000000000000" {
+		t.Errorf("got %s, want the next millisecond", uid)
+	}
+
+	// - identifiers of a real clock sort in the order they are generated
+	g = NewVehicleULID()
+	last := ""
+	for i := 0; i < 1000; i++ {
+		uid, err = g.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ulidPattern.MatchString(uid) || uid <= last {
+			t.Fatalf("got %s after %s, want a greater ULID", uid, last)
+		}
+		last = uid
+	}
+}
+
+func TestVehicleUID_Next_RandFails(t *testing.T) {
+	broken := iotest.ErrReader(errors.New("no entropy"))
+	ms := int64(1)
+	for name, next := range map[string]func() (string, error){
+		"uuidv7": (&VehicleUIDv7{now: fixedClock(&ms), rand: broken}).Next,
+		"ulid":   (&VehicleULID{now: fixedClock(&ms), rand: broken}).Next,
+	} {
+		if _, err := next(); err == nil {
+			t.Errorf("%s: got no error, want the random bits not read", name)
+		}
+	}
+}
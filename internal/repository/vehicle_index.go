@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/search"
+)
+
+// newVehicleIndex is a function that returns a new instance of vehicleIndex
+func newVehicleIndex() *vehicleIndex {
+	ix := &vehicleIndex{
+		prefixes: make(map[string]*search.PrefixIndex),
+		values:   make(map[string]map[string]map[int]struct{}),
+	}
+	for _, field := range internal.SearchableFields {
+		ix.prefixes[field] = search.NewPrefixIndex()
+		ix.values[field] = make(map[string]map[int]struct{})
+	}
+	return ix
+}
+
+// vehicleIndex is a struct that indexes the searchable fields of the vehicles
+type vehicleIndex struct {
+	// prefixes is a map of the prefix index of each field
+	prefixes map[string]*search.PrefixIndex
+	// values is a map of the ids of the vehicles by field and value
+	values map[string]map[string]map[int]struct{}
+}
+
+// add is a method that adds a vehicle to the index
+func (ix *vehicleIndex) add(v internal.Vehicle) {
+	for _, field := range internal.SearchableFields {
+		value := vehicleField(v, field)
+		if value == "" {
+			continue
+		}
+		ix.prefixes[field].Add(value)
+		ids, ok := ix.values[field][value]
+		if !ok {
+			ids = make(map[int]struct{})
+			ix.values[field][value] = ids
+		}
+		ids[v.Id] = struct{}{}
+	}
+}
+
+// remove is a method that removes a vehicle from the index
+func (ix *vehicleIndex) remove(v internal.Vehicle) {
+	for _, field := range internal.SearchableFields {
+		value := vehicleField(v, field)
+		ids, ok := ix.values[field][value]
+		if !ok {
+			continue
+		}
+		if _, ok := ids[v.Id]; !ok {
+			continue
+		}
+		ix.prefixes[field].Remove(value)
+		delete(ids, v.Id)
+		if len(ids) == 0 {
+			delete(ix.values[field], value)
+		}
+	}
+}
+
+// vehicleField is a function that returns the value of a searchable field of a vehicle
+func vehicleField(v internal.Vehicle, field string) string {
+	switch field {
+	case internal.FieldBrand:
+		return v.Brand
+	case internal.FieldModel:
+		return v.Model
+	case internal.FieldRegistration:
+		return v.Registration
+	case internal.FieldColor:
+		return v.Color
+	}
+	return ""
+}
@@ -2,33 +2,128 @@ package repository
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/rhinosc/code-review-1/internal"
-	"github.com/rhinosc/code-review-1/internal/loader"
+	"github.com/rhinosc/code-review-1/internal/search"
 )
 
 // NewVehicleMap is a function that returns a new instance of VehicleMap
-func NewVehicleMap(ld *loader.VehicleJSONFile, db map[int]internal.Vehicle, lastID int) *VehicleMap {
+func NewVehicleMap(ld internal.VehicleLoader, db map[int]internal.Vehicle, gen internal.VehicleIDGenerator) *VehicleMap {
 	// default db
 	defaultDb := make(map[int]internal.Vehicle)
 	if db != nil {
 		defaultDb = db
 	}
-	return &VehicleMap{ld: *ld, db: defaultDb, lastID: lastID}
+	// index
+	ix := newVehicleIndex()
+	for _, value := range defaultDb {
+		ix.add(value)
+	}
+	// ids
+	ids := make([]int, 0, len(defaultDb))
+	for key := range defaultDb {
+		ids = append(ids, key)
+		gen.Observe(key)
+	}
+	sort.Ints(ids)
+	m := &VehicleMap{ld: ld, db: defaultDb, ix: ix, ids: ids, gen: gen, onOutbox: func() {}}
+	// outbox
+	if ol, ok := ld.(internal.VehicleOutboxLoader); ok {
+		m.outbox = ol.Outbox()
+	}
+	for _, value := range m.outbox {
+		m.lastOutboxID = max(m.lastOutboxID, value.ID)
+	}
+	return m
 }
 
-// VehicleMap is a struct that represents a vehicle repository
+// VehicleMap is a struct that represents a vehicle repository, implementing the OutboxRepository interface too.
+// The outbox is saved in the same file as the vehicles when the loader keeps one, and only in memory otherwise
 type VehicleMap struct {
-	// ld is the loader that loads the vehicles from a JSON file
-	ld loader.VehicleJSONFile
+	// mu guards db, ix, ids, outbox and lastOutboxID
+	mu sync.RWMutex
+	// ld is the loader that saves the vehicles
+	ld internal.VehicleLoader
 	// db is a map of vehicles
-	db     map[int]internal.Vehicle
-	lastID int
+	db map[int]internal.Vehicle
+	// ix is the index of the searchable fields of the vehicles in db
+	ix *vehicleIndex
+	// ids is the sorted list of the ids in db
+	ids []int
+	// gen is the generator of the ids of new vehicles
+	gen internal.VehicleIDGenerator
+	// uid is the generator of the unique identifiers of new vehicles, nil when they are not given one
+	uid internal.VehicleUIDGenerator
+	// outbox is the list of the entries waiting to be relayed, oldest first
+	outbox []internal.OutboxEntry
+	// lastOutboxID is the greatest id of the outbox entries
+	lastOutboxID int
+	// onOutbox is called after a change adding entries to the outbox is saved
+	onOutbox func()
+}
+
+// SetUIDGenerator is a method that sets the generator of the unique identifiers of new vehicles, such as UUIDv7 or ULID
+func (r *VehicleMap) SetUIDGenerator(g internal.VehicleUIDGenerator) {
+	r.uid = g
+}
+
+// OnOutbox is a method that sets the function called after a change adding entries to the outbox is saved,
+// such as waking up the relay. It is called without the lock held
+func (r *VehicleMap) OnOutbox(fn func()) {
+	r.onOutbox = fn
+}
+
+// stage is a method that returns the outbox with the events of raise for a vehicle appended, and the last id it takes.
+// The outbox of the repository is left as it is, so it can be kept when the save fails; mu must be held
+func (r *VehicleMap) stage(raise internal.EventsFunc, v internal.Vehicle) (o []internal.OutboxEntry, lastID int) {
+	o, lastID = r.outbox, r.lastOutboxID
+	if raise == nil {
+		return
+	}
+	o, lastID = r.stageEvents(raise(v))
+	return
+}
+
+// stageEvents is a method that returns the outbox with events appended, and the last id it takes; mu must be held
+func (r *VehicleMap) stageEvents(e []internal.Event) (o []internal.OutboxEntry, lastID int) {
+	o, lastID = r.outbox, r.lastOutboxID
+	if len(e) == 0 {
+		return
+	}
+	o = make([]internal.OutboxEntry, 0, len(r.outbox)+len(e))
+	o = append(o, r.outbox...)
+	for _, value := range e {
+		lastID++
+		o = append(o, internal.OutboxEntry{ID: lastID, Event: value})
+	}
+	return
+}
+
+// save is a method that saves the vehicles with the outbox, at once when the loader keeps the outbox; mu must be held
+func (r *VehicleMap) save(db map[int]internal.Vehicle, o []internal.OutboxEntry) (err error) {
+	if ol, ok := r.ld.(internal.VehicleOutboxLoader); ok {
+		err = ol.SaveWithOutbox(db, o)
+		return
+	}
+	err = r.ld.Save(db)
+	return
+}
+
+// commit is a method that keeps a saved outbox and reports whether it grew; mu must be held
+func (r *VehicleMap) commit(o []internal.OutboxEntry, lastID int) (grew bool) {
+	grew = lastID > r.lastOutboxID
+	r.outbox, r.lastOutboxID = o, lastID
+	return
 }
 
 // FindAll is a method that returns a map of all vehicles
 func (r *VehicleMap) FindAll() (v map[int]internal.Vehicle, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	v = make(map[int]internal.Vehicle)
 
 	// copy db
@@ -39,55 +134,376 @@ func (r *VehicleMap) FindAll() (v map[int]internal.Vehicle, err error) {
 	return
 }
 
-// Create is a method that creates a vehicle
-func (r *VehicleMap) Create(v *internal.Vehicle) (err error) {
-	r.lastID++
-	v.Id = r.lastID
+// Create is a method that creates a vehicle with the events of raise, nothing changes when the save fails
+func (r *VehicleMap) Create(v *internal.Vehicle, raise internal.EventsFunc) (err error) {
+	r.mu.Lock()
+	grew := false
+	defer func() {
+		r.mu.Unlock()
+		if grew {
+			r.onOutbox()
+		}
+	}()
+
+	id, err := r.gen.Next()
+	if err != nil {
+		return
+	}
+	// - never overwrite a stored vehicle
+	if _, ok := r.db[id]; ok {
+		err = fmt.Errorf("%w: %d", internal.ErrVehicleDuplicateID, id)
+		return
+	}
+	var uid string
+	if r.uid != nil {
+		if uid, err = r.uid.Next(); err != nil {
+			return
+		}
+	}
+	v.Id, v.UID = id, uid
+	r.insertID(v.Id)
 	r.db[v.Id] = *v
+	r.ix.add(*v)
+	o, lastID := r.stage(raise, *v)
+
+	// save db to JSON file
+	if err = r.save(r.db, o); err != nil {
+		// - memory is kept as the file
+		delete(r.db, v.Id)
+		r.ix.remove(*v)
+		r.removeID(v.Id)
+		return
+	}
+	grew = r.commit(o, lastID)
+	return
+}
+
+// FindByID is a method that returns a vehicle by id
+func (r *VehicleMap) FindByID(id int) (v internal.Vehicle, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	v, ok := r.db[id]
+	if !ok {
+		err = fmt.Errorf("%w: %d", internal.ErrVehicleNotFound, id)
+	}
+	return
+}
+
+// Update is a method that changes a vehicle with update and saves it with the events of raise, nothing changes when update fails.
+// The vehicle is read and written under the lock, so concurrent updates of the same vehicle do not overwrite each other
+func (r *VehicleMap) Update(id int, update func(v *internal.Vehicle) (err error), raise internal.EventsFunc) (v internal.Vehicle, err error) {
+	r.mu.Lock()
+	grew := false
+	defer func() {
+		r.mu.Unlock()
+		if grew {
+			r.onOutbox()
+		}
+	}()
+
+	old, ok := r.db[id]
+	if !ok {
+		err = fmt.Errorf("%w: %d", internal.ErrVehicleNotFound, id)
+		return
+	}
+	v = old
+	if err = update(&v); err != nil {
+		return
+	}
+	v.Id, v.UID = id, old.UID
+	r.db[id] = v
+	r.ix.remove(old)
+	r.ix.add(v)
+	o, lastID := r.stage(raise, v)
 
 	// save db to JSON file
-	err = r.ld.Save(r.db)
+	if err = r.save(r.db, o); err != nil {
+		// - memory is kept as the file
+		r.db[id] = old
+		r.ix.remove(v)
+		r.ix.add(old)
+		return
+	}
+	grew = r.commit(o, lastID)
 	return
 }
 
-// GetByColorAndYear is a method that returns a map of vehicles by color and year
-func (r *VehicleMap) GetByColorAndYear(color string, year int) (v map[int]internal.Vehicle, err error) {
+// Replace is a method that swaps every vehicle for the given ones at once, saving them with the reset event when any
+// vehicle changes. The index is built before taking the lock so readers are only blocked for the swap.
+// They are saved before the swap like Restore does, through the loader of the watcher reloading them, which takes the save
+// as its own and fails it when the server saved since the file was loaded; a failed save changes nothing
+func (r *VehicleMap) Replace(db map[int]internal.Vehicle) (err error) {
+	ix, ids := indexVehicles(db)
+
+	r.mu.Lock()
+	grew := false
+	defer func() {
+		r.mu.Unlock()
+		if grew {
+			r.onOutbox()
+		}
+	}()
+
+	if e := r.reset(internal.ResetReload, db); len(e) > 0 {
+		o, lastID := r.stageEvents(e)
+		if err = r.save(db, o); err != nil {
+			return
+		}
+		grew = r.commit(o, lastID)
+	}
+	for _, id := range ids {
+		r.gen.Observe(id)
+	}
+	r.db, r.ix, r.ids = db, ix, ids
+	return
+}
+
+// Restore is a method that swaps every vehicle for the given ones at once and saves them with the reset event.
+// They are saved before the swap while holding the lock, so no vehicle is created in between and a failed save changes nothing
+func (r *VehicleMap) Restore(db map[int]internal.Vehicle) (err error) {
+	ix, ids := indexVehicles(db)
+
+	r.mu.Lock()
+	grew := false
+	defer func() {
+		r.mu.Unlock()
+		if grew {
+			r.onOutbox()
+		}
+	}()
+
+	o, lastID := r.stageEvents(r.reset(internal.ResetRestore, db))
+	if err = r.save(db, o); err != nil {
+		return
+	}
+	grew = r.commit(o, lastID)
+	for _, id := range ids {
+		r.gen.Observe(id)
+	}
+	r.db, r.ix, r.ids = db, ix, ids
+	return
+}
+
+// reset is a method that returns the reset event of a swap for the given vehicles, none when no vehicle changes; mu must be held
+func (r *VehicleMap) reset(reason internal.ResetReason, db map[int]internal.Vehicle) (e []internal.Event) {
+	value := internal.NewResetEvent(reason, r.db, db)
+	if value.Reset.Empty() {
+		return
+	}
+	e = []internal.Event{value}
+	return
+}
+
+// indexVehicles is a function that builds the index and the sorted list of ids of the given vehicles
+func indexVehicles(db map[int]internal.Vehicle) (ix *vehicleIndex, ids []int) {
+	ix = newVehicleIndex()
+	ids = make([]int, 0, len(db))
+	for key, value := range db {
+		ix.add(value)
+		ids = append(ids, key)
+	}
+	sort.Ints(ids)
+	return
+}
+
+// FindAfter is a method that returns up to limit vehicles with an id greater than afterID, ordered by id
+func (r *VehicleMap) FindAfter(afterID, limit int) (v []internal.Vehicle, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i := sort.SearchInts(r.ids, afterID+1)
+	for ; i < len(r.ids) && len(v) < limit; i++ {
+		v = append(v, r.db[r.ids[i]])
+	}
+	return
+}
+
+// Delete is a method that deletes a vehicle with the events of raise and returns it, nothing changes when the save fails
+func (r *VehicleMap) Delete(id int, raise internal.EventsFunc) (v internal.Vehicle, err error) {
+	r.mu.Lock()
+	grew := false
+	defer func() {
+		r.mu.Unlock()
+		if grew {
+			r.onOutbox()
+		}
+	}()
+
+	v, ok := r.db[id]
+	if !ok {
+		err = fmt.Errorf("%w: %d", internal.ErrVehicleNotFound, id)
+		return
+	}
+	delete(r.db, id)
+	o, lastID := r.stage(raise, v)
+
+	// save db to JSON file
+	if err = r.save(r.db, o); err != nil {
+		// - memory is kept as the file
+		r.db[id] = v
+		return
+	}
+	grew = r.commit(o, lastID)
+	r.ix.remove(v)
+	r.removeID(id)
+	return
+}
+
+// removeID is a method that removes an id from the sorted list of ids, it must be called with mu held
+func (r *VehicleMap) removeID(id int) {
+	i := sort.SearchInts(r.ids, id)
+	if i < len(r.ids) && r.ids[i] == id {
+		r.ids = append(r.ids[:i], r.ids[i+1:]...)
+	}
+}
+
+// FindPending is a method that returns up to limit entries of the outbox, oldest first
+func (r *VehicleMap) FindPending(limit int) (o []internal.OutboxEntry, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n := min(limit, len(r.outbox))
+	o = make([]internal.OutboxEntry, n)
+	copy(o, r.outbox[:n])
+	return
+}
+
+// Ack is a method that removes an entry relayed to every sink, nothing changes when the save fails
+func (r *VehicleMap) Ack(id int) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i, err := r.findOutbox(id)
+	if err != nil {
+		return
+	}
+	o := make([]internal.OutboxEntry, 0, len(r.outbox)-1)
+	o = append(o, r.outbox[:i]...)
+	o = append(o, r.outbox[i+1:]...)
+
+	// save db to JSON file
+	if err = r.save(r.db, o); err != nil {
+		return
+	}
+	r.outbox = o
+	return
+}
+
+// Fail is a method that records a failed attempt to relay an entry, nothing changes when the save fails
+func (r *VehicleMap) Fail(id int, delivered []string, lastError string) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i, err := r.findOutbox(id)
+	if err != nil {
+		return
+	}
+	o := make([]internal.OutboxEntry, len(r.outbox))
+	copy(o, r.outbox)
+	o[i].Attempts++
+	o[i].LastError = lastError
+	o[i].Delivered = delivered
+
+	// save db to JSON file
+	if err = r.save(r.db, o); err != nil {
+		return
+	}
+	r.outbox = o
+	return
+}
+
+// findOutbox is a method that returns the position of an entry in the outbox, it must be called with mu held
+func (r *VehicleMap) findOutbox(id int) (i int, err error) {
+	i = sort.Search(len(r.outbox), func(i int) bool { return r.outbox[i].ID >= id })
+	if i == len(r.outbox) || r.outbox[i].ID != id {
+		err = fmt.Errorf("%w: %d", internal.ErrOutboxEntryNotFound, id)
+	}
+	return
+}
+
+// insertID is a method that inserts an id in the sorted list of ids, it must be called with mu held
+func (r *VehicleMap) insertID(id int) {
+	i := sort.SearchInts(r.ids, id)
+	// ids are usually generated in increasing order
+	if i == len(r.ids) {
+		r.ids = append(r.ids, id)
+		return
+	}
+	r.ids = append(r.ids, 0)
+	copy(r.ids[i+1:], r.ids[i:])
+	r.ids[i] = id
+}
+
+// FindByStatus is a method that returns a map of the vehicles in the statuses of the filter, every vehicle when it is empty
+func (r *VehicleMap) FindByStatus(f internal.StatusFilter) (v map[int]internal.Vehicle, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	v = make(map[int]internal.Vehicle)
 
 	// filter db
 	for key, value := range r.db {
-		if value.Color == color && value.FabricationYear == year {
+		if f.Contains(value.Status) {
+			v[key] = value
+		}
+	}
+
+	return
+}
+
+// GetByColorAndYear is a method that returns a map of vehicles by color and year in the statuses of the filter
+func (r *VehicleMap) GetByColorAndYear(color string, year int, status internal.StatusFilter) (v map[int]internal.Vehicle, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	v = make(map[int]internal.Vehicle)
+
+	// filter db
+	for key, value := range r.db {
+		if strings.EqualFold(value.Color, color) && value.FabricationYear == year && status.Contains(value.Status) {
 			v[key] = value
 		}
 	}
 
 	if len(v) == 0 {
-		err = fmt.Errorf("no vehicles found with color %s and year %d", color, year)
+		err = fmt.Errorf("%w: no vehicles found with color %s and year %d%s", internal.ErrVehicleNotFound, color, year, inStatus(status))
 		return
 	}
 
 	return
 }
 
-// GetByDimensions is a method that returns a map of vehicles by dimensions
-func (r *VehicleMap) GetByDimensions(minLength, maxLength, minWidth, maxWidth float64) (v map[int]internal.Vehicle, err error) {
+// GetByDimensions is a method that returns a map of vehicles by dimensions in the statuses of the filter
+func (r *VehicleMap) GetByDimensions(f internal.DimensionsFilter, status internal.StatusFilter) (v map[int]internal.Vehicle, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	v = make(map[int]internal.Vehicle)
 
 	// filter db
 	for key, value := range r.db {
-		if value.Length >= minLength && value.Length <= maxLength && value.Width >= minWidth && value.Width <= maxWidth {
+		if f.Contains(value.Dimensions) && status.Contains(value.Status) {
 			v[key] = value
 		}
 	}
 
 	if len(v) == 0 {
-		err = fmt.Errorf("no vehicles found with dimensions between %f and %f for length and between %f and %f for width", minLength, maxLength, minWidth, maxWidth)
+		err = fmt.Errorf("%w: no vehicles found with length in %s, width in %s and height in %s%s", internal.ErrVehicleNotFound, f.Length, f.Width, f.Height, inStatus(status))
 		return
 	}
 
 	return
 }
 
+// inStatus is a function that describes the statuses of a filter for an error message, nothing when it is empty
+func inStatus(f internal.StatusFilter) string {
+	if len(f) == 0 {
+		return ""
+	}
+	return " in status " + f.String()
+}
+
 // GetAverageSpeedByBrand is a method that returns the average speed of a vehicle
 func (r *VehicleMap) GetAverageSpeedByBrand(brand string) (averageSpeed float64, err error) {
 
@@ -96,6 +512,9 @@ func (r *VehicleMap) GetAverageSpeedByBrand(brand string) (averageSpeed float64,
 		return
 	}
 
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	var brandCount int
 
 	//filter db
@@ -115,3 +534,101 @@ func (r *VehicleMap) GetAverageSpeedByBrand(brand string) (averageSpeed float64,
 
 	return
 }
+
+// Search is a method that returns up to limit vehicles matching a typo-tolerant query, most relevant first.
+// Every word of the query has to match one of the searchable fields and the score is the average of the best match of each word
+func (r *VehicleMap) Search(query string, limit int) (m []internal.VehicleMatch, err error) {
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// score each distinct value once per term
+	type hit struct {
+		score  float64
+		fields map[string]struct{}
+	}
+	hits := make([]map[int]*hit, len(terms))
+	for i, term := range terms {
+		hits[i] = make(map[int]*hit)
+		for _, field := range internal.SearchableFields {
+			for value, ids := range r.ix.values[field] {
+				s := search.Similarity(term, value)
+				if s == 0 {
+					continue
+				}
+				for id := range ids {
+					h, ok := hits[i][id]
+					if !ok {
+						h = &hit{fields: make(map[string]struct{})}
+						hits[i][id] = h
+					}
+					h.score = max(h.score, s)
+					h.fields[field] = struct{}{}
+				}
+			}
+		}
+	}
+
+	// keep the vehicles matched by every term
+	for id, first := range hits[0] {
+		score := first.score
+		fields := make(map[string]struct{})
+		for field := range first.fields {
+			fields[field] = struct{}{}
+		}
+		matched := true
+		for _, termHits := range hits[1:] {
+			h, ok := termHits[id]
+			if !ok {
+				matched = false
+				break
+			}
+			score += h.score
+			for field := range h.fields {
+				fields[field] = struct{}{}
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		match := internal.VehicleMatch{Vehicle: r.db[id], Score: score / float64(len(terms))}
+		for _, field := range internal.SearchableFields {
+			if _, ok := fields[field]; ok {
+				match.Fields = append(match.Fields, field)
+			}
+		}
+		m = append(m, match)
+	}
+
+	sort.Slice(m, func(i, j int) bool {
+		if m[i].Score != m[j].Score {
+			return m[i].Score > m[j].Score
+		}
+		return m[i].Id < m[j].Id
+	})
+	if limit > 0 && len(m) > limit {
+		m = m[:limit]
+	}
+	return
+}
+
+// Suggest is a method that returns up to limit values of a field starting with a prefix
+func (r *VehicleMap) Suggest(field, prefix string, limit int) (s []internal.Suggestion, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ix, ok := r.ix.prefixes[field]
+	if !ok {
+		err = fmt.Errorf("%w: %s", internal.ErrFieldNotSearchable, field)
+		return
+	}
+	for _, sg := range ix.Prefix(prefix, limit) {
+		s = append(s, internal.Suggestion{Value: sg.Value, Count: sg.Count})
+	}
+	return
+}
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/loader"
+)
+
+// testVehicle is a function that returns a valid vehicle of a brand
+func testVehicle(id int, brand string) internal.Vehicle {
+	return internal.Vehicle{
+		Id:                id,
+		VehicleAttributes: internal.VehicleAttributes{Brand: brand, Model: "T", FabricationYear: 1920, Capacity: 2},
+		Status:            internal.VehicleStatusInService,
+	}
+}
+
+// failingLoader is a struct that fails every save, the other methods are not used
+type failingLoader struct {
+	internal.VehicleLoader
+}
+
+func (l failingLoader) Save(v map[int]internal.Vehicle) (err error) {
+	err = errors.New("disk full")
+	return
+}
+
+// newTestVehicleMap is a function that returns a repository saving to a file in a temporary directory, with the
+// loader reading it back and a counter of the wake-ups of the relay
+func newTestVehicleMap(t *testing.T, db map[int]internal.Vehicle) (r *VehicleMap, ld *loader.VehicleJSONFile, woken *int) {
+	t.Helper()
+	ld = loader.NewVehicleJSONFile(filepath.Join(t.TempDir(), "vehicles.json"))
+	r = NewVehicleMap(ld, db, NewVehicleIDSequential())
+	woken = new(int)
+	r.OnOutbox(func() { *woken++ })
+	return
+}
+
+// pendingResets is a function that returns the reset events pending in the outbox of a repository
+func pendingResets(t *testing.T, r *VehicleMap) (e []internal.Event) {
+	t.Helper()
+	o, err := r.FindPending(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, value := range o {
+		if value.Event.Type == internal.EventVehiclesReset {
+			e = append(e, value.Event)
+		}
+	}
+	return
+}
+
+func TestVehicleMap_Restore_ResetEvent(t *testing.T) {
+	before := map[int]internal.Vehicle{1: testVehicle(1, "Ford"), 2: testVehicle(2, "Fiat"), 3: testVehicle(3, "Audi")}
+	r, ld, woken := newTestVehicleMap(t, before)
+
+	after := map[int]internal.Vehicle{1: testVehicle(1, "Ford"), 2: testVehicle(2, "Seat"), 4: testVehicle(4, "Kia")}
+	if err := r.Restore(after); err != nil {
+		t.Fatal(err)
+	}
+
+	want := internal.VehiclesReset{Reason: internal.ResetRestore, Vehicles: 3, Created: []int{4}, Updated: []int{2}, Deleted: []int{3}}
+	e := pendingResets(t, r)
+	if len(e) != 1 || !reflect.DeepEqual(*e[0].Reset, want) {
+		t.Fatalf("got reset events %+v, want one with %+v", e, want)
+	}
+	if *woken != 1 {
+		t.Errorf("got %d wake-ups of the relay, want 1", *woken)
+	}
+
+	// - the event is saved with the vehicles
+	v, err := ld.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v) != 3 {
+		t.Errorf("got %d vehicles saved, want 3", len(v))
+	}
+	o := ld.Outbox()
+	if len(o) != 1 || o[0].Event.ID != e[0].ID || !reflect.DeepEqual(*o[0].Event.Reset, want) {
+		t.Fatalf("got outbox saved %+v, want the reset event %s", o, e[0].ID)
+	}
+}
+
+func TestVehicleMap_Restore_SaveFails(t *testing.T) {
+	before := map[int]internal.Vehicle{1: testVehicle(1, "Ford")}
+	r := NewVehicleMap(failingLoader{}, before, NewVehicleIDSequential())
+	woken := 0
+	r.OnOutbox(func() { woken++ })
+
+	if err := r.Restore(map[int]internal.Vehicle{2: testVehicle(2, "Kia")}); err == nil {
+		t.Fatal("got no error, want the error of the save")
+	}
+	if e := pendingResets(t, r); len(e) != 0 {
+		t.Errorf("got reset events %+v after a failed save, want none", e)
+	}
+	if woken != 0 {
+		t.Errorf("got %d wake-ups of the relay, want none", woken)
+	}
+	if _, err := r.FindByID(1); err != nil {
+		t.Errorf("got error %v finding a vehicle kept by the failed restore", err)
+	}
+}
+
+func TestVehicleMap_Replace_ResetEvent(t *testing.T) {
+	before := map[int]internal.Vehicle{1: testVehicle(1, "Ford"), 2: testVehicle(2, "Fiat")}
+	r, ld, woken := newTestVehicleMap(t, before)
+
+	changed := testVehicle(1, "Ford")
+	changed.Status = internal.VehicleStatusInMaintenance
+	if err := r.Replace(map[int]internal.Vehicle{1: changed}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := internal.VehiclesReset{Reason: internal.ResetReload, Vehicles: 1, Updated: []int{1}, Deleted: []int{2}}
+	e := pendingResets(t, r)
+	if len(e) != 1 || !reflect.DeepEqual(*e[0].Reset, want) {
+		t.Fatalf("got reset events %+v, want one with %+v", e, want)
+	}
+	if *woken != 1 {
+		t.Errorf("got %d wake-ups of the relay, want 1", *woken)
+	}
+
+	// - the event is saved with the vehicles
+	v, err := ld.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v) != 1 {
+		t.Errorf("got %d vehicles saved, want 1", len(v))
+	}
+	if o := ld.Outbox(); len(o) != 1 || o[0].Event.ID != e[0].ID {
+		t.Fatalf("got outbox saved %+v, want the reset event %s", o, e[0].ID)
+	}
+
+	// - the same vehicles again change nothing, nor are saved
+	if err := r.Replace(map[int]internal.Vehicle{1: changed}); err != nil {
+		t.Fatal(err)
+	}
+	if e := pendingResets(t, r); len(e) != 1 || *woken != 1 {
+		t.Errorf("got %d reset events and %d wake-ups after reloading the same vehicles, want 1 and 1", len(e), *woken)
+	}
+}
+
+func TestVehicleMap_Replace_SaveFails(t *testing.T) {
+	r := NewVehicleMap(failingLoader{}, map[int]internal.Vehicle{1: testVehicle(1, "Ford")}, NewVehicleIDSequential())
+
+	if err := r.Replace(map[int]internal.Vehicle{2: testVehicle(2, "Kia")}); err == nil {
+		t.Fatal("got no error, want the save failed")
+	}
+	if _, err := r.FindByID(1); err != nil {
+		t.Errorf("got error %v finding a vehicle kept by the failed reload", err)
+	}
+	if e := pendingResets(t, r); len(e) != 0 {
+		t.Errorf("got %d reset events after the failed reload, want none", len(e))
+	}
+}
+
+func TestEventFilter_Matches_Reset(t *testing.T) {
+	e := internal.NewResetEvent(internal.ResetRestore, nil, map[int]internal.Vehicle{1: testVehicle(1, "Ford")})
+	f := internal.EventFilter{Brands: []string{"Kia"}, Statuses: internal.StatusFilter{internal.VehicleStatusDecommissioned}}
+	if !f.Matches(e) {
+		t.Error("got a reset filtered out, want it to pass every filter")
+	}
+}
+
+func TestVehicleMap_Create_UID(t *testing.T) {
+	r, ld, _ := newTestVehicleMap(t, map[int]internal.Vehicle{89: testVehicle(89, "Ford")})
+	r.SetUIDGenerator(NewVehicleULID())
+
+	v := testVehicle(0, "Fiat")
+	if err := r.Create(&v, nil); err != nil {
+		t.Fatal(err)
+	}
+	if v.Id != 90 || !ulidPattern.MatchString(v.UID) {
+		t.Fatalf("got id %d and uid %q, want 90 and a ULID", v.Id, v.UID)
+	}
+
+	// - an update keeps the uid, whatever the vehicle it is given
+	u, err := r.Update(v.Id, func(u *internal.Vehicle) (err error) {
+		*u = testVehicle(0, "Seat")
+		return
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.UID != v.UID {
+		t.Errorf("got uid %q after the update, want %q", u.UID, v.UID)
+	}
+
+	// - the uid is saved with the vehicle
+	db, err := ld.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db[v.Id].UID != v.UID || db[89].UID != "" {
+		t.Errorf("got uids %q and %q saved, want %q and none", db[v.Id].UID, db[89].UID, v.UID)
+	}
+}
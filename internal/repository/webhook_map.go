@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// maxWebhookDeliveries is the number of the latest attempts of each webhook kept in the delivery log
+const maxWebhookDeliveries = 100
+
+// NewWebhookMap is a function that returns a new instance of WebhookMap
+func NewWebhookMap(ld internal.WebhookLoader, d internal.WebhookData) *WebhookMap {
+	// default data
+	if d.Webhooks == nil {
+		d.Webhooks = make(map[int]internal.Webhook)
+	}
+	if d.DeadLetters == nil {
+		d.DeadLetters = make(map[int]internal.WebhookDeadLetter)
+	}
+	// last ids
+	m := &WebhookMap{ld: ld, d: d}
+	for key := range d.Webhooks {
+		m.lastWebhookID = max(m.lastWebhookID, key)
+	}
+	for _, value := range d.Deliveries {
+		m.lastDeliveryID = max(m.lastDeliveryID, value.ID)
+	}
+	for key := range d.DeadLetters {
+		m.lastDeadLetterID = max(m.lastDeadLetterID, key)
+	}
+	return m
+}
+
+// WebhookMap is a struct that represents a repository of webhooks, their delivery logs and dead letters
+type WebhookMap struct {
+	// mu guards d and the last ids
+	mu sync.RWMutex
+	// ld is the loader that saves the webhooks, delivery logs and dead letters
+	ld internal.WebhookLoader
+	// d is the set of webhooks, delivery logs and dead letters
+	d internal.WebhookData
+	// lastWebhookID is the greatest id of the webhooks
+	lastWebhookID int
+	// lastDeliveryID is the greatest id of the attempts
+	lastDeliveryID int
+	// lastDeadLetterID is the greatest id of the dead letters
+	lastDeadLetterID int
+}
+
+// FindAll is a method that returns every webhook, ordered by id
+func (m *WebhookMap) FindAll() (w []internal.Webhook, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	w = make([]internal.Webhook, 0, len(m.d.Webhooks))
+	for _, value := range m.d.Webhooks {
+		w = append(w, value)
+	}
+	sort.Slice(w, func(i, j int) bool { return w[i].ID < w[j].ID })
+	return
+}
+
+// FindByID is a method that returns a webhook by id
+func (m *WebhookMap) FindByID(id int) (w internal.Webhook, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	w, ok := m.d.Webhooks[id]
+	if !ok {
+		err = fmt.Errorf("%w: %d", internal.ErrWebhookNotFound, id)
+	}
+	return
+}
+
+// Create is a method that creates a webhook
+func (m *WebhookMap) Create(w *internal.Webhook) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastWebhookID++
+	w.ID = m.lastWebhookID
+	m.d.Webhooks[w.ID] = *w
+
+	// save data to JSON file
+	if err = m.ld.Save(m.d); err != nil {
+		// - memory is kept as the file
+		delete(m.d.Webhooks, w.ID)
+		m.lastWebhookID--
+	}
+	return
+}
+
+// Delete is a method that deletes a webhook with its delivery log and dead letters
+func (m *WebhookMap) Delete(id int) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w, ok := m.d.Webhooks[id]
+	if !ok {
+		err = fmt.Errorf("%w: %d", internal.ErrWebhookNotFound, id)
+		return
+	}
+	old := m.d
+	d := internal.WebhookData{
+		Webhooks:    make(map[int]internal.Webhook, len(old.Webhooks)),
+		Deliveries:  make([]internal.WebhookDelivery, 0, len(old.Deliveries)),
+		DeadLetters: make(map[int]internal.WebhookDeadLetter, len(old.DeadLetters)),
+	}
+	for key, value := range old.Webhooks {
+		if key != w.ID {
+			d.Webhooks[key] = value
+		}
+	}
+	for _, value := range old.Deliveries {
+		if value.WebhookID != w.ID {
+			d.Deliveries = append(d.Deliveries, value)
+		}
+	}
+	for key, value := range old.DeadLetters {
+		if value.WebhookID != w.ID {
+			d.DeadLetters[key] = value
+		}
+	}
+	m.d = d
+
+	// save data to JSON file
+	if err = m.ld.Save(m.d); err != nil {
+		// - memory is kept as the file
+		m.d = old
+	}
+	return
+}
+
+// AddDelivery is a method that logs an attempt, only the latest maxWebhookDeliveries attempts of each webhook are kept
+func (m *WebhookMap) AddDelivery(d *internal.WebhookDelivery) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old := m.d.Deliveries
+	m.lastDeliveryID++
+	d.ID = m.lastDeliveryID
+
+	// - drop the oldest attempt of the webhook past the limit
+	deliveries := make([]internal.WebhookDelivery, 0, len(old)+1)
+	n := 0
+	for _, value := range old {
+		if value.WebhookID == d.WebhookID {
+			n++
+		}
+	}
+	for _, value := range old {
+		if value.WebhookID == d.WebhookID && n >= maxWebhookDeliveries {
+			n--
+			continue
+		}
+		deliveries = append(deliveries, value)
+	}
+	m.d.Deliveries = append(deliveries, *d)
+
+	// save data to JSON file
+	if err = m.ld.Save(m.d); err != nil {
+		// - memory is kept as the file
+		m.d.Deliveries = old
+		m.lastDeliveryID--
+	}
+	return
+}
+
+// FindDeliveries is a method that returns the logged attempts of a webhook, newest first
+func (m *WebhookMap) FindDeliveries(webhookID int) (d []internal.WebhookDelivery, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	d = make([]internal.WebhookDelivery, 0)
+	for i := len(m.d.Deliveries) - 1; i >= 0; i-- {
+		if m.d.Deliveries[i].WebhookID == webhookID {
+			d = append(d, m.d.Deliveries[i])
+		}
+	}
+	return
+}
+
+// AddDeadLetter is a method that creates a dead letter
+func (m *WebhookMap) AddDeadLetter(dl *internal.WebhookDeadLetter) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastDeadLetterID++
+	dl.ID = m.lastDeadLetterID
+	m.d.DeadLetters[dl.ID] = *dl
+
+	// save data to JSON file
+	if err = m.ld.Save(m.d); err != nil {
+		// - memory is kept as the file
+		delete(m.d.DeadLetters, dl.ID)
+		m.lastDeadLetterID--
+	}
+	return
+}
+
+// FindDeadLetters is a method that returns the dead letters of a webhook, oldest first
+func (m *WebhookMap) FindDeadLetters(webhookID int) (dl []internal.WebhookDeadLetter, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	dl = make([]internal.WebhookDeadLetter, 0)
+	for _, value := range m.d.DeadLetters {
+		if value.WebhookID == webhookID {
+			dl = append(dl, value)
+		}
+	}
+	sort.Slice(dl, func(i, j int) bool { return dl[i].ID < dl[j].ID })
+	return
+}
+
+// DeleteDeadLetter is a method that deletes a dead letter of a webhook and returns it
+func (m *WebhookMap) DeleteDeadLetter(webhookID, id int) (dl internal.WebhookDeadLetter, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dl, ok := m.d.DeadLetters[id]
+	if !ok || dl.WebhookID != webhookID {
+		err = fmt.Errorf("%w: %d", internal.ErrDeadLetterNotFound, id)
+		return
+	}
+	delete(m.d.DeadLetters, id)
+
+	// save data to JSON file
+	if err = m.ld.Save(m.d); err != nil {
+		// - memory is kept as the file
+		m.d.DeadLetters[id] = dl
+	}
+	return
+}
@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrReservationInvalid is an error that represents a reservation with invalid attributes
+	ErrReservationInvalid = errors.New("reservation invalid")
+	// ErrReservationNotFound is an error that represents a reservation not found
+	ErrReservationNotFound = errors.New("reservation not found")
+	// ErrReservationConflict is an error that represents a reservation the vehicle can not take
+	ErrReservationConflict = errors.New("reservation conflict")
+	// ErrReservationCancelled is an error that represents a change to a reservation already cancelled
+	ErrReservationCancelled = errors.New("reservation cancelled")
+)
+
+// ReservationStatus is the state of a reservation
+type ReservationStatus string
+
+const (
+	// ReservationStatusActive is a reservation that holds the vehicle
+	ReservationStatusActive ReservationStatus = "active"
+	// ReservationStatusCancelled is a reservation that no longer holds the vehicle
+	ReservationStatusCancelled ReservationStatus = "cancelled"
+)
+
+// Valid is a method that reports whether the status is known
+func (s ReservationStatus) Valid() bool {
+	return s == ReservationStatusActive || s == ReservationStatusCancelled
+}
+
+// Reservation is a struct that represents the booking of a vehicle by a driver over the interval [From, To)
+type Reservation struct {
+	// ID is the unique identifier of the reservation
+	ID int
+	// VehicleID is the id of the vehicle
+	VehicleID int
+	// Driver is the name of the driver
+	Driver string
+	// Purpose is a free text description of the trip
+	Purpose string
+	// From is the start of the reservation
+	From time.Time
+	// To is the end of the reservation, excluded
+	To time.Time
+	// Status is the state of the reservation
+	Status ReservationStatus
+	// CreatedAt is the time the reservation was made
+	CreatedAt time.Time
+	// CancelledAt is the time the reservation was cancelled, zero while it is active
+	CancelledAt time.Time
+}
+
+// Validate is a method that checks the attributes of a reservation are consistent
+func (r Reservation) Validate() (err error) {
+	switch {
+	case r.VehicleID <= 0:
+		err = fmt.Errorf("%w: vehicle id must be positive", ErrReservationInvalid)
+	case strings.TrimSpace(r.Driver) == "":
+		err = fmt.Errorf("%w: driver is required", ErrReservationInvalid)
+	case r.From.IsZero() || r.To.IsZero():
+		err = fmt.Errorf("%w: from and to are required", ErrReservationInvalid)
+	case !r.From.Before(r.To):
+		err = fmt.Errorf("%w: from must be before to", ErrReservationInvalid)
+	case !r.Status.Valid():
+		err = fmt.Errorf("%w: status %q is unknown", ErrReservationInvalid, r.Status)
+	}
+	return
+}
+
+// Active is a method that reports whether the reservation holds the vehicle
+func (r Reservation) Active() bool {
+	return r.Status == ReservationStatusActive
+}
+
+// Overlaps is a method that reports whether the reservation holds the vehicle at some time of [from, to)
+func (r Reservation) Overlaps(from, to time.Time) bool {
+	return r.Active() && r.From.Before(to) && from.Before(r.To)
+}
+
+// Window is a method that returns the interval [from, to) the vehicle is in the workshop, whole days from the start of Date to the end of End
+func (r MaintenanceRecord) Window() (from, to time.Time) {
+	from = r.Date
+	to = r.End().AddDate(0, 0, 1)
+	return
+}
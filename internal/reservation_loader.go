@@ -0,0 +1,10 @@
+package internal
+
+// ReservationLoader is an interface that represents the loader for reservations
+type ReservationLoader interface {
+	// Load is a method that loads the reservations
+	Load() (r map[int]Reservation, err error)
+
+	// Save is a method that saves the reservations
+	Save(r map[int]Reservation) (err error)
+}
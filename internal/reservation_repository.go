@@ -0,0 +1,19 @@
+package internal
+
+// ReservationRepository is an interface that represents a repository of reservations
+type ReservationRepository interface {
+	// FindAll is a method that returns every reservation
+	FindAll() (r []Reservation, err error)
+
+	// FindByVehicle is a method that returns the reservations of a vehicle, earliest first
+	FindByVehicle(vehicleID int) (r []Reservation, err error)
+
+	// FindByID is a method that returns a reservation by id
+	FindByID(id int) (r Reservation, err error)
+
+	// Create is a method that creates a reservation, it fails with ErrReservationConflict when an active reservation of the vehicle overlaps it
+	Create(r *Reservation) (err error)
+
+	// Update is a method that changes a reservation with update and saves it, nothing changes when update fails
+	Update(id int, update func(r *Reservation) (err error)) (r Reservation, err error)
+}
@@ -0,0 +1,18 @@
+package internal
+
+import "time"
+
+// ReservationService is an interface that represents a service of reservations
+type ReservationService interface {
+	// FindByVehicle is a method that returns the reservations of a vehicle, earliest first
+	FindByVehicle(vehicleID int) (r []Reservation, err error)
+
+	// Create is a method that books a vehicle, it fails with ErrReservationConflict when the vehicle is booked or in the workshop
+	Create(r *Reservation) (err error)
+
+	// Cancel is a method that cancels a reservation of a vehicle
+	Cancel(vehicleID, id int) (r Reservation, err error)
+
+	// Available is a method that returns the vehicles with at least minPassengers seats free over [from, to), ordered by id
+	Available(from, to time.Time, minPassengers int) (v []Vehicle, err error)
+}
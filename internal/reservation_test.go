@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReservation_Overlaps(t *testing.T) {
+	// a booking from 10:00 to 12:00
+	from := time.Date(2026, 11, 2, 10, 0, 0, 0, time.UTC)
+	to := from.Add(2 * time.Hour)
+
+	tests := []struct {
+		name   string
+		status ReservationStatus
+		from   time.Time
+		to     time.Time
+		want   bool
+	}{
+		{name: "same interval", status: ReservationStatusActive, from: from, to: to, want: true},
+		{name: "inside", status: ReservationStatusActive, from: from.Add(30 * time.Minute), to: from.Add(time.Hour), want: true},
+		{name: "around", status: ReservationStatusActive, from: from.Add(-time.Hour), to: to.Add(time.Hour), want: true},
+		{name: "over the start", status: ReservationStatusActive, from: from.Add(-time.Hour), to: from.Add(time.Second), want: true},
+		{name: "over the end", status: ReservationStatusActive, from: to.Add(-time.Second), to: to.Add(time.Hour), want: true},
+		// the end is excluded, so bookings back to back do not overlap
+		{name: "ending at the start", status: ReservationStatusActive, from: from.Add(-time.Hour), to: from},
+		{name: "starting at the end", status: ReservationStatusActive, from: to, to: to.Add(time.Hour)},
+		{name: "before", status: ReservationStatusActive, from: from.Add(-2 * time.Hour), to: from.Add(-time.Hour)},
+		{name: "cancelled", status: ReservationStatusCancelled, from: from, to: to},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Reservation{ID: 1, VehicleID: 1, Driver: "Ana", From: from, To: to, Status: tt.status}
+			if got := r.Overlaps(tt.from, tt.to); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaintenanceRecord_Window(t *testing.T) {
+	day := time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		end      time.Time
+		wantFrom time.Time
+		wantTo   time.Time
+	}{
+		{name: "single day", wantFrom: day, wantTo: day.AddDate(0, 0, 1)},
+		{name: "several days", end: day.AddDate(0, 0, 2), wantFrom: day, wantTo: day.AddDate(0, 0, 3)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to := MaintenanceRecord{Date: day, EndDate: tt.end}.Window()
+			if !from.Equal(tt.wantFrom) || !to.Equal(tt.wantTo) {
+				t.Errorf("got [%s, %s), want [%s, %s)", from, to, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// NewDaily is a function that returns a new instance of Daily, at is the time of the day of the runs in loc, e.g. 8 hours
+// for 08:00, and the local time zone is used when loc is nil
+func NewDaily(name string, at time.Duration, loc *time.Location, fn func(ctx context.Context, now time.Time) error) *Daily {
+	if loc == nil {
+		loc = time.Local
+	}
+	at = ((at % (24 * time.Hour)) + 24*time.Hour) % (24 * time.Hour)
+	return &Daily{name: name, at: at, loc: loc, fn: fn, clock: systemClock{}}
+}
+
+// Clock is an interface that represents the source of the time of the runs
+type Clock interface {
+	// Now is a method that returns the current time
+	Now() time.Time
+	// After is a method that returns a channel receiving the time once d has elapsed
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is a struct that implements the Clock interface with the time of the system
+type systemClock struct{}
+
+// Now is a method that returns the current time
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// After is a method that returns a channel receiving the time once d has elapsed
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Daily is a struct that runs a job once a day at a time of the day, and once as it starts so a run missed while the
+// server was down is caught up. The job must then be safe to run more than once a day
+type Daily struct {
+	// name is the name of the job in the logs
+	name string
+	// at is the time of the day of the runs
+	at time.Duration
+	// loc is the time zone of the time of the day
+	loc *time.Location
+	// fn is the job
+	fn func(ctx context.Context, now time.Time) error
+	// clock is the source of the time of the runs
+	clock Clock
+}
+
+// SetClock is a method that sets the source of the time of the runs, the time of the system by default.
+// It must be called before Run
+func (d *Daily) SetClock(c Clock) {
+	d.clock = c
+}
+
+// Next is a method that returns the first run after a time
+func (d *Daily) Next(after time.Time) time.Time {
+	// - the time of the day is set on the clock rather than added to midnight, as a day may not last 24 hours across a
+	// change of daylight saving time
+	t := after.In(d.loc)
+	next := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, int(d.at), d.loc)
+	for !next.After(after) {
+		t = t.AddDate(0, 0, 1)
+		next = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, int(d.at), d.loc)
+	}
+	return next
+}
+
+// Run is a method that runs the job until the context is done, a failed run is logged and retried at the next run
+func (d *Daily) Run(ctx context.Context) {
+	for {
+		d.run(ctx)
+
+		now := d.clock.Now()
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.clock.After(d.Next(now).Sub(now)):
+		}
+	}
+}
+
+// run is a method that runs the job once
+func (d *Daily) run(ctx context.Context) {
+	if err := d.fn(ctx, d.clock.Now()); err != nil {
+		log.Printf("scheduled %s failed: %v", d.name, err)
+	}
+}
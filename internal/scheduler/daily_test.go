@@ -0,0 +1,157 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+// fakeClock is a struct that implements the Clock interface with a time only moved forward by the test
+type fakeClock struct {
+	// mu guards the fields below
+	mu sync.Mutex
+	// now is the current time
+	now time.Time
+	// wake is the time the pending timer fires at
+	wake time.Time
+	// fire is the channel of the pending timer
+	fire chan time.Time
+	// waits receives the duration of every timer started
+	waits chan time.Duration
+}
+
+// newFakeClock is a function that returns a fake clock at a time
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, waits: make(chan time.Duration, 1)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	c.wake = c.now.Add(d)
+	c.fire = make(chan time.Time, 1)
+	ch := c.fire
+	c.mu.Unlock()
+	c.waits <- d
+	return ch
+}
+
+// advance is a method that moves the clock to the time of the pending timer and fires it
+func (c *fakeClock) advance() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.wake
+	c.fire <- c.now
+}
+
+// receive is a function that returns the next value of a channel, failing the test when none comes in time
+func receive[T any](t *testing.T, ch <-chan T) (v T) {
+	t.Helper()
+	select {
+	case v = <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("got nothing in 5s")
+	}
+	return
+}
+
+func TestDaily_Next(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		at    time.Duration
+		after time.Time
+		want  time.Time
+	}{
+		{name: "later today", at: 8 * time.Hour, after: time.Date(2026, 5, 4, 7, 0, 0, 0, madrid), want: time.Date(2026, 5, 4, 8, 0, 0, 0, madrid)},
+		{name: "exactly at the time", at: 8 * time.Hour, after: time.Date(2026, 5, 4, 8, 0, 0, 0, madrid), want: time.Date(2026, 5, 5, 8, 0, 0, 0, madrid)},
+		{name: "tomorrow", at: 8 * time.Hour, after: time.Date(2026, 5, 4, 9, 0, 0, 0, madrid), want: time.Date(2026, 5, 5, 8, 0, 0, 0, madrid)},
+		{name: "end of the year", at: 8*time.Hour + 30*time.Minute, after: time.Date(2026, 12, 31, 23, 0, 0, 0, madrid), want: time.Date(2027, 1, 1, 8, 30, 0, 0, madrid)},
+		{name: "start of daylight saving time", at: 8 * time.Hour, after: time.Date(2026, 3, 28, 9, 0, 0, 0, madrid), want: time.Date(2026, 3, 29, 8, 0, 0, 0, madrid)},
+		{name: "end of daylight saving time", at: 8 * time.Hour, after: time.Date(2026, 10, 24, 9, 0, 0, 0, madrid), want: time.Date(2026, 10, 25, 8, 0, 0, 0, madrid)},
+		{name: "after in another zone", at: 8 * time.Hour, after: time.Date(2026, 5, 4, 5, 0, 0, 0, time.UTC), want: time.Date(2026, 5, 4, 8, 0, 0, 0, madrid)},
+		{name: "time beyond a day", at: 32 * time.Hour, after: time.Date(2026, 5, 4, 7, 0, 0, 0, madrid), want: time.Date(2026, 5, 4, 8, 0, 0, 0, madrid)},
+		{name: "negative time", at: -16 * time.Hour, after: time.Date(2026, 5, 4, 7, 0, 0, 0, madrid), want: time.Date(2026, 5, 4, 8, 0, 0, 0, madrid)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDaily("test", tt.at, madrid, nil)
+			if got := d.Next(tt.after); !got.Equal(tt.want) {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDaily_Run(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 3, 28, 7, 0, 0, 0, madrid)
+	clock := newFakeClock(start)
+	runs := make(chan time.Time, 1)
+	d := NewDaily("test", 8*time.Hour, madrid, func(ctx context.Context, now time.Time) error {
+		runs <- now
+		// - a failed run does not stop the next ones
+		return errors.New("mail server down")
+	})
+	d.SetClock(clock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	// - a run as it starts, catching up a run missed while the server was down
+	if got := receive(t, runs); !got.Equal(start) {
+		t.Fatalf("got a first run at %s, want %s", got, start)
+	}
+	if got := receive(t, clock.waits); got != time.Hour {
+		t.Fatalf("got a wait of %s, want 1h until 08:00", got)
+	}
+
+	// - then a run every day at 08:00, the day daylight saving time starts lasting 23 hours
+	want := []struct {
+		run  time.Time
+		wait time.Duration
+	}{
+		{run: time.Date(2026, 3, 28, 8, 0, 0, 0, madrid), wait: 23 * time.Hour},
+		{run: time.Date(2026, 3, 29, 8, 0, 0, 0, madrid), wait: 24 * time.Hour},
+		{run: time.Date(2026, 3, 30, 8, 0, 0, 0, madrid), wait: 24 * time.Hour},
+	}
+	for _, value := range want {
+		clock.advance()
+		if got := receive(t, runs); !got.Equal(value.run) {
+			t.Fatalf("got a run at %s, want %s", got, value.run)
+		}
+		if got := receive(t, clock.waits); got != value.wait {
+			t.Fatalf("got a wait of %s after the run at %s, want %s", got, value.run, value.wait)
+		}
+	}
+
+	cancel()
+	receive(t, done)
+	select {
+	case got := <-runs:
+		t.Errorf("got a run at %s after the context is done, want none", got)
+	default:
+	}
+}
@@ -0,0 +1,125 @@
+package search
+
+import (
+	"sort"
+)
+
+// Suggestion is a struct that represents a value completing a prefix
+type Suggestion struct {
+	// Value is the value as it was added to the index
+	Value string
+	// Count is the number of times the value was added to the index
+	Count int
+}
+
+// NewPrefixIndex is a function that returns a new instance of PrefixIndex
+func NewPrefixIndex() *PrefixIndex {
+	return &PrefixIndex{root: newTrieNode()}
+}
+
+// PrefixIndex is a struct that represents a case-insensitive trie of values counted by occurrence
+type PrefixIndex struct {
+	// root is the node of the empty prefix
+	root *trieNode
+}
+
+// trieNode is a struct that represents a node of the trie
+type trieNode struct {
+	// children is a map of the next nodes by rune
+	children map[rune]*trieNode
+	// values is a map of the values ending at the node to their count, values with the same folding share a node
+	values map[string]int
+}
+
+// newTrieNode is a function that returns a new empty node
+func newTrieNode() *trieNode {
+	return &trieNode{children: make(map[rune]*trieNode)}
+}
+
+// Add is a method that adds an occurrence of a value to the index
+func (ix *PrefixIndex) Add(value string) {
+	if value == "" {
+		return
+	}
+	n := ix.root
+	for _, r := range Fold(value) {
+		child, ok := n.children[r]
+		if !ok {
+			child = newTrieNode()
+			n.children[r] = child
+		}
+		n = child
+	}
+	if n.values == nil {
+		n.values = make(map[string]int)
+	}
+	n.values[value]++
+}
+
+// Remove is a method that removes an occurrence of a value from the index
+func (ix *PrefixIndex) Remove(value string) {
+	if value == "" {
+		return
+	}
+	// walk the path keeping the nodes to prune the empty ones
+	path := []*trieNode{ix.root}
+	key := []rune(Fold(value))
+	for _, r := range key {
+		child, ok := path[len(path)-1].children[r]
+		if !ok {
+			return
+		}
+		path = append(path, child)
+	}
+	n := path[len(path)-1]
+	if n.values[value] == 0 {
+		return
+	}
+	n.values[value]--
+	if n.values[value] == 0 {
+		delete(n.values, value)
+	}
+	// prune
+	for i := len(path) - 1; i > 0; i-- {
+		if len(path[i].values) > 0 || len(path[i].children) > 0 {
+			break
+		}
+		delete(path[i-1].children, key[i-1])
+	}
+}
+
+// Prefix is a method that returns up to limit values starting with a prefix, most frequent first
+func (ix *PrefixIndex) Prefix(prefix string, limit int) (s []Suggestion) {
+	n := ix.root
+	for _, r := range Fold(prefix) {
+		child, ok := n.children[r]
+		if !ok {
+			return
+		}
+		n = child
+	}
+
+	// collect every value under the node
+	stack := []*trieNode{n}
+	for len(stack) > 0 {
+		n = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for value, count := range n.values {
+			s = append(s, Suggestion{Value: value, Count: count})
+		}
+		for _, child := range n.children {
+			stack = append(stack, child)
+		}
+	}
+
+	sort.Slice(s, func(i, j int) bool {
+		if s[i].Count != s[j].Count {
+			return s[i].Count > s[j].Count
+		}
+		return s[i].Value < s[j].Value
+	})
+	if limit > 0 && len(s) > limit {
+		s = s[:limit]
+	}
+	return
+}
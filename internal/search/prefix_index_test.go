@@ -0,0 +1,60 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPrefixIndex_Prefix(t *testing.T) {
+	ix := NewPrefixIndex()
+	for _, value := range []string{"Ford", "Ford", "Ford", "Fiat", "Ferrari", "Ferrari", "FORD", "Seat", ""} {
+		ix.Add(value)
+	}
+
+	tests := []struct {
+		name   string
+		prefix string
+		limit  int
+		want   []Suggestion
+	}{
+		{name: "most frequent first", prefix: "f", want: []Suggestion{{"Ford", 3}, {"Ferrari", 2}, {"FORD", 1}, {"Fiat", 1}}},
+		{name: "prefix in other case", prefix: "FE", want: []Suggestion{{"Ferrari", 2}}},
+		{name: "whole value", prefix: "ford", want: []Suggestion{{"Ford", 3}, {"FORD", 1}}},
+		{name: "limit", prefix: "f", limit: 2, want: []Suggestion{{"Ford", 3}, {"Ferrari", 2}}},
+		{name: "empty prefix", prefix: "", limit: 1, want: []Suggestion{{"Ford", 3}}},
+		{name: "no value", prefix: "fo rd", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ix.Prefix(tt.prefix, tt.limit); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrefixIndex_Remove(t *testing.T) {
+	ix := NewPrefixIndex()
+	for _, value := range []string{"Ford", "Ford", "Fiat"} {
+		ix.Add(value)
+	}
+
+	// - an occurrence is removed at a time, and values never added are ignored
+	ix.Remove("Ford")
+	ix.Remove("FORD")
+	ix.Remove("Fo")
+	ix.Remove("Opel")
+	if got, want := ix.Prefix("f", 0), []Suggestion{{"Fiat", 1}, {"Ford", 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// - the nodes left without values are pruned
+	ix.Remove("Ford")
+	ix.Remove("Fiat")
+	if len(ix.root.children) != 0 {
+		t.Errorf("got %d nodes under the root, want none", len(ix.root.children))
+	}
+	if got := ix.Prefix("", 0); got != nil {
+		t.Errorf("got %v, want none", got)
+	}
+}
@@ -0,0 +1,133 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Fold is a function that returns the Unicode simple case folding of a string,
+// two strings have the same folding when strings.EqualFold reports them equal
+func Fold(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for _, r := range s {
+		// the smallest rune of the folding orbit is its canonical representative
+		lowest := r
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			if f < lowest {
+				lowest = f
+			}
+		}
+		sb.WriteRune(lowest)
+	}
+	return sb.String()
+}
+
+// EditDistance is a function that returns the number of insertions, deletions, substitutions and
+// transpositions of adjacent runes needed to turn a into b (optimal string alignment distance)
+func EditDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 {
+		return len(rb)
+	}
+	if len(rb) == 0 {
+		return len(ra)
+	}
+
+	// three rows of the distance matrix, transpositions look two rows back
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)]
+}
+
+// trigrams is a function that returns the set of trigrams of a string padded with spaces
+func trigrams(s string) map[string]struct{} {
+	rs := []rune("  " + s + " ")
+	set := make(map[string]struct{}, len(rs))
+	for i := 0; i+3 <= len(rs); i++ {
+		set[string(rs[i:i+3])] = struct{}{}
+	}
+	return set
+}
+
+// Trigram is a function that returns the Jaccard similarity between the trigrams of two strings
+func Trigram(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	var shared int
+	for t := range ta {
+		if _, ok := tb[t]; ok {
+			shared++
+		}
+	}
+	union := len(ta) + len(tb) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+// maxEdits is a function that returns the number of typos tolerated for a term of the given length
+func maxEdits(n int) int {
+	switch {
+	case n <= 2:
+		return 0
+	case n <= 4:
+		return 1
+	case n <= 8:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// minTrigram is the minimum trigram similarity for a term to match
+const minTrigram = 0.35
+
+// Similarity is a function that scores how well a term matches a value, from 0 (no match) to 1 (exact match).
+// The term is compared case-insensitively against the whole value and against each of its words,
+// accepting exact matches, prefixes, a number of typos proportional to its length and similar trigrams.
+func Similarity(term, value string) (score float64) {
+	term, value = Fold(strings.TrimSpace(term)), Fold(value)
+	if term == "" {
+		return
+	}
+
+	candidates := append([]string{value}, strings.Fields(value)...)
+	for _, c := range candidates {
+		var s float64
+		switch {
+		case c == term:
+			s = 1
+		case strings.HasPrefix(c, term):
+			s = 0.9
+		default:
+			n := max(len([]rune(term)), len([]rune(c)))
+			if d := EditDistance(term, c); d <= maxEdits(len([]rune(term))) {
+				s = 0.85 * (1 - float64(d)/float64(n))
+			}
+			if t := Trigram(term, c); t >= minTrigram {
+				s = max(s, 0.8*t)
+			}
+		}
+		score = max(score, s)
+	}
+	return
+}
@@ -0,0 +1,92 @@
+package search
+
+import "testing"
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{a: "Chevrolet", b: "CHEVROLET"},
+		{a: "Škoda", b: "ŠKODA"},
+		{a: "ſtick", b: "STICK"},
+		{a: "Ωmega", b: "ωMEGA"},
+	}
+	for _, tt := range tests {
+		if Fold(tt.a) != Fold(tt.b) {
+			t.Errorf("got %q and %q, want the same folding", Fold(tt.a), Fold(tt.b))
+		}
+	}
+	if Fold("Ford") == Fold("Fort") {
+		t.Error("got Ford and Fort folded the same, want them different")
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "", want: 0},
+		{a: "", b: "ford", want: 4},
+		{a: "ford", b: "", want: 4},
+		{a: "ford", b: "ford", want: 0},
+		{a: "ford", b: "fort", want: 1},
+		{a: "chevrolet", b: "chevrolt", want: 1},
+		{a: "chevrolet", b: "chevorlet", want: 1},
+		{a: "chevrolet", b: "chvrolte", want: 2},
+		{a: "kitten", b: "sitting", want: 3},
+		{a: "škoda", b: "skoda", want: 1},
+	}
+	for _, tt := range tests {
+		if got := EditDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("%q to %q: got %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := EditDistance(tt.b, tt.a); got != tt.want {
+			t.Errorf("%q to %q: got %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestTrigram(t *testing.T) {
+	if got := Trigram("ford", "ford"); got != 1 {
+		t.Errorf("got %v for the same string, want 1", got)
+	}
+	if got := Trigram("ford", "xyz"); got != 0 {
+		t.Errorf("got %v for strings without shared trigrams, want 0", got)
+	}
+	if near, far := Trigram("volkswagen", "volkswagon"), Trigram("volkswagen", "vauxhall"); near <= far {
+		t.Errorf("got %v for a typo and %v for another brand, want the typo closer", near, far)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		name  string
+		term  string
+		value string
+		want  func(s float64) bool
+	}{
+		{name: "exact", term: "Chevrolet", value: "Chevrolet", want: func(s float64) bool { return s == 1 }},
+		{name: "exact in other case", term: "CHEVROLET", value: "Chevrolet", want: func(s float64) bool { return s == 1 }},
+		{name: "exact word", term: "rover", value: "Land Rover", want: func(s float64) bool { return s == 1 }},
+		{name: "prefix", term: "chev", value: "Chevrolet", want: func(s float64) bool { return s == 0.9 }},
+		{name: "one typo", term: "chevrolt", value: "Chevrolet", want: func(s float64) bool { return s > 0.7 && s < 0.9 }},
+		{name: "transposition", term: "chevorlet", value: "Chevrolet", want: func(s float64) bool { return s > 0.7 && s < 0.9 }},
+		{name: "typo of a short term", term: "fird", value: "Ford", want: func(s float64) bool { return s > 0 && s < 0.9 }},
+		{name: "no typo tolerated in two letters", term: "bn", value: "BMW", want: func(s float64) bool { return s == 0 }},
+		{name: "unrelated", term: "toyota", value: "Chevrolet", want: func(s float64) bool { return s == 0 }},
+		{name: "empty term", term: "  ", value: "Chevrolet", want: func(s float64) bool { return s == 0 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Similarity(tt.term, tt.value); !tt.want(got) {
+				t.Errorf("got %v for %q in %q", got, tt.term, tt.value)
+			}
+		})
+	}
+
+	// - closer matches score higher
+	if one, two := Similarity("chevrolt", "Chevrolet"), Similarity("chvrolte", "Chevrolet"); one <= two {
+		t.Errorf("got %v for one typo and %v for two, want one typo ranked first", one, two)
+	}
+}
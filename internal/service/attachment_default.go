@@ -0,0 +1,272 @@
+package service
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+const (
+	// defaultAttachmentMaxSize is the greatest size of a file by default, 10 MiB
+	defaultAttachmentMaxSize = 10 << 20
+	// maxAttachmentName is the greatest length in bytes of the name of a file
+	maxAttachmentName = 255
+	// sniffLen is the number of bytes the type of a file is detected from
+	sniffLen = 512
+)
+
+// DefaultAttachmentTypes is the list of the media types allowed by default: documents and photos
+var DefaultAttachmentTypes = []string{"application/pdf", "image/jpeg", "image/png", "image/gif", "image/webp"}
+
+// ConfigAttachmentDefault is a struct that represents the configuration for AttachmentDefault
+type ConfigAttachmentDefault struct {
+	// MaxSize is the greatest size of a file in bytes
+	MaxSize int64
+	// Types is the list of the media types allowed
+	Types []string
+}
+
+// NewAttachmentDefault is a function that returns a new instance of AttachmentDefault
+func NewAttachmentDefault(rp internal.AttachmentRepository, vr internal.VehicleRepository, st internal.BlobStore, cfg *ConfigAttachmentDefault) *AttachmentDefault {
+	// default config
+	defaultCfg := &ConfigAttachmentDefault{
+		MaxSize: defaultAttachmentMaxSize,
+		Types:   DefaultAttachmentTypes,
+	}
+	if cfg != nil {
+		if cfg.MaxSize > 0 {
+			defaultCfg.MaxSize = cfg.MaxSize
+		}
+		if len(cfg.Types) > 0 {
+			defaultCfg.Types = cfg.Types
+		}
+	}
+	types := make(map[string]bool)
+	for _, t := range defaultCfg.Types {
+		types[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+	return &AttachmentDefault{rp: rp, vr: vr, st: st, maxSize: defaultCfg.MaxSize, types: types}
+}
+
+// AttachmentDefault is a struct that represents the default service for attachments
+type AttachmentDefault struct {
+	// mu is held for reading while a content is stored and its attachment created, and for writing while unreferenced contents
+	// are deleted, so a content is never deleted between being deduplicated and being referred to
+	mu sync.RWMutex
+	// rp is the repository of the attachments
+	rp internal.AttachmentRepository
+	// vr is the repository of the vehicles
+	vr internal.VehicleRepository
+	// st is the store of the contents
+	st internal.BlobStore
+	// maxSize is the greatest size of a file in bytes
+	maxSize int64
+	// types is the set of the media types allowed
+	types map[string]bool
+}
+
+// FindByVehicle is a method that returns the attachments of a vehicle, ordered by id
+func (s *AttachmentDefault) FindByVehicle(vehicleID int) (a []internal.Attachment, err error) {
+	if _, err = s.vr.FindByID(vehicleID); err != nil {
+		return
+	}
+	a, err = s.rp.FindByVehicle(vehicleID)
+	return
+}
+
+// Upload is a method that attaches a file to a vehicle. The type is detected from the content rather than trusted from the client,
+// and it fails with ErrAttachmentTooLarge or ErrAttachmentType when the file is not allowed, storing nothing
+func (s *AttachmentDefault) Upload(vehicleID int, name string, r io.Reader) (a internal.Attachment, err error) {
+	if _, err = s.vr.FindByID(vehicleID); err != nil {
+		return
+	}
+
+	// detect type
+	br := bufio.NewReaderSize(r, sniffLen)
+	head, err := br.Peek(sniffLen)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return
+	}
+	if len(head) == 0 {
+		err = fmt.Errorf("%w: the file is empty", internal.ErrAttachmentInvalid)
+		return
+	}
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return
+	}
+	if !s.types[contentType] {
+		err = fmt.Errorf("%w: %s", internal.ErrAttachmentType, contentType)
+		return
+	}
+
+	// store content
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sum, size, err := s.st.Put(&maxSizeReader{r: br, n: s.maxSize, max: s.maxSize})
+	if err != nil {
+		return
+	}
+	a = internal.Attachment{
+		VehicleID:   vehicleID,
+		Name:        cleanAttachmentName(name),
+		ContentType: contentType,
+		Size:        size,
+		SHA256:      sum,
+		CreatedAt:   time.Now().UTC(),
+	}
+	err = s.rp.Create(&a)
+	return
+}
+
+// Open is a method that opens the content of an attachment of a vehicle
+func (s *AttachmentDefault) Open(vehicleID, id int) (a internal.Attachment, f io.ReadSeekCloser, err error) {
+	a, err = s.rp.FindByID(id)
+	if err != nil {
+		return
+	}
+	if a.VehicleID != vehicleID {
+		err = fmt.Errorf("%w: %d", internal.ErrAttachmentNotFound, id)
+		return
+	}
+	f, err = s.st.Open(a.SHA256)
+	return
+}
+
+// Delete is a method that deletes an attachment of a vehicle, and its content when no other attachment shares it
+func (s *AttachmentDefault) Delete(vehicleID, id int) (err error) {
+	a, err := s.rp.Delete(func(a internal.Attachment) bool { return a.ID == id && a.VehicleID == vehicleID })
+	if err != nil {
+		return
+	}
+	if len(a) == 0 {
+		err = fmt.Errorf("%w: %d", internal.ErrAttachmentNotFound, id)
+		return
+	}
+	_, err = s.deleteUnreferenced(a)
+	return
+}
+
+// DeleteByVehicle is a method that deletes the attachments of a vehicle, and the contents no other attachment shares
+func (s *AttachmentDefault) DeleteByVehicle(vehicleID int) (err error) {
+	a, err := s.rp.Delete(func(a internal.Attachment) bool { return a.VehicleID == vehicleID })
+	if err != nil {
+		return
+	}
+	_, err = s.deleteUnreferenced(a)
+	return
+}
+
+// Cleanup is a method that deletes the attachments of vehicles that no longer exist, e.g. after a reload or a restore,
+// and the contents no attachment refers to, e.g. left by a failed save
+func (s *AttachmentDefault) Cleanup() (attachments, blobs int, err error) {
+	v, err := s.vr.FindAll()
+	if err != nil {
+		return
+	}
+	a, err := s.rp.Delete(func(a internal.Attachment) bool {
+		_, ok := v[a.VehicleID]
+		return !ok
+	})
+	if err != nil {
+		return
+	}
+	attachments = len(a)
+
+	sums, err := s.st.List()
+	if err != nil {
+		return
+	}
+	candidates := make([]internal.Attachment, 0, len(sums))
+	for _, sum := range sums {
+		candidates = append(candidates, internal.Attachment{SHA256: sum})
+	}
+	blobs, err = s.deleteUnreferenced(candidates)
+	return
+}
+
+// deleteUnreferenced is a method that deletes the contents of attachments that no attachment refers to any longer
+func (s *AttachmentDefault) deleteUnreferenced(a []internal.Attachment) (deleted int, err error) {
+	if len(a) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all, err := s.rp.FindAll()
+	if err != nil {
+		return
+	}
+	referenced := make(map[string]bool, len(all))
+	for _, value := range all {
+		referenced[value.SHA256] = true
+	}
+	for _, value := range a {
+		if referenced[value.SHA256] {
+			continue
+		}
+		if err = s.st.Delete(value.SHA256); err != nil {
+			return
+		}
+		referenced[value.SHA256] = true
+		deleted++
+	}
+	return
+}
+
+// maxSizeReader is a struct that reads up to n bytes and fails with ErrAttachmentTooLarge past them
+type maxSizeReader struct {
+	// r is the reader
+	r io.Reader
+	// n is the number of bytes left
+	n int64
+	// max is the number of bytes allowed
+	max int64
+}
+
+// Read is a method that reads from the reader
+func (r *maxSizeReader) Read(p []byte) (n int, err error) {
+	if r.n < 0 {
+		err = fmt.Errorf("%w: the greatest size is %d bytes", internal.ErrAttachmentTooLarge, r.max)
+		return
+	}
+	if int64(len(p)) > r.n+1 {
+		p = p[:r.n+1]
+	}
+	n, err = r.r.Read(p)
+	if r.n -= int64(n); r.n < 0 {
+		n, err = 0, fmt.Errorf("%w: the greatest size is %d bytes", internal.ErrAttachmentTooLarge, r.max)
+	}
+	return
+}
+
+// cleanAttachmentName is a function that returns the base name of a file without control characters, cut to maxAttachmentName bytes
+func cleanAttachmentName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, name))
+	for len(name) > maxAttachmentName {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	if name == "" || name == "." || name == "/" {
+		name = "attachment"
+	}
+	return name
+}
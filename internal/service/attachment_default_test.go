@@ -0,0 +1,251 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/blob"
+	"github.com/rhinosc/code-review-1/internal/loader"
+	"github.com/rhinosc/code-review-1/internal/repository"
+)
+
+// pngHeader is the signature of a PNG file, enough for its type to be detected
+const pngHeader = "\x89PNG\r\n\x1a\n"
+
+// attachmentFixture is a struct that holds the services of a fleet of two vehicles with attachments wired as the application does
+type attachmentFixture struct {
+	// vehicles is the service of the vehicles
+	vehicles *VehicleDefault
+	// attachments is the service of the attachments
+	attachments *AttachmentDefault
+	// store is the store of the contents
+	store *blob.Store
+	// repository is the repository of the attachments
+	repository *repository.AttachmentMap
+}
+
+// newAttachmentFixture is a function that returns the services of a fleet of vehicles 1 and 2, saved to a temporary directory
+func newAttachmentFixture(t *testing.T, cfg *ConfigAttachmentDefault) (f attachmentFixture) {
+	t.Helper()
+	dir := t.TempDir()
+	db := map[int]internal.Vehicle{
+		1: {Id: 1, VehicleAttributes: internal.VehicleAttributes{Brand: "Ford", Model: "Transit", FabricationYear: 2020, Capacity: 3}},
+		2: {Id: 2, VehicleAttributes: internal.VehicleAttributes{Brand: "Seat", Model: "Leon", FabricationYear: 2021, Capacity: 5}},
+	}
+	vr := repository.NewVehicleMap(loader.NewVehicleJSONFile(filepath.Join(dir, "vehicles.json")), db, repository.NewVehicleIDSequential())
+	st, err := blob.NewStore(filepath.Join(dir, "blobs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.store = st
+	f.repository = repository.NewAttachmentMap(loader.NewAttachmentJSONFile(&loader.ConfigAttachmentJSONFile{Path: filepath.Join(dir, "attachments.json")}), nil)
+	f.vehicles = NewVehicleDefault(vr, nil)
+	f.attachments = NewAttachmentDefault(f.repository, vr, st, cfg)
+	f.vehicles.OnDelete(func(v internal.Vehicle) {
+		if err := f.attachments.DeleteByVehicle(v.Id); err != nil {
+			t.Errorf("deleting attachments of vehicle %d: %v", v.Id, err)
+		}
+	})
+	return
+}
+
+// blobs is a method that returns the number of contents stored
+func (f attachmentFixture) blobs(t *testing.T) int {
+	t.Helper()
+	sums, err := f.store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(sums)
+}
+
+func TestAttachmentDefault_Upload(t *testing.T) {
+	tests := []struct {
+		name            string
+		vehicleID       int
+		filename        string
+		content         string
+		wantErr         error
+		wantName        string
+		wantContentType string
+	}{
+		{name: "pdf", vehicleID: 1, filename: "papers.pdf", content: "%PDF-1.7\n", wantName: "papers.pdf", wantContentType: "application/pdf"},
+		{name: "png under the greatest size", vehicleID: 1, filename: "dent.png", content: pngHeader + strings.Repeat("x", 56), wantName: "dent.png", wantContentType: "image/png"},
+		{name: "type from the content, not the name", vehicleID: 1, filename: "dent.pdf", content: pngHeader, wantName: "dent.pdf", wantContentType: "image/png"},
+		{name: "name without directories nor control characters", vehicleID: 1, filename: "..\\..\\etc/\"dent\x00.png\"", content: pngHeader, wantName: "dent.png", wantContentType: "image/png"},
+		{name: "no name", vehicleID: 1, filename: "", content: pngHeader, wantName: "attachment", wantContentType: "image/png"},
+		{name: "over the greatest size", vehicleID: 1, filename: "dent.png", content: pngHeader + strings.Repeat("x", 57), wantErr: internal.ErrAttachmentTooLarge},
+		{name: "type not allowed", vehicleID: 1, filename: "notes.pdf", content: "just some notes", wantErr: internal.ErrAttachmentType},
+		{name: "html", vehicleID: 1, filename: "page.png", content: "<html><script>alert(1)</script>", wantErr: internal.ErrAttachmentType},
+		{name: "empty", vehicleID: 1, filename: "empty.pdf", wantErr: internal.ErrAttachmentInvalid},
+		{name: "vehicle not found", vehicleID: 9, filename: "papers.pdf", content: "%PDF-1.7\n", wantErr: internal.ErrVehicleNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAttachmentFixture(t, &ConfigAttachmentDefault{MaxSize: 64})
+
+			a, err := f.attachments.Upload(tt.vehicleID, tt.filename, strings.NewReader(tt.content))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				// - nothing is stored for a file not allowed
+				all, _ := f.repository.FindAll()
+				if n := f.blobs(t); len(all) != 0 || n != 0 {
+					t.Errorf("got %d attachments and %d contents, want none", len(all), n)
+				}
+				return
+			}
+
+			if a.ID == 0 || a.VehicleID != tt.vehicleID || a.Name != tt.wantName || a.ContentType != tt.wantContentType || a.Size != int64(len(tt.content)) {
+				t.Errorf("got %+v, want %q of type %s and %d bytes", a, tt.wantName, tt.wantContentType, len(tt.content))
+			}
+			_, r, err := f.attachments.Open(tt.vehicleID, a.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			if got, _ := io.ReadAll(r); string(got) != tt.content {
+				t.Errorf("got content %q, want %q", got, tt.content)
+			}
+		})
+	}
+}
+
+func TestAttachmentDefault_Upload_DefaultSize(t *testing.T) {
+	f := newAttachmentFixture(t, nil)
+
+	content := append([]byte(pngHeader), bytes.Repeat([]byte{0}, defaultAttachmentMaxSize-len(pngHeader)+1)...)
+	if _, err := f.attachments.Upload(1, "big.png", bytes.NewReader(content)); !errors.Is(err, internal.ErrAttachmentTooLarge) {
+		t.Errorf("got %v, want %v past %d bytes", err, internal.ErrAttachmentTooLarge, defaultAttachmentMaxSize)
+	}
+	if _, err := f.attachments.Upload(1, "big.png", bytes.NewReader(content[:defaultAttachmentMaxSize])); err != nil {
+		t.Errorf("got %v, want a file of %d bytes allowed", err, defaultAttachmentMaxSize)
+	}
+}
+
+func TestAttachmentDefault_Delete_SharedContent(t *testing.T) {
+	f := newAttachmentFixture(t, nil)
+
+	// - the same file attached twice is stored once
+	first, err := f.attachments.Upload(1, "papers.pdf", strings.NewReader("%PDF-1.7\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := f.attachments.Upload(2, "copy.pdf", strings.NewReader("%PDF-1.7\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.SHA256 != second.SHA256 || f.blobs(t) != 1 {
+		t.Fatalf("got %s and %s in %d contents, want the same one", first.SHA256, second.SHA256, f.blobs(t))
+	}
+
+	// - an attachment is only found under its own vehicle
+	if err := f.attachments.Delete(1, second.ID); !errors.Is(err, internal.ErrAttachmentNotFound) {
+		t.Errorf("got %v, want %v", err, internal.ErrAttachmentNotFound)
+	}
+	if _, _, err := f.attachments.Open(1, second.ID); !errors.Is(err, internal.ErrAttachmentNotFound) {
+		t.Errorf("got %v, want %v", err, internal.ErrAttachmentNotFound)
+	}
+
+	// - the content is kept until the last attachment referring to it is deleted
+	if err := f.attachments.Delete(1, first.ID); err != nil {
+		t.Fatal(err)
+	}
+	_, r, err := f.attachments.Open(2, second.ID)
+	if err != nil {
+		t.Fatalf("got %v, want the shared content kept", err)
+	}
+	r.Close()
+	if err := f.attachments.Delete(2, second.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n := f.blobs(t); n != 0 {
+		t.Errorf("got %d contents, want none", n)
+	}
+}
+
+func TestAttachmentDefault_DeleteByVehicle(t *testing.T) {
+	f := newAttachmentFixture(t, nil)
+	for _, value := range []struct {
+		vehicleID int
+		content   string
+	}{{1, "%PDF-1.7\nfirst"}, {1, pngHeader}, {2, "%PDF-1.7\nsecond"}, {2, pngHeader}} {
+		if _, err := f.attachments.Upload(value.vehicleID, "file", strings.NewReader(value.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// - deleting a vehicle deletes its attachments and the contents only it referred to
+	if err := f.vehicles.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+	all, err := f.repository.FindAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range all {
+		if a.VehicleID != 2 {
+			t.Errorf("got attachment %+v, want it deleted with vehicle 1", a)
+		}
+	}
+	if n := f.blobs(t); len(all) != 2 || n != 2 {
+		t.Errorf("got %d attachments and %d contents, want those of vehicle 2", len(all), n)
+	}
+	if _, err := f.attachments.FindByVehicle(1); !errors.Is(err, internal.ErrVehicleNotFound) {
+		t.Errorf("got %v, want %v", err, internal.ErrVehicleNotFound)
+	}
+}
+
+func TestAttachmentDefault_Cleanup(t *testing.T) {
+	f := newAttachmentFixture(t, nil)
+	kept, err := f.attachments.Upload(1, "papers.pdf", strings.NewReader("%PDF-1.7\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// - an attachment of a vehicle no longer in the fleet, e.g. after a reload, and a content no attachment refers to
+	orphan := internal.Attachment{VehicleID: 9, Name: "old.png", ContentType: "image/png", SHA256: kept.SHA256}
+	if err := f.repository.Create(&orphan); err != nil {
+		t.Fatal(err)
+	}
+	orphanSum, _, err := f.store.Put(strings.NewReader(pngHeader + "orphan"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	unreferenced, _, err := f.store.Put(strings.NewReader("left by a failed save"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	orphan2 := internal.Attachment{VehicleID: 9, Name: "other.png", ContentType: "image/png", SHA256: orphanSum}
+	if err := f.repository.Create(&orphan2); err != nil {
+		t.Fatal(err)
+	}
+
+	attachments, blobs, err := f.attachments.Cleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attachments != 2 || blobs != 2 {
+		t.Errorf("got %d attachments and %d contents deleted, want 2 and 2", attachments, blobs)
+	}
+	all, _ := f.repository.FindAll()
+	if len(all) != 1 || all[0].ID != kept.ID {
+		t.Errorf("got attachments %+v, want only %d", all, kept.ID)
+	}
+	for _, sum := range []string{orphanSum, unreferenced} {
+		if _, err := f.store.Open(sum); !errors.Is(err, internal.ErrBlobNotFound) {
+			t.Errorf("got %v, want content %s deleted", err, sum)
+		}
+	}
+	// - the content shared with an attachment of a vehicle in the fleet is kept
+	if _, r, err := f.attachments.Open(1, kept.ID); err != nil {
+		t.Errorf("got %v, want the content kept", err)
+	} else {
+		r.Close()
+	}
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// DefaultReminderWindows is the list of the windows, in days before an expiry, reminders are sent by default
+var DefaultReminderWindows = []int{30, 7, 1}
+
+// NewComplianceDefault is a function that returns a new instance of ComplianceDefault, an expiry is reminded once as it
+// enters each of the windows and once as it expires
+func NewComplianceDefault(rp internal.ComplianceRepository, vr internal.VehicleRepository, nt internal.Notifier, windows []int) *ComplianceDefault {
+	// default windows
+	ws := make([]int, 0, len(windows))
+	for _, w := range windows {
+		if w > 0 {
+			ws = append(ws, w)
+		}
+	}
+	if len(ws) == 0 {
+		ws = DefaultReminderWindows
+	}
+	return &ComplianceDefault{rp: rp, vr: vr, nt: nt, windows: ws}
+}
+
+// ComplianceDefault is a struct that represents the default service for compliance items
+type ComplianceDefault struct {
+	// remindMu serializes the reminders, so an expiry is not reminded twice by concurrent runs
+	remindMu sync.Mutex
+	// rp is the repository of the compliance items
+	rp internal.ComplianceRepository
+	// vr is the repository of the vehicles
+	vr internal.VehicleRepository
+	// nt is the notifier the reminders are delivered through
+	nt internal.Notifier
+	// windows is the list of the windows in days
+	windows []int
+}
+
+// FindByVehicle is a method that returns the items of a vehicle, soonest expiry first
+func (s *ComplianceDefault) FindByVehicle(vehicleID int) (i []internal.ComplianceItem, err error) {
+	if _, err = s.vr.FindByID(vehicleID); err != nil {
+		return
+	}
+	i, err = s.rp.FindByVehicle(vehicleID)
+	return
+}
+
+// Create is a method that creates an item of an existing vehicle
+func (s *ComplianceDefault) Create(i *internal.ComplianceItem) (err error) {
+	normalizeComplianceItem(i)
+	if err = i.Validate(); err != nil {
+		return
+	}
+	if _, err = s.vr.FindByID(i.VehicleID); err != nil {
+		return
+	}
+	err = s.rp.Create(i)
+	return
+}
+
+// Update is a method that replaces the attributes of an item of a vehicle, a new expiry is reminded again
+func (s *ComplianceDefault) Update(vehicleID, id int, i internal.ComplianceItem) (u internal.ComplianceItem, err error) {
+	i.VehicleID = vehicleID
+	normalizeComplianceItem(&i)
+	if err = i.Validate(); err != nil {
+		return
+	}
+	u, err = s.rp.Update(id, func(u *internal.ComplianceItem) (err error) {
+		if u.VehicleID != vehicleID {
+			err = fmt.Errorf("%w: %d", internal.ErrComplianceNotFound, id)
+			return
+		}
+		u.Kind, u.Reference, u.Provider, u.Expires, u.Notes = i.Kind, i.Reference, i.Provider, i.Expires, i.Notes
+		return
+	})
+	return
+}
+
+// Delete is a method that deletes an item of a vehicle
+func (s *ComplianceDefault) Delete(vehicleID, id int) (err error) {
+	i, err := s.rp.Delete(func(i internal.ComplianceItem) bool { return i.ID == id && i.VehicleID == vehicleID })
+	if err == nil && len(i) == 0 {
+		err = fmt.Errorf("%w: %d", internal.ErrComplianceNotFound, id)
+	}
+	return
+}
+
+// DeleteByVehicle is a method that deletes the items of a vehicle
+func (s *ComplianceDefault) DeleteByVehicle(vehicleID int) (err error) {
+	_, err = s.rp.Delete(func(i internal.ComplianceItem) bool { return i.VehicleID == vehicleID })
+	return
+}
+
+// Expiring is a method that returns the items expired at the day of at or expiring within the given days, soonest first
+func (s *ComplianceDefault) Expiring(at time.Time, within int) (e []internal.ComplianceExpiry, err error) {
+	e, err = s.expiries(at, func(i internal.ComplianceItem, daysLeft int) bool { return daysLeft <= within })
+	return
+}
+
+// Remind is a method that notifies the expiries that entered a reminder window since they were last reminded and returns them.
+// They are notified together and recorded as sent once the notifier succeeds, so a failed delivery is retried on the next run
+func (s *ComplianceDefault) Remind(ctx context.Context, at time.Time) (r []internal.ComplianceReminder, err error) {
+	s.remindMu.Lock()
+	defer s.remindMu.Unlock()
+
+	// reminders sent
+	sent, err := s.rp.FindSent()
+	if err != nil {
+		return
+	}
+	type key struct {
+		itemID  int
+		expires string
+		window  int
+	}
+	done := make(map[key]bool, len(sent))
+	for _, value := range sent {
+		done[key{value.ItemID, formatDay(value.Expires), value.Window}] = true
+	}
+
+	// reminders due
+	e, err := s.expiries(at, func(i internal.ComplianceItem, daysLeft int) bool {
+		w, ok := internal.ReminderWindow(s.windows, daysLeft)
+		return ok && !done[key{i.ID, formatDay(i.Expires), w}]
+	})
+	if err != nil || len(e) == 0 {
+		return
+	}
+	r = make([]internal.ComplianceReminder, 0, len(e))
+	for _, value := range e {
+		w, _ := internal.ReminderWindow(s.windows, value.DaysLeft)
+		r = append(r, internal.ComplianceReminder{Expiry: value, Window: w})
+	}
+
+	// notify
+	n := internal.Notification{Subject: reminderSubject(r), Body: reminderBody(r), Reminders: r}
+	if err = s.nt.Notify(ctx, n); err != nil {
+		r = nil
+		return
+	}
+	record := make([]internal.ComplianceSent, 0, len(r))
+	for _, value := range r {
+		record = append(record, internal.ComplianceSent{
+			ItemID:  value.Expiry.Item.ID,
+			Expires: value.Expiry.Item.Expires,
+			Window:  value.Window,
+			SentAt:  at.UTC(),
+		})
+	}
+	err = s.rp.AddSent(record)
+	return
+}
+
+// expiries is a method that returns the expiries of the items matching a condition at the day of at, soonest first
+func (s *ComplianceDefault) expiries(at time.Time, match func(i internal.ComplianceItem, daysLeft int) bool) (e []internal.ComplianceExpiry, err error) {
+	items, err := s.rp.FindAll()
+	if err != nil {
+		return
+	}
+	v, err := s.vr.FindAll()
+	if err != nil {
+		return
+	}
+
+	e = make([]internal.ComplianceExpiry, 0)
+	for _, value := range items {
+		daysLeft := value.DaysLeft(at)
+		if !match(value, daysLeft) {
+			continue
+		}
+		e = append(e, internal.ComplianceExpiry{Item: value, Registration: v[value.VehicleID].Registration, DaysLeft: daysLeft})
+	}
+	sort.Slice(e, func(i, j int) bool {
+		if e[i].DaysLeft != e[j].DaysLeft {
+			return e[i].DaysLeft < e[j].DaysLeft
+		}
+		return e[i].Item.ID < e[j].Item.ID
+	})
+	return
+}
+
+// normalizeComplianceItem is a function that trims the text of an item and keeps the day of its expiry
+func normalizeComplianceItem(i *internal.ComplianceItem) {
+	i.Kind = internal.ComplianceKind(strings.ToLower(strings.TrimSpace(string(i.Kind))))
+	i.Reference = strings.TrimSpace(i.Reference)
+	i.Provider = strings.TrimSpace(i.Provider)
+	i.Notes = strings.TrimSpace(i.Notes)
+	if !i.Expires.IsZero() {
+		i.Expires = time.Date(i.Expires.Year(), i.Expires.Month(), i.Expires.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// reminderSubject is a function that returns the subject of a notification of reminders
+func reminderSubject(r []internal.ComplianceReminder) string {
+	expired := 0
+	for _, value := range r {
+		if value.Expiry.DaysLeft < 0 {
+			expired++
+		}
+	}
+	switch {
+	case expired == 0:
+		return fmt.Sprintf("Vehicle compliance: %d document(s) expiring soon", len(r))
+	case expired == len(r):
+		return fmt.Sprintf("Vehicle compliance: %d document(s) expired", expired)
+	}
+	return fmt.Sprintf("Vehicle compliance: %d document(s) expired, %d expiring soon", expired, len(r)-expired)
+}
+
+// reminderBody is a function that returns the text of a notification of reminders, a line per reminder
+func reminderBody(r []internal.ComplianceReminder) string {
+	var b strings.Builder
+	b.WriteString("The following vehicle documents need attention:\n\n")
+	for _, value := range r {
+		i := value.Expiry.Item
+		fmt.Fprintf(&b, "- vehicle %d", i.VehicleID)
+		if value.Expiry.Registration != "" {
+			fmt.Fprintf(&b, " (%s)", value.Expiry.Registration)
+		}
+		fmt.Fprintf(&b, ": %s", i.Kind)
+		if i.Reference != "" {
+			fmt.Fprintf(&b, " %s", i.Reference)
+		}
+		if i.Provider != "" {
+			fmt.Fprintf(&b, " from %s", i.Provider)
+		}
+		switch d := value.Expiry.DaysLeft; {
+		case d < -1:
+			fmt.Fprintf(&b, " expired on %s, %d days ago\n", formatDay(i.Expires), -d)
+		case d == -1:
+			fmt.Fprintf(&b, " expired yesterday, %s\n", formatDay(i.Expires))
+		case d == 0:
+			fmt.Fprintf(&b, " expires today, %s\n", formatDay(i.Expires))
+		case d == 1:
+			fmt.Fprintf(&b, " expires tomorrow, %s\n", formatDay(i.Expires))
+		default:
+			fmt.Fprintf(&b, " expires on %s, in %d days\n", formatDay(i.Expires), d)
+		}
+	}
+	return b.String()
+}
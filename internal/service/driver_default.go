@@ -0,0 +1,120 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// NewDriverDefault is a function that returns a new instance of DriverDefault
+func NewDriverDefault(rp internal.DriverRepository, vr internal.VehicleRepository) *DriverDefault {
+	return &DriverDefault{rp: rp, vr: vr}
+}
+
+// DriverDefault is a struct that represents the default service for drivers and their assignments
+type DriverDefault struct {
+	// rp is the repository of the drivers and assignments
+	rp internal.DriverRepository
+	// vr is the repository of the vehicles
+	vr internal.VehicleRepository
+}
+
+// FindAll is a method that returns every driver, ordered by id
+func (s *DriverDefault) FindAll() (d []internal.Driver, err error) {
+	d, err = s.rp.FindAll()
+	return
+}
+
+// FindByID is a method that returns a driver by id
+func (s *DriverDefault) FindByID(id int) (d internal.Driver, err error) {
+	d, err = s.rp.FindByID(id)
+	return
+}
+
+// Create is a method that creates a driver
+func (s *DriverDefault) Create(d *internal.Driver) (err error) {
+	normalizeDriver(d)
+	if err = d.Validate(); err != nil {
+		return
+	}
+	err = s.rp.Create(d)
+	return
+}
+
+// Update is a method that replaces the attributes of a driver, the assignments are kept
+func (s *DriverDefault) Update(id int, d internal.Driver) (u internal.Driver, err error) {
+	normalizeDriver(&d)
+	if err = d.Validate(); err != nil {
+		return
+	}
+	u, err = s.rp.Update(id, func(u *internal.Driver) (err error) {
+		u.Name, u.LicenceNumber, u.Licences = d.Name, d.LicenceNumber, d.Licences
+		return
+	})
+	return
+}
+
+// Delete is a method that deletes a driver without a current assignment
+func (s *DriverDefault) Delete(id int) (err error) {
+	err = s.rp.Delete(id)
+	return
+}
+
+// Assign is a method that assigns a driver to a vehicle that is not decommissioned, replacing its current driver.
+// It fails with ErrLicenceNotPermitted when the driver holds no valid category the weight and capacity of the vehicle require
+func (s *DriverDefault) Assign(vehicleID, driverID int) (a internal.Assignment, err error) {
+	v, err := s.vr.FindByID(vehicleID)
+	if err != nil {
+		return
+	}
+	if v.Status == internal.VehicleStatusDecommissioned {
+		err = fmt.Errorf("%w: vehicle %d is %s", internal.ErrAssignmentInvalid, vehicleID, v.Status)
+		return
+	}
+
+	now := time.Now().UTC()
+	a = internal.Assignment{DriverID: driverID, VehicleID: vehicleID, From: now}
+	err = s.rp.Assign(&a, func(d internal.Driver) (err error) {
+		err = d.Permits(v.VehicleAttributes, now)
+		return
+	})
+	return
+}
+
+// Unassign is a method that ends the current assignment of a vehicle
+func (s *DriverDefault) Unassign(vehicleID int) (a internal.Assignment, err error) {
+	if _, err = s.vr.FindByID(vehicleID); err != nil {
+		return
+	}
+	a, err = s.rp.Unassign(vehicleID, time.Now().UTC())
+	return
+}
+
+// FindAssignmentsByVehicle is a method that returns the assignments of a vehicle, oldest first
+func (s *DriverDefault) FindAssignmentsByVehicle(vehicleID int) (a []internal.Assignment, err error) {
+	if _, err = s.vr.FindByID(vehicleID); err != nil {
+		return
+	}
+	a, err = s.rp.FindAssignments(vehicleID, 0)
+	return
+}
+
+// FindAssignmentsByDriver is a method that returns the assignments of a driver, oldest first
+func (s *DriverDefault) FindAssignmentsByDriver(driverID int) (a []internal.Assignment, err error) {
+	if _, err = s.rp.FindByID(driverID); err != nil {
+		return
+	}
+	a, err = s.rp.FindAssignments(0, driverID)
+	return
+}
+
+// normalizeDriver is a function that trims the attributes of a driver, upper-cases its categories and truncates expiry dates to the day
+func normalizeDriver(d *internal.Driver) {
+	d.Name, d.LicenceNumber = strings.TrimSpace(d.Name), strings.TrimSpace(d.LicenceNumber)
+	for i := range d.Licences {
+		d.Licences[i].Category = internal.LicenceCategory(strings.ToUpper(strings.TrimSpace(string(d.Licences[i].Category))))
+		d.Licences[i].Expires = truncateDay(d.Licences[i].Expires)
+	}
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/loader"
+	"github.com/rhinosc/code-review-1/internal/repository"
+)
+
+// newDriverService is a function that returns the service of a fleet and its drivers saved to a temporary directory:
+//   - vehicle 1 is a car, 2 a truck, 3 a minibus and 4 a decommissioned car
+//   - driver 1 holds B and an expired C, driver 2 holds B and D1, driver 3 holds an expired B
+func newDriverService(t *testing.T) *DriverDefault {
+	t.Helper()
+	dir := t.TempDir()
+	vehicles := make(map[int]internal.Vehicle)
+	for id, value := range map[int]struct {
+		weight   float64
+		capacity int
+		status   internal.VehicleStatus
+	}{1: {1500, 5, internal.VehicleStatusInService}, 2: {12000, 2, internal.VehicleStatusInService}, 3: {3000, 12, internal.VehicleStatusInService}, 4: {1500, 5, internal.VehicleStatusDecommissioned}} {
+		vehicles[id] = internal.Vehicle{
+			Id:                id,
+			VehicleAttributes: internal.VehicleAttributes{Brand: "Ford", Model: "Transit", FabricationYear: 2020, Weight: value.weight, Capacity: value.capacity},
+			Status:            value.status,
+		}
+	}
+	valid, expired := time.Now().UTC().AddDate(1, 0, 0), time.Now().UTC().AddDate(0, -1, 0)
+	data := internal.DriverData{Drivers: map[int]internal.Driver{
+		1: {ID: 1, Name: "Ana", LicenceNumber: "A-1", Licences: []internal.Licence{{Category: internal.LicenceCategoryB, Expires: valid}, {Category: internal.LicenceCategoryC, Expires: expired}}},
+		2: {ID: 2, Name: "Luis", LicenceNumber: "L-2", Licences: []internal.Licence{{Category: internal.LicenceCategoryB, Expires: valid}, {Category: internal.LicenceCategoryD1, Expires: valid}}},
+		3: {ID: 3, Name: "Eva", LicenceNumber: "E-3", Licences: []internal.Licence{{Category: internal.LicenceCategoryB, Expires: expired}}},
+	}}
+
+	vr := repository.NewVehicleMap(loader.NewVehicleJSONFile(filepath.Join(dir, "vehicles.json")), vehicles, repository.NewVehicleIDSequential())
+	rp := repository.NewDriverMap(loader.NewDriverJSONFile(&loader.ConfigDriverJSONFile{Path: filepath.Join(dir, "drivers.json")}), data)
+	return NewDriverDefault(rp, vr)
+}
+
+func TestDriverDefault_Assign(t *testing.T) {
+	tests := []struct {
+		name      string
+		vehicleID int
+		driverID  int
+		wantErr   error
+	}{
+		{name: "car", vehicleID: 1, driverID: 1},
+		{name: "minibus", vehicleID: 3, driverID: 2},
+		{name: "truck with an expired category", vehicleID: 2, driverID: 1, wantErr: internal.ErrLicenceNotPermitted},
+		{name: "truck without the category", vehicleID: 2, driverID: 2, wantErr: internal.ErrLicenceNotPermitted},
+		{name: "minibus without the category", vehicleID: 3, driverID: 1, wantErr: internal.ErrLicenceNotPermitted},
+		{name: "car with an expired licence", vehicleID: 1, driverID: 3, wantErr: internal.ErrLicenceNotPermitted},
+		{name: "decommissioned vehicle", vehicleID: 4, driverID: 1, wantErr: internal.ErrAssignmentInvalid},
+		{name: "vehicle not found", vehicleID: 9, driverID: 1, wantErr: internal.ErrVehicleNotFound},
+		{name: "driver not found", vehicleID: 1, driverID: 9, wantErr: internal.ErrDriverNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv := newDriverService(t)
+
+			a, err := sv.Assign(tt.vehicleID, tt.driverID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (a.ID == 0 || a.VehicleID != tt.vehicleID || a.DriverID != tt.driverID || !a.Current()) {
+				t.Errorf("got %+v, want a current assignment", a)
+			}
+			// - a refused assignment is not recorded
+			if history, _ := sv.FindAssignmentsByDriver(tt.driverID); err != nil && len(history) != 0 {
+				t.Errorf("got %+v, want no assignments", history)
+			}
+		})
+	}
+}
+
+func TestDriverDefault_Assign_History(t *testing.T) {
+	sv := newDriverService(t)
+
+	// - the driver of a vehicle is replaced, and a driver drives one vehicle at a time
+	first, err := sv.Assign(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sv.Assign(3, 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sv.Assign(1, 2); !errors.Is(err, internal.ErrDriverAssigned) {
+		t.Fatalf("got %v, want %v", err, internal.ErrDriverAssigned)
+	}
+	if _, err := sv.Unassign(3); err != nil {
+		t.Fatal(err)
+	}
+	second, err := sv.Assign(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sv.Delete(2); !errors.Is(err, internal.ErrDriverAssigned) {
+		t.Fatalf("got %v, want %v", err, internal.ErrDriverAssigned)
+	}
+	ended, err := sv.Unassign(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sv.Unassign(1); !errors.Is(err, internal.ErrAssignmentNotFound) {
+		t.Fatalf("got %v, want %v", err, internal.ErrAssignmentNotFound)
+	}
+
+	history, err := sv.FindAssignmentsByVehicle(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].ID != first.ID || history[1].ID != second.ID {
+		t.Fatalf("got %+v, want the assignments %d and %d", history, first.ID, second.ID)
+	}
+	if !history[0].To.Equal(second.From) || !history[1].To.Equal(ended.To) || history[1].Current() {
+		t.Errorf("got %+v, want the first ended by the second and the second by the unassignment", history)
+	}
+
+	// - the history of the vehicle outlives its drivers
+	if err := sv.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+	if history, _ := sv.FindAssignmentsByVehicle(1); len(history) != 2 {
+		t.Errorf("got %+v, want the 2 assignments kept", history)
+	}
+	if _, err := sv.FindAssignmentsByDriver(1); !errors.Is(err, internal.ErrDriverNotFound) {
+		t.Errorf("got %v, want %v", err, internal.ErrDriverNotFound)
+	}
+}
+
+func TestDriverDefault_Create(t *testing.T) {
+	expires := time.Date(2030, 5, 1, 15, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		driver  internal.Driver
+		want    []internal.Licence
+		wantErr error
+	}{
+		{name: "normalized", driver: internal.Driver{Name: " Marta ", LicenceNumber: " M-4 ", Licences: []internal.Licence{{Category: " c1 ", Expires: expires}}},
+			want: []internal.Licence{{Category: internal.LicenceCategoryC1, Expires: time.Date(2030, 5, 1, 0, 0, 0, 0, time.UTC)}}},
+		{name: "licence number of another driver", driver: internal.Driver{Name: "Marta", LicenceNumber: "a- 1", Licences: []internal.Licence{{Category: internal.LicenceCategoryB, Expires: expires}}}, wantErr: internal.ErrDriverDuplicate},
+		{name: "unknown category", driver: internal.Driver{Name: "Marta", LicenceNumber: "M-4", Licences: []internal.Licence{{Category: "BE", Expires: expires}}}, wantErr: internal.ErrDriverInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv := newDriverService(t)
+			d := tt.driver
+
+			err := sv.Create(&d)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got, err := sv.FindByID(d.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Name != "Marta" || got.LicenceNumber != "M-4" || !reflect.DeepEqual(got.Licences, tt.want) {
+				t.Errorf("got %+v, want the attributes trimmed and licences %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// ConfigEventBus is a struct that represents the configuration for EventBus
+type ConfigEventBus struct {
+	// ReplaySize is the number of the latest events kept for replay, 1024 by default
+	ReplaySize int
+	// SubscriberBuffer is the number of events a subscriber may fall behind before it is dropped, 256 by default
+	SubscriberBuffer int
+}
+
+// NewEventBus is a function that returns a new instance of EventBus
+func NewEventBus(cfg *ConfigEventBus) *EventBus {
+	// default config
+	defaultCfg := &ConfigEventBus{
+		ReplaySize:       1024,
+		SubscriberBuffer: 256,
+	}
+	if cfg != nil {
+		if cfg.ReplaySize > 0 {
+			defaultCfg.ReplaySize = cfg.ReplaySize
+		}
+		if cfg.SubscriberBuffer > 0 {
+			defaultCfg.SubscriberBuffer = cfg.SubscriberBuffer
+		}
+	}
+	return &EventBus{
+		replay: make([]internal.Event, 0, defaultCfg.ReplaySize),
+		size:   defaultCfg.ReplaySize,
+		buffer: defaultCfg.SubscriberBuffer,
+		subs:   make(map[*subscription]struct{}),
+	}
+}
+
+// EventBus is a struct that implements the EventBus interface in memory. The latest events are kept in a bounded
+// buffer for replay, and publishing never blocks: a subscriber whose buffer is full is dropped, so it reconnects and
+// replays what it missed. Every event is also forwarded to the publishers set with Forward
+type EventBus struct {
+	// mu guards replay, subs and fw
+	mu sync.Mutex
+	// replay is the latest events, oldest first
+	replay []internal.Event
+	// size is the greatest number of events in replay
+	size int
+	// buffer is the size of the channel of each subscriber
+	buffer int
+	// subs is the set of the open subscriptions
+	subs map[*subscription]struct{}
+	// fw is the list of the publishers events are forwarded to
+	fw []internal.EventPublisher
+}
+
+// subscription is a struct that implements the EventSubscription interface
+type subscription struct {
+	// bus is the bus of the subscription
+	bus *EventBus
+	// f is the filter of the events
+	f internal.EventFilter
+	// ch is the channel of the events, closed once the subscription ends
+	ch chan internal.Event
+}
+
+// Events is a method that returns the events of the subscription
+func (s *subscription) Events() <-chan internal.Event {
+	return s.ch
+}
+
+// Close is a method that ends the subscription, it may be called more than once
+func (s *subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.drop(s)
+}
+
+// Forward is a method that adds a publisher every event is forwarded to, such as the webhook dispatcher
+func (b *EventBus) Forward(pb internal.EventPublisher) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.fw = append(b.fw, pb)
+}
+
+// Publish is a method that keeps an event for replay, sends it to the subscribers it passes the filter of and forwards it
+func (b *EventBus) Publish(e internal.Event) {
+	b.mu.Lock()
+	if len(b.replay) == b.size {
+		copy(b.replay, b.replay[1:])
+		b.replay = b.replay[:len(b.replay)-1]
+	}
+	b.replay = append(b.replay, e)
+	for s := range b.subs {
+		if !s.f.Matches(e) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			// - a slow subscriber must not hold back the others
+			b.drop(s)
+		}
+	}
+	fw := b.fw
+	b.mu.Unlock()
+
+	for _, pb := range fw {
+		pb.Publish(e)
+	}
+}
+
+// Subscribe is a method that subscribes to the events passing the filter, replaying the kept events after lastEventID
+func (b *EventBus) Subscribe(f internal.EventFilter, lastEventID string) (replay []internal.Event, s internal.EventSubscription, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// - the replay and the subscription are taken at once so no event is missed or received twice
+	if lastEventID != "" {
+		i := len(b.replay) - 1
+		for ; i >= 0; i-- {
+			if b.replay[i].ID == lastEventID {
+				break
+			}
+		}
+		if i < 0 {
+			// - the subscriber cannot tell what it missed, so nothing is replayed
+			err = fmt.Errorf("%w: event %s", internal.ErrEventReplayUnavailable, lastEventID)
+		} else {
+			for _, value := range b.replay[i+1:] {
+				if f.Matches(value) {
+					replay = append(replay, value)
+				}
+			}
+		}
+	}
+	sub := &subscription{bus: b, f: f, ch: make(chan internal.Event, b.buffer)}
+	b.subs[sub] = struct{}{}
+	s = sub
+	return
+}
+
+// drop is a method that ends a subscription, b.mu must be held
+func (b *EventBus) drop(s *subscription) {
+	if _, ok := b.subs[s]; !ok {
+		return
+	}
+	delete(b.subs, s)
+	close(s.ch)
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// NewFuelLogDefault is a function that returns a new instance of FuelLogDefault
+func NewFuelLogDefault(rp internal.FuelLogRepository, vr internal.VehicleRepository) *FuelLogDefault {
+	return &FuelLogDefault{rp: rp, vr: vr}
+}
+
+// FuelLogDefault is a struct that represents the default service for fuel logs and consumption
+type FuelLogDefault struct {
+	// rp is the repository of the entries
+	rp internal.FuelLogRepository
+	// vr is the repository of the vehicles
+	vr internal.VehicleRepository
+}
+
+// FindByVehicle is a method that returns the entries of a vehicle, ordered by date
+func (s *FuelLogDefault) FindByVehicle(vehicleID int) (e []internal.FuelLogEntry, err error) {
+	if _, err = s.vr.FindByID(vehicleID); err != nil {
+		return
+	}
+	e, err = s.rp.FindByVehicle(vehicleID)
+	return
+}
+
+// Create is a method that creates an entry of an existing vehicle, an entry without unit takes the unit of the fuel type of the vehicle.
+// It fails with ErrOdometerNotMonotonic when the reading goes back from an earlier entry or passes a later one
+func (s *FuelLogDefault) Create(e *internal.FuelLogEntry) (err error) {
+	v, err := s.vr.FindByID(e.VehicleID)
+	if err != nil {
+		return
+	}
+	if e.Unit, err = internal.ParseFuelUnit(string(e.Unit), v.FuelType); err != nil {
+		return
+	}
+	e.Date, e.Station = e.Date.UTC(), strings.TrimSpace(e.Station)
+	if err = e.Validate(); err != nil {
+		return
+	}
+	err = s.rp.Create(e, internal.CheckFuelLogEntry)
+	return
+}
+
+// Stats is a method that returns the consumption of a vehicle
+func (s *FuelLogDefault) Stats(vehicleID int) (st internal.FuelStats, err error) {
+	log, err := s.FindByVehicle(vehicleID)
+	if err != nil {
+		return
+	}
+	st = internal.NewFuelStats(vehicleID, log)
+	return
+}
+
+// GroupStats is a method that returns the consumption of the vehicles with segments grouped by brand, model or fuel type,
+// and by unit so litres and kWh are never added up. Models are grouped with their brand, groups are ordered by value then unit
+func (s *FuelLogDefault) GroupStats(field string) (st []internal.FuelGroupStats, err error) {
+	var value func(v internal.Vehicle) string
+	switch field {
+	case internal.FieldBrand:
+		value = func(v internal.Vehicle) string { return v.Brand }
+	case internal.FieldModel:
+		value = func(v internal.Vehicle) string { return v.Brand + " " + v.Model }
+	case internal.FieldFuelType:
+		value = func(v internal.Vehicle) string { return v.FuelType }
+	default:
+		err = fmt.Errorf("%w: %q, expected %s, %s or %s", internal.ErrFuelGroupInvalid, field, internal.FieldBrand, internal.FieldModel, internal.FieldFuelType)
+		return
+	}
+
+	vehicles, err := s.vr.FindAll()
+	if err != nil {
+		return
+	}
+	entries, err := s.rp.FindAll()
+	if err != nil {
+		return
+	}
+	logs := make(map[int][]internal.FuelLogEntry)
+	for _, e := range entries {
+		logs[e.VehicleID] = append(logs[e.VehicleID], e)
+	}
+
+	type key struct {
+		value string
+		unit  internal.FuelUnit
+	}
+	groups := make(map[key]*internal.FuelGroupStats)
+	for id, log := range logs {
+		v, ok := vehicles[id]
+		if !ok {
+			continue
+		}
+		vs := internal.NewFuelStats(id, log)
+		if len(vs.Segments) == 0 {
+			continue
+		}
+		k := key{value: value(v), unit: vs.Unit}
+		g, ok := groups[k]
+		if !ok {
+			g = &internal.FuelGroupStats{Value: k.value, FuelTotals: internal.FuelTotals{Unit: k.unit}}
+			groups[k] = g
+		}
+		g.Vehicles++
+		for _, seg := range vs.Segments {
+			g.Add(seg)
+		}
+	}
+
+	st = make([]internal.FuelGroupStats, 0, len(groups))
+	for _, g := range groups {
+		st = append(st, *g)
+	}
+	sort.Slice(st, func(i, j int) bool {
+		if st[i].Value != st[j].Value {
+			return st[i].Value < st[j].Value
+		}
+		return st[i].Unit < st[j].Unit
+	})
+	return
+}
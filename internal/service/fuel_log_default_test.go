@@ -0,0 +1,192 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/loader"
+	"github.com/rhinosc/code-review-1/internal/repository"
+)
+
+// newFuelLogService is a function that returns the service of a fleet saved to a temporary directory: vehicles 1 and 2
+// are diesel Fords, a Transit and a Focus, 3 an electric Tesla and 4 a diesel Seat
+func newFuelLogService(t *testing.T) *FuelLogDefault {
+	t.Helper()
+	dir := t.TempDir()
+	vehicles := make(map[int]internal.Vehicle)
+	for id, value := range map[int][3]string{1: {"Ford", "Transit", "diesel"}, 2: {"Ford", "Focus", "diesel"}, 3: {"Tesla", "Model 3", "electric"}, 4: {"Seat", "Leon", "diesel"}} {
+		vehicles[id] = internal.Vehicle{
+			Id:                id,
+			VehicleAttributes: internal.VehicleAttributes{Brand: value[0], Model: value[1], FuelType: value[2], FabricationYear: 2020, Capacity: 5},
+			Status:            internal.VehicleStatusInService,
+		}
+	}
+	vr := repository.NewVehicleMap(loader.NewVehicleJSONFile(filepath.Join(dir, "vehicles.json")), vehicles, repository.NewVehicleIDSequential())
+	rp := repository.NewFuelLogMap(loader.NewFuelLogJSONFile(&loader.ConfigFuelLogJSONFile{Path: filepath.Join(dir, "fuel_log.json")}), nil)
+	return NewFuelLogDefault(rp, vr)
+}
+
+// fuelDay is a function that returns a day of January 2026
+func fuelDay(d int) time.Time {
+	return time.Date(2026, 1, d, 9, 0, 0, 0, time.UTC)
+}
+
+func TestFuelLogDefault_Create(t *testing.T) {
+	tests := []struct {
+		name      string
+		vehicleID int
+		date      time.Time
+		odometer  int
+		quantity  float64
+		unit      internal.FuelUnit
+		wantUnit  internal.FuelUnit
+		wantErr   error
+	}{
+		{name: "after the last", vehicleID: 1, date: fuelDay(25), odometer: 3500, quantity: 40, wantUnit: internal.FuelUnitLitre},
+		{name: "back-dated between two readings", vehicleID: 1, date: fuelDay(5), odometer: 1500, wantUnit: internal.FuelUnitLitre},
+		{name: "back-dated, lower than the earlier reading", vehicleID: 1, date: fuelDay(5), odometer: 900, wantErr: internal.ErrOdometerNotMonotonic},
+		{name: "back-dated, greater than the later reading", vehicleID: 1, date: fuelDay(5), odometer: 2500, wantErr: internal.ErrOdometerNotMonotonic},
+		{name: "after the last, lower", vehicleID: 1, date: fuelDay(25), odometer: 2900, wantErr: internal.ErrOdometerNotMonotonic},
+		{name: "unit of an electric vehicle", vehicleID: 3, date: fuelDay(25), odometer: 100, quantity: 50, wantUnit: internal.FuelUnitKWh},
+		{name: "fill in another unit", vehicleID: 1, date: fuelDay(25), odometer: 3500, quantity: 40, unit: internal.FuelUnitKWh, wantErr: internal.ErrFuelLogInvalid},
+		{name: "unknown unit", vehicleID: 1, date: fuelDay(25), odometer: 3500, quantity: 40, unit: "gallons", wantErr: internal.ErrFuelLogInvalid},
+		{name: "negative quantity", vehicleID: 1, date: fuelDay(25), odometer: 3500, quantity: -1, wantErr: internal.ErrFuelLogInvalid},
+		{name: "vehicle not found", vehicleID: 9, date: fuelDay(25), odometer: 3500, wantErr: internal.ErrVehicleNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// - vehicle 1 was filled on the 1st at 1000 km and on the 10th at 2000 km, and read on the 20th at 3000 km
+			sv := newFuelLogService(t)
+			for _, e := range []internal.FuelLogEntry{
+				{VehicleID: 1, Date: fuelDay(1), Odometer: 1000, Quantity: 40},
+				{VehicleID: 1, Date: fuelDay(10), Odometer: 2000, Quantity: 35},
+				{VehicleID: 1, Date: fuelDay(20), Odometer: 3000},
+			} {
+				if err := sv.Create(&e); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			e := internal.FuelLogEntry{VehicleID: tt.vehicleID, Date: tt.date, Odometer: tt.odometer, Quantity: tt.quantity, Unit: tt.unit, Station: " Repsol "}
+			err := sv.Create(&e)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if e.ID != 4 || e.Unit != tt.wantUnit || e.Station != "Repsol" {
+				t.Errorf("got %+v, want entry 4 in %s at Repsol", e, tt.wantUnit)
+			}
+
+			// - the log stays ordered by date
+			log, err := sv.FindByVehicle(tt.vehicleID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 1; i < len(log); i++ {
+				if log[i].Date.Before(log[i-1].Date) || log[i].Odometer < log[i-1].Odometer {
+					t.Errorf("got entry %+v after %+v, want the log ordered", log[i], log[i-1])
+				}
+			}
+		})
+	}
+}
+
+func TestFuelLogDefault_GroupStats(t *testing.T) {
+	sv := newFuelLogService(t)
+	// - the Fords use 6 and 8 l/100 km, the Tesla 15 kWh/100 km, the Seat has a single fill and no segment
+	for _, e := range []internal.FuelLogEntry{
+		{VehicleID: 1, Date: fuelDay(1), Odometer: 1000, Quantity: 50, Cost: 75},
+		{VehicleID: 1, Date: fuelDay(10), Odometer: 2000, Quantity: 60, Cost: 90},
+		{VehicleID: 2, Date: fuelDay(1), Odometer: 500, Quantity: 40, Cost: 60},
+		{VehicleID: 2, Date: fuelDay(10), Odometer: 1000, Quantity: 40, Cost: 60},
+		{VehicleID: 3, Date: fuelDay(1), Odometer: 0, Quantity: 60, Cost: 12},
+		{VehicleID: 3, Date: fuelDay(10), Odometer: 400, Quantity: 60, Cost: 12},
+		{VehicleID: 4, Date: fuelDay(1), Odometer: 100, Quantity: 45, Cost: 70},
+	} {
+		if err := sv.Create(&e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// group is the expected group of a field
+	type group struct {
+		value       string
+		unit        internal.FuelUnit
+		vehicles    int
+		distance    int
+		consumption float64
+		costPerKm   float64
+	}
+	tests := []struct {
+		field   string
+		want    []group
+		wantErr error
+	}{
+		{field: internal.FieldBrand, want: []group{
+			{value: "Ford", unit: internal.FuelUnitLitre, vehicles: 2, distance: 1500, consumption: 100 * 100 / 1500.0, costPerKm: 0.1},
+			{value: "Tesla", unit: internal.FuelUnitKWh, vehicles: 1, distance: 400, consumption: 15, costPerKm: 0.03},
+		}},
+		{field: internal.FieldModel, want: []group{
+			{value: "Ford Focus", unit: internal.FuelUnitLitre, vehicles: 1, distance: 500, consumption: 8, costPerKm: 0.12},
+			{value: "Ford Transit", unit: internal.FuelUnitLitre, vehicles: 1, distance: 1000, consumption: 6, costPerKm: 0.09},
+			{value: "Tesla Model 3", unit: internal.FuelUnitKWh, vehicles: 1, distance: 400, consumption: 15, costPerKm: 0.03},
+		}},
+		{field: internal.FieldFuelType, want: []group{
+			{value: "diesel", unit: internal.FuelUnitLitre, vehicles: 2, distance: 1500, consumption: 100 * 100 / 1500.0, costPerKm: 0.1},
+			{value: "electric", unit: internal.FuelUnitKWh, vehicles: 1, distance: 400, consumption: 15, costPerKm: 0.03},
+		}},
+		{field: internal.FieldColor, wantErr: internal.ErrFuelGroupInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			st, err := sv.GroupStats(tt.field)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if len(st) != len(tt.want) {
+				t.Fatalf("got groups %+v, want %+v", st, tt.want)
+			}
+			for i, want := range tt.want {
+				got := st[i]
+				if got.Value != want.value || got.Unit != want.unit || got.Vehicles != want.vehicles || got.Distance != want.distance ||
+					math.Abs(got.Consumption()-want.consumption) > 1e-9 || math.Abs(got.CostPerKm()-want.costPerKm) > 1e-9 {
+					t.Errorf("got group %+v with %v per 100 km and %v per km, want %+v", got, got.Consumption(), got.CostPerKm(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestFuelLogDefault_Stats(t *testing.T) {
+	sv := newFuelLogService(t)
+	// - entries are created out of order, the stats follow the dates
+	for _, e := range []internal.FuelLogEntry{
+		{VehicleID: 1, Date: fuelDay(1), Odometer: 1000, Quantity: 40, Cost: 60},
+		{VehicleID: 1, Date: fuelDay(20), Odometer: 2000, Quantity: 30, Cost: 45},
+		{VehicleID: 1, Date: fuelDay(10), Odometer: 1500, Quantity: 40, Cost: 60},
+	} {
+		if err := sv.Create(&e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	st, err := sv.Stats(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Segments) != 2 || st.Segments[0].Consumption != 8 || st.Segments[1].Consumption != 6 {
+		t.Errorf("got segments %+v, want 8 then 6 l/100 km", st.Segments)
+	}
+	if st.Odometer != 2000 || math.Abs(st.Consumption()-7) > 1e-9 || math.Abs(st.CostPerKm()-0.105) > 1e-9 {
+		t.Errorf("got %d km, %v per 100 km and %v per km, want 2000, 7 and 0.105", st.Odometer, st.Consumption(), st.CostPerKm())
+	}
+	if _, err := sv.Stats(9); !errors.Is(err, internal.ErrVehicleNotFound) {
+		t.Errorf("got %v, want %v", err, internal.ErrVehicleNotFound)
+	}
+}
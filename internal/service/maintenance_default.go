@@ -0,0 +1,187 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// NewMaintenanceDefault is a function that returns a new instance of MaintenanceDefault
+func NewMaintenanceDefault(rp internal.MaintenanceRepository, vr internal.VehicleRepository, fr internal.FuelLogRepository) *MaintenanceDefault {
+	return &MaintenanceDefault{rp: rp, vr: vr, fr: fr}
+}
+
+// MaintenanceDefault is a struct that represents the default service for maintenance records and schedules
+type MaintenanceDefault struct {
+	// rp is the repository of the records and rules
+	rp internal.MaintenanceRepository
+	// vr is the repository of the vehicles
+	vr internal.VehicleRepository
+	// fr is the repository of the fuel logs, whose readings count for the distance of the vehicles
+	fr internal.FuelLogRepository
+}
+
+// FindByVehicle is a method that returns the records of a vehicle, oldest first
+func (s *MaintenanceDefault) FindByVehicle(vehicleID int) (r []internal.MaintenanceRecord, err error) {
+	if _, err = s.vr.FindByID(vehicleID); err != nil {
+		return
+	}
+	r, err = s.rp.FindByVehicle(vehicleID)
+	return
+}
+
+// Create is a method that creates a record of an existing vehicle, a record fulfilling a rule takes its type when none is given
+func (s *MaintenanceDefault) Create(r *internal.MaintenanceRecord) (err error) {
+	if _, err = s.vr.FindByID(r.VehicleID); err != nil {
+		return
+	}
+	if r.RuleID != 0 {
+		var rule internal.MaintenanceRule
+		if rule, err = s.rp.FindRule(r.RuleID); err != nil {
+			return
+		}
+		if r.Type == "" {
+			r.Type = rule.Type
+		}
+		if r.Type != rule.Type {
+			err = fmt.Errorf("%w: rule %d is of type %s", internal.ErrMaintenanceInvalid, rule.ID, rule.Type)
+			return
+		}
+	}
+	r.Date, r.EndDate = truncateDay(r.Date), truncateDay(r.EndDate)
+	if err = r.Validate(); err != nil {
+		return
+	}
+	err = s.rp.Create(r)
+	return
+}
+
+// FindRules is a method that returns every schedule rule
+func (s *MaintenanceDefault) FindRules() (r []internal.MaintenanceRule, err error) {
+	r, err = s.rp.FindRules()
+	return
+}
+
+// CreateRule is a method that creates a schedule rule
+func (s *MaintenanceDefault) CreateRule(r *internal.MaintenanceRule) (err error) {
+	r.Name, r.Brand, r.Model = strings.TrimSpace(r.Name), strings.TrimSpace(r.Brand), strings.TrimSpace(r.Model)
+	if err = r.Validate(); err != nil {
+		return
+	}
+	err = s.rp.CreateRule(r)
+	return
+}
+
+// Due is a method that returns the services overdue at now or due before the given time, soonest first.
+// Each vehicle in the fleet gets the most specific rule of each service name, a service never done is overdue,
+// and the distance of a vehicle is the greatest odometer reading of its records and its fuel log
+func (s *MaintenanceDefault) Due(now, before time.Time) (d []internal.MaintenanceDue, err error) {
+	vehicles, err := s.vr.FindAll()
+	if err != nil {
+		return
+	}
+	rules, err := s.rp.FindRules()
+	if err != nil {
+		return
+	}
+	records, err := s.rp.FindAll()
+	if err != nil {
+		return
+	}
+	entries, err := s.fr.FindAll()
+	if err != nil {
+		return
+	}
+	byVehicle := make(map[int][]internal.MaintenanceRecord)
+	odometers := make(map[int]int)
+	for _, rec := range records {
+		byVehicle[rec.VehicleID] = append(byVehicle[rec.VehicleID], rec)
+		odometers[rec.VehicleID] = max(odometers[rec.VehicleID], rec.Odometer)
+	}
+	for _, e := range entries {
+		odometers[e.VehicleID] = max(odometers[e.VehicleID], e.Odometer)
+	}
+
+	d = make([]internal.MaintenanceDue, 0)
+	for _, v := range vehicles {
+		if v.Status == internal.VehicleStatusDecommissioned {
+			continue
+		}
+		recs, odometer := byVehicle[v.Id], odometers[v.Id]
+
+		for _, rule := range applicableRules(rules, v) {
+			due := internal.MaintenanceDue{VehicleID: v.Id, Rule: rule, Odometer: odometer}
+			// - records are sorted by date, planned work does not count until it is done
+			for i := len(recs) - 1; i >= 0; i-- {
+				if rule.Fulfils(recs[i]) && !recs[i].Date.After(now) {
+					last := recs[i]
+					due.Last = &last
+					break
+				}
+			}
+
+			if due.Last == nil {
+				due.Overdue = true
+			} else {
+				if rule.EveryMonths > 0 {
+					due.DueDate = due.Last.End().AddDate(0, rule.EveryMonths, 0)
+					due.Overdue = !due.DueDate.After(now)
+				}
+				if rule.EveryKm > 0 {
+					due.DueOdometer = due.Last.Odometer + rule.EveryKm
+					due.Overdue = due.Overdue || odometer >= due.DueOdometer
+				}
+			}
+			if !due.Overdue && (due.DueDate.IsZero() || due.DueDate.After(before)) {
+				continue
+			}
+			d = append(d, due)
+		}
+	}
+
+	sort.Slice(d, func(i, j int) bool {
+		switch {
+		case d[i].Overdue != d[j].Overdue:
+			return d[i].Overdue
+		case !d[i].DueDate.Equal(d[j].DueDate):
+			return d[i].DueDate.Before(d[j].DueDate)
+		case d[i].VehicleID != d[j].VehicleID:
+			return d[i].VehicleID < d[j].VehicleID
+		}
+		return d[i].Rule.ID < d[j].Rule.ID
+	})
+	return
+}
+
+// applicableRules is a function that returns the rules of a vehicle, the most specific one of each service name
+func applicableRules(rules []internal.MaintenanceRule, v internal.Vehicle) (r []internal.MaintenanceRule) {
+	byName := make(map[string]int)
+	for _, rule := range rules {
+		if !rule.Matches(v) {
+			continue
+		}
+		name := strings.ToLower(rule.Name)
+		i, ok := byName[name]
+		if !ok {
+			byName[name] = len(r)
+			r = append(r, rule)
+			continue
+		}
+		if rule.Specificity() > r[i].Specificity() {
+			r[i] = rule
+		}
+	}
+	return
+}
+
+// truncateDay is a function that returns the start of the day of a time in UTC, zero times are kept
+func truncateDay(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/loader"
+	"github.com/rhinosc/code-review-1/internal/repository"
+)
+
+// maintenanceDay is a function that returns a day in UTC
+func maintenanceDay(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// newMaintenanceService is a function that returns the service of a fleet saved to a temporary directory:
+//   - vehicle 1 is a Ford Transit, serviced at 20000 km in January 2026 and read at 25500 km by its fuel log since
+//   - vehicle 2 is a Ford Focus, serviced in May 2025 and repaired since
+//   - vehicle 3 is a Seat Leon, never inspected but for an inspection planned in June 2026
+//   - vehicle 4 is a decommissioned Ford Transit, never serviced
+//
+// Fords get an oil change every 10000 km or 12 months and Transits every 5000 km, every vehicle an inspection every 12 months
+func newMaintenanceService(t *testing.T) *MaintenanceDefault {
+	t.Helper()
+	dir := t.TempDir()
+	vehicles := make(map[int]internal.Vehicle)
+	for id, value := range map[int]struct {
+		brand, model string
+		status       internal.VehicleStatus
+	}{1: {"Ford", "Transit", internal.VehicleStatusInService}, 2: {"Ford", "Focus", internal.VehicleStatusInService}, 3: {"Seat", "Leon", internal.VehicleStatusInService}, 4: {"Ford", "Transit", internal.VehicleStatusDecommissioned}} {
+		vehicles[id] = internal.Vehicle{
+			Id:                id,
+			VehicleAttributes: internal.VehicleAttributes{Brand: value.brand, Model: value.model, FabricationYear: 2020, Capacity: 5},
+			Status:            value.status,
+		}
+	}
+	data := internal.MaintenanceData{
+		Records: map[int]internal.MaintenanceRecord{
+			1: {ID: 1, VehicleID: 1, Type: internal.MaintenanceTypeService, Date: maintenanceDay(2026, time.January, 10), Odometer: 20000},
+			2: {ID: 2, VehicleID: 1, Type: internal.MaintenanceTypeInspection, Date: maintenanceDay(2025, time.June, 10), Odometer: 15000},
+			3: {ID: 3, VehicleID: 2, Type: internal.MaintenanceTypeService, Date: maintenanceDay(2025, time.May, 15), EndDate: maintenanceDay(2025, time.May, 20), Odometer: 8000},
+			4: {ID: 4, VehicleID: 2, Type: internal.MaintenanceTypeInspection, Date: maintenanceDay(2025, time.September, 1), Odometer: 9000},
+			5: {ID: 5, VehicleID: 2, Type: internal.MaintenanceTypeRepair, Date: maintenanceDay(2026, time.May, 30), Odometer: 12000},
+			6: {ID: 6, VehicleID: 3, Type: internal.MaintenanceTypeInspection, Date: maintenanceDay(2026, time.June, 20)},
+		},
+		Rules: map[int]internal.MaintenanceRule{
+			1: {ID: 1, Name: "Oil change", Type: internal.MaintenanceTypeService, Brand: "Ford", EveryKm: 10000, EveryMonths: 12},
+			2: {ID: 2, Name: "oil change", Type: internal.MaintenanceTypeService, Brand: "ford", Model: "transit", EveryKm: 5000},
+			3: {ID: 3, Name: "Inspection", Type: internal.MaintenanceTypeInspection, EveryMonths: 12},
+		},
+	}
+	entries := map[int]internal.FuelLogEntry{
+		1: {ID: 1, VehicleID: 1, Date: maintenanceDay(2026, time.May, 1), Odometer: 25500},
+	}
+
+	vr := repository.NewVehicleMap(loader.NewVehicleJSONFile(filepath.Join(dir, "vehicles.json")), vehicles, repository.NewVehicleIDSequential())
+	rp := repository.NewMaintenanceMap(loader.NewMaintenanceJSONFile(&loader.ConfigMaintenanceJSONFile{Path: filepath.Join(dir, "maintenance.json")}), data)
+	fr := repository.NewFuelLogMap(loader.NewFuelLogJSONFile(&loader.ConfigFuelLogJSONFile{Path: filepath.Join(dir, "fuel_log.json")}), entries)
+	return NewMaintenanceDefault(rp, vr, fr)
+}
+
+func TestMaintenanceDefault_Due(t *testing.T) {
+	now := maintenanceDay(2026, time.June, 1)
+
+	// due is a struct that represents the expected fields of a due service
+	type due struct {
+		vehicleID, ruleID int
+		lastID            int
+		dueDate           time.Time
+		dueOdometer       int
+		odometer          int
+		overdue           bool
+	}
+	var (
+		// - the Transit rule overrides the Ford one, and the distance of the fuel log is past its km
+		transitOil = due{vehicleID: 1, ruleID: 2, lastID: 1, dueOdometer: 25000, odometer: 25500, overdue: true}
+		// - the planned inspection does not count until it is done
+		seatInspection = due{vehicleID: 3, ruleID: 3, overdue: true}
+		// - the date counts from the end of the work, and the repair does not fulfil an oil change
+		focusOil = due{vehicleID: 2, ruleID: 1, lastID: 3, dueDate: maintenanceDay(2026, time.May, 20), dueOdometer: 18000, odometer: 12000, overdue: true}
+		// - upcoming services are not overdue
+		transitInspection = due{vehicleID: 1, ruleID: 3, lastID: 2, dueDate: maintenanceDay(2026, time.June, 10), odometer: 25500}
+		focusInspection   = due{vehicleID: 2, ruleID: 3, lastID: 4, dueDate: maintenanceDay(2026, time.September, 1), odometer: 12000}
+	)
+
+	tests := []struct {
+		name   string
+		before time.Time
+		want   []due
+	}{
+		{name: "overdue only", before: now, want: []due{transitOil, seatInspection, focusOil}},
+		{name: "upcoming within the month", before: maintenanceDay(2026, time.July, 1), want: []due{transitOil, seatInspection, focusOil, transitInspection}},
+		{name: "upcoming on the day", before: maintenanceDay(2026, time.June, 10), want: []due{transitOil, seatInspection, focusOil, transitInspection}},
+		{name: "upcoming within the year", before: maintenanceDay(2026, time.December, 31), want: []due{transitOil, seatInspection, focusOil, transitInspection, focusInspection}},
+	}
+	sv := newMaintenanceService(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := sv.Due(now, tt.before)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]due, 0, len(d))
+			for _, value := range d {
+				g := due{vehicleID: value.VehicleID, ruleID: value.Rule.ID, dueDate: value.DueDate, dueOdometer: value.DueOdometer, odometer: value.Odometer, overdue: value.Overdue}
+				if value.Last != nil {
+					g.lastID = value.Last.ID
+				}
+				got = append(got, g)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaintenanceDefault_Due_Done(t *testing.T) {
+	sv := newMaintenanceService(t)
+	now := maintenanceDay(2026, time.June, 1)
+
+	// - a service of the Transit at its latest reading clears the distance, one fulfilling another rule does not
+	for _, rec := range []internal.MaintenanceRecord{
+		{VehicleID: 1, RuleID: 2, Date: maintenanceDay(2026, time.May, 31), Odometer: 25500},
+		{VehicleID: 2, RuleID: 3, Date: maintenanceDay(2026, time.May, 31), Odometer: 12000},
+	} {
+		if err := sv.Create(&rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d, err := sv.Due(now, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids [][2]int
+	for _, value := range d {
+		ids = append(ids, [2]int{value.VehicleID, value.Rule.ID})
+	}
+	if want := [][2]int{{3, 3}, {2, 1}}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("got vehicles and rules %v, want %v", ids, want)
+	}
+}
+
+func TestMaintenanceDefault_Create(t *testing.T) {
+	tests := []struct {
+		name     string
+		record   internal.MaintenanceRecord
+		wantType internal.MaintenanceType
+		wantErr  error
+	}{
+		{name: "type of the rule", record: internal.MaintenanceRecord{VehicleID: 1, RuleID: 3, Date: maintenanceDay(2026, time.May, 2)}, wantType: internal.MaintenanceTypeInspection},
+		{name: "type other than the rule", record: internal.MaintenanceRecord{VehicleID: 1, RuleID: 3, Type: internal.MaintenanceTypeRepair, Date: maintenanceDay(2026, time.May, 2)}, wantErr: internal.ErrMaintenanceInvalid},
+		{name: "rule not found", record: internal.MaintenanceRecord{VehicleID: 1, RuleID: 9, Date: maintenanceDay(2026, time.May, 2)}, wantErr: internal.ErrMaintenanceRuleNotFound},
+		{name: "end before start", record: internal.MaintenanceRecord{VehicleID: 1, Type: internal.MaintenanceTypeRepair, Date: maintenanceDay(2026, time.May, 2), EndDate: maintenanceDay(2026, time.May, 1)}, wantErr: internal.ErrMaintenanceInvalid},
+		{name: "vehicle not found", record: internal.MaintenanceRecord{VehicleID: 9, Type: internal.MaintenanceTypeRepair, Date: maintenanceDay(2026, time.May, 2)}, wantErr: internal.ErrVehicleNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv := newMaintenanceService(t)
+			rec := tt.record
+
+			err := sv.Create(&rec)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (rec.ID == 0 || rec.Type != tt.wantType) {
+				t.Errorf("got %+v, want a record of type %s", rec, tt.wantType)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// NewReservationDefault is a function that returns a new instance of ReservationDefault
+func NewReservationDefault(rp internal.ReservationRepository, vr internal.VehicleRepository, mr internal.MaintenanceRepository) *ReservationDefault {
+	return &ReservationDefault{rp: rp, vr: vr, mr: mr}
+}
+
+// ReservationDefault is a struct that represents the default service for reservations
+type ReservationDefault struct {
+	// rp is the repository of the reservations
+	rp internal.ReservationRepository
+	// vr is the repository of the vehicles
+	vr internal.VehicleRepository
+	// mr is the repository of the maintenance records, whose windows the vehicles can not be booked in
+	mr internal.MaintenanceRepository
+}
+
+// FindByVehicle is a method that returns the reservations of a vehicle, earliest first
+func (s *ReservationDefault) FindByVehicle(vehicleID int) (r []internal.Reservation, err error) {
+	if _, err = s.vr.FindByID(vehicleID); err != nil {
+		return
+	}
+	r, err = s.rp.FindByVehicle(vehicleID)
+	return
+}
+
+// Create is a method that books a vehicle in service or reserved. It fails with ErrReservationConflict
+// when the vehicle is in another status, in the workshop or booked by another active reservation
+func (s *ReservationDefault) Create(r *internal.Reservation) (err error) {
+	v, err := s.vr.FindByID(r.VehicleID)
+	if err != nil {
+		return
+	}
+	now := time.Now().UTC()
+	r.Driver, r.Purpose = strings.TrimSpace(r.Driver), strings.TrimSpace(r.Purpose)
+	r.From, r.To = r.From.UTC(), r.To.UTC()
+	r.Status, r.CreatedAt, r.CancelledAt = internal.ReservationStatusActive, now, time.Time{}
+	if err = r.Validate(); err != nil {
+		return
+	}
+	if !r.To.After(now) {
+		err = fmt.Errorf("%w: to must be in the future", internal.ErrReservationInvalid)
+		return
+	}
+	if !bookable(v) {
+		err = fmt.Errorf("%w: vehicle %d is %s", internal.ErrReservationConflict, v.Id, v.Status)
+		return
+	}
+
+	// - the repository checks the other reservations under its lock
+	records, err := s.mr.FindByVehicle(r.VehicleID)
+	if err != nil {
+		return
+	}
+	for _, rec := range records {
+		if from, to := rec.Window(); from.Before(r.To) && r.From.Before(to) {
+			err = fmt.Errorf("%w: vehicle %d is in the workshop from %s to %s", internal.ErrReservationConflict,
+				r.VehicleID, formatDay(rec.Date), formatDay(rec.End()))
+			return
+		}
+	}
+	err = s.rp.Create(r)
+	return
+}
+
+// Cancel is a method that cancels an active reservation of a vehicle, it fails with ErrReservationCancelled when it was already cancelled
+func (s *ReservationDefault) Cancel(vehicleID, id int) (r internal.Reservation, err error) {
+	r, err = s.rp.Update(id, func(r *internal.Reservation) (err error) {
+		switch {
+		case r.VehicleID != vehicleID:
+			err = fmt.Errorf("%w: %d", internal.ErrReservationNotFound, id)
+		case !r.Active():
+			err = fmt.Errorf("%w: %d", internal.ErrReservationCancelled, id)
+		default:
+			r.Status, r.CancelledAt = internal.ReservationStatusCancelled, time.Now().UTC()
+		}
+		return
+	})
+	return
+}
+
+// Available is a method that returns the vehicles in service or reserved with a capacity of at least minPassengers
+// that are neither booked nor in the workshop at some time of [from, to), ordered by id
+func (s *ReservationDefault) Available(from, to time.Time, minPassengers int) (v []internal.Vehicle, err error) {
+	if !from.Before(to) {
+		err = fmt.Errorf("%w: from must be before to", internal.ErrReservationInvalid)
+		return
+	}
+	vehicles, err := s.vr.FindAll()
+	if err != nil {
+		return
+	}
+	reservations, err := s.rp.FindAll()
+	if err != nil {
+		return
+	}
+	records, err := s.mr.FindAll()
+	if err != nil {
+		return
+	}
+
+	// busy vehicles
+	busy := make(map[int]bool)
+	for _, r := range reservations {
+		if r.Overlaps(from, to) {
+			busy[r.VehicleID] = true
+		}
+	}
+	for _, rec := range records {
+		if start, end := rec.Window(); start.Before(to) && from.Before(end) {
+			busy[rec.VehicleID] = true
+		}
+	}
+
+	v = make([]internal.Vehicle, 0)
+	for _, value := range vehicles {
+		if busy[value.Id] || !bookable(value) || value.Capacity < minPassengers {
+			continue
+		}
+		v = append(v, value)
+	}
+	sort.Slice(v, func(i, j int) bool { return v[i].Id < v[j].Id })
+	return
+}
+
+// bookable is a function that reports whether the status of a vehicle lets it be booked
+func bookable(v internal.Vehicle) bool {
+	return v.Status == internal.VehicleStatusInService || v.Status == internal.VehicleStatusReserved
+}
+
+// formatDay is a function that formats the day of a time
+func formatDay(t time.Time) string {
+	return t.Format("2006-01-02")
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/loader"
+	"github.com/rhinosc/code-review-1/internal/repository"
+)
+
+// newReservationService is a function that returns the service of a fleet saved to a temporary directory, from the day given on:
+//   - vehicle 1 seats 5, it is booked from 10:00 to 12:00 and was booked from 12:00 to 14:00 by a cancelled reservation
+//   - vehicle 2 seats 2, it is in the workshop the next day
+//   - vehicle 3 seats 7, it is in maintenance
+//   - vehicle 4 seats 9, it is reserved
+func newReservationService(t *testing.T, day time.Time) *ReservationDefault {
+	t.Helper()
+	dir := t.TempDir()
+	vehicles := make(map[int]internal.Vehicle)
+	for id, value := range map[int]struct {
+		capacity int
+		status   internal.VehicleStatus
+	}{1: {5, internal.VehicleStatusInService}, 2: {2, internal.VehicleStatusInService}, 3: {7, internal.VehicleStatusInMaintenance}, 4: {9, internal.VehicleStatusReserved}} {
+		vehicles[id] = internal.Vehicle{
+			Id:                id,
+			VehicleAttributes: internal.VehicleAttributes{Brand: "Ford", Model: "Transit", FabricationYear: 2020, Capacity: value.capacity},
+			Status:            value.status,
+		}
+	}
+	reservations := map[int]internal.Reservation{
+		1: {ID: 1, VehicleID: 1, Driver: "Ana", From: day.Add(10 * time.Hour), To: day.Add(12 * time.Hour), Status: internal.ReservationStatusActive},
+		2: {ID: 2, VehicleID: 1, Driver: "Luis", From: day.Add(12 * time.Hour), To: day.Add(14 * time.Hour), Status: internal.ReservationStatusCancelled},
+	}
+	records := internal.MaintenanceData{Records: map[int]internal.MaintenanceRecord{
+		1: {ID: 1, VehicleID: 2, Type: internal.MaintenanceTypeService, Date: day.AddDate(0, 0, 1), Odometer: 10000},
+	}}
+
+	vr := repository.NewVehicleMap(loader.NewVehicleJSONFile(filepath.Join(dir, "vehicles.json")), vehicles, repository.NewVehicleIDSequential())
+	rp := repository.NewReservationMap(loader.NewReservationJSONFile(&loader.ConfigReservationJSONFile{Path: filepath.Join(dir, "reservations.json")}), reservations)
+	mr := repository.NewMaintenanceMap(loader.NewMaintenanceJSONFile(&loader.ConfigMaintenanceJSONFile{Path: filepath.Join(dir, "maintenance.json")}), records)
+	return NewReservationDefault(rp, vr, mr)
+}
+
+// testDay is a function that returns the start of a day a month from now, so reservations on it are in the future
+func testDay() time.Time {
+	return time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 1, 0)
+}
+
+func TestReservationDefault_Create(t *testing.T) {
+	day := testDay()
+	workshop := day.AddDate(0, 0, 1)
+
+	tests := []struct {
+		name      string
+		vehicleID int
+		driver    string
+		from      time.Time
+		to        time.Time
+		wantErr   error
+	}{
+		// bookings
+		{name: "overlapping a booking", vehicleID: 1, driver: "Eva", from: day.Add(11 * time.Hour), to: day.Add(13 * time.Hour), wantErr: internal.ErrReservationConflict},
+		{name: "back to back with a booking", vehicleID: 1, driver: "Eva", from: day.Add(9 * time.Hour), to: day.Add(10 * time.Hour)},
+		{name: "over a cancelled booking", vehicleID: 1, driver: "Eva", from: day.Add(12 * time.Hour), to: day.Add(14 * time.Hour)},
+		// maintenance windows, whole days
+		{name: "in the workshop", vehicleID: 2, driver: "Eva", from: workshop.Add(20 * time.Hour), to: workshop.Add(22 * time.Hour), wantErr: internal.ErrReservationConflict},
+		{name: "over the workshop", vehicleID: 2, driver: "Eva", from: day.Add(20 * time.Hour), to: workshop.Add(time.Second), wantErr: internal.ErrReservationConflict},
+		{name: "ending when the workshop starts", vehicleID: 2, driver: "Eva", from: day.Add(20 * time.Hour), to: workshop},
+		{name: "starting when the workshop ends", vehicleID: 2, driver: "Eva", from: workshop.AddDate(0, 0, 1), to: workshop.AddDate(0, 0, 1).Add(time.Hour)},
+		// status of the vehicle
+		{name: "vehicle in maintenance", vehicleID: 3, driver: "Eva", from: day, to: day.Add(time.Hour), wantErr: internal.ErrReservationConflict},
+		{name: "vehicle reserved", vehicleID: 4, driver: "Eva", from: day, to: day.Add(time.Hour)},
+		// attributes
+		{name: "in the past", vehicleID: 1, driver: "Eva", from: day.AddDate(0, -2, 0), to: day.AddDate(0, -2, 0).Add(time.Hour), wantErr: internal.ErrReservationInvalid},
+		{name: "empty interval", vehicleID: 1, driver: "Eva", from: day, to: day, wantErr: internal.ErrReservationInvalid},
+		{name: "without a driver", vehicleID: 1, driver: "  ", from: day, to: day.Add(time.Hour), wantErr: internal.ErrReservationInvalid},
+		{name: "vehicle not found", vehicleID: 9, driver: "Eva", from: day, to: day.Add(time.Hour), wantErr: internal.ErrVehicleNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv := newReservationService(t, day)
+
+			r := internal.Reservation{VehicleID: tt.vehicleID, Driver: tt.driver, From: tt.from, To: tt.to}
+			err := sv.Create(&r)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if r.ID != 3 || !r.Active() || r.CreatedAt.IsZero() {
+				t.Errorf("got %+v, want reservation 3 active", r)
+			}
+		})
+	}
+}
+
+func TestReservationDefault_Available(t *testing.T) {
+	day := testDay()
+	workshop := day.AddDate(0, 0, 1)
+
+	tests := []struct {
+		name          string
+		from          time.Time
+		to            time.Time
+		minPassengers int
+		want          []int
+		wantErr       error
+	}{
+		{name: "no booking nor workshop", from: day, to: day.Add(10 * time.Hour), minPassengers: 1, want: []int{1, 2, 4}},
+		{name: "during a booking", from: day.Add(11 * time.Hour), to: day.Add(12 * time.Hour), minPassengers: 1, want: []int{2, 4}},
+		{name: "after a booking, during a cancelled one", from: day.Add(12 * time.Hour), to: day.Add(14 * time.Hour), minPassengers: 1, want: []int{1, 2, 4}},
+		{name: "during the workshop", from: workshop.Add(time.Hour), to: workshop.Add(2 * time.Hour), minPassengers: 1, want: []int{1, 4}},
+		{name: "ending when the workshop starts", from: day.Add(20 * time.Hour), to: workshop, minPassengers: 1, want: []int{1, 2, 4}},
+		{name: "capacity", from: day, to: day.Add(time.Hour), minPassengers: 5, want: []int{1, 4}},
+		{name: "capacity of none", from: day, to: day.Add(time.Hour), minPassengers: 10, want: []int{}},
+		{name: "empty interval", from: day, to: day, minPassengers: 1, wantErr: internal.ErrReservationInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv := newReservationService(t, day)
+
+			v, err := sv.Available(tt.from, tt.to, tt.minPassengers)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got := make([]int, 0, len(v))
+			for _, value := range v {
+				got = append(got, value.Id)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got vehicles %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReservationDefault_Cancel(t *testing.T) {
+	day := testDay()
+	sv := newReservationService(t, day)
+
+	tests := []struct {
+		name      string
+		vehicleID int
+		id        int
+		wantErr   error
+	}{
+		{name: "of another vehicle", vehicleID: 2, id: 1, wantErr: internal.ErrReservationNotFound},
+		{name: "active", vehicleID: 1, id: 1},
+		{name: "already cancelled", vehicleID: 1, id: 1, wantErr: internal.ErrReservationCancelled},
+		{name: "not found", vehicleID: 1, id: 9, wantErr: internal.ErrReservationNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := sv.Cancel(tt.vehicleID, tt.id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (r.Active() || r.CancelledAt.IsZero()) {
+				t.Errorf("got %+v, want it cancelled", r)
+			}
+		})
+	}
+
+	// - the cancelled reservation no longer holds the vehicle
+	r := internal.Reservation{VehicleID: 1, Driver: "Eva", From: day.Add(10 * time.Hour), To: day.Add(12 * time.Hour)}
+	if err := sv.Create(&r); err != nil {
+		t.Errorf("got %v, want the vehicle booked again", err)
+	}
+}
@@ -2,19 +2,43 @@ package service
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/rhinosc/code-review-1/internal"
 )
 
 // NewVehicleDefault is a function that returns a new instance of VehicleDefault
-func NewVehicleDefault(rp internal.VehicleRepository) *VehicleDefault {
-	return &VehicleDefault{rp: rp}
+func NewVehicleDefault(rp internal.VehicleRepository, nz *VehicleNormalizer) *VehicleDefault {
+	// default normalizer
+	if nz == nil {
+		nz = NewVehicleNormalizer(nil)
+	}
+	return &VehicleDefault{rp: rp, nz: nz}
 }
 
 // VehicleDefault is a struct that represents the default service for vehicles
 type VehicleDefault struct {
 	// rp is the repository that will be used by the service
 	rp internal.VehicleRepository
+	// nz is the normalizer that canonicalises attributes on write and query
+	nz *VehicleNormalizer
+	// onDelete is the functions called with every deleted vehicle
+	onDelete []func(v internal.Vehicle)
+}
+
+// raise is a function that returns an EventsFunc raising a single event of a type
+func raise(t internal.EventType) internal.EventsFunc {
+	return func(v internal.Vehicle) (e []internal.Event) {
+		e = []internal.Event{internal.NewEvent(t, v)}
+		return
+	}
+}
+
+// OnDelete is a method that registers a function to call with every deleted vehicle, e.g. to clean up what refers to it.
+// It must be called before the service is used
+func (s *VehicleDefault) OnDelete(fn func(v internal.Vehicle)) {
+	s.onDelete = append(s.onDelete, fn)
 }
 
 // FindAll is a method that returns a map of all vehicles
@@ -23,33 +47,141 @@ func (s *VehicleDefault) FindAll() (v map[int]internal.Vehicle, err error) {
 	return
 }
 
-// Create is a method that creates a vehicle
+// FindByStatus is a method that returns a map of the vehicles in the statuses of the filter, every vehicle when it is empty
+func (s *VehicleDefault) FindByStatus(f internal.StatusFilter) (v map[int]internal.Vehicle, err error) {
+	v, err = s.rp.FindByStatus(f)
+	return
+}
+
+// FindAfter is a method that returns up to limit vehicles with an id greater than afterID, ordered by id
+func (s *VehicleDefault) FindAfter(afterID, limit int) (v []internal.Vehicle, err error) {
+	v, err = s.rp.FindAfter(afterID, limit)
+	return
+}
+
+// FindByID is a method that returns a vehicle by id
+func (s *VehicleDefault) FindByID(id int) (v internal.Vehicle, err error) {
+	v, err = s.rp.FindByID(id)
+	return
+}
+
+// Create is a method that creates a vehicle, in service unless another status is given.
+// It fails with ErrVehicleInvalid when the attributes are inconsistent, as the loader would reject the vehicle once saved
 func (s *VehicleDefault) Create(v *internal.Vehicle) (err error) {
-	err = s.rp.Create(v)
+	s.nz.Vehicle(v)
+	if v.Status == "" {
+		v.Status = internal.VehicleStatusInService
+	}
+	// - the id is given by the repository, so only the attributes and status are checked
+	if err = v.VehicleAttributes.Validate(); err != nil {
+		return
+	}
+	if !v.Status.Valid() {
+		err = fmt.Errorf("%w: status %q is unknown", internal.ErrVehicleInvalid, v.Status)
+		return
+	}
+	err = s.rp.Create(v, raise(internal.EventVehicleCreated))
+	return
+}
+
+// Update is a method that replaces the attributes of a vehicle, its status and history are kept.
+// It fails with ErrVehicleInvalid when the attributes are inconsistent, and raises no event when nothing changed
+func (s *VehicleDefault) Update(id int, attrs internal.VehicleAttributes) (v internal.Vehicle, err error) {
+	var previous internal.Vehicle
+	v, err = s.rp.Update(id, func(v *internal.Vehicle) (err error) {
+		previous = *v
+		v.VehicleAttributes = attrs
+		s.nz.Vehicle(v)
+		err = v.Validate()
+		return
+	}, func(v internal.Vehicle) (e []internal.Event) {
+		if v.VehicleAttributes == previous.VehicleAttributes {
+			return
+		}
+		ev := internal.NewEvent(internal.EventVehicleUpdated, v)
+		ev.Previous = &previous
+		e = append(e, ev)
+		return
+	})
 	return
 }
 
-// GetByColorAndYear is a method that returns a map of vehicles by color and year
-func (s *VehicleDefault) GetByColorAndYear(color string, year int) (v map[int]internal.Vehicle, err error) {
-	v, err = s.rp.GetByColorAndYear(color, year)
+// Delete is a method that deletes a vehicle, then calls the functions registered with OnDelete
+func (s *VehicleDefault) Delete(id int) (err error) {
+	v, err := s.rp.Delete(id, raise(internal.EventVehicleDeleted))
+	if err != nil {
+		return
+	}
+	for _, fn := range s.onDelete {
+		fn(v)
+	}
+	return
+}
+
+// Transition is a method that changes the status of a vehicle, it fails with ErrVehicleTransitionInvalid when the change is not allowed
+func (s *VehicleDefault) Transition(id int, to internal.VehicleStatus, reason string) (v internal.Vehicle, t internal.StatusTransition, err error) {
+	v, err = s.rp.Update(id, func(v *internal.Vehicle) (err error) {
+		t, err = v.Transition(to, time.Now().UTC(), reason)
+		return
+	}, func(v internal.Vehicle) (e []internal.Event) {
+		ev := internal.NewEvent(internal.EventVehicleStatusChanged, v)
+		ev.Transition = &t
+		e = append(e, ev)
+		return
+	})
+	return
+}
+
+// GetByColorAndYear is a method that returns a map of vehicles by color and year in the statuses of the filter
+func (s *VehicleDefault) GetByColorAndYear(color string, year int, status internal.StatusFilter) (v map[int]internal.Vehicle, err error) {
+	v, err = s.rp.GetByColorAndYear(s.nz.Normalize(internal.FieldColor, color), year, status)
 	if err != nil {
 		err = fmt.Errorf("error getting vehicles by color and year: %w", err)
 	}
 	return
 }
 
-// GetByDimensions is a method that returns a map of vehicles by dimensions
-func (s *VehicleDefault) GetByDimensions(minLength, maxLength, minWidth, maxWidth float64) (v map[int]internal.Vehicle, err error) {
-	v, err = s.rp.GetByDimensions(minLength, maxLength, minWidth, maxWidth)
+// GetByDimensions is a method that returns a map of vehicles by dimensions in the statuses of the filter
+func (s *VehicleDefault) GetByDimensions(f internal.DimensionsFilter, status internal.StatusFilter) (v map[int]internal.Vehicle, err error) {
+	v, err = s.rp.GetByDimensions(f, status)
 	if err != nil {
 		err = fmt.Errorf("error getting vehicles by dimensions: %w", err)
 	}
 	return
 }
 
+// Search is a method that returns up to limit vehicles matching a typo-tolerant query, most relevant first
+func (s *VehicleDefault) Search(query string, limit int) (m []internal.VehicleMatch, err error) {
+	// - replace known aliases so "chevy" finds Chevrolet
+	terms := strings.Fields(query)
+	for i, term := range terms {
+		for _, field := range []string{internal.FieldBrand, internal.FieldColor} {
+			if canonical, ok := s.nz.Alias(field, term); ok {
+				terms[i] = canonical
+				break
+			}
+		}
+	}
+
+	m, err = s.rp.Search(strings.Join(terms, " "), limit)
+	if err != nil {
+		err = fmt.Errorf("error searching vehicles: %w", err)
+	}
+	return
+}
+
+// Suggest is a method that returns up to limit values of a field starting with a prefix
+func (s *VehicleDefault) Suggest(field, prefix string, limit int) (sg []internal.Suggestion, err error) {
+	sg, err = s.rp.Suggest(field, prefix, limit)
+	if err != nil {
+		err = fmt.Errorf("error suggesting values: %w", err)
+	}
+	return
+}
+
 // GetAverageSpeedByBrand is a method that returns the average speed of a vehicle
 func (s *VehicleDefault) GetAverageSpeedByBrand(brand string) (averageSpeed float64, err error) {
-	averageSpeed, err = s.rp.GetAverageSpeedByBrand(brand)
+	averageSpeed, err = s.rp.GetAverageSpeedByBrand(s.nz.Normalize(internal.FieldBrand, brand))
 	if err != nil {
 		err = fmt.Errorf("error getting average speed by brand: %w", internal.ErrVehicleNotFound)
 	}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/loader"
+	"github.com/rhinosc/code-review-1/internal/repository"
+)
+
+func TestVehicleDefault_Create_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vehicles.json")
+	if err := os.WriteFile(path, []byte("[]"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// open is a function that loads the file as the application does on start up
+	open := func() (*VehicleDefault, map[int]internal.Vehicle) {
+		t.Helper()
+		ld := loader.NewVehicleJSONFile(path)
+		db, err := ld.Load()
+		if err != nil {
+			t.Fatalf("load: %v", err)
+		}
+		return NewVehicleDefault(repository.NewVehicleMap(ld, db, repository.NewVehicleIDSequential()), nil), db
+	}
+	sv, _ := open()
+
+	valid := internal.Vehicle{VehicleAttributes: internal.VehicleAttributes{Brand: "ford", Model: "Transit", FabricationYear: 2020, Capacity: 3}}
+	if err := sv.Create(&valid); err != nil {
+		t.Fatalf("create valid: %v", err)
+	}
+	invalid := []internal.VehicleAttributes{
+		{Brand: "", Model: "", FabricationYear: 1, Capacity: -3},
+		{Brand: "Ford", Model: "Transit", FabricationYear: 2020, Capacity: 0},
+		{Brand: "Ford", Model: "Transit", FabricationYear: 2020, Capacity: 3, Weight: -1},
+	}
+	for _, value := range invalid {
+		v := internal.Vehicle{VehicleAttributes: value}
+		if err := sv.Create(&v); !errors.Is(err, internal.ErrVehicleInvalid) {
+			t.Fatalf("create %+v: got %v, want %v", value, err, internal.ErrVehicleInvalid)
+		}
+	}
+
+	// the saved file loads back with the valid vehicle only
+	_, db := open()
+	if len(db) != 1 {
+		t.Fatalf("loaded %d vehicles, want 1", len(db))
+	}
+	got, ok := db[valid.Id]
+	if !ok || got.Brand != valid.Brand || got.Status != internal.VehicleStatusInService {
+		t.Fatalf("loaded %+v, want %+v", got, valid)
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/search"
+)
+
+// Aliases is a map of alias tables by field, each table maps a folded alias to its canonical value
+type Aliases map[string]map[string]string
+
+// DefaultAliases is the alias table used when none is given
+var DefaultAliases = Aliases{
+	internal.FieldBrand: {
+		"chevy":         "Chevrolet",
+		"chevrolet":     "Chevrolet",
+		"gmc":           "GMC",
+		"bmw":           "BMW",
+		"vw":            "Volkswagen",
+		"mercedes":      "Mercedes-Benz",
+		"mercedes benz": "Mercedes-Benz",
+		"benz":          "Mercedes-Benz",
+		"landrover":     "Land Rover",
+		"rolls royce":   "Rolls-Royce",
+	},
+	internal.FieldColor: {
+		"grey":   "Gray",
+		"mauv":   "Mauve",
+		"fuscia": "Fuchsia",
+	},
+	internal.FieldFuelType: {
+		"gas":         "gasoline",
+		"petrol":      "gasoline",
+		"bio-diesel":  "biodiesel",
+		"bio diesel":  "biodiesel",
+		"electricity": "electric",
+		"ev":          "electric",
+	},
+	internal.FieldTransmission: {
+		"auto":           "automatic",
+		"semi automatic": "semi-automatic",
+		"semiautomatic":  "semi-automatic",
+		"semi-auto":      "semi-automatic",
+		"stick":          "manual",
+	},
+}
+
+// NewVehicleNormalizer is a function that returns a new instance of VehicleNormalizer
+func NewVehicleNormalizer(aliases Aliases) *VehicleNormalizer {
+	// default aliases
+	if aliases == nil {
+		aliases = DefaultAliases
+	}
+	// index aliases by folded key
+	folded := make(Aliases)
+	for field, table := range aliases {
+		folded[field] = make(map[string]string)
+		for alias, canonical := range table {
+			folded[field][search.Fold(collapseSpaces(alias))] = canonical
+		}
+	}
+	return &VehicleNormalizer{aliases: folded}
+}
+
+// VehicleNormalizer is a struct that canonicalises the free-text attributes of vehicles
+type VehicleNormalizer struct {
+	// aliases is the alias table indexed by field and folded alias
+	aliases Aliases
+}
+
+// Normalize is a method that returns the canonical form of a value of a field
+func (n *VehicleNormalizer) Normalize(field, value string) string {
+	value = collapseSpaces(value)
+	if canonical, ok := n.aliases[field][search.Fold(value)]; ok {
+		return canonical
+	}
+
+	switch field {
+	case internal.FieldBrand:
+		// brands keep their spelling unless they were typed all lowercase
+		if value == strings.ToLower(value) {
+			return titleCase(value)
+		}
+		return value
+	case internal.FieldColor:
+		return titleCase(value)
+	case internal.FieldFuelType, internal.FieldTransmission:
+		return strings.ToLower(value)
+	}
+	return value
+}
+
+// Alias is a method that returns the canonical value of an alias of a field
+func (n *VehicleNormalizer) Alias(field, value string) (canonical string, ok bool) {
+	canonical, ok = n.aliases[field][search.Fold(collapseSpaces(value))]
+	return
+}
+
+// Vehicle is a method that canonicalises the attributes of a vehicle and reports whether any changed
+func (n *VehicleNormalizer) Vehicle(v *internal.Vehicle) (changed bool) {
+	before := v.VehicleAttributes
+	v.Brand = n.Normalize(internal.FieldBrand, v.Brand)
+	v.Model = collapseSpaces(v.Model)
+	v.Registration = collapseSpaces(v.Registration)
+	v.Color = n.Normalize(internal.FieldColor, v.Color)
+	v.FuelType = n.Normalize(internal.FieldFuelType, v.FuelType)
+	v.Transmission = n.Normalize(internal.FieldTransmission, v.Transmission)
+	return before != v.VehicleAttributes
+}
+
+// collapseSpaces is a function that trims a string and collapses its inner whitespace to single spaces
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// titleCase is a function that upper-cases the first letter of every word and lower-cases the rest
+func titleCase(s string) string {
+	words := strings.Fields(s)
+	for i, word := range words {
+		rs := []rune(strings.ToLower(word))
+		rs[0] = unicode.ToTitle(rs[0])
+		words[i] = string(rs)
+	}
+	return strings.Join(words, " ")
+}
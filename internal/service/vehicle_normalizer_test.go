@@ -0,0 +1,112 @@
+package service
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/loader"
+	"github.com/rhinosc/code-review-1/internal/repository"
+)
+
+func TestVehicleNormalizer_Normalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		value string
+		want  string
+	}{
+		// aliases, matched on the folded value with the spaces collapsed
+		{name: "brand alias", field: internal.FieldBrand, value: "CHEVY", want: "Chevrolet"},
+		{name: "brand alias of several words", field: internal.FieldBrand, value: "  mercedes   BENZ ", want: "Mercedes-Benz"},
+		{name: "color alias", field: internal.FieldColor, value: "grey", want: "Gray"},
+		{name: "fuel type alias", field: internal.FieldFuelType, value: "Gas", want: "gasoline"},
+		{name: "transmission alias", field: internal.FieldTransmission, value: "Semi   AUTOMATIC", want: "semi-automatic"},
+		{name: "alias folded beyond ASCII", field: internal.FieldTransmission, value: "ſtick", want: "manual"},
+		// canonical forms
+		{name: "brand in lowercase", field: internal.FieldBrand, value: "land rover", want: "Land Rover"},
+		{name: "brand with its own spelling", field: internal.FieldBrand, value: "McLaren", want: "McLaren"},
+		{name: "color", field: internal.FieldColor, value: " dark   BLUE ", want: "Dark Blue"},
+		{name: "color beyond ASCII", field: internal.FieldColor, value: "ÉMERAUDE", want: "Émeraude"},
+		{name: "fuel type", field: internal.FieldFuelType, value: " Diesel ", want: "diesel"},
+		{name: "transmission", field: internal.FieldTransmission, value: "MANUAL", want: "manual"},
+		{name: "other field", field: internal.FieldModel, value: " Model  S ", want: "Model S"},
+		{name: "empty", field: internal.FieldColor, value: "  ", want: ""},
+	}
+	nz := NewVehicleNormalizer(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nz.Normalize(tt.field, tt.value); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVehicleNormalizer_Vehicle(t *testing.T) {
+	nz := NewVehicleNormalizer(Aliases{internal.FieldFuelType: {"Gas Oil": "diesel"}})
+
+	v := internal.Vehicle{VehicleAttributes: internal.VehicleAttributes{
+		Brand: "seat", Model: " Leon ", Registration: "1234  ABC", Color: "red", FuelType: "GAS  OIL", Transmission: "Manual",
+	}}
+	if !nz.Vehicle(&v) {
+		t.Error("got no change reported, want the attributes changed")
+	}
+	want := internal.VehicleAttributes{Brand: "Seat", Model: "Leon", Registration: "1234 ABC", Color: "Red", FuelType: "diesel", Transmission: "manual"}
+	if v.VehicleAttributes != want {
+		t.Errorf("got %+v, want %+v", v.VehicleAttributes, want)
+	}
+
+	// - a canonical vehicle is left as is, and the default aliases are not used with a table given
+	v.FuelType = "gasoline"
+	if nz.Vehicle(&v) {
+		t.Errorf("got a change reported for %+v, want none", v.VehicleAttributes)
+	}
+	v.FuelType = "gas"
+	if nz.Vehicle(&v); v.FuelType != "gas" {
+		t.Errorf("got fuel type %q, want gas as the default aliases are replaced", v.FuelType)
+	}
+}
+
+func TestVehicleDefault_Normalize(t *testing.T) {
+	ld := loader.NewVehicleJSONFile(filepath.Join(t.TempDir(), "vehicles.json"))
+	sv := NewVehicleDefault(repository.NewVehicleMap(ld, nil, repository.NewVehicleIDSequential()), nil)
+
+	// - attributes are canonicalised on create
+	v := internal.Vehicle{VehicleAttributes: internal.VehicleAttributes{
+		Brand: "chevy", Model: "Spark", Color: "GREY", FabricationYear: 2015, Capacity: 4, MaxSpeed: 150, FuelType: "Petrol", Transmission: "AUTO",
+	}}
+	if err := sv.Create(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Brand != "Chevrolet" || v.Color != "Gray" || v.FuelType != "gasoline" || v.Transmission != "automatic" {
+		t.Errorf("got %+v, want the attributes canonical", v.VehicleAttributes)
+	}
+
+	// - and on update
+	attrs := v.VehicleAttributes
+	attrs.FuelType, attrs.Color = "EV", "fuscia"
+	updated, err := sv.Update(v.Id, attrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.FuelType != "electric" || updated.Color != "Fuchsia" {
+		t.Errorf("got %+v, want the attributes canonical", updated.VehicleAttributes)
+	}
+
+	// - queries match aliases and any case
+	for _, color := range []string{"fuchsia", "FUSCIA", " Fuchsia "} {
+		if found, err := sv.GetByColorAndYear(color, 2015, nil); err != nil || len(found) != 1 {
+			t.Errorf("color %q: got %d vehicles, %v, want 1", color, len(found), err)
+		}
+	}
+	for _, brand := range []string{"chevrolet", "CHEVY", "Chevy"} {
+		if speed, err := sv.GetAverageSpeedByBrand(brand); err != nil || speed != 150 {
+			t.Errorf("brand %q: got %v, %v, want 150", brand, speed, err)
+		}
+	}
+	if _, err := sv.GetAverageSpeedByBrand("chev"); !errors.Is(err, internal.ErrVehicleNotFound) {
+		t.Errorf("got %v, want %v", err, internal.ErrVehicleNotFound)
+	}
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// NewWebhookDefault is a function that returns a new instance of WebhookDefault
+func NewWebhookDefault(rp internal.WebhookRepository, dp internal.WebhookDispatcher) *WebhookDefault {
+	return &WebhookDefault{rp: rp, dp: dp}
+}
+
+// WebhookDefault is a struct that represents the default service for webhooks
+type WebhookDefault struct {
+	// rp is the repository of the webhooks
+	rp internal.WebhookRepository
+	// dp is the dispatcher of the deliveries
+	dp internal.WebhookDispatcher
+}
+
+// FindAll is a method that returns every webhook, ordered by id
+func (s *WebhookDefault) FindAll() (w []internal.Webhook, err error) {
+	w, err = s.rp.FindAll()
+	return
+}
+
+// FindByID is a method that returns a webhook by id
+func (s *WebhookDefault) FindByID(id int) (w internal.Webhook, err error) {
+	w, err = s.rp.FindByID(id)
+	return
+}
+
+// Create is a method that registers a webhook, a random secret of 32 bytes in hex is generated when none is given.
+// The URL must be one the dispatcher may deliver to
+func (s *WebhookDefault) Create(w *internal.Webhook) (err error) {
+	w.URL = strings.TrimSpace(w.URL)
+	if w.Secret == "" {
+		var b [32]byte
+		if _, err = rand.Read(b[:]); err != nil {
+			return
+		}
+		w.Secret = hex.EncodeToString(b[:])
+	}
+	// - each type once
+	seen := make(map[internal.EventType]bool, len(w.Events))
+	events := make([]internal.EventType, 0, len(w.Events))
+	for _, t := range w.Events {
+		t = internal.EventType(strings.ToLower(strings.TrimSpace(string(t))))
+		if !seen[t] {
+			seen[t] = true
+			events = append(events, t)
+		}
+	}
+	w.Events = events
+	w.CreatedAt = time.Now().UTC()
+	if err = w.Validate(); err != nil {
+		return
+	}
+	if err = s.dp.CheckTarget(w.URL); err != nil {
+		return
+	}
+	err = s.rp.Create(w)
+	return
+}
+
+// Delete is a method that deletes a webhook, its pending deliveries are dropped
+func (s *WebhookDefault) Delete(id int) (err error) {
+	err = s.rp.Delete(id)
+	return
+}
+
+// FindDeliveries is a method that returns the logged attempts of a webhook, newest first
+func (s *WebhookDefault) FindDeliveries(id int) (d []internal.WebhookDelivery, err error) {
+	if _, err = s.rp.FindByID(id); err != nil {
+		return
+	}
+	d, err = s.rp.FindDeliveries(id)
+	return
+}
+
+// FindDeadLetters is a method that returns the dead letters of a webhook, oldest first
+func (s *WebhookDefault) FindDeadLetters(id int) (dl []internal.WebhookDeadLetter, err error) {
+	if _, err = s.rp.FindByID(id); err != nil {
+		return
+	}
+	dl, err = s.rp.FindDeadLetters(id)
+	return
+}
+
+// Redeliver is a method that removes a dead letter of a webhook and queues it for delivery again.
+// A delivery failing again every attempt becomes a new dead letter
+func (s *WebhookDefault) Redeliver(id, deadLetterID int) (err error) {
+	w, err := s.rp.FindByID(id)
+	if err != nil {
+		return
+	}
+	dl, err := s.rp.DeleteDeadLetter(id, deadLetterID)
+	if err != nil {
+		return
+	}
+	err = s.dp.Redeliver(w, dl)
+	return
+}
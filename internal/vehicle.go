@@ -1,5 +1,32 @@
 package internal
 
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+var (
+	// ErrVehicleInvalid is an error that represents a vehicle with invalid attributes
+	ErrVehicleInvalid = errors.New("vehicle invalid")
+)
+
+const (
+	// FieldBrand is the name of the brand attribute
+	FieldBrand = "brand"
+	// FieldModel is the name of the model attribute
+	FieldModel = "model"
+	// FieldRegistration is the name of the registration attribute
+	FieldRegistration = "registration"
+	// FieldColor is the name of the color attribute
+	FieldColor = "color"
+	// FieldFuelType is the name of the fuel type attribute
+	FieldFuelType = "fuel_type"
+	// FieldTransmission is the name of the transmission attribute
+	FieldTransmission = "transmission"
+)
+
 // Dimensions is a struct that represents a dimension in 3d
 type Dimensions struct {
 	// Height is the height of the dimension
@@ -10,6 +37,21 @@ type Dimensions struct {
 	Width float64
 }
 
+// DimensionsFilter is a struct that represents the ranges a vehicle's dimensions must fall in
+type DimensionsFilter struct {
+	// Height is the range of the height
+	Height Range
+	// Length is the range of the length
+	Length Range
+	// Width is the range of the width
+	Width Range
+}
+
+// Contains is a method that reports whether the dimensions fall in every range of the filter
+func (f DimensionsFilter) Contains(d Dimensions) bool {
+	return f.Height.Contains(d.Height) && f.Length.Contains(d.Length) && f.Width.Contains(d.Width)
+}
+
 // VehicleAttributes is a struct that represents the attributes of a vehicle
 type VehicleAttributes struct {
 	// Brand is the brand of the vehicle
@@ -40,7 +82,60 @@ type VehicleAttributes struct {
 type Vehicle struct {
 	// Id is the unique identifier of the vehicle
 	Id int
+	// UID is the time-ordered unique identifier of the vehicle, a UUIDv7 or a ULID, empty when it was created without one
+	UID string
 
 	// VehicleAttribue is the attributes of a vehicle
 	VehicleAttributes
+
+	// Status is the lifecycle status of the vehicle
+	Status VehicleStatus
+	// Transitions is the history of the changes of status, oldest first
+	Transitions []StatusTransition
+}
+
+// minFabricationYear is the year of the first automobile
+const minFabricationYear = 1886
+
+// Validate is a method that checks the attributes of a vehicle are consistent
+func (v Vehicle) Validate() (err error) {
+	if v.Id <= 0 {
+		err = fmt.Errorf("%w: id must be positive", ErrVehicleInvalid)
+		return
+	}
+	if err = v.VehicleAttributes.Validate(); err != nil {
+		return
+	}
+	if !v.Status.Valid() {
+		err = fmt.Errorf("%w: status %q is unknown", ErrVehicleInvalid, v.Status)
+	}
+	return
+}
+
+// Validate is a method that checks the attributes are consistent, such as before a vehicle is given an id
+func (a VehicleAttributes) Validate() (err error) {
+	switch {
+	case strings.TrimSpace(a.Brand) == "":
+		err = fmt.Errorf("%w: brand is required", ErrVehicleInvalid)
+	case strings.TrimSpace(a.Model) == "":
+		err = fmt.Errorf("%w: model is required", ErrVehicleInvalid)
+	case a.FabricationYear < minFabricationYear:
+		err = fmt.Errorf("%w: year must not be before %d", ErrVehicleInvalid, minFabricationYear)
+	case a.Capacity < 1:
+		err = fmt.Errorf("%w: passengers must be at least 1", ErrVehicleInvalid)
+	}
+	if err != nil {
+		return
+	}
+
+	for _, f := range []struct {
+		name  string
+		value float64
+	}{{"max_speed", a.MaxSpeed}, {"weight", a.Weight}, {"height", a.Height}, {"length", a.Length}, {"width", a.Width}} {
+		if f.value < 0 || math.IsNaN(f.value) || math.IsInf(f.value, 0) {
+			err = fmt.Errorf("%w: %s must be a non-negative number", ErrVehicleInvalid, f.name)
+			return
+		}
+	}
+	return
 }
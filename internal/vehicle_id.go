@@ -0,0 +1,16 @@
+package internal
+
+// VehicleIDGenerator is an interface that represents a generator of vehicle ids
+type VehicleIDGenerator interface {
+	// Observe is a method that informs the generator of an id in use, so it is never generated
+	Observe(id int)
+
+	// Next is a method that returns a new id greater than every id generated or observed
+	Next() (id int, err error)
+}
+
+// VehicleUIDGenerator is an interface that represents a generator of the time-ordered unique identifiers of vehicles
+type VehicleUIDGenerator interface {
+	// Next is a method that returns a new identifier greater than every identifier generated
+	Next() (uid string, err error)
+}
@@ -1,5 +1,51 @@
 package internal
 
+import (
+	"errors"
+	"strconv"
+)
+
+var (
+	// ErrVehicleDuplicateID is an error that represents a vehicle whose id is already taken
+	ErrVehicleDuplicateID = errors.New("vehicle id duplicated")
+)
+
+// IDRange is a struct that represents a range of consecutive ids, both ends included
+type IDRange struct {
+	// From is the first id of the range
+	From int
+	// To is the last id of the range
+	To int
+}
+
+// String is a method that returns the range as from-to, or a single id when both ends are equal
+func (r IDRange) String() string {
+	if r.From == r.To {
+		return strconv.Itoa(r.From)
+	}
+	return strconv.Itoa(r.From) + "-" + strconv.Itoa(r.To)
+}
+
+// LoadReport is a struct that represents the outcome of a load
+type LoadReport struct {
+	// Records is the number of records read
+	Records int
+	// Accepted is the number of records loaded
+	Accepted int
+	// Rejected is the number of records left out of the load
+	Rejected int
+	// Duplicates is the list of ids found in more than one record, only the first record of each is loaded
+	Duplicates []int
+	// Gaps is the list of ids missing between 1 and MaxID
+	Gaps []IDRange
+	// MaxID is the greatest id loaded
+	MaxID int
+	// SchemaVersion is the schema version of the file
+	SchemaVersion int
+	// KeyID is the id of the key the file was encrypted with, empty when it was not encrypted
+	KeyID string
+}
+
 // VehicleLoader is an interface that represents the loader for vehicles
 type VehicleLoader interface {
 	// Load is a method that loads the vehicles
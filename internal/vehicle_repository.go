@@ -5,15 +5,47 @@ type VehicleRepository interface {
 	// FindAll is a method that returns a map of all vehicles
 	FindAll() (v map[int]Vehicle, err error)
 
-	// Create is a method that creates a vehicle
-	Create(v *Vehicle) (err error)
+	// FindByStatus is a method that returns a map of the vehicles in the statuses of the filter, every vehicle when it is empty
+	FindByStatus(f StatusFilter) (v map[int]Vehicle, err error)
 
-	// GetByColorAndYear is a method that returns a map of vehicles by color and year
-	GetByColorAndYear(color string, year int) (v map[int]Vehicle, err error)
+	// FindAfter is a method that returns up to limit vehicles with an id greater than afterID, ordered by id
+	FindAfter(afterID, limit int) (v []Vehicle, err error)
 
-	// GetByDimensions is a method that returns a map of vehicles by dimensions
-	GetByDimensions(minLength, maxLength, minWidth, maxWidth float64) (v map[int]Vehicle, err error)
+	// Replace is a method that swaps every vehicle for the ones of the reloaded file at once, the reset event is saved to
+	// the outbox with them when any vehicle changes
+	Replace(v map[int]Vehicle) (err error)
+
+	// Restore is a method that swaps every vehicle for the given ones at once and saves them, the reset event is saved to
+	// the outbox with them
+	Restore(v map[int]Vehicle) (err error)
+
+	// FindByID is a method that returns a vehicle by id
+	FindByID(id int) (v Vehicle, err error)
+
+	// Create is a method that creates a vehicle, the events of raise are saved to the outbox with it; raise may be nil
+	Create(v *Vehicle, raise EventsFunc) (err error)
+
+	// Update is a method that changes a vehicle with update and saves it, nothing changes when update fails.
+	// The events of raise are saved to the outbox with the change; raise may be nil
+	Update(id int, update func(v *Vehicle) (err error), raise EventsFunc) (v Vehicle, err error)
+
+	// Delete is a method that deletes a vehicle and returns it, the events of raise are saved to the outbox with it; raise may be nil
+	Delete(id int, raise EventsFunc) (v Vehicle, err error)
+
+	// GetByColorAndYear is a method that returns a map of vehicles by color and year in the statuses of the filter,
+	// it fails with ErrVehicleNotFound when there is none
+	GetByColorAndYear(color string, year int, status StatusFilter) (v map[int]Vehicle, err error)
+
+	// GetByDimensions is a method that returns a map of vehicles by dimensions in the statuses of the filter,
+	// it fails with ErrVehicleNotFound when there is none
+	GetByDimensions(f DimensionsFilter, status StatusFilter) (v map[int]Vehicle, err error)
 
 	// GetAverageSpeedByBrand is a method that returns the average speed of a vehicle
 	GetAverageSpeedByBrand(brand string) (averageSpeed float64, err error)
+
+	// Search is a method that returns up to limit vehicles matching a typo-tolerant query, most relevant first
+	Search(query string, limit int) (m []VehicleMatch, err error)
+
+	// Suggest is a method that returns up to limit values of a field starting with a prefix
+	Suggest(field, prefix string, limit int) (s []Suggestion, err error)
 }
@@ -0,0 +1,29 @@
+package internal
+
+import "errors"
+
+var (
+	// ErrFieldNotSearchable is an error that represents a field that can not be searched
+	ErrFieldNotSearchable = errors.New("field not searchable")
+)
+
+// SearchableFields is the list of fields covered by search and suggestions
+var SearchableFields = []string{FieldBrand, FieldModel, FieldRegistration, FieldColor}
+
+// VehicleMatch is a struct that represents a vehicle matching a search
+type VehicleMatch struct {
+	// Vehicle is the vehicle that matched
+	Vehicle
+	// Score is the relevance of the match, from 0 to 1
+	Score float64
+	// Fields is the list of fields that matched the search
+	Fields []string
+}
+
+// Suggestion is a struct that represents a value completing a prefix
+type Suggestion struct {
+	// Value is the suggested value
+	Value string
+	// Count is the number of vehicles with the value
+	Count int
+}
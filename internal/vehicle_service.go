@@ -12,15 +12,41 @@ type VehicleService interface {
 	// FindAll is a method that returns a map of all vehicles
 	FindAll() (v map[int]Vehicle, err error)
 
-	// Create is a method that creates a vehicle
+	// FindByStatus is a method that returns a map of the vehicles in the statuses of the filter, every vehicle when it is empty
+	FindByStatus(f StatusFilter) (v map[int]Vehicle, err error)
+
+	// FindAfter is a method that returns up to limit vehicles with an id greater than afterID, ordered by id
+	FindAfter(afterID, limit int) (v []Vehicle, err error)
+
+	// FindByID is a method that returns a vehicle by id
+	FindByID(id int) (v Vehicle, err error)
+
+	// Create is a method that creates a vehicle, it fails with ErrVehicleInvalid when the attributes are inconsistent
 	Create(v *Vehicle) (err error)
 
-	// GetByColorAndYear is a method that returns a map of vehicles by color and year
-	GetByColorAndYear(color string, year int) (v map[int]Vehicle, err error)
+	// Update is a method that replaces the attributes of a vehicle, its status and history are kept
+	Update(id int, attrs VehicleAttributes) (v Vehicle, err error)
+
+	// Delete is a method that deletes a vehicle
+	Delete(id int) (err error)
 
-	// GetByDimensions is a method that returns a map of vehicles by dimensions
-	GetByDimensions(minLength, maxLength, minWidth, maxWidth float64) (v map[int]Vehicle, err error)
+	// Transition is a method that changes the status of a vehicle, it fails with ErrVehicleTransitionInvalid when the change is not allowed
+	Transition(id int, to VehicleStatus, reason string) (v Vehicle, t StatusTransition, err error)
+
+	// GetByColorAndYear is a method that returns a map of vehicles by color and year in the statuses of the filter,
+	// it fails with ErrVehicleNotFound when there is none
+	GetByColorAndYear(color string, year int, status StatusFilter) (v map[int]Vehicle, err error)
+
+	// GetByDimensions is a method that returns a map of vehicles by dimensions in the statuses of the filter,
+	// it fails with ErrVehicleNotFound when there is none
+	GetByDimensions(f DimensionsFilter, status StatusFilter) (v map[int]Vehicle, err error)
 
 	// GetAverageSpeedByBrand is a method that returns the average speed of a vehicle
 	GetAverageSpeedByBrand(brand string) (averageSpeed float64, err error)
+
+	// Search is a method that returns up to limit vehicles matching a typo-tolerant query, most relevant first
+	Search(query string, limit int) (m []VehicleMatch, err error)
+
+	// Suggest is a method that returns up to limit values of a field starting with a prefix
+	Suggest(field, prefix string, limit int) (s []Suggestion, err error)
 }
@@ -0,0 +1,142 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrVehicleStatusInvalid is an error that represents an unknown status
+	ErrVehicleStatusInvalid = errors.New("vehicle status invalid")
+	// ErrVehicleTransitionInvalid is an error that represents a change of status that is not allowed
+	ErrVehicleTransitionInvalid = errors.New("vehicle transition invalid")
+	// ErrVehicleTransitionReason is an error that represents a change of status missing the reason it requires, it is
+	// always wrapped with ErrVehicleTransitionInvalid
+	ErrVehicleTransitionReason = errors.New("a reason is required")
+)
+
+// VehicleStatus is the lifecycle status of a vehicle
+type VehicleStatus string
+
+const (
+	// VehicleStatusInService is the status of a vehicle available to be used
+	VehicleStatusInService VehicleStatus = "in_service"
+	// VehicleStatusInMaintenance is the status of a vehicle being repaired or serviced
+	VehicleStatusInMaintenance VehicleStatus = "in_maintenance"
+	// VehicleStatusReserved is the status of a vehicle held for someone
+	VehicleStatusReserved VehicleStatus = "reserved"
+	// VehicleStatusDecommissioned is the status of a vehicle out of the fleet for good
+	VehicleStatusDecommissioned VehicleStatus = "decommissioned"
+)
+
+// VehicleStatuses is the list of statuses
+var VehicleStatuses = []VehicleStatus{VehicleStatusInService, VehicleStatusInMaintenance, VehicleStatusReserved, VehicleStatusDecommissioned}
+
+// vehicleTransitions is the set of statuses each status can change to, decommissioned is final
+var vehicleTransitions = map[VehicleStatus][]VehicleStatus{
+	VehicleStatusInService:      {VehicleStatusInMaintenance, VehicleStatusReserved, VehicleStatusDecommissioned},
+	VehicleStatusInMaintenance:  {VehicleStatusInService, VehicleStatusDecommissioned},
+	VehicleStatusReserved:       {VehicleStatusInService},
+	VehicleStatusDecommissioned: {},
+}
+
+// ParseVehicleStatus is a function that returns the status with the given name
+func ParseVehicleStatus(s string) (st VehicleStatus, err error) {
+	st = VehicleStatus(strings.ToLower(strings.TrimSpace(s)))
+	if !st.Valid() {
+		err = fmt.Errorf("%w: %q, expected one of %v", ErrVehicleStatusInvalid, s, VehicleStatuses)
+	}
+	return
+}
+
+// Valid is a method that reports whether the status is known
+func (s VehicleStatus) Valid() bool {
+	_, ok := vehicleTransitions[s]
+	return ok
+}
+
+// Next is a method that returns the statuses the status can change to
+func (s VehicleStatus) Next() []VehicleStatus {
+	return vehicleTransitions[s]
+}
+
+// CanTransition is a method that reports whether the status can change to another one
+func (s VehicleStatus) CanTransition(to VehicleStatus) bool {
+	for _, next := range vehicleTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusTransition is a struct that represents a change of status of a vehicle
+type StatusTransition struct {
+	// From is the status before the change
+	From VehicleStatus
+	// To is the status after the change
+	To VehicleStatus
+	// At is the time of the change
+	At time.Time
+	// Reason is why the status changed
+	Reason string
+}
+
+// StatusFilter is a set of statuses a vehicle must be in, an empty filter matches every status
+type StatusFilter []VehicleStatus
+
+// String is a method that returns the statuses of the filter separated by commas
+func (f StatusFilter) String() string {
+	names := make([]string, 0, len(f))
+	for _, value := range f {
+		names = append(names, string(value))
+	}
+	return strings.Join(names, ",")
+}
+
+// Contains is a method that reports whether a status is in the filter
+func (f StatusFilter) Contains(s VehicleStatus) bool {
+	if len(f) == 0 {
+		return true
+	}
+	for _, value := range f {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition is a method that changes the status of a vehicle and records the change in its history.
+// Besides the allowed transitions, a vehicle can only be decommissioned with a reason and changes can not go back in time
+func (v *Vehicle) Transition(to VehicleStatus, at time.Time, reason string) (t StatusTransition, err error) {
+	if !to.Valid() {
+		err = fmt.Errorf("%w: %q", ErrVehicleStatusInvalid, to)
+		return
+	}
+
+	// guards
+	switch {
+	case v.Status == to:
+		err = fmt.Errorf("%w: vehicle is already %s", ErrVehicleTransitionInvalid, to)
+	case !v.Status.CanTransition(to):
+		err = fmt.Errorf("%w: from %s to %s, allowed are %v", ErrVehicleTransitionInvalid, v.Status, to, v.Status.Next())
+	case to == VehicleStatusDecommissioned && strings.TrimSpace(reason) == "":
+		err = fmt.Errorf("%w: %w to decommission a vehicle", ErrVehicleTransitionInvalid, ErrVehicleTransitionReason)
+	case len(v.Transitions) > 0 && at.Before(v.Transitions[len(v.Transitions)-1].At):
+		err = fmt.Errorf("%w: %s is before the last transition", ErrVehicleTransitionInvalid, at.Format(time.RFC3339))
+	}
+	if err != nil {
+		return
+	}
+
+	// - the history may be shared with copies of the vehicle
+	t = StatusTransition{From: v.Status, To: to, At: at, Reason: strings.TrimSpace(reason)}
+	history := make([]StatusTransition, len(v.Transitions), len(v.Transitions)+1)
+	copy(history, v.Transitions)
+	v.Transitions = append(history, t)
+	v.Status = to
+	return
+}
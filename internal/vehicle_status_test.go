@@ -0,0 +1,98 @@
+package internal
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVehicle_Transition(t *testing.T) {
+	at := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	last := StatusTransition{From: VehicleStatusReserved, To: VehicleStatusInService, At: at.Add(-time.Hour)}
+
+	tests := []struct {
+		name    string
+		from    VehicleStatus
+		to      VehicleStatus
+		at      time.Time
+		reason  string
+		wantErr error
+	}{
+		// allowed
+		{name: "in service to maintenance", from: VehicleStatusInService, to: VehicleStatusInMaintenance, at: at, reason: "  brakes  "},
+		{name: "in service to reserved", from: VehicleStatusInService, to: VehicleStatusReserved, at: at},
+		{name: "reserved to in service", from: VehicleStatusReserved, to: VehicleStatusInService, at: at},
+		{name: "maintenance to in service", from: VehicleStatusInMaintenance, to: VehicleStatusInService, at: at},
+		{name: "maintenance to decommissioned", from: VehicleStatusInMaintenance, to: VehicleStatusDecommissioned, at: at, reason: "written off"},
+		{name: "at the time of the last transition", from: VehicleStatusInService, to: VehicleStatusReserved, at: last.At},
+		// forbidden
+		{name: "reserved to maintenance", from: VehicleStatusReserved, to: VehicleStatusInMaintenance, at: at, wantErr: ErrVehicleTransitionInvalid},
+		{name: "reserved to decommissioned", from: VehicleStatusReserved, to: VehicleStatusDecommissioned, at: at, reason: "sold", wantErr: ErrVehicleTransitionInvalid},
+		{name: "decommissioned is final", from: VehicleStatusDecommissioned, to: VehicleStatusInService, at: at, wantErr: ErrVehicleTransitionInvalid},
+		{name: "same status", from: VehicleStatusInService, to: VehicleStatusInService, at: at, wantErr: ErrVehicleTransitionInvalid},
+		{name: "decommissioned without a reason", from: VehicleStatusInService, to: VehicleStatusDecommissioned, at: at, reason: "  ", wantErr: ErrVehicleTransitionReason},
+		{name: "before the last transition", from: VehicleStatusInService, to: VehicleStatusReserved, at: last.At.Add(-time.Second), wantErr: ErrVehicleTransitionInvalid},
+		{name: "unknown status", from: VehicleStatusInService, to: "parked", at: at, wantErr: ErrVehicleStatusInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := []StatusTransition{last}
+			v := Vehicle{Id: 1, Status: tt.from, Transitions: history[:1:1]}
+
+			got, err := v.Transition(tt.to, tt.at, tt.reason)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if v.Status != tt.from || len(v.Transitions) != 1 {
+					t.Errorf("got status %s and %d transitions after a failed transition, want them unchanged", v.Status, len(v.Transitions))
+				}
+				return
+			}
+
+			// - the change is recorded at the end of the history, with the reason trimmed
+			want := StatusTransition{From: tt.from, To: tt.to, At: tt.at, Reason: strings.TrimSpace(tt.reason)}
+			if got != want {
+				t.Errorf("got transition %+v, want %+v", got, want)
+			}
+			if v.Status != tt.to || !reflect.DeepEqual(v.Transitions, []StatusTransition{last, got}) {
+				t.Errorf("got status %s and history %+v, want %s after %+v", v.Status, v.Transitions, tt.to, last)
+			}
+		})
+	}
+}
+
+func TestVehicle_Transition_SharedHistory(t *testing.T) {
+	at := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	v := Vehicle{Id: 1, Status: VehicleStatusInService, Transitions: make([]StatusTransition, 0, 4)}
+	c := v
+
+	if _, err := v.Transition(VehicleStatusReserved, at, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Transition(VehicleStatusInMaintenance, at, "tyres"); err != nil {
+		t.Fatal(err)
+	}
+	// - a copy of the vehicle keeps its own history although the slice had room to grow in place
+	if v.Transitions[0].To != VehicleStatusReserved || c.Transitions[0].To != VehicleStatusInMaintenance {
+		t.Errorf("got histories %+v and %+v, want each with its own transition", v.Transitions, c.Transitions)
+	}
+}
+
+func TestStatusFilter_Contains(t *testing.T) {
+	f := StatusFilter{VehicleStatusReserved, VehicleStatusInMaintenance}
+	for _, st := range VehicleStatuses {
+		want := st == VehicleStatusReserved || st == VehicleStatusInMaintenance
+		if got := f.Contains(st); got != want {
+			t.Errorf("got %t for %s, want %t", got, st, want)
+		}
+		if !(StatusFilter{}).Contains(st) {
+			t.Errorf("got an empty filter not matching %s, want every status matched", st)
+		}
+	}
+	if got := f.String(); got != "reserved,in_maintenance" {
+		t.Errorf("got %q, want reserved,in_maintenance", got)
+	}
+}
@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+var (
+	// ErrWebhookInvalid is an error that represents a webhook with invalid attributes
+	ErrWebhookInvalid = errors.New("webhook invalid")
+	// ErrWebhookNotFound is an error that represents a webhook not found
+	ErrWebhookNotFound = errors.New("webhook not found")
+	// ErrDeadLetterNotFound is an error that represents a dead letter not found
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+)
+
+// minWebhookSecret is the least length of the secret deliveries are signed with
+const minWebhookSecret = 16
+
+// Webhook is a struct that represents a subscriber the events are delivered to
+type Webhook struct {
+	// ID is the unique identifier of the webhook
+	ID int
+	// URL is the URL events are posted to
+	URL string
+	// Secret is the secret deliveries are signed with
+	Secret string
+	// Events is the list of the types of the events delivered, empty for every type
+	Events []EventType
+	// CreatedAt is when the webhook was registered
+	CreatedAt time.Time
+}
+
+// Validate is a method that checks the attributes of a webhook are consistent
+func (w Webhook) Validate() (err error) {
+	u, uErr := url.Parse(w.URL)
+	switch {
+	case uErr != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "":
+		err = fmt.Errorf("%w: url must be an absolute http or https url", ErrWebhookInvalid)
+	case u.User != nil:
+		err = fmt.Errorf("%w: url must not hold credentials", ErrWebhookInvalid)
+	case len(w.Secret) < minWebhookSecret:
+		err = fmt.Errorf("%w: secret must be at least %d characters", ErrWebhookInvalid, minWebhookSecret)
+	}
+	if err != nil {
+		return
+	}
+	for _, t := range w.Events {
+		if !t.Valid() {
+			err = fmt.Errorf("%w: event type %q is unknown", ErrWebhookInvalid, t)
+			return
+		}
+	}
+	return
+}
+
+// Subscribes is a method that reports whether the events of a type are delivered to the webhook
+func (w Webhook) Subscribes(t EventType) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, value := range w.Events {
+		if value == t {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is a struct that represents an attempt to deliver an event to a webhook
+type WebhookDelivery struct {
+	// ID is the unique identifier of the attempt
+	ID int
+	// WebhookID is the id of the webhook
+	WebhookID int
+	// EventID is the id of the event
+	EventID string
+	// EventType is the type of the event
+	EventType EventType
+	// Attempt is the number of the attempt, from 1
+	Attempt int
+	// StatusCode is the status the webhook responded with, zero when it did not respond
+	StatusCode int
+	// Error is why the attempt failed, empty when it succeeded
+	Error string
+	// Duration is the time the attempt took
+	Duration time.Duration
+	// At is when the attempt started
+	At time.Time
+}
+
+// Succeeded is a method that reports whether the event was delivered
+func (d WebhookDelivery) Succeeded() bool {
+	return d.Error == ""
+}
+
+// WebhookDeadLetter is a struct that represents an event that could not be delivered to a webhook after every attempt
+type WebhookDeadLetter struct {
+	// ID is the unique identifier of the dead letter
+	ID int
+	// WebhookID is the id of the webhook
+	WebhookID int
+	// EventID is the id of the event
+	EventID string
+	// EventType is the type of the event
+	EventType EventType
+	// Payload is the body of the event as it was posted
+	Payload []byte
+	// Attempts is the number of attempts made
+	Attempts int
+	// LastError is why the last attempt failed
+	LastError string
+	// FailedAt is when the last attempt failed
+	FailedAt time.Time
+}
+
+// WebhookData is a struct that represents the webhooks, their delivery logs and dead letters persisted together
+type WebhookData struct {
+	// Webhooks is the set of webhooks by id
+	Webhooks map[int]Webhook
+	// Deliveries is the list of the latest attempts, oldest first
+	Deliveries []WebhookDelivery
+	// DeadLetters is the set of dead letters by id
+	DeadLetters map[int]WebhookDeadLetter
+}
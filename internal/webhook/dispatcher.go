@@ -0,0 +1,281 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/event"
+)
+
+// userAgent is the user agent of the deliveries
+const userAgent = "vehicles-webhooks/1"
+
+// Config is a struct that represents the configuration for Dispatcher
+type Config struct {
+	// Client is the client of the deliveries, by default a client with a timeout of 10 seconds that only connects to the
+	// addresses the target policy allows
+	Client *http.Client
+	// AllowedHosts is the list of the hosts events are delivered to whatever their address, such as receivers on the
+	// private network; other hosts must resolve to public addresses
+	AllowedHosts []string
+	// Workers is the number of deliveries made at once, 4 by default
+	Workers int
+	// QueueSize is the number of deliveries waiting for a worker, 1024 by default; a delivery past it is dead-lettered
+	QueueSize int
+	// MaxAttempts is the number of attempts of a delivery before it is dead-lettered, 6 by default
+	MaxAttempts int
+	// Backoff is the wait before the second attempt, doubled before each next one; 1 second by default
+	Backoff time.Duration
+	// MaxBackoff is the longest wait between attempts, 5 minutes by default
+	MaxBackoff time.Duration
+}
+
+// NewDispatcher is a function that returns a new instance of Dispatcher, it delivers once Start is called
+func NewDispatcher(rp internal.WebhookRepository, cfg *Config) *Dispatcher {
+	// default config
+	defaultCfg := &Config{
+		Workers:     4,
+		QueueSize:   1024,
+		MaxAttempts: 6,
+		Backoff:     time.Second,
+		MaxBackoff:  5 * time.Minute,
+	}
+	if cfg != nil {
+		defaultCfg.Client = cfg.Client
+		defaultCfg.AllowedHosts = cfg.AllowedHosts
+		if cfg.Workers > 0 {
+			defaultCfg.Workers = cfg.Workers
+		}
+		if cfg.QueueSize > 0 {
+			defaultCfg.QueueSize = cfg.QueueSize
+		}
+		if cfg.MaxAttempts > 0 {
+			defaultCfg.MaxAttempts = cfg.MaxAttempts
+		}
+		if cfg.Backoff > 0 {
+			defaultCfg.Backoff = cfg.Backoff
+		}
+		if cfg.MaxBackoff > 0 {
+			defaultCfg.MaxBackoff = cfg.MaxBackoff
+		}
+	}
+	tp := NewTargetPolicy(defaultCfg.AllowedHosts)
+	if defaultCfg.Client == nil {
+		defaultCfg.Client = tp.Client(10 * time.Second)
+	}
+	return &Dispatcher{
+		rp:          rp,
+		tp:          tp,
+		cl:          defaultCfg.Client,
+		workers:     defaultCfg.Workers,
+		queue:       make(chan job, defaultCfg.QueueSize),
+		maxAttempts: defaultCfg.MaxAttempts,
+		backoff:     defaultCfg.Backoff,
+		maxBackoff:  defaultCfg.MaxBackoff,
+	}
+}
+
+// Dispatcher is a struct that delivers events to the webhooks subscribed to them, implementing the EventPublisher and
+// WebhookDispatcher interfaces. Deliveries are posted by a pool of workers and retried with exponential backoff; each attempt
+// is logged, and a delivery failing every attempt is kept as a dead letter. Pending deliveries are held in memory, so
+// they are lost on restart, and retries may deliver the events of a vehicle out of order
+type Dispatcher struct {
+	// rp is the repository of the webhooks
+	rp internal.WebhookRepository
+	// tp is the policy of the hosts events are delivered to
+	tp *TargetPolicy
+	// cl is the client of the deliveries
+	cl *http.Client
+	// workers is the number of deliveries made at once
+	workers int
+	// queue is the deliveries waiting for a worker
+	queue chan job
+	// maxAttempts is the number of attempts of a delivery
+	maxAttempts int
+	// backoff is the wait before the second attempt
+	backoff time.Duration
+	// maxBackoff is the longest wait between attempts
+	maxBackoff time.Duration
+}
+
+// job is a struct that represents an attempt to deliver an event to a webhook
+type job struct {
+	// webhookID is the id of the webhook
+	webhookID int
+	// eventID is the id of the event
+	eventID string
+	// eventType is the type of the event
+	eventType internal.EventType
+	// payload is the body posted
+	payload []byte
+	// attempt is the number of the attempt, from 1
+	attempt int
+}
+
+// Start is a method that starts the workers, they stop once the context is done
+func (d *Dispatcher) Start(ctx context.Context) {
+	for i := 0; i < d.workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case j := <-d.queue:
+					d.deliver(ctx, j)
+				}
+			}
+		}()
+	}
+}
+
+// Publish is a method that queues an event for delivery to every webhook subscribed to its type
+func (d *Dispatcher) Publish(e internal.Event) {
+	w, err := d.rp.FindAll()
+	if err != nil {
+		log.Printf("error publishing event %s to webhooks: %v", e.ID, err)
+		return
+	}
+	payload, err := event.Marshal(e)
+	if err != nil {
+		log.Printf("error publishing event %s to webhooks: %v", e.ID, err)
+		return
+	}
+	for _, value := range w {
+		if value.Subscribes(e.Type) {
+			d.enqueue(job{webhookID: value.ID, eventID: e.ID, eventType: e.Type, payload: payload, attempt: 1})
+		}
+	}
+}
+
+// CheckTarget is a method that returns an error wrapping ErrWebhookInvalid when events may not be delivered to a URL
+func (d *Dispatcher) CheckTarget(url string) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = d.tp.Check(ctx, url)
+	return
+}
+
+// Redeliver is a method that queues a dead letter for delivery again, with every attempt
+func (d *Dispatcher) Redeliver(w internal.Webhook, dl internal.WebhookDeadLetter) (err error) {
+	if dl.WebhookID != w.ID {
+		err = fmt.Errorf("%w: %d", internal.ErrDeadLetterNotFound, dl.ID)
+		return
+	}
+	d.enqueue(job{webhookID: w.ID, eventID: dl.EventID, eventType: dl.EventType, payload: dl.Payload, attempt: 1})
+	return
+}
+
+// enqueue is a method that queues a delivery, it is dead-lettered when the queue is full so publishers never block
+func (d *Dispatcher) enqueue(j job) {
+	select {
+	case d.queue <- j:
+	default:
+		d.deadLetter(j, "delivery queue full")
+	}
+}
+
+// deliver is a method that makes an attempt, then schedules the next one or dead-letters the delivery when it fails
+func (d *Dispatcher) deliver(ctx context.Context, j job) {
+	// - the webhook may have been deleted since the event was published
+	w, err := d.rp.FindByID(j.webhookID)
+	if err != nil {
+		if !errors.Is(err, internal.ErrWebhookNotFound) {
+			log.Printf("error delivering event %s to webhook %d: %v", j.eventID, j.webhookID, err)
+		}
+		return
+	}
+
+	at := time.Now().UTC()
+	status, err := d.post(ctx, w, j, at)
+	dv := internal.WebhookDelivery{
+		WebhookID:  w.ID,
+		EventID:    j.eventID,
+		EventType:  j.eventType,
+		Attempt:    j.attempt,
+		StatusCode: status,
+		Duration:   time.Since(at),
+		At:         at,
+	}
+	if err != nil {
+		dv.Error = err.Error()
+	}
+	if lErr := d.rp.AddDelivery(&dv); lErr != nil {
+		log.Printf("error logging delivery of event %s to webhook %d: %v", j.eventID, w.ID, lErr)
+	}
+	if err == nil {
+		return
+	}
+
+	// - retry
+	if j.attempt >= d.maxAttempts {
+		d.deadLetter(j, dv.Error)
+		return
+	}
+	next := j
+	next.attempt++
+	time.AfterFunc(d.wait(j.attempt), func() { d.enqueue(next) })
+}
+
+// post is a method that posts the payload of a delivery, it fails unless the webhook responds with a 2xx status
+func (d *Dispatcher) post(ctx context.Context, w internal.Webhook, j job, at time.Time) (status int, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(j.payload))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderEventID, j.eventID)
+	req.Header.Set(HeaderEventType, string(j.eventType))
+	req.Header.Set(HeaderAttempt, strconv.Itoa(j.attempt))
+	req.Header.Set(HeaderSignature, Sign(w.Secret, at, j.payload))
+
+	res, err := d.cl.Do(req)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	status = res.StatusCode
+	if status < 200 || status > 299 {
+		err = fmt.Errorf("webhook responded %s", res.Status)
+	}
+	return
+}
+
+// wait is a method that returns the wait after a failed attempt: the backoff doubled for each previous attempt, capped,
+// of which a random half is waited so the retries of many deliveries do not fire at once
+func (d *Dispatcher) wait(attempt int) time.Duration {
+	w := d.backoff
+	for i := 1; i < attempt && w < d.maxBackoff; i++ {
+		w *= 2
+	}
+	w = min(w, d.maxBackoff)
+	return w/2 + time.Duration(rand.Int63n(int64(w/2)+1))
+}
+
+// deadLetter is a method that keeps a delivery that failed every attempt
+func (d *Dispatcher) deadLetter(j job, reason string) {
+	dl := internal.WebhookDeadLetter{
+		WebhookID: j.webhookID,
+		EventID:   j.eventID,
+		EventType: j.eventType,
+		Payload:   j.payload,
+		Attempts:  j.attempt,
+		LastError: reason,
+		FailedAt:  time.Now().UTC(),
+	}
+	if err := d.rp.AddDeadLetter(&dl); err != nil {
+		log.Printf("error dead-lettering event %s to webhook %d: %v", j.eventID, j.webhookID, err)
+		return
+	}
+	log.Printf("event %s dead-lettered for webhook %d after %d attempts: %s", j.eventID, j.webhookID, j.attempt, reason)
+}
@@ -0,0 +1,312 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+	"github.com/rhinosc/code-review-1/internal/event"
+	"github.com/rhinosc/code-review-1/internal/loader"
+	"github.com/rhinosc/code-review-1/internal/repository"
+)
+
+// testSecret is the secret the deliveries of the tests are signed with
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// request is a struct that represents a delivery received by a test receiver
+type request struct {
+	// header is the header of the request
+	header http.Header
+	// body is the body of the request
+	body []byte
+	// at is when the request was received
+	at time.Time
+}
+
+// receiver is a struct that records the deliveries it receives, responding with the status of status
+type receiver struct {
+	// mu guards requests
+	mu sync.Mutex
+	// requests is the list of the deliveries received
+	requests []request
+	// status is a function that returns the status of the response to the n-th delivery, from 1
+	status func(n int) int
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	rc.requests = append(rc.requests, request{header: r.Header.Clone(), body: body, at: time.Now()})
+	n := len(rc.requests)
+	rc.mu.Unlock()
+	w.WriteHeader(rc.status(n))
+}
+
+// received is a method that returns the deliveries received so far
+func (rc *receiver) received() []request {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return append([]request(nil), rc.requests...)
+}
+
+// newTestDispatcher is a function that returns a started dispatcher with a webhook subscribed to every event and
+// posting to a receiver, and the repository of the webhook
+func newTestDispatcher(t *testing.T, rc *receiver, cfg *Config) (d *Dispatcher, rp *repository.WebhookMap, w internal.Webhook) {
+	t.Helper()
+	srv := httptest.NewServer(rc)
+	t.Cleanup(srv.Close)
+
+	rp = repository.NewWebhookMap(loader.NewWebhookJSONFile(&loader.ConfigWebhookJSONFile{Path: filepath.Join(t.TempDir(), "webhooks.json")}), internal.WebhookData{})
+	w = internal.Webhook{URL: srv.URL, Secret: testSecret, CreatedAt: time.Now().UTC()}
+	if err := rp.Create(&w); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.AllowedHosts = []string{"127.0.0.1"}
+	d = NewDispatcher(rp, cfg)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	d.Start(ctx)
+	return
+}
+
+// eventually is a function that waits until cond holds, failing the test after a while
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDispatcher_Publish_Signature(t *testing.T) {
+	rc := &receiver{status: func(int) int { return http.StatusNoContent }}
+	d, rp, w := newTestDispatcher(t, rc, &Config{})
+
+	e := internal.NewEvent(internal.EventVehicleCreated, internal.Vehicle{Id: 7, VehicleAttributes: internal.VehicleAttributes{Brand: "Ford"}})
+	d.Publish(e)
+	eventually(t, "the delivery", func() bool { return len(rc.received()) == 1 })
+
+	r := rc.received()[0]
+	want, err := event.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(r.body) != string(want) {
+		t.Errorf("got body %s, want %s", r.body, want)
+	}
+	if err := Verify(testSecret, r.header.Get(HeaderSignature), r.body, time.Now(), 5*time.Minute); err != nil {
+		t.Errorf("got signature %q not verified: %v", r.header.Get(HeaderSignature), err)
+	}
+	if err := Verify("another secret of the same size", r.header.Get(HeaderSignature), r.body, time.Now(), 5*time.Minute); err == nil {
+		t.Error("got the signature verified with another secret")
+	}
+	for header, value := range map[string]string{HeaderEventID: e.ID, HeaderEventType: string(e.Type), HeaderAttempt: "1", "Content-Type": "application/json"} {
+		if got := r.header.Get(header); got != value {
+			t.Errorf("got header %s %q, want %q", header, got, value)
+		}
+	}
+
+	eventually(t, "the delivery logged", func() bool {
+		dv, _ := rp.FindDeliveries(w.ID)
+		return len(dv) == 1
+	})
+	dv, _ := rp.FindDeliveries(w.ID)
+	if !dv[0].Succeeded() || dv[0].StatusCode != http.StatusNoContent {
+		t.Errorf("got delivery %+v, want it succeeded with status 204", dv[0])
+	}
+}
+
+func TestDispatcher_Publish_Unsubscribed(t *testing.T) {
+	rc := &receiver{status: func(int) int { return http.StatusNoContent }}
+	d, rp, _ := newTestDispatcher(t, rc, &Config{})
+	other := internal.Webhook{URL: "http://127.0.0.1:1/", Secret: testSecret, Events: []internal.EventType{internal.EventVehicleDeleted}}
+	if err := rp.Create(&other); err != nil {
+		t.Fatal(err)
+	}
+
+	d.Publish(internal.NewEvent(internal.EventVehicleCreated, internal.Vehicle{Id: 7}))
+	eventually(t, "the delivery", func() bool { return len(rc.received()) == 1 })
+	time.Sleep(50 * time.Millisecond)
+	if dv, _ := rp.FindDeliveries(other.ID); len(dv) != 0 {
+		t.Errorf("got %d deliveries to a webhook not subscribed to the event, want none", len(dv))
+	}
+}
+
+func TestDispatcher_Retry_DeadLetter(t *testing.T) {
+	rc := &receiver{status: func(int) int { return http.StatusInternalServerError }}
+	backoff := 40 * time.Millisecond
+	d, rp, w := newTestDispatcher(t, rc, &Config{MaxAttempts: 3, Backoff: backoff})
+
+	e := internal.NewEvent(internal.EventVehicleDeleted, internal.Vehicle{Id: 7})
+	d.Publish(e)
+	eventually(t, "the dead letter", func() bool {
+		dl, _ := rp.FindDeadLetters(w.ID)
+		return len(dl) == 1
+	})
+
+	// - every attempt is made, numbered, for the same event
+	r := rc.received()
+	if len(r) != 3 {
+		t.Fatalf("got %d attempts, want 3", len(r))
+	}
+	for i, value := range r {
+		if got := value.header.Get(HeaderAttempt); got != strconv.Itoa(i+1) {
+			t.Errorf("got attempt header %q on attempt %d", got, i+1)
+		}
+		if got := value.header.Get(HeaderEventID); got != e.ID {
+			t.Errorf("got event id %q on attempt %d, want %q", got, i+1, e.ID)
+		}
+	}
+	// - the wait doubles, of which at least a half is waited
+	for i := 1; i < len(r); i++ {
+		least := (backoff << (i - 1)) / 2
+		if gap := r[i].at.Sub(r[i-1].at); gap < least {
+			t.Errorf("got %s between attempts %d and %d, want at least %s", gap, i, i+1, least)
+		}
+	}
+
+	dl, _ := rp.FindDeadLetters(w.ID)
+	if dl[0].EventID != e.ID || dl[0].Attempts != 3 || !strings.Contains(dl[0].LastError, "500") || string(dl[0].Payload) != string(r[0].body) {
+		t.Errorf("got dead letter %+v, want the event after 3 attempts failing with 500", dl[0])
+	}
+	dv, _ := rp.FindDeliveries(w.ID)
+	if len(dv) != 3 {
+		t.Fatalf("got %d deliveries logged, want 3", len(dv))
+	}
+	for _, value := range dv {
+		if value.Succeeded() || value.StatusCode != http.StatusInternalServerError {
+			t.Errorf("got delivery %+v, want it failed with status 500", value)
+		}
+	}
+	// - no attempt follows the dead letter
+	time.Sleep(4 * backoff)
+	if n := len(rc.received()); n != 3 {
+		t.Errorf("got %d attempts after the dead letter, want 3", n)
+	}
+}
+
+func TestDispatcher_Retry_Recovers(t *testing.T) {
+	rc := &receiver{status: func(n int) int {
+		if n < 3 {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusOK
+	}}
+	d, rp, w := newTestDispatcher(t, rc, &Config{MaxAttempts: 5, Backoff: 10 * time.Millisecond})
+
+	d.Publish(internal.NewEvent(internal.EventVehicleUpdated, internal.Vehicle{Id: 7}))
+	eventually(t, "the third attempt logged", func() bool {
+		dv, _ := rp.FindDeliveries(w.ID)
+		return len(dv) == 3
+	})
+	time.Sleep(50 * time.Millisecond)
+	if n := len(rc.received()); n != 3 {
+		t.Errorf("got %d attempts, want 3", n)
+	}
+	if dl, _ := rp.FindDeadLetters(w.ID); len(dl) != 0 {
+		t.Errorf("got dead letters %+v for a delivery that succeeded", dl)
+	}
+}
+
+func TestDispatcher_Redeliver(t *testing.T) {
+	fail := true
+	var mu sync.Mutex
+	rc := &receiver{status: func(int) int {
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			return http.StatusBadGateway
+		}
+		return http.StatusOK
+	}}
+	d, rp, w := newTestDispatcher(t, rc, &Config{MaxAttempts: 1})
+
+	d.Publish(internal.NewEvent(internal.EventVehicleCreated, internal.Vehicle{Id: 7}))
+	eventually(t, "the dead letter", func() bool {
+		dl, _ := rp.FindDeadLetters(w.ID)
+		return len(dl) == 1
+	})
+
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+	dl, err := rp.DeleteDeadLetter(w.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Redeliver(w, dl); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the redelivery", func() bool { return len(rc.received()) == 2 })
+	r := rc.received()[1]
+	if string(r.body) != string(dl.Payload) || r.header.Get(HeaderAttempt) != "1" {
+		t.Errorf("got redelivery of %s as attempt %s, want the payload of the dead letter as attempt 1", r.body, r.header.Get(HeaderAttempt))
+	}
+}
+
+func TestDispatcher_wait(t *testing.T) {
+	d := NewDispatcher(nil, &Config{Backoff: time.Second, MaxBackoff: 10 * time.Second})
+	tests := []struct {
+		attempt int
+		full    time.Duration
+	}{
+		{attempt: 1, full: time.Second},
+		{attempt: 2, full: 2 * time.Second},
+		{attempt: 3, full: 4 * time.Second},
+		{attempt: 4, full: 8 * time.Second},
+		{attempt: 5, full: 10 * time.Second},
+		{attempt: 50, full: 10 * time.Second},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			if got := d.wait(tt.attempt); got < tt.full/2 || got > tt.full {
+				t.Fatalf("got wait %s after attempt %d, want between %s and %s", got, tt.attempt, tt.full/2, tt.full)
+			}
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	at := time.Unix(1700000000, 0)
+	header := Sign(testSecret, at, body)
+
+	tests := []struct {
+		name    string
+		secret  string
+		header  string
+		body    []byte
+		now     time.Time
+		wantErr bool
+	}{
+		{name: "valid", secret: testSecret, header: header, body: body, now: at.Add(time.Minute)},
+		{name: "rotated secrets", secret: testSecret, header: header + ",v1=" + strings.Repeat("0", 64), body: body, now: at},
+		{name: "wrong secret", secret: "another secret of the same size", header: header, body: body, now: at, wantErr: true},
+		{name: "tampered body", secret: testSecret, header: header, body: []byte(`{"id":"2"}`), now: at, wantErr: true},
+		{name: "too old", secret: testSecret, header: header, body: body, now: at.Add(6 * time.Minute), wantErr: true},
+		{name: "from the future", secret: testSecret, header: header, body: body, now: at.Add(-6 * time.Minute), wantErr: true},
+		{name: "no time", secret: testSecret, header: header[strings.Index(header, ",")+1:], body: body, now: at, wantErr: true},
+		{name: "no signature", secret: testSecret, header: "t=1700000000", body: body, now: at, wantErr: true},
+		{name: "empty", secret: testSecret, body: body, now: at, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.header, tt.body, tt.now, 5*time.Minute)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
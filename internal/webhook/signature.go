@@ -0,0 +1,76 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// HeaderSignature is the header of the signature of a delivery
+	HeaderSignature = "X-Webhook-Signature"
+	// HeaderEventID is the header of the id of the event, the same across the attempts of a delivery
+	HeaderEventID = "X-Webhook-Event-Id"
+	// HeaderEventType is the header of the type of the event
+	HeaderEventType = "X-Webhook-Event-Type"
+	// HeaderAttempt is the header of the number of the attempt, from 1
+	HeaderAttempt = "X-Webhook-Attempt"
+)
+
+// ErrSignatureInvalid is an error that represents a delivery whose signature does not match, or is too old
+var ErrSignatureInvalid = errors.New("webhook signature invalid")
+
+// Sign is a function that returns the signature header of a body sent at t: t=<unix seconds>,v1=<hex HMAC-SHA256>.
+// The MAC covers the time, a dot and the body, so a receiver can reject replays of old deliveries
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + mac(secret, ts, body)
+}
+
+// Verify is a function that checks the signature header of a body received at now. It fails with ErrSignatureInvalid
+// unless a v1 signature matches and, when tolerance is positive, the time is within tolerance of now
+func Verify(secret, header string, body []byte, now time.Time, tolerance time.Duration) (err error) {
+	var ts string
+	var sigs []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			sigs = append(sigs, value)
+		}
+	}
+	unix, pErr := strconv.ParseInt(ts, 10, 64)
+	if pErr != nil || len(sigs) == 0 {
+		err = ErrSignatureInvalid
+		return
+	}
+	if tolerance > 0 {
+		if d := now.Sub(time.Unix(unix, 0)); d > tolerance || d < -tolerance {
+			err = ErrSignatureInvalid
+			return
+		}
+	}
+	want := mac(secret, ts, body)
+	for _, sig := range sigs {
+		if hmac.Equal([]byte(sig), []byte(want)) {
+			return
+		}
+	}
+	err = ErrSignatureInvalid
+	return
+}
+
+// mac is a function that returns the HMAC-SHA256 in hex of a time and a body
+func mac(secret, ts string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(ts))
+	h.Write([]byte("."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,126 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+// ErrTargetForbidden is an error that represents a delivery to an address webhooks may not reach
+var ErrTargetForbidden = errors.New("webhook target forbidden")
+
+// sharedAddressSpace is the range of carrier-grade NAT addresses, internal to a provider network
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// NewTargetPolicy is a function that returns a new instance of TargetPolicy, allowing the given hosts whatever their address
+func NewTargetPolicy(allowedHosts []string) *TargetPolicy {
+	p := &TargetPolicy{allowed: make(map[string]bool, len(allowedHosts))}
+	for _, value := range allowedHosts {
+		if value = strings.ToLower(strings.TrimSpace(value)); value != "" {
+			p.allowed[value] = true
+		}
+	}
+	return p
+}
+
+// TargetPolicy is a struct that restricts the hosts events are delivered to: addresses of the server itself or of its
+// private network, such as loopback, private, link-local or unspecified ones, are refused unless their host is allowed.
+// URLs are checked on registration, and the address of every connection is checked again when it is dialed, so a host
+// resolving to a public address on registration and to an internal one later is refused too
+type TargetPolicy struct {
+	// allowed is the set of the hosts, in lower case, allowed whatever their address
+	allowed map[string]bool
+}
+
+// Check is a method that returns an error wrapping ErrWebhookInvalid when events may not be delivered to a URL
+func (p *TargetPolicy) Check(ctx context.Context, rawURL string) (err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		err = fmt.Errorf("%w: url must be an absolute http or https url", internal.ErrWebhookInvalid)
+		return
+	}
+	host := strings.ToLower(u.Hostname())
+	if p.allowed[host] {
+		return
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		err = fmt.Errorf("%w: host %q is not reachable by webhooks", internal.ErrWebhookInvalid, host)
+		return
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		err = fmt.Errorf("%w: host %q does not resolve", internal.ErrWebhookInvalid, host)
+		return
+	}
+	for _, value := range addrs {
+		if !Public(value) && value.Unmap().String() == host {
+			err = fmt.Errorf("%w: address %s is not reachable by webhooks", internal.ErrWebhookInvalid, host)
+			return
+		}
+		if !Public(value) {
+			err = fmt.Errorf("%w: host %q resolves to %s, which is not reachable by webhooks", internal.ErrWebhookInvalid, host, value.Unmap())
+			return
+		}
+	}
+	return
+}
+
+// Client is a method that returns a client that only connects to the addresses the policy allows.
+// Proxies are not used, as the address of a connection through one could not be checked
+func (p *TargetPolicy) Client(timeout time.Duration) *http.Client {
+	guarded := &net.Dialer{Timeout: 10 * time.Second, Control: p.control}
+	open := &net.Dialer{Timeout: 10 * time.Second}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy: nil,
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				host, _, err := net.SplitHostPort(addr)
+				if err == nil && p.allowed[strings.ToLower(host)] {
+					return open.DialContext(ctx, network, addr)
+				}
+				return guarded.DialContext(ctx, network, addr)
+			},
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+	}
+}
+
+// control is a method that refuses a connection to an address that is not public, once its host is resolved
+func (p *TargetPolicy) control(network, address string, _ syscall.RawConn) (err error) {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return
+	}
+	if !Public(ap.Addr()) {
+		err = fmt.Errorf("%w: %s", ErrTargetForbidden, ap.Addr().Unmap())
+	}
+	return
+}
+
+// Public is a function that reports whether an address is reachable on the internet, rather than being an address of the
+// host itself or of a private network
+func Public(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	switch {
+	case !addr.IsValid(), addr.IsUnspecified(), addr.IsLoopback(), addr.IsPrivate(),
+		addr.IsLinkLocalUnicast(), addr.IsLinkLocalMulticast(), addr.IsInterfaceLocalMulticast(), addr.IsMulticast():
+		return false
+	case addr.Is4() && (addr.As4()[0] == 0 || sharedAddressSpace.Contains(addr)):
+		return false
+	}
+	return true
+}
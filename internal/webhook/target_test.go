@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/rhinosc/code-review-1/internal"
+)
+
+func TestPublic(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "93.184.216.34", want: true},
+		{addr: "2606:2800:220:1:248:1893:25c8:1946", want: true},
+		{addr: "127.0.0.1", want: false},
+		{addr: "::1", want: false},
+		{addr: "10.1.2.3", want: false},
+		{addr: "172.16.0.1", want: false},
+		{addr: "192.168.1.1", want: false},
+		{addr: "169.254.169.254", want: false},
+		{addr: "fe80::1", want: false},
+		{addr: "fc00::1", want: false},
+		{addr: "0.0.0.0", want: false},
+		{addr: "0.1.2.3", want: false},
+		{addr: "::", want: false},
+		{addr: "100.64.0.1", want: false},
+		{addr: "224.0.0.1", want: false},
+		{addr: "::ffff:127.0.0.1", want: false},
+		{addr: "::ffff:10.0.0.1", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := Public(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTargetPolicy_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		url     string
+		wantErr bool
+	}{
+		{name: "public address", url: "https://93.184.216.34/hook"},
+		{name: "loopback", url: "http://127.0.0.1:8080/hook", wantErr: true},
+		{name: "loopback v6", url: "http://[::1]/hook", wantErr: true},
+		{name: "private", url: "http://192.168.0.10/hook", wantErr: true},
+		{name: "metadata service", url: "http://169.254.169.254/latest/meta-data", wantErr: true},
+		{name: "localhost", url: "http://localhost:8080/hook", wantErr: true},
+		{name: "subdomain of localhost", url: "http://api.localhost/hook", wantErr: true},
+		{name: "unresolvable host", url: "http://host.invalid/hook", wantErr: true},
+		{name: "allowed private host", allowed: []string{"192.168.0.10"}, url: "http://192.168.0.10/hook"},
+		{name: "allowed host in other case", allowed: []string{"LocalHost"}, url: "http://localhost:8080/hook"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewTargetPolicy(tt.allowed).Check(context.Background(), tt.url)
+			if tt.wantErr {
+				if !errors.Is(err, internal.ErrWebhookInvalid) {
+					t.Fatalf("got error %v, want ErrWebhookInvalid", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("got error %v", err)
+			}
+		})
+	}
+}
+
+func TestTargetPolicy_Client(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("refuses a loopback address", func(t *testing.T) {
+		_, err := NewTargetPolicy(nil).Client(time.Second).Get(srv.URL)
+		if !errors.Is(err, ErrTargetForbidden) {
+			t.Fatalf("got error %v, want ErrTargetForbidden", err)
+		}
+	})
+
+	t.Run("refuses a redirect to a loopback address", func(t *testing.T) {
+		// - the redirecting server is allowed, the server it redirects to is not
+		redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "http://localhost:"+u.Port()+"/", http.StatusFound)
+		}))
+		defer redirect.Close()
+		_, err := NewTargetPolicy([]string{"127.0.0.1"}).Client(time.Second).Get(redirect.URL)
+		if !errors.Is(err, ErrTargetForbidden) {
+			t.Fatalf("got error %v, want ErrTargetForbidden", err)
+		}
+	})
+
+	t.Run("connects to an allowed host", func(t *testing.T) {
+		res, err := NewTargetPolicy([]string{u.Hostname()}).Client(time.Second).Get(srv.URL)
+		if err != nil {
+			t.Fatalf("got error %v", err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusNoContent {
+			t.Fatalf("got status %d, want %d", res.StatusCode, http.StatusNoContent)
+		}
+	})
+}
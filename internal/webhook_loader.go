@@ -0,0 +1,10 @@
+package internal
+
+// WebhookLoader is an interface that represents the loader for webhooks, their delivery logs and dead letters
+type WebhookLoader interface {
+	// Load is a method that loads the webhooks, delivery logs and dead letters
+	Load() (d WebhookData, err error)
+
+	// Save is a method that saves the webhooks, delivery logs and dead letters
+	Save(d WebhookData) (err error)
+}
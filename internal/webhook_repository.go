@@ -0,0 +1,31 @@
+package internal
+
+// WebhookRepository is an interface that represents a repository of webhooks, their delivery logs and dead letters
+type WebhookRepository interface {
+	// FindAll is a method that returns every webhook, ordered by id
+	FindAll() (w []Webhook, err error)
+
+	// FindByID is a method that returns a webhook by id
+	FindByID(id int) (w Webhook, err error)
+
+	// Create is a method that creates a webhook
+	Create(w *Webhook) (err error)
+
+	// Delete is a method that deletes a webhook with its delivery log and dead letters
+	Delete(id int) (err error)
+
+	// AddDelivery is a method that logs an attempt, only the latest attempts of each webhook are kept
+	AddDelivery(d *WebhookDelivery) (err error)
+
+	// FindDeliveries is a method that returns the logged attempts of a webhook, newest first
+	FindDeliveries(webhookID int) (d []WebhookDelivery, err error)
+
+	// AddDeadLetter is a method that creates a dead letter
+	AddDeadLetter(dl *WebhookDeadLetter) (err error)
+
+	// FindDeadLetters is a method that returns the dead letters of a webhook, oldest first
+	FindDeadLetters(webhookID int) (dl []WebhookDeadLetter, err error)
+
+	// DeleteDeadLetter is a method that deletes a dead letter of a webhook and returns it
+	DeleteDeadLetter(webhookID, id int) (dl WebhookDeadLetter, err error)
+}
@@ -0,0 +1,34 @@
+package internal
+
+// WebhookDispatcher is an interface that represents the delivery of events to webhooks
+type WebhookDispatcher interface {
+	// CheckTarget is a method that returns an error wrapping ErrWebhookInvalid when events may not be delivered to a URL
+	CheckTarget(url string) (err error)
+
+	// Redeliver is a method that queues a dead letter for delivery again, with every attempt
+	Redeliver(w Webhook, dl WebhookDeadLetter) (err error)
+}
+
+// WebhookService is an interface that represents a service of webhooks
+type WebhookService interface {
+	// FindAll is a method that returns every webhook, ordered by id
+	FindAll() (w []Webhook, err error)
+
+	// FindByID is a method that returns a webhook by id
+	FindByID(id int) (w Webhook, err error)
+
+	// Create is a method that registers a webhook, a secret is generated when none is given
+	Create(w *Webhook) (err error)
+
+	// Delete is a method that deletes a webhook, its pending deliveries are dropped
+	Delete(id int) (err error)
+
+	// FindDeliveries is a method that returns the logged attempts of a webhook, newest first
+	FindDeliveries(id int) (d []WebhookDelivery, err error)
+
+	// FindDeadLetters is a method that returns the dead letters of a webhook, oldest first
+	FindDeadLetters(id int) (dl []WebhookDeadLetter, err error)
+
+	// Redeliver is a method that removes a dead letter of a webhook and queues it for delivery again
+	Redeliver(id, deadLetterID int) (err error)
+}